@@ -0,0 +1,180 @@
+package audit
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 常用動作名稱
+const (
+	ActionNavigate = "navigate"
+	ActionClick    = "click"
+	ActionType     = "type"
+	ActionScript   = "script"
+	ActionDownload = "download"
+	ActionBlocked  = "blocked"
+	ActionHandoff  = "handoff"
+)
+
+// Entry 單筆操作紀錄
+// Hash 由前一筆的 Hash 與本筆內容計算，任何修改、刪除或重排都會讓 Verify 失敗
+type Entry struct {
+	Seq      int       `json:"seq"`
+	Time     time.Time `json:"time"`
+	Job      string    `json:"job"`
+	Action   string    `json:"action"`
+	Target   string    `json:"target,omitempty"`
+	Value    string    `json:"value,omitempty"`
+	Detail   string    `json:"detail,omitempty"`
+	PrevHash string    `json:"prev_hash"`
+	Hash     string    `json:"hash"`
+}
+
+// Log 單次爬取工作的操作紀錄，可安全地被多個 goroutine 共用
+type Log struct {
+	mu      sync.Mutex
+	job     string
+	key     []byte
+	entries []Entry
+}
+
+// New 建立工作 ID 為 job 的紀錄
+func New(job string) *Log {
+	return &Log{job: job}
+}
+
+// NewKeyed 同 New，但以 HMAC-SHA256 串接雜湊；沒有金鑰者無法重新計算出合法的鏈
+func NewKeyed(job string, key []byte) *Log {
+	return &Log{job: job, key: key}
+}
+
+// Job 回傳工作 ID
+func (l *Log) Job() string {
+	return l.job
+}
+
+// Record 記錄一筆操作
+func (l *Log) Record(action, target, detail string) {
+	l.append(action, target, "", detail)
+}
+
+// RecordSecret 記錄含有敏感值的操作（例如輸入的欄位），值只保存 SHA-256 雜湊
+func (l *Log) RecordSecret(action, target, value string) {
+	l.append(action, target, HashValue(value), "")
+}
+
+// Entries 回傳目前所有紀錄的副本
+func (l *Log) Entries() []Entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make([]Entry, len(l.entries))
+	copy(out, l.entries)
+	return out
+}
+
+// Verify 重新計算整條雜湊鏈，確認紀錄未被竄改
+func (l *Log) Verify() error {
+	return Verify(l.Entries(), l.key)
+}
+
+// WriteJSON 以 JSON Lines 格式輸出，每行一筆
+func (l *Log) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	for _, e := range l.Entries() {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteFile 將紀錄寫入檔案
+func (l *Log) WriteFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("無法建立稽核紀錄檔 %s: %w", path, err)
+	}
+	defer f.Close()
+	if err := l.WriteJSON(f); err != nil {
+		return fmt.Errorf("寫入稽核紀錄失敗: %w", err)
+	}
+	return f.Close()
+}
+
+// Verify 驗證一組紀錄的雜湊鏈；key 需與建立時相同（未使用金鑰則傳 nil）
+func Verify(entries []Entry, key []byte) error {
+	prev := ""
+	for i, e := range entries {
+		if e.Seq != i+1 {
+			return fmt.Errorf("稽核紀錄序號不連續：第 %d 筆的序號為 %d", i+1, e.Seq)
+		}
+		if e.PrevHash != prev {
+			return fmt.Errorf("稽核紀錄第 %d 筆的前一雜湊不符", e.Seq)
+		}
+		if sum := computeHash(e, key); sum != e.Hash {
+			return fmt.Errorf("稽核紀錄第 %d 筆內容已被修改", e.Seq)
+		}
+		prev = e.Hash
+	}
+	return nil
+}
+
+// HashValue 計算敏感值的 SHA-256 雜湊
+func HashValue(v string) string {
+	sum := sha256.Sum256([]byte(v))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// ----------------- 內部輔助 -----------------
+
+func (l *Log) append(action, target, value, detail string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e := Entry{
+		Seq:    len(l.entries) + 1,
+		Time:   time.Now().UTC(),
+		Job:    l.job,
+		Action: action,
+		Target: target,
+		Value:  value,
+		Detail: detail,
+	}
+	if n := len(l.entries); n > 0 {
+		e.PrevHash = l.entries[n-1].Hash
+	}
+	e.Hash = computeHash(e, l.key)
+	l.entries = append(l.entries, e)
+}
+
+func computeHash(e Entry, key []byte) string {
+	var h hash.Hash
+	if len(key) > 0 {
+		h = hmac.New(sha256.New, key)
+	} else {
+		h = sha256.New()
+	}
+	// 以長度前綴串接欄位，避免不同欄位組合產生相同輸入
+	for _, f := range []string{
+		e.PrevHash,
+		strconv.Itoa(e.Seq),
+		e.Time.Format(time.RFC3339Nano),
+		e.Job,
+		e.Action,
+		e.Target,
+		e.Value,
+		e.Detail,
+	} {
+		fmt.Fprintf(h, "%d:%s", len(f), f)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
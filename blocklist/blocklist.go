@@ -0,0 +1,449 @@
+// Package blocklist 解析 EasyList 格式的網路過濾規則子集，用於在請求攔截階段丟棄已知的追蹤器與廣告，
+// 同時加快大量爬取並減少被追蹤腳本識別的機會。
+//
+// 支援的語法：
+//
+//	! 註解                         [Adblock Plus 2.0] 標頭
+//	||tracker.example^             網域錨點（含子網域），^ 為分隔字元
+//	|https://ads.example/          開頭錨點；結尾的 | 為結尾錨點
+//	/banner/*/ad.js                子字串比對，* 為萬用字元
+//	@@||cdn.example^$script        例外規則，優先於阻擋規則
+//	$third-party、$~third-party    只比對第三方（或第一方）請求
+//	$script,image,~font            限定（或排除）資源類型
+//	$domain=a.example|~b.example   限定（或排除）發出請求的頁面網域
+//	$match-case                    區分大小寫
+//
+// 元素隱藏規則（##、#@#）、正規表示式規則與其他選項（redirect、csp 等）不支援，會被略過並計入 Skipped
+package blocklist
+
+import (
+	"bufio"
+	"context"
+	_ "embed"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+//go:embed default.txt
+var defaultRules string
+
+// List 編譯後的過濾規則，可同時供多個分頁使用
+type List struct {
+	// Rules 成功解析的規則數（含例外規則）
+	Rules int
+	// Skipped 不支援而略過的規則數
+	Skipped int
+
+	// 純網域規則（||host^）依 host 建立索引，其餘逐條比對
+	block, allow         []*rule
+	blockHost, allowHost map[string][]*rule
+}
+
+// Default 回傳內建的追蹤器與廣告清單
+func Default() *List {
+	l, _ := Parse(strings.NewReader(defaultRules))
+	return l
+}
+
+// Parse 從 r 讀取規則
+func Parse(r io.Reader) (*List, error) {
+	l := &List{blockHost: map[string][]*rule{}, allowHost: map[string][]*rule{}}
+	if err := l.add(r); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Load 讀取並合併多個規則檔
+func Load(paths ...string) (*List, error) {
+	l := &List{blockHost: map[string][]*rule{}, allowHost: map[string][]*rule{}}
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("無法讀取阻擋清單 %s: %w", path, err)
+		}
+		err = l.add(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("無法解析阻擋清單 %s: %w", path, err)
+		}
+	}
+	return l, nil
+}
+
+// Fetch 下載並解析遠端清單
+func Fetch(ctx context.Context, rawURL string) (*List, error) {
+	body, err := download(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(strings.NewReader(body))
+}
+
+// Update 下載遠端清單並以原子方式寫入 path，供之後以 Load 載入；
+// 下載或解析失敗時保留原檔案
+func Update(ctx context.Context, rawURL, path string) (*List, error) {
+	body, err := download(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	l, err := Parse(strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if l.Rules == 0 {
+		return nil, fmt.Errorf("阻擋清單 %s 沒有可用的規則", rawURL)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".blocklist-*")
+	if err != nil {
+		return nil, fmt.Errorf("無法寫入阻擋清單: %w", err)
+	}
+	if _, err := tmp.WriteString(body); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, fmt.Errorf("無法寫入阻擋清單: %w", err)
+	}
+	tmp.Close()
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return nil, fmt.Errorf("無法寫入阻擋清單: %w", err)
+	}
+	return l, nil
+}
+
+// Match 判斷 pageURL 頁面發出的 reqURL 請求是否應阻擋，並回傳命中的規則原文。
+// resourceType 為 CDP 的資源類型（"Script"、"Image"、"XHR" 等），留空時只比對不限類型的規則
+func (l *List) Match(reqURL, pageURL, resourceType string) (string, bool) {
+	if l == nil {
+		return "", false
+	}
+	u, err := url.Parse(reqURL)
+	if err != nil || u.Host == "" {
+		return "", false
+	}
+	req := &request{
+		url:     reqURL,
+		host:    normalizeHost(u.Hostname()),
+		typ:     abpType(resourceType),
+		page:    pageHost(pageURL),
+		pageSet: pageURL != "",
+	}
+	req.thirdParty = req.pageSet && site(req.host) != site(req.page)
+
+	r := l.find(req, l.blockHost, l.block)
+	if r == nil || l.find(req, l.allowHost, l.allow) != nil {
+		return "", false
+	}
+	return r.text, true
+}
+
+// ----------------- 內部實作 -----------------
+
+// rule 一條編譯後的規則
+type rule struct {
+	text string
+	// re 為 nil 時是純網域規則，只需比對 host
+	re *regexp.Regexp
+	// party 1 只比對第三方、-1 只比對第一方、0 不限
+	party int
+	// types 限定的資源類型；notTypes 排除的資源類型
+	types, notTypes map[string]bool
+	// domains 限定的頁面網域；notDomains 排除的頁面網域
+	domains, notDomains []string
+}
+
+// request 一次比對所需的請求資訊
+type request struct {
+	url, host, page, typ string
+	pageSet, thirdParty  bool
+}
+
+// hostOnly 可放入 host 索引的純網域規則
+var hostOnly = regexp.MustCompile(`^\|\|([a-z0-9.-]+)\^$`)
+
+func (l *List) add(r io.Reader) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "!") || strings.HasPrefix(line, "[") {
+			continue
+		}
+		if err := l.addRule(line); err != nil {
+			l.Skipped++
+			continue
+		}
+		l.Rules++
+	}
+	return sc.Err()
+}
+
+func (l *List) addRule(line string) error {
+	if strings.Contains(line, "##") || strings.Contains(line, "#@#") || strings.Contains(line, "#?#") || strings.Contains(line, "#$#") {
+		return fmt.Errorf("不支援元素隱藏規則")
+	}
+	r := &rule{text: line}
+	exception := strings.HasPrefix(line, "@@")
+	pattern := strings.TrimPrefix(line, "@@")
+
+	matchCase := false
+	if i := strings.LastIndex(pattern, "$"); i >= 0 {
+		var err error
+		if matchCase, err = r.parseOptions(pattern[i+1:]); err != nil {
+			return err
+		}
+		pattern = pattern[:i]
+	}
+	if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		return fmt.Errorf("不支援正規表示式規則")
+	}
+	if pattern == "" || pattern == "*" || pattern == "||" || pattern == "|" {
+		if len(r.domains) == 0 && r.types == nil {
+			return fmt.Errorf("規則過於寬鬆")
+		}
+	}
+
+	hosts, list := l.blockHost, &l.block
+	if exception {
+		hosts, list = l.allowHost, &l.allow
+	}
+	if m := hostOnly.FindStringSubmatch(strings.ToLower(pattern)); m != nil {
+		host := strings.Trim(m[1], ".")
+		hosts[host] = append(hosts[host], r)
+		return nil
+	}
+	re, err := compile(pattern, matchCase)
+	if err != nil {
+		return err
+	}
+	r.re = re
+	*list = append(*list, r)
+	return nil
+}
+
+// parseOptions 解析 $ 之後的選項，回傳是否區分大小寫
+func (r *rule) parseOptions(opts string) (bool, error) {
+	matchCase := false
+	for _, opt := range strings.Split(opts, ",") {
+		opt = strings.ToLower(strings.TrimSpace(opt))
+		neg := strings.HasPrefix(opt, "~")
+		name := strings.TrimPrefix(opt, "~")
+		switch {
+		case name == "third-party" || name == "3p":
+			r.party = 1
+			if neg {
+				r.party = -1
+			}
+		case name == "first-party" || name == "1p":
+			r.party = -1
+			if neg {
+				r.party = 1
+			}
+		case name == "match-case":
+			matchCase = true
+		case strings.HasPrefix(opt, "domain="):
+			for _, d := range strings.Split(strings.TrimPrefix(opt, "domain="), "|") {
+				if strings.HasPrefix(d, "~") {
+					r.notDomains = append(r.notDomains, strings.TrimPrefix(d, "~"))
+				} else if d != "" {
+					r.domains = append(r.domains, d)
+				}
+			}
+		case abpTypes[name]:
+			if neg {
+				if r.notTypes == nil {
+					r.notTypes = map[string]bool{}
+				}
+				r.notTypes[name] = true
+			} else {
+				if r.types == nil {
+					r.types = map[string]bool{}
+				}
+				r.types[name] = true
+			}
+		default:
+			return false, fmt.Errorf("不支援的選項 %q", opt)
+		}
+	}
+	return matchCase, nil
+}
+
+// compile 將規則樣式轉為正規表示式
+func compile(pattern string, matchCase bool) (*regexp.Regexp, error) {
+	var b strings.Builder
+	if !matchCase {
+		b.WriteString("(?i)")
+	}
+	switch {
+	case strings.HasPrefix(pattern, "||"):
+		// 網域錨點：從 host 的某個標籤開頭比對
+		b.WriteString(`^[a-z][a-z0-9+.-]*://(?:[^/?#]*\.)?`)
+		pattern = pattern[2:]
+	case strings.HasPrefix(pattern, "|"):
+		b.WriteString("^")
+		pattern = pattern[1:]
+	}
+	end := false
+	if strings.HasSuffix(pattern, "|") {
+		end = true
+		pattern = pattern[:len(pattern)-1]
+	}
+	for _, c := range pattern {
+		switch c {
+		case '*':
+			b.WriteString(".*")
+		case '^':
+			// 分隔字元：字母、數字與 _-.% 以外的字元，或網址結尾
+			b.WriteString(`(?:[^\w.%-]|$)`)
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	if end {
+		b.WriteString("$")
+	}
+	return regexp.Compile(b.String())
+}
+
+// find 回傳第一條符合請求的規則
+func (l *List) find(req *request, hosts map[string][]*rule, list []*rule) *rule {
+	for h := req.host; h != ""; {
+		for _, r := range hosts[h] {
+			if r.applies(req) {
+				return r
+			}
+		}
+		i := strings.IndexByte(h, '.')
+		if i < 0 {
+			break
+		}
+		h = h[i+1:]
+	}
+	for _, r := range list {
+		if r.applies(req) && r.re.MatchString(req.url) {
+			return r
+		}
+	}
+	return nil
+}
+
+// applies 檢查規則的選項是否適用於此請求
+func (r *rule) applies(req *request) bool {
+	if r.party != 0 {
+		if !req.pageSet || (r.party == 1) != req.thirdParty {
+			return false
+		}
+	}
+	if r.types != nil && !r.types[req.typ] {
+		return false
+	}
+	if r.notTypes[req.typ] {
+		return false
+	}
+	if len(r.domains) > 0 && !matchDomain(req.page, r.domains) {
+		return false
+	}
+	if len(r.notDomains) > 0 && matchDomain(req.page, r.notDomains) {
+		return false
+	}
+	return true
+}
+
+// matchDomain 判斷 host 是否為 domains 之一或其子網域
+func matchDomain(host string, domains []string) bool {
+	for _, d := range domains {
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+// abpTypes 支援的資源類型選項
+var abpTypes = map[string]bool{
+	"script": true, "image": true, "stylesheet": true, "font": true, "media": true,
+	"xmlhttprequest": true, "subdocument": true, "websocket": true, "ping": true, "other": true,
+}
+
+// abpType 將 CDP 資源類型對應為規則的類型名稱
+func abpType(cdpType string) string {
+	switch strings.ToLower(cdpType) {
+	case "":
+		return ""
+	case "script":
+		return "script"
+	case "image":
+		return "image"
+	case "stylesheet":
+		return "stylesheet"
+	case "font":
+		return "font"
+	case "media":
+		return "media"
+	case "xhr", "fetch", "eventsource":
+		return "xmlhttprequest"
+	case "document":
+		// 主框架導航不應經過清單比對，出現時皆視為 iframe
+		return "subdocument"
+	case "websocket":
+		return "websocket"
+	case "ping", "cspviolationreport":
+		return "ping"
+	default:
+		return "other"
+	}
+}
+
+func pageHost(pageURL string) string {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return ""
+	}
+	return normalizeHost(u.Hostname())
+}
+
+// normalizeHost 轉為小寫並去掉完整網域名稱結尾的點，讓 example.com. 與 example.com 比對結果相同
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+// site 回傳 host 的可註冊網域（eTLD+1），用於判斷第三方請求；IP 位址與無法判斷的 host 回傳 host 本身
+func site(host string) string {
+	if net.ParseIP(host) != nil {
+		return host
+	}
+	if s, err := publicsuffix.EffectiveTLDPlusOne(host); err == nil {
+		return s
+	}
+	return host
+}
+
+func download(ctx context.Context, rawURL string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("無法下載阻擋清單 %s: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("無法下載阻擋清單 %s: HTTP %d", rawURL, resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 32<<20))
+	if err != nil {
+		return "", fmt.Errorf("無法下載阻擋清單 %s: %w", rawURL, err)
+	}
+	return string(body), nil
+}
@@ -0,0 +1,295 @@
+package blocklist
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testRules = `[Adblock Plus 2.0]
+! 註解
+||tracker.example^
+||ads.example^$third-party
+||first.example^$~third-party
+||cdn.example^$script,image
+||fonts.example^$~font
+||widget.example^$domain=shop.test|~checkout.shop.test
+|https://exact.example/ad.js|
+/banner/*/ad.
+&adtype=^
+||path.example/ads/
+/pixel.gif$third-party
+||case.example/Promo$match-case
+$script,domain=evil.test
+@@||tracker.example/allowed/
+@@||cdn.example^$image
+@@||ads.example^$domain=partner.test
+`
+
+func TestParseCountsRules(t *testing.T) {
+	l, err := Parse(strings.NewReader(testRules + `
+example.com##.ad
+example.com#@#.ad
+example.com#?#div:-abp-has(.ad)
+/^https?:\/\/ads\./
+||redirect.example^$redirect=noopjs
+||trailing.example^$script,
+$third-party
+@@*
+|
+||
+
+  ! 前後有空白的註解
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Rules != 16 || l.Skipped != 10 {
+		t.Errorf("Rules = %d、Skipped = %d，應為 16 與 10", l.Rules, l.Skipped)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	l, err := Parse(strings.NewReader(testRules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		req, page, typ string
+		want           string // 命中的規則，空字串表示不阻擋
+	}{
+		// 網域錨點含子網域，但不含名稱相似的其他網域
+		{"https://tracker.example/t.js", "https://site.test/", "Script", "||tracker.example^"},
+		{"https://a.b.tracker.example/p?x=1", "", "", "||tracker.example^"},
+		{"https://TRACKER.example:8443/", "", "", "||tracker.example^"},
+		{"https://tracker.example./t.js", "", "", "||tracker.example^"},
+		{"https://nottracker.example/", "", "", ""},
+		{"https://tracker.example.evil.test/", "", "", ""},
+		{"https://evil.test/?u=https://tracker.example/", "", "", ""},
+		{"https://user@evil.test/tracker.example/", "", "", ""},
+
+		// 例外規則優先
+		{"https://tracker.example/allowed/x.js", "", "", ""},
+		{"https://x.tracker.example/allowed/x.js", "", "", ""},
+
+		// 第三方與第一方
+		{"https://ads.example/a.js", "https://site.test/", "Script", "||ads.example^$third-party"},
+		{"https://ads.example/a.js", "https://www.ads.example/", "Script", ""},
+		{"https://ads.example/a.js", "", "Script", ""},
+		{"https://first.example/x", "https://first.example/", "", "||first.example^$~third-party"},
+		{"https://first.example/x", "https://site.test/", "", ""},
+		{"http://192.168.0.1/pixel.gif", "http://10.0.0.1/", "Image", "/pixel.gif$third-party"},
+		{"http://10.0.0.1:8080/pixel.gif", "http://10.0.0.1/", "Image", ""},
+		{"http://[::1]/pixel.gif", "http://localhost/", "Image", "/pixel.gif$third-party"},
+		{"https://cdn.shop.co.uk/pixel.gif", "https://www.shop.co.uk/", "Image", ""},
+		{"https://cdn.other.co.uk/pixel.gif", "https://www.shop.co.uk/", "Image", "/pixel.gif$third-party"},
+
+		// 例外規則的頁面網域
+		{"https://ads.example/a.js", "https://partner.test/", "Script", ""},
+		{"https://ads.example/a.js", "https://www.partner.test/", "Script", ""},
+
+		// 資源類型
+		{"https://cdn.example/lib.js", "", "Script", "||cdn.example^$script,image"},
+		{"https://cdn.example/logo.png", "", "Image", ""},
+		{"https://cdn.example/style.css", "", "Stylesheet", ""},
+		{"https://cdn.example/lib.js", "", "", ""},
+		{"https://fonts.example/a.css", "", "Stylesheet", "||fonts.example^$~font"},
+		{"https://fonts.example/a.woff2", "", "Font", ""},
+
+		// 限定頁面網域
+		{"https://widget.example/w.js", "https://shop.test/", "", "||widget.example^$domain=shop.test|~checkout.shop.test"},
+		{"https://widget.example/w.js", "https://m.shop.test/", "", "||widget.example^$domain=shop.test|~checkout.shop.test"},
+		{"https://widget.example/w.js", "https://checkout.shop.test/", "", ""},
+		{"https://widget.example/w.js", "https://site.test/", "", ""},
+		{"https://widget.example/w.js", "", "", ""},
+		{"https://any.test/app.js", "https://evil.test/", "Script", "$script,domain=evil.test"},
+		{"https://any.test/app.js", "https://site.test/", "Script", ""},
+
+		// 開頭與結尾錨點
+		{"https://exact.example/ad.js", "", "", "|https://exact.example/ad.js|"},
+		{"https://exact.example/ad.js?v=1", "", "", ""},
+		{"http://exact.example/ad.js", "", "", ""},
+
+		// 萬用字元、分隔字元與子字串
+		{"https://site.test/img/banner/300x250/ad.png", "", "", "/banner/*/ad."},
+		{"https://site.test/banner/ad.png", "", "", ""},
+		{"https://site.test/x?a=1&adtype=&b=2", "", "", "&adtype=^"},
+		{"https://site.test/x?a=1&adtype=", "", "", "&adtype=^"},
+		{"https://site.test/x?&adtype=banner", "", "", ""},
+		{"https://path.example/ads/x.gif", "", "", "||path.example/ads/"},
+		{"https://www.path.example/ads/x.gif", "", "", "||path.example/ads/"},
+		{"https://path.example/news/ads/", "", "", ""},
+
+		// 預設不分大小寫；match-case 只比對相同大小寫
+		{"https://site.test/BANNER/1/AD.gif", "", "", "/banner/*/ad."},
+		{"https://case.example/Promo", "", "", "||case.example/Promo$match-case"},
+		{"https://case.example/promo", "", "", ""},
+
+		// 無法解析或沒有 host 的網址不阻擋
+		{"", "", "", ""},
+		{"%zz", "", "", ""},
+		{"data:text/javascript,tracker.example", "", "", ""},
+		{"/relative/banner/1/ad.png", "", "", ""},
+	} {
+		got, ok := l.Match(tc.req, tc.page, tc.typ)
+		if got != tc.want || ok != (tc.want != "") {
+			t.Errorf("Match(%q, %q, %q) = %q, %v，應為 %q", tc.req, tc.page, tc.typ, got, ok, tc.want)
+		}
+	}
+
+	var nilList *List
+	if _, ok := nilList.Match("https://tracker.example/", "", ""); ok {
+		t.Error("nil List 不應阻擋")
+	}
+}
+
+func TestAbpType(t *testing.T) {
+	for cdp, want := range map[string]string{
+		"":            "",
+		"Script":      "script",
+		"XHR":         "xmlhttprequest",
+		"Fetch":       "xmlhttprequest",
+		"EventSource": "xmlhttprequest",
+		"Document":    "subdocument",
+		"WebSocket":   "websocket",
+		"Ping":        "ping",
+		"Manifest":    "other",
+	} {
+		if got := abpType(cdp); got != want {
+			t.Errorf("abpType(%q) = %q，應為 %q", cdp, got, want)
+		}
+	}
+}
+
+func TestDefault(t *testing.T) {
+	l := Default()
+	if l.Rules == 0 || l.Skipped != 0 {
+		t.Fatalf("內建清單 Rules = %d、Skipped = %d", l.Rules, l.Skipped)
+	}
+	if _, ok := l.Match("https://www.google-analytics.com/analytics.js", "https://shop.test/", "Script"); !ok {
+		t.Error("內建清單應阻擋第三方的 google-analytics")
+	}
+	if _, ok := l.Match("https://www.google-analytics.com/analytics.js", "https://google-analytics.com/", "Script"); ok {
+		t.Error("內建清單不應阻擋第一方請求")
+	}
+	if _, ok := l.Match("https://shop.test/app.js", "https://shop.test/", "Script"); ok {
+		t.Error("內建清單不應阻擋一般的第一方腳本")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt")
+	os.WriteFile(a, []byte("||a.example^\n"), 0644)
+	os.WriteFile(b, []byte("||b.example^\n@@||a.example/ok/\nx##.ad\n"), 0644)
+
+	l, err := Load(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Rules != 3 || l.Skipped != 1 {
+		t.Errorf("Rules = %d、Skipped = %d，應為 3 與 1", l.Rules, l.Skipped)
+	}
+	for req, want := range map[string]bool{
+		"https://a.example/x":    true,
+		"https://b.example/x":    true,
+		"https://a.example/ok/x": false,
+	} {
+		if _, ok := l.Match(req, "", ""); ok != want {
+			t.Errorf("Match(%q) = %v，應為 %v", req, ok, want)
+		}
+	}
+
+	if _, err := Load(a, filepath.Join(dir, "missing.txt")); err == nil {
+		t.Error("不存在的檔案應回傳錯誤")
+	}
+	// 超過 1 MiB 的單行無法解析
+	long := filepath.Join(dir, "long.txt")
+	os.WriteFile(long, []byte("||"+strings.Repeat("a", 2<<20)+"^\n"), 0644)
+	if _, err := Load(long); err == nil {
+		t.Error("過長的行應回傳錯誤")
+	}
+}
+
+// 下載或解析失敗時保留原檔案
+func TestUpdate(t *testing.T) {
+	body := "||fetched.example^\n"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/list.txt":
+			w.Write([]byte(body))
+		case "/empty.txt":
+			w.Write([]byte("! 只有註解\nx##.ad\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "list.txt")
+	os.WriteFile(path, []byte("||old.example^\n"), 0644)
+	ctx := context.Background()
+
+	for _, u := range []string{srv.URL + "/missing.txt", srv.URL + "/empty.txt"} {
+		if _, err := Update(ctx, u, path); err == nil {
+			t.Errorf("Update(%s) 應失敗", u)
+		}
+		if got, _ := os.ReadFile(path); string(got) != "||old.example^\n" {
+			t.Errorf("Update(%s) 失敗後原檔案被修改：%q", u, got)
+		}
+	}
+
+	l, err := Update(ctx, srv.URL+"/list.txt", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := l.Match("https://fetched.example/", "", ""); !ok {
+		t.Error("Update 回傳的清單應包含下載的規則")
+	}
+	if got, _ := os.ReadFile(path); string(got) != body {
+		t.Errorf("寫入的檔案為 %q，應為 %q", got, body)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("目錄中留下暫存檔：%v", entries)
+	}
+}
+
+// 截斷或夾雜特殊字元的規則只會被略過，不可 panic；比對任意網址也不可 panic
+func TestParseGarbage(t *testing.T) {
+	junk := []string{"", "|", "||", "^", "*", "$", "~", "@@", "#", "##", "/", ",", "=", "\\", "(", "[", "\xff", "名"}
+	reqs := []string{"https://a.b.example/x?y=1", "", "%zz", "https://[::1]:8080/", "http://10.0.0.1/", "https://例子.测试/廣告"}
+	check := func(line string) {
+		defer func() {
+			if r := recover(); r != nil {
+				t.Errorf("%q panic: %v", line, r)
+			}
+		}()
+		l, err := Parse(strings.NewReader(line))
+		if err != nil {
+			t.Errorf("Parse(%q): %v", line, err)
+			return
+		}
+		for _, req := range reqs {
+			for _, page := range reqs {
+				l.Match(req, page, "Script")
+			}
+			l.Match(req, line, "")
+			l.Match(line, req, "")
+		}
+	}
+	for _, line := range strings.Split(strings.TrimSpace(testRules), "\n") {
+		for i := 0; i <= len(line); i++ {
+			check(line[:i])
+			check(line[i:])
+			for _, j := range junk {
+				if i < len(line) {
+					check(line[:i] + j + line[i+1:])
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	cdpbrowser "github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/security"
+	"github.com/chromedp/cdproto/storage"
+	"github.com/chromedp/cdproto/target"
+	"github.com/chromedp/chromedp"
+)
+
+// ContextOptions NewContext 的選項
+type ContextOptions struct {
+	// Proxy 此 context 所有分頁使用的代理，例如 http://proxy.example.com:8080 或 socks5://host:1080；
+	// 留空沿用瀏覽器的設定。不支援在網址中帶帳密
+	Proxy string
+	// ProxyBypass 不經代理的主機，以逗號分隔，例如 "localhost,*.internal"
+	ProxyBypass string
+	// Permissions 預先授予的權限，例如 "geolocation"、"notifications"、"clipboardReadWrite"
+	Permissions []string
+	// PermissionOrigin 權限授予的來源，例如 https://example.com；留空套用到所有來源
+	PermissionOrigin string
+	// Cookies 建立後預先設定的 cookies，例如登入後取得的 session
+	Cookies []*network.CookieParam
+	// IgnoreCertErrors 此 context 的分頁忽略憑證錯誤（自簽、過期、主機名稱不符），其他 context 不受影響
+	IgnoreCertErrors bool
+}
+
+// BrowserContext 瀏覽器中的獨立 browser context（類似無痕視窗）：其中的分頁共用 cookies、快取、
+// Web Storage、權限與代理，與其他 context 的分頁互相隔離。適合每個工作或租戶各自的登入狀態與代理。
+// 瀏覽器重置後 context 隨之失效，NewPageContext 會回傳 TabInvalidatedError，須重新建立
+type BrowserContext struct {
+	bm         *BrowserManager
+	id         cdp.BrowserContextID
+	generation uint64
+	opts       ContextOptions
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// NewContext 建立新的 browser context 並套用代理、權限與 cookies；用完須呼叫 Close。Remote 模式不支援
+func (bm *BrowserManager) NewContext(opts ContextOptions) (*BrowserContext, error) {
+	st := bm.state.Load()
+	if st.proc == nil && !st.external {
+		return nil, fmt.Errorf("Remote 模式不支援獨立的 browser context")
+	}
+	bc := &BrowserContext{bm: bm, generation: st.generation, opts: opts}
+	err := bc.do(func(ctx context.Context) error {
+		params := target.CreateBrowserContext()
+		if opts.Proxy != "" {
+			params = params.WithProxyServer(opts.Proxy)
+			if opts.ProxyBypass != "" {
+				params = params.WithProxyBypassList(opts.ProxyBypass)
+			}
+		}
+		id, err := params.Do(ctx)
+		if err != nil {
+			return fmt.Errorf("建立 browser context 失敗: %w", err)
+		}
+		bc.id = id
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(opts.Permissions) > 0 {
+		if err := bc.GrantPermissions(opts.PermissionOrigin, opts.Permissions...); err != nil {
+			bc.Close()
+			return nil, err
+		}
+	}
+	if len(opts.Cookies) > 0 {
+		if err := bc.SetCookies(opts.Cookies); err != nil {
+			bc.Close()
+			return nil, err
+		}
+	}
+	log.Printf("[cdpkit] 建立 browser context %s", bc.id)
+	return bc, nil
+}
+
+// ID 回傳 CDP 的 browser context ID
+func (bc *BrowserContext) ID() cdp.BrowserContextID {
+	return bc.id
+}
+
+// Options 回傳建立時的選項
+func (bc *BrowserContext) Options() ContextOptions {
+	return bc.opts
+}
+
+// NewPageContext 在此 context 中建立新分頁的 context，分頁計數與 BrowserManager.NewPageContext 共用
+func (bc *BrowserContext) NewPageContext() (context.Context, context.CancelFunc, error) {
+	if err := bc.valid(); err != nil {
+		return nil, nil, err
+	}
+	ctx, cancel, err := bc.bm.newPageContext(chromedp.WithExistingBrowserContext(bc.id))
+	if err != nil {
+		return nil, nil, err
+	}
+	// 達到分頁上限時 newPageContext 會重置瀏覽器，此 context 已不存在
+	if err := bc.valid(); err != nil {
+		cancel()
+		return nil, nil, err
+	}
+	if bc.opts.IgnoreCertErrors {
+		if err := chromedp.Run(ctx, security.SetIgnoreCertificateErrors(true)); err != nil {
+			cancel()
+			return nil, nil, fmt.Errorf("設定忽略憑證錯誤失敗: %w", err)
+		}
+	}
+	return ctx, cancel, nil
+}
+
+// Cookies 回傳此 context 中所有的 cookies
+func (bc *BrowserContext) Cookies() ([]*network.Cookie, error) {
+	var cookies []*network.Cookie
+	err := bc.do(func(ctx context.Context) error {
+		var err error
+		cookies, err = storage.GetCookies().WithBrowserContextID(bc.id).Do(ctx)
+		return err
+	})
+	return cookies, err
+}
+
+// SetCookies 設定 cookies，此 context 中的所有分頁立即生效
+func (bc *BrowserContext) SetCookies(cookies []*network.CookieParam) error {
+	return bc.do(func(ctx context.Context) error {
+		if err := storage.SetCookies(cookies).WithBrowserContextID(bc.id).Do(ctx); err != nil {
+			return fmt.Errorf("設定 cookies 失敗: %w", err)
+		}
+		return nil
+	})
+}
+
+// ClearCookies 清除此 context 中所有的 cookies
+func (bc *BrowserContext) ClearCookies() error {
+	return bc.do(func(ctx context.Context) error {
+		return storage.ClearCookies().WithBrowserContextID(bc.id).Do(ctx)
+	})
+}
+
+// GrantPermissions 授予權限，origin 留空時套用到所有來源；未授予的權限維持瀏覽器預設（通常為詢問後拒絕）
+func (bc *BrowserContext) GrantPermissions(origin string, permissions ...string) error {
+	types := make([]cdpbrowser.PermissionType, len(permissions))
+	for i, p := range permissions {
+		types[i] = cdpbrowser.PermissionType(p)
+	}
+	return bc.do(func(ctx context.Context) error {
+		params := cdpbrowser.GrantPermissions(types).WithBrowserContextID(bc.id)
+		if origin != "" {
+			params = params.WithOrigin(origin)
+		}
+		if err := params.Do(ctx); err != nil {
+			return fmt.Errorf("授予權限失敗: %w", err)
+		}
+		return nil
+	})
+}
+
+// ResetPermissions 撤銷所有以 GrantPermissions 授予的權限
+func (bc *BrowserContext) ResetPermissions() error {
+	return bc.do(func(ctx context.Context) error {
+		return cdpbrowser.ResetPermissions().WithBrowserContextID(bc.id).Do(ctx)
+	})
+}
+
+// Close 關閉 context 中的所有分頁並丟棄其 cookies 與快取；重複呼叫或瀏覽器已重置時不做任何事
+func (bc *BrowserContext) Close() error {
+	bc.mu.Lock()
+	if bc.closed {
+		bc.mu.Unlock()
+		return nil
+	}
+	bc.closed = true
+	bc.mu.Unlock()
+	if bc.id == "" || bc.bm.state.Load().generation != bc.generation {
+		return nil
+	}
+	log.Printf("[cdpkit] 關閉 browser context %s", bc.id)
+	return bc.do(func(ctx context.Context) error {
+		return target.DisposeBrowserContext(bc.id).Do(ctx)
+	})
+}
+
+// ----------------- 內部實作 -----------------
+
+// browserContextTimeout 建立、設定與關閉 browser context 的逾時
+const browserContextTimeout = 10 * time.Second
+
+// valid 檢查 context 未關閉且仍屬於目前世代的瀏覽器
+func (bc *BrowserContext) valid() error {
+	bc.mu.Lock()
+	closed := bc.closed
+	bc.mu.Unlock()
+	if closed {
+		return fmt.Errorf("browser context %s 已關閉", bc.id)
+	}
+	if g := bc.bm.state.Load().generation; g != bc.generation {
+		return &TabInvalidatedError{Reason: "已重置", Generation: bc.generation}
+	}
+	return nil
+}
+
+// do 以瀏覽器層級的 executor 執行 fn
+func (bc *BrowserContext) do(fn func(ctx context.Context) error) error {
+	st := bc.bm.state.Load()
+	if st.generation != bc.generation {
+		return &TabInvalidatedError{Reason: "已重置", Generation: bc.generation}
+	}
+	if cause := InvalidationCause(st.allocCtx); cause != nil {
+		return cause
+	}
+	c := chromedp.FromContext(st.allocCtx)
+	if c == nil || c.Browser == nil {
+		return fmt.Errorf("瀏覽器尚未啟動")
+	}
+	ctx, cancel := context.WithTimeout(st.allocCtx, browserContextTimeout)
+	defer cancel()
+	return fn(cdp.WithExecutor(ctx, c.Browser))
+}
@@ -0,0 +1,153 @@
+package browser
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/chromedp"
+	"github.com/firehourse/cdpkit/config"
+)
+
+// 崩潰後等待 crashpad 寫完 minidump 的時間
+const crashDumpDelay = time.Second
+
+// CrashReport 一次崩潰收集到的檔案，供事後分析
+type CrashReport struct {
+	Time time.Time `json:"time"`
+	// Reason "browser"（Chrome 主行程結束或失聯）、"renderer"（分頁崩潰）或 "shutdown"（關閉時發現未收集的傾印）
+	Reason     string `json:"reason"`
+	Generation uint64 `json:"generation"`
+	// Dir 檔案所在目錄
+	Dir string `json:"dir"`
+	// Files chrome_debug.log 與 minidump 的檔名
+	Files []string `json:"files"`
+}
+
+// diagnostics 單一 Chrome 實例的日誌與傾印工作目錄
+type diagnostics struct {
+	// work Chrome 寫入 chrome_debug.log 與 crashpad 傾印的目錄
+	work string
+	// dest 收集崩潰檔案的目錄
+	dest string
+
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+// newDiagnostics 在 cfg.CrashDir 下建立工作目錄，回傳對應的 Chrome 旗標；未設定 CrashDir 時回傳 nil
+func newDiagnostics(cfg config.Config, generation uint64) (*diagnostics, []chromedp.ExecAllocatorOption, error) {
+	if cfg.CrashDir == "" {
+		return nil, nil, nil
+	}
+	if err := os.MkdirAll(cfg.CrashDir, 0755); err != nil {
+		return nil, nil, fmt.Errorf("無法建立崩潰收集目錄: %w", err)
+	}
+	work, err := os.MkdirTemp(cfg.CrashDir, fmt.Sprintf(".chrome-g%d-", generation))
+	if err != nil {
+		return nil, nil, fmt.Errorf("無法建立 Chrome 日誌目錄: %w", err)
+	}
+	dumps := filepath.Join(work, "dumps")
+	opts := []chromedp.ExecAllocatorOption{
+		chromedp.Flag("enable-logging", true),
+		chromedp.Flag("log-file", filepath.Join(work, "chrome_debug.log")),
+		chromedp.Flag("v", fmt.Sprint(cfg.ChromeLogLevel)),
+		chromedp.Flag("enable-crash-reporter", true),
+		chromedp.Flag("crash-dumps-dir", dumps),
+		chromedp.Flag("noerrdialogs", true),
+	}
+	return &diagnostics{work: work, dest: cfg.CrashDir, seen: map[string]bool{}}, opts, nil
+}
+
+// collect 將 chrome_debug.log 與尚未收集的 minidump 複製到新的崩潰目錄；
+// onlyDumps 為 true 時沒有新的傾印就不建立報告
+func (d *diagnostics) collect(reason string, generation uint64, onlyDumps bool) (CrashReport, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var dumps []string
+	filepath.WalkDir(filepath.Join(d.work, "dumps"), func(path string, e os.DirEntry, err error) error {
+		if err == nil && !e.IsDir() && strings.HasSuffix(path, ".dmp") && !d.seen[path] {
+			dumps = append(dumps, path)
+		}
+		return nil
+	})
+	if onlyDumps && len(dumps) == 0 {
+		return CrashReport{}, false
+	}
+
+	now := time.Now()
+	report := CrashReport{
+		Time:       now,
+		Reason:     reason,
+		Generation: generation,
+		Dir:        filepath.Join(d.dest, fmt.Sprintf("crash-%s-g%d-%s", now.Format("20060102-150405.000"), generation, reason)),
+	}
+	if err := os.MkdirAll(report.Dir, 0755); err != nil {
+		log.Printf("[cdpkit] 警告：無法建立崩潰目錄: %v", err)
+		return CrashReport{}, false
+	}
+	for _, src := range append([]string{filepath.Join(d.work, "chrome_debug.log")}, dumps...) {
+		name := filepath.Base(src)
+		if err := copyFile(src, filepath.Join(report.Dir, name)); err != nil {
+			if !os.IsNotExist(err) {
+				log.Printf("[cdpkit] 警告：收集 %s 失敗: %v", name, err)
+			}
+			continue
+		}
+		d.seen[src] = true
+		report.Files = append(report.Files, name)
+	}
+	return report, true
+}
+
+// remove 刪除工作目錄；須在 Chrome 結束後呼叫
+func (d *diagnostics) remove() {
+	if err := os.RemoveAll(d.work); err != nil {
+		log.Printf("[cdpkit] 警告：無法刪除 Chrome 日誌目錄 %s: %v", d.work, err)
+	}
+}
+
+// collectCrash 收集 st 的崩潰檔案並記錄；未設定 CrashDir 時不做任何事
+func (bm *BrowserManager) collectCrash(st *browserState, reason string, onlyDumps bool) {
+	if st.diag == nil {
+		return
+	}
+	report, ok := st.diag.collect(reason, st.generation, onlyDumps)
+	if !ok {
+		return
+	}
+	log.Printf("[cdpkit] 已收集 Chrome 崩潰資料 (%s): %s", reason, report.Dir)
+	bm.crashMu.Lock()
+	bm.crashes = append(bm.crashes, report)
+	bm.crashMu.Unlock()
+}
+
+// CrashReports 回傳已收集的崩潰報告
+func (bm *BrowserManager) CrashReports() []CrashReport {
+	bm.crashMu.Lock()
+	defer bm.crashMu.Unlock()
+	return append([]CrashReport(nil), bm.crashes...)
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
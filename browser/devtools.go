@@ -0,0 +1,210 @@
+package browser
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os/exec"
+	"runtime"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+// DevToolsTarget Chrome 調試埠上的一個分頁
+type DevToolsTarget struct {
+	ID    string `json:"id"`
+	Type  string `json:"type"`
+	Title string `json:"title"`
+	URL   string `json:"url"`
+	// FrontendURL 以 DevTools 檢視此分頁的網址
+	FrontendURL string `json:"devtoolsFrontendUrl"`
+}
+
+// DevToolsTargets 回傳 Chrome 目前開啟的頁面；FrontendURL 直接指向調試埠
+func (bm *BrowserManager) DevToolsTargets() ([]DevToolsTarget, error) {
+	return bm.devtoolsTargets(bm.debugAddr(), "")
+}
+
+// DevToolsURL 回傳以 DevTools 檢視分頁的網址；ctx 為 NewPageContext 建立的分頁 context，
+// 分頁須已執行過任何動作（例如導航）才會有對應的 target。
+// 網址直接指向調試埠，只適合本機使用，遠端檢視請改用 ServeDevTools
+func (bm *BrowserManager) DevToolsURL(ctx context.Context) (string, error) {
+	return bm.frontendURL(ctx, bm.debugAddr(), "")
+}
+
+// DevToolsProxy 將 Chrome 調試埠透過需要 token 的本機代理公開，
+// 不必開放調試埠本身也能以 DevTools 檢視無頭分頁，見 ServeDevTools
+type DevToolsProxy struct {
+	bm     *BrowserManager
+	token  string
+	addr   string
+	server *http.Server
+}
+
+// ServeDevTools 在 addr（預設 127.0.0.1:0）啟動 DevTools 代理。所有請求路徑都須以 token 開頭，
+// 否則回傳 404；開啟 p.URL() 可列出所有分頁並連至各自的 DevTools。
+// 代理可存取整個瀏覽器，請勿在未經保護的網路介面上監聽
+func (bm *BrowserManager) ServeDevTools(addr string) (*DevToolsProxy, error) {
+	if addr == "" {
+		addr = "127.0.0.1:0"
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("無法啟動 DevTools 代理: %w", err)
+	}
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		ln.Close()
+		return nil, err
+	}
+
+	p := &DevToolsProxy{bm: bm, token: hex.EncodeToString(buf), addr: ln.Addr().String()}
+	p.server = &http.Server{Handler: p, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := p.server.Serve(ln); err != nil && err != http.ErrServerClosed {
+			log.Printf("[cdpkit] DevTools 代理結束: %v", err)
+		}
+	}()
+	log.Printf("[cdpkit] DevTools 代理: %s", p.URL())
+	return p, nil
+}
+
+// URL 回傳分頁清單的網址（含 token）
+func (p *DevToolsProxy) URL() string {
+	return "http://" + p.addr + "/" + p.token + "/"
+}
+
+// TabURL 回傳經由代理以 DevTools 檢視分頁的網址，ctx 同 DevToolsURL
+func (p *DevToolsProxy) TabURL(ctx context.Context) (string, error) {
+	return p.bm.frontendURL(ctx, p.addr, "/"+p.token)
+}
+
+// Close 停止代理並中斷已連線的 DevTools
+func (p *DevToolsProxy) Close() error {
+	return p.server.Close()
+}
+
+func (p *DevToolsProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	prefix := "/" + p.token
+	path := r.URL.Path
+	if len(path) < len(prefix) || subtle.ConstantTimeCompare([]byte(path[:len(prefix)]), []byte(prefix)) != 1 {
+		http.NotFound(w, r)
+		return
+	}
+	rest := path[len(prefix):]
+	if rest != "" && rest[0] != '/' {
+		http.NotFound(w, r)
+		return
+	}
+	if rest == "" || rest == "/" {
+		p.index(w, r)
+		return
+	}
+
+	target := &url.URL{Scheme: "http", Host: p.bm.debugAddr()}
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+			pr.Out.URL.Path = rest
+			pr.Out.URL.RawPath = ""
+			// Chrome 拒絕 Origin 不在 --remote-allow-origins 中的 WebSocket 連線；
+			// 請求已通過 token 驗證，移除 Origin 讓 Chrome 視為本機工具連線
+			pr.Out.Header.Del("Origin")
+		},
+	}
+	proxy.ServeHTTP(w, r)
+}
+
+var devtoolsIndex = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>cdpkit DevTools</title></head>
+<body><h1>分頁</h1>
+{{if not .}}<p>目前沒有開啟的分頁</p>{{end}}
+<ul>{{range .}}<li><a href="{{.FrontendURL}}" target="_blank">{{if .Title}}{{.Title}}{{else}}{{.URL}}{{end}}</a><br><small>{{.URL}}</small></li>{{end}}</ul>
+</body></html>`))
+
+// index 列出所有頁面及其經由代理的 DevTools 連結
+func (p *DevToolsProxy) index(w http.ResponseWriter, r *http.Request) {
+	targets, err := p.bm.devtoolsTargets(p.addr, "/"+p.token)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	devtoolsIndex.Execute(w, targets)
+}
+
+// OpenURL 以系統預設的瀏覽器開啟網址，例如 DevToolsURL 的結果
+func OpenURL(u string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", u)
+	case "darwin":
+		cmd = exec.Command("open", u)
+	default:
+		cmd = exec.Command("xdg-open", u)
+	}
+	return cmd.Start()
+}
+
+// ----------------- 內部實作 -----------------
+
+// debugAddr 回傳 Chrome 調試埠的 host:port；Remote 模式取自 WebSocketURL
+func (bm *BrowserManager) debugAddr() string {
+	if bm.cfg.WebSocketURL != "" {
+		if u, err := url.Parse(bm.cfg.WebSocketURL); err == nil && u.Host != "" {
+			return u.Host
+		}
+	}
+	return fmt.Sprintf("127.0.0.1:%d", bm.cfg.RemotePort)
+}
+
+// devtoolsTargets 讀取 /json/list 的頁面，並將 FrontendURL 改寫為經由 host 與路徑前綴 prefix 連線
+func (bm *BrowserManager) devtoolsTargets(host, prefix string) ([]DevToolsTarget, error) {
+	client := http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get("http://" + bm.debugAddr() + "/json/list")
+	if err != nil {
+		return nil, fmt.Errorf("無法取得分頁清單: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var all []DevToolsTarget
+	if err := json.NewDecoder(resp.Body).Decode(&all); err != nil {
+		return nil, fmt.Errorf("無法解析分頁清單: %w", err)
+	}
+	pages := all[:0]
+	for _, t := range all {
+		if t.Type != "page" {
+			continue
+		}
+		t.FrontendURL = frontendURL(t.ID, host, prefix)
+		pages = append(pages, t)
+	}
+	return pages, nil
+}
+
+func (bm *BrowserManager) frontendURL(ctx context.Context, host, prefix string) (string, error) {
+	c := chromedp.FromContext(ctx)
+	if c == nil {
+		return "", fmt.Errorf("不是 chromedp 的分頁 context")
+	}
+	if c.Target == nil {
+		return "", fmt.Errorf("分頁尚未建立，請先導航或執行任何動作")
+	}
+	return frontendURL(string(c.Target.TargetID), host, prefix), nil
+}
+
+// frontendURL 使用 Chrome 內建的 DevTools 前端；ws 參數不含 scheme，與 /json/list 的格式相同
+func frontendURL(id, host, prefix string) string {
+	return fmt.Sprintf("http://%s%s/devtools/inspector.html?ws=%s%s/devtools/page/%s", host, prefix, host, prefix, id)
+}
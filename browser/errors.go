@@ -0,0 +1,42 @@
+package browser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrTabInvalidated 分頁所屬的瀏覽器已重置或關閉，可用 errors.Is 判斷
+var ErrTabInvalidated = errors.New("分頁已失效")
+
+// ErrClosed 瀏覽器管理器已 Shutdown，無法再建立分頁或重置
+var ErrClosed = errors.New("瀏覽器管理器已關閉")
+
+// TabInvalidatedError 說明分頁失效的原因與所屬的瀏覽器世代
+type TabInvalidatedError struct {
+	// Reason 例如「已重置」、「已關閉」
+	Reason string
+	// Generation 分頁建立時的瀏覽器世代
+	Generation uint64
+}
+
+func (e *TabInvalidatedError) Error() string {
+	return fmt.Sprintf("分頁已失效：瀏覽器%s (第 %d 代)", e.Reason, e.Generation)
+}
+
+// Is 讓 errors.Is(err, ErrTabInvalidated) 成立
+func (e *TabInvalidatedError) Is(target error) bool {
+	return target == ErrTabInvalidated
+}
+
+// InvalidationCause 若 ctx 因瀏覽器重置或關閉而失效，回傳對應的 TabInvalidatedError；否則回傳 nil
+func InvalidationCause(ctx context.Context) error {
+	if ctx == nil {
+		return nil
+	}
+	var tie *TabInvalidatedError
+	if errors.As(context.Cause(ctx), &tie) {
+		return tie
+	}
+	return nil
+}
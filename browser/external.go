@@ -0,0 +1,63 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/chromedp/chromedp"
+	"github.com/firehourse/cdpkit/config"
+)
+
+// NewManagerFromAllocator 以呼叫端自行建立的 chromedp allocator（chromedp.NewExecAllocator 或
+// chromedp.NewRemoteAllocator 回傳的 context）建立 BrowserManager，讓已用 chromedp 管理 Chrome 的程式
+// 逐步改用 cdpkit 的分頁與爬蟲。啟動旗標與連線方式由 allocator 決定，cfg 只使用 TabLimit；
+// 瀏覽器層的其餘欄位（Flags、ChromePath、Limits、CrashDir 等）不會套用。
+//
+// 分頁仍計入 TabLimit，但外部提供的瀏覽器無法重置：達到上限或瀏覽器結束後 NewPageContext 回傳錯誤。
+// Shutdown 只結束 BrowserManager 自己建立的瀏覽器層 context 與分頁；ExecAllocator 啟動的 Chrome
+// 會隨之關閉，allocator 本身仍由呼叫端取消
+func NewManagerFromAllocator(allocCtx context.Context, cfg config.Config) (*BrowserManager, error) {
+	if chromedp.FromContext(allocCtx) != nil {
+		return nil, fmt.Errorf("allocCtx 已是瀏覽器 context，請改用 NewManagerFromContext")
+	}
+	browserCtx, browserCancel := chromedp.NewContext(allocCtx, chromedp.WithLogf(log.Printf))
+	if err := chromedp.Run(browserCtx); err != nil {
+		browserCancel()
+		return nil, fmt.Errorf("啟動 Chrome 失敗: %w", err)
+	}
+	return newExternalManager(browserCtx, browserCancel, cfg), nil
+}
+
+// NewManagerFromContext 以呼叫端的 chromedp context（chromedp.NewContext 建立、已執行或尚未執行）
+// 建立 BrowserManager，之後的分頁以新分頁的形式開在同一個瀏覽器中，與呼叫端既有的分頁並存。
+// 尚未執行時會先以 chromedp.Run 啟動或連線瀏覽器，瀏覽器仍屬於呼叫端的 context。
+// cfg 的使用方式與重置限制同 NewManagerFromAllocator；Shutdown 只關閉經由 BrowserManager 開啟的分頁，
+// 不影響呼叫端的 context 與瀏覽器
+func NewManagerFromContext(browserCtx context.Context, cfg config.Config) (*BrowserManager, error) {
+	if chromedp.FromContext(browserCtx) == nil {
+		return nil, fmt.Errorf("browserCtx 不是 chromedp context，allocator 請改用 NewManagerFromAllocator")
+	}
+	if err := chromedp.Run(browserCtx); err != nil {
+		return nil, fmt.Errorf("連接 Chrome 失敗: %w", err)
+	}
+	return newExternalManager(browserCtx, func() {}, cfg), nil
+}
+
+// ----------------- 內部實作 -----------------
+
+// newExternalManager 以外部的瀏覽器層 context 建立狀態；release 結束 BrowserManager 持有的部分
+func newExternalManager(browserCtx context.Context, release context.CancelFunc, cfg config.Config) *BrowserManager {
+	root, rootCancel := context.WithCancelCause(browserCtx)
+	st := &browserState{
+		allocCtx: root,
+		cancel: func(cause error) {
+			rootCancel(cause)
+			release()
+		},
+		generation: 1,
+		external:   true,
+	}
+	log.Printf("[cdpkit] 使用外部提供的 Chrome")
+	return newManager(cfg, st)
+}
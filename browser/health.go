@@ -0,0 +1,134 @@
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// 健康狀態
+const (
+	// HealthOK 已連線且 Chrome 行程存活
+	HealthOK = "ok"
+	// HealthDegraded 連線中斷，下次 NewPageContext 會自動重置
+	HealthDegraded = "degraded"
+	// HealthDown 連線中斷且上次重置失敗
+	HealthDown = "down"
+)
+
+// Health 瀏覽器管理器的健康狀態，供存活與就緒探測使用
+type Health struct {
+	// Status 見 HealthOK、HealthDegraded、HealthDown
+	Status string `json:"status"`
+	// Mode "remote"、"exec" 或 "external"
+	Mode string `json:"mode"`
+	// Connected 與 Chrome 的連線是否仍有效
+	Connected bool `json:"connected"`
+	// ChromeAlive Exec 模式為 Chrome 主行程是否存在；Remote 模式同 Connected
+	ChromeAlive bool `json:"chrome_alive"`
+	// ChromePID Exec 模式的 Chrome 主行程 PID
+	ChromePID int `json:"chrome_pid,omitempty"`
+	// ActiveTabs 目前存活的分頁數
+	ActiveTabs int `json:"tabs_open"`
+	// TabLimit 觸發重置前允許的最大分頁數
+	TabLimit int `json:"tab_limit"`
+	// Restarts 重置次數
+	Restarts int `json:"restarts"`
+	// Generation 目前的瀏覽器世代
+	Generation uint64 `json:"generation"`
+	// LastError 最近一次重置失敗或連線中斷的原因
+	LastError string `json:"last_error,omitempty"`
+	// LastErrorAt LastError 發生的時間
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+	// Uptime 自建立以來經過的時間
+	Uptime time.Duration `json:"-"`
+	// UptimeSeconds 同 Uptime，以秒表示
+	UptimeSeconds float64 `json:"uptime_seconds"`
+}
+
+// Live 行程是否應繼續執行；連線中斷但仍可自動重置時視為存活，避免不必要的重啟
+func (h Health) Live() bool {
+	return h.Status != HealthDown
+}
+
+// Ready 是否可以接受新工作
+func (h Health) Ready() bool {
+	return h.Status == HealthOK
+}
+
+// Health 回傳目前的健康狀態
+func (bm *BrowserManager) Health() Health {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+
+	st := bm.state.Load()
+	h := Health{
+		Mode:       st.mode(),
+		Connected:  st.allocCtx.Err() == nil,
+		ActiveTabs: bm.tabCount,
+		TabLimit:   bm.tabLimit,
+		Restarts:   bm.restarts,
+		Generation: st.generation,
+		Uptime:     time.Since(bm.startedAt),
+	}
+	h.UptimeSeconds = h.Uptime.Seconds()
+	h.ChromeAlive = h.Connected
+	if st.proc != nil {
+		h.ChromePID = st.proc.pid()
+		h.ChromeAlive = h.ChromePID != 0 && processAlive(h.ChromePID)
+	}
+
+	lastErr, at := bm.lastErr, bm.lastErrAt
+	if !h.Connected && lastErr == nil {
+		// 連線中斷但尚未嘗試重置：以取消原因說明
+		if cause := context.Cause(st.allocCtx); cause != nil {
+			lastErr, at = cause, time.Time{}
+		}
+	}
+	if lastErr != nil {
+		h.LastError = lastErr.Error()
+		if !at.IsZero() {
+			h.LastErrorAt = &at
+		}
+	}
+
+	switch {
+	case h.Connected && h.ChromeAlive:
+		h.Status = HealthOK
+	case bm.lastErr != nil && bm.lastErrAt.After(bm.restartedAt):
+		h.Status = HealthDown
+	default:
+		h.Status = HealthDegraded
+	}
+	return h
+}
+
+// LivenessHandler 回傳存活探測用的 http.Handler：Health().Live() 為 false 時回應 503，
+// 回應內容為 Health 的 JSON
+func (bm *BrowserManager) LivenessHandler() http.Handler {
+	return healthHandler(bm, Health.Live)
+}
+
+// ReadinessHandler 回傳就緒探測用的 http.Handler：Health().Ready() 為 false 時回應 503
+//
+//	mux.Handle("/healthz", bm.LivenessHandler())
+//	mux.Handle("/readyz", bm.ReadinessHandler())
+func (bm *BrowserManager) ReadinessHandler() http.Handler {
+	return healthHandler(bm, Health.Ready)
+}
+
+func healthHandler(bm *BrowserManager, pass func(Health) bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := bm.Health()
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if !pass(h) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		if r.Method == http.MethodHead {
+			return
+		}
+		json.NewEncoder(w).Encode(h)
+	})
+}
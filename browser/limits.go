@@ -0,0 +1,79 @@
+package browser
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/chromedp/chromedp"
+	"github.com/firehourse/cdpkit/config"
+)
+
+// limitFlags 依資源限制產生的 Chrome 旗標；使用者自訂的 js-flags 會保留並合併
+func limitFlags(cfg config.Config) []chromedp.ExecAllocatorOption {
+	l := cfg.Limits
+	var opts []chromedp.ExecAllocatorOption
+	if l.JSHeapMB > 0 {
+		jsFlags := fmt.Sprintf("--max-old-space-size=%d", l.JSHeapMB)
+		if user, ok := cfg.Flags["js-flags"].(string); ok && user != "" {
+			jsFlags = user + " " + jsFlags
+		}
+		opts = append(opts, chromedp.Flag("js-flags", jsFlags))
+	}
+	if l.RendererProcesses > 0 {
+		opts = append(opts, chromedp.Flag("renderer-process-limit", fmt.Sprint(l.RendererProcesses)))
+	}
+	return opts
+}
+
+// applyLimits 在 Chrome 啟動後套用 CPU 優先權與 cgroup 限制。
+// 此時 zygote 等子行程已存在，因此以整個行程群組為對象；之後產生的 renderer 會繼承設定
+func applyLimits(proc *chromeProcess, l config.ResourceLimits) error {
+	pid := proc.pid()
+	if pid == 0 {
+		return nil
+	}
+	if l.Nice != 0 {
+		if err := setPriority(pid, l.Nice); err != nil {
+			return fmt.Errorf("設定 Chrome 優先權失敗: %w", err)
+		}
+	}
+	if !l.NeedsCgroup() {
+		return nil
+	}
+	if l.Cgroup == "" {
+		log.Printf("[cdpkit] 警告：未設定 Limits.Cgroup，忽略記憶體、行程數與 CPU 配額限制")
+		return nil
+	}
+	dir, err := joinCgroup(pid, l)
+	if err != nil {
+		return fmt.Errorf("套用 cgroup 限制失敗: %w", err)
+	}
+	proc.mu.Lock()
+	proc.cgroup = dir
+	proc.mu.Unlock()
+	log.Printf("[cdpkit] Chrome 已加入 cgroup %s", dir)
+	return nil
+}
+
+// cgroupLimits cgroup v2 介面檔與對應的值
+func cgroupLimits(l config.ResourceLimits) map[string]string {
+	files := map[string]string{}
+	if l.MemoryMB > 0 {
+		files["memory.max"] = fmt.Sprint(int64(l.MemoryMB) << 20)
+		// 避免以 swap 規避記憶體上限
+		files["memory.swap.max"] = "0"
+	}
+	if l.MaxProcesses > 0 {
+		files["pids.max"] = fmt.Sprint(l.MaxProcesses)
+	}
+	if l.CPUPercent > 0 {
+		const period = 100000
+		files["cpu.max"] = fmt.Sprintf("%d %d", l.CPUPercent*period/100, period)
+	}
+	return files
+}
+
+// cgroupName Chrome 子 cgroup 的目錄名稱
+func cgroupName(pid int) string {
+	return fmt.Sprintf("cdpkit-chrome-%d", pid)
+}
@@ -0,0 +1,91 @@
+//go:build linux
+
+package browser
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/firehourse/cdpkit/config"
+)
+
+// joinCgroup 在 l.Cgroup 下建立子 cgroup、寫入限制，並移入 Chrome 行程群組的所有行程
+func joinCgroup(pid int, l config.ResourceLimits) (string, error) {
+	dir := filepath.Join(l.Cgroup, cgroupName(pid))
+	if err := os.Mkdir(dir, 0755); err != nil && !os.IsExist(err) {
+		return "", err
+	}
+	for file, value := range cgroupLimits(l) {
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(value), 0644); err != nil {
+			// 部分核心未啟用 swap 記帳，沒有 memory.swap.max
+			if file == "memory.swap.max" && os.IsNotExist(err) {
+				continue
+			}
+			removeCgroup(dir)
+			return "", fmt.Errorf("寫入 %s 失敗: %w", file, err)
+		}
+	}
+
+	members, err := processGroupMembers(pid)
+	if err != nil {
+		removeCgroup(dir)
+		return "", err
+	}
+	procs := filepath.Join(dir, "cgroup.procs")
+	for _, p := range members {
+		// 行程可能在列出後已結束
+		if err := os.WriteFile(procs, []byte(strconv.Itoa(p)), 0644); err != nil && processAlive(p) {
+			removeCgroup(dir)
+			return "", fmt.Errorf("移入行程 %d 失敗: %w", p, err)
+		}
+	}
+	return dir, nil
+}
+
+// removeCgroup 刪除子 cgroup；行程終止後 cgroup 才會清空，因此稍候重試
+func removeCgroup(dir string) {
+	for i := 0; i < 20; i++ {
+		if err := os.Remove(dir); err == nil || os.IsNotExist(err) {
+			return
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+	log.Printf("[cdpkit] 警告：無法刪除 cgroup %s", dir)
+}
+
+// processGroupMembers 從 /proc/<pid>/stat 找出行程群組為 pgid 的所有行程
+func processGroupMembers(pgid int) ([]int, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+	var pids []int
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		stat, err := os.ReadFile(filepath.Join("/proc", e.Name(), "stat"))
+		if err != nil {
+			continue
+		}
+		// comm 可能含空白，從最後一個 ')' 之後解析：state ppid pgrp ...
+		i := bytes.LastIndexByte(stat, ')')
+		if i < 0 {
+			continue
+		}
+		fields := bytes.Fields(stat[i+1:])
+		if len(fields) < 3 {
+			continue
+		}
+		if g, _ := strconv.Atoi(string(fields[2])); g == pgid {
+			pids = append(pids, pid)
+		}
+	}
+	return pids, nil
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+
+package browser
+
+import (
+	"errors"
+
+	"github.com/firehourse/cdpkit/config"
+)
+
+func joinCgroup(pid int, l config.ResourceLimits) (string, error) {
+	return "", errors.New("cgroup 僅支援 Linux")
+}
+
+func removeCgroup(dir string) {}
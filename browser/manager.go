@@ -6,13 +6,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os/exec"
 	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/chromedp/cdproto/inspector"
 	"github.com/chromedp/chromedp"
 	"github.com/firehourse/cdpkit/cdp"
 	"github.com/firehourse/cdpkit/config"
@@ -21,16 +24,67 @@ import (
 // BrowserManager 可連線既有 Chrome (RemoteAllocator)
 // 亦可自行啟動 Chrome (ExecAllocator)；取決於 cfg.WebSocketURL 是否為空。
 type BrowserManager struct {
-	allocCtx context.Context
-	cancel   context.CancelFunc
+	// state 目前的 Chrome 連線狀態；restart 時以新狀態整組替換，
+	// 其他 goroutine 可在不持有 mu 的情況下安全讀取
+	state atomic.Pointer[browserState]
 
 	tabLimit int
 	tabCount int
 	mu       sync.Mutex
+	// closed Shutdown 後為 true，由 mu 保護；之後不再建立分頁或重置
+	closed bool
+
+	// 統計資料，由 mu 保護
+	tabsCreated uint64
+	restarts    int
+	startedAt   time.Time
+	// lastErr 最近一次重置失敗的原因；restartedAt 之後的失敗代表目前無法恢復
+	lastErr     error
+	lastErrAt   time.Time
+	restartedAt time.Time
+
+	// crashes 已收集的崩潰報告，由 crashMu 保護（收集可能在持有 mu 時進行）
+	crashMu sync.Mutex
+	crashes []CrashReport
 
 	cfg config.Config
 }
 
+// Stats 瀏覽器管理器的即時統計，供監控使用
+type Stats struct {
+	// Mode "remote" 連接現有 Chrome；"exec" 自行啟動；"external" 由呼叫端的 chromedp 提供
+	Mode string
+	// ActiveTabs 目前存活的分頁數
+	ActiveTabs int
+	// TabLimit 觸發重置前允許的最大分頁數
+	TabLimit int
+	// TabsCreated 自建立以來累計開啟的分頁數
+	TabsCreated uint64
+	// Restarts 重置次數
+	Restarts int
+	// Generation 目前的瀏覽器世代
+	Generation uint64
+	// ChromePID Exec 模式的 Chrome 主行程 PID
+	ChromePID int
+	// Uptime 自建立以來經過的時間
+	Uptime time.Duration
+}
+
+// browserState 單次連線（或啟動）的 Chrome 狀態
+type browserState struct {
+	allocCtx context.Context
+	// cancel 以指定原因結束此狀態，所有由它衍生的分頁 context 都會一併失效
+	cancel context.CancelCauseFunc
+	// proc Exec 模式下啟動的 Chrome 行程；Remote 模式為 nil
+	proc *chromeProcess
+	// generation 每次 restart 遞增，用於辨識分頁屬於哪一代瀏覽器
+	generation uint64
+	// diag 設定 CrashDir 時的日誌與崩潰傾印目錄；Remote 模式為 nil
+	diag *diagnostics
+	// external 瀏覽器由呼叫端的 chromedp allocator 或 context 提供，無法重置
+	external bool
+}
+
 // ---------------- 新增：依設定初始化 ----------------
 
 func NewManagerFromConfig(cfg config.Config) (*BrowserManager, error) {
@@ -55,27 +109,65 @@ func NewManagerFromConfig(cfg config.Config) (*BrowserManager, error) {
 	return bm, nil
 }
 
+func newManager(cfg config.Config, st *browserState) *BrowserManager {
+	bm := &BrowserManager{
+		tabLimit:  defaultTabLimit(cfg.TabLimit),
+		startedAt: time.Now(),
+		cfg:       cfg,
+	}
+	bm.state.Store(st)
+	return bm
+}
+
 // ---------- Remote 模式 (連接現有 Chrome) ----------
 
 func newRemoteManager(cfg config.Config) (*BrowserManager, error) {
-	allocCtx, allocCancel, err := cdp.NewRemoteAllocator(cfg.WebSocketURL)
+	st, err := connectRemote(cfg, 1)
+	if err != nil {
+		return nil, err
+	}
+	return newManager(cfg, st), nil
+}
+
+func connectRemote(cfg config.Config, generation uint64) (*browserState, error) {
+	root, rootCancel := context.WithCancelCause(context.Background())
+	allocCtx, allocCancel, err := cdp.NewRemoteAllocatorContext(root, cfg.WebSocketURL)
 	if err != nil {
+		rootCancel(err)
 		return nil, fmt.Errorf("連接 Chrome 失敗: %w", err)
 	}
 	log.Printf("[cdpkit] 成功連接到 Chrome: %s", cfg.WebSocketURL)
-	return &BrowserManager{
+	return &browserState{
 		allocCtx: allocCtx,
-		cancel:   allocCancel,
-		tabLimit: defaultTabLimit(cfg.TabLimit),
-		cfg:      cfg,
+		cancel: func(cause error) {
+			// 先以原因取消根 context，衍生的分頁才能透過 context.Cause 取得它
+			rootCancel(cause)
+			allocCancel()
+		},
+		generation: generation,
 	}, nil
 }
 
 // ---------- Exec 模式 (自啟 Chrome) ----------
 
 func newExecManager(cfg config.Config) (*BrowserManager, error) {
-	// 1. 準備啟動選項
+	st, err := launchExec(cfg, 1)
+	if err != nil {
+		return nil, err
+	}
+	return newManager(cfg, st), nil
+}
+
+func launchExec(cfg config.Config, generation uint64) (*browserState, error) {
+	// 1. 準備啟動選項，並透過 ModifyCmdFunc 追蹤 Chrome 行程
+	proc := &chromeProcess{}
 	opts := prepareExecOptions(cfg)
+	opts = append(opts, chromedp.ModifyCmdFunc(proc.attach))
+	diag, diagOpts, err := newDiagnostics(cfg, generation)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, diagOpts...)
 	log.Printf("[cdpkit] 使用以下選項啟動 Chrome:")
 	for _, opt := range opts {
 		if strings.Contains(fmt.Sprintf("%v", opt), "--remote-debugging-port") {
@@ -84,11 +176,32 @@ func newExecManager(cfg config.Config) (*BrowserManager, error) {
 	}
 
 	// 2. 啟動 Chrome
-	allocCtx, allocCancel := chromedp.NewExecAllocator(context.Background(), opts...)
+	// chromedp 會延遲到第一次 Run 才真正啟動行程，因此先建立瀏覽器層 context 並執行，
+	// 之後的分頁都從這個 context 衍生，共用同一個 Chrome
+	root, rootCancel := context.WithCancelCause(context.Background())
+	allocCtx, allocCancel := chromedp.NewExecAllocator(root, opts...)
+	browserCtx, browserCancel := chromedp.NewContext(allocCtx, chromedp.WithLogf(log.Printf))
+	cancel := func(cause error) {
+		rootCancel(cause)
+		browserCancel()
+		allocCancel()
+		proc.kill()
+		if diag != nil {
+			diag.remove()
+		}
+	}
+
+	if err := chromedp.Run(browserCtx); err != nil {
+		cancel(err)
+		return nil, fmt.Errorf("啟動 Chrome 失敗: %w", err)
+	}
+	if err := applyLimits(proc, cfg.Limits); err != nil {
+		cancel(err)
+		return nil, err
+	}
 
 	// 3. 等待 debug 埠可連接
 	var wsURL string
-	var err error
 	for i := 0; i < 5; i++ { // 最多重試 5 次
 		wsURL, err = waitForDebugger(cfg.RemotePort, 3*time.Second)
 		if err == nil {
@@ -99,16 +212,19 @@ func newExecManager(cfg config.Config) (*BrowserManager, error) {
 	}
 
 	if wsURL == "" {
-		allocCancel()
+		// 啟動到一半失敗：cancel 終止本次啟動的主行程與其行程群組，殘留的 renderer 一併清除；
+		// 不依 Port 比對，以免誤殺使用者在同一個調試埠上開的 Chrome
+		cancel(err)
 		return nil, fmt.Errorf("啟動 Chrome 後無法連接調試埠: %v", err)
 	}
 
-	log.Printf("[cdpkit] Chrome 已啟動並就緒: %s", wsURL)
-	return &BrowserManager{
-		allocCtx: allocCtx,
-		cancel:   allocCancel,
-		tabLimit: defaultTabLimit(cfg.TabLimit),
-		cfg:      cfg,
+	log.Printf("[cdpkit] Chrome 已啟動並就緒: %s (PID=%d)", wsURL, proc.pid())
+	return &browserState{
+		allocCtx:   browserCtx,
+		cancel:     cancel,
+		proc:       proc,
+		generation: generation,
+		diag:       diag,
 	}, nil
 }
 
@@ -160,7 +276,16 @@ func prepareExecOptions(cfg config.Config) []chromedp.ExecAllocatorOption {
 		opts = append(opts, chromedp.Flag(k, v))
 	}
 
-	// 7. Chrome 執行檔路徑
+	// 7. 資源限制（合併使用者的 js-flags，因此放在自定 flags 之後）
+	opts = append(opts, limitFlags(cfg)...)
+
+	// 7.1 WebRTC IP 處理政策（使用者自訂的同名旗標優先）
+	opts = append(opts, webrtcFlags(cfg)...)
+
+	// 7.2 最小流量：關閉背景連線與預先解析（合併使用者的 disable-features）
+	opts = append(opts, trafficFlags(cfg)...)
+
+	// 8. Chrome 執行檔路徑
 	if cfg.ChromePath != "" {
 		opts = append(opts, chromedp.ExecPath(cfg.ChromePath))
 	} else {
@@ -214,72 +339,214 @@ func waitForDebugger(port int, timeout time.Duration) (string, error) {
 
 // ---------- 公共方法 ----------
 
+// NewPageContext 建立新分頁的 context。分頁計數跟隨 context 生命週期：
+// 呼叫 cancel、target 被關閉/崩潰或瀏覽器重置時都會自動釋放，不需手動遞減
 func (bm *BrowserManager) NewPageContext() (context.Context, context.CancelFunc, error) {
+	return bm.newPageContext()
+}
+
+// NewIsolatedPageContext 同 NewPageContext，但分頁位於新的 browser context（類似無痕視窗）：
+// cookies、快取與 Web Storage 都與其他分頁隔離，分頁關閉時一併丟棄。Remote 模式不支援
+func (bm *BrowserManager) NewIsolatedPageContext() (context.Context, context.CancelFunc, error) {
+	if st := bm.state.Load(); st.proc == nil && !st.external {
+		return nil, nil, fmt.Errorf("Remote 模式不支援獨立的 browser context")
+	}
+	return bm.newPageContext(chromedp.WithNewBrowserContext())
+}
+
+// FreePort 回傳本機目前空閒的 TCP 埠，用於另外啟動 Chrome 時指定 RemotePort
+func FreePort() (int, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, fmt.Errorf("找不到可用的連接埠: %w", err)
+	}
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port, nil
+}
+
+func (bm *BrowserManager) newPageContext(opts ...chromedp.ContextOption) (context.Context, context.CancelFunc, error) {
 	bm.mu.Lock()
 	defer bm.mu.Unlock()
+	if bm.closed {
+		return nil, nil, ErrClosed
+	}
 
-	if bm.tabCount >= bm.tabLimit {
-		log.Printf("[cdpkit] 分頁達到上限 (%d)，嘗試重置...", bm.tabLimit)
+	st := bm.state.Load()
+	if bm.tabCount >= bm.tabLimit || st.allocCtx.Err() != nil {
+		log.Printf("[cdpkit] 分頁達到上限 (%d) 或連線已中斷，嘗試重置...", bm.tabLimit)
 		if err := bm.restart(); err != nil {
+			bm.lastErr, bm.lastErrAt = err, time.Now()
 			return nil, nil, fmt.Errorf("無法重置瀏覽器: %w", err)
 		}
+		st = bm.state.Load()
 	}
 
 	ctx, cancel := chromedp.NewContext(
-		bm.allocCtx,
-		chromedp.WithLogf(log.Printf),
+		st.allocCtx,
+		append([]chromedp.ContextOption{chromedp.WithLogf(log.Printf)}, opts...)...,
 	)
 	bm.tabCount++
+	bm.tabsCreated++
+	bm.trackTab(ctx, st)
 	log.Printf("[cdpkit] 創建新分頁 (目前總數: %d)", bm.tabCount)
 	return ctx, cancel, nil
 }
 
+// trackTab 在分頁 context 結束或 target 脫離時釋放計數，每個分頁只釋放一次
+func (bm *BrowserManager) trackTab(ctx context.Context, st *browserState) {
+	var once sync.Once
+	release := func() {
+		once.Do(func() { bm.releaseTab(st.generation) })
+	}
+
+	chromedp.ListenTarget(ctx, func(ev interface{}) {
+		switch ev.(type) {
+		case *inspector.EventDetached:
+			release()
+		case *inspector.EventTargetCrashed:
+			release()
+			go func() {
+				time.Sleep(crashDumpDelay)
+				bm.collectCrash(st, "renderer", false)
+			}()
+		}
+	})
+	go func() {
+		<-ctx.Done()
+		release()
+	}()
+}
+
+// releaseTab 只處理目前世代的分頁；舊世代的計數已在 restart 時歸零
+func (bm *BrowserManager) releaseTab(generation uint64) {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+	if generation != bm.state.Load().generation || bm.tabCount == 0 {
+		return
+	}
+	bm.tabCount--
+	log.Printf("[cdpkit] 關閉分頁 (剩餘: %d)", bm.tabCount)
+}
+
+// ActiveTabs 回傳目前存活的分頁數
+func (bm *BrowserManager) ActiveTabs() int {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+	return bm.tabCount
+}
+
+// Stats 回傳目前的統計快照
+func (bm *BrowserManager) Stats() Stats {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+
+	st := bm.state.Load()
+	pid := 0
+	if st.proc != nil {
+		pid = st.proc.pid()
+	}
+	return Stats{
+		Mode:        st.mode(),
+		ActiveTabs:  bm.tabCount,
+		TabLimit:    bm.tabLimit,
+		TabsCreated: bm.tabsCreated,
+		Restarts:    bm.restarts,
+		Generation:  st.generation,
+		ChromePID:   pid,
+		Uptime:      time.Since(bm.startedAt),
+	}
+}
+
+// Shutdown 關閉瀏覽器，所有分頁以 TabInvalidatedError 失效；之後 NewPageContext 回傳 ErrClosed。
+// 與進行中的重置互斥，重置建立的新狀態也會一併關閉；可重複呼叫
 func (bm *BrowserManager) Shutdown() {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+	if bm.closed {
+		return
+	}
+	bm.closed = true
 	log.Printf("[cdpkit] 關閉瀏覽器管理器")
-	if bm.cancel != nil {
-		bm.cancel()
+	st := bm.state.Load()
+	if st != nil {
+		bm.collectCrash(st, "shutdown", true)
+		st.cancel(&TabInvalidatedError{Reason: "已關閉", Generation: st.generation})
 	}
 }
 
-func (bm *BrowserManager) DecrementTabCount() {
-	bm.mu.Lock()
-	if bm.tabCount > 0 {
-		bm.tabCount--
-		log.Printf("[cdpkit] 關閉分頁 (剩餘: %d)", bm.tabCount)
+// ChromePID 回傳 Exec 模式啟動的 Chrome 主行程 PID；Remote 模式或尚未啟動時為 0
+func (bm *BrowserManager) ChromePID() int {
+	st := bm.state.Load()
+	if st == nil || st.proc == nil {
+		return 0
 	}
-	bm.mu.Unlock()
+	return st.proc.pid()
 }
 
+// DecrementTabCount 保留相容性，分頁計數已改由 context 生命週期自動管理
+//
+// Deprecated: 取消 NewPageContext 回傳的 context 即可釋放分頁。
+func (bm *BrowserManager) DecrementTabCount() {}
+
 // restart：Remote 模式 → 重新連線；Exec 模式 → 整個重啟 Chrome
+// 呼叫端須持有 bm.mu。舊狀態衍生的分頁會收到 TabInvalidatedError，
+// 新狀態建立失敗時保留已取消的舊狀態，下次 NewPageContext 會再次嘗試
 func (bm *BrowserManager) restart() error {
+	if bm.closed {
+		return ErrClosed
+	}
+	old := bm.state.Load()
+	if old.external {
+		return fmt.Errorf("外部提供的瀏覽器無法重置")
+	}
 	log.Printf("[cdpkit] 重置瀏覽器開始...")
-	bm.cancel()
-	time.Sleep(time.Second)
+	if old.diag != nil && (old.allocCtx.Err() != nil || !processAlive(old.proc.pid())) {
+		// Chrome 非經由我們關閉而結束，收集日誌與傾印供事後分析
+		time.Sleep(crashDumpDelay)
+		bm.collectCrash(old, "browser", false)
+	}
+	old.cancel(&TabInvalidatedError{Reason: "已重置", Generation: old.generation})
+	time.Sleep(restartDelay)
 
+	var st *browserState
+	var err error
 	if bm.cfg.WebSocketURL == "" {
 		// Exec 模式重建
 		log.Printf("[cdpkit] 重新啟動 Chrome...")
-		m, err := newExecManager(bm.cfg)
-		if err != nil {
-			return err
-		}
-		*bm = *m
+		st, err = launchExec(bm.cfg, old.generation+1)
 	} else {
 		// Remote 模式重連
 		log.Printf("[cdpkit] 重新連接 Chrome: %s", bm.cfg.WebSocketURL)
-		m, err := newRemoteManager(bm.cfg)
-		if err != nil {
-			return err
-		}
-		*bm = *m
+		st, err = connectRemote(bm.cfg, old.generation+1)
+	}
+	if err != nil {
+		return err
 	}
+
+	bm.state.Store(st)
 	bm.tabCount = 0
-	log.Printf("[cdpkit] 瀏覽器重置完成")
+	bm.restarts++
+	bm.restartedAt = time.Now()
+	log.Printf("[cdpkit] 瀏覽器重置完成 (第 %d 代)", st.generation)
 	return nil
 }
 
 // ----------------- 內部輔助 -----------------
 
+// restartDelay 重置時關閉舊瀏覽器後、建立新狀態前的等待，讓舊的連線與行程完全結束
+var restartDelay = time.Second
+
+// mode 回傳 Stats 與 Health 的 Mode
+func (st *browserState) mode() string {
+	switch {
+	case st.external:
+		return "external"
+	case st.proc != nil:
+		return "exec"
+	}
+	return "remote"
+}
+
 func defaultTabLimit(n int) int {
 	if n <= 0 {
 		return 50
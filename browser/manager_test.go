@@ -0,0 +1,249 @@
+package browser
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/firehourse/cdpkit/config"
+)
+
+// newTestManager 建立 Remote 模式的管理器；chromedp 在第一次執行動作時才連線，
+// 只建立與取消分頁 context 不需要真正的 Chrome
+func newTestManager(t *testing.T, tabLimit int) *BrowserManager {
+	t.Helper()
+	restartDelay = 0
+	bm, err := newRemoteManager(config.Config{
+		WebSocketURL: "ws://127.0.0.1:1/devtools/browser/test",
+		TabLimit:     tabLimit,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bm
+}
+
+// forceRestart 以與 newPageContext 相同的方式持有 mu 重置瀏覽器
+func forceRestart(t *testing.T, bm *BrowserManager) {
+	t.Helper()
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+	if err := bm.restart(); err != nil {
+		t.Error(err)
+	}
+}
+
+// assertInvalidated 確認 ctx 已因瀏覽器重置或關閉而結束
+func assertInvalidated(t *testing.T, ctx context.Context, generation uint64) {
+	t.Helper()
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("分頁 context 未結束")
+	}
+	err := InvalidationCause(ctx)
+	if !errors.Is(err, ErrTabInvalidated) {
+		t.Fatalf("InvalidationCause = %v，應為 ErrTabInvalidated", err)
+	}
+	if !errors.Is(context.Cause(ctx), ErrTabInvalidated) {
+		t.Fatalf("context.Cause = %v，應為 ErrTabInvalidated", context.Cause(ctx))
+	}
+	var tie *TabInvalidatedError
+	if errors.As(err, &tie) && generation != 0 && tie.Generation != generation {
+		t.Fatalf("失效的世代為 %d，應為 %d", tie.Generation, generation)
+	}
+}
+
+// waitTabs 等待分頁計數降到 want；計數由背景 goroutine 在 context 結束後釋放
+func waitTabs(t *testing.T, bm *BrowserManager, want int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for bm.ActiveTabs() != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("分頁數為 %d，應為 %d", bm.ActiveTabs(), want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRestartInvalidatesOldTabs(t *testing.T) {
+	bm := newTestManager(t, 10)
+	defer bm.Shutdown()
+
+	var olds []context.CancelFunc
+	var ctxs []context.Context
+	for i := 0; i < 3; i++ {
+		ctx, cancel, err := bm.NewPageContext()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctxs, olds = append(ctxs, ctx), append(olds, cancel)
+	}
+	if n := bm.ActiveTabs(); n != 3 {
+		t.Fatalf("分頁數為 %d，應為 3", n)
+	}
+
+	forceRestart(t, bm)
+	for _, ctx := range ctxs {
+		assertInvalidated(t, ctx, 1)
+	}
+	if st := bm.Stats(); st.ActiveTabs != 0 || st.Generation != 2 || st.Restarts != 1 {
+		t.Fatalf("重置後的統計 %+v", st)
+	}
+
+	ctx, cancel, err := bm.NewPageContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if InvalidationCause(ctx) != nil {
+		t.Fatal("新世代的分頁不應失效")
+	}
+	// 取消舊世代的分頁不影響新世代的計數
+	for _, c := range olds {
+		c()
+	}
+	waitTabs(t, bm, 1)
+	cancel()
+	waitTabs(t, bm, 0)
+}
+
+func TestTabLimitTriggersRestart(t *testing.T) {
+	bm := newTestManager(t, 2)
+	defer bm.Shutdown()
+
+	first, cancel1, _ := bm.NewPageContext()
+	defer cancel1()
+	_, cancel2, _ := bm.NewPageContext()
+	defer cancel2()
+	_, cancel3, err := bm.NewPageContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancel3()
+
+	assertInvalidated(t, first, 1)
+	if st := bm.Stats(); st.ActiveTabs != 1 || st.Generation != 2 {
+		t.Fatalf("超過上限後的統計 %+v", st)
+	}
+}
+
+func TestShutdownInvalidatesTabs(t *testing.T) {
+	bm := newTestManager(t, 10)
+	ctx, cancel, err := bm.NewPageContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancel()
+
+	bm.Shutdown()
+	assertInvalidated(t, ctx, 1)
+	var tie *TabInvalidatedError
+	if !errors.As(InvalidationCause(ctx), &tie) || tie.Reason != "已關閉" {
+		t.Fatalf("失效原因 %v，應為已關閉", InvalidationCause(ctx))
+	}
+	waitTabs(t, bm, 0)
+}
+
+// Shutdown 後不得再建立分頁，也不得因連線已中斷而重置出新的瀏覽器
+func TestNewPageContextFailsAfterShutdown(t *testing.T) {
+	bm := newTestManager(t, 10)
+	bm.Shutdown()
+
+	if _, _, err := bm.NewPageContext(); !errors.Is(err, ErrClosed) {
+		t.Fatalf("NewPageContext 回傳 %v，應為 ErrClosed", err)
+	}
+	bm.mu.Lock()
+	err := bm.restart()
+	bm.mu.Unlock()
+	if !errors.Is(err, ErrClosed) {
+		t.Fatalf("restart 回傳 %v，應為 ErrClosed", err)
+	}
+	if st := bm.Stats(); st.Restarts != 0 || st.Generation != 1 || st.TabsCreated != 0 {
+		t.Fatalf("關閉後的統計 %+v", st)
+	}
+	bm.Shutdown()
+}
+
+// 與重置同時進行的 Shutdown 結束後，留下的狀態（包括重置新建立的）都已關閉
+func TestShutdownDuringRestart(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		bm := newTestManager(t, 10)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			bm.mu.Lock()
+			defer bm.mu.Unlock()
+			if err := bm.restart(); err != nil && !errors.Is(err, ErrClosed) {
+				t.Error(err)
+			}
+		}()
+		bm.Shutdown()
+		<-done
+		if st := bm.state.Load(); st.allocCtx.Err() == nil {
+			t.Fatalf("第 %d 次：Shutdown 後仍有第 %d 代的瀏覽器狀態存活", i, st.generation)
+		}
+	}
+}
+
+// TestConcurrentTabsAndRestarts 以 go test -race 檢查分頁建立、重置與關閉同時進行時的狀態替換與計數
+func TestConcurrentTabsAndRestarts(t *testing.T) {
+	bm := newTestManager(t, 5)
+
+	var mu sync.Mutex
+	var live []context.Context
+	var cancels []context.CancelFunc
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				ctx, cancel, err := bm.NewPageContext()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if (w+i)%2 == 0 {
+					cancel()
+					continue
+				}
+				mu.Lock()
+				live, cancels = append(live, ctx), append(cancels, cancel)
+				mu.Unlock()
+			}
+		}(w)
+	}
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			forceRestart(t, bm)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			st := bm.Stats()
+			if st.ActiveTabs < 0 || st.ActiveTabs > st.TabLimit {
+				t.Errorf("分頁數 %d 超出範圍", st.ActiveTabs)
+			}
+			bm.ChromePID()
+		}
+	}()
+	wg.Wait()
+
+	bm.Shutdown()
+	for _, ctx := range live {
+		assertInvalidated(t, ctx, 0)
+	}
+	for _, cancel := range cancels {
+		cancel()
+	}
+	waitTabs(t, bm, 0)
+	if st := bm.Stats(); st.TabsCreated != 400 || st.Restarts < 20 {
+		t.Fatalf("統計 %+v", st)
+	}
+}
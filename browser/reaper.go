@@ -0,0 +1,136 @@
+package browser
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// chromeProcess 追蹤 Exec 模式啟動的 Chrome 主行程，
+// 讓啟動失敗或 Shutdown 時能連同 renderer 等子行程一併清除
+type chromeProcess struct {
+	mu  sync.Mutex
+	cmd *exec.Cmd
+	// cgroup 套用資源限制時建立的子 cgroup，終止後刪除
+	cgroup string
+}
+
+// attach 供 chromedp.ModifyCmdFunc 使用：記錄 cmd 並設定行程群組
+func (p *chromeProcess) attach(cmd *exec.Cmd) {
+	setProcessGroup(cmd)
+	p.mu.Lock()
+	p.cmd = cmd
+	p.mu.Unlock()
+}
+
+// pid 回傳 Chrome 主行程 PID；尚未啟動時回傳 0
+func (p *chromeProcess) pid() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cmd == nil || p.cmd.Process == nil {
+		return 0
+	}
+	return p.cmd.Process.Pid
+}
+
+// kill 終止 Chrome 主行程及其整個行程群組，可重複呼叫
+func (p *chromeProcess) kill() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cmd == nil || p.cmd.Process == nil {
+		return
+	}
+	// 失敗通常代表行程已由 chromedp 正常回收，無需處理
+	pid := p.cmd.Process.Pid
+	if err := killProcessTree(pid); err == nil {
+		log.Printf("[cdpkit] 已終止 Chrome 行程 %d", pid)
+	}
+	p.cmd = nil
+	if p.cgroup != "" {
+		go removeCgroup(p.cgroup)
+		p.cgroup = ""
+	}
+}
+
+// KillOrphans 終止所有以指定 remote-debugging-port 啟動的 Chrome，
+// 以及與其共用 user-data-dir 的 renderer/GPU 等子行程，回傳終止的行程數。
+// 依命令列比對，會連同不是由 cdpkit 啟動的 Chrome 一起終止，因此 BrowserManager 不會自動呼叫；
+// 僅在確定該 Port 專供 cdpkit 使用時（例如服務啟動前清除上次崩潰留下的行程）明確呼叫
+func KillOrphans(port int) (int, error) {
+	procs, err := listProcesses()
+	if err != nil {
+		return 0, fmt.Errorf("無法列出系統行程: %w", err)
+	}
+
+	portArg := fmt.Sprintf("--remote-debugging-port=%d", port)
+	self := os.Getpid()
+
+	// 1. 找出主行程與其 user-data-dir
+	targets := map[int]bool{}
+	var dataDirs []string
+	for _, p := range procs {
+		if p.pid == self || !hasArg(p.args, portArg) {
+			continue
+		}
+		targets[p.pid] = true
+		if dir := argValue(p.args, "--user-data-dir="); dir != "" {
+			dataDirs = append(dataDirs, "--user-data-dir="+dir)
+		}
+	}
+
+	// 2. 共用 user-data-dir 的子行程也一併清除
+	for _, p := range procs {
+		if p.pid == self || targets[p.pid] {
+			continue
+		}
+		for _, dir := range dataDirs {
+			if hasArg(p.args, dir) {
+				targets[p.pid] = true
+				break
+			}
+		}
+	}
+
+	killed := 0
+	for pid := range targets {
+		if err := killProcess(pid); err != nil {
+			log.Printf("[cdpkit] 警告：終止孤兒行程 %d 失敗: %v", pid, err)
+			continue
+		}
+		killed++
+	}
+	if killed > 0 {
+		log.Printf("[cdpkit] 已清除 %d 個 Port=%d 的殘留 Chrome 行程", killed, port)
+	}
+	return killed, nil
+}
+
+// ----------------- 內部輔助 -----------------
+
+type procInfo struct {
+	pid  int
+	args string
+}
+
+// hasArg 檢查命令列是否含有完整的參數（避免 9222 誤中 92220）
+func hasArg(args, arg string) bool {
+	for _, f := range strings.Fields(args) {
+		if f == arg {
+			return true
+		}
+	}
+	return false
+}
+
+// argValue 取出 prefix 開頭參數的值
+func argValue(args, prefix string) string {
+	for _, f := range strings.Fields(args) {
+		if strings.HasPrefix(f, prefix) {
+			return strings.TrimPrefix(f, prefix)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,16 @@
+//go:build linux
+
+package browser
+
+import (
+	"os"
+	"syscall"
+)
+
+func setParentDeathSignal(attr *syscall.SysProcAttr) {
+	// AWS Lambda 不支援 Pdeathsig，與 chromedp 的預設行為一致
+	if _, ok := os.LookupEnv("LAMBDA_TASK_ROOT"); ok {
+		return
+	}
+	attr.Pdeathsig = syscall.SIGKILL
+}
@@ -0,0 +1,7 @@
+//go:build !linux && !windows
+
+package browser
+
+import "syscall"
+
+func setParentDeathSignal(attr *syscall.SysProcAttr) {}
@@ -0,0 +1,96 @@
+//go:build !windows
+
+package browser
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// setProcessGroup 讓 Chrome 自成一個行程群組，方便整組終止；
+// Linux 上另外保留 chromedp 預設的 Pdeathsig，Go 行程結束時 Chrome 一併結束
+func setProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+	setParentDeathSignal(cmd.SysProcAttr)
+}
+
+// killProcessTree 以 SIGKILL 終止整個行程群組
+func killProcessTree(pid int) error {
+	if err := syscall.Kill(-pid, syscall.SIGKILL); err != nil {
+		return syscall.Kill(pid, syscall.SIGKILL)
+	}
+	return nil
+}
+
+func killProcess(pid int) error {
+	return syscall.Kill(pid, syscall.SIGKILL)
+}
+
+// listProcesses Linux 直接讀 /proc，其他 Unix 則透過 ps
+func listProcesses() ([]procInfo, error) {
+	if runtime.GOOS == "linux" {
+		return listProcFS()
+	}
+
+	out, err := exec.Command("ps", "-axww", "-o", "pid=,args=").Output()
+	if err != nil {
+		return nil, err
+	}
+	var procs []procInfo
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		pidStr, args, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+		pid, err := strconv.Atoi(pidStr)
+		if err != nil {
+			continue
+		}
+		procs = append(procs, procInfo{pid: pid, args: args})
+	}
+	return procs, sc.Err()
+}
+
+func listProcFS() ([]procInfo, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+	var procs []procInfo
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		raw, err := os.ReadFile(filepath.Join("/proc", e.Name(), "cmdline"))
+		if err != nil || len(raw) == 0 {
+			continue
+		}
+		args := strings.ReplaceAll(strings.TrimRight(string(raw), "\x00"), "\x00", " ")
+		procs = append(procs, procInfo{pid: pid, args: args})
+	}
+	return procs, nil
+}
+
+// processAlive 以 signal 0 檢查行程是否存在
+func processAlive(pid int) bool {
+	return syscall.Kill(pid, 0) == nil
+}
+
+// setPriority 調整整個行程群組的 nice 值
+func setPriority(pgid, nice int) error {
+	return syscall.Setpriority(syscall.PRIO_PGRP, pgid, nice)
+}
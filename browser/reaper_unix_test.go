@@ -0,0 +1,71 @@
+//go:build !windows
+
+package browser
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/firehourse/cdpkit/config"
+)
+
+// 啟動失敗時只清除本次啟動的 Chrome 與其行程群組，
+// 同一個調試埠上不是由 cdpkit 啟動的 Chrome 不受影響
+func TestLaunchFailureKeepsForeignChrome(t *testing.T) {
+	const port = 39222
+	dir := t.TempDir()
+
+	// 使用者自己開的 Chrome：命令列帶有相同的調試埠
+	foreign := exec.Command("sh", "-c", "sleep 30; :", "chrome", fmt.Sprintf("--remote-debugging-port=%d", port))
+	if err := foreign.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		foreign.Process.Kill()
+		foreign.Wait()
+	}()
+
+	// 假的 Chrome：留下一個背景子行程（相當於 renderer）後即結束，讓啟動失敗
+	childFile := filepath.Join(dir, "child.pid")
+	fake := filepath.Join(dir, "chrome")
+	script := fmt.Sprintf("#!/bin/sh\nsleep 30 >/dev/null 2>&1 </dev/null &\necho $! > %s\nexit 1\n", childFile)
+	if err := os.WriteFile(fake, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := launchExec(config.Config{ChromePath: fake, RemotePort: port}, 1); err == nil {
+		t.Fatal("假的 Chrome 應啟動失敗")
+	}
+
+	b, err := os.ReadFile(childFile)
+	if err != nil {
+		t.Fatalf("假的 Chrome 未執行: %v", err)
+	}
+	child, _ := strconv.Atoi(strings.TrimSpace(string(b)))
+	if !waitGone(child, 2*time.Second) {
+		t.Errorf("本次啟動留下的子行程 %d 未被清除", child)
+	}
+	if waitGone(foreign.Process.Pid, 200*time.Millisecond) {
+		t.Error("同一個調試埠上的其他 Chrome 被終止")
+	}
+}
+
+// waitGone 等待行程結束；已結束但未回收的行程視為結束
+func waitGone(pid int, timeout time.Duration) bool {
+	for deadline := time.Now().Add(timeout); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+		if err != nil && !processAlive(pid) {
+			return true
+		}
+		if err == nil && strings.Contains(string(stat), ") Z ") {
+			return true
+		}
+	}
+	return false
+}
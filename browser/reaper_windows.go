@@ -0,0 +1,58 @@
+//go:build windows
+
+package browser
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessTree 透過 taskkill /T 連同子行程一起終止
+func killProcessTree(pid int) error {
+	return exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(pid)).Run()
+}
+
+func killProcess(pid int) error {
+	return exec.Command("taskkill", "/F", "/PID", strconv.Itoa(pid)).Run()
+}
+
+func listProcesses() ([]procInfo, error) {
+	out, err := exec.Command("powershell", "-NoProfile", "-Command",
+		`Get-CimInstance Win32_Process | ForEach-Object { "$($_.ProcessId) $($_.CommandLine)" }`).Output()
+	if err != nil {
+		return nil, err
+	}
+	var procs []procInfo
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		pidStr, args, _ := strings.Cut(strings.TrimSpace(sc.Text()), " ")
+		pid, err := strconv.Atoi(pidStr)
+		if err != nil {
+			continue
+		}
+		procs = append(procs, procInfo{pid: pid, args: args})
+	}
+	return procs, sc.Err()
+}
+
+// processAlive 能開啟行程代表仍存在
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	p.Release()
+	return true
+}
+
+func setPriority(pgid, nice int) error {
+	return errors.New("Windows 不支援 Nice")
+}
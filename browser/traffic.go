@@ -0,0 +1,71 @@
+package browser
+
+import (
+	"strings"
+
+	"github.com/chromedp/chromedp"
+	"github.com/firehourse/cdpkit/config"
+)
+
+// chromedpDisabledFeatures chromedp.DefaultExecAllocatorOptions 預設的 disable-features，
+// 另外設定 disable-features 時會取代它，因此須一併帶上
+const chromedpDisabledFeatures = "site-per-process,Translate,BlinkGenPropertyTrees"
+
+// minimalTrafficFlags 關閉與目標頁面無關的背景連線：元件與安全瀏覽清單更新、網域可靠性回報、
+// 同步、DNS 預先解析與超連結稽核 ping
+var minimalTrafficFlags = map[string]interface{}{
+	"disable-background-networking":                      true,
+	"disable-component-update":                           true,
+	"disable-component-extensions-with-background-pages": true,
+	"disable-domain-reliability":                         true,
+	"disable-sync":                                       true,
+	"disable-default-apps":                               true,
+	"disable-client-side-phishing-detection":             true,
+	"safebrowsing-disable-auto-update":                   true,
+	"dns-prefetch-disable":                               true,
+	"no-pings":                                           true,
+	"metrics-recording-only":                             true,
+}
+
+// minimalTrafficFeatures 一併停用的功能：翻譯、最佳化提示與模型下載、投放裝置探索、自動填入伺服器、
+// 憑證透明度清單更新、內容推薦、網路時間查詢，以及頁面 speculation rules 觸發的預先渲染
+var minimalTrafficFeatures = []string{
+	"Translate",
+	"OptimizationHints",
+	"OptimizationGuideModelDownloading",
+	"MediaRouter",
+	"DialMediaRouteProvider",
+	"AutofillServerCommunication",
+	"CertificateTransparencyComponentUpdater",
+	"InterestFeedContentSuggestions",
+	"NetworkTimeServiceQuerying",
+	"Prerender2",
+}
+
+// trafficFlags cfg.MinimalTraffic 時的 Chrome 旗標；使用者自訂的同名旗標優先，
+// disable-features 則與 chromedp 預設值及使用者的設定合併
+func trafficFlags(cfg config.Config) []chromedp.ExecAllocatorOption {
+	if !cfg.MinimalTraffic {
+		return nil
+	}
+	var opts []chromedp.ExecAllocatorOption
+	for k, v := range minimalTrafficFlags {
+		if _, ok := cfg.Flags[k]; !ok {
+			opts = append(opts, chromedp.Flag(k, v))
+		}
+	}
+
+	features := chromedpDisabledFeatures
+	if user, ok := cfg.Flags["disable-features"].(string); ok && user != "" {
+		features = user
+	}
+	seen := map[string]bool{}
+	var merged []string
+	for _, f := range append(strings.Split(features, ","), minimalTrafficFeatures...) {
+		if f = strings.TrimSpace(f); f != "" && !seen[f] {
+			seen[f] = true
+			merged = append(merged, f)
+		}
+	}
+	return append(opts, chromedp.Flag("disable-features", strings.Join(merged, ",")))
+}
@@ -0,0 +1,25 @@
+package browser
+
+import (
+	"github.com/chromedp/chromedp"
+	"github.com/firehourse/cdpkit/config"
+)
+
+// webrtcFlag Chrome 的 WebRTC IP 處理政策旗標
+const webrtcFlag = "force-webrtc-ip-handling-policy"
+
+// webrtcFlags 依 cfg.WebRTC 產生的 Chrome 旗標；WebRTCDisabled 在旗標層面等同 WebRTCProxyOnly，
+// API 由分頁的初始化腳本移除。使用者已自訂同名旗標時不覆寫
+func webrtcFlags(cfg config.Config) []chromedp.ExecAllocatorOption {
+	if cfg.WebRTC == config.WebRTCDefault {
+		return nil
+	}
+	if _, ok := cfg.Flags[webrtcFlag]; ok {
+		return nil
+	}
+	policy := cfg.WebRTC
+	if policy == config.WebRTCDisabled {
+		policy = config.WebRTCProxyOnly
+	}
+	return []chromedp.ExecAllocatorOption{chromedp.Flag(webrtcFlag, string(policy))}
+}
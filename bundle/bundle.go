@@ -0,0 +1,253 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const (
+	// ManifestName 封存檔中的清單檔名
+	ManifestName = "MANIFEST.json"
+	// SignatureName 清單的 ed25519 簽章（base64）
+	SignatureName = "MANIFEST.sig"
+)
+
+// ErrBadSignature 簽章驗證失敗
+var ErrBadSignature = errors.New("bundle: 簽章驗證失敗")
+
+// FileEntry 清單中單一檔案的雜湊資訊
+type FileEntry struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Manifest 封存檔清單，列出所有檔案的內容雜湊
+type Manifest struct {
+	Tool string `json:"tool"`
+	// JobID 對應的爬取工作
+	JobID string `json:"job_id,omitempty"`
+	// CreatedAt 為零值時不輸出，確保相同內容產生位元組完全相同的封存檔
+	CreatedAt *time.Time        `json:"created_at,omitempty"`
+	Meta      map[string]string `json:"meta,omitempty"`
+	Files     []FileEntry       `json:"files"`
+}
+
+// Builder 累積要打包的檔案；輸出順序與時間戳固定，相同輸入必得相同輸出
+type Builder struct {
+	JobID     string
+	CreatedAt time.Time
+	// Meta 額外的描述資訊，例如 extractor 版本
+	Meta map[string]string
+
+	files map[string][]byte
+}
+
+// NewBuilder 建立空的 Builder
+func NewBuilder(jobID string) *Builder {
+	return &Builder{JobID: jobID, Meta: map[string]string{}, files: map[string][]byte{}}
+}
+
+// AddBytes 加入檔案內容；同名檔案會被覆寫
+func (b *Builder) AddBytes(name string, data []byte) {
+	b.files[path.Clean(filepath.ToSlash(name))] = data
+}
+
+// AddJSON 以縮排 JSON 加入任意值
+func (b *Builder) AddJSON(name string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化 %s 失敗: %w", name, err)
+	}
+	b.AddBytes(name, data)
+	return nil
+}
+
+// AddFile 加入磁碟上的檔案
+func (b *Builder) AddFile(name, filePath string) error {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("無法讀取 %s: %w", filePath, err)
+	}
+	b.AddBytes(name, data)
+	return nil
+}
+
+// AddDir 遞迴加入目錄內所有檔案，封存路徑為 prefix/相對路徑
+func (b *Builder) AddDir(prefix, dir string) error {
+	return filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		return b.AddFile(path.Join(prefix, filepath.ToSlash(rel)), p)
+	})
+}
+
+// Manifest 依目前內容計算清單
+func (b *Builder) Manifest() Manifest {
+	m := Manifest{Tool: "cdpkit", JobID: b.JobID}
+	if !b.CreatedAt.IsZero() {
+		t := b.CreatedAt.UTC()
+		m.CreatedAt = &t
+	}
+	if len(b.Meta) > 0 {
+		m.Meta = b.Meta
+	}
+	for _, name := range b.names() {
+		data := b.files[name]
+		sum := sha256.Sum256(data)
+		m.Files = append(m.Files, FileEntry{Name: name, Size: int64(len(data)), SHA256: hex.EncodeToString(sum[:])})
+	}
+	return m
+}
+
+// WriteTarGz 輸出 tar.gz；key 不為 nil 時附上清單簽章
+func (b *Builder) WriteTarGz(w io.Writer, key ed25519.PrivateKey) error {
+	manifest, err := json.MarshalIndent(b.Manifest(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// gzip 標頭不帶檔名與時間，確保可重現
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	if err := writeTarEntry(tw, ManifestName, manifest); err != nil {
+		return err
+	}
+	if key != nil {
+		sig := base64.StdEncoding.EncodeToString(ed25519.Sign(key, manifest))
+		if err := writeTarEntry(tw, SignatureName, []byte(sig)); err != nil {
+			return err
+		}
+	}
+	for _, name := range b.names() {
+		if err := writeTarEntry(tw, name, b.files[name]); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// WriteFile 將封存檔寫入路徑
+func (b *Builder) WriteFile(filePath string, key ed25519.PrivateKey) error {
+	var buf bytes.Buffer
+	if err := b.WriteTarGz(&buf, key); err != nil {
+		return fmt.Errorf("打包失敗: %w", err)
+	}
+	if err := os.WriteFile(filePath, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("寫入封存檔 %s 失敗: %w", filePath, err)
+	}
+	return nil
+}
+
+// Verify 讀取封存檔並驗證簽章與每個檔案的雜湊；pub 為 nil 時只驗證雜湊
+func Verify(r io.Reader, pub ed25519.PublicKey) (*Manifest, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("無法解壓縮封存檔: %w", err)
+	}
+	tr := tar.NewReader(gz)
+
+	var manifestRaw, sig []byte
+	files := map[string][]byte{}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("讀取封存檔失敗: %w", err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		switch hdr.Name {
+		case ManifestName:
+			manifestRaw = data
+		case SignatureName:
+			sig = data
+		default:
+			files[hdr.Name] = data
+		}
+	}
+	if manifestRaw == nil {
+		return nil, fmt.Errorf("封存檔缺少 %s", ManifestName)
+	}
+
+	if pub != nil {
+		raw, err := base64.StdEncoding.DecodeString(string(sig))
+		if err != nil || !ed25519.Verify(pub, manifestRaw, raw) {
+			return nil, ErrBadSignature
+		}
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(manifestRaw, &m); err != nil {
+		return nil, fmt.Errorf("無法解析清單: %w", err)
+	}
+	if len(m.Files) != len(files) {
+		return nil, fmt.Errorf("清單列出 %d 個檔案，封存檔內有 %d 個", len(m.Files), len(files))
+	}
+	for _, f := range m.Files {
+		data, ok := files[f.Name]
+		if !ok {
+			return nil, fmt.Errorf("封存檔缺少 %s", f.Name)
+		}
+		sum := sha256.Sum256(data)
+		if hex.EncodeToString(sum[:]) != f.SHA256 {
+			return nil, fmt.Errorf("檔案 %s 的雜湊不符", f.Name)
+		}
+	}
+	return &m, nil
+}
+
+// ----------------- 內部輔助 -----------------
+
+func (b *Builder) names() []string {
+	names := make([]string, 0, len(b.files))
+	for name := range b.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func writeTarEntry(tw *tar.Writer, name string, data []byte) error {
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: time.Unix(0, 0).UTC(),
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("寫入 %s 標頭失敗: %w", name, err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("寫入 %s 失敗: %w", name, err)
+	}
+	return nil
+}
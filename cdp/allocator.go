@@ -9,6 +9,11 @@ import (
 
 // NewRemoteAllocator 連線至已啟動的 Chrome Remote Debugger
 func NewRemoteAllocator(wsURL string) (context.Context, context.CancelFunc, error) {
-	ctx, cancel := chromedp.NewRemoteAllocator(context.Background(), wsURL)
+	return NewRemoteAllocatorContext(context.Background(), wsURL)
+}
+
+// NewRemoteAllocatorContext 同 NewRemoteAllocator，但由呼叫端提供父 context
+func NewRemoteAllocatorContext(parent context.Context, wsURL string) (context.Context, context.CancelFunc, error) {
+	ctx, cancel := chromedp.NewRemoteAllocator(parent, wsURL)
 	return ctx, cancel, nil
 }
@@ -0,0 +1,162 @@
+// Package cdpkit 提供以分層設定建立瀏覽器與分頁的進入點：
+//
+//	b, err := cdpkit.Launch(config.Layered{
+//		Browser: config.Browser{Flags: config.SafeDefaults()},
+//		Tab:     config.Tab{Locale: "de-DE"},
+//		Request: config.Request{Timeout: 30 * time.Second},
+//	})
+//	defer b.Close()
+//
+//	t, err := b.NewTab(cdpkit.TabOptions{Tab: config.Tab{Device: "iPhone 14"}})
+//	defer t.Close()
+//
+// 需要各自的 cookies、權限或代理時，先以 NewContext 建立 browser context，再由其開啟分頁：
+//
+//	jobCtx, err := b.NewContext(browser.ContextOptions{Proxy: "http://tenant-a.proxy:8080"})
+//	defer jobCtx.Close()
+//	t, err := jobCtx.NewTab(cdpkit.TabOptions{})
+//
+// 已用 chromedp 管理 Chrome 的程式可用 FromAllocator、FromContext 與 Adopt 逐步改用 cdpkit：
+//
+//	allocCtx, cancel := chromedp.NewExecAllocator(ctx, myOpts...)
+//	defer cancel()
+//	b, err := cdpkit.FromAllocator(allocCtx, config.Layered{Tab: config.Tab{Locale: "de-DE"}})
+//
+//	t := cdpkit.Adopt(myTabCtx, config.Layered{}) // 既有的 chromedp 分頁
+//
+// 瀏覽器層在 Launch 時決定；分頁層與請求層為每個分頁的預設值，可由 TabOptions 逐欄覆寫。
+// 舊的 browser.NewManagerFromConfig、tab.NewTab 與 Tab.Close(mgr) 仍可使用，
+// 已有的 BrowserManager 可用 Wrap 取得相同的介面。
+package cdpkit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/firehourse/cdpkit/browser"
+	"github.com/firehourse/cdpkit/config"
+	"github.com/firehourse/cdpkit/tab"
+)
+
+// Browser 包裝 BrowserManager 與其分層設定；BrowserManager 的方法皆可直接呼叫
+type Browser struct {
+	*browser.BrowserManager
+	layers config.Layered
+}
+
+// TabOptions NewTab 的選項；Tab、Request 中的非零欄位覆寫 Browser 的預設
+type TabOptions struct {
+	Tab     config.Tab
+	Request config.Request
+	// Isolated 為 true 時分頁位於獨立的 browser context，cookies 與快取不與其他分頁共用；Remote 模式不支援
+	Isolated bool
+}
+
+// Context Browser.NewContext 建立的 browser context；BrowserContext 的方法皆可直接呼叫
+type Context struct {
+	*browser.BrowserContext
+	b *Browser
+}
+
+// Tab NewTab 建立的分頁；Close 不需傳入 BrowserManager，可直接作為 io.Closer
+type Tab struct {
+	*tab.Tab
+	once sync.Once
+}
+
+// Launch 依分層設定連接或啟動 Chrome
+func Launch(layers config.Layered) (*Browser, error) {
+	bm, err := browser.NewManagerFromConfig(layers.Flatten())
+	if err != nil {
+		return nil, err
+	}
+	return &Browser{BrowserManager: bm, layers: layers}, nil
+}
+
+// Wrap 以既有的 BrowserManager 建立 Browser，layers 的分頁層與請求層作為分頁的預設值
+func Wrap(bm *browser.BrowserManager, layers config.Layered) *Browser {
+	return &Browser{BrowserManager: bm, layers: layers}
+}
+
+// FromAllocator 以呼叫端的 chromedp allocator 建立 Browser，layers 只使用瀏覽器層的 TabLimit，
+// 分頁層與請求層作為分頁的預設值；見 browser.NewManagerFromAllocator
+func FromAllocator(allocCtx context.Context, layers config.Layered) (*Browser, error) {
+	bm, err := browser.NewManagerFromAllocator(allocCtx, layers.Flatten())
+	if err != nil {
+		return nil, err
+	}
+	return &Browser{BrowserManager: bm, layers: layers}, nil
+}
+
+// FromContext 以呼叫端的 chromedp context 建立 Browser，分頁開在同一個瀏覽器中；
+// layers 的用法同 FromAllocator，見 browser.NewManagerFromContext
+func FromContext(browserCtx context.Context, layers config.Layered) (*Browser, error) {
+	bm, err := browser.NewManagerFromContext(browserCtx, layers.Flatten())
+	if err != nil {
+		return nil, err
+	}
+	return &Browser{BrowserManager: bm, layers: layers}, nil
+}
+
+// Adopt 以分頁層與請求層設定包裝呼叫端以 chromedp 建立的分頁；不計入任何 Browser 的分頁數，
+// Close 只釋放包裝，分頁仍由呼叫端關閉
+func Adopt(tabCtx context.Context, layers config.Layered) *Tab {
+	return &Tab{Tab: tab.Open(tabCtx, nil, layers)}
+}
+
+// Layers 回傳建立 Browser 時的分層設定
+func (b *Browser) Layers() config.Layered {
+	return b.layers
+}
+
+// NewTab 開啟新分頁並套用分頁層設定（UA、viewport、反檢測、時區語系、合規防護）
+func (b *Browser) NewTab(opts TabOptions) (*Tab, error) {
+	newPage := b.NewPageContext
+	if opts.Isolated {
+		newPage = b.NewIsolatedPageContext
+	}
+	return b.openTab(newPage, opts)
+}
+
+// NewContext 建立共用 cookies、快取、權限與代理的 browser context，用完須呼叫 Close；Remote 模式不支援
+func (b *Browser) NewContext(opts browser.ContextOptions) (*Context, error) {
+	bc, err := b.BrowserManager.NewContext(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Context{BrowserContext: bc, b: b}, nil
+}
+
+// NewTab 在此 context 中開啟新分頁，分頁層設定同 Browser.NewTab；TabOptions.Isolated 不適用
+func (c *Context) NewTab(opts TabOptions) (*Tab, error) {
+	if opts.Isolated {
+		return nil, fmt.Errorf("browser context 中的分頁不能再設定 Isolated")
+	}
+	return c.b.openTab(c.NewPageContext, opts)
+}
+
+// Close 關閉瀏覽器；Launch 自行啟動的 Chrome 會一併結束，FromContext 時只關閉經由 Browser 開啟的分頁
+func (b *Browser) Close() error {
+	b.Shutdown()
+	return nil
+}
+
+// Close 關閉分頁並釋放分頁計數，重複呼叫無作用
+func (t *Tab) Close() error {
+	t.once.Do(func() { t.Tab.Close(nil) })
+	return nil
+}
+
+// ----------------- 內部實作 -----------------
+
+// openTab 以 newPage 建立分頁 context，套用 Browser 的預設與 opts 的覆寫
+func (b *Browser) openTab(newPage func() (context.Context, context.CancelFunc, error), opts TabOptions) (*Tab, error) {
+	ctx, cancel, err := newPage()
+	if err != nil {
+		return nil, fmt.Errorf("創建分頁失敗: %w", err)
+	}
+	defaults := config.Layered{Tab: b.layers.Tab, Request: b.layers.Request}.Flatten()
+	cfg := config.Layered{Tab: opts.Tab, Request: opts.Request}.Apply(defaults)
+	return &Tab{Tab: tab.Open(ctx, cancel, config.Split(cfg))}, nil
+}
@@ -0,0 +1,294 @@
+// cdpkitd 的 gRPC 介面：以 cdpkit 的瀏覽器池提供渲染服務，非 Go 的服務可由此檔產生用戶端。
+// 修改後在 cdpkitpb 目錄執行 go generate 重新產生 Go 程式碼。
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: cdpkitd.proto
+
+package cdpkitpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Renderer_SubmitJob_FullMethodName     = "/cdpkit.v1.Renderer/SubmitJob"
+	Renderer_GetResult_FullMethodName     = "/cdpkit.v1.Renderer/GetResult"
+	Renderer_StreamResults_FullMethodName = "/cdpkit.v1.Renderer/StreamResults"
+	Renderer_Screenshot_FullMethodName    = "/cdpkit.v1.Renderer/Screenshot"
+	Renderer_RenderPDF_FullMethodName     = "/cdpkit.v1.Renderer/RenderPDF"
+)
+
+// RendererClient is the client API for Renderer service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Renderer 遠端渲染服務
+type RendererClient interface {
+	// SubmitJob 將一批網址排入爬取佇列並立即回傳工作 ID；結果以 GetResult 或 StreamResults 取得
+	SubmitJob(ctx context.Context, in *SubmitJobRequest, opts ...grpc.CallOption) (*SubmitJobResponse, error)
+	// GetResult 回傳工作目前的狀態與已完成的結果；工作不存在（或已過保留期限）時回傳 NOT_FOUND
+	GetResult(ctx context.Context, in *GetResultRequest, opts ...grpc.CallOption) (*JobResult, error)
+	// StreamResults 依完成順序串流工作的結果，包含呼叫前已完成的部分；工作完成時結束
+	StreamResults(ctx context.Context, in *StreamResultsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[PageResult], error)
+	// Screenshot 開啟網址並擷取 PNG 截圖，不經過爬取佇列
+	Screenshot(ctx context.Context, in *ScreenshotRequest, opts ...grpc.CallOption) (*ScreenshotResponse, error)
+	// RenderPDF 開啟網址並輸出 PDF，不經過爬取佇列
+	RenderPDF(ctx context.Context, in *RenderPDFRequest, opts ...grpc.CallOption) (*RenderPDFResponse, error)
+}
+
+type rendererClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRendererClient(cc grpc.ClientConnInterface) RendererClient {
+	return &rendererClient{cc}
+}
+
+func (c *rendererClient) SubmitJob(ctx context.Context, in *SubmitJobRequest, opts ...grpc.CallOption) (*SubmitJobResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SubmitJobResponse)
+	err := c.cc.Invoke(ctx, Renderer_SubmitJob_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *rendererClient) GetResult(ctx context.Context, in *GetResultRequest, opts ...grpc.CallOption) (*JobResult, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(JobResult)
+	err := c.cc.Invoke(ctx, Renderer_GetResult_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *rendererClient) StreamResults(ctx context.Context, in *StreamResultsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[PageResult], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Renderer_ServiceDesc.Streams[0], Renderer_StreamResults_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamResultsRequest, PageResult]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Renderer_StreamResultsClient = grpc.ServerStreamingClient[PageResult]
+
+func (c *rendererClient) Screenshot(ctx context.Context, in *ScreenshotRequest, opts ...grpc.CallOption) (*ScreenshotResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ScreenshotResponse)
+	err := c.cc.Invoke(ctx, Renderer_Screenshot_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *rendererClient) RenderPDF(ctx context.Context, in *RenderPDFRequest, opts ...grpc.CallOption) (*RenderPDFResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RenderPDFResponse)
+	err := c.cc.Invoke(ctx, Renderer_RenderPDF_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RendererServer is the server API for Renderer service.
+// All implementations must embed UnimplementedRendererServer
+// for forward compatibility.
+//
+// Renderer 遠端渲染服務
+type RendererServer interface {
+	// SubmitJob 將一批網址排入爬取佇列並立即回傳工作 ID；結果以 GetResult 或 StreamResults 取得
+	SubmitJob(context.Context, *SubmitJobRequest) (*SubmitJobResponse, error)
+	// GetResult 回傳工作目前的狀態與已完成的結果；工作不存在（或已過保留期限）時回傳 NOT_FOUND
+	GetResult(context.Context, *GetResultRequest) (*JobResult, error)
+	// StreamResults 依完成順序串流工作的結果，包含呼叫前已完成的部分；工作完成時結束
+	StreamResults(*StreamResultsRequest, grpc.ServerStreamingServer[PageResult]) error
+	// Screenshot 開啟網址並擷取 PNG 截圖，不經過爬取佇列
+	Screenshot(context.Context, *ScreenshotRequest) (*ScreenshotResponse, error)
+	// RenderPDF 開啟網址並輸出 PDF，不經過爬取佇列
+	RenderPDF(context.Context, *RenderPDFRequest) (*RenderPDFResponse, error)
+	mustEmbedUnimplementedRendererServer()
+}
+
+// UnimplementedRendererServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedRendererServer struct{}
+
+func (UnimplementedRendererServer) SubmitJob(context.Context, *SubmitJobRequest) (*SubmitJobResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SubmitJob not implemented")
+}
+func (UnimplementedRendererServer) GetResult(context.Context, *GetResultRequest) (*JobResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetResult not implemented")
+}
+func (UnimplementedRendererServer) StreamResults(*StreamResultsRequest, grpc.ServerStreamingServer[PageResult]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamResults not implemented")
+}
+func (UnimplementedRendererServer) Screenshot(context.Context, *ScreenshotRequest) (*ScreenshotResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Screenshot not implemented")
+}
+func (UnimplementedRendererServer) RenderPDF(context.Context, *RenderPDFRequest) (*RenderPDFResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RenderPDF not implemented")
+}
+func (UnimplementedRendererServer) mustEmbedUnimplementedRendererServer() {}
+func (UnimplementedRendererServer) testEmbeddedByValue()                  {}
+
+// UnsafeRendererServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RendererServer will
+// result in compilation errors.
+type UnsafeRendererServer interface {
+	mustEmbedUnimplementedRendererServer()
+}
+
+func RegisterRendererServer(s grpc.ServiceRegistrar, srv RendererServer) {
+	// If the following call pancis, it indicates UnimplementedRendererServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Renderer_ServiceDesc, srv)
+}
+
+func _Renderer_SubmitJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SubmitJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RendererServer).SubmitJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Renderer_SubmitJob_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RendererServer).SubmitJob(ctx, req.(*SubmitJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Renderer_GetResult_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetResultRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RendererServer).GetResult(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Renderer_GetResult_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RendererServer).GetResult(ctx, req.(*GetResultRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Renderer_StreamResults_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamResultsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RendererServer).StreamResults(m, &grpc.GenericServerStream[StreamResultsRequest, PageResult]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Renderer_StreamResultsServer = grpc.ServerStreamingServer[PageResult]
+
+func _Renderer_Screenshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ScreenshotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RendererServer).Screenshot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Renderer_Screenshot_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RendererServer).Screenshot(ctx, req.(*ScreenshotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Renderer_RenderPDF_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RenderPDFRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RendererServer).RenderPDF(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Renderer_RenderPDF_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RendererServer).RenderPDF(ctx, req.(*RenderPDFRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Renderer_ServiceDesc is the grpc.ServiceDesc for Renderer service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Renderer_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "cdpkit.v1.Renderer",
+	HandlerType: (*RendererServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SubmitJob",
+			Handler:    _Renderer_SubmitJob_Handler,
+		},
+		{
+			MethodName: "GetResult",
+			Handler:    _Renderer_GetResult_Handler,
+		},
+		{
+			MethodName: "Screenshot",
+			Handler:    _Renderer_Screenshot_Handler,
+		},
+		{
+			MethodName: "RenderPDF",
+			Handler:    _Renderer_RenderPDF_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamResults",
+			Handler:       _Renderer_StreamResults_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "cdpkitd.proto",
+}
@@ -0,0 +1,5 @@
+// Package cdpkitpb cdpkitd 的 gRPC 介面（cdpkitd.proto）產生的 Go 程式碼，
+// 供 Go 用戶端呼叫 cdpkitd，其他語言請由 cdpkitd.proto 自行產生。
+package cdpkitpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative cdpkitd.proto
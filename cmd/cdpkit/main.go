@@ -0,0 +1,59 @@
+// cdpkit 命令列工具：
+//
+//	cdpkit repl [flags] [url]    開啟分頁並以互動指令操作，快速試驗選擇器與腳本
+//	cdpkit record -o <前綴> <url> 錄製頁面為離線 fixture 並產生擷取的 golden 檔，供 testkit.Run 使用
+//	cdpkit screenshot -from-sitemap <url> -out <目錄>  批次截取 sitemap 中所有頁面並產生 index.html 總覽
+//	cdpkit monitor -config monitor.yaml    依排程檢查網址的斷言，輸出 Prometheus 指標並以 webhook 告警
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+// command 子命令；run 接收子命令之後的參數，回傳行程結束碼
+type command struct {
+	summary string
+	run     func(args []string) int
+}
+
+var commands = map[string]command{
+	"repl":       {"互動式操作分頁（goto、click、eval、html、screenshot、cookies 等）", runREPL},
+	"monitor":    {"合成監控：定期檢查網址的狀態碼、元素、文字與載入時間", runMonitor},
+	"record":     {"錄製頁面 fixture 與擷取的 golden 檔，供黃金頁面回歸測試使用", runRecord},
+	"screenshot": {"批次截取網址清單或 sitemap 中的頁面，產生縮圖總覽", runScreenshot},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	name := os.Args[1]
+	if name == "help" || name == "-h" || name == "--help" {
+		usage()
+		return
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "未知的子命令: %s\n\n", name)
+		usage()
+		os.Exit(2)
+	}
+	os.Exit(cmd.run(os.Args[2:]))
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "用法: cdpkit <子命令> [參數]")
+	fmt.Fprintln(os.Stderr, "\n子命令:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", name, commands[name].summary)
+	}
+	fmt.Fprintln(os.Stderr, "\n執行 cdpkit <子命令> -h 查看各子命令的參數")
+}
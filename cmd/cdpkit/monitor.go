@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/firehourse/cdpkit/browser"
+	"github.com/firehourse/cdpkit/config"
+	"github.com/firehourse/cdpkit/monitor"
+)
+
+// runMonitor 依設定檔定期檢查網址並輸出指標與告警；-once 時每個檢查執行一次後依結果結束
+func runMonitor(args []string) int {
+	fs := flag.NewFlagSet("monitor", flag.ExitOnError)
+	configPath := fs.String("config", "monitor.yaml", "監控設定檔 (YAML 或 JSON)")
+	metrics := fs.String("metrics", "", "Prometheus 指標的監聽位址，例如 127.0.0.1:9336 (路徑為 /metrics)")
+	webhook := fs.String("webhook", "", "狀態改變時 POST 告警的網址 (取代設定檔的 webhook)")
+	once := fs.Bool("once", false, "每個檢查只執行一次，有任何失敗時以結束碼 1 結束，適合 CI 或 cron")
+	concurrency := fs.Int("c", 2, "同時進行的檢查數")
+	headless := fs.Bool("headless", true, "是否使用無頭模式")
+	wsURL := fs.String("ws", "", "連接既有 Chrome 的 WebSocket URL")
+	proxy := fs.String("proxy", "", "代理URL")
+	device := fs.String("device", "", "模擬的裝置名稱，例如 iPhone 14")
+	verbose := fs.Bool("v", false, "顯示 cdpkit 的日誌")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "用法: cdpkit monitor -config monitor.yaml [參數]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+	cfg, err := monitor.LoadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	flags := config.SafeDefaults()
+	flags["headless"] = *headless
+	browserCfg := config.Config{
+		WebSocketURL: *wsURL,
+		Proxy:        *proxy,
+		Device:       *device,
+		Flags:        flags,
+	}
+	bm, err := browser.NewManagerFromConfig(browserCfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "初始化瀏覽器失敗: %v\n", err)
+		return 1
+	}
+	defer bm.Shutdown()
+
+	m := monitor.New(bm, cfg, monitor.Options{
+		Layers:      config.Split(browserCfg),
+		Concurrency: *concurrency,
+		Webhook:     *webhook,
+		OnOutcome: func(o monitor.Outcome) {
+			status := "通過"
+			if !o.OK {
+				status = "失敗: " + strings.Join(o.Failures, "；")
+			}
+			fmt.Printf("%s %s (%v) %s\n", o.Time.Format("15:04:05"), o.Check, o.LoadTime.Round(time.Millisecond), status)
+		},
+	})
+
+	if *once {
+		failed := 0
+		for _, ch := range cfg.Checks {
+			if o := m.RunOnce(context.Background(), ch); !o.OK {
+				failed++
+			}
+		}
+		if failed > 0 {
+			return 1
+		}
+		return 0
+	}
+
+	if *metrics != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", m.MetricsHandler())
+		srv := &http.Server{Addr: *metrics, Handler: mux}
+		go func() {
+			if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				fmt.Fprintf(os.Stderr, "指標服務結束: %v\n", err)
+			}
+		}()
+		defer srv.Close()
+		fmt.Fprintf(os.Stderr, "指標: http://%s/metrics\n", *metrics)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	fmt.Fprintf(os.Stderr, "監控 %d 個檢查，按 Ctrl+C 結束\n", len(cfg.Checks))
+	m.Run(ctx)
+	return 0
+}
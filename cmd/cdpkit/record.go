@@ -0,0 +1,123 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/firehourse/cdpkit/browser"
+	"github.com/firehourse/cdpkit/config"
+	"github.com/firehourse/cdpkit/crawler"
+	"github.com/firehourse/cdpkit/tab"
+	"github.com/firehourse/cdpkit/testkit"
+)
+
+// runRecord 錄製頁面為 fixture，並以重播的結果產生 golden 檔
+func runRecord(args []string) int {
+	fs := flag.NewFlagSet("record", flag.ExitOnError)
+	out := fs.String("o", "", "輸出檔名前綴，例如 testdata/product 會寫出 product.fixture.json 與 product.golden.json")
+	js := fs.String("js", "", "擷取用的 JS 腳本檔")
+	extractPath := fs.String("extract", "", "宣告式擷取規則檔路徑 (取代 -js)")
+	headless := fs.Bool("headless", true, "是否使用無頭模式")
+	wsURL := fs.String("ws", "", "連接既有 Chrome 的 WebSocket URL")
+	proxy := fs.String("proxy", "", "代理URL")
+	idle := fs.Duration("idle", time.Second, "導航後等待網路閒置的時間")
+	timeout := fs.Duration("timeout", 30*time.Second, "導航與擷取的逾時")
+	verbose := fs.Bool("v", false, "顯示 cdpkit 的日誌")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "用法: cdpkit record -o <前綴> [-js 腳本 | -extract 規則] <url>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 || *out == "" {
+		fs.Usage()
+		return 2
+	}
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+
+	var extract testkit.Extractor
+	if *extractPath != "" {
+		spec, err := crawler.LoadExtractSpec(*extractPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		extract = testkit.Spec(spec)
+	} else if *js != "" {
+		b, err := os.ReadFile(*js)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "無法讀取腳本 %s: %v\n", *js, err)
+			return 1
+		}
+		extract = testkit.Script(string(b))
+	}
+
+	flags := config.SafeDefaults()
+	flags["headless"] = *headless
+	cfg := config.Config{
+		WebSocketURL: *wsURL,
+		Timeout:      *timeout,
+		Proxy:        *proxy,
+		Flags:        flags,
+	}
+	bm, err := browser.NewManagerFromConfig(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "初始化瀏覽器失敗: %v\n", err)
+		return 1
+	}
+	defer bm.Shutdown()
+
+	newTab := func() (*tab.Tab, error) {
+		ctx, cancel, err := bm.NewPageContext()
+		if err != nil {
+			return nil, fmt.Errorf("創建分頁失敗: %w", err)
+		}
+		return tab.Open(ctx, cancel, config.Split(cfg)), nil
+	}
+
+	liveTab, err := newTab()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	f, err := testkit.Record(liveTab, fs.Arg(0), testkit.RecordOptions{Idle: *idle, Timeout: *timeout})
+	liveTab.Close(bm)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fixturePath := *out + testkit.FixtureExt
+	if err := f.Save(fixturePath); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Printf("已錄製 %d 個資源到 %s\n", len(f.Resources), fixturePath)
+	if extract == nil {
+		return 0
+	}
+
+	// golden 以重播結果產生，確保 fixture 足以重現擷取
+	replayTab, err := newTab()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer replayTab.Close(bm)
+	data, err := testkit.Extract(replayTab, f, extract, *timeout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	goldenPath := *out + testkit.GoldenExt
+	if err := testkit.WriteGolden(goldenPath, data); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Printf("已寫入預期輸出到 %s，請檢查內容後提交\n", goldenPath)
+	return 0
+}
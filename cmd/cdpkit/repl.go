@@ -0,0 +1,460 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/firehourse/cdpkit/browser"
+	"github.com/firehourse/cdpkit/config"
+	"github.com/firehourse/cdpkit/liveview"
+	"github.com/firehourse/cdpkit/tab"
+)
+
+// errQuit 使用者輸入 quit
+var errQuit = errors.New("quit")
+
+// replHelp 指令說明；選擇器支援 CSS、XPath 與 ">>>" 穿透 shadow root
+const replHelp = `指令（含空白的參數請加引號）：
+  goto <url>                導航至網址
+  click <selector>          點擊元素
+  type <selector> <text>    在元素中輸入文字
+  press <key>               按下按鍵，例如 Enter、Tab
+  wait <selector>           等待元素出現
+  text <selector>           元素的文字
+  attr <selector> <name>    元素的屬性
+  count <selector>          符合的元素數量
+  eval <js>                 執行 JS 並以 JSON 顯示結果
+  html [selector]           整頁或元素的 HTML
+  screenshot [file] [full]  截圖，預設 screenshot-<n>.png
+  cookies                   目前頁面的 cookies
+  url                       目前網址
+  devtools [open]           以 DevTools 檢視此分頁的網址，open 時以系統瀏覽器開啟
+  handoff [message]         開啟有頭 Chrome 交由人工操作，按下完成後帶回 cookies 與網址
+  history                   已成功執行的指令
+  save <file>               將已成功執行的指令存成腳本，可用 -script 重播
+  help                      顯示此說明
+  quit                      結束
+行尾加上 \ 可延續到下一行。`
+
+// repl 互動式工作階段：所有指令共用同一個分頁，cookies、登入狀態與頁面狀態都會保留
+type repl struct {
+	bm  *browser.BrowserManager
+	cfg config.Config
+	tab *tab.Tab
+	// devtools 設定 -devtools 時的 DevTools 代理
+	devtools *browser.DevToolsProxy
+	out      io.Writer
+	history  []string
+	shots    int
+}
+
+func runREPL(args []string) int {
+	fs := flag.NewFlagSet("repl", flag.ExitOnError)
+	headless := fs.Bool("headless", true, "是否使用無頭模式")
+	wsURL := fs.String("ws", "", "連接既有 Chrome 的 WebSocket URL")
+	proxy := fs.String("proxy", "", "代理URL")
+	device := fs.String("device", "", "模擬的裝置名稱，例如 iPhone 14")
+	timeout := fs.Duration("timeout", 30*time.Second, "每個指令的逾時")
+	script := fs.String("script", "", "啟動後先執行的指令腳本（每行一個指令）")
+	verbose := fs.Bool("v", false, "顯示 cdpkit 的日誌")
+	devtools := fs.String("devtools", "", "DevTools 代理的監聽位址，例如 127.0.0.1:9333 (留空則直接連調試埠)")
+	live := fs.String("live", "", "即時畫面串流的監聽位址，例如 127.0.0.1:9334，可在瀏覽器觀看無頭分頁")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "用法: cdpkit repl [參數] [url]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+
+	flags := config.SafeDefaults()
+	flags["headless"] = *headless
+	cfg := config.Config{
+		WebSocketURL: *wsURL,
+		Timeout:      *timeout,
+		Proxy:        *proxy,
+		Device:       *device,
+		Flags:        flags,
+	}
+	bm, err := browser.NewManagerFromConfig(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "初始化瀏覽器失敗: %v\n", err)
+		return 1
+	}
+	defer bm.Shutdown()
+
+	ctx, cancel, err := bm.NewPageContext()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "創建分頁失敗: %v\n", err)
+		return 1
+	}
+	r := &repl{bm: bm, cfg: cfg, tab: tab.Open(ctx, cancel, config.Split(cfg)), out: os.Stdout}
+	defer r.tab.Close(bm)
+
+	if *devtools != "" {
+		p, err := bm.ServeDevTools(*devtools)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		defer p.Close()
+		r.devtools = p
+		fmt.Fprintf(r.out, "DevTools 代理: %s\n", p.URL())
+	}
+	if *live != "" {
+		s, err := liveview.Serve(*live)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		defer s.Close()
+		s.Add("repl", r.tab)
+		fmt.Fprintf(r.out, "即時畫面: %s\n", s.URL())
+	}
+
+	if *script != "" {
+		f, err := os.Open(*script)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "無法讀取腳本 %s: %v\n", *script, err)
+			return 1
+		}
+		err = r.loop(f, false)
+		f.Close()
+		if errors.Is(err, errQuit) {
+			return 0
+		}
+	}
+	if url := fs.Arg(0); url != "" {
+		r.exec("goto " + url)
+	}
+
+	fmt.Fprintln(r.out, "輸入 help 查看指令，quit 結束")
+	if err := r.loop(os.Stdin, true); err != nil && !errors.Is(err, errQuit) {
+		fmt.Fprintf(os.Stderr, "讀取輸入失敗: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// loop 逐行讀取並執行指令；interactive 為 true 時顯示提示字元
+func (r *repl) loop(in io.Reader, interactive bool) error {
+	sc := bufio.NewScanner(in)
+	sc.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	prompt := func(p string) {
+		if interactive {
+			fmt.Fprint(r.out, p)
+		}
+	}
+
+	var buf strings.Builder
+	prompt("cdpkit> ")
+	for sc.Scan() {
+		line := sc.Text()
+		if strings.HasSuffix(line, `\`) {
+			buf.WriteString(strings.TrimSuffix(line, `\`))
+			buf.WriteString("\n")
+			prompt("   ...> ")
+			continue
+		}
+		buf.WriteString(line)
+		cmd := strings.TrimSpace(buf.String())
+		buf.Reset()
+		if cmd != "" && !strings.HasPrefix(cmd, "#") {
+			if !interactive {
+				fmt.Fprintf(r.out, "cdpkit> %s\n", cmd)
+			}
+			if err := r.exec(cmd); errors.Is(err, errQuit) {
+				return err
+			}
+		}
+		prompt("cdpkit> ")
+	}
+	return sc.Err()
+}
+
+// exec 執行一個指令並顯示結果；成功的指令記入 history
+func (r *repl) exec(line string) error {
+	name, rest, _ := strings.Cut(line, " ")
+	name, rest = strings.ToLower(strings.TrimSpace(name)), strings.TrimSpace(rest)
+	var args []string
+	if name != "eval" && name != "js" {
+		// eval 的 JS 原樣傳入，不做引號處理
+		var err error
+		if args, err = splitArgs(rest); err != nil {
+			fmt.Fprintf(r.out, "錯誤: %v\n", err)
+			return err
+		}
+	}
+
+	start := time.Now()
+	err := r.dispatch(name, rest, args)
+	switch {
+	case errors.Is(err, errQuit):
+		return err
+	case err != nil:
+		fmt.Fprintf(r.out, "錯誤: %v\n", err)
+		return err
+	}
+	switch name {
+	case "help", "history", "save", "devtools":
+	default:
+		r.history = append(r.history, line)
+		if d := time.Since(start); d > time.Second {
+			fmt.Fprintf(r.out, "(%s)\n", d.Round(time.Millisecond))
+		}
+	}
+	return nil
+}
+
+func (r *repl) dispatch(name, rest string, args []string) error {
+	t := r.tab
+	need := func(n int, usage string) error {
+		if len(args) < n {
+			return fmt.Errorf("用法: %s", usage)
+		}
+		return nil
+	}
+
+	switch name {
+	case "goto", "open":
+		if err := need(1, "goto <url>"); err != nil {
+			return err
+		}
+		if err := t.Navigate(args[0], 0); err != nil {
+			return err
+		}
+		title, _ := t.RunJS("document.title", 0)
+		fmt.Fprintf(r.out, "%v\n", title)
+	case "click":
+		if err := need(1, "click <selector>"); err != nil {
+			return err
+		}
+		return t.Click(args[0])
+	case "type":
+		if err := need(2, "type <selector> <text>"); err != nil {
+			return err
+		}
+		return t.Type(args[0], strings.Join(args[1:], " "), 0)
+	case "press":
+		if err := need(1, "press <key>"); err != nil {
+			return err
+		}
+		return t.Press(args[0])
+	case "wait":
+		if err := need(1, "wait <selector>"); err != nil {
+			return err
+		}
+		return t.WaitVisible(args[0], 0)
+	case "text":
+		if err := need(1, "text <selector>"); err != nil {
+			return err
+		}
+		text, err := t.Text(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(r.out, text)
+	case "attr":
+		if err := need(2, "attr <selector> <name>"); err != nil {
+			return err
+		}
+		value, ok, err := t.Attribute(args[0], args[1])
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Fprintln(r.out, "(無此屬性)")
+			return nil
+		}
+		fmt.Fprintln(r.out, value)
+	case "count":
+		if err := need(1, "count <selector>"); err != nil {
+			return err
+		}
+		n, err := t.Count(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(r.out, n)
+	case "eval", "js":
+		if rest == "" {
+			return fmt.Errorf("用法: eval <js>")
+		}
+		v, err := t.RunJS(rest, 0)
+		if err != nil {
+			return err
+		}
+		return r.printJSON(v)
+	case "html":
+		var html string
+		var err error
+		if len(args) > 0 {
+			html, err = t.InnerHTML(args[0])
+		} else {
+			html, err = t.HTML(0)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(r.out, html)
+	case "screenshot", "shot":
+		return r.screenshot(args)
+	case "cookies":
+		cookies, err := t.Cookies()
+		if err != nil {
+			return err
+		}
+		if len(cookies) == 0 {
+			fmt.Fprintln(r.out, "(沒有 cookies)")
+		}
+		for _, c := range cookies {
+			fmt.Fprintf(r.out, "%s=%s\t%s%s\n", c.Name, c.Value, c.Domain, c.Path)
+		}
+	case "url":
+		v, err := t.RunJS("location.href", 0)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(r.out, v)
+	case "handoff":
+		fmt.Fprintln(r.out, "已開啟有頭 Chrome，完成後按下頁面底部的「完成」按鈕")
+		state, err := t.Handoff(tab.HandoffOptions{
+			Browser: config.Config{Proxy: r.cfg.Proxy, Device: r.cfg.Device, Timeout: r.cfg.Timeout},
+			Message: strings.Join(args, " "),
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(r.out, "%s (%d 個 cookies)\n", state.URL, len(state.Cookies))
+	case "devtools":
+		var u string
+		var err error
+		if r.devtools != nil {
+			u, err = r.devtools.TabURL(t.Ctx)
+		} else {
+			u, err = r.bm.DevToolsURL(t.Ctx)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(r.out, u)
+		if len(args) > 0 && args[0] == "open" {
+			return browser.OpenURL(u)
+		}
+	case "history":
+		for i, h := range r.history {
+			fmt.Fprintf(r.out, "%3d  %s\n", i+1, h)
+		}
+	case "save":
+		if err := need(1, "save <file>"); err != nil {
+			return err
+		}
+		// 多行指令以行尾的 \ 延續，讀回時與互動輸入相同
+		var data strings.Builder
+		for _, h := range r.history {
+			data.WriteString(strings.ReplaceAll(h, "\n", "\\\n") + "\n")
+		}
+		if err := os.WriteFile(args[0], []byte(data.String()), 0644); err != nil {
+			return err
+		}
+		fmt.Fprintf(r.out, "已儲存 %d 個指令至 %s\n", len(r.history), args[0])
+	case "help", "?":
+		fmt.Fprintln(r.out, replHelp)
+	case "quit", "exit":
+		return errQuit
+	default:
+		return fmt.Errorf("未知的指令 %q，輸入 help 查看指令", name)
+	}
+	return nil
+}
+
+// screenshot 參數可為檔名與 full（整頁），順序不拘
+func (r *repl) screenshot(args []string) error {
+	full := false
+	path := ""
+	for _, a := range args {
+		if a == "full" {
+			full = true
+		} else {
+			path = a
+		}
+	}
+	if path == "" {
+		r.shots++
+		path = fmt.Sprintf("screenshot-%d.png", r.shots)
+	}
+	png, err := r.tab.Screenshot(full)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, png, 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(r.out, "已儲存 %s (%d bytes)\n", path, len(png))
+	return nil
+}
+
+func (r *repl) printJSON(v interface{}) error {
+	if s, ok := v.(string); ok {
+		fmt.Fprintln(r.out, s)
+		return nil
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(r.out, string(data))
+	return nil
+}
+
+// splitArgs 以空白分隔參數；以單引號或雙引號開頭的參數到對應的引號為止，
+// 參數中間的引號照原樣保留（例如 input[name="q"]），反斜線跳脫下一個字元
+func splitArgs(s string) ([]string, error) {
+	var args []string
+	var cur strings.Builder
+	var quote rune
+	inArg, escaped := false, false
+	for _, c := range s {
+		switch {
+		case escaped:
+			cur.WriteRune(c)
+			escaped = false
+		case c == '\\' && quote != '\'':
+			escaped, inArg = true, true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(c)
+			}
+		case (c == '"' || c == '\'') && !inArg:
+			quote, inArg = c, true
+		case c == ' ' || c == '\t' || c == '\n':
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteRune(c)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("引號未閉合")
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/firehourse/cdpkit/crawler"
+	"github.com/firehourse/cdpkit/tab"
+)
+
+// shot 一個頁面的截圖結果，供索引頁使用
+type shot struct {
+	URL   string
+	Title string
+	File  string
+	Error string
+	// Status 主文件的 HTTP 狀態碼
+	Status  int
+	Elapsed time.Duration
+}
+
+// runScreenshot 批次截取網址清單或 sitemap 中每個頁面的整頁截圖，並產生 index.html 總覽
+func runScreenshot(args []string) int {
+	fs := flag.NewFlagSet("screenshot", flag.ExitOnError)
+	sitemap := fs.String("from-sitemap", "", "sitemap 或 sitemap index 的網址，截取其中所有頁面")
+	input := fs.String("from-file", "", "網址清單檔，每行一個網址")
+	out := fs.String("out", "screenshots", "輸出目錄")
+	viewport := fs.String("viewport", "1280x800", "視窗大小，寬x高")
+	device := fs.String("device", "", "模擬的裝置名稱，例如 iPhone 14 (取代 -viewport)")
+	concurrency := fs.Int("c", 4, "同時截圖的頁面數")
+	limit := fs.Int("limit", 0, "最多截取的頁面數，0 表示不限")
+	ready := fs.Bool("ready", true, "截圖前等待字型與圖片載入")
+	stopAnimations := fs.Bool("stop-animations", false, "截圖前停止 CSS 動畫與影片播放")
+	headless := fs.Bool("headless", true, "是否使用無頭模式")
+	proxy := fs.String("proxy", "", "代理URL")
+	timeout := fs.Duration("timeout", 60*time.Second, "每個頁面的逾時")
+	verbose := fs.Bool("v", false, "顯示 cdpkit 的日誌")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "用法: cdpkit screenshot [-from-sitemap 網址 | -from-file 檔案] [參數] [url...]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+	width, height, err := parseViewport(*viewport)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	urls := fs.Args()
+	if *input != "" {
+		list, err := readURLList(*input)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		urls = append(urls, list...)
+	}
+	if *sitemap != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		list, err := crawler.FetchSitemap(ctx, *sitemap, crawler.SitemapOptions{Limit: *limit})
+		cancel()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "sitemap 中有 %d 個網址\n", len(list))
+		urls = append(urls, list...)
+	}
+	if *limit > 0 && len(urls) > *limit {
+		urls = urls[:*limit]
+	}
+	if len(urls) == 0 {
+		fs.Usage()
+		return 2
+	}
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		fmt.Fprintf(os.Stderr, "無法建立輸出目錄: %v\n", err)
+		return 1
+	}
+
+	opts := crawler.DefaultOptions()
+	opts.Concurrency = *concurrency
+	opts.Timeout = *timeout
+	opts.Headless = *headless
+	opts.ProxyURL = *proxy
+	opts.WindowSize = [2]int{width, height}
+	opts.Device = *device
+	opts.Screenshot = true
+	opts.StopAnimations = *stopAnimations
+	if *ready {
+		opts.ScreenshotReady = &tab.ReadyOptions{}
+	}
+	if !*verbose {
+		opts.LogLevel = 0
+	}
+	c, err := crawler.New(opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "初始化爬蟲失敗: %v\n", err)
+		return 1
+	}
+	defer c.Close()
+
+	started := time.Now()
+	var (
+		mu    sync.Mutex
+		shots []shot
+	)
+	err = c.FetchAllFunc(urls, "", func(r crawler.Result) {
+		s := shot{URL: r.URL, Title: r.Title, Error: r.Error, Status: r.ResponseCode, Elapsed: r.ElapsedTime.Round(time.Millisecond)}
+		if len(r.Screenshot) > 0 {
+			s.File = shotName(r.URL)
+			if err := os.WriteFile(filepath.Join(*out, s.File), r.Screenshot, 0o644); err != nil {
+				s.File, s.Error = "", fmt.Sprintf("寫入截圖失敗: %v", err)
+			}
+		} else if s.Error == "" {
+			s.Error = "沒有截圖"
+		}
+
+		mu.Lock()
+		shots = append(shots, s)
+		n := len(shots)
+		mu.Unlock()
+		status := "完成"
+		if s.Error != "" {
+			status = "失敗: " + s.Error
+		}
+		fmt.Fprintf(os.Stderr, "[%d/%d] %s %s\n", n, len(urls), r.URL, status)
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	index := filepath.Join(*out, "index.html")
+	if err := writeShotIndex(index, shots, time.Since(started)); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	failed := 0
+	for _, s := range shots {
+		if s.Error != "" {
+			failed++
+		}
+	}
+	fmt.Printf("已截取 %d 個頁面，失敗 %d 個，總覽: %s\n", len(shots)-failed, failed, index)
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// parseViewport 解析 "1280x800" 格式的視窗大小
+func parseViewport(s string) (int, int, error) {
+	w, h, ok := strings.Cut(strings.ToLower(s), "x")
+	width, err1 := strconv.Atoi(strings.TrimSpace(w))
+	height, err2 := strconv.Atoi(strings.TrimSpace(h))
+	if !ok || err1 != nil || err2 != nil || width <= 0 || height <= 0 {
+		return 0, 0, fmt.Errorf("無效的視窗大小 %q，格式為 寬x高，例如 1280x800", s)
+	}
+	return width, height, nil
+}
+
+// readURLList 讀取每行一個網址的清單，略過空行與 # 開頭的註解
+func readURLList(path string) ([]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("無法讀取網址清單 %s: %w", path, err)
+	}
+	var urls []string
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			urls = append(urls, line)
+		}
+	}
+	return urls, nil
+}
+
+var unsafeNameRe = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// shotName 由網址產生易讀且不重複的檔名，例如 example.com-blog-post-1a2b3c4d.png
+func shotName(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	name := raw
+	if u, err := url.Parse(raw); err == nil {
+		name = u.Host + u.Path
+	}
+	name = strings.Trim(unsafeNameRe.ReplaceAllString(name, "-"), "-.")
+	if len(name) > 80 {
+		name = name[:80]
+	}
+	return name + "-" + hex.EncodeToString(sum[:4]) + ".png"
+}
+
+// writeShotIndex 寫出依網址排列的縮圖總覽，點擊縮圖開啟原圖
+func writeShotIndex(path string, shots []shot, elapsed time.Duration) error {
+	shots = append([]shot(nil), shots...)
+	// 失敗的排在前面，其餘依網址排序
+	sort.Slice(shots, func(i, j int) bool {
+		a, b := shots[i], shots[j]
+		if (a.Error != "") != (b.Error != "") {
+			return a.Error != ""
+		}
+		return a.URL < b.URL
+	})
+	failed := 0
+	for _, s := range shots {
+		if s.Error != "" {
+			failed++
+		}
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("無法建立總覽 %s: %w", path, err)
+	}
+	defer f.Close()
+	err = shotIndexTmpl.Execute(f, map[string]interface{}{
+		"Shots":     shots,
+		"Total":     len(shots),
+		"Failed":    failed,
+		"Elapsed":   elapsed.Round(time.Second),
+		"Generated": time.Now().Format("2006-01-02 15:04:05"),
+	})
+	if err != nil {
+		return fmt.Errorf("無法寫入總覽 %s: %w", path, err)
+	}
+	return nil
+}
+
+var shotIndexTmpl = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html lang="zh-Hant">
+<head>
+<meta charset="utf-8">
+<title>截圖總覽</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 24px; color: #222; }
+.summary { color: #555; margin-bottom: 16px; }
+.grid { display: grid; grid-template-columns: repeat(auto-fill, minmax(280px, 1fr)); gap: 16px; }
+.card { border: 1px solid #ddd; border-radius: 6px; overflow: hidden; background: #fff; }
+.card.failed { border-color: #d33; }
+.card img { display: block; width: 100%; height: 220px; object-fit: cover; object-position: top; border-bottom: 1px solid #eee; }
+.meta { padding: 8px 10px; font-size: 13px; word-break: break-all; }
+.meta .title { font-weight: 600; margin-bottom: 4px; }
+.meta .error { color: #d33; margin-top: 4px; }
+.meta .info { color: #777; margin-top: 4px; }
+</style>
+</head>
+<body>
+<h1>截圖總覽</h1>
+<div class="summary">共 {{.Total}} 個頁面，失敗 {{.Failed}} 個，耗時 {{.Elapsed}}，產生於 {{.Generated}}</div>
+<div class="grid">
+{{range .Shots}}<div class="card{{if .Error}} failed{{end}}">
+{{if .File}}<a href="{{.File}}" target="_blank"><img src="{{.File}}" loading="lazy" alt=""></a>{{end}}
+<div class="meta">
+<div class="title">{{if .Title}}{{.Title}}{{else}}(無標題){{end}}</div>
+<a href="{{.URL}}" target="_blank">{{.URL}}</a>
+<div class="info">{{if .Status}}HTTP {{.Status}}，{{end}}{{.Elapsed}}</div>
+{{if .Error}}<div class="error">{{.Error}}</div>{{end}}
+</div>
+</div>
+{{end}}</div>
+</body>
+</html>
+`))
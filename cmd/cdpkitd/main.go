@@ -0,0 +1,94 @@
+// cdpkitd 以 gRPC 提供 cdpkit 的渲染服務，讓非 Go 的服務把 cdpkit 當作無頭渲染的微服務使用：
+//
+//	cdpkitd -addr 127.0.0.1:50051 -concurrency 4
+//
+// 介面定義見 cdpkitpb/cdpkitd.proto：SubmitJob 將網址排入爬蟲的優先度佇列，結果以 GetResult 查詢
+// 或 StreamResults 串流取得；Screenshot 與 RenderPDF 直接開分頁處理，同時處理的數量同樣受 -concurrency 限制。
+// 所有請求共用同一個 Chrome。工作與結果只保存在記憶體，完成後保留 -job-ttl，重新啟動後即遺失。
+// 收到 SIGTERM 時停止接受新工作，在 -grace 內等待進行中的頁面完成後關閉。
+// 只接受 http 與 https 網址；預設只監聽本機，監聽其他位址時須設定 -token，
+// 請求須在 metadata 帶 authorization: Bearer <token>。
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net"
+	"time"
+
+	"github.com/firehourse/cdpkit"
+	"github.com/firehourse/cdpkit/cdpkitpb"
+	"github.com/firehourse/cdpkit/config"
+	"github.com/firehourse/cdpkit/crawler"
+	"github.com/firehourse/cdpkit/lifecycle"
+	"google.golang.org/grpc"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:50051", "gRPC 監聽位址")
+	token := flag.String("token", "", "要求請求的 metadata 帶有 authorization: Bearer <token> (監聽非本機位址時必填)")
+	wsURL := flag.String("ws", "", "連接既有 Chrome 的 WebSocket 網址 (留空則自行啟動)")
+	concurrency := flag.Int("concurrency", 4, "同時處理的網址數，Screenshot 與 RenderPDF 另計")
+	timeout := flag.Duration("timeout", 30*time.Second, "每個頁面的操作逾時")
+	tabLimit := flag.Int("tab-limit", 0, "累計開啟多少分頁後重置 Chrome (0 使用預設)")
+	saveHTML := flag.Bool("save-html", true, "結果是否包含完整 HTML")
+	jobTTL := flag.Duration("job-ttl", time.Hour, "工作完成後保留結果的時間")
+	grace := flag.Duration("grace", lifecycle.DefaultGrace, "收到 SIGTERM 後等待進行中頁面完成的寬限期")
+	logLevel := flag.Int("log-level", 2, "日誌級別 (0=無, 1=錯誤, 2=警告, 3=信息, 4=調試)")
+	flag.Parse()
+	if *token == "" && !isLoopback(*addr) {
+		log.Fatalf("監聽非本機位址 %s 時須設定 -token", *addr)
+	}
+
+	b, err := cdpkit.Launch(config.Layered{
+		Browser: config.Browser{WebSocketURL: *wsURL, Flags: config.SafeDefaults(), TabLimit: *tabLimit},
+		Request: config.Request{Timeout: *timeout},
+	})
+	if err != nil {
+		log.Fatalf("啟動瀏覽器失敗: %v", err)
+	}
+	c, err := crawler.New(crawler.Options{
+		Browser:     b.BrowserManager,
+		Concurrency: *concurrency,
+		Timeout:     *timeout,
+		SaveHTML:    *saveHTML,
+		LogLevel:    *logLevel,
+	})
+	if err != nil {
+		b.Close()
+		log.Fatalf("初始化爬蟲失敗: %v", err)
+	}
+
+	lis, err := net.Listen("tcp", *addr)
+	if err != nil {
+		c.Close()
+		b.Close()
+		log.Fatalf("無法監聽 %s: %v", *addr, err)
+	}
+	srv := newServer(b, c, *concurrency, *timeout, *jobTTL)
+	var opts []grpc.ServerOption
+	if *token != "" {
+		opts = tokenAuth(*token)
+	}
+	gs := grpc.NewServer(opts...)
+	cdpkitpb.RegisterRendererServer(gs, srv)
+
+	lc := lifecycle.New(*grace)
+	lc.OnShutdown("crawler", c.Drain)
+	lc.OnShutdown("grpc", srv.stop(gs))
+	lc.OnShutdown("browser", func(ctx context.Context) error {
+		c.Close()
+		return b.Close()
+	})
+	lc.Listen()
+
+	go srv.dispatch()
+	log.Printf("[cdpkit] cdpkitd 監聽 %s", lis.Addr())
+	if err := gs.Serve(lis); err != nil {
+		log.Fatalf("gRPC 服務結束: %v", err)
+	}
+	if err := lc.Wait(); err != nil {
+		log.Fatalf("關閉失敗: %v", err)
+	}
+}
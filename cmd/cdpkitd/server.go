@@ -0,0 +1,430 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/firehourse/cdpkit"
+	"github.com/firehourse/cdpkit/cdpkitpb"
+	"github.com/firehourse/cdpkit/config"
+	"github.com/firehourse/cdpkit/crawler"
+	"github.com/firehourse/cdpkit/tab"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// jobTag 結果的 Tags 中記錄所屬工作的鍵，回傳前移除
+const jobTag = "cdpkitd.job"
+
+// server 實作 cdpkitpb.RendererServer
+type server struct {
+	cdpkitpb.UnimplementedRendererServer
+
+	b       *cdpkit.Browser
+	c       *crawler.Crawler
+	timeout time.Duration
+	ttl     time.Duration
+	// render 限制同時處理的 Screenshot 與 RenderPDF
+	render chan struct{}
+	// wake SubmitJob 排入網址後通知 dispatch
+	wake chan struct{}
+	// closing 關閉時關閉，結束等待中的 StreamResults
+	closing chan struct{}
+
+	mu   sync.Mutex
+	jobs map[string]*job
+}
+
+// job 一次 SubmitJob 的狀態與結果，由 mu 保護
+type job struct {
+	id    string
+	total int
+
+	mu      sync.Mutex
+	results []*cdpkitpb.PageResult
+	// finished 已處理完畢的網址數；一個網址可能有多筆結果（翻頁）或沒有結果（被 Hooks 或 Transforms 捨棄）
+	finished int
+	// updated 有新結果時關閉並替換，供 StreamResults 等待
+	updated chan struct{}
+}
+
+func newServer(b *cdpkit.Browser, c *crawler.Crawler, concurrency int, timeout, ttl time.Duration) *server {
+	return &server{
+		b:       b,
+		c:       c,
+		timeout: timeout,
+		ttl:     ttl,
+		render:  make(chan struct{}, concurrency),
+		wake:    make(chan struct{}, 1),
+		closing: make(chan struct{}),
+		jobs:    map[string]*job{},
+	}
+}
+
+// dispatch 持續以爬蟲處理排入的網址，直到爬蟲開始關閉
+func (s *server) dispatch() {
+	for {
+		if err := s.c.Run("", s.deliver); err != nil {
+			return
+		}
+		select {
+		case <-s.wake:
+		case <-s.closing:
+			return
+		}
+	}
+}
+
+// stop 回傳 lifecycle 的關閉步驟：結束串流並等待進行中的 RPC，寬限期過後強制中斷
+func (s *server) stop(gs *grpc.Server) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		close(s.closing)
+		done := make(chan struct{})
+		go func() {
+			gs.GracefulStop()
+			close(done)
+		}()
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			gs.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *server) SubmitJob(ctx context.Context, req *cdpkitpb.SubmitJobRequest) (*cdpkitpb.SubmitJobResponse, error) {
+	if len(req.Urls) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "urls 不可為空")
+	}
+	for _, u := range req.Urls {
+		if err := checkURL(u); err != nil {
+			return nil, err
+		}
+	}
+	id, err := newJobID()
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	tags := make(map[string]string, len(req.Tags)+1)
+	for k, v := range req.Tags {
+		tags[k] = v
+	}
+	tags[jobTag] = id
+	j := &job{id: id, total: len(req.Urls), updated: make(chan struct{})}
+	meta := crawler.Meta{Script: req.Script, Tags: tags, Finished: func(ok bool) { s.finish(j, ok) }}
+	if req.Deadline != nil {
+		meta.Deadline = req.Deadline.AsTime()
+	}
+
+	s.mu.Lock()
+	s.jobs[id] = j
+	s.mu.Unlock()
+	for _, u := range req.Urls {
+		if err := s.c.Enqueue(u, crawler.Priority(req.Priority), meta); err != nil {
+			// 只會發生在關閉中，已排入的網址不會再被處理
+			s.mu.Lock()
+			delete(s.jobs, id)
+			s.mu.Unlock()
+			return nil, status.Error(codes.Unavailable, err.Error())
+		}
+	}
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return &cdpkitpb.SubmitJobResponse{JobId: id}, nil
+}
+
+func (s *server) GetResult(ctx context.Context, req *cdpkitpb.GetResultRequest) (*cdpkitpb.JobResult, error) {
+	j, err := s.job(req.JobId)
+	if err != nil {
+		return nil, err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	res := &cdpkitpb.JobResult{
+		JobId:     j.id,
+		State:     cdpkitpb.JobState_JOB_STATE_RUNNING,
+		Total:     int32(j.total),
+		Completed: int32(j.finished),
+		Results:   append([]*cdpkitpb.PageResult(nil), j.results...),
+	}
+	if j.done() {
+		res.State = cdpkitpb.JobState_JOB_STATE_DONE
+	}
+	return res, nil
+}
+
+func (s *server) StreamResults(req *cdpkitpb.StreamResultsRequest, stream grpc.ServerStreamingServer[cdpkitpb.PageResult]) error {
+	j, err := s.job(req.JobId)
+	if err != nil {
+		return err
+	}
+	sent := 0
+	for {
+		j.mu.Lock()
+		pending := j.results[sent:]
+		done := j.done()
+		updated := j.updated
+		j.mu.Unlock()
+
+		for _, r := range pending {
+			if err := stream.Send(r); err != nil {
+				return err
+			}
+		}
+		sent += len(pending)
+		if done {
+			return nil
+		}
+		select {
+		case <-updated:
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-s.closing:
+			return status.Error(codes.Unavailable, "cdpkitd 正在關閉")
+		}
+	}
+}
+
+func (s *server) Screenshot(ctx context.Context, req *cdpkitpb.ScreenshotRequest) (*cdpkitpb.ScreenshotResponse, error) {
+	var png []byte
+	err := s.withPage(ctx, req.Url, req.WaitSelector, req.Timeout, config.Tab{Device: req.Device}, func(t *cdpkit.Tab) (err error) {
+		png, err = t.Screenshot(req.FullPage)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &cdpkitpb.ScreenshotResponse{Png: png}, nil
+}
+
+func (s *server) RenderPDF(ctx context.Context, req *cdpkitpb.RenderPDFRequest) (*cdpkitpb.RenderPDFResponse, error) {
+	var pdf []byte
+	err := s.withPage(ctx, req.Url, req.WaitSelector, req.Timeout, config.Tab{}, func(t *cdpkit.Tab) (err error) {
+		pdf, err = t.PDF(tab.PDFOptions{
+			Landscape:       req.Landscape,
+			PrintBackground: req.PrintBackground,
+			PaperWidth:      req.PaperWidth,
+			PaperHeight:     req.PaperHeight,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &cdpkitpb.RenderPDFResponse{Pdf: pdf}, nil
+}
+
+// ----------------- 內部實作 -----------------
+
+// deliver 將爬蟲的結果交給所屬的工作
+func (s *server) deliver(r crawler.Result) {
+	j, err := s.job(r.Tags[jobTag])
+	if err != nil {
+		return
+	}
+	pr := toPageResult(j.id, r)
+	j.mu.Lock()
+	j.results = append(j.results, pr)
+	j.notify()
+	j.mu.Unlock()
+}
+
+// finish 一個網址處理完畢；所有網址都完成後保留 ttl 再移除工作。
+// 因關閉而中斷的網址不計入，工作維持執行中
+func (s *server) finish(j *job, ok bool) {
+	if !ok {
+		return
+	}
+	j.mu.Lock()
+	j.finished++
+	j.notify()
+	done := j.done()
+	j.mu.Unlock()
+	if done {
+		time.AfterFunc(s.ttl, func() {
+			s.mu.Lock()
+			delete(s.jobs, j.id)
+			s.mu.Unlock()
+		})
+	}
+}
+
+func (s *server) job(id string) (*job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, ok := s.jobs[id]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "找不到工作 %q", id)
+	}
+	return j, nil
+}
+
+// done 每個網址都已處理完畢；呼叫端須持有 j.mu
+func (j *job) done() bool {
+	return j.finished >= j.total
+}
+
+// notify 喚醒等待中的 StreamResults；呼叫端須持有 j.mu
+func (j *job) notify() {
+	close(j.updated)
+	j.updated = make(chan struct{})
+}
+
+// withPage 開新分頁載入網址後執行 fn；RPC 取消或逾時時中斷進行中的操作
+func (s *server) withPage(ctx context.Context, url, waitSelector string, timeout *durationpb.Duration, tabCfg config.Tab, fn func(t *cdpkit.Tab) error) error {
+	if err := checkURL(url); err != nil {
+		return err
+	}
+	d := s.timeout
+	if timeout != nil && timeout.AsDuration() > 0 {
+		d = timeout.AsDuration()
+	}
+	ctx, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
+
+	select {
+	case s.render <- struct{}{}:
+		defer func() { <-s.render }()
+	case <-ctx.Done():
+		return status.FromContextError(ctx.Err()).Err()
+	case <-s.closing:
+		return status.Error(codes.Unavailable, "cdpkitd 正在關閉")
+	}
+
+	t, err := s.b.NewTab(cdpkit.TabOptions{Tab: tabCfg, Request: config.Request{Timeout: d}})
+	if err != nil {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	defer t.Close()
+	// 只取消分頁的 context，進行中的操作隨即失敗；Close 由上面的 defer 負責
+	stop := context.AfterFunc(ctx, t.Cancel)
+	defer stop()
+
+	err = t.Navigate(url, d)
+	if err == nil && waitSelector != "" {
+		err = t.WaitVisible(waitSelector, d)
+	}
+	if err == nil {
+		err = fn(t)
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return status.FromContextError(ctx.Err()).Err()
+		}
+		return status.Error(codes.Unknown, err.Error())
+	}
+	return nil
+}
+
+// checkURL 只接受完整的 http 或 https 網址，拒絕 file:、chrome: 等可讀取本機資料的網址
+func checkURL(raw string) error {
+	if raw == "" {
+		return status.Error(codes.InvalidArgument, "url 不可為空")
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return status.Errorf(codes.InvalidArgument, "url 須為完整的 http 或 https 網址: %q", raw)
+	}
+	return nil
+}
+
+// tokenAuth 回傳檢查 token 的 unary 與 stream 攔截器
+func tokenAuth(token string) []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			if err := checkToken(ctx, token); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.ChainStreamInterceptor(func(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := checkToken(ss.Context(), token); err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		}),
+	}
+}
+
+// checkToken 檢查 metadata 中的 authorization: Bearer <token>
+func checkToken(ctx context.Context, token string) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, v := range md.Get("authorization") {
+		got, ok := strings.CutPrefix(v, "Bearer ")
+		if ok && subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "token 不正確")
+}
+
+// isLoopback 監聽位址是否只接受本機連線；主機留空表示所有介面
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// toPageResult 將爬蟲結果轉為 gRPC 訊息，Tags 中移除 jobTag
+func toPageResult(jobID string, r crawler.Result) *cdpkitpb.PageResult {
+	pr := &cdpkitpb.PageResult{
+		JobId:        jobID,
+		Url:          r.URL,
+		Title:        r.Title,
+		Html:         r.HTML,
+		Error:        r.Error,
+		ResponseCode: int32(r.ResponseCode),
+		Elapsed:      durationpb.New(r.ElapsedTime),
+		Attempts:     int32(r.Attempts),
+		Timestamp:    timestamppb.New(r.Timestamp),
+	}
+	if len(r.Data) > 0 {
+		data, err := structpb.NewStruct(r.Data)
+		if err != nil && pr.Error == "" {
+			pr.Error = fmt.Sprintf("無法轉換擷取結果: %v", err)
+		} else if err == nil {
+			pr.Data = data
+		}
+	}
+	for k, v := range r.Tags {
+		if k == jobTag {
+			continue
+		}
+		if pr.Tags == nil {
+			pr.Tags = map[string]string{}
+		}
+		pr.Tags[k] = v
+	}
+	return pr
+}
+
+func newJobID() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
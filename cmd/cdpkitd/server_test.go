@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/firehourse/cdpkit/cdpkitpb"
+	"github.com/firehourse/cdpkit/crawler"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestCheckURL(t *testing.T) {
+	for _, tc := range []struct {
+		url string
+		ok  bool
+	}{
+		{"https://example.com/", true},
+		{"http://example.com:8080/a?b=1", true},
+		{"", false},
+		{"file:///etc/passwd", false},
+		{"chrome://settings", false},
+		{"javascript:alert(1)", false},
+		{"data:text/html,<h1>x</h1>", false},
+		{"example.com/path", false},
+		{"https:///no-host", false},
+	} {
+		err := checkURL(tc.url)
+		if tc.ok != (err == nil) {
+			t.Errorf("checkURL(%q) = %v", tc.url, err)
+		}
+		if err != nil && status.Code(err) != codes.InvalidArgument {
+			t.Errorf("checkURL(%q) 的狀態碼為 %v，應為 InvalidArgument", tc.url, status.Code(err))
+		}
+	}
+}
+
+// 網址不合法時整個工作被拒絕，不排入任何網址
+func TestSubmitJobRejectsLocalURLs(t *testing.T) {
+	s := newServer(nil, nil, 1, 0, 0)
+	_, err := s.SubmitJob(context.Background(), &cdpkitpb.SubmitJobRequest{Urls: []string{"https://example.com/", "file:///etc/passwd"}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("SubmitJob 回傳 %v，應為 InvalidArgument", err)
+	}
+	if len(s.jobs) != 0 {
+		t.Errorf("被拒絕的工作仍留在 jobs 中")
+	}
+	_, err = s.Screenshot(context.Background(), &cdpkitpb.ScreenshotRequest{Url: "chrome://version"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("Screenshot 回傳 %v，應為 InvalidArgument", err)
+	}
+}
+
+func TestIsLoopback(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"127.0.0.1:50051": true,
+		"localhost:50051": true,
+		"[::1]:50051":     true,
+		":50051":          false,
+		"0.0.0.0:50051":   false,
+		"10.0.0.5:50051":  false,
+		"50051":           false,
+	} {
+		if got := isLoopback(addr); got != want {
+			t.Errorf("isLoopback(%q) = %v", addr, got)
+		}
+	}
+}
+
+func TestCheckToken(t *testing.T) {
+	for _, tc := range []struct {
+		header string
+		code   codes.Code
+	}{
+		{"Bearer s3cret", codes.OK},
+		{"Bearer wrong", codes.Unauthenticated},
+		{"Bearer s3cret2", codes.Unauthenticated},
+		{"s3cret", codes.Unauthenticated},
+		{"", codes.Unauthenticated},
+	} {
+		ctx := context.Background()
+		if tc.header != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", tc.header))
+		}
+		if err := checkToken(ctx, "s3cret"); status.Code(err) != tc.code {
+			t.Errorf("authorization=%q 回傳 %v，應為 %v", tc.header, err, tc.code)
+		}
+	}
+}
+
+// 工作依網址是否處理完畢判斷完成，與結果筆數無關：翻頁可能產生多筆結果，
+// 被 Hooks 或 Transforms 捨棄的網址則沒有結果
+func TestJobDoneTracksFinishedURLs(t *testing.T) {
+	s := newServer(nil, nil, 1, 0, time.Hour)
+	j := &job{id: "j1", total: 2, updated: make(chan struct{})}
+	s.jobs[j.id] = j
+	state := func() (*cdpkitpb.JobResult, error) {
+		return s.GetResult(context.Background(), &cdpkitpb.GetResultRequest{JobId: j.id})
+	}
+	tags := map[string]string{jobTag: j.id}
+
+	// 第一個網址翻頁產生三筆結果
+	for page := 1; page <= 3; page++ {
+		s.deliver(crawler.Result{URL: "https://example.com/list", Page: page, Tags: tags})
+	}
+	res, err := state()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.State != cdpkitpb.JobState_JOB_STATE_RUNNING || res.Completed != 0 || len(res.Results) != 3 {
+		t.Fatalf("尚未完成任何網址時為 %v，completed=%d，%d 筆結果", res.State, res.Completed, len(res.Results))
+	}
+	s.finish(j, true)
+
+	// 第二個網址的結果被捨棄，沒有結果但仍處理完畢；中斷的網址不計入
+	s.finish(j, false)
+	if res, _ = state(); res.State != cdpkitpb.JobState_JOB_STATE_RUNNING || res.Completed != 1 {
+		t.Fatalf("一個網址完成時為 %v，completed=%d", res.State, res.Completed)
+	}
+	s.finish(j, true)
+	if res, _ = state(); res.State != cdpkitpb.JobState_JOB_STATE_DONE || res.Completed != 2 || len(res.Results) != 3 {
+		t.Fatalf("所有網址完成時為 %v，completed=%d，%d 筆結果", res.State, res.Completed, len(res.Results))
+	}
+}
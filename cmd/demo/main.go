@@ -5,9 +5,8 @@ import (
 	"log"
 	"time"
 
-	"github.com/firehourse/cdpkit/browser"
+	"github.com/firehourse/cdpkit"
 	"github.com/firehourse/cdpkit/config"
-	"github.com/firehourse/cdpkit/tab"
 )
 
 func main() {
@@ -15,58 +14,57 @@ func main() {
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
 	log.Println("cdpkit 示例程序啟動")
 
-	// 創建配置
-	cfg := config.Config{
-		// 留空時會自動查找或啟動 Chrome
-		WebSocketURL: "",
-
-		// 如果找不到系統 Chrome，可以指定路徑
-		// ChromePath: "/usr/bin/google-chrome",
-
-		// 遠程調試埠
-		RemotePort: 9222,
-
-		// 操作超時設置
-		Timeout: 60 * time.Second,
-
-		// 瀏覽器視窗大小 (若設為 [0,0] 則使用默認值)
-		WindowSize: [2]int{1280, 720},
-
-		// 用戶代理字符串 (若留空則隨機選擇)
-		UserAgent: "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/123.0.0.0 Safari/537.36",
+	// 創建分層配置：瀏覽器層在啟動時決定，分頁層與請求層為每個分頁的預設值
+	layers := config.Layered{
+		Browser: config.Browser{
+			// 留空時會自動查找或啟動 Chrome
+			WebSocketURL: "",
+
+			// 如果找不到系統 Chrome，可以指定路徑
+			// ChromePath: "/usr/bin/google-chrome",
+
+			// 遠程調試埠
+			RemotePort: 9222,
+
+			// Chrome 啟動選項
+			Flags: map[string]interface{}{
+				"headless":              true, // 無頭模式
+				"no-sandbox":            true, // 沙箱限制
+				"disable-gpu":           true, // 禁用 GPU 加速
+				"disable-dev-shm-usage": true, // 禁用 /dev/shm (低內存環境)
+			},
+		},
+		Tab: config.Tab{
+			// 瀏覽器視窗大小 (若設為 [0,0] 則使用默認值)
+			WindowSize: [2]int{1280, 720},
 
-		// Chrome 啟動選項
-		Flags: map[string]interface{}{
-			"headless":              true, // 無頭模式
-			"no-sandbox":            true, // 沙箱限制
-			"disable-gpu":           true, // 禁用 GPU 加速
-			"disable-dev-shm-usage": true, // 禁用 /dev/shm (低內存環境)
+			// 用戶代理字符串 (若留空則隨機選擇)
+			UserAgent: "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/123.0.0.0 Safari/537.36",
 		},
+		// 操作超時設置
+		Request: config.Request{Timeout: 60 * time.Second},
 	}
 
-	// 步驟 1: 建立瀏覽器管理器
-	log.Println("步驟 1: 初始化瀏覽器管理器")
-	bm, err := browser.NewManagerFromConfig(cfg)
+	// 步驟 1: 啟動瀏覽器
+	log.Println("步驟 1: 初始化瀏覽器")
+	b, err := cdpkit.Launch(layers)
 	if err != nil {
 		log.Fatalf("初始化失敗: %v", err)
 	}
 	defer func() {
 		log.Println("關閉瀏覽器")
-		bm.Shutdown()
+		b.Close()
 	}()
 
-	// 步驟 2: 創建一個新分頁 (使用 NewTab，會自動套用配置)
+	// 步驟 2: 創建一個新分頁，自動套用 UA、視窗和反檢測腳本
 	log.Println("步驟 2: 創建新分頁")
-	ctx, cancel, err := bm.NewPageContext()
+	pageTab, err := b.NewTab(cdpkit.TabOptions{})
 	if err != nil {
-		log.Fatalf("創建分頁失敗: %v", err)
+		log.Fatalf("%v", err)
 	}
-
-	// 使用新的 NewTab 方法自動設置 UA、視窗和注入腳本
-	pageTab := tab.NewTab(ctx, cancel, cfg)
 	defer func() {
 		log.Println("關閉分頁")
-		pageTab.Close(bm)
+		pageTab.Close()
 	}()
 
 	// 確保有足夠時間初始化
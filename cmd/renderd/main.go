@@ -0,0 +1,84 @@
+// renderd 以 HTTP 提供預渲染服務，供 SPA 網站在回應搜尋引擎爬蟲時改回傳渲染後的 HTML：
+//
+//	renderd -addr :3000 -cache-ttl 10m
+//
+//	GET /render?url=https://example.com/&wait=networkidle&format=html|png|pdf|mhtml
+//	GET /https://example.com/page?x=1    與 prerender.io 相容的形式，回傳 HTML
+//
+// /render 的參數：wait 為 load（預設，等待 load 事件）或 networkidle；selector 額外等待元素出現；
+// full_page=1 時 png 擷取整頁；ready=1 時 png、pdf 先等待網頁字型與圖片就緒。HTML 會移除 <script>（JSON-LD 除外），並依頁面中的
+// <meta name="prerender-status-code"> 與 <meta name="prerender-header"> 設定回應的狀態碼與標頭。
+// 設定 -cache-ttl 時以 rendercache 快取狀態碼 200 的結果，/cache 可查詢統計與清除快取；
+// 設定 -token 時請求須帶相同的 X-Prerender-Token 標頭或 token 參數。
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/firehourse/cdpkit"
+	"github.com/firehourse/cdpkit/config"
+	"github.com/firehourse/cdpkit/lifecycle"
+	"github.com/firehourse/cdpkit/rendercache"
+)
+
+func main() {
+	addr := flag.String("addr", ":3000", "HTTP 監聽位址")
+	wsURL := flag.String("ws", "", "連接既有 Chrome 的 WebSocket 網址 (留空則自行啟動)")
+	concurrency := flag.Int("concurrency", 4, "同時渲染的頁面數")
+	timeout := flag.Duration("timeout", 30*time.Second, "每個頁面的渲染逾時")
+	wait := flag.String("wait", "networkidle", "prerender.io 形式請求的等待條件 (load 或 networkidle)")
+	stripScripts := flag.Bool("strip-scripts", true, "回傳的 HTML 是否移除 <script> (JSON-LD 除外)")
+	cacheTTL := flag.Duration("cache-ttl", 0, "渲染結果的快取時間 (0 則不快取)")
+	cacheEntries := flag.Int("cache-entries", 1000, "快取的最大筆數")
+	token := flag.String("token", "", "要求請求帶有此 X-Prerender-Token (留空則不驗證)")
+	grace := flag.Duration("grace", lifecycle.DefaultGrace, "收到 SIGTERM 後等待進行中請求完成的寬限期")
+	flag.Parse()
+
+	if *wait != "load" && *wait != "networkidle" {
+		log.Fatalf("不支援的 -wait: %s", *wait)
+	}
+	b, err := cdpkit.Launch(config.Layered{
+		Browser: config.Browser{WebSocketURL: *wsURL, Flags: config.SafeDefaults()},
+		Request: config.Request{Timeout: *timeout},
+	})
+	if err != nil {
+		log.Fatalf("啟動瀏覽器失敗: %v", err)
+	}
+
+	r := &renderer{
+		b:            b,
+		timeout:      *timeout,
+		wait:         *wait,
+		stripScripts: *stripScripts,
+		token:        *token,
+		slots:        make(chan struct{}, *concurrency),
+	}
+	mux := http.NewServeMux()
+	if *cacheTTL > 0 {
+		r.cache = rendercache.New(*cacheTTL, *cacheEntries)
+		mux.Handle("/cache", r.authorize(r.cache.Handler()))
+	}
+	mux.Handle("/render", r.authorize(http.HandlerFunc(r.serveRender)))
+	mux.Handle("/healthz", b.LivenessHandler())
+	mux.Handle("/readyz", b.ReadinessHandler())
+	srv := &http.Server{Addr: *addr, Handler: r.prerender(mux), ReadHeaderTimeout: 10 * time.Second}
+
+	lc := lifecycle.New(*grace)
+	lc.OnShutdown("http", srv.Shutdown)
+	lc.OnShutdown("browser", func(ctx context.Context) error { return b.Close() })
+	lc.Listen()
+
+	log.Printf("[cdpkit] renderd 監聽 %s", *addr)
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		b.Close()
+		log.Fatalf("HTTP 服務結束: %v", err)
+	}
+	if err := lc.Wait(); err != nil {
+		log.Fatalf("關閉失敗: %v", err)
+	}
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/firehourse/cdpkit"
+	"github.com/firehourse/cdpkit/rendercache"
+	"github.com/firehourse/cdpkit/tab"
+)
+
+// contentTypes 各輸出格式的 Content-Type
+var contentTypes = map[string]string{
+	"html":  "text/html; charset=utf-8",
+	"png":   "image/png",
+	"pdf":   "application/pdf",
+	"mhtml": "multipart/related",
+}
+
+// renderOptions 影響輸出的選項，同時作為快取鍵的一部分
+type renderOptions struct {
+	Format   string `json:"format"`
+	Wait     string `json:"wait"`
+	Selector string `json:"selector,omitempty"`
+	FullPage bool   `json:"full_page,omitempty"`
+	Ready    bool   `json:"ready,omitempty"`
+}
+
+// renderer 處理渲染請求
+type renderer struct {
+	b            *cdpkit.Browser
+	cache        *rendercache.Cache
+	timeout      time.Duration
+	wait         string
+	stripScripts bool
+	token        string
+	// slots 限制同時渲染的頁面數
+	slots chan struct{}
+}
+
+// page 一次渲染的結果；狀態碼不是 200 或帶有自訂標頭時不寫入快取
+type page struct {
+	status int
+	header http.Header
+	entry  rendercache.Entry
+}
+
+// uncached 以 error 的形式將不寫入快取的結果帶出 rendercache.Cache.Do
+type uncached struct{ p *page }
+
+func (u *uncached) Error() string { return fmt.Sprintf("狀態碼 %d，不寫入快取", u.p.status) }
+
+// serveRender GET /render?url=...&format=...&wait=...&selector=...&full_page=1&ready=1
+func (r *renderer) serveRender(w http.ResponseWriter, req *http.Request) {
+	q := req.URL.Query()
+	opts := renderOptions{
+		Format:   q.Get("format"),
+		Wait:     q.Get("wait"),
+		Selector: q.Get("selector"),
+		FullPage: q.Get("full_page") == "1" || q.Get("full_page") == "true",
+		Ready:    q.Get("ready") == "1" || q.Get("ready") == "true",
+	}
+	if opts.Format == "" {
+		opts.Format = "html"
+	}
+	if opts.Wait == "" {
+		opts.Wait = "load"
+	}
+	if _, ok := contentTypes[opts.Format]; !ok {
+		http.Error(w, "format 須為 html、png、pdf 或 mhtml", http.StatusBadRequest)
+		return
+	}
+	if opts.Wait != "load" && opts.Wait != "networkidle" {
+		http.Error(w, "wait 須為 load 或 networkidle", http.StatusBadRequest)
+		return
+	}
+	r.serve(w, req, q.Get("url"), opts)
+}
+
+// prerender 處理 prerender.io 形式的請求（GET /https://example.com/...），其餘交給 next。
+// 須在 ServeMux 之前處理，否則路徑中的 "//" 會被整理掉
+func (r *renderer) prerender(next http.Handler) http.Handler {
+	auth := r.authorize(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		target := strings.TrimPrefix(req.RequestURI, "/")
+		r.serve(w, req, target, renderOptions{Format: "html", Wait: r.wait})
+	}))
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if strings.HasPrefix(req.URL.Path, "/http://") || strings.HasPrefix(req.URL.Path, "/https://") {
+			auth.ServeHTTP(w, req)
+			return
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+// authorize 設定 -token 時檢查 X-Prerender-Token 標頭或 token 參數
+func (r *renderer) authorize(next http.Handler) http.Handler {
+	if r.token == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		got := req.Header.Get("X-Prerender-Token")
+		if got == "" {
+			got = req.URL.Query().Get("token")
+		}
+		if subtle.ConstantTimeCompare([]byte(got), []byte(r.token)) != 1 {
+			http.Error(w, "token 不正確", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+// serve 渲染（或取出快取）並回應
+func (r *renderer) serve(w http.ResponseWriter, req *http.Request, target string, opts renderOptions) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		http.Error(w, "只支援 GET", http.StatusMethodNotAllowed)
+		return
+	}
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		http.Error(w, "url 須為完整的 http 或 https 網址", http.StatusBadRequest)
+		return
+	}
+
+	if r.cache == nil {
+		p, err := r.render(req.Context(), target, opts)
+		if err != nil {
+			renderFailed(w, err)
+			return
+		}
+		writePage(w, p)
+		return
+	}
+
+	key := rendercache.Key(target, opts)
+	e, hit, err := r.cache.Do(key, rendercache.ParseControl(req), func() (rendercache.Entry, error) {
+		p, err := r.render(req.Context(), target, opts)
+		if err != nil {
+			return rendercache.Entry{}, err
+		}
+		if p.status != http.StatusOK || len(p.header) > 0 {
+			return rendercache.Entry{}, &uncached{p}
+		}
+		return p.entry, nil
+	})
+	var nc *uncached
+	switch {
+	case errors.As(err, &nc):
+		writePage(w, nc.p)
+	case err != nil:
+		renderFailed(w, err)
+	default:
+		rendercache.Serve(w, req, e, hit)
+	}
+}
+
+// render 開新分頁載入網址，等待後依格式輸出
+func (r *renderer) render(ctx context.Context, target string, opts renderOptions) (*page, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	select {
+	case r.slots <- struct{}{}:
+		defer func() { <-r.slots }()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	t, err := r.b.NewTab(cdpkit.TabOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer t.Close()
+	// 請求取消或逾時時取消分頁的 context，進行中的操作隨即失敗
+	stop := context.AfterFunc(ctx, t.Cancel)
+	defer stop()
+
+	if err := t.Navigate(target, r.timeout); err != nil {
+		return nil, r.cause(ctx, err)
+	}
+	if opts.Wait == "networkidle" {
+		if err := t.WaitNetworkIdle(500*time.Millisecond, r.timeout); err != nil {
+			return nil, r.cause(ctx, err)
+		}
+	}
+	if opts.Selector != "" {
+		if err := t.WaitVisible(opts.Selector, r.timeout); err != nil {
+			return nil, r.cause(ctx, err)
+		}
+	}
+
+	p := &page{status: http.StatusOK, entry: rendercache.Entry{URL: target, ContentType: contentTypes[opts.Format]}}
+	if doc := documentResponse(t.Tab, target); doc != nil && doc.Status != 0 {
+		p.status = int(doc.Status)
+	}
+	var ready *tab.ReadyOptions
+	if opts.Ready {
+		ready = &tab.ReadyOptions{}
+	}
+	switch opts.Format {
+	case "html":
+		err = r.html(t.Tab, p)
+	case "png":
+		p.entry.Body, err = t.ScreenshotWith(tab.ScreenshotOptions{FullPage: opts.FullPage, Ready: ready})
+	case "pdf":
+		p.entry.Body, err = t.PDF(tab.PDFOptions{PrintBackground: true, Ready: ready})
+	case "mhtml":
+		p.entry.Body, err = t.MHTML()
+	}
+	if err != nil {
+		return nil, r.cause(ctx, err)
+	}
+	return p, nil
+}
+
+// htmlScript 讀取 prerender 的 meta 設定，依需要移除 script 後序列化整份文件（含 doctype）
+const htmlScript = `(() => {
+	const meta = name => Array.from(document.querySelectorAll('meta[name="' + name + '"]')).map(m => m.getAttribute('content') || '');
+	const status = meta('prerender-status-code')[0] || '';
+	const headers = meta('prerender-header');
+	if (%t) {
+		document.querySelectorAll('script:not([type="application/ld+json"])').forEach(s => s.remove());
+	}
+	const doctype = document.doctype ? new XMLSerializer().serializeToString(document.doctype) : '';
+	return {status, headers, html: doctype + document.documentElement.outerHTML};
+})()`
+
+// html 取得渲染後的 HTML，並套用 prerender-status-code 與 prerender-header
+func (r *renderer) html(t *tab.Tab, p *page) error {
+	res, err := t.RunJS(fmt.Sprintf(htmlScript, r.stripScripts), r.timeout)
+	if err != nil {
+		return err
+	}
+	m, _ := res.(map[string]interface{})
+	html, _ := m["html"].(string)
+	p.entry.Body = []byte(html)
+	if s, _ := m["status"].(string); s != "" {
+		if code, err := strconv.Atoi(s); err == nil && code >= 100 && code <= 599 {
+			p.status = code
+		}
+	}
+	headers, _ := m["headers"].([]interface{})
+	for _, h := range headers {
+		name, value, ok := strings.Cut(fmt.Sprint(h), ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			continue
+		}
+		if p.header == nil {
+			p.header = http.Header{}
+		}
+		p.header.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+	return nil
+}
+
+// cause 請求已取消或逾時時以 ctx 的錯誤取代分頁操作的錯誤
+func (r *renderer) cause(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// documentResponse 找出頁面主文件的回應；有多個時優先取網址相同的
+func documentResponse(t *tab.Tab, pageURL string) *tab.Response {
+	var doc *tab.Response
+	for _, r := range t.Responses() {
+		if r.ResourceType != network.ResourceTypeDocument {
+			continue
+		}
+		r := r
+		if doc == nil || r.URL == pageURL {
+			doc = &r
+		}
+		if r.URL == pageURL {
+			break
+		}
+	}
+	return doc
+}
+
+// writePage 以渲染結果回應，不經過快取
+func writePage(w http.ResponseWriter, p *page) {
+	for k, vs := range p.header {
+		for _, v := range vs {
+			w.Header().Add(k, v)
+		}
+	}
+	w.Header().Set("Content-Type", p.entry.ContentType)
+	w.WriteHeader(p.status)
+	w.Write(p.entry.Body)
+}
+
+// renderFailed 渲染失敗：逾時回應 504，其餘 502
+func renderFailed(w http.ResponseWriter, err error) {
+	status := http.StatusBadGateway
+	if errors.Is(err, context.DeadlineExceeded) {
+		status = http.StatusGatewayTimeout
+	}
+	http.Error(w, fmt.Sprintf("渲染失敗: %v", err), status)
+}
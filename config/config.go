@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os" // Replaced io/ioutil with os
 	"time"
+
+	"github.com/firehourse/cdpkit/policy"
 )
 
 // FlagMergeFunc 允許外部自訂 flags 合併策略
@@ -22,11 +24,14 @@ type Config struct {
 	// Flags 由使用者指定、用於覆寫 DefaultFlags
 	Flags map[string]interface{}
 	// MergeFn 合併策略；nil 時採用 collectFlags 的預設行為
-	MergeFn FlagMergeFunc
+	MergeFn FlagMergeFunc `json:"-"`
 	// TabLimit 單個 BrowserManager 允許的最大分頁數；<=0 則退回 50
 	TabLimit int
 	// Timeout 全域預設操作超時
 	Timeout time.Duration
+	// HangTimeout 分頁操作期間持續沒有 CDP 事件且無法回應探測多久後視為渲染程序卡住並強制關閉，
+	// 0 表示停用（見 tab.ErrRendererHung）
+	HangTimeout time.Duration
 	// UserAgent 自定義 User-Agent，若為空則隨機選擇
 	UserAgent string
 	// WindowSize 瀏覽器窗口大小 [寬, 高]，若為 [0, 0] 則隨機生成
@@ -35,6 +40,141 @@ type Config struct {
 	Proxy      string
 	ChromePath string // (可選) 指定 chrome 二進位路徑
 	RemotePort int
+	// Policy 合規防護：網域允許清單與個資遮蔽；nil 表示不限制
+	Policy *policy.Policy
+	// StealthProfile 指紋 profile 名稱，例如 "win-chrome-123"、"mac-safari"（見 stealth.Names）；
+	// 留空使用只隱藏自動化特徵的 legacy profile。UserAgent 為空時採用 profile 的 UA
+	StealthProfile string
+	// Device 模擬的裝置名稱，例如 "iPhone 14"、"Pixel 7"（見 devices.Names）；
+	// 會覆寫 WindowSize，UserAgent 為空時採用裝置的 UA
+	Device string
+	// Seed 隨機種子，固定未指定 UA 時隨機選擇的 UA 與 stealth 的 canvas、AudioContext 雜訊，
+	// 相同種子得到相同的指紋；0 表示每個分頁各自隨機
+	Seed int64
+	// Timezone IANA 時區，例如 "Europe/Berlin"；留空使用系統時區
+	Timezone string
+	// Locale 語系，例如 "de-DE"，同時影響 Intl API 與 Accept-Language
+	Locale string
+	// Geolocation 模擬的地理位置；nil 表示不覆寫
+	Geolocation *Geolocation
+	// WebRTC 限制 WebRTC，避免經代理爬取時由 ICE candidate 洩漏真實 IP（見 WebRTCPolicy）；
+	// 每個分頁以初始化腳本過濾，自行啟動的 Chrome 另以 --force-webrtc-ip-handling-policy 套用到整個瀏覽器
+	WebRTC WebRTCPolicy
+	// ClientHints 明確指定 User-Agent Client Hints（Sec-CH-UA-* 標頭與 navigator.userAgentData）；
+	// nil 時由 UA 自動推導，非 Chromium 的 UA 不送出 Client Hints
+	ClientHints *ClientHints
+	// IgnoreCertErrors 忽略憑證錯誤（自簽、過期、主機名稱不符），以 Security 網域套用到每個分頁，
+	// 不影響同一瀏覽器中的其他分頁；只應用於爬取內部或設定錯誤的 HTTPS 網站
+	IgnoreCertErrors bool
+	// ClientCertificates 對指定來源出示的 TLS 用戶端憑證（mTLS）；Chrome 無法經由 CDP 選擇用戶端憑證，
+	// 符合來源的請求改由 Go 送出後將回應交給分頁
+	ClientCertificates []ClientCertificate
+	// Credentials 目標網站要求 HTTP 驗證（Basic、Digest、NTLM、Negotiate）時回應的帳密，key 為來源，
+	// 例如 https://intranet.example.com；NTLM 的網域寫在帳號中，例如 CORP\alice
+	Credentials map[string]Credential
+	// Limits 自行啟動的 Chrome 的資源限制，避免失控的頁面拖垮同機的服務；Remote 模式不適用
+	Limits ResourceLimits
+	// CrashDir 設定後以 --enable-logging 與 crashpad 記錄 Chrome 日誌與 minidump，
+	// Chrome 或分頁崩潰時複製到此目錄下的 crash-* 子目錄；Exec 模式限定
+	CrashDir string
+	// ChromeLogLevel Chrome 日誌詳細程度（--v），需搭配 CrashDir
+	ChromeLogLevel int
+	// MinimalTraffic 關閉元件更新、安全瀏覽清單、翻譯、DNS 預先解析、超連結 ping 等背景連線，
+	// 按流量計費或經代理爬取時只為目標頁面本身付費；Exec 模式限定
+	MinimalTraffic bool
+	// NoAutomationTweaks 連接已自行設定指紋的瀏覽器（例如 Kameleo、AdsPower）時設為 true：
+	// 建立分頁時不覆寫 UA、viewport、時區語系與地理位置，也不注入反檢測腳本，
+	// 以免破壞瀏覽器原有的設定；UserAgent、WindowSize、StealthProfile、Device、Timezone、Locale、Geolocation、ClientHints 皆不使用，
+	// WebRTC 只剩啟動旗標有效
+	NoAutomationTweaks bool
+}
+
+// ResourceLimits Chrome 行程的資源限制；零值欄位表示不限制
+type ResourceLimits struct {
+	// Nice CPU 排程優先權（1~19，數值越大越讓出 CPU），套用到整個 Chrome 行程群組；Unix 限定
+	Nice int
+	// JSHeapMB 每個 V8 isolate 的 heap 上限，透過 --js-flags=--max-old-space-size 設定
+	JSHeapMB int
+	// RendererProcesses renderer 行程數上限（--renderer-process-limit）
+	RendererProcesses int
+	// Cgroup 已委派給本服務的 cgroup v2 目錄，例如 /sys/fs/cgroup/cdpkit.slice，
+	// 須已在 cgroup.subtree_control 啟用 memory、pids、cpu。
+	// 設定後每個 Chrome 會放入其下的子 cgroup，並套用以下三項限制；Linux 限定
+	Cgroup string
+	// MemoryMB 整個 Chrome 行程樹的記憶體上限（memory.max），超過時由核心 OOM 終止
+	MemoryMB int
+	// MaxProcesses 整個 Chrome 行程樹的行程數上限（pids.max）
+	MaxProcesses int
+	// CPUPercent CPU 配額，100 代表一個核心（cpu.max）
+	CPUPercent int
+}
+
+// NeedsCgroup 是否設定了需要 cgroup 的限制
+func (l ResourceLimits) NeedsCgroup() bool {
+	return l.MemoryMB > 0 || l.MaxProcesses > 0 || l.CPUPercent > 0
+}
+
+// WebRTCPolicy WebRTC 的 IP 處理方式
+type WebRTCPolicy string
+
+const (
+	// WebRTCDefault 不限制
+	WebRTCDefault WebRTCPolicy = ""
+	// WebRTCPublicOnly 只使用預設路由的公開介面，不暴露區網 IP；經代理時仍可能暴露出口 IP
+	WebRTCPublicOnly WebRTCPolicy = "default_public_interface_only"
+	// WebRTCProxyOnly 停用不經代理的 UDP，只保留 relay（TURN）candidate，本機與出口 IP 都不暴露；經代理爬取時建議使用
+	WebRTCProxyOnly WebRTCPolicy = "disable_non_proxied_udp"
+	// WebRTCDisabled 移除 RTCPeerConnection 等 API；最徹底，但網站可察覺 WebRTC 不存在
+	WebRTCDisabled WebRTCPolicy = "disabled"
+)
+
+// ClientCertificate 對某個來源出示的用戶端憑證
+type ClientCertificate struct {
+	// Origin 套用的來源，例如 https://intranet.example.com 或 https://10.0.0.5:8443
+	Origin string
+	// CertFile、KeyFile PEM 格式的憑證（可附上中繼憑證）與私鑰
+	CertFile string
+	KeyFile  string
+}
+
+// Credential HTTP 驗證的帳號與密碼
+type Credential struct {
+	Username string
+	Password string
+}
+
+// ClientHints User-Agent Client Hints 的覆寫值；零值欄位沿用由 UA 推導的值
+type ClientHints struct {
+	// Brands 品牌與完整版本，例如 {"Google Chrome", "123.0.6312.86"}；
+	// Sec-CH-UA 與 userAgentData.brands 使用主要版本，Sec-CH-UA-Full-Version-List 使用完整版本
+	Brands []Brand
+	// Platform Sec-CH-UA-Platform，例如 "Windows"、"macOS"、"Android"，同時決定 navigator.platform
+	Platform string
+	// PlatformVersion Sec-CH-UA-Platform-Version，例如 "15.0.0"（Windows 11）
+	PlatformVersion string
+	// Architecture Sec-CH-UA-Arch，例如 "x86"、"arm"
+	Architecture string
+	// Bitness Sec-CH-UA-Bitness，例如 "64"
+	Bitness string
+	// Model Sec-CH-UA-Model，行動裝置的型號，例如 "Pixel 7"
+	Model string
+	// Mobile Sec-CH-UA-Mobile；nil 時依 UA 是否含 Mobile 判斷
+	Mobile *bool
+	// Wow64 Sec-CH-UA-WoW64
+	Wow64 bool
+}
+
+// Brand Client Hints 的一個品牌
+type Brand struct {
+	Brand   string
+	Version string
+}
+
+// Geolocation 經緯度與精確度（公尺）
+type Geolocation struct {
+	Latitude  float64
+	Longitude float64
+	Accuracy  float64
 }
 
 // SafeDefaults 提供穩定可用的旗標集合
@@ -0,0 +1,215 @@
+package config
+
+import (
+	"time"
+
+	"github.com/firehourse/cdpkit/policy"
+)
+
+// Layered 依作用範圍分層的設定：瀏覽器層在建立 BrowserManager 時決定，
+// 分頁層可在每個分頁覆寫，請求層為個別操作的預設值。與扁平的 Config 可互相轉換（見 Split、Flatten）
+type Layered struct {
+	Browser Browser
+	Tab     Tab
+	Request Request
+}
+
+// Browser 瀏覽器層：連線或啟動 Chrome 的方式、旗標與資源限制
+type Browser struct {
+	// WebSocketURL 連接既有 Chrome；留空則自行啟動
+	WebSocketURL string
+	// DefaultFlags 內建旗標（若自行啟動 Chrome 才會用到）
+	DefaultFlags map[string]interface{}
+	// Flags 由使用者指定、用於覆寫 DefaultFlags
+	Flags map[string]interface{}
+	// MergeFn 合併策略；nil 時採用預設行為
+	MergeFn FlagMergeFunc `json:"-"`
+	// TabLimit 最大分頁數；<=0 則退回 50
+	TabLimit int
+	// Proxy HTTP/SOCKS5 代理地址
+	Proxy      string
+	ChromePath string
+	RemotePort int
+	// Limits 自行啟動的 Chrome 的資源限制
+	Limits ResourceLimits
+	// CrashDir Chrome 日誌與崩潰傾印的收集目錄
+	CrashDir string
+	// ChromeLogLevel Chrome 日誌詳細程度（--v），需搭配 CrashDir
+	ChromeLogLevel int
+	// MinimalTraffic 關閉與目標頁面無關的背景連線，見 Config.MinimalTraffic
+	MinimalTraffic bool
+}
+
+// Tab 分頁層：指紋、裝置模擬、時區語系、合規防護、憑證與看門狗
+type Tab struct {
+	UserAgent      string
+	WindowSize     [2]int
+	StealthProfile string
+	Device         string
+	Seed           int64
+	Timezone       string
+	Locale         string
+	Geolocation    *Geolocation
+	ClientHints    *ClientHints
+	WebRTC         WebRTCPolicy
+	Policy         *policy.Policy
+	HangTimeout    time.Duration
+	// IgnoreCertErrors、ClientCertificates、Credentials 見 Config 的同名欄位
+	IgnoreCertErrors   bool
+	ClientCertificates []ClientCertificate
+	Credentials        map[string]Credential
+	// NoAutomationTweaks 見 Config.NoAutomationTweaks
+	NoAutomationTweaks bool
+}
+
+// Request 請求層：個別操作未指定逾時時使用的預設值
+type Request struct {
+	Timeout time.Duration
+}
+
+// Split 將扁平的 Config 拆為分層設定
+func Split(c Config) Layered {
+	return Layered{
+		Browser: Browser{
+			WebSocketURL:   c.WebSocketURL,
+			DefaultFlags:   c.DefaultFlags,
+			Flags:          c.Flags,
+			MergeFn:        c.MergeFn,
+			TabLimit:       c.TabLimit,
+			Proxy:          c.Proxy,
+			ChromePath:     c.ChromePath,
+			RemotePort:     c.RemotePort,
+			Limits:         c.Limits,
+			CrashDir:       c.CrashDir,
+			ChromeLogLevel: c.ChromeLogLevel,
+			MinimalTraffic: c.MinimalTraffic,
+		},
+		Tab: Tab{
+			UserAgent:      c.UserAgent,
+			WindowSize:     c.WindowSize,
+			StealthProfile: c.StealthProfile,
+			Device:         c.Device,
+			Seed:           c.Seed,
+			Timezone:       c.Timezone,
+			Locale:         c.Locale,
+			Geolocation:    c.Geolocation,
+			ClientHints:    c.ClientHints,
+			WebRTC:         c.WebRTC,
+			Policy:         c.Policy,
+			HangTimeout:    c.HangTimeout,
+
+			IgnoreCertErrors:   c.IgnoreCertErrors,
+			ClientCertificates: c.ClientCertificates,
+			Credentials:        c.Credentials,
+			NoAutomationTweaks: c.NoAutomationTweaks,
+		},
+		Request: Request{Timeout: c.Timeout},
+	}
+}
+
+// Flatten 轉為扁平的 Config，供仍接受 Config 的 API 使用
+func (l Layered) Flatten() Config {
+	return l.Apply(Config{})
+}
+
+// Apply 以 l 中的非零欄位覆寫 base 並回傳結果；Flags 逐鍵合併，其餘欄位整個取代
+func (l Layered) Apply(base Config) Config {
+	b, t := l.Browser, l.Tab
+	if b.WebSocketURL != "" {
+		base.WebSocketURL = b.WebSocketURL
+	}
+	if b.DefaultFlags != nil {
+		base.DefaultFlags = b.DefaultFlags
+	}
+	if b.Flags != nil {
+		flags := make(map[string]interface{}, len(base.Flags)+len(b.Flags))
+		for k, v := range base.Flags {
+			flags[k] = v
+		}
+		for k, v := range b.Flags {
+			flags[k] = v
+		}
+		base.Flags = flags
+	}
+	if b.MergeFn != nil {
+		base.MergeFn = b.MergeFn
+	}
+	if b.TabLimit != 0 {
+		base.TabLimit = b.TabLimit
+	}
+	if b.Proxy != "" {
+		base.Proxy = b.Proxy
+	}
+	if b.ChromePath != "" {
+		base.ChromePath = b.ChromePath
+	}
+	if b.RemotePort != 0 {
+		base.RemotePort = b.RemotePort
+	}
+	if b.Limits != (ResourceLimits{}) {
+		base.Limits = b.Limits
+	}
+	if b.CrashDir != "" {
+		base.CrashDir = b.CrashDir
+	}
+	if b.ChromeLogLevel != 0 {
+		base.ChromeLogLevel = b.ChromeLogLevel
+	}
+	if b.MinimalTraffic {
+		base.MinimalTraffic = true
+	}
+
+	if t.UserAgent != "" {
+		base.UserAgent = t.UserAgent
+	}
+	if t.WindowSize != [2]int{} {
+		base.WindowSize = t.WindowSize
+	}
+	if t.StealthProfile != "" {
+		base.StealthProfile = t.StealthProfile
+	}
+	if t.Device != "" {
+		base.Device = t.Device
+	}
+	if t.Seed != 0 {
+		base.Seed = t.Seed
+	}
+	if t.Timezone != "" {
+		base.Timezone = t.Timezone
+	}
+	if t.Locale != "" {
+		base.Locale = t.Locale
+	}
+	if t.Geolocation != nil {
+		base.Geolocation = t.Geolocation
+	}
+	if t.ClientHints != nil {
+		base.ClientHints = t.ClientHints
+	}
+	if t.WebRTC != "" {
+		base.WebRTC = t.WebRTC
+	}
+	if t.Policy != nil {
+		base.Policy = t.Policy
+	}
+	if t.HangTimeout != 0 {
+		base.HangTimeout = t.HangTimeout
+	}
+	if t.IgnoreCertErrors {
+		base.IgnoreCertErrors = true
+	}
+	if t.ClientCertificates != nil {
+		base.ClientCertificates = t.ClientCertificates
+	}
+	if t.Credentials != nil {
+		base.Credentials = t.Credentials
+	}
+	if t.NoAutomationTweaks {
+		base.NoAutomationTweaks = true
+	}
+
+	if l.Request.Timeout != 0 {
+		base.Timeout = l.Request.Timeout
+	}
+	return base
+}
@@ -0,0 +1,482 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 只實作設定檔需要的 YAML 子集：區塊 mapping 與 sequence、flow 形式的 [] 與 {}、
+// 單雙引號字串、| 與 > 區塊字串以及 # 註解。不支援 anchor、tag 與多文件。
+
+type yamlLine struct {
+	num    int
+	indent int
+	text   string // 去除縮排後的內容
+	raw    string
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+// ParseYAML 解析 YAML，結果為 map[string]interface{}、[]interface{} 與 string/float64/bool/nil
+func ParseYAML(data []byte) (interface{}, error) {
+	p := &yamlParser{}
+	for i, raw := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimLeft(raw, " ")
+		if strings.HasPrefix(trimmed, "\t") {
+			return nil, fmt.Errorf("第 %d 行: YAML 縮排不可使用 tab", i+1)
+		}
+		p.lines = append(p.lines, yamlLine{num: i + 1, indent: len(raw) - len(trimmed), text: trimmed, raw: raw})
+	}
+
+	p.skipBlank()
+	if p.pos < len(p.lines) && p.lines[p.pos].text == "---" {
+		p.pos++
+	}
+	v, err := p.parseNode(0)
+	if err != nil {
+		return nil, err
+	}
+	p.skipBlank()
+	if p.pos < len(p.lines) {
+		return nil, p.errorf("無法解析的內容 %q", p.lines[p.pos].text)
+	}
+	return v, nil
+}
+
+// UnmarshalYAML 解析 YAML 後經 JSON 轉入 v，欄位名稱與型別規則與 JSON 設定相同
+func UnmarshalYAML(data []byte, v interface{}) error {
+	parsed, err := ParseYAML(data)
+	if err != nil {
+		return err
+	}
+	raw, err := json.Marshal(parsed)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
+
+func (p *yamlParser) errorf(format string, args ...interface{}) error {
+	line := 0
+	if p.pos < len(p.lines) {
+		line = p.lines[p.pos].num
+	}
+	return fmt.Errorf("第 %d 行: "+format, append([]interface{}{line}, args...)...)
+}
+
+// skipBlank 略過空白行與註解行
+func (p *yamlParser) skipBlank() {
+	for p.pos < len(p.lines) {
+		t := p.lines[p.pos].text
+		if t != "" && !strings.HasPrefix(t, "#") {
+			return
+		}
+		p.pos++
+	}
+}
+
+// parseNode 解析縮排至少為 minIndent 的節點；沒有內容時回傳 nil
+func (p *yamlParser) parseNode(minIndent int) (interface{}, error) {
+	p.skipBlank()
+	if p.pos >= len(p.lines) || p.lines[p.pos].indent < minIndent {
+		return nil, nil
+	}
+	l := p.lines[p.pos]
+	switch {
+	case isSeqItem(l.text):
+		return p.parseSeq(l.indent)
+	case mappingKey(l.text) >= 0:
+		return p.parseMap(l.indent)
+	default:
+		p.pos++
+		return parseFlow(stripComment(l.text))
+	}
+}
+
+func (p *yamlParser) parseMap(indent int) (interface{}, error) {
+	out := map[string]interface{}{}
+	for {
+		p.skipBlank()
+		if p.pos >= len(p.lines) {
+			return out, nil
+		}
+		l := p.lines[p.pos]
+		if l.indent < indent {
+			return out, nil
+		}
+		if l.indent > indent {
+			return nil, p.errorf("縮排不一致")
+		}
+		i := mappingKey(l.text)
+		if i < 0 {
+			if isSeqItem(l.text) {
+				return out, nil
+			}
+			return nil, p.errorf("預期為 key: value，實際為 %q", l.text)
+		}
+		key, err := parseScalar(strings.TrimSpace(l.text[:i]))
+		if err != nil {
+			return nil, p.errorf("%v", err)
+		}
+		rest := strings.TrimSpace(stripComment(l.text[i+1:]))
+		p.pos++
+
+		var v interface{}
+		switch {
+		case rest == "":
+			// 值可能位於下一行；sequence 可與 key 同一縮排
+			p.skipBlank()
+			if p.pos < len(p.lines) && p.lines[p.pos].indent == indent && isSeqItem(p.lines[p.pos].text) {
+				v, err = p.parseSeq(indent)
+			} else {
+				v, err = p.parseNode(indent + 1)
+			}
+		case rest[0] == '|' || rest[0] == '>':
+			v = p.parseBlockScalar(indent, rest)
+		default:
+			v, err = parseFlow(rest)
+			if err != nil {
+				p.pos--
+				err = p.errorf("%v", err)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		out[fmt.Sprint(key)] = v
+	}
+}
+
+func (p *yamlParser) parseSeq(indent int) (interface{}, error) {
+	out := []interface{}{}
+	for {
+		p.skipBlank()
+		if p.pos >= len(p.lines) {
+			return out, nil
+		}
+		l := p.lines[p.pos]
+		if l.indent < indent || !isSeqItem(l.text) {
+			return out, nil
+		}
+		if l.indent > indent {
+			return nil, p.errorf("縮排不一致")
+		}
+		rest := strings.TrimLeft(l.text[1:], " ")
+
+		var v interface{}
+		var err error
+		switch {
+		case strings.TrimSpace(stripComment(rest)) == "":
+			p.pos++
+			v, err = p.parseNode(indent + 1)
+		case isSeqItem(rest) || mappingKey(rest) >= 0:
+			// "- key: value" 或 "- - item"：將目前這行改寫為縮排較深的節點
+			p.lines[p.pos].indent = indent + len(l.text) - len(rest)
+			p.lines[p.pos].text = rest
+			v, err = p.parseNode(indent + 1)
+		case rest[0] == '|' || rest[0] == '>':
+			p.pos++
+			v = p.parseBlockScalar(indent, rest)
+		default:
+			v, err = parseFlow(stripComment(rest))
+			if err != nil {
+				err = p.errorf("%v", err)
+			}
+			p.pos++
+		}
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+}
+
+// parseBlockScalar 讀取縮排大於 indent 的原始行；| 保留換行，> 將換行折疊為空白。
+// 指示字元後的 - 去除結尾換行，+ 保留所有結尾換行
+func (p *yamlParser) parseBlockScalar(indent int, header string) string {
+	var lines []string
+	content := -1
+	for p.pos < len(p.lines) {
+		l := p.lines[p.pos]
+		if l.text == "" {
+			lines = append(lines, "")
+			p.pos++
+			continue
+		}
+		if l.indent <= indent {
+			break
+		}
+		if content < 0 {
+			content = l.indent
+		}
+		if l.indent < content {
+			break
+		}
+		lines = append(lines, l.raw[content:])
+		p.pos++
+	}
+
+	trailing := 0
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+		trailing++
+	}
+
+	var s string
+	if header[0] == '|' {
+		s = strings.Join(lines, "\n")
+	} else {
+		var b strings.Builder
+		for i, line := range lines {
+			// 空行折疊為一個換行，空行前後的換行不再另外保留
+			switch {
+			case line == "":
+				b.WriteByte('\n')
+			case i == 0 || lines[i-1] == "":
+			default:
+				b.WriteByte(' ')
+			}
+			b.WriteString(line)
+		}
+		s = b.String()
+	}
+
+	switch {
+	case strings.Contains(header, "-") || len(lines) == 0:
+		return s
+	case strings.Contains(header, "+"):
+		return s + strings.Repeat("\n", trailing+1)
+	default:
+		return s + "\n"
+	}
+}
+
+func isSeqItem(s string) bool {
+	return s == "-" || strings.HasPrefix(s, "- ")
+}
+
+// mappingKey 回傳 "key: value" 中冒號的位置；不是 mapping 時回傳 -1
+func mappingKey(s string) int {
+	if s == "" || s[0] == '[' || s[0] == '{' || s[0] == '#' {
+		return -1
+	}
+	quote := byte(0)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\'' && quote == '\'' && i+1 < len(s) && s[i+1] == '\'' {
+				// 單引號字串中的 '' 代表一個單引號
+				i++
+			} else if c == quote {
+				quote = 0
+			} else if c == '\\' && quote == '"' {
+				i++
+			}
+		case i == 0 && (c == '"' || c == '\''):
+			quote = c
+		case c == '#' && i > 0 && s[i-1] == ' ':
+			return -1
+		case c == ':' && (i+1 == len(s) || s[i+1] == ' '):
+			return i
+		}
+	}
+	return -1
+}
+
+// stripComment 移除引號外、前面為空白的 # 註解
+func stripComment(s string) string {
+	quote := byte(0)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\'' && quote == '\'' && i+1 < len(s) && s[i+1] == '\'' {
+				// 單引號字串中的 '' 代表一個單引號
+				i++
+			} else if c == quote {
+				quote = 0
+			} else if c == '\\' && quote == '"' {
+				i++
+			}
+		case (c == '"' || c == '\'') && (i == 0 || strings.IndexByte(" [{,:", s[i-1]) >= 0):
+			quote = c
+		case c == '#' && (i == 0 || s[i-1] == ' '):
+			return strings.TrimRight(s[:i], " ")
+		}
+	}
+	return s
+}
+
+// parseFlow 解析單行的值，可為純量或 flow 形式的 [...]、{...}
+func parseFlow(s string) (interface{}, error) {
+	f := &flowScanner{s: strings.TrimSpace(s)}
+	v, err := f.value()
+	if err != nil {
+		return nil, err
+	}
+	f.space()
+	if f.i < len(f.s) {
+		return nil, fmt.Errorf("無法解析的內容 %q", f.s[f.i:])
+	}
+	return v, nil
+}
+
+type flowScanner struct {
+	s     string
+	i     int
+	depth int
+}
+
+func (f *flowScanner) space() {
+	for f.i < len(f.s) && f.s[f.i] == ' ' {
+		f.i++
+	}
+}
+
+func (f *flowScanner) value() (interface{}, error) {
+	f.space()
+	if f.i >= len(f.s) {
+		return nil, nil
+	}
+	switch f.s[f.i] {
+	case '[':
+		f.i++
+		f.depth++
+		out := []interface{}{}
+		for {
+			f.space()
+			if f.i < len(f.s) && f.s[f.i] == ']' {
+				f.i++
+				f.depth--
+				return out, nil
+			}
+			v, err := f.value()
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, v)
+			if err := f.separator(']'); err != nil {
+				return nil, err
+			}
+		}
+	case '{':
+		f.i++
+		f.depth++
+		out := map[string]interface{}{}
+		for {
+			f.space()
+			if f.i < len(f.s) && f.s[f.i] == '}' {
+				f.i++
+				f.depth--
+				return out, nil
+			}
+			k, err := f.scalar(":")
+			if err != nil {
+				return nil, err
+			}
+			if f.i >= len(f.s) || f.s[f.i] != ':' {
+				return nil, fmt.Errorf("flow mapping 缺少冒號")
+			}
+			f.i++
+			v, err := f.value()
+			if err != nil {
+				return nil, err
+			}
+			out[fmt.Sprint(k)] = v
+			if err := f.separator('}'); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return f.scalar(",]}")
+}
+
+// separator 讀取逗號或結尾括號（結尾括號留給呼叫端處理）
+func (f *flowScanner) separator(end byte) error {
+	f.space()
+	if f.i >= len(f.s) {
+		return fmt.Errorf("缺少 %q", end)
+	}
+	switch f.s[f.i] {
+	case ',':
+		f.i++
+		return nil
+	case end:
+		return nil
+	}
+	return fmt.Errorf("預期為 ',' 或 %q", end)
+}
+
+// scalar 讀取到 stops 中任一字元為止的純量；位於 flow 之外時 stops 不生效
+func (f *flowScanner) scalar(stops string) (interface{}, error) {
+	f.space()
+	start := f.i
+	if f.i < len(f.s) && (f.s[f.i] == '"' || f.s[f.i] == '\'') {
+		q := f.s[f.i]
+		f.i++
+		for f.i < len(f.s) {
+			c := f.s[f.i]
+			if c == q {
+				if q == '\'' && f.i+1 < len(f.s) && f.s[f.i+1] == '\'' {
+					// 單引號字串中的 '' 代表一個單引號
+					f.i += 2
+					continue
+				}
+				break
+			}
+			if q == '"' && c == '\\' {
+				f.i++
+			}
+			f.i++
+		}
+		if f.i >= len(f.s) {
+			return nil, fmt.Errorf("字串缺少結尾引號")
+		}
+		f.i++
+		return parseScalar(f.s[start:f.i])
+	}
+
+	for f.i < len(f.s) {
+		c := f.s[f.i]
+		if f.depth > 0 && strings.IndexByte(stops, c) >= 0 {
+			// flow mapping 的冒號須後接空白，避免截斷 URL
+			if c != ':' || f.i+1 == len(f.s) || f.s[f.i+1] == ' ' {
+				break
+			}
+		}
+		f.i++
+	}
+	return parseScalar(strings.TrimSpace(f.s[start:f.i]))
+}
+
+// parseScalar 解析純量：引號字串、布林、null、數字，其餘視為字串
+func parseScalar(s string) (interface{}, error) {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		v, err := strconv.Unquote(s)
+		if err != nil {
+			return nil, fmt.Errorf("無效的字串 %s", s)
+		}
+		return v, nil
+	}
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	}
+	switch s {
+	case "", "~", "null", "Null", "NULL":
+		return nil, nil
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	}
+	if n, err := strconv.ParseFloat(s, 64); err == nil && !strings.ContainsAny(s, "xXnN_") {
+		return n, nil
+	}
+	return s, nil
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	type m = map[string]interface{}
+	type l = []interface{}
+	for _, tc := range []struct {
+		name string
+		in   string
+		want interface{}
+	}{
+		{"純量", "a: 1\nb: 2.5\nc: true\nd: ~\ne: hello world\nf: 0x10\ng: 1_000", m{
+			"a": 1.0, "b": 2.5, "c": true, "d": nil, "e": "hello world", "f": "0x10", "g": "1_000",
+		}},
+		{"單引號", `a: 'it''s'` + "\n" + `b: 'say "hi"'` + "\nc: ''\nd: ''''", m{
+			"a": "it's", "b": `say "hi"`, "c": "", "d": "'",
+		}},
+		{"雙引號跳脫", `a: "tab\tnew\nline \"q\" \u00e9"` + "\n" + `b: "it's"`, m{
+			"a": "tab\tnew\nline \"q\" é", "b": "it's",
+		}},
+		{"引號中的特殊字元", `a: 'x: y # z'` + "\n" + `b: "a, b]"` + "\n" + `'k''s: x': 1`, m{
+			"a": "x: y # z", "b": "a, b]", "k's: x": 1.0,
+		}},
+		{"註解", "# 開頭註解\na: 1 # 行尾註解\n\n  # 縮排的註解\nb: x#y\nc: 'q' # 引號後的註解\nd: \"#\"", m{
+			"a": 1.0, "b": "x#y", "c": "q", "d": "#",
+		}},
+		{"URL 不是 mapping", "url: https://example.com:8080/a#b\nlist: [http://a.example/x, 'b']", m{
+			"url": "https://example.com:8080/a#b", "list": l{"http://a.example/x", "b"},
+		}},
+		{"巢狀 mapping", "a:\n  b:\n    c: 1\n  d: 2\ne: 3", m{
+			"a": m{"b": m{"c": 1.0}, "d": 2.0}, "e": 3.0,
+		}},
+		{"sequence", "a:\n  - 1\n  - x\nb:\n- y\n- - 1\n  - 2\n- k: v\n  k2: v2", m{
+			"a": l{1.0, "x"}, "b": l{"y", l{1.0, 2.0}, m{"k": "v", "k2": "v2"}},
+		}},
+		{"空值", "a:\nb:\n  c:\n", m{"a": nil, "b": m{"c": nil}}},
+		{"flow", "a: [1, [2, 3], {k: v, 'q': \"r\"}]\nb: {x: [], y: {}, z: 'it''s'}\nc: []", m{
+			"a": l{1.0, l{2.0, 3.0}, m{"k": "v", "q": "r"}},
+			"b": m{"x": l{}, "y": m{}, "z": "it's"},
+			"c": l{},
+		}},
+		{"literal 區塊", "a: |\n  line 1\n    indented\n\n  line 3\nb: 1", m{
+			"a": "line 1\n  indented\n\nline 3\n", "b": 1.0,
+		}},
+		{"folded 區塊", "a: >\n  one\n  two\n\n  three\n\n\n  four\nb: x", m{
+			"a": "one two\nthree\n\nfour\n", "b": "x",
+		}},
+		{"flow 中有空白的純量", "a: [1 2, x y]", m{"a": l{"1 2", "x y"}}},
+		{"區塊 chomping", "a: |-\n  x\n\nb: |+\n  y\n\n\nc: >-\n  z\n  w", m{
+			"a": "x", "b": "y\n\n\n", "c": "z w",
+		}},
+		{"sequence 中的區塊", "- |\n  a\n  b\n- c", l{"a\nb\n", "c"}},
+		{"文件開頭", "---\na: 1", m{"a": 1.0}},
+		{"CRLF", "a: 1\r\nb:\r\n  - x\r\n", m{"a": 1.0, "b": l{"x"}}},
+		{"空文件", "# 只有註解\n\n", nil},
+		{"頂層 flow", "[a, b]", l{"a", "b"}},
+	} {
+		got, err := ParseYAML([]byte(tc.in))
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s:\n得到 %#v\n應為 %#v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestParseYAMLErrors(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want string
+	}{
+		{"a: 1\n\tb: 2", "第 2 行: YAML 縮排不可使用 tab"},
+		{"a: 1\n  b: 2", "第 2 行: 縮排不一致"},
+		{"a:\n  - 1\n    - 2", "第 3 行: 縮排不一致"},
+		{"a: 1\nb: 2\nc: [1, 2", `第 3 行: 缺少 ']'`},
+		{"a: {k v}", "第 1 行: flow mapping 缺少冒號"},
+		{"a: [[1] 2]", `第 1 行: 預期為 ',' 或 ']'`},
+		{"a: 'open", "第 1 行: 字串缺少結尾引號"},
+		{`a: "bad \q"`, `第 1 行: 無效的字串 "bad \q"`},
+		{"a: 'it''s' extra", `第 1 行: 無法解析的內容 "extra"`},
+		{"a: 1\njust text", `第 2 行: 預期為 key: value，實際為 "just text"`},
+		{"- a\nb: 1", `第 2 行: 無法解析的內容 "b: 1"`},
+	} {
+		_, err := ParseYAML([]byte(tc.in))
+		if err == nil || err.Error() != tc.want {
+			t.Errorf("ParseYAML(%q) 的錯誤為 %v，應為 %s", tc.in, err, tc.want)
+		}
+	}
+}
+
+// 任意截斷的輸入只能回傳錯誤，不可 panic
+func TestParseYAMLTruncated(t *testing.T) {
+	doc := "a: 'it''s'\nb:\n  - [1, {k: \"v\\\"\"}]\n  - |\n    text\nc: {x: 'y'} # c\n"
+	for i := 0; i <= len(doc); i++ {
+		for _, in := range []string{doc[:i], doc[i:]} {
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						t.Errorf("ParseYAML(%q) panic: %v", in, r)
+					}
+				}()
+				ParseYAML([]byte(in))
+			}()
+		}
+	}
+}
+
+func TestUnmarshalYAML(t *testing.T) {
+	var v struct {
+		Name    string            `json:"name"`
+		Timeout int               `json:"timeout"`
+		Tags    []string          `json:"tags"`
+		Headers map[string]string `json:"headers"`
+	}
+	in := "name: 'O''Brien'\ntimeout: 30\ntags: [a, b]\nheaders:\n  X-Token: \"abc\"\n"
+	if err := UnmarshalYAML([]byte(in), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Name != "O'Brien" || v.Timeout != 30 || strings.Join(v.Tags, ",") != "a,b" || v.Headers["X-Token"] != "abc" {
+		t.Errorf("解析結果 %+v", v)
+	}
+}
@@ -0,0 +1,259 @@
+package crawler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/firehourse/cdpkit/domains"
+	"github.com/firehourse/cdpkit/tab"
+)
+
+// AdaptiveWait 依各 host 過去實際就緒所需的時間調整導航後的等待，取代固定的 2 秒。
+// 樣本不足時於 load 事件後量測網路閒置與 DOM 穩定的時間，累積 MinSamples 筆後改用推得的策略，
+// 例如「load 後即可擷取」或「等待網路閒置後再等 1.5 秒」。網域設定了 wait_selector 或 wait_delay 時以網域設定為準
+type AdaptiveWait struct {
+	// State 歷史紀錄檔，跨執行保留量測結果，Close 時寫回；留空只在本次執行內學習
+	State string
+	// MinSamples 改用推得的策略前每個 host 需要的樣本數，預設 5
+	MinSamples int
+	// Resample 套用策略後每隔幾個頁面重新量測一次，讓策略跟上網站的改版，預設 20；負值表示不再量測
+	Resample int
+	// Quiet 判定網路閒置與 DOM 穩定的靜止時間，預設 500ms
+	Quiet time.Duration
+	// Max 量測與等待的上限，預設 10 秒
+	Max time.Duration
+}
+
+// WaitStrategy 由歷史就緒時間推得的等待策略
+type WaitStrategy struct {
+	// NetworkIdle 是否等待網路閒置；false 表示 load 事件後不必等待網路
+	NetworkIdle bool `json:"network_idle"`
+	// IdleTimeout 等待網路閒置的上限
+	IdleTimeout domains.Duration `json:"idle_timeout,omitempty"`
+	// Delay 之後再等待的時間，涵蓋網路閒置後仍以 JS 渲染的部分
+	Delay domains.Duration `json:"delay,omitempty"`
+	// Samples 推得策略所依據的樣本數
+	Samples int `json:"samples"`
+}
+
+// String 以 "load"、"networkidle + 1.5s" 的形式描述策略
+func (s WaitStrategy) String() string {
+	name := "load"
+	if s.NetworkIdle {
+		name = "networkidle"
+	}
+	if s.Delay > 0 {
+		name += " + " + time.Duration(s.Delay).String()
+	}
+	return name
+}
+
+// WaitStrategies 回傳各 host 目前推得的等待策略；未設定 AdaptiveWait 或樣本不足的 host 不列出
+func (c *Crawler) WaitStrategies() map[string]WaitStrategy {
+	if c.waits == nil {
+		return nil
+	}
+	return c.waits.strategies()
+}
+
+// ----------------- 內部實作 -----------------
+
+// maxReadySamples 每個 host 保留的最近樣本數
+const maxReadySamples = 20
+
+// readySample 一次量測：Idle 為 load 事件後到網路閒置、Ready 為到網路閒置且 DOM 穩定的時間；
+// TimedOut 表示超過 Max 仍未就緒，此時以 Max 計
+type readySample struct {
+	Idle     domains.Duration `json:"idle"`
+	Ready    domains.Duration `json:"ready"`
+	TimedOut bool             `json:"timed_out,omitempty"`
+	At       time.Time        `json:"at"`
+}
+
+// hostReadiness 一個 host 的量測紀錄；pages 為套用策略後處理的頁面數，不寫入紀錄檔
+type hostReadiness struct {
+	Samples []readySample `json:"samples"`
+	pages   int
+}
+
+// waitTuner 追蹤各 host 的就緒時間並推得等待策略
+type waitTuner struct {
+	opts AdaptiveWait
+
+	mu    sync.Mutex
+	hosts map[string]*hostReadiness
+	dirty bool
+}
+
+// newWaitTuner 套用預設值並載入既有的紀錄檔（不存在時從頭學習）
+func newWaitTuner(opts AdaptiveWait) (*waitTuner, error) {
+	if opts.MinSamples <= 0 {
+		opts.MinSamples = 5
+	}
+	if opts.Resample == 0 {
+		opts.Resample = 20
+	}
+	if opts.Quiet <= 0 {
+		opts.Quiet = 500 * time.Millisecond
+	}
+	if opts.Max <= 0 {
+		opts.Max = 10 * time.Second
+	}
+	w := &waitTuner{opts: opts, hosts: map[string]*hostReadiness{}}
+	if opts.State == "" {
+		return w, nil
+	}
+	data, err := os.ReadFile(opts.State)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("讀取等待紀錄檔失敗: %w", err)
+	default:
+		if err := json.Unmarshal(data, &w.hosts); err != nil {
+			return nil, fmt.Errorf("解析等待紀錄檔 %s 失敗: %w", opts.State, err)
+		}
+	}
+	return w, nil
+}
+
+// settle 依 host 的策略等待；樣本不足或到了重新量測的時候改為量測並記錄
+func (w *waitTuner) settle(pageTab *tab.Tab, host string) (WaitStrategy, bool) {
+	strategy, ok := w.plan(host)
+	if !ok {
+		w.record(host, w.measure(pageTab))
+		return strategy, false
+	}
+	if strategy.NetworkIdle {
+		// 逾時表示這次比過去慢，照常擷取
+		_ = pageTab.WaitNetworkIdle(w.opts.Quiet, time.Duration(strategy.IdleTimeout))
+	}
+	time.Sleep(time.Duration(strategy.Delay))
+	return strategy, true
+}
+
+// plan 回傳 host 的策略；第二個回傳值為 false 時本次應量測
+func (w *waitTuner) plan(host string) (WaitStrategy, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	h := w.hosts[host]
+	if h == nil || len(h.Samples) < w.opts.MinSamples {
+		return WaitStrategy{}, false
+	}
+	h.pages++
+	if w.opts.Resample > 0 && h.pages%w.opts.Resample == 0 {
+		return WaitStrategy{}, false
+	}
+	return strategyFor(h.Samples, w.opts), true
+}
+
+// measure 於 load 事件後等待網路閒置與 DOM 穩定，回傳各自花費的時間（不含判定用的靜止時間）
+func (w *waitTuner) measure(pageTab *tab.Tab) readySample {
+	quiet, max := w.opts.Quiet, w.opts.Max
+	start := time.Now()
+	s := readySample{At: start}
+	if err := pageTab.WaitNetworkIdle(quiet, max); err != nil {
+		s.TimedOut = true
+	}
+	idle := time.Since(start) - quiet
+	if rest := max - time.Since(start); !s.TimedOut && rest > quiet {
+		if err := pageTab.WaitDOMStable(quiet, rest); err != nil {
+			s.TimedOut = true
+		}
+	}
+	ready := time.Since(start) - quiet
+	if s.TimedOut {
+		ready = max
+	}
+	s.Idle, s.Ready = domains.Duration(clampDuration(idle, max)), domains.Duration(clampDuration(ready, max))
+	return s
+}
+
+// record 加入樣本，只保留最近 maxReadySamples 筆
+func (w *waitTuner) record(host string, s readySample) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	h := w.hosts[host]
+	if h == nil {
+		h = &hostReadiness{}
+		w.hosts[host] = h
+	}
+	h.Samples = append(h.Samples, s)
+	if n := len(h.Samples); n > maxReadySamples {
+		h.Samples = h.Samples[n-maxReadySamples:]
+	}
+	w.dirty = true
+}
+
+func (w *waitTuner) strategies() map[string]WaitStrategy {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	out := map[string]WaitStrategy{}
+	for host, h := range w.hosts {
+		if len(h.Samples) >= w.opts.MinSamples {
+			out[host] = strategyFor(h.Samples, w.opts)
+		}
+	}
+	return out
+}
+
+// strategyFor 以樣本的第 90 百分位推得策略：網路在 load 後 100ms 內閒置時不等待網路，
+// DOM 在網路閒置後仍持續變化的部分改以固定延遲等待；延遲取到 100ms
+func strategyFor(samples []readySample, opts AdaptiveWait) WaitStrategy {
+	var idle, ready, after histogram
+	for _, s := range samples {
+		idle.add(time.Duration(s.Idle))
+		ready.add(time.Duration(s.Ready))
+		after.add(time.Duration(s.Ready - s.Idle))
+	}
+	st := WaitStrategy{Samples: len(samples)}
+	delay := ready.quantile(0.9)
+	if p := idle.quantile(0.9); p >= 100*time.Millisecond {
+		st.NetworkIdle = true
+		st.IdleTimeout = domains.Duration(clampDuration(2*p+opts.Quiet, opts.Max))
+		delay = after.quantile(0.9)
+	}
+	if delay >= 100*time.Millisecond {
+		st.Delay = domains.Duration(clampDuration(roundUp(delay, 100*time.Millisecond), opts.Max))
+	}
+	return st
+}
+
+// save 有新樣本時寫回紀錄檔；先寫暫存檔再改名
+func (w *waitTuner) save() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.opts.State == "" || !w.dirty {
+		return nil
+	}
+	data, err := json.MarshalIndent(w.hosts, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := w.opts.State + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("寫入等待紀錄檔失敗: %w", err)
+	}
+	if err := os.Rename(tmp, w.opts.State); err != nil {
+		return fmt.Errorf("寫入等待紀錄檔失敗: %w", err)
+	}
+	w.dirty = false
+	return nil
+}
+
+func clampDuration(d, max time.Duration) time.Duration {
+	switch {
+	case d < 0:
+		return 0
+	case d > max:
+		return max
+	}
+	return d
+}
+
+func roundUp(d, unit time.Duration) time.Duration {
+	return (d + unit - 1) / unit * unit
+}
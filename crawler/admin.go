@@ -0,0 +1,243 @@
+package crawler
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/firehourse/cdpkit/browser"
+	"github.com/firehourse/cdpkit/crawler/frontier"
+)
+
+// Status 爬蟲目前的狀態，供管理端點的 /stats 使用
+type Status struct {
+	Summary Summary `json:"summary"`
+	// Paused 是否已 Pause
+	Paused bool `json:"paused"`
+	// Draining 是否已開始 Drain
+	Draining bool `json:"draining"`
+	// InFlight 進行中（含暫停時等待中）的頁面數
+	InFlight int `json:"inflight"`
+	// Queued Enqueue 排入但尚未開始處理的網址數
+	Queued int `json:"queued"`
+	// Browser 主要 Chrome 的健康狀態；已關閉時為 nil
+	Browser *browser.Health `json:"browser,omitempty"`
+	// Frontiers 進行中的 Crawl 的待爬佇列統計
+	Frontiers []frontier.Stats `json:"frontiers,omitempty"`
+}
+
+// FrontierDump /dump-frontier 回應中一個進行中的 Crawl 的佇列內容；
+// Frontier 未實作 frontier.Lister 時只有 Stats
+type FrontierDump struct {
+	Stats    frontier.Stats  `json:"stats"`
+	Queued   []frontier.Item `json:"queued,omitempty"`
+	InFlight []frontier.Item `json:"inflight,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// Status 回傳目前的狀態
+func (c *Crawler) Status() Status {
+	s := Status{Summary: c.Summary(), Queued: c.Queued()}
+	c.mu.Lock()
+	s.Paused = c.paused != nil
+	s.InFlight = c.active
+	select {
+	case <-c.draining:
+		s.Draining = true
+	default:
+	}
+	bm := c.bm
+	queues := c.crawlQueues()
+	c.mu.Unlock()
+
+	if bm != nil {
+		h := bm.Health()
+		s.Browser = &h
+	}
+	for _, q := range queues {
+		if st, err := q.store.Stats(); err == nil {
+			s.Frontiers = append(s.Frontiers, st)
+		}
+	}
+	return s
+}
+
+// DumpFrontier 回傳進行中的 Crawl 的待爬佇列，每個最多列出 limit 個排隊中的網址
+func (c *Crawler) DumpFrontier(limit int) []FrontierDump {
+	c.mu.Lock()
+	queues := c.crawlQueues()
+	c.mu.Unlock()
+
+	dumps := make([]FrontierDump, 0, len(queues))
+	for _, q := range queues {
+		var d FrontierDump
+		st, err := q.store.Stats()
+		if err == nil {
+			d.Stats = st
+			if l, ok := q.store.(frontier.Lister); ok {
+				d.Queued, d.InFlight, err = l.List(limit)
+			}
+		}
+		if err != nil {
+			d.Error = err.Error()
+		}
+		dumps = append(dumps, d)
+	}
+	return dumps
+}
+
+// AdminServer ServeAdmin 啟動的管理端點
+type AdminServer struct {
+	token   string
+	addr    string
+	handler http.Handler
+	server  *http.Server
+}
+
+// ServeAdmin 在 addr（預設 127.0.0.1:0）啟動管理端點，供長時間的爬取工作遠端觀察與控制：
+//
+//	GET  /healthz        Chrome 的健康狀態，無法恢復時回應 503
+//	GET  /stats          Status 的 JSON
+//	POST /pause          暫停派發新頁面
+//	POST /resume         恢復派發
+//	GET  /dump-frontier  進行中的 Crawl 的待爬佇列，?limit= 限制排隊網址數（預設 1000）
+//
+// 除了 /healthz 之外，所有路徑都須以隨機 token 開頭（見 AdminServer.URL），否則回傳 404。
+// /healthz 不需 token，可直接作為存活探測。Close 時一併關閉；設定 Options.Admin 時由 New 啟動
+func (c *Crawler) ServeAdmin(addr string) (*AdminServer, error) {
+	if addr == "" {
+		addr = "127.0.0.1:0"
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.admin != nil {
+		return nil, fmt.Errorf("管理端點已在 %s 啟動", c.admin.addr)
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("無法啟動管理端點: %w", err)
+	}
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		ln.Close()
+		return nil, err
+	}
+
+	s := &AdminServer{token: hex.EncodeToString(buf), addr: ln.Addr().String(), handler: c.AdminHandler()}
+	s.server = &http.Server{Handler: http.HandlerFunc(s.serveToken), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := s.server.Serve(ln); err != nil && err != http.ErrServerClosed {
+			log.Printf("[cdpkit] 管理端點結束: %v", err)
+		}
+	}()
+	c.admin = s
+	return s, nil
+}
+
+// AdminHandler 回傳不含 token 保護的管理端點，供掛載在自行管理驗證的伺服器上，路徑同 ServeAdmin
+func (c *Crawler) AdminHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", c.adminHealth)
+	mux.HandleFunc("GET /stats", func(w http.ResponseWriter, r *http.Request) {
+		writeAdminJSON(w, http.StatusOK, c.Status())
+	})
+	mux.HandleFunc("POST /pause", func(w http.ResponseWriter, r *http.Request) {
+		c.Pause()
+		writeAdminJSON(w, http.StatusOK, map[string]bool{"paused": true})
+	})
+	mux.HandleFunc("POST /resume", func(w http.ResponseWriter, r *http.Request) {
+		c.Resume()
+		writeAdminJSON(w, http.StatusOK, map[string]bool{"paused": false})
+	})
+	mux.HandleFunc("GET /dump-frontier", func(w http.ResponseWriter, r *http.Request) {
+		limit := 1000
+		if v := r.URL.Query().Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				http.Error(w, "limit 須為非負整數", http.StatusBadRequest)
+				return
+			}
+			limit = n
+		}
+		writeAdminJSON(w, http.StatusOK, map[string][]FrontierDump{"frontiers": c.DumpFrontier(limit)})
+	})
+	return mux
+}
+
+// URL 回傳管理端點的網址（含 token），例如 URL() + "stats"
+func (s *AdminServer) URL() string {
+	return "http://" + s.addr + "/" + s.token + "/"
+}
+
+// HealthURL 回傳不需 token 的存活探測網址
+func (s *AdminServer) HealthURL() string {
+	return "http://" + s.addr + "/healthz"
+}
+
+// Close 停止管理端點
+func (s *AdminServer) Close() error {
+	return s.server.Close()
+}
+
+// ----------------- 內部實作 -----------------
+
+// crawlQueues 呼叫端須持有 c.mu
+func (c *Crawler) crawlQueues() []*crawlQueue {
+	queues := make([]*crawlQueue, 0, len(c.crawls))
+	for q := range c.crawls {
+		queues = append(queues, q)
+	}
+	return queues
+}
+
+// adminHealth 回應 Chrome 的健康狀態與暫停、關閉狀態
+func (c *Crawler) adminHealth(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	bm := c.bm
+	paused := c.paused != nil
+	c.mu.Unlock()
+	if bm == nil {
+		writeAdminJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "closed"})
+		return
+	}
+	h := bm.Health()
+	code := http.StatusOK
+	if !h.Live() {
+		code = http.StatusServiceUnavailable
+	}
+	writeAdminJSON(w, code, map[string]interface{}{"status": h.Status, "paused": paused, "browser": h})
+}
+
+func writeAdminJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+// serveToken /healthz 直接處理，其餘路徑驗證並去除 token 前綴後交給 AdminHandler
+func (s *AdminServer) serveToken(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Path
+	if path == "/healthz" {
+		s.handler.ServeHTTP(w, r)
+		return
+	}
+	prefix := "/" + s.token + "/"
+	if len(path) < len(prefix) || subtle.ConstantTimeCompare([]byte(path[:len(prefix)]), []byte(prefix)) != 1 {
+		http.NotFound(w, r)
+		return
+	}
+	r2 := r.Clone(r.Context())
+	r2.URL.Path = path[len(prefix)-1:]
+	r2.URL.RawPath = ""
+	s.handler.ServeHTTP(w, r2)
+}
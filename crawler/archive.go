@@ -0,0 +1,33 @@
+package crawler
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/firehourse/cdpkit/tab"
+)
+
+// ----------------- 內部實作 -----------------
+
+// archive 將頁面的 MHTML 快照寫入 ArchiveDir，檔名為網址雜湊加上爬取時間，與抽樣資料相同
+func (c *Crawler) archive(pageTab *tab.Tab, result *Result) {
+	mhtml, err := pageTab.CaptureSnapshot()
+	if err != nil {
+		c.logf(2, "警告: %v", err)
+		return
+	}
+	if err := os.MkdirAll(c.options.ArchiveDir, 0755); err != nil {
+		c.logf(2, "警告: 無法建立封存目錄: %v", err)
+		return
+	}
+	sum := sha256.Sum256([]byte(result.URL))
+	path := filepath.Join(c.options.ArchiveDir, fmt.Sprintf("%x-%s.mhtml", sum[:8], result.Timestamp.Format("20060102-150405")))
+	if err := os.WriteFile(path, []byte(mhtml), 0644); err != nil {
+		c.logf(2, "警告: 寫入 MHTML 失敗: %v", err)
+		return
+	}
+	result.Archive = path
+	c.logf(4, "%s 已封存到 %s", result.URL, path)
+}
@@ -0,0 +1,59 @@
+package crawler
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrBandwidthExceeded 流量預算已用盡，頁面未載入或載入到一半被中斷；不會重試
+var ErrBandwidthExceeded = errors.New("超過流量預算")
+
+// Bandwidth 流量預算，以瀏覽器實際收到的位元組（含標頭、壓縮後的大小）計算，保護按流量計費的代理方案。
+// 用盡時分頁停止載入並拒絕之後的請求，頁面照常擷取並標記 Result.Truncated；
+// 預算用盡後的網址不再載入，以 ErrBandwidthExceeded 失敗。HTTP 優先取得的頁面以回應主體大小計入
+type Bandwidth struct {
+	// Total 整個爬蟲合計的位元組上限，0 表示不限
+	Total int64
+	// PerHost 每個 host 的位元組上限，0 表示不限；子資源（CDN、圖片等）計入所在頁面的 host
+	PerHost int64
+}
+
+// ----------------- 內部實作 -----------------
+
+// byteBudget 追蹤 Bandwidth 的用量
+type byteBudget struct {
+	limits Bandwidth
+
+	mu    sync.Mutex
+	total int64
+	hosts map[string]int64
+}
+
+func newByteBudget(limits Bandwidth) *byteBudget {
+	return &byteBudget{limits: limits, hosts: map[string]int64{}}
+}
+
+// charge 將 host 的頁面收到的 n 個位元組計入用量，回傳是否仍在預算內；n 為 0 時只查詢
+func (b *byteBudget) charge(host string, n int64) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.total += n
+	b.hosts[host] += n
+	if b.limits.Total > 0 && b.total >= b.limits.Total {
+		return false
+	}
+	return b.limits.PerHost <= 0 || b.hosts[host] < b.limits.PerHost
+}
+
+// used 回傳合計與各 host 的用量
+func (b *byteBudget) used() (int64, map[string]int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	hosts := make(map[string]int64, len(b.hosts))
+	for host, n := range b.hosts {
+		if n > 0 {
+			hosts[host] = n
+		}
+	}
+	return b.total, hosts
+}
@@ -0,0 +1,52 @@
+package crawler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"os"
+
+	"github.com/firehourse/cdpkit/bundle"
+)
+
+// NewBundle 將結果、設定、腳本、摘要、稽核紀錄與法律文件打包，
+// 呼叫端可再加入其他檔案後以 WriteFile 輸出並簽章
+func (c *Crawler) NewBundle(results []Result, jsScript string) (*bundle.Builder, error) {
+	b := bundle.NewBuilder(c.options.JobID)
+
+	if err := b.AddJSON("results.json", results); err != nil {
+		return nil, err
+	}
+	if err := b.AddJSON("summary.json", c.Summary()); err != nil {
+		return nil, err
+	}
+
+	// 代理帳密不應出現在封存檔中
+	opts := c.options
+	if u, err := url.Parse(opts.ProxyURL); err == nil && opts.ProxyURL != "" {
+		opts.ProxyURL = u.Redacted()
+	}
+	if err := b.AddJSON("options.json", opts); err != nil {
+		return nil, err
+	}
+
+	if jsScript != "" {
+		sum := sha256.Sum256([]byte(jsScript))
+		b.AddBytes("extractor.js", []byte(jsScript))
+		b.Meta["extractor_sha256"] = hex.EncodeToString(sum[:])
+	}
+
+	if c.audit != nil {
+		if err := b.AddJSON("audit.json", c.audit.Entries()); err != nil {
+			return nil, err
+		}
+	}
+	if c.legal != nil {
+		if _, err := os.Stat(c.legal.dir); err == nil {
+			if err := b.AddDir("legal", c.legal.dir); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return b, nil
+}
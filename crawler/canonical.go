@@ -0,0 +1,91 @@
+package crawler
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// CanonicalOptions 控制 canonical JSON 的正規化方式
+type CanonicalOptions struct {
+	// KeepHTML 是否保留 HTML；預設省略，因為 HTML 中的 nonce、追蹤參數每次都不同
+	KeepHTML bool
+	// ExcludeKeys 額外從 Data 移除的易變欄位（例如 "fetchedAt"、"csrf"）
+	ExcludeKeys []string
+}
+
+// ResultsToCanonicalJSON 產生適合逐日 diff 的 JSON：依 URL 排序、鍵順序固定、
+// 字串內空白正規化，並排除 timestamp、elapsed_time 等每次爬取都會變動的欄位
+func ResultsToCanonicalJSON(results []Result, opts CanonicalOptions) ([]byte, error) {
+	exclude := make(map[string]bool, len(opts.ExcludeKeys))
+	for _, k := range opts.ExcludeKeys {
+		exclude[k] = true
+	}
+
+	out := make([]map[string]interface{}, 0, len(results))
+	for _, r := range results {
+		m := map[string]interface{}{"url": r.URL}
+		if r.Title != "" {
+			m["title"] = normalizeSpace(r.Title)
+		}
+		if r.Error != "" {
+			m["error"] = r.Error
+		}
+		if r.ResponseCode != 0 {
+			m["response_code"] = r.ResponseCode
+		}
+		if opts.KeepHTML && r.HTML != "" {
+			m["html"] = r.HTML
+		}
+		if len(r.Data) > 0 {
+			data := make(map[string]interface{}, len(r.Data))
+			for k, v := range r.Data {
+				if !exclude[k] {
+					data[k] = canonicalValue(v)
+				}
+			}
+			m["data"] = data
+		}
+		out = append(out, m)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i]["url"].(string) < out[j]["url"].(string)
+	})
+
+	// encoding/json 對 map 鍵排序，輸出順序因此固定；關閉 HTML 跳脫讓 diff 更易讀
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// canonicalValue 遞迴正規化字串中的空白
+func canonicalValue(v interface{}) interface{} {
+	switch x := v.(type) {
+	case string:
+		return normalizeSpace(x)
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(x))
+		for k, child := range x {
+			m[k] = canonicalValue(child)
+		}
+		return m
+	case []interface{}:
+		a := make([]interface{}, len(x))
+		for i, child := range x {
+			a[i] = canonicalValue(child)
+		}
+		return a
+	}
+	return v
+}
+
+// normalizeSpace 去除頭尾空白並將連續空白（含換行）合併為單一空格
+func normalizeSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,160 @@
+package crawler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Checkpoint FetchAll 的進度檔內容，設定 Options.Checkpoint 時定期寫出
+type Checkpoint struct {
+	JobID   string    `json:"job_id"`
+	SavedAt time.Time `json:"saved_at"`
+	// Done 已完成（含失敗）且結果已交給呼叫端的輸入網址
+	Done []string `json:"done"`
+	// Pending 尚未完成的輸入網址（含處理中），依輸入順序
+	Pending []string `json:"pending"`
+}
+
+// LoadCheckpoint 讀取進度檔
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("無法讀取進度檔 %s: %w", path, err)
+	}
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("解析進度檔 %s 失敗: %w", path, err)
+	}
+	return &cp, nil
+}
+
+// ----------------- 內部實作 -----------------
+
+// checkpointer 追蹤一次 FetchAll 的進度並定期寫入進度檔
+type checkpointer struct {
+	path  string
+	jobID string
+
+	mu    sync.Mutex
+	urls  []string
+	done  map[string]bool
+	dirty bool
+	stop  chan struct{}
+	wg    sync.WaitGroup
+}
+
+// openCheckpoint 載入既有的進度檔（不存在時從頭開始），並每隔 interval 寫出一次
+func (c *Crawler) openCheckpoint(urls []string) (*checkpointer, error) {
+	cp := &checkpointer{
+		path:  c.options.Checkpoint,
+		jobID: c.options.JobID,
+		urls:  urls,
+		done:  map[string]bool{},
+		stop:  make(chan struct{}),
+	}
+	prev, err := LoadCheckpoint(cp.path)
+	switch {
+	case err == nil:
+		for _, u := range prev.Done {
+			cp.done[u] = true
+		}
+		c.logf(3, "從進度檔 %s 繼續: 已完成 %d 個網址（%s 寫出）", cp.path, len(prev.Done), prev.SavedAt.Format(time.RFC3339))
+	case errors.Is(err, os.ErrNotExist):
+	default:
+		return nil, err
+	}
+
+	interval := c.options.CheckpointInterval
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	cp.wg.Add(1)
+	go func() {
+		defer cp.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := cp.save(); err != nil {
+					c.logf(1, "%v", err)
+				}
+			case <-cp.stop:
+				return
+			}
+		}
+	}()
+	return cp, nil
+}
+
+// skip 網址是否已在先前的執行中完成
+func (cp *checkpointer) skip(url string) bool {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	return cp.done[url]
+}
+
+// finish 標記網址已完成
+func (cp *checkpointer) finish(url string) {
+	cp.mu.Lock()
+	cp.done[url] = true
+	cp.dirty = true
+	cp.mu.Unlock()
+}
+
+// close 停止定期寫出並寫出最後的進度
+func (cp *checkpointer) close() error {
+	close(cp.stop)
+	cp.wg.Wait()
+	cp.mu.Lock()
+	cp.dirty = true
+	cp.mu.Unlock()
+	return cp.save()
+}
+
+// save 有變更時以暫存檔加 rename 寫出，中途當機也不會留下不完整的進度檔
+func (cp *checkpointer) save() error {
+	cp.mu.Lock()
+	if !cp.dirty {
+		cp.mu.Unlock()
+		return nil
+	}
+	state := Checkpoint{JobID: cp.jobID, SavedAt: time.Now(), Done: make([]string, 0, len(cp.done)), Pending: []string{}}
+	listed := make(map[string]bool, len(cp.urls))
+	for _, u := range cp.urls {
+		if listed[u] {
+			continue
+		}
+		listed[u] = true
+		if cp.done[u] {
+			state.Done = append(state.Done, u)
+		} else {
+			state.Pending = append(state.Pending, u)
+		}
+	}
+	// 保留先前執行中完成、但不在本次輸入中的網址
+	for u := range cp.done {
+		if !listed[u] {
+			state.Done = append(state.Done, u)
+		}
+	}
+	cp.dirty = false
+	cp.mu.Unlock()
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := cp.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("寫入進度檔失敗: %w", err)
+	}
+	if err := os.Rename(tmp, cp.path); err != nil {
+		return fmt.Errorf("寫入進度檔失敗: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,378 @@
+package crawler
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/firehourse/cdpkit/crawler/frontier"
+	"github.com/firehourse/cdpkit/tab"
+)
+
+// CrawlOptions 遞迴爬取的範圍；零值欄位使用預設值
+type CrawlOptions struct {
+	// MaxDepth 自種子網址起最多跟隨幾層連結，預設 3；設為負值則不限
+	MaxDepth int
+	// SameHostOnly 只跟隨與種子網址相同 host 的連結
+	SameHostOnly bool
+	// URLFilters 正規表示式，設定時連結須符合其中任一才會跟隨；種子網址不受限制
+	URLFilters []string
+	// MaxPages 最多爬取的頁面數（含種子），預設 100
+	MaxPages int
+	// LinkSelector 擷取連結的選擇器，預設 "a[href]"；可限定範圍，例如 "main" 或 ".pagination"
+	LinkSelector string
+	// Script 每個頁面的擷取腳本，同 FetchAll 的 jsScript
+	Script string
+	// Frontier 待爬佇列，預設為記憶體佇列；使用 frontier.OpenBolt 或 frontier.OpenRedis
+	// 保存進度時，中斷後以相同的 Frontier 再次呼叫 Crawl 即從上次的進度繼續
+	Frontier frontier.Frontier
+	// Priority 計算連結的優先度，數值越大越先爬取；預設為 -depth，即先爬完淺層
+	Priority func(link string, depth int) int
+	// MaxRetries 載入失敗的頁面稍後重新排入佇列的次數，預設 0 不重試
+	MaxRetries int
+}
+
+// Crawl 從種子網址開始遞迴爬取：每個頁面的連結經正規化與去除重複後加入待爬佇列，
+// 由 Concurrency 個工作者處理，直到佇列清空或達到 MaxPages。
+// 連結同樣受 Hooks 的 url_filter 與 Policy 的網域允許清單限制。
+// Drain 開始後回傳已完成的結果與 ErrDraining，處理中的網址保留在 Frontier 供下次繼續
+func (c *Crawler) Crawl(seeds []string, opts CrawlOptions) ([]Result, error) {
+	if opts.MaxDepth == 0 {
+		opts.MaxDepth = 3
+	}
+	if opts.MaxPages <= 0 {
+		opts.MaxPages = 100
+	}
+	if opts.LinkSelector == "" {
+		opts.LinkSelector = "a[href]"
+	}
+	filters := make([]*regexp.Regexp, 0, len(opts.URLFilters))
+	for _, f := range opts.URLFilters {
+		re, err := regexp.Compile(f)
+		if err != nil {
+			return nil, fmt.Errorf("無效的 URL 過濾條件 %q: %w", f, err)
+		}
+		filters = append(filters, re)
+	}
+
+	if opts.Priority == nil {
+		opts.Priority = func(_ string, depth int) int { return -depth }
+	}
+	store := opts.Frontier
+	if store == nil {
+		store = frontier.NewMemory()
+	}
+	if st, err := store.Stats(); err == nil && st.Seen > 0 {
+		c.logf(3, "從上次的進度繼續: 已看過 %d 個網址，%d 個待爬", st.Seen, st.Queued)
+	}
+
+	f := newCrawlQueue(store, opts.MaxPages)
+	c.mu.Lock()
+	c.crawls[f] = struct{}{}
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.crawls, f)
+		c.mu.Unlock()
+	}()
+	seedHosts := map[string]bool{}
+	for _, s := range seeds {
+		u, ok := NormalizeURL(s)
+		if !ok {
+			c.logf(2, "警告: 略過無效的種子網址 %s", s)
+			continue
+		}
+		seedHosts[hostOf(u)] = true
+		if err := f.push(frontier.Item{URL: u, Priority: opts.Priority(u, 0)}); err != nil {
+			return nil, err
+		}
+	}
+
+	// follow 判斷連結是否在爬取範圍內
+	follow := func(link string) bool {
+		if opts.SameHostOnly && !seedHosts[hostOf(link)] {
+			return false
+		}
+		if len(filters) > 0 && !matchAny(filters, link) {
+			return false
+		}
+		return c.options.Hooks.AllowURL(link) && c.options.Policy.AllowURL(link) == nil
+	}
+
+	var (
+		mu      sync.Mutex
+		results []Result
+		drained bool
+		wg      sync.WaitGroup
+	)
+	for i := 0; i < c.options.Concurrency; i++ {
+		wg.Add(1)
+		go func(workerID int) {
+			defer wg.Done()
+			for {
+				item, ok := f.next()
+				if !ok {
+					return
+				}
+				c.logf(3, "工作者 %d: 開始處理 %s (深度 %d)", workerID, item.URL, item.Depth)
+				result, err := c.fetchLinks(item.URL, opts.Script, opts.LinkSelector)
+				if errors.Is(err, ErrDraining) {
+					mu.Lock()
+					drained = true
+					mu.Unlock()
+					f.stop()
+					f.release()
+					return
+				}
+				if err != nil {
+					c.logf(2, "工作者 %d: 爬取 %s 失敗: %v", workerID, item.URL, err)
+					if item.Retries < opts.MaxRetries {
+						c.logf(3, "工作者 %d: %s 稍後重試 (第 %d 次)", workerID, item.URL, item.Retries+1)
+						f.retry(item)
+						continue
+					}
+				}
+
+				result.Depth = item.Depth
+				result.Links = normalizeLinks(result.Links)
+				if opts.MaxDepth < 0 || item.Depth < opts.MaxDepth {
+					for _, link := range result.Links {
+						if follow(link) {
+							f.push(frontier.Item{URL: link, Depth: item.Depth + 1, Priority: opts.Priority(link, item.Depth+1)})
+						}
+					}
+				}
+
+				mu.Lock()
+				results = append(results, result)
+				mu.Unlock()
+				f.done(item)
+			}
+		}(i + 1)
+	}
+	wg.Wait()
+
+	if st, err := store.Stats(); err == nil {
+		c.logf(3, "遞迴爬取完成: %d 個頁面，發現 %d 個網址，%d 個待爬", len(results), st.Seen, st.Queued+st.InFlight)
+	}
+	if c.incr != nil {
+		if err := c.incr.save(); err != nil {
+			c.logf(1, "%v", err)
+		}
+	}
+	if c.options.Hooks != nil {
+		results = Pipeline{c.options.Hooks.Transformer()}.Apply(results)
+	}
+	results = c.options.Transforms.Apply(results)
+	if drained {
+		return results, ErrDraining
+	}
+	return results, f.err
+}
+
+// NormalizeURL 正規化網址以便去除重複：scheme 與 host 轉小寫、移除預設 port 與 fragment、
+// 移除 utm_* 等追蹤參數並排序查詢參數。非 http(s) 網址回傳 false
+func NormalizeURL(raw string) (string, bool) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", false
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", false
+	}
+	host := strings.ToLower(u.Hostname())
+	if strings.Contains(host, ":") {
+		// IPv6 位址須加上方括號
+		host = "[" + host + "]"
+	}
+	if port := u.Port(); port != "" && !(u.Scheme == "http" && port == "80") && !(u.Scheme == "https" && port == "443") {
+		host += ":" + port
+	}
+	u.Host = host
+	u.User = nil
+	u.Fragment, u.RawFragment = "", ""
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	if u.RawQuery != "" {
+		q := u.Query()
+		for k := range q {
+			if isTrackingParam(k) {
+				q.Del(k)
+			}
+		}
+		u.RawQuery = q.Encode()
+	}
+	return u.String(), true
+}
+
+// ----------------- 內部實作 -----------------
+
+// crawlQueue 在 Frontier 之上協調工作者：追蹤處理中的頁面以判斷何時爬取完畢，並限制本次爬取的頁面數。
+// Frontier 出錯時停止派發，錯誤由 Crawl 回傳
+type crawlQueue struct {
+	store frontier.Frontier
+	mu    sync.Mutex
+	cond  *sync.Cond
+	// active 已取出但尚未完成的頁面；scheduled 本次已取出的頁面數，不超過 limit
+	active    int
+	scheduled int
+	limit     int
+	stopped   bool
+	err       error
+}
+
+func newCrawlQueue(store frontier.Frontier, limit int) *crawlQueue {
+	q := &crawlQueue{store: store, limit: limit}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// push 加入未看過的網址
+func (q *crawlQueue) push(item frontier.Item) error {
+	added, err := q.store.Push(item)
+	if err != nil {
+		q.fail(err)
+		return err
+	}
+	if added {
+		// 持有鎖再通知，next 在 Pop 與 Wait 之間持有鎖，通知不會遺失
+		q.mu.Lock()
+		q.cond.Signal()
+		q.mu.Unlock()
+	}
+	return nil
+}
+
+// next 取出下一個網址；佇列暫時為空但仍有頁面處理中時等待其產生新連結。
+// 已達上限、已停止或佇列清空且沒有處理中的頁面時回傳 false
+func (q *crawlQueue) next() (frontier.Item, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for {
+		if q.stopped || q.scheduled >= q.limit {
+			return frontier.Item{}, false
+		}
+		item, ok, err := q.store.Pop()
+		if err != nil {
+			q.stopped = true
+			q.err = fmt.Errorf("讀取待爬佇列失敗: %w", err)
+			q.cond.Broadcast()
+			return frontier.Item{}, false
+		}
+		if ok {
+			q.active++
+			q.scheduled++
+			return item, true
+		}
+		if q.active == 0 {
+			return frontier.Item{}, false
+		}
+		q.cond.Wait()
+	}
+}
+
+// done 標記頁面處理完畢
+func (q *crawlQueue) done(item frontier.Item) {
+	if err := q.store.Done(item); err != nil {
+		q.fail(err)
+	}
+	q.release()
+}
+
+// retry 將頁面重新排入佇列，不計入本次的頁面數
+func (q *crawlQueue) retry(item frontier.Item) {
+	if err := q.store.Retry(item); err != nil {
+		q.fail(err)
+	}
+	q.mu.Lock()
+	q.scheduled--
+	q.mu.Unlock()
+	q.release()
+}
+
+// release 結束一個處理中的頁面，但不更新 Frontier；Drain 時頁面保留為處理中，下次重新開啟時放回佇列
+func (q *crawlQueue) release() {
+	q.mu.Lock()
+	q.active--
+	q.mu.Unlock()
+	q.cond.Broadcast()
+}
+
+// stop 停止派發，等待中的工作者隨即結束
+func (q *crawlQueue) stop() {
+	q.mu.Lock()
+	q.stopped = true
+	q.mu.Unlock()
+	q.cond.Broadcast()
+}
+
+func (q *crawlQueue) fail(err error) {
+	q.mu.Lock()
+	if q.err == nil {
+		q.err = fmt.Errorf("寫入待爬佇列失敗: %w", err)
+	}
+	q.stopped = true
+	q.mu.Unlock()
+	q.cond.Broadcast()
+}
+
+// normalizeLinks 正規化並去除重複，保留出現順序
+func normalizeLinks(links []string) []string {
+	if len(links) == 0 {
+		return nil
+	}
+	seen := make(map[string]bool, len(links))
+	out := make([]string, 0, len(links))
+	for _, l := range links {
+		n, ok := NormalizeURL(l)
+		if !ok || seen[n] {
+			continue
+		}
+		seen[n] = true
+		out = append(out, n)
+	}
+	return out
+}
+
+// collectLinks 取得頁面上的連結；失敗時只記錄日誌，不影響頁面結果
+func (c *Crawler) collectLinks(pageTab *tab.Tab, selector string) []string {
+	links, err := pageTab.Links(selector)
+	if err != nil {
+		c.logf(2, "警告: %v", err)
+		return nil
+	}
+	return links
+}
+
+// trackingParams 移除的追蹤參數；另外移除所有 utm_ 開頭的參數
+var trackingParams = map[string]bool{
+	"fbclid": true, "gclid": true, "dclid": true, "msclkid": true,
+	"mc_cid": true, "mc_eid": true, "_ga": true, "yclid": true,
+}
+
+func isTrackingParam(k string) bool {
+	k = strings.ToLower(k)
+	return strings.HasPrefix(k, "utm_") || trackingParams[k]
+}
+
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+func matchAny(res []*regexp.Regexp, s string) bool {
+	for _, re := range res {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
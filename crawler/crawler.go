@@ -3,27 +3,68 @@ package crawler
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"math/rand"
+	"net/http"
 	"sync"
 	"time"
 
+	"github.com/firehourse/cdpkit/audit"
+	"github.com/firehourse/cdpkit/blocklist"
 	"github.com/firehourse/cdpkit/browser"
 	"github.com/firehourse/cdpkit/config"
+	"github.com/firehourse/cdpkit/domains"
+	"github.com/firehourse/cdpkit/liveview"
+	"github.com/firehourse/cdpkit/pageaudit"
+	"github.com/firehourse/cdpkit/policy"
 	"github.com/firehourse/cdpkit/tab"
+	"github.com/firehourse/cdpkit/warc"
 )
 
+// ErrDraining 爬蟲已開始關閉（見 Drain），不再接受新的頁面
+var ErrDraining = errors.New("爬蟲正在關閉，不再接受新工作")
+
 // Result 表示單個頁面的爬取結果
 type Result struct {
-	URL           string                 `json:"url"`
-	Title         string                 `json:"title,omitempty"`
-	HTML          string                 `json:"html,omitempty"`
-	Data          map[string]interface{} `json:"data,omitempty"`
-	Error         string                 `json:"error,omitempty"`
-	ResponseCode  int                    `json:"response_code,omitempty"`
-	ElapsedTime   time.Duration          `json:"elapsed_time,omitempty"`
-	Timestamp     time.Time              `json:"timestamp"`
-	RawJSResponse interface{}            `json:"-"` // 原始JS返回值，不序列化
+	URL            string                 `json:"url"`
+	Title          string                 `json:"title,omitempty"`
+	HTML           string                 `json:"html,omitempty"`
+	Data           map[string]interface{} `json:"data,omitempty"`
+	Error          string                 `json:"error,omitempty"`
+	ResponseCode   int                    `json:"response_code,omitempty"`
+	FinalURL       string                 `json:"final_url,omitempty"`      // 經過所有重新導向後主文件的網址
+	RedirectChain  []Redirect             `json:"redirect_chain,omitempty"` // 主文件經過的重新導向，依發生順序排列
+	TLS            *TLSInfo               `json:"tls,omitempty"`            // 啟用 CaptureTLS 時主文件的 TLS 參數與憑證鏈
+	Findings       []Finding              `json:"findings,omitempty"`       // 設定 Scan 時合規掃描發現的問題
+	Audit          *pageaudit.Report      `json:"audit,omitempty"`          // 設定 PageAudit 時的效能、無障礙與最佳實務計分報告
+	ElapsedTime    time.Duration          `json:"elapsed_time,omitempty"`
+	Unchanged      bool                   `json:"unchanged,omitempty"`       // 增量模式下自上次爬取後未變更，未重新擷取
+	Truncated      bool                   `json:"truncated,omitempty"`       // 設定 Bandwidth 時流量預算用盡，頁面未載入完整或未載入
+	JobID          string                 `json:"job_id,omitempty"`          // 啟用 TagRequests 時記錄關聯 ID
+	Page           int                    `json:"page,omitempty"`            // 啟用 Pagination 時為列表的頁碼
+	Depth          int                    `json:"depth,omitempty"`           // Crawl 時距離種子網址的連結層數
+	Links          []string               `json:"links,omitempty"`           // Crawl 時頁面上的連結（已正規化、去除重複）
+	HTTPOnly       bool                   `json:"http_only,omitempty"`       // 啟用 HTTPFirst 時以 HTTP 取得，未經瀏覽器導航
+	Attempts       int                    `json:"attempts,omitempty"`        // 爬取次數，含重試
+	Screenshot     []byte                 `json:"-"`                         // 啟用 Screenshot 或抽中稽核時的整頁 PNG 截圖，不序列化
+	HAR            []byte                 `json:"-"`                         // 抽中稽核時頁面載入的 HAR，不序列化
+	Sampled        bool                   `json:"sampled,omitempty"`         // 依 SampleRate 抽中品質稽核，已保存完整 HTML、截圖與 HAR
+	Archive        string                 `json:"archive,omitempty"`         // 設定 ArchiveDir 時頁面 MHTML 快照的檔案路徑
+	StructuredData *StructuredData        `json:"structured_data,omitempty"` // 啟用 StructuredData 時的 JSON-LD、OpenGraph、Twitter card 與 microdata
+	Timings        *Timings               `json:"timings,omitempty"`         // 各階段耗時與資源數
+	Tags           map[string]string      `json:"tags,omitempty"`            // Enqueue 時 Meta.Tags 的標記
+	Timestamp      time.Time              `json:"timestamp"`
+	RawJSResponse  interface{}            `json:"-"` // 原始JS返回值，不序列化
+}
+
+// Redirect 導航經過的一次重新導向
+type Redirect struct {
+	URL      string `json:"url"`
+	Location string `json:"location"`
+	// Status HTTP 重新導向的 3xx 狀態碼；meta refresh 或 JS 造成的導航為 0
+	Status int `json:"status,omitempty"`
 }
 
 // Options 爬蟲配置選項
@@ -48,8 +89,167 @@ type Options struct {
 	DebugPort int
 	// 是否保存完整HTML
 	SaveHTML bool
+	// 是否擷取整頁截圖（PNG），存於 Result.Screenshot，可由 output 的物件儲存或 webhook 輸出
+	Screenshot bool
+	// 截圖前等待網頁字型與圖片就緒的條件，套用於 Screenshot 與品質抽樣的截圖；nil 時立即截圖
+	ScreenshotReady *tab.ReadyOptions
+	// 截圖（含品質抽樣）前停止 CSS 動畫、transition 與影片播放，避免輪播、讀取動畫造成視覺比對的誤判
+	StopAnimations bool
+	// 是否記錄主文件連線協商的 TLS 版本、加密套件與伺服器憑證鏈，存於 Result.TLS；HTTP 優先取得的頁面不記錄
+	CaptureTLS bool
+	// 合規掃描：檢查每個頁面的混合內容、安全性標頭、第三方網域與 cookie 屬性，存於 Result.Findings；
+	// 需要瀏覽器載入的回應，設定後不走 HTTP 優先
+	Scan *Scan
+	// 頁面稽核：以效能指標、JS 與 CSS 覆蓋率、console 錯誤、混合內容與無障礙樹為每個頁面計分，存於 Result.Audit；
+	// 需要瀏覽器載入，設定後不走 HTTP 優先。與記錄操作的 Audit 無關
+	PageAudit *pageaudit.Options
+	// 是否擷取 JSON-LD、OpenGraph、Twitter card 與 microdata，存於 Result.StructuredData；HTTP 優先時同樣適用
+	StructuredData bool
+	// SampleRate 0~1，隨機抽出此比例的頁面保存完整 HTML、整頁截圖與 HAR，不受 SaveHTML、Screenshot 影響，
+	// 供大量爬取時以少量成本持續檢查擷取品質；抽中的結果 Sampled 為 true
+	SampleRate float64
+	// SampleByURL 為 true 時依網址雜湊抽樣，同一網址每次執行都會（或都不會）被抽中，方便跨次比較
+	SampleByURL bool
+	// SampleDir 設定時將抽樣的 HTML、截圖與 HAR 寫入此目錄
+	SampleDir string
+	// ArchiveDir 設定時將每個頁面連同圖片、樣式等子資源保存為單一 MHTML 檔寫入此目錄，
+	// 檔案路徑記錄於 Result.Archive；需要瀏覽器渲染，設定後不走 HTTP 優先
+	ArchiveDir string
 	// 日誌級別 (0=無, 1=錯誤, 2=警告, 3=信息, 4=調試)
 	LogLevel int
+	// 是否記錄稽核紀錄（導航、腳本執行、下載等），可透過 AuditLog 匯出
+	Audit bool
+	// 工作ID，寫入稽核紀錄；留空則以啟動時間產生
+	JobID string
+	// 合規防護：網域允許清單與結果個資遮蔽
+	Policy *policy.Policy
+	// 是否封存每個網域的 robots.txt、security.txt 與服務條款頁面
+	CaptureLegal bool
+	// 法律文件封存目錄，預設為 legal
+	LegalDir string
+	// 服務條款頁面候選路徑，依序嘗試，預設 /terms、/tos 等
+	TermsPaths []string
+	// WARC 輸出路徑，副檔名為 .gz 時壓縮；留空則不輸出
+	WARCPath string
+	// 是否一併封存子資源（圖片、腳本、XHR 等）
+	WARCSubresources bool
+	// 阻擋的資源類型，例如 "image"、"font"、"media"、"stylesheet"，可大幅降低頻寬與載入時間
+	BlockResources []string
+	// 阻擋的 URL 模式；不含 * 時為子字串比對，含 * 時為萬用字元比對
+	BlockURLPatterns []string
+	// 追蹤器與廣告阻擋清單，例如 blocklist.Default() 或 blocklist.Load 載入的 EasyList；
+	// 阻擋的請求數統計於 Summary
+	Blocklist *blocklist.List `json:"-"`
+	// 模擬的裝置名稱，例如 "iPhone 14"，用於爬取行動版網站
+	Device string
+	// 流量預算；用盡時中斷頁面載入並標記 Result.Truncated，nil 表示不限制
+	Bandwidth *Bandwidth
+	// 模擬的網路狀況，例如 tab.NetworkPresets["Slow 3G"]，用於測量頁面在行動網路下的載入表現；
+	// HTTP 優先取得的頁面不受限制
+	Throttle *tab.NetworkConditions
+	// 結果後處理（過濾、補充、重整），於 FetchAll 回傳前依序套用
+	Transforms Pipeline `json:"-"`
+	// 增量爬取狀態檔；設定後記錄每個 URL 的 ETag、Last-Modified 與內容雜湊，
+	// 未變更的頁面標記為 Unchanged 並略過擷取
+	IncrementalState string
+	// 增量模式下導航前先以頁面 session 送出條件式 HEAD，未變更時完全略過導航
+	IncrementalHEAD bool
+	// 各網域的設定覆寫（等待條件、標頭、cookies、速率限制、擷取腳本等），可由 domains.Load 載入
+	Domains domains.Overrides
+	// 依各 host 過去的就緒時間自動調整導航後的等待；nil 時未設定等待條件的網域固定等待 2 秒
+	AdaptiveWait *AdaptiveWait
+	// 停用 RegisterHandler 註冊的網站專用處理器，一律使用預設流程
+	DisableHandlers bool
+	// 以 jq 運算式撰寫的 URL 過濾、欄位計算與重試條件，可由 LoadHooks 載入
+	Hooks *Hooks `json:"-"`
+	// 是否在瀏覽器送出的每個請求加上 JobID 標頭，並在日誌與結果中記錄，
+	// 方便與配合的目標網站比對兩端日誌
+	TagRequests bool
+	// 關聯標頭名稱，預設 X-Cdpkit-Job
+	TagHeader string
+	// 自行啟動的 Chrome 的資源限制（CPU 優先權、JS heap、cgroup 記憶體與行程數）
+	Limits config.ResourceLimits
+	// Chrome 日誌與崩潰傾印的收集目錄；崩潰紀錄會列在 Summary.Crashes
+	CrashDir string
+	// 宣告式擷取規則；Fetch、FetchAll 的腳本為空時依此擷取，Data 依欄位型別轉型
+	Extract ExtractSpec
+	// 自動翻頁與無限捲動；FetchAll 會將每個網址視為列表第一頁走訪後續頁面
+	Pagination *Pagination
+	// DevTools 代理的監聽位址，例如 "127.0.0.1:9333"；設定後可在爬取時以 DevTools 檢視各分頁，
+	// 網址（含 token）記錄於日誌並可由 DevToolsURL 取得
+	DevTools string
+	// LiveView 畫面串流的監聽位址，例如 "127.0.0.1:9334"；設定後可在瀏覽器中即時觀看各分頁的操作畫面，
+	// 網址（含 token）記錄於日誌並可由 LiveViewURL 取得
+	LiveView string
+	// Admin 管理端點的監聽位址，例如 "127.0.0.1:9335"；設定後可遠端查詢狀態、暫停與恢復，見 ServeAdmin
+	Admin string
+	// 每個網址之間的隔離程度，預設 fresh-tab；見 IsolationSharedTab 等常數
+	Isolation Isolation
+	// 先以 HTTP GET 取得頁面，不需要 JS 渲染時略過瀏覽器導航；nil 時一律使用瀏覽器
+	HTTPFirst *HTTPFirst
+	// 同一 host 兩次導航之間的最小間隔；網域設定的 interval 優先
+	PerHostDelay time.Duration
+	// 同一 host 同時處理的頁面上限，避免所有工作者同時湧向同一網站；網域設定的 concurrency 優先
+	PerHostConcurrency int
+	// 全部 host 合計每秒最多開始的頁面數，0 表示不限
+	MaxQPS float64
+	// 暫時性失敗（逾時、網路錯誤、5xx、代理錯誤等）的自動重試；nil 時只依 Hooks 的重試條件
+	Retry *Retry
+	// 分頁持續無回應多久後強制關閉並以 tab.ErrRendererHung 結束，不必等到 Timeout；0 表示停用
+	HangTimeout time.Duration
+	// 最慢頁面報告保留的頁數（見 Summary.Latency），預設 10；負值表示不保留
+	SlowPages int
+	// FetchAll 的進度檔；設定後定期記錄已完成與待處理的網址，當機或重新部署後以相同設定重新執行時
+	// 略過已完成的網址。檔案在完成後保留，刪除即可從頭開始；見 Checkpoint
+	Checkpoint string
+	// 寫出進度檔的間隔，預設 30 秒；結束時（含 Drain）一律再寫出一次
+	CheckpointInterval time.Duration
+	// 本次執行的隨機種子，決定抽樣、未指定 UA 時選擇的 UA 與 stealth 的指紋雜訊；0 時由 New 隨機產生。
+	// 種子與完整設定記錄於 Manifest，以 Manifest.Reproduce 重新執行可得到相同的選擇
+	Seed int64
+	// 分層的瀏覽器設定；非零欄位取代 Timeout、ProxyURL、UserAgent、WindowSize、DebugPort、BrowserFlags、
+	// Limits、CrashDir、Device、Policy、HangTimeout 等重複的欄位，其餘分頁層設定（指紋、時區、語系、
+	// 地理位置）套用到每個分頁，網域設定覆寫仍優先
+	Config *config.Layered
+	// Browser 使用既有的 BrowserManager，例如以 browser.NewManagerFromAllocator 包裝自有的 chromedp allocator；
+	// 此時 BrowserFlags、DebugPort、ProxyURL 等瀏覽器設定不套用到它，Close 與 Drain 也不會將它關閉
+	Browser *browser.BrowserManager `json:"-"`
+}
+
+// Summary 一次爬取工作的摘要
+type Summary struct {
+	JobID     string    `json:"job_id"`
+	StartedAt time.Time `json:"started_at"`
+	// Pages 已處理的頁面數
+	Pages int `json:"pages"`
+	// Failed 失敗的頁面數
+	Failed int `json:"failed"`
+	// Unchanged 增量模式下未變更而略過的頁面數
+	Unchanged int `json:"unchanged,omitempty"`
+	// HTTPOnly 啟用 HTTPFirst 時未經瀏覽器導航的頁面數
+	HTTPOnly int `json:"http_only,omitempty"`
+	// Sampled 依 SampleRate 抽中品質稽核的頁面數
+	Sampled int `json:"sampled,omitempty"`
+	// Blocked 依 Options.Blocklist 阻擋的請求數；BlockedHosts 為各 host 的阻擋數
+	Blocked      int            `json:"blocked,omitempty"`
+	BlockedHosts map[string]int `json:"blocked_hosts,omitempty"`
+	// Findings 設定 Scan 時各規則發現的問題數
+	Findings map[string]int `json:"findings,omitempty"`
+	// 設定 Bandwidth 時瀏覽器收到的位元組合計與各 host 的用量，以及因預算用盡而不完整的頁面數
+	Bytes       int64            `json:"bytes,omitempty"`
+	BytesByHost map[string]int64 `json:"bytes_by_host,omitempty"`
+	Truncated   int              `json:"truncated,omitempty"`
+	// 設定 AdaptiveWait 時各 host 推得的等待策略
+	Waits map[string]WaitStrategy `json:"waits,omitempty"`
+	// 設定 PageAudit 時各頁面總分與各類別分數的平均
+	AuditScore      int            `json:"audit_score,omitempty"`
+	AuditCategories map[string]int `json:"audit_categories,omitempty"`
+	// Latency 各 host 的階段延遲分位數與最慢的頁面；尚未處理頁面時為 nil
+	Latency *LatencyReport `json:"latency,omitempty"`
+	// Legal 各網域封存的法律文件
+	Legal []LegalRecord `json:"legal,omitempty"`
+	// Crashes Chrome 崩潰時收集的日誌與 minidump
+	Crashes []browser.CrashReport `json:"crashes,omitempty"`
 }
 
 // DefaultOptions 返回默認配置選項
@@ -76,10 +276,69 @@ type Crawler struct {
 	ctx     context.Context
 	cancel  context.CancelFunc
 	mu      sync.Mutex
+	audit   *audit.Log
+	legal   *legalArchiver
+	warc    *warc.Writer
+	incr    *incrementalStore
+	waits   *waitTuner
+	gate    *hostGate
+	// devtools 設定 Options.DevTools 時的 DevTools 代理
+	devtools *browser.DevToolsProxy
+	// live 設定 Options.LiveView 時的畫面串流
+	live *liveview.Server
+	// admin ServeAdmin 啟動的管理端點
+	admin *AdminServer
+	// http 設定 Options.HTTPFirst 時的 HTTP 用戶端
+	http *httpFetcher
+	// extractJS 由 Options.Extract 產生的擷取腳本
+	extractJS string
+	// browserCfg 主要 Chrome 的設定，fresh-browser 以此啟動各網址專屬的 Chrome
+	browserCfg config.Config
+	// idle shared-tab 的閒置分頁，依 tabKey 分組；owned 分頁專屬的 Chrome，皆由 mu 保護
+	idle  map[string][]*tab.Tab
+	owned map[*tab.Tab]*browser.BrowserManager
+
+	// draining 於 Drain 開始時關閉；inflight 追蹤進行中的 Fetch，active 為其數量（由 mu 保護）
+	draining chan struct{}
+	inflight sync.WaitGroup
+	active   int
+	// paused Pause 後不為 nil，Resume 時關閉；crawls 進行中的 Crawl 的佇列。皆由 mu 保護
+	paused chan struct{}
+	crawls map[*crawlQueue]struct{}
+
+	// 摘要統計，由 mu 保護
+	startedAt time.Time
+	pages     int
+	failed    int
+	unchanged int
+	httpOnly  int
+	sampled   int
+	truncated int
+	blocked   map[string]int
+	findings  map[string]int
+	// audits 設定 PageAudit 時的分數合計
+	audits auditTotals
+	// budget 設定 Options.Bandwidth 時的流量用量，自帶鎖
+	budget *byteBudget
+	// latency 各階段延遲統計，自帶鎖
+	latency *latencyStats
+	// queue Enqueue 排入、由 Run 處理的排程佇列，自帶鎖
+	queue *scheduler
+	// crashes Close 時保留的崩潰報告
+	crashes []browser.CrashReport
+	// rng 以 Seed 初始化的亂數來源；chrome、extractors、handlers 為 Manifest 記錄的執行資訊。皆由 mu 保護
+	rng        *rand.Rand
+	chrome     string
+	extractors []Extractor
+	handlers   map[string]bool
 }
 
 // New 創建新的爬蟲客戶端
 func New(options Options) (*Crawler, error) {
+	if options.Config != nil {
+		options = options.withLayers(*options.Config)
+	}
+
 	// 套用默認值
 	opts := DefaultOptions()
 
@@ -105,6 +364,78 @@ func New(options Options) (*Crawler, error) {
 	opts.Headless = options.Headless
 	opts.DisableJS = options.DisableJS
 	opts.SaveHTML = options.SaveHTML
+	opts.Screenshot = options.Screenshot
+	opts.ScreenshotReady = options.ScreenshotReady
+	opts.StopAnimations = options.StopAnimations
+	opts.CaptureTLS = options.CaptureTLS
+	opts.Scan = options.Scan
+	opts.PageAudit = options.PageAudit
+	opts.StructuredData = options.StructuredData
+	opts.SampleRate = options.SampleRate
+	opts.SampleByURL = options.SampleByURL
+	opts.SampleDir = options.SampleDir
+	opts.ArchiveDir = options.ArchiveDir
+	opts.Audit = options.Audit
+	opts.JobID = options.JobID
+	opts.Policy = options.Policy
+	opts.CaptureLegal = options.CaptureLegal
+	opts.LegalDir = options.LegalDir
+	opts.TermsPaths = options.TermsPaths
+	opts.WARCPath = options.WARCPath
+	opts.WARCSubresources = options.WARCSubresources
+	opts.BlockResources = options.BlockResources
+	opts.BlockURLPatterns = options.BlockURLPatterns
+	opts.Blocklist = options.Blocklist
+	opts.Transforms = options.Transforms
+	opts.Device = options.Device
+	opts.Throttle = options.Throttle
+	opts.Bandwidth = options.Bandwidth
+	opts.IncrementalState = options.IncrementalState
+	opts.IncrementalHEAD = options.IncrementalHEAD
+	opts.Domains = options.Domains
+	opts.AdaptiveWait = options.AdaptiveWait
+	opts.DisableHandlers = options.DisableHandlers
+	opts.Hooks = options.Hooks
+	opts.TagRequests = options.TagRequests
+	opts.TagHeader = options.TagHeader
+	opts.Limits = options.Limits
+	opts.CrashDir = options.CrashDir
+	opts.Extract = options.Extract
+	opts.Pagination = options.Pagination
+	opts.DevTools = options.DevTools
+	opts.LiveView = options.LiveView
+	opts.Admin = options.Admin
+	isolation, err := ParseIsolation(string(options.Isolation))
+	if err != nil {
+		return nil, err
+	}
+	opts.Isolation = isolation
+	opts.HTTPFirst = options.HTTPFirst
+	opts.PerHostDelay = options.PerHostDelay
+	opts.PerHostConcurrency = options.PerHostConcurrency
+	opts.MaxQPS = options.MaxQPS
+	opts.HangTimeout = options.HangTimeout
+	opts.SlowPages = options.SlowPages
+	opts.Checkpoint = options.Checkpoint
+	opts.CheckpointInterval = options.CheckpointInterval
+	opts.Seed = options.Seed
+	opts.Config = options.Config
+	opts.Browser = options.Browser
+	if opts.SlowPages == 0 {
+		opts.SlowPages = 10
+	}
+	if options.Retry != nil {
+		opts.Retry = options.Retry.withDefaults()
+	}
+	if opts.TagHeader == "" {
+		opts.TagHeader = "X-Cdpkit-Job"
+	}
+	if opts.JobID == "" {
+		opts.JobID = time.Now().Format("20060102-150405")
+	}
+	for opts.Seed == 0 {
+		opts.Seed = rand.Int63()
+	}
 	if options.LogLevel > 0 {
 		opts.LogLevel = options.LogLevel
 	}
@@ -119,6 +450,15 @@ func New(options Options) (*Crawler, error) {
 	// 設置是否無頭模式
 	opts.BrowserFlags["headless"] = opts.Headless
 
+	var extractJS string
+	if len(opts.Extract) > 0 {
+		script, err := opts.Extract.Script()
+		if err != nil {
+			return nil, err
+		}
+		extractJS = script
+	}
+
 	// 創建上下文
 	ctx, cancel := context.WithCancel(context.Background())
 
@@ -129,6 +469,8 @@ func New(options Options) (*Crawler, error) {
 		WindowSize: opts.WindowSize,
 		UserAgent:  opts.UserAgent,
 		Flags:      opts.BrowserFlags,
+		Limits:     opts.Limits,
+		CrashDir:   opts.CrashDir,
 	}
 
 	// 設置代理
@@ -141,57 +483,695 @@ func New(options Options) (*Crawler, error) {
 		}
 	}
 
+	// 分層設定中爬蟲沒有對應欄位的瀏覽器設定（WebSocketURL、ChromePath 等）；
+	// 分頁層的 WebRTC 政策同時以啟動旗標套用到整個 Chrome
+	if opts.Config != nil {
+		browserCfg = config.Layered{Browser: opts.Config.Browser}.Apply(browserCfg)
+		browserCfg.WebRTC = opts.Config.Tab.WebRTC
+	}
+
 	// 初始化瀏覽器管理器
-	bm, err := browser.NewManagerFromConfig(browserCfg)
-	if err != nil {
-		cancel()
-		return nil, fmt.Errorf("初始化瀏覽器失敗: %w", err)
+	bm := opts.Browser
+	if bm == nil {
+		var err error
+		if bm, err = browser.NewManagerFromConfig(browserCfg); err != nil {
+			cancel()
+			return nil, fmt.Errorf("初始化瀏覽器失敗: %w", err)
+		}
+	}
+
+	c := &Crawler{
+		options:    opts,
+		bm:         bm,
+		ctx:        ctx,
+		cancel:     cancel,
+		gate:       newHostGate(opts),
+		latency:    newLatencyStats(opts.SlowPages),
+		queue:      newScheduler(),
+		extractJS:  extractJS,
+		browserCfg: browserCfg,
+		idle:       map[string][]*tab.Tab{},
+		owned:      map[*tab.Tab]*browser.BrowserManager{},
+		draining:   make(chan struct{}),
+		crawls:     map[*crawlQueue]struct{}{},
+		startedAt:  time.Now(),
+		rng:        rand.New(rand.NewSource(opts.Seed)),
+	}
+	if opts.Isolation == IsolationFreshContext && bm.Stats().Mode == "remote" {
+		c.Close()
+		return nil, fmt.Errorf("連線既有的 Chrome 時不支援 %s 隔離", IsolationFreshContext)
+	}
+	if opts.Audit {
+		c.audit = audit.New(opts.JobID)
+	}
+	if opts.DevTools != "" {
+		p, err := bm.ServeDevTools(opts.DevTools)
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+		c.devtools = p
+		c.logf(3, "DevTools: %s", p.URL())
+	}
+	if opts.LiveView != "" {
+		s, err := liveview.Serve(opts.LiveView)
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+		c.live = s
+		c.logf(3, "即時畫面: %s", s.URL())
+	}
+	if opts.Admin != "" {
+		s, err := c.ServeAdmin(opts.Admin)
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+		c.logf(3, "管理端點: %s", s.URL())
+	}
+	if opts.CaptureLegal {
+		c.legal = newLegalArchiver(opts)
+	}
+	if opts.Bandwidth != nil {
+		c.budget = newByteBudget(*opts.Bandwidth)
+	}
+	if opts.HTTPFirst != nil {
+		c.http = newHTTPFetcher(opts)
+	}
+	if opts.WARCPath != "" {
+		w, err := warc.Create(opts.WARCPath)
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+		w.WriteInfo(map[string]string{
+			"software": "cdpkit",
+			"format":   "WARC File Format 1.1",
+			"job":      opts.JobID,
+		})
+		c.warc = w
+	}
+	if opts.IncrementalState != "" {
+		s, err := newIncrementalStore(opts)
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+		c.incr = s
+	}
+	if opts.AdaptiveWait != nil {
+		w, err := newWaitTuner(*opts.AdaptiveWait)
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+		c.waits = w
+	}
+	return c, nil
+}
+
+// Summary 回傳目前的爬取摘要
+func (c *Crawler) Summary() Summary {
+	c.mu.Lock()
+	s := Summary{
+		JobID:     c.options.JobID,
+		StartedAt: c.startedAt,
+		Pages:     c.pages,
+		Failed:    c.failed,
+		Unchanged: c.unchanged,
+		HTTPOnly:  c.httpOnly,
+		Sampled:   c.sampled,
+	}
+	if len(c.blocked) > 0 {
+		s.BlockedHosts = make(map[string]int, len(c.blocked))
+		for host, n := range c.blocked {
+			s.BlockedHosts[host] = n
+			s.Blocked += n
+		}
+	}
+	s.Truncated = c.truncated
+	if len(c.findings) > 0 {
+		s.Findings = make(map[string]int, len(c.findings))
+		for rule, n := range c.findings {
+			s.Findings[rule] = n
+		}
+	}
+	s.AuditScore, s.AuditCategories = c.audits.averages()
+	c.mu.Unlock()
+
+	if c.budget != nil {
+		s.Bytes, s.BytesByHost = c.budget.used()
+	}
+	if c.waits != nil {
+		s.Waits = c.waits.strategies()
+	}
+	if s.Pages > 0 {
+		r := c.latency.report()
+		s.Latency = &r
 	}
 
-	return &Crawler{
-		options: opts,
-		bm:      bm,
-		ctx:     ctx,
-		cancel:  cancel,
-	}, nil
+	if c.legal != nil {
+		s.Legal = c.legal.snapshot()
+	}
+	if c.bm != nil {
+		s.Crashes = c.bm.CrashReports()
+	} else {
+		s.Crashes = c.crashes
+	}
+	return s
+}
+
+// DevToolsURL 回傳列出所有分頁的 DevTools 代理網址；未設定 Options.DevTools 時為空字串
+func (c *Crawler) DevToolsURL() string {
+	if c.devtools == nil {
+		return ""
+	}
+	return c.devtools.URL()
+}
+
+// LiveViewURL 回傳即時畫面的分頁清單網址；未設定 Options.LiveView 時為空字串
+func (c *Crawler) LiveViewURL() string {
+	if c.live == nil {
+		return ""
+	}
+	return c.live.URL()
+}
+
+// AuditLog 回傳本次爬取的稽核紀錄；未啟用 Options.Audit 時為 nil
+func (c *Crawler) AuditLog() *audit.Log {
+	return c.audit
 }
 
 // Close 關閉爬蟲客戶端和瀏覽器
 func (c *Crawler) Close() {
 	c.cancel()
+	if c.devtools != nil {
+		c.devtools.Close()
+		c.devtools = nil
+	}
+	if c.live != nil {
+		c.live.Close()
+		c.live = nil
+	}
+	c.mu.Lock()
+	admin := c.admin
+	c.admin = nil
+	c.mu.Unlock()
+	if admin != nil {
+		admin.Close()
+	}
+	c.closeIdle()
 	if c.bm != nil {
-		c.bm.Shutdown()
+		if c.options.Browser == nil {
+			c.bm.Shutdown()
+		}
+		c.crashes = c.bm.CrashReports()
 		c.bm = nil
 	}
+	if c.warc != nil {
+		c.warc.Close()
+		c.warc = nil
+	}
+	if c.incr != nil {
+		if err := c.incr.save(); err != nil {
+			c.logf(1, "%v", err)
+		}
+	}
+	if c.waits != nil {
+		if err := c.waits.save(); err != nil {
+			c.logf(1, "%v", err)
+		}
+	}
+}
+
+// Drain 停止接受新工作並等待進行中的頁面完成，之後寫出增量狀態與 WARC 並關閉 Chrome。
+// ctx 先結束時中斷剩餘頁面並回傳 ctx.Err()；可直接註冊為 lifecycle.OnShutdown 的步驟
+func (c *Crawler) Drain(ctx context.Context) error {
+	c.mu.Lock()
+	select {
+	case <-c.draining:
+	default:
+		close(c.draining)
+	}
+	c.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		c.inflight.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+		c.logf(3, "進行中的頁面已全部完成")
+	case <-ctx.Done():
+		err = ctx.Err()
+		c.logf(2, "寬限期已過，中斷進行中的頁面")
+		// 關閉分頁使進行中的操作立即失敗，讓結果與狀態仍能寫出
+		c.cancel()
+		if c.bm != nil && c.options.Browser == nil {
+			c.bm.Shutdown()
+		}
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			c.logf(1, "部分頁面未能在中斷後結束")
+		}
+	}
+	c.Close()
+	return err
 }
 
-// Fetch 爬取單個頁面
+// begin 登記一個進行中的頁面；已開始關閉時回傳 false
+func (c *Crawler) begin() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	select {
+	case <-c.draining:
+		return false
+	default:
+	}
+	// 與 Drain 關閉 draining 同在 mu 保護下，Wait 開始後不會再有 Add
+	c.inflight.Add(1)
+	c.active++
+	return true
+}
+
+// end 結束 begin 登記的頁面
+func (c *Crawler) end() {
+	c.mu.Lock()
+	c.active--
+	c.mu.Unlock()
+	c.inflight.Done()
+}
+
+// Pause 暫停派發新頁面：進行中的頁面照常完成，之後開始的 Fetch 等待 Resume；
+// 暫停期間 Drain 仍可關閉，等待中的頁面回傳 ErrDraining
+func (c *Crawler) Pause() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.paused == nil {
+		c.paused = make(chan struct{})
+		c.logf(3, "已暫停派發新頁面")
+	}
+}
+
+// Resume 恢復 Pause 暫停的派發
+func (c *Crawler) Resume() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.paused != nil {
+		close(c.paused)
+		c.paused = nil
+		c.logf(3, "已恢復派發")
+	}
+}
+
+// Paused 是否已暫停
+func (c *Crawler) Paused() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.paused != nil
+}
+
+// waitResume 暫停時等待 Resume；期間開始關閉時回傳 ErrDraining
+func (c *Crawler) waitResume() error {
+	c.mu.Lock()
+	ch := c.paused
+	c.mu.Unlock()
+	if ch == nil {
+		return nil
+	}
+	select {
+	case <-ch:
+		return nil
+	case <-c.draining:
+		return ErrDraining
+	case <-c.ctx.Done():
+		return ErrDraining
+	}
+}
+
+// Fetch 爬取單個頁面；Drain 開始後回傳 ErrDraining
 func (c *Crawler) Fetch(url string, jsScript string) (Result, error) {
-	result := Result{
+	return c.fetchLinks(url, jsScript, "")
+}
+
+// fetchLinks 同 Fetch；linkSelector 不為空時將符合的連結記錄於 Result.Links
+func (c *Crawler) fetchLinks(url, jsScript, linkSelector string) (Result, error) {
+	return c.fetchRetry(url, func() (Result, error) { return c.fetch(url, jsScript, linkSelector) })
+}
+
+// fetchRetry 以 fetch 取得 url，依 Retry 設定重試並計入摘要
+func (c *Crawler) fetchRetry(url string, fetch func() (Result, error)) (Result, error) {
+	if !c.begin() {
+		return Result{URL: url, Error: ErrDraining.Error(), Timestamp: time.Now()}, ErrDraining
+	}
+	defer c.end()
+	if err := c.waitResume(); err != nil {
+		return Result{URL: url, Error: err.Error(), Timestamp: time.Now()}, err
+	}
+
+	if c.legal != nil && c.options.Policy.AllowURL(url) == nil {
+		c.legal.capture(url)
+	}
+
+	result, err := fetch()
+	for attempt := 1; ; attempt++ {
+		if err != nil && result.Error == "" {
+			result.Error = err.Error()
+		}
+		result.Attempts = attempt
+		delay, retry := c.retryDelay(result, err, attempt)
+		if !retry {
+			break
+		}
+		c.logf(3, "重試 %s (第 %d 次，%v 後): %s", url, attempt, delay, result.Error)
+		select {
+		case <-time.After(delay):
+		case <-c.ctx.Done():
+			return result, c.ctx.Err()
+		}
+		result, err = fetch()
+	}
+	c.record(&result, err)
+	return result, err
+}
+
+// record 將已完成的頁面計入摘要，並在啟用 TagRequests 時記錄 JobID
+func (c *Crawler) record(result *Result, err error) {
+	if c.options.TagRequests {
+		result.JobID = c.options.JobID
+	}
+
+	c.mu.Lock()
+	c.pages++
+	if err != nil || result.Error != "" {
+		c.failed++
+	}
+	if result.Unchanged {
+		c.unchanged++
+	}
+	if result.HTTPOnly {
+		c.httpOnly++
+	}
+	if result.Sampled {
+		c.sampled++
+	}
+	if result.Truncated {
+		c.truncated++
+	}
+	c.audits.add(result.Audit)
+	c.mu.Unlock()
+	// 翻頁的後續頁面不經 fetch，以建立結果到完成的時間為總時間
+	if result.Timings != nil && result.Timings.Total == 0 {
+		result.Timings.Total = time.Since(result.Timestamp)
+	}
+	c.latency.add(*result)
+}
+
+func (c *Crawler) fetch(url, jsScript, linkSelector string) (result Result, err error) {
+	result = Result{
 		URL:       url,
 		Timestamp: time.Now(),
+		Sampled:   c.sample(url),
+		Timings:   &Timings{},
 	}
+	defer func(started time.Time) {
+		result.Timings.Total = time.Since(started)
+	}(result.Timestamp)
 
-	// 創建新分頁
-	tabCtx, tabCancel, err := c.bm.NewPageContext()
+	host, ov := c.domainOverride(url)
+	if jsScript == "" {
+		jsScript = c.extractJS
+	}
+	if ov.Script != "" {
+		jsScript = ov.Script
+	}
+	c.noteScript(jsScript, host, ov)
+	release, err := c.gate.acquire(c.ctx, host, ov)
+	result.Timings.Queue = time.Since(result.Timestamp)
 	if err != nil {
-		return result, fmt.Errorf("創建分頁失敗: %w", err)
+		result.Error = fmt.Sprintf("等待網域 %s 的速率限制: %v", host, err)
+		return result, err
 	}
+	defer release()
 
-	pageTab := tab.NewTab(tabCtx, tabCancel, config.Config{Timeout: c.options.Timeout})
-	defer pageTab.Close(c.bm)
+	if c.budget != nil && !c.budget.charge(host, 0) {
+		result.Truncated = true
+		return result, fmt.Errorf("%w: %s", ErrBandwidthExceeded, host)
+	}
+	if c.http != nil {
+		if r, ok := c.fetchHTTP(url, result, jsScript, linkSelector, ov); ok {
+			return r, nil
+		}
+	}
+
+	opened := time.Now()
+	pageTab, err := c.openTab(url, host, ov)
+	result.Timings.Open = time.Since(opened)
+	if err != nil {
+		return result, err
+	}
+	defer c.closeTab(pageTab, ov)
+
+	if c.incr != nil {
+		prev, known := c.incr.get(url)
+		if known && c.options.IncrementalHEAD && c.incr.unchangedByHEAD(pageTab, url, c.options.UserAgent, prev) {
+			c.logf(4, "未變更，略過: %s", url)
+			result.Unchanged = true
+			return result, nil
+		}
+		if known {
+			if err := conditionalNavigation(pageTab, url, prev); err != nil {
+				c.logf(2, "警告: 無法送出條件式請求: %v", err)
+			}
+		}
+	}
+
+	if h := c.handlerFor(url); h != nil {
+		c.logf(4, "使用處理器 %s: %s", h.Name, url)
+		c.noteHandler(h.Name)
+		page := &Page{URL: url, Script: jsScript, Tab: pageTab, Options: c.options, c: c, ov: ov}
+		started, timings := result.Timestamp, result.Timings
+		result, err = h.Fetch(page)
+		// 處理器未經 Page.Default 時沒有 Timings
+		if result.Timings == nil {
+			result.Timings = timings
+		} else {
+			result.Timings.Queue, result.Timings.Open = timings.Queue, timings.Open
+		}
+		if result.URL == "" {
+			result.URL = url
+		}
+		if result.Timestamp.IsZero() {
+			result.Timestamp = started
+		}
+		if err != nil && result.Error == "" {
+			result.Error = err.Error()
+		}
+		c.scrub(&result)
+	} else {
+		result, err = c.load(pageTab, result, jsScript, ov)
+	}
+	if c.budget != nil && pageTab.BytesExceeded() {
+		result.Truncated = true
+		if err != nil {
+			err = fmt.Errorf("%w: %v", ErrBandwidthExceeded, err)
+			result.Error = err.Error()
+		}
+	}
+	if linkSelector != "" && err == nil {
+		result.Links = c.collectLinks(pageTab, linkSelector)
+	}
+	return result, err
+}
 
+// openTab 依 Options.Isolation 取得分頁，並套用資源阻擋、網路節流、關聯標頭與網域的標頭、cookies 設定
+func (c *Crawler) openTab(url, host string, ov domains.Override) (*tab.Tab, error) {
+	pageTab, reused, err := c.acquireTab(ov)
+	if err != nil {
+		return nil, err
+	}
+
+	if !reused {
+		if err := pageTab.BlockResources(c.options.BlockResources, c.options.BlockURLPatterns); err != nil {
+			c.logf(2, "警告: 無法啟用資源阻擋: %v", err)
+		}
+		if err := pageTab.BlockTrackers(c.options.Blocklist, c.countBlocked); err != nil {
+			c.logf(2, "警告: 無法啟用阻擋清單: %v", err)
+		}
+		if c.options.Throttle != nil {
+			if err := pageTab.EmulateNetwork(*c.options.Throttle); err != nil {
+				c.logf(2, "警告: %v", err)
+			}
+		}
+		if c.options.TagRequests {
+			jobID := c.options.JobID
+			err := pageTab.AddInterceptor(func(r *tab.PausedRequest) {
+				r.SetHeader(c.options.TagHeader, jobID)
+			})
+			if err != nil {
+				c.logf(2, "警告: 無法加上關聯標頭: %v", err)
+			}
+		}
+	}
+	// 重複使用的分頁也須以本次的 host 重新計量
+	if c.budget != nil {
+		if err := pageTab.MeterBytes(func(n int64) bool { return c.budget.charge(host, n) }); err != nil {
+			c.logf(2, "警告: 無法計量流量: %v", err)
+		}
+	}
+	// 重複使用的分頁須以本次網域的標頭取代上一個網址的設定
+	if len(ov.Headers) > 0 || reused {
+		if err := pageTab.SetExtraHeaders(ov.Headers); err != nil {
+			c.logf(2, "警告: 無法設定 %s 的額外標頭: %v", host, err)
+		}
+	}
+	if err := pageTab.SetCookies(url, ov.Cookies); err != nil {
+		c.logf(2, "警告: 無法設定 %s 的 cookies: %v", host, err)
+	}
+	if c.live != nil {
+		c.live.Add(url, pageTab)
+	}
+	return pageTab, nil
+}
+
+// countBlocked 記錄阻擋清單丟棄的請求
+func (c *Crawler) countBlocked(rawURL, rule string) {
+	c.logf(4, "阻擋 %s（%s）", rawURL, rule)
+	c.mu.Lock()
+	if c.blocked == nil {
+		c.blocked = map[string]int{}
+	}
+	c.blocked[hostOf(rawURL)]++
+	c.mu.Unlock()
+}
+
+// closeTab 結束分頁的即時畫面後交還或關閉分頁
+func (c *Crawler) closeTab(pageTab *tab.Tab, ov domains.Override) {
+	if c.live != nil {
+		c.live.Remove(pageTab)
+	}
+	c.releaseTab(pageTab, ov)
+}
+
+// load 導航並以 jsScript 擷取資料，套用網域等待設定、WARC 封存與增量比對
+func (c *Crawler) load(pageTab *tab.Tab, result Result, jsScript string, ov domains.Override) (Result, error) {
+	url := result.URL
 	startTime := time.Now()
+	if result.Timings == nil {
+		result.Timings = &Timings{}
+	}
+	if c.live != nil {
+		c.live.SetLabel(pageTab, url)
+	}
+
+	rec := c.startPageAudit(pageTab)
 
 	// 導航到頁面
-	if err := pageTab.Navigate(url, c.options.Timeout); err != nil {
+	err := pageTab.Navigate(url, c.options.Timeout)
+	result.Timings.Navigate = time.Since(startTime)
+	if err != nil {
+		recordNavigation(pageTab, &result)
 		result.Error = fmt.Sprintf("導航失敗: %v", err)
 		return result, fmt.Errorf("導航失敗: %w", err)
 	}
 
-	// 等待頁面加載
-	time.Sleep(2 * time.Second)
+	settled := time.Now()
+	c.settle(pageTab, url, ov)
+	result.Timings.Settle = time.Since(settled)
+	result.Timings.Resources = len(pageTab.Responses())
+	docURL := recordNavigation(pageTab, &result)
+	doc := documentResponse(pageTab, docURL)
+	if doc != nil {
+		result.ResponseCode = int(doc.Status)
+	}
+	c.recordTLS(pageTab, &result, doc)
+	c.scanPage(pageTab, &result, docURL, doc)
+	c.finishPageAudit(rec, &result, docURL)
+
+	if c.warc != nil {
+		c.archiveWARC(pageTab)
+	}
+
+	if c.incr != nil {
+		prev, known := c.incr.get(url)
+		if status, v, ok := c.documentValidators(pageTab, docURL); ok {
+			result.ResponseCode = status
+			switch {
+			case status == http.StatusNotModified:
+				prev.FetchedAt = v.FetchedAt
+				c.incr.put(url, prev)
+				result.Unchanged = true
+			case status >= 400:
+			case known && v.ContentHash != "" && v.ContentHash == prev.ContentHash:
+				c.incr.put(url, v)
+				result.Unchanged = true
+			default:
+				c.incr.put(url, v)
+			}
+			if result.Unchanged {
+				result.ElapsedTime = time.Since(startTime)
+				return result, nil
+			}
+		}
+	}
+
+	return c.extract(pageTab, result, jsScript, startTime), nil
+}
+
+// recordNavigation 將分頁記錄的重新導向與最終網址存入 result，回傳主文件的網址（沒有紀錄時為原網址）
+func recordNavigation(pageTab *tab.Tab, result *Result) string {
+	result.RedirectChain = nil
+	for _, r := range pageTab.Redirects() {
+		result.RedirectChain = append(result.RedirectChain, Redirect{URL: r.URL, Location: r.Location, Status: int(r.Status)})
+	}
+	result.FinalURL = pageTab.DocumentURL()
+	if result.FinalURL == "" {
+		return result.URL
+	}
+	return result.FinalURL
+}
+
+// screenshotOptions 整頁截圖套用的等待與動畫設定
+func (c *Crawler) screenshotOptions() tab.ScreenshotOptions {
+	return tab.ScreenshotOptions{FullPage: true, Ready: c.options.ScreenshotReady, StopAnimations: c.options.StopAnimations}
+}
+
+// settle 依網域設定或 AdaptiveWait 推得的策略等待頁面就緒，啟用 Pagination.Scroll 時再捲動載入無限列表
+func (c *Crawler) settle(pageTab *tab.Tab, pageURL string, ov domains.Override) {
+	if c.waits != nil && ov.WaitSelector == "" && ov.WaitDelay == 0 {
+		host := hostOf(pageURL)
+		if strategy, tuned := c.waits.settle(pageTab, host); tuned {
+			c.logf(4, "%s 依歷史等待 %s", host, strategy)
+		}
+	} else {
+		wait := 2 * time.Second
+		if ov.WaitSelector != "" {
+			if err := pageTab.WaitVisible(ov.WaitSelector, c.options.Timeout); err != nil {
+				c.logf(2, "警告: 等待 %s 失敗: %v", ov.WaitSelector, err)
+			}
+			wait = 0
+		}
+		if ov.WaitDelay > 0 {
+			wait = time.Duration(ov.WaitDelay)
+		}
+		time.Sleep(wait)
+	}
+
+	if p := c.options.Pagination; p != nil && p.Scroll != nil {
+		if _, err := pageTab.ScrollToBottom(*p.Scroll); err != nil {
+			c.logf(2, "警告: 捲動載入失敗: %v", err)
+		}
+	}
+}
 
+// extract 以 jsScript 擷取目前頁面的標題、資料與 HTML
+func (c *Crawler) extract(pageTab *tab.Tab, result Result, jsScript string, startTime time.Time) Result {
+	extractStart := time.Now()
 	// 獲取頁面標題
 	title, err := pageTab.RunJS("document.title", c.options.Timeout)
 	if err == nil && title != nil {
@@ -227,6 +1207,9 @@ func (c *Crawler) Fetch(url string, jsScript string) (Result, error) {
 
 			// 嘗試轉換為map
 			if m, ok := scriptResult.(map[string]interface{}); ok {
+				if jsScript == c.extractJS {
+					m = c.options.Extract.Coerce(m)
+				}
 				result.Data = m
 			} else {
 				// 如果不是map，放入特殊鍵
@@ -245,63 +1228,128 @@ func (c *Crawler) Fetch(url string, jsScript string) (Result, error) {
 		}
 	}
 
+	if c.options.StructuredData {
+		c.structuredData(pageTab, &result)
+	}
+
+	if c.options.Screenshot {
+		png, err := pageTab.ScreenshotWith(c.screenshotOptions())
+		if err != nil {
+			c.logf(2, "警告: %v", err)
+		} else {
+			result.Screenshot = png
+		}
+	}
+	if result.Sampled {
+		c.captureSample(pageTab, &result)
+	}
+	if c.options.ArchiveDir != "" {
+		c.archive(pageTab, &result)
+	}
+
+	if result.Timings != nil {
+		result.Timings.Extract = time.Since(extractStart)
+	}
 	result.ElapsedTime = time.Since(startTime)
-	return result, nil
+	c.scrub(&result)
+	return result
+}
+
+// scrub 依合規設定在結果儲存前遮蔽個資，位於使用者腳本之後，無法被腳本略過
+func (c *Crawler) scrub(r *Result) {
+	p := c.options.Policy
+	if p == nil || !p.ScrubPII {
+		return
+	}
+	r.Title = p.Scrub(r.Title)
+	r.HTML = p.Scrub(r.HTML)
+	if len(r.HAR) > 0 {
+		r.HAR = []byte(p.Scrub(string(r.HAR)))
+	}
+	if r.Data != nil {
+		r.Data = p.ScrubValue(r.Data).(map[string]interface{})
+	}
+	r.RawJSResponse = p.ScrubValue(r.RawJSResponse)
+	if r.StructuredData != nil {
+		c.scrubStructuredData(r)
+	}
 }
 
-// FetchAll 批量爬取多個頁面
+// FetchAll 批量爬取多個頁面；Drain 開始後不再派發新的 URL，
+// 回傳已完成的結果與 ErrDraining
 func (c *Crawler) FetchAll(urls []string, jsScript string) ([]Result, error) {
 	results := make([]Result, 0, len(urls))
-	resultCh := make(chan Result, len(urls))
-
-	// 創建URL通道
-	urlCh := make(chan string, c.options.Concurrency)
-
-	// 啟動工作協程
-	var wg sync.WaitGroup
-	for i := 0; i < c.options.Concurrency; i++ {
-		wg.Add(1)
-		go func(workerID int) {
-			defer wg.Done()
-
-			for url := range urlCh {
-				logf(c.options.LogLevel, 3, "工作者 %d: 開始處理 %s", workerID, url)
-				result, err := c.Fetch(url, jsScript)
-				if err != nil {
-					logf(c.options.LogLevel, 2, "工作者 %d: 爬取 %s 失敗: %v", workerID, url, err)
-				} else {
-					logf(c.options.LogLevel, 3, "工作者 %d: 成功爬取 %s", workerID, url)
-				}
-				resultCh <- result
-			}
-		}(i + 1)
+	err := c.FetchAllFunc(urls, jsScript, func(r Result) {
+		results = append(results, r)
+	})
+	return results, err
+}
+
+// FetchAllFunc 同 FetchAll，但每完成一筆就在套用 Hooks 與 Transforms 後交給 fn，不在記憶體中保留結果，
+// 適合大量網址邊爬邊寫出。fn 依完成順序在呼叫端的 goroutine 中逐一執行，執行期間會暫停收取新的結果。
+// 設定 Options.Checkpoint 時定期記錄進度，重新執行時略過先前已完成的網址
+func (c *Crawler) FetchAllFunc(urls []string, jsScript string, fn func(Result)) error {
+	var cp *checkpointer
+	if c.options.Checkpoint != "" {
+		var err error
+		if cp, err = c.openCheckpoint(urls); err != nil {
+			return err
+		}
 	}
 
-	// 發送URL到通道
-	go func() {
-		for _, url := range urls {
-			select {
-			case <-c.ctx.Done():
-				break
-			case urlCh <- url:
-				// URL已發送
-			}
+	// 所有網址排入排程器，由工作者依 host 輪流處理
+	q := newScheduler()
+	skipped := 0
+	for _, url := range urls {
+		if !c.options.Hooks.AllowURL(url) {
+			c.logf(4, "掛鉤 url_filter 略過: %s", url)
+			continue
 		}
-		close(urlCh)
-	}()
+		if cp != nil && cp.skip(url) {
+			skipped++
+			continue
+		}
+		q.push(c.schedItem(url, PriorityNormal, Meta{}))
+	}
+	if skipped > 0 {
+		c.logf(3, "進度檔中已完成，略過 %d 個網址", skipped)
+	}
 
-	// 等待所有工作完成
-	go func() {
-		wg.Wait()
-		close(resultCh)
-	}()
+	drained := c.process(q, jsScript, fn, func(url string, ok bool) {
+		// 結果交給 fn 之後才計入進度，當機時最多重爬處理中的網址
+		if cp != nil && ok {
+			cp.finish(url)
+		}
+	})
 
-	// 收集結果
-	for result := range resultCh {
-		results = append(results, result)
+	if cp != nil {
+		if err := cp.close(); err != nil {
+			c.logf(1, "%v", err)
+		}
 	}
 
-	return results, nil
+	if drained {
+		return ErrDraining
+	}
+	return nil
+}
+
+// Stream 同 FetchAllFunc，以 channel 逐筆回傳結果，全部完成後關閉 channel；
+// 呼叫時已開始 Drain 則回傳 ErrDraining。讀取端須持續讀取到 channel 關閉，否則爬取會暫停
+func (c *Crawler) Stream(urls []string, jsScript string) (<-chan Result, error) {
+	select {
+	case <-c.draining:
+		return nil, ErrDraining
+	default:
+	}
+	ch := make(chan Result, c.options.Concurrency)
+	go func() {
+		defer close(ch)
+		if err := c.FetchAllFunc(urls, jsScript, func(r Result) { ch <- r }); err != nil {
+			c.logf(2, "串流結束: %v", err)
+		}
+	}()
+	return ch, nil
 }
 
 // ToJSON 將結果轉換為JSON
@@ -316,6 +1364,55 @@ func ResultsToJSON(results []Result) ([]byte, error) {
 
 // Helper functions
 
+// withLayers 以分層設定的非零欄位取代爬蟲中重複的欄位
+func (o Options) withLayers(l config.Layered) Options {
+	b, t := l.Browser, l.Tab
+	if l.Request.Timeout > 0 {
+		o.Timeout = l.Request.Timeout
+	}
+	if b.Proxy != "" {
+		o.ProxyURL = b.Proxy
+	}
+	if b.RemotePort > 0 {
+		o.DebugPort = b.RemotePort
+	}
+	if b.Flags != nil {
+		flags := make(map[string]interface{}, len(o.BrowserFlags)+len(b.Flags))
+		for k, v := range o.BrowserFlags {
+			flags[k] = v
+		}
+		for k, v := range b.Flags {
+			flags[k] = v
+		}
+		if headless, ok := b.Flags["headless"].(bool); ok {
+			o.Headless = headless
+		}
+		o.BrowserFlags = flags
+	}
+	if b.Limits != (config.ResourceLimits{}) {
+		o.Limits = b.Limits
+	}
+	if b.CrashDir != "" {
+		o.CrashDir = b.CrashDir
+	}
+	if t.UserAgent != "" {
+		o.UserAgent = t.UserAgent
+	}
+	if t.WindowSize[0] > 0 && t.WindowSize[1] > 0 {
+		o.WindowSize = t.WindowSize
+	}
+	if t.Device != "" {
+		o.Device = t.Device
+	}
+	if t.Policy != nil {
+		o.Policy = t.Policy
+	}
+	if t.HangTimeout > 0 {
+		o.HangTimeout = t.HangTimeout
+	}
+	return o
+}
+
 // isValidProxyURL 驗證代理URL格式是否正確
 func isValidProxyURL(proxyURL string) bool {
 	// 檢查是否以常見代理前綴開頭
@@ -350,6 +1447,14 @@ func containsPort(s string) bool {
 	return false
 }
 
+// logf 依設定的日誌級別輸出；啟用 TagRequests 時加上 JobID 前綴以便關聯
+func (c *Crawler) logf(msgLevel int, format string, args ...interface{}) {
+	if c.options.TagRequests {
+		format = "[" + c.options.JobID + "] " + format
+	}
+	logf(c.options.LogLevel, msgLevel, format, args...)
+}
+
 // logf 根據日誌級別打印日誌
 func logf(configLevel, msgLevel int, format string, args ...interface{}) {
 	if configLevel >= msgLevel {
@@ -0,0 +1,132 @@
+package crawler
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/firehourse/cdpkit/domains"
+)
+
+// hostGate 限制同一 host 的並發數與導航間隔，並限制全部 host 合計的每秒導航數；
+// 網域設定覆寫優先於 Options.PerHostConcurrency、Options.PerHostDelay
+type hostGate struct {
+	concurrency int
+	delay       time.Duration
+	// spacing 由 Options.MaxQPS 換算的全域導航間隔；next 為下一個可用時段，由 mu 保護
+	spacing time.Duration
+
+	mu    sync.Mutex
+	hosts map[string]*hostState
+	next  time.Time
+}
+
+type hostState struct {
+	sem  chan struct{}
+	mu   sync.Mutex
+	next time.Time
+}
+
+func newHostGate(opts Options) *hostGate {
+	g := &hostGate{
+		concurrency: opts.PerHostConcurrency,
+		delay:       opts.PerHostDelay,
+		hosts:       map[string]*hostState{},
+	}
+	if opts.MaxQPS > 0 {
+		g.spacing = time.Duration(float64(time.Second) / opts.MaxQPS)
+	}
+	return g
+}
+
+// acquire 等待取得 host 的處理名額並遵守最小間隔與全域速率，回傳釋放函式
+func (g *hostGate) acquire(ctx context.Context, host string, ov domains.Override) (func(), error) {
+	concurrency, interval := ov.Concurrency, time.Duration(ov.Interval)
+	if concurrency <= 0 {
+		concurrency = g.concurrency
+	}
+	if interval <= 0 {
+		interval = g.delay
+	}
+	if concurrency <= 0 && interval <= 0 && g.spacing <= 0 {
+		return func() {}, nil
+	}
+
+	g.mu.Lock()
+	s, ok := g.hosts[host]
+	if !ok {
+		s = &hostState{}
+		if concurrency > 0 {
+			s.sem = make(chan struct{}, concurrency)
+		}
+		g.hosts[host] = s
+	}
+	g.mu.Unlock()
+
+	release := func() {}
+	if s.sem != nil {
+		select {
+		case s.sem <- struct{}{}:
+			release = func() { <-s.sem }
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	// 預約下一個可用時段，並發的工作者依序錯開
+	s.mu.Lock()
+	start := reserve(&s.next, interval)
+	s.mu.Unlock()
+	if err := waitUntil(ctx, start); err != nil {
+		release()
+		return nil, err
+	}
+
+	// 取得 host 的時段後才預約全域時段，等待其他 host 的工作者不會占用全域名額
+	if g.spacing > 0 {
+		g.mu.Lock()
+		start := reserve(&g.next, g.spacing)
+		g.mu.Unlock()
+		if err := waitUntil(ctx, start); err != nil {
+			release()
+			return nil, err
+		}
+	}
+	return release, nil
+}
+
+// reserve 預約不早於現在的時段，並將 next 往後推 interval
+func reserve(next *time.Time, interval time.Duration) time.Time {
+	start := *next
+	if now := time.Now(); start.Before(now) {
+		start = now
+	}
+	*next = start.Add(interval)
+	return start
+}
+
+func waitUntil(ctx context.Context, t time.Time) error {
+	d := time.Until(t)
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// domainOverride 回傳 pageURL 所屬 host 的設定覆寫
+func (c *Crawler) domainOverride(pageURL string) (string, domains.Override) {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return "", domains.Override{}
+	}
+	ov, _ := c.options.Domains.Lookup(u.Host)
+	return u.Host, ov
+}
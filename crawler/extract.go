@@ -0,0 +1,254 @@
+package crawler
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/firehourse/cdpkit/config"
+	"github.com/firehourse/cdpkit/tab"
+)
+
+// ExtractSpec 宣告式擷取規則：欄位名稱對應擷取方式，爬蟲據此產生擷取腳本，
+// 不需撰寫 JavaScript。可寫在 YAML 或 JSON 中，只寫字串時視為取文字的選擇器；
+// 選擇器與 Tab 相同，以 "xpath="、"/" 或 "(" 開頭時視為 XPath：
+//
+//	title: h1
+//	author: //span[@itemprop="author"]
+//	price:
+//	  selector: .price
+//	  type: float
+//	tags:
+//	  selector: .tag
+//	  list: true
+//	next:
+//	  xpath: //a[@rel="next"]/@href
+//	reviews:
+//	  selector: .review
+//	  list: true
+//	  fields:
+//	    author: .author
+//	    stars: {selector: .stars, attr: data-value, type: int}
+type ExtractSpec map[string]Field
+
+// Field 單一欄位的擷取方式
+type Field struct {
+	// Selector CSS 選擇器，或以 "xpath="、"/"、"(" 開頭的 XPath；巢狀欄位相對於父元素
+	Selector string `json:"selector,omitempty"`
+	// XPath 以 XPath 選取，與 Selector 擇一；巢狀欄位請以 "./" 開頭表示相對路徑
+	XPath string `json:"xpath,omitempty"`
+	// Attr 讀取的屬性；留空取 innerText，"html" 取 innerHTML，"outer_html" 取 outerHTML。
+	// href、src 會轉為絕對網址
+	Attr string `json:"attr,omitempty"`
+	// Type "string"（預設）、"int"、"float" 或 "bool"；數字會忽略貨幣符號與千分位逗號
+	Type string `json:"type,omitempty"`
+	// List 擷取所有符合的元素為陣列
+	List bool `json:"list,omitempty"`
+	// Fields 巢狀物件：以選取的元素為根擷取子欄位
+	Fields ExtractSpec `json:"fields,omitempty"`
+}
+
+// UnmarshalJSON 接受字串（文字欄位的選擇器）或物件
+func (f *Field) UnmarshalJSON(data []byte) error {
+	var sel string
+	if err := json.Unmarshal(data, &sel); err == nil {
+		*f = Field{Selector: sel}
+		return nil
+	}
+	type plain Field
+	return json.Unmarshal(data, (*plain)(f))
+}
+
+// LoadExtractSpec 讀取擷取規則；副檔名為 .json 時以 JSON 解析，其餘以 YAML 解析
+func LoadExtractSpec(path string) (ExtractSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("無法讀取擷取規則 %s: %w", path, err)
+	}
+	var spec ExtractSpec
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(data, &spec)
+	} else {
+		err = config.UnmarshalYAML(data, &spec)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("無法解析擷取規則 %s: %w", path, err)
+	}
+	if err := spec.validate(""); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// extractJS 依規則擷取的腳本，規則以 JSON 嵌入
+const extractJS = `((spec) => {
+	const query = (root, f, all) => {
+		if (f.xpath) {
+			const r = document.evaluate(f.xpath, root, null, XPathResult.ORDERED_NODE_SNAPSHOT_TYPE, null);
+			const out = [];
+			for (let i = 0; i < r.snapshotLength && (all || i < 1); i++) out.push(r.snapshotItem(i));
+			return out;
+		}
+		if (!f.selector) return [root];
+		return all ? Array.from(root.querySelectorAll(f.selector)) : [root.querySelector(f.selector)].filter(Boolean);
+	};
+	const value = (el, f) => {
+		if (f.fields) return extract(el, f.fields);
+		// XPath 選到的屬性或文字節點
+		if (el.nodeType !== Node.ELEMENT_NODE) return el.textContent.trim();
+		switch (f.attr || '') {
+		case '': return (el.innerText ?? el.textContent).trim();
+		case 'html': return el.innerHTML;
+		case 'outer_html': return el.outerHTML;
+		case 'href': case 'src': return el.hasAttribute(f.attr) ? el[f.attr] || el.getAttribute(f.attr) : null;
+		default: return el.getAttribute(f.attr);
+		}
+	};
+	const extract = (root, fields) => {
+		const out = {};
+		for (const [name, f] of Object.entries(fields)) {
+			const els = query(root, f, !!f.list);
+			out[name] = f.list ? els.map(el => value(el, f)) : (els.length ? value(els[0], f) : null);
+		}
+		return out;
+	};
+	return extract(document, spec);
+})(%s)`
+
+// Script 產生擷取腳本，可直接傳給 FetchAll 或 Tab.RunJS；
+// 回傳的資料為字串，經 Coerce 才會轉為 Type 指定的型別
+func (s ExtractSpec) Script() (string, error) {
+	if err := s.validate(""); err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(s.normalize())
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(extractJS, b), nil
+}
+
+// Coerce 將擷取腳本的輸出依 Type 轉型；無法轉換的值為 nil
+func (s ExtractSpec) Coerce(data map[string]interface{}) map[string]interface{} {
+	if data == nil {
+		return nil
+	}
+	out := make(map[string]interface{}, len(data))
+	for name, v := range data {
+		f, ok := s[name]
+		if !ok {
+			out[name] = v
+			continue
+		}
+		out[name] = f.coerce(v)
+	}
+	return out
+}
+
+// ----------------- 內部實作 -----------------
+
+func (s ExtractSpec) validate(prefix string) error {
+	if len(s) == 0 && prefix == "" {
+		return fmt.Errorf("擷取規則沒有任何欄位")
+	}
+	for name, f := range s {
+		path := prefix + name
+		if f.Selector != "" && f.XPath != "" {
+			return fmt.Errorf("擷取欄位 %s: selector 與 xpath 只能擇一", path)
+		}
+		if f.Selector == "" && f.XPath == "" && len(f.Fields) == 0 {
+			return fmt.Errorf("擷取欄位 %s: 缺少 selector 或 xpath", path)
+		}
+		switch f.Type {
+		case "", "string", "int", "float", "bool":
+		default:
+			return fmt.Errorf("擷取欄位 %s: 不支援的型別 %q", path, f.Type)
+		}
+		if len(f.Fields) > 0 {
+			if f.Type != "" || f.Attr != "" {
+				return fmt.Errorf("擷取欄位 %s: 巢狀欄位不能設定 type 或 attr", path)
+			}
+			if err := f.Fields.validate(path + "."); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// normalize 將寫在 Selector 的 XPath 移至 XPath，腳本只需依欄位判斷查詢方式
+func (s ExtractSpec) normalize() ExtractSpec {
+	out := make(ExtractSpec, len(s))
+	for name, f := range s {
+		if tab.IsXPath(f.Selector) {
+			f.XPath = strings.TrimPrefix(strings.TrimSpace(f.Selector), tab.XPathPrefix)
+			f.Selector = ""
+		}
+		if len(f.Fields) > 0 {
+			f.Fields = f.Fields.normalize()
+		}
+		out[name] = f
+	}
+	return out
+}
+
+func (f Field) coerce(v interface{}) interface{} {
+	if f.List {
+		items, ok := v.([]interface{})
+		if !ok {
+			return nil
+		}
+		single := f
+		single.List = false
+		out := make([]interface{}, len(items))
+		for i, item := range items {
+			out[i] = single.coerce(item)
+		}
+		return out
+	}
+	if len(f.Fields) > 0 {
+		m, _ := v.(map[string]interface{})
+		return f.Fields.Coerce(m)
+	}
+
+	s, isString := v.(string)
+	switch f.Type {
+	case "int":
+		if n, ok := parseNumber(s); isString && ok {
+			return int64(n)
+		}
+		return nil
+	case "float":
+		if n, ok := parseNumber(s); isString && ok {
+			return n
+		}
+		return nil
+	case "bool":
+		// 屬性存在但無值（例如 disabled）視為 true，元素或屬性不存在為 false
+		if !isString {
+			return false
+		}
+		switch strings.ToLower(strings.TrimSpace(s)) {
+		case "false", "0", "no", "off":
+			return false
+		}
+		return true
+	}
+	return v
+}
+
+var numberPattern = regexp.MustCompile(`-?\d[\d,]*(?:\.\d+)?`)
+
+// parseNumber 取出字串中的第一個數字，忽略貨幣符號與千分位逗號
+func parseNumber(s string) (float64, bool) {
+	m := numberPattern.FindString(s)
+	if m == "" {
+		return 0, false
+	}
+	n, err := strconv.ParseFloat(strings.ReplaceAll(m, ",", ""), 64)
+	return n, err == nil
+}
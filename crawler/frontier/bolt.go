@@ -0,0 +1,181 @@
+package frontier
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	boltSeen     = []byte("seen")
+	boltQueue    = []byte("queue")
+	boltInflight = []byte("inflight")
+)
+
+// Bolt 以 BoltDB 檔案保存的佇列；同一個檔案同時只能由一個程序開啟
+type Bolt struct {
+	db *bolt.DB
+}
+
+// OpenBolt 開啟或建立 path 的佇列檔；上次中斷時處理中的網址會重新排入佇列
+func OpenBolt(path string) (*Bolt, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("無法開啟佇列檔 %s: %w", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{boltSeen, boltQueue, boltInflight} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		// 放回上次未完成的網址
+		inflight := tx.Bucket(boltInflight)
+		var items []Item
+		if err := inflight.ForEach(func(k, v []byte) error {
+			var item Item
+			if err := json.Unmarshal(v, &item); err != nil {
+				return err
+			}
+			items = append(items, item)
+			return nil
+		}); err != nil {
+			return err
+		}
+		for _, item := range items {
+			if err := inflight.Delete([]byte(Hash(item.URL))); err != nil {
+				return err
+			}
+			if err := boltEnqueue(tx, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("無法初始化佇列檔 %s: %w", path, err)
+	}
+	return &Bolt{db: db}, nil
+}
+
+// Push 實作 Frontier
+func (b *Bolt) Push(item Item) (bool, error) {
+	added := false
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		seen := tx.Bucket(boltSeen)
+		h := []byte(Hash(item.URL))
+		if seen.Get(h) != nil {
+			return nil
+		}
+		if err := seen.Put(h, []byte{1}); err != nil {
+			return err
+		}
+		added = true
+		return boltEnqueue(tx, item)
+	})
+	return added, err
+}
+
+// Pop 實作 Frontier
+func (b *Bolt) Pop() (Item, bool, error) {
+	var item Item
+	found := false
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		c := tx.Bucket(boltQueue).Cursor()
+		k, v := c.First()
+		if k == nil {
+			return nil
+		}
+		if err := json.Unmarshal(v, &item); err != nil {
+			return err
+		}
+		if err := c.Delete(); err != nil {
+			return err
+		}
+		found = true
+		return tx.Bucket(boltInflight).Put([]byte(Hash(item.URL)), v)
+	})
+	return item, found, err
+}
+
+// Done 實作 Frontier
+func (b *Bolt) Done(item Item) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltInflight).Delete([]byte(Hash(item.URL)))
+	})
+}
+
+// Retry 實作 Frontier
+func (b *Bolt) Retry(item Item) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket(boltInflight).Delete([]byte(Hash(item.URL))); err != nil {
+			return err
+		}
+		item.Retries++
+		return boltEnqueue(tx, item)
+	})
+}
+
+// Stats 實作 Frontier
+func (b *Bolt) Stats() (Stats, error) {
+	var s Stats
+	err := b.db.View(func(tx *bolt.Tx) error {
+		s.Seen = tx.Bucket(boltSeen).Stats().KeyN
+		s.Queued = tx.Bucket(boltQueue).Stats().KeyN
+		s.InFlight = tx.Bucket(boltInflight).Stats().KeyN
+		return nil
+	})
+	return s, err
+}
+
+// List 實作 Lister
+func (b *Bolt) List(limit int) (queued, inflight []Item, err error) {
+	err = b.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(boltQueue).Cursor()
+		for k, v := c.First(); k != nil && len(queued) < limit; k, v = c.Next() {
+			var item Item
+			if err := json.Unmarshal(v, &item); err != nil {
+				return err
+			}
+			queued = append(queued, item)
+		}
+		return tx.Bucket(boltInflight).ForEach(func(_, v []byte) error {
+			var item Item
+			if err := json.Unmarshal(v, &item); err != nil {
+				return err
+			}
+			inflight = append(inflight, item)
+			return nil
+		})
+	})
+	return queued, inflight, err
+}
+
+// Close 實作 Frontier
+func (b *Bolt) Close() error {
+	return b.db.Close()
+}
+
+// ----------------- 內部實作 -----------------
+
+// boltEnqueue 以「優先度（反轉）+ 序號」為鍵加入佇列，游標由小到大即為取出順序
+func boltEnqueue(tx *bolt.Tx, item Item) error {
+	queue := tx.Bucket(boltQueue)
+	seq, err := queue.NextSequence()
+	if err != nil {
+		return err
+	}
+	v, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	key := make([]byte, 16)
+	// 翻轉符號位元讓有號整數依大小排序，再取補數讓優先度高者在前
+	binary.BigEndian.PutUint64(key, ^(uint64(int64(item.Priority)) ^ 1<<63))
+	binary.BigEndian.PutUint64(key[8:], seq)
+	return queue.Put(key, v)
+}
@@ -0,0 +1,179 @@
+// Package frontier 提供遞迴爬取的待爬佇列：記錄看過的網址雜湊、各網址的優先度與重試次數。
+// 持久化的實作（BoltDB、Redis）在中斷後重新開啟即可從上次的進度繼續，
+// 取出但尚未完成的網址會重新排入佇列。
+package frontier
+
+import (
+	"container/heap"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"sync"
+)
+
+// Item 佇列中的一個網址
+type Item struct {
+	URL string `json:"url"`
+	// Depth 與種子網址的距離
+	Depth int `json:"depth"`
+	// Priority 數值越大越先取出，相同時先進先出
+	Priority int `json:"priority"`
+	// Retries 已重新排入佇列的次數
+	Retries int `json:"retries,omitempty"`
+}
+
+// Stats 佇列統計
+type Stats struct {
+	// Seen 看過的網址數（含已完成、排隊中與處理中）
+	Seen int `json:"seen"`
+	// Queued 排隊中的網址數
+	Queued int `json:"queued"`
+	// InFlight 已取出但尚未 Done 的網址數
+	InFlight int `json:"inflight"`
+}
+
+// Frontier 待爬佇列的儲存後端，所有方法都必須可同時呼叫
+type Frontier interface {
+	// Push 加入未看過的網址，回傳是否加入；看過與否以 Hash(URL) 判斷
+	Push(item Item) (bool, error)
+	// Pop 取出優先度最高的網址；佇列為空時回傳 false。
+	// 取出的網址在 Done 或 Retry 之前視為處理中
+	Pop() (Item, bool, error)
+	// Done 標記取出的網址已處理完畢
+	Done(item Item) error
+	// Retry 將處理失敗的網址重新排入佇列，Retries 加一
+	Retry(item Item) error
+	// Stats 回傳目前的統計
+	Stats() (Stats, error)
+	// Close 釋放資源；持久化的實作會保留所有狀態
+	Close() error
+}
+
+// Lister 可列出佇列內容的 Frontier，供除錯與管理端點使用；Memory、Bolt、Redis 皆有實作
+type Lister interface {
+	// List 依取出順序回傳最多 limit 個排隊中的網址，以及所有處理中的網址
+	List(limit int) (queued, inflight []Item, err error)
+}
+
+// Hash 回傳記錄看過網址用的雜湊（SHA-256 前 16 位元組），網址應先正規化
+func Hash(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(sum[:16])
+}
+
+// Memory 記憶體中的佇列，程式結束後狀態即消失
+type Memory struct {
+	mu       sync.Mutex
+	seq      int64
+	queue    itemHeap
+	seen     map[string]bool
+	inflight map[string]Item
+}
+
+// NewMemory 建立記憶體佇列
+func NewMemory() *Memory {
+	return &Memory{seen: map[string]bool{}, inflight: map[string]Item{}}
+}
+
+// Push 實作 Frontier
+func (m *Memory) Push(item Item) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h := Hash(item.URL)
+	if m.seen[h] {
+		return false, nil
+	}
+	m.seen[h] = true
+	m.enqueue(item)
+	return true, nil
+}
+
+// Pop 實作 Frontier
+func (m *Memory) Pop() (Item, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.queue.Len() == 0 {
+		return Item{}, false, nil
+	}
+	item := heap.Pop(&m.queue).(queued).Item
+	m.inflight[Hash(item.URL)] = item
+	return item, true, nil
+}
+
+// Done 實作 Frontier
+func (m *Memory) Done(item Item) error {
+	m.mu.Lock()
+	delete(m.inflight, Hash(item.URL))
+	m.mu.Unlock()
+	return nil
+}
+
+// Retry 實作 Frontier
+func (m *Memory) Retry(item Item) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.inflight, Hash(item.URL))
+	item.Retries++
+	m.enqueue(item)
+	return nil
+}
+
+// Stats 實作 Frontier
+func (m *Memory) Stats() (Stats, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return Stats{Seen: len(m.seen), Queued: m.queue.Len(), InFlight: len(m.inflight)}, nil
+}
+
+// List 實作 Lister
+func (m *Memory) List(limit int) (queued, inflight []Item, err error) {
+	m.mu.Lock()
+	h := append(itemHeap(nil), m.queue...)
+	for _, item := range m.inflight {
+		inflight = append(inflight, item)
+	}
+	m.mu.Unlock()
+	sort.Sort(h)
+	for i := 0; i < len(h) && i < limit; i++ {
+		queued = append(queued, h[i].Item)
+	}
+	return queued, inflight, nil
+}
+
+// Close 實作 Frontier
+func (m *Memory) Close() error {
+	return nil
+}
+
+// ----------------- 內部實作 -----------------
+
+// enqueue 呼叫端須持有 m.mu
+func (m *Memory) enqueue(item Item) {
+	m.seq++
+	heap.Push(&m.queue, queued{Item: item, seq: m.seq})
+}
+
+// queued 記錄加入順序，讓相同優先度的網址先進先出
+type queued struct {
+	Item
+	seq int64
+}
+
+type itemHeap []queued
+
+func (h itemHeap) Len() int { return len(h) }
+func (h itemHeap) Less(i, j int) bool {
+	if h[i].Priority != h[j].Priority {
+		return h[i].Priority > h[j].Priority
+	}
+	return h[i].seq < h[j].seq
+}
+func (h itemHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *itemHeap) Push(x interface{}) { *h = append(*h, x.(queued)) }
+func (h *itemHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
@@ -0,0 +1,349 @@
+package frontier
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Redis 以 Redis 保存的佇列，可跨機器保留進度。使用的鍵（皆以 Prefix 開頭）：
+//
+//	seen      SET，看過的網址雜湊
+//	queue     ZSET，排隊中的網址（JSON），分數由優先度與序號組成
+//	inflight  HASH，處理中的網址 → 原本的分數
+//	seq       序號計數器
+//
+// 需要 Redis 5.0 以上（ZPOPMIN）；只使用基本指令與 EVAL，不依賴第三方用戶端
+type Redis struct {
+	// Prefix 鍵的前綴，預設 "cdpkit:frontier:"；不同的爬取工作應使用不同前綴
+	Prefix string
+
+	addr     string
+	tls      bool
+	username string
+	password string
+	db       int
+	timeout  time.Duration
+
+	mu   sync.Mutex
+	conn net.Conn
+	rd   *bufio.Reader
+}
+
+// redisPopScript 原子地取出分數最小的網址並記錄為處理中
+const redisPopScript = `local r = redis.call('ZPOPMIN', KEYS[1])
+if #r == 0 then return false end
+redis.call('HSET', KEYS[2], r[1], r[2])
+return r[1]`
+
+// OpenRedis 連線到 rawURL（例如 "redis://:password@localhost:6379/0"，TLS 使用 "rediss://"），
+// 並將上次中斷時處理中的網址重新排入佇列。多個程序共用同一個 prefix 時，
+// 開啟時也會放回其他程序處理中的網址，請勿在其他程序執行中開啟
+func OpenRedis(rawURL, prefix string) (*Redis, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "redis" && u.Scheme != "rediss") {
+		return nil, fmt.Errorf("無效的 Redis 網址: %s", rawURL)
+	}
+	r := &Redis{
+		Prefix:  prefix,
+		addr:    u.Host,
+		tls:     u.Scheme == "rediss",
+		timeout: 10 * time.Second,
+	}
+	if r.Prefix == "" {
+		r.Prefix = "cdpkit:frontier:"
+	}
+	if u.Port() == "" {
+		r.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		r.username = u.User.Username()
+		r.password, _ = u.User.Password()
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		if r.db, err = strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("無效的 Redis 資料庫編號: %s", db)
+		}
+	}
+	if err := r.recover(); err != nil {
+		r.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// Push 實作 Frontier
+func (r *Redis) Push(item Item) (bool, error) {
+	n, err := r.do("SADD", r.key("seen"), Hash(item.URL))
+	if err != nil {
+		return false, err
+	}
+	if n != int64(1) {
+		return false, nil
+	}
+	return true, r.enqueue(item)
+}
+
+// Pop 實作 Frontier
+func (r *Redis) Pop() (Item, bool, error) {
+	v, err := r.do("EVAL", redisPopScript, "2", r.key("queue"), r.key("inflight"))
+	if err != nil || v == nil {
+		return Item{}, false, err
+	}
+	var item Item
+	if err := json.Unmarshal([]byte(v.(string)), &item); err != nil {
+		return Item{}, false, fmt.Errorf("無法解析佇列項目: %w", err)
+	}
+	return item, true, nil
+}
+
+// Done 實作 Frontier
+func (r *Redis) Done(item Item) error {
+	member, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	_, err = r.do("HDEL", r.key("inflight"), string(member))
+	return err
+}
+
+// Retry 實作 Frontier
+func (r *Redis) Retry(item Item) error {
+	if err := r.Done(item); err != nil {
+		return err
+	}
+	item.Retries++
+	return r.enqueue(item)
+}
+
+// Stats 實作 Frontier
+func (r *Redis) Stats() (Stats, error) {
+	var s Stats
+	for _, c := range []struct {
+		dst  *int
+		cmd  string
+		name string
+	}{{&s.Seen, "SCARD", "seen"}, {&s.Queued, "ZCARD", "queue"}, {&s.InFlight, "HLEN", "inflight"}} {
+		v, err := r.do(c.cmd, r.key(c.name))
+		if err != nil {
+			return s, err
+		}
+		n, _ := v.(int64)
+		*c.dst = int(n)
+	}
+	return s, nil
+}
+
+// List 實作 Lister
+func (r *Redis) List(limit int) (queued, inflight []Item, err error) {
+	if limit > 0 {
+		v, err := r.do("ZRANGE", r.key("queue"), "0", strconv.Itoa(limit-1))
+		if err != nil {
+			return nil, nil, err
+		}
+		if queued, err = decodeItems(v); err != nil {
+			return nil, nil, err
+		}
+	}
+	v, err := r.do("HKEYS", r.key("inflight"))
+	if err != nil {
+		return nil, nil, err
+	}
+	inflight, err = decodeItems(v)
+	return queued, inflight, err
+}
+
+// Close 實作 Frontier
+func (r *Redis) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.conn == nil {
+		return nil
+	}
+	err := r.conn.Close()
+	r.conn = nil
+	return err
+}
+
+// ----------------- 內部實作 -----------------
+
+func (r *Redis) key(name string) string {
+	return r.Prefix + name
+}
+
+// enqueue 分數 = -優先度 × 1e9 + 序號，ZPOPMIN 即取出優先度最高、最早加入的網址；
+// 優先度須在 ±1e6 之內、序號在 1e9 之內才能維持 float64 的精確度
+func (r *Redis) enqueue(item Item) error {
+	member, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	v, err := r.do("INCR", r.key("seq"))
+	if err != nil {
+		return err
+	}
+	seq, _ := v.(int64)
+	score := -float64(item.Priority)*1e9 + float64(seq)
+	_, err = r.do("ZADD", r.key("queue"), strconv.FormatFloat(score, 'f', -1, 64), string(member))
+	return err
+}
+
+// decodeItems 解析陣列回應中的 JSON 項目
+func decodeItems(v interface{}) ([]Item, error) {
+	members, _ := v.([]interface{})
+	items := make([]Item, 0, len(members))
+	for _, m := range members {
+		s, _ := m.(string)
+		var item Item
+		if err := json.Unmarshal([]byte(s), &item); err != nil {
+			return nil, fmt.Errorf("無法解析佇列項目: %w", err)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// recover 將處理中的網址以原本的分數放回佇列
+func (r *Redis) recover() error {
+	v, err := r.do("HGETALL", r.key("inflight"))
+	if err != nil {
+		return err
+	}
+	fields, _ := v.([]interface{})
+	for i := 0; i+1 < len(fields); i += 2 {
+		member, _ := fields[i].(string)
+		score, _ := fields[i+1].(string)
+		if _, err := r.do("ZADD", r.key("queue"), score, member); err != nil {
+			return err
+		}
+		if _, err := r.do("HDEL", r.key("inflight"), member); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// do 送出指令並讀取回應；連線中斷時下次呼叫會重新連線
+func (r *Redis) do(args ...string) (interface{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.conn == nil {
+		if err := r.dial(); err != nil {
+			return nil, err
+		}
+	}
+	v, err := r.roundTrip(args)
+	var redisErr redisError
+	if err != nil && !errors.As(err, &redisErr) {
+		// 連線狀態不明，捨棄連線
+		r.conn.Close()
+		r.conn = nil
+	}
+	return v, err
+}
+
+func (r *Redis) dial() error {
+	d := net.Dialer{Timeout: r.timeout}
+	var conn net.Conn
+	var err error
+	if r.tls {
+		host, _, _ := net.SplitHostPort(r.addr)
+		conn, err = tls.DialWithDialer(&d, "tcp", r.addr, &tls.Config{ServerName: host})
+	} else {
+		conn, err = d.Dial("tcp", r.addr)
+	}
+	if err != nil {
+		return fmt.Errorf("無法連線 Redis %s: %w", r.addr, err)
+	}
+	r.conn, r.rd = conn, bufio.NewReader(conn)
+
+	var setup [][]string
+	if r.password != "" {
+		if r.username != "" {
+			setup = append(setup, []string{"AUTH", r.username, r.password})
+		} else {
+			setup = append(setup, []string{"AUTH", r.password})
+		}
+	}
+	if r.db != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(r.db)})
+	}
+	for _, args := range setup {
+		if _, err := r.roundTrip(args); err != nil {
+			conn.Close()
+			r.conn = nil
+			return fmt.Errorf("Redis %s 失敗: %w", args[0], err)
+		}
+	}
+	return nil
+}
+
+func (r *Redis) roundTrip(args []string) (interface{}, error) {
+	r.conn.SetDeadline(time.Now().Add(r.timeout))
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, a := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(a), a)
+	}
+	if _, err := io.WriteString(r.conn, b.String()); err != nil {
+		return nil, err
+	}
+	return readRESP(r.rd)
+}
+
+// redisError 伺服器回傳的錯誤，連線本身仍可使用
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+// readRESP 解析一個 RESP2 回應：字串以 string、整數以 int64、陣列以 []interface{} 表示，nil 代表空值
+func readRESP(rd *bufio.Reader) (interface{}, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, fmt.Errorf("redis: 空白回應")
+	}
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(rd, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		arr := make([]interface{}, n)
+		for i := range arr {
+			if arr[i], err = readRESP(rd); err != nil {
+				return nil, err
+			}
+		}
+		return arr, nil
+	}
+	return nil, fmt.Errorf("redis: 無法解析的回應 %q", line)
+}
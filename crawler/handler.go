@@ -0,0 +1,121 @@
+package crawler
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"plugin"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/firehourse/cdpkit/domains"
+	"github.com/firehourse/cdpkit/tab"
+)
+
+// Handler 網站專用的處理器：Match 符合的頁面改由 Fetch 處理，取代預設的導航與腳本擷取。
+// 轉接器套件通常在 init 中呼叫 RegisterHandler，使用端以空白匯入啟用：
+//
+//	import _ "example.com/cdpkit-adapters/shopee"
+type Handler struct {
+	// Name 處理器名稱，必須唯一
+	Name string
+	// Match 判斷是否處理此 URL
+	Match func(u *url.URL) bool
+	// Fetch 處理頁面並回傳結果；URL、Timestamp 未填時由爬蟲補上
+	Fetch func(p *Page) (Result, error)
+}
+
+// Page 交給 Handler 的頁面；分頁已套用合規、資源阻擋與網域設定，但尚未導航
+type Page struct {
+	URL string
+	// Script 全域或網域設定的擷取腳本
+	Script  string
+	Tab     *tab.Tab
+	Options Options
+
+	c  *Crawler
+	ov domains.Override
+}
+
+// Default 以預設流程處理頁面（導航、等待、執行 Script），
+// 適合只需在前後加入登入、點擊等步驟的處理器
+func (p *Page) Default() (Result, error) {
+	return p.c.load(p.Tab, Result{URL: p.URL, Timestamp: time.Now()}, p.Script, p.ov)
+}
+
+var (
+	handlersMu sync.RWMutex
+	handlers   []Handler
+)
+
+// RegisterHandler 註冊網站專用處理器；多個處理器符合同一 URL 時，後註冊者優先。
+// 名稱重複或缺少 Match、Fetch 時 panic
+func RegisterHandler(h Handler) {
+	if h.Name == "" || h.Match == nil || h.Fetch == nil {
+		panic("crawler: RegisterHandler 需要 Name、Match 與 Fetch")
+	}
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+	for _, existing := range handlers {
+		if existing.Name == h.Name {
+			panic("crawler: 處理器 " + h.Name + " 重複註冊")
+		}
+	}
+	handlers = append(handlers, h)
+}
+
+// Handlers 回傳已註冊的處理器名稱（依字母排序）
+func Handlers() []string {
+	handlersMu.RLock()
+	defer handlersMu.RUnlock()
+	names := make([]string, len(handlers))
+	for i, h := range handlers {
+		names[i] = h.Name
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadPlugin 載入以 -buildmode=plugin 編譯的轉接器，其 init 中的 RegisterHandler 隨即生效。
+// plugin 須以相同版本的 Go 與 cdpkit 編譯
+func LoadPlugin(path string) error {
+	if _, err := plugin.Open(path); err != nil {
+		return fmt.Errorf("載入外掛 %s 失敗: %w", path, err)
+	}
+	return nil
+}
+
+// LoadPlugins 載入目錄中所有 .so 外掛
+func LoadPlugins(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.so"))
+	if err != nil {
+		return err
+	}
+	for _, p := range paths {
+		if err := LoadPlugin(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handlerFor 回傳處理 pageURL 的處理器；未啟用或沒有符合者時回傳 nil
+func (c *Crawler) handlerFor(pageURL string) *Handler {
+	if c.options.DisableHandlers {
+		return nil
+	}
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return nil
+	}
+	handlersMu.RLock()
+	defer handlersMu.RUnlock()
+	for i := len(handlers) - 1; i >= 0; i-- {
+		if handlers[i].Match(u) {
+			h := handlers[i]
+			return &h
+		}
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package crawler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/firehourse/cdpkit/config"
+	"github.com/firehourse/cdpkit/domains"
+	"github.com/firehourse/cdpkit/query"
+)
+
+// HooksSpec 以 jq 運算式（見 query 套件）撰寫的輕量掛鉤，可寫在 YAML 或 JSON 設定檔中，
+// 調整爬取行為不需重新編譯：
+//
+//	url_filter: '.path | startswith("/product/")'
+//	keep: '.error == null'
+//	fields:
+//	  - name: price_num
+//	    expr: '.data.price | tonumber'
+//	retry:
+//	  when: '.error != null and (.error | test("timeout"))'
+//	  max: 3
+//	  delay: 2s
+type HooksSpec struct {
+	// URLFilter 決定 FetchAll 是否爬取某個 URL；輸入為 {url, scheme, host, path, query}
+	URLFilter string `json:"url_filter,omitempty"`
+	// Keep 決定是否保留結果；輸入為結果 JSON
+	Keep string `json:"keep,omitempty"`
+	// Fields 依序計算並寫入 Data 的欄位
+	Fields []FieldHook `json:"fields,omitempty"`
+	// Retry 失敗重試條件
+	Retry *RetryHook `json:"retry,omitempty"`
+}
+
+// FieldHook 以 Expr 對結果 JSON 求值，將第一個輸出寫入 Data[Name]
+type FieldHook struct {
+	Name string `json:"name"`
+	Expr string `json:"expr"`
+}
+
+// RetryHook 結果符合 When 時重新爬取，最多 Max 次（預設 2），每次間隔 Delay
+type RetryHook struct {
+	When  string           `json:"when"`
+	Max   int              `json:"max,omitempty"`
+	Delay domains.Duration `json:"delay,omitempty"`
+}
+
+// Hooks 已編譯的掛鉤
+type Hooks struct {
+	urlFilter *query.Query
+	keep      *query.Query
+	fields    []compiledField
+	retryWhen *query.Query
+	retryMax  int
+	delay     time.Duration
+}
+
+type compiledField struct {
+	name string
+	q    *query.Query
+}
+
+// CompileHooks 編譯所有運算式
+func CompileHooks(spec HooksSpec) (*Hooks, error) {
+	h := &Hooks{}
+	compile := func(what, expr string) (*query.Query, error) {
+		if strings.TrimSpace(expr) == "" {
+			return nil, nil
+		}
+		q, err := query.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("掛鉤 %s: %w", what, err)
+		}
+		return q, nil
+	}
+
+	var err error
+	if h.urlFilter, err = compile("url_filter", spec.URLFilter); err != nil {
+		return nil, err
+	}
+	if h.keep, err = compile("keep", spec.Keep); err != nil {
+		return nil, err
+	}
+	for _, f := range spec.Fields {
+		if f.Name == "" {
+			return nil, fmt.Errorf("掛鉤 fields: 缺少 name")
+		}
+		q, err := compile("fields."+f.Name, f.Expr)
+		if err != nil {
+			return nil, err
+		}
+		if q == nil {
+			return nil, fmt.Errorf("掛鉤 fields.%s: 缺少 expr", f.Name)
+		}
+		h.fields = append(h.fields, compiledField{name: f.Name, q: q})
+	}
+	if r := spec.Retry; r != nil {
+		if h.retryWhen, err = compile("retry.when", r.When); err != nil {
+			return nil, err
+		}
+		h.retryMax = r.Max
+		if h.retryMax <= 0 {
+			h.retryMax = 2
+		}
+		h.delay = time.Duration(r.Delay)
+	}
+	return h, nil
+}
+
+// LoadHooks 讀取掛鉤設定檔；副檔名為 .json 時以 JSON 解析，其餘以 YAML 解析
+func LoadHooks(path string) (*Hooks, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("無法讀取掛鉤設定 %s: %w", path, err)
+	}
+	var spec HooksSpec
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(data, &spec)
+	} else {
+		err = config.UnmarshalYAML(data, &spec)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("無法解析掛鉤設定 %s: %w", path, err)
+	}
+	return CompileHooks(spec)
+}
+
+// AllowURL 回傳 url_filter 是否允許爬取；未設定時一律允許，運算式錯誤時不爬取
+func (h *Hooks) AllowURL(pageURL string) bool {
+	if h == nil || h.urlFilter == nil {
+		return true
+	}
+	input := map[string]interface{}{"url": pageURL}
+	if u, err := url.Parse(pageURL); err == nil {
+		input["scheme"] = u.Scheme
+		input["host"] = u.Host
+		input["path"] = u.Path
+		input["query"] = u.RawQuery
+	}
+	ok, err := h.urlFilter.Match(input)
+	return err == nil && ok
+}
+
+// Transformer 依序計算 fields 並套用 keep，可加入 Pipeline；
+// 欄位運算式出錯時寫入 Result.Error 並保留結果
+func (h *Hooks) Transformer() Transformer {
+	return func(r *Result) bool {
+		if h == nil {
+			return true
+		}
+		for _, f := range h.fields {
+			out, err := f.q.RunJSON(r)
+			if err != nil {
+				r.Error = fmt.Sprintf("掛鉤 %s: %v", f.name, err)
+				break
+			}
+			if len(out) == 0 {
+				continue
+			}
+			if r.Data == nil {
+				r.Data = map[string]interface{}{}
+			}
+			r.Data[f.name] = out[0]
+		}
+		return h.keep == nil || matchJSON(h.keep, r)
+	}
+}
+
+// shouldRetry 判斷第 attempt 次爬取的結果是否需要重試
+func (h *Hooks) shouldRetry(r Result, attempt int) bool {
+	if h == nil || h.retryWhen == nil || attempt > h.retryMax {
+		return false
+	}
+	return matchJSON(h.retryWhen, r)
+}
+
+// matchJSON 將 v 轉為 JSON 後以 q 判斷真值；運算式出錯視為不符合
+func matchJSON(q *query.Query, v interface{}) bool {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return false
+	}
+	var input interface{}
+	if err := json.Unmarshal(b, &input); err != nil {
+		return false
+	}
+	ok, err := q.Match(input)
+	return err == nil && ok
+}
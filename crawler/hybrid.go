@@ -0,0 +1,326 @@
+package crawler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/firehourse/cdpkit/config"
+	"github.com/firehourse/cdpkit/domains"
+	"github.com/firehourse/cdpkit/tab"
+)
+
+// HTTPFirst 先以一般 HTTP GET 取得頁面，內容看起來不需要 JS 渲染時直接擷取，不經瀏覽器導航；
+// 否則改用瀏覽器。請求沿用代理、網域設定的 UA、標頭與 cookies，fresh-tab 與 shared-tab 隔離時
+// 也與瀏覽器共用 cookies。擷取腳本在停用頁面 JS 的分頁中對取得的 HTML 執行，location 不是頁面網址。
+// 使用網站專用處理器、增量爬取、WARC 封存、截圖、合規掃描或抽中品質稽核的網址一律使用瀏覽器
+type HTTPFirst struct {
+	// MinText 去除標籤後的可見文字少於此字數時視為需要渲染，預設 200
+	MinText int
+	// Markers 額外的渲染標記，HTML 含任一字串時交由瀏覽器，例如 "data-server-rendered=\"false\""
+	Markers []string
+	// MaxBytes 回應超過此大小時交由瀏覽器，預設 5 MiB
+	MaxBytes int64
+}
+
+// ----------------- 內部實作 -----------------
+
+// spaMountRe 內容為空的常見框架掛載點
+var spaMountRe = regexp.MustCompile(`(?is)<(div|main)[^>]*\bid=["']?(root|app|__next|__nuxt|svelte|q-app)["']?[^>]*>\s*</(div|main)>|<(app-root|ion-app)[^>]*>\s*</(app-root|ion-app)>`)
+
+// noscriptRe 要求啟用 JavaScript 的 noscript 提示
+var noscriptRe = regexp.MustCompile(`(?is)<noscript[^>]*>[^<]*(enable javascript|requires javascript|javascript is required|啟用 ?javascript|启用 ?javascript)`)
+
+// challengeMarkers 反爬蟲驗證頁面，需要瀏覽器執行腳本
+var challengeMarkers = []string{"challenge-platform", "cf-browser-verification", "_Incapsula_Resource", "/_bm/"}
+
+var (
+	scriptStyleRe = regexp.MustCompile(`(?is)<(script|style|noscript|template)[^>]*>.*?</(script|style|noscript|template)>`)
+	tagRe         = regexp.MustCompile(`(?s)<[^>]*>`)
+	metaCharsetRe = regexp.MustCompile(`(?i)<meta[^>]+charset=["']?([\w-]+)`)
+	headRe        = regexp.MustCompile(`(?i)<head[^>]*>`)
+	refreshRe     = regexp.MustCompile(`(?i)<meta[^>]+http-equiv=["']?refresh`)
+)
+
+// httpFetcher HTTPFirst 的 HTTP 用戶端
+type httpFetcher struct {
+	opts   HTTPFirst
+	client *http.Client
+}
+
+func newHTTPFetcher(opts Options) *httpFetcher {
+	h := *opts.HTTPFirst
+	if h.MinText <= 0 {
+		h.MinText = 200
+	}
+	if h.MaxBytes <= 0 {
+		h.MaxBytes = 5 << 20
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if opts.ProxyURL != "" {
+		if u, err := url.Parse(opts.ProxyURL); err == nil {
+			transport.Proxy = http.ProxyURL(u)
+		}
+	}
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   opts.Timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return errors.New("重新導向次數過多")
+			}
+			return opts.Policy.AllowURL(req.URL.String())
+		},
+	}
+	return &httpFetcher{opts: h, client: client}
+}
+
+// fetchHTTP 以 HTTP 取得並擷取頁面；ok 為 false 時表示應改用瀏覽器
+func (c *Crawler) fetchHTTP(pageURL string, result Result, jsScript, linkSelector string, ov domains.Override) (Result, bool) {
+	if c.incr != nil || c.warc != nil || c.options.Screenshot || c.options.ArchiveDir != "" || c.options.Scan != nil || c.options.PageAudit != nil || result.Sampled || c.handlerFor(pageURL) != nil || c.options.Policy.AllowURL(pageURL) != nil {
+		return result, false
+	}
+	startTime := time.Now()
+	staticTab, err := c.acquireStatic()
+	if err != nil {
+		c.logf(2, "警告: %v", err)
+		return result, false
+	}
+	defer c.releaseStatic(staticTab)
+
+	body, status, finalURL, chain, err := c.get(staticTab, pageURL, ov)
+	if u, perr := url.Parse(pageURL); perr == nil && c.budget != nil {
+		c.budget.charge(u.Host, int64(len(body)))
+	}
+	if result.Timings != nil {
+		result.Timings.Navigate, result.Timings.Resources = time.Since(startTime), 1
+	}
+	if err != nil {
+		c.logf(4, "HTTP 取得 %s 失敗，改用瀏覽器: %v", pageURL, err)
+		return result, false
+	}
+	if reason := c.http.needsBrowser(body); reason != "" {
+		c.logf(4, "%s 需要瀏覽器渲染（%s）", pageURL, reason)
+		return result, false
+	}
+	if err := staticTab.SetContent(withBase(string(body), finalURL)); err != nil {
+		c.logf(2, "警告: %v", err)
+		return result, false
+	}
+	if ov.WaitSelector != "" {
+		if n, err := staticTab.Count(ov.WaitSelector); err != nil || n == 0 {
+			c.logf(4, "%s 的 HTML 沒有 %s，改用瀏覽器", pageURL, ov.WaitSelector)
+			return result, false
+		}
+	}
+
+	c.logf(4, "以 HTTP 取得: %s", pageURL)
+	result.ResponseCode = status
+	result.FinalURL, result.RedirectChain = finalURL, chain
+	result.HTTPOnly = true
+	result = c.extract(staticTab, result, jsScript, startTime)
+	if c.options.SaveHTML {
+		// 以原始回應取代加上 <base> 後的文件
+		result.HTML = string(body)
+		if p := c.options.Policy; p != nil && p.ScrubPII {
+			result.HTML = p.Scrub(result.HTML)
+		}
+	}
+	if linkSelector != "" {
+		result.Links = c.collectLinks(staticTab, linkSelector)
+	}
+	return result, true
+}
+
+// get 送出 GET；與瀏覽器共用 cookies 時先讀取瀏覽器的 cookies，並將回應設定的 cookies 寫回瀏覽器
+func (c *Crawler) get(staticTab *tab.Tab, pageURL string, ov domains.Override) (body []byte, status int, finalURL string, chain []Redirect, err error) {
+	req, err := http.NewRequestWithContext(c.ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, 0, "", nil, err
+	}
+	ua := ov.UserAgent
+	if ua == "" {
+		// 與瀏覽器分頁相同的 UA
+		if v, err := staticTab.RunJS("navigator.userAgent", 5*time.Second); err == nil {
+			ua, _ = v.(string)
+		}
+	}
+	if ua != "" {
+		req.Header.Set("User-Agent", ua)
+	}
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+	for k, v := range ov.Headers {
+		req.Header.Set(k, v)
+	}
+	if c.options.TagRequests {
+		req.Header.Set(c.options.TagHeader, c.options.JobID)
+	}
+
+	shared := c.options.Isolation == IsolationFreshTab || c.options.Isolation == IsolationSharedTab
+	if shared {
+		if cookies, err := staticTab.Cookies(pageURL); err == nil {
+			for _, ck := range cookies {
+				req.AddCookie(&http.Cookie{Name: ck.Name, Value: ck.Value})
+			}
+		}
+	}
+	for name, value := range ov.Cookies {
+		req.AddCookie(&http.Cookie{Name: name, Value: value})
+	}
+
+	resp, err := c.http.client.Do(req)
+	if err != nil {
+		return nil, 0, "", nil, err
+	}
+	defer resp.Body.Close()
+	finalURL = resp.Request.URL.String()
+	chain = redirectChain(resp)
+
+	if shared {
+		set := map[string]string{}
+		for _, ck := range resp.Cookies() {
+			set[ck.Name] = ck.Value
+		}
+		if err := staticTab.SetCookies(finalURL, set); err != nil {
+			c.logf(2, "警告: %v", err)
+		}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.StatusCode, finalURL, chain, fmt.Errorf("狀態碼 %d", resp.StatusCode)
+	}
+	mediaType, params, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, resp.StatusCode, finalURL, chain, fmt.Errorf("內容類型 %q", mediaType)
+	}
+	if cs := params["charset"]; cs != "" && !isUTF8(cs) {
+		return nil, resp.StatusCode, finalURL, chain, fmt.Errorf("編碼 %s", cs)
+	}
+	body, err = io.ReadAll(io.LimitReader(resp.Body, c.http.opts.MaxBytes+1))
+	if err != nil {
+		return nil, resp.StatusCode, finalURL, chain, err
+	}
+	if int64(len(body)) > c.http.opts.MaxBytes {
+		return nil, resp.StatusCode, finalURL, chain, fmt.Errorf("回應超過 %d 位元組", c.http.opts.MaxBytes)
+	}
+	return body, resp.StatusCode, finalURL, chain, nil
+}
+
+// redirectChain 由回應回溯 http.Client 跟隨的重新導向
+func redirectChain(resp *http.Response) []Redirect {
+	var chain []Redirect
+	for req := resp.Request; req.Response != nil; req = req.Response.Request {
+		prev := req.Response
+		chain = append(chain, Redirect{URL: prev.Request.URL.String(), Location: req.URL.String(), Status: prev.StatusCode})
+	}
+	slices.Reverse(chain)
+	return chain
+}
+
+// needsBrowser 以啟發式規則判斷 HTML 是否需要執行 JS 才有內容，回傳原因；空字串表示可直接擷取
+func (h *httpFetcher) needsBrowser(body []byte) string {
+	if !utf8.Valid(body) {
+		return "非 UTF-8 編碼"
+	}
+	head := body
+	if len(head) > 2048 {
+		head = head[:2048]
+	}
+	if m := metaCharsetRe.FindSubmatch(head); m != nil && !isUTF8(string(m[1])) {
+		return "編碼 " + string(m[1])
+	}
+	html := string(body)
+	for _, m := range challengeMarkers {
+		if strings.Contains(html, m) {
+			return "驗證頁面"
+		}
+	}
+	for _, m := range h.opts.Markers {
+		if strings.Contains(html, m) {
+			return "標記 " + m
+		}
+	}
+	if refreshRe.MatchString(html) {
+		return "meta refresh 導向"
+	}
+	if spaMountRe.MatchString(html) {
+		return "空白的框架掛載點"
+	}
+	if noscriptRe.MatchString(html) {
+		return "要求啟用 JavaScript"
+	}
+	text := tagRe.ReplaceAllString(scriptStyleRe.ReplaceAllString(html, " "), " ")
+	if n := utf8.RuneCountInString(strings.Join(strings.Fields(text), " ")); n < h.opts.MinText {
+		return fmt.Sprintf("可見文字只有 %d 字", n)
+	}
+	return ""
+}
+
+// acquireStatic 取得停用 JS 並阻擋所有請求的分頁，用於對 HTTP 取得的 HTML 執行擷取腳本
+func (c *Crawler) acquireStatic() (*tab.Tab, error) {
+	if t := c.idleTab(staticKey); t != nil {
+		return t, nil
+	}
+	tabCtx, tabCancel, err := c.bm.NewPageContext()
+	if err != nil {
+		return nil, fmt.Errorf("創建分頁失敗: %w", err)
+	}
+	layers := config.Layered{
+		Tab:     config.Tab{Device: c.options.Device},
+		Request: config.Request{Timeout: c.options.Timeout},
+	}
+	if c.options.Config != nil {
+		layers.Tab.NoAutomationTweaks = c.options.Config.Tab.NoAutomationTweaks
+	}
+	t := tab.Open(tabCtx, tabCancel, layers)
+	t.Audit = c.audit
+	if err := t.DisableScripts(true); err != nil {
+		t.Close(c.bm)
+		return nil, err
+	}
+	if err := t.BlockResources(nil, []string{"*"}); err != nil {
+		t.Close(c.bm)
+		return nil, err
+	}
+	return t, nil
+}
+
+// releaseStatic 將分頁放回閒置分頁；爬蟲已關閉時直接關閉
+func (c *Crawler) releaseStatic(t *tab.Tab) {
+	c.mu.Lock()
+	if c.idle != nil && t.Ctx.Err() == nil {
+		c.idle[staticKey] = append(c.idle[staticKey], t)
+		c.mu.Unlock()
+		return
+	}
+	c.mu.Unlock()
+	t.Close(c.bm)
+}
+
+// staticKey 閒置分頁中 HTTPFirst 擷取用分頁的鍵，不會與 tabKey 重複
+const staticKey = "\x00static"
+
+// withBase 在 head 開頭加上 <base href>，讓相對連結以原本的網址解析
+func withBase(html, pageURL string) string {
+	base := `<base href="` + strings.ReplaceAll(pageURL, `"`, "%22") + `">`
+	if loc := headRe.FindStringIndex(html); loc != nil {
+		return html[:loc[1]] + base + html[loc[1]:]
+	}
+	return base + html
+}
+
+func isUTF8(charset string) bool {
+	switch strings.ToLower(charset) {
+	case "utf-8", "utf8", "us-ascii", "ascii":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,201 @@
+package crawler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/firehourse/cdpkit/tab"
+)
+
+// Validators 單一 URL 上次爬取時的驗證值
+type Validators struct {
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	ContentHash  string    `json:"content_hash,omitempty"`
+	FetchedAt    time.Time `json:"fetched_at"`
+}
+
+// incrementalStore 以 URL 為鍵保存驗證值，於 Close 時寫回狀態檔
+type incrementalStore struct {
+	path   string
+	client *http.Client
+
+	mu    sync.Mutex
+	state map[string]Validators
+	dirty bool
+}
+
+func newIncrementalStore(opts Options) (*incrementalStore, error) {
+	s := &incrementalStore{path: opts.IncrementalState, state: map[string]Validators{}}
+	data, err := os.ReadFile(s.path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("讀取增量狀態檔失敗: %w", err)
+	default:
+		if err := json.Unmarshal(data, &s.state); err != nil {
+			return nil, fmt.Errorf("解析增量狀態檔 %s 失敗: %w", s.path, err)
+		}
+	}
+
+	if opts.IncrementalHEAD {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		if opts.ProxyURL != "" {
+			if u, err := url.Parse(opts.ProxyURL); err == nil {
+				transport.Proxy = http.ProxyURL(u)
+			}
+		}
+		s.client = &http.Client{Transport: transport, Timeout: 15 * time.Second}
+	}
+	return s, nil
+}
+
+func (s *incrementalStore) get(pageURL string) (Validators, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.state[pageURL]
+	return v, ok
+}
+
+func (s *incrementalStore) put(pageURL string, v Validators) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state[pageURL] = v
+	s.dirty = true
+}
+
+// save 有變更時寫回狀態檔；先寫暫存檔再改名，中斷時不會留下損毀的狀態
+func (s *incrementalStore) save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.dirty {
+		return nil
+	}
+	data, err := json.MarshalIndent(s.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("寫入增量狀態檔失敗: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("寫入增量狀態檔失敗: %w", err)
+	}
+	s.dirty = false
+	return nil
+}
+
+// unchangedByHEAD 以頁面 session 的 cookies 送出條件式 HEAD；回應 304，
+// 或 ETag/Last-Modified 與上次相同時視為未變更。任何錯誤都視為已變更，交由正常流程處理
+func (s *incrementalStore) unchangedByHEAD(pageTab *tab.Tab, pageURL, userAgent string, prev Validators) bool {
+	if prev.ETag == "" && prev.LastModified == "" {
+		return false
+	}
+	req, err := http.NewRequest(http.MethodHead, pageURL, nil)
+	if err != nil {
+		return false
+	}
+	if userAgent != "" {
+		req.Header.Set("User-Agent", userAgent)
+	}
+	if cookies, err := pageTab.Cookies(pageURL); err == nil {
+		for _, c := range cookies {
+			req.AddCookie(&http.Cookie{Name: c.Name, Value: c.Value})
+		}
+	}
+	setConditionalHeaders(req.Header.Set, prev)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified:
+		return true
+	case resp.StatusCode != http.StatusOK:
+		return false
+	case prev.ETag != "":
+		return resp.Header.Get("ETag") == prev.ETag
+	default:
+		return resp.Header.Get("Last-Modified") == prev.LastModified
+	}
+}
+
+// setConditionalHeaders 依上次的驗證值設定 If-None-Match / If-Modified-Since
+func setConditionalHeaders(set func(name, value string), prev Validators) {
+	if prev.ETag != "" {
+		set("If-None-Match", prev.ETag)
+	}
+	if prev.LastModified != "" {
+		set("If-Modified-Since", prev.LastModified)
+	}
+}
+
+// conditionalNavigation 讓頁面主文件請求帶上條件式標頭，伺服器可直接回應 304
+func conditionalNavigation(pageTab *tab.Tab, pageURL string, prev Validators) error {
+	if prev.ETag == "" && prev.LastModified == "" {
+		return nil
+	}
+	return pageTab.AddInterceptor(func(r *tab.PausedRequest) {
+		if r.Event.ResourceType == network.ResourceTypeDocument && r.Event.Request.URL == pageURL {
+			setConditionalHeaders(r.SetHeader, prev)
+		}
+	})
+}
+
+// documentResponse 回傳 pageURL 的主文件回應；沒有完全相符的網址（例如經過重新導向）時取第一個文件回應
+func documentResponse(pageTab *tab.Tab, pageURL string) *tab.Response {
+	var doc *tab.Response
+	for _, r := range pageTab.Responses() {
+		if r.ResourceType != network.ResourceTypeDocument {
+			continue
+		}
+		r := r
+		if doc == nil || r.URL == pageURL {
+			doc = &r
+		}
+		if r.URL == pageURL {
+			break
+		}
+	}
+	return doc
+}
+
+// documentValidators 取得頁面主文件回應的狀態碼與驗證值；找不到主文件時 ok 為 false
+func (c *Crawler) documentValidators(pageTab *tab.Tab, pageURL string) (status int, v Validators, ok bool) {
+	doc := documentResponse(pageTab, pageURL)
+	if doc == nil {
+		return 0, v, false
+	}
+
+	v.FetchedAt = time.Now()
+	for k, val := range doc.Headers {
+		switch strings.ToLower(k) {
+		case "etag":
+			v.ETag = fmt.Sprintf("%v", val)
+		case "last-modified":
+			v.LastModified = fmt.Sprintf("%v", val)
+		}
+	}
+	if doc.Status == http.StatusNotModified {
+		return int(doc.Status), v, true
+	}
+	if body, err := pageTab.GetResponseBody(doc.RequestID, c.options.Timeout); err == nil {
+		sum := sha256.Sum256(body)
+		v.ContentHash = hex.EncodeToString(sum[:])
+	}
+	return int(doc.Status), v, true
+}
@@ -0,0 +1,178 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/firehourse/cdpkit/browser"
+	"github.com/firehourse/cdpkit/config"
+	"github.com/firehourse/cdpkit/domains"
+	"github.com/firehourse/cdpkit/tab"
+)
+
+// Isolation 每個網址之間的隔離程度，以速度換取獨立性，見 Options.Isolation
+type Isolation string
+
+const (
+	// IsolationSharedTab 重複使用閒置的分頁，省下建立分頁與初始化的時間；
+	// cookies、storage 與頁面狀態會延續到下一個網址
+	IsolationSharedTab Isolation = "shared-tab"
+	// IsolationFreshTab 每個網址開新分頁（預設）；cookies 與快取仍與其他分頁共用
+	IsolationFreshTab Isolation = "fresh-tab"
+	// IsolationFreshContext 每個網址使用新的 browser context（類似無痕視窗），
+	// cookies、快取與 storage 互不影響；不支援連線既有 Chrome 的 Remote 模式
+	IsolationFreshContext Isolation = "fresh-context"
+	// IsolationFreshBrowser 每個網址啟動新的 Chrome，最慢但連行程層級的狀態都不共用；
+	// DevTools 代理只涵蓋主要的 Chrome
+	IsolationFreshBrowser Isolation = "fresh-browser"
+)
+
+// ParseIsolation 解析隔離程度名稱，空字串視為 fresh-tab
+func ParseIsolation(s string) (Isolation, error) {
+	switch Isolation(s) {
+	case "":
+		return IsolationFreshTab, nil
+	case IsolationSharedTab, IsolationFreshTab, IsolationFreshContext, IsolationFreshBrowser:
+		return Isolation(s), nil
+	}
+	return "", fmt.Errorf("未知的隔離程度 %q（可用 shared-tab、fresh-tab、fresh-context、fresh-browser）", s)
+}
+
+// ----------------- 內部實作 -----------------
+
+// tabKey 分頁建立時套用的 UA 與指紋 profile 之後無法更改，只有相同的網域設定才能共用分頁
+func tabKey(ov domains.Override) string {
+	return ov.UserAgent + "\x00" + ov.StealthProfile
+}
+
+// acquireTab 依隔離程度取得分頁；reused 為 true 表示取自閒置分頁，已完成資源阻擋等一次性設定
+func (c *Crawler) acquireTab(ov domains.Override) (pageTab *tab.Tab, reused bool, err error) {
+	if c.options.Isolation == IsolationSharedTab {
+		if pageTab := c.idleTab(tabKey(ov)); pageTab != nil {
+			pageTab.ResetResponses()
+			return pageTab, true, nil
+		}
+	}
+
+	bm := c.bm
+	var tabCtx context.Context
+	var tabCancel context.CancelFunc
+	switch c.options.Isolation {
+	case IsolationFreshContext:
+		tabCtx, tabCancel, err = bm.NewIsolatedPageContext()
+	case IsolationFreshBrowser:
+		if bm, err = c.launchBrowser(); err != nil {
+			return nil, false, err
+		}
+		if tabCtx, tabCancel, err = bm.NewPageContext(); err != nil {
+			bm.Shutdown()
+		}
+	default:
+		tabCtx, tabCancel, err = bm.NewPageContext()
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("創建分頁失敗: %w", err)
+	}
+
+	layers := config.Layered{Request: config.Request{Timeout: c.options.Timeout}}
+	if c.options.Config != nil {
+		layers.Tab = c.options.Config.Tab
+	}
+	layers.Tab.HangTimeout = c.options.HangTimeout
+	layers.Tab.Policy = c.options.Policy
+	layers.Tab.Device = c.options.Device
+	if layers.Tab.Seed == 0 {
+		layers.Tab.Seed = c.options.Seed
+	}
+	if ov.UserAgent != "" {
+		layers.Tab.UserAgent = ov.UserAgent
+	}
+	if ov.StealthProfile != "" {
+		layers.Tab.StealthProfile = ov.StealthProfile
+	}
+	pageTab = tab.Open(tabCtx, tabCancel, layers)
+	pageTab.Audit = c.audit
+	c.noteBrowser(pageTab)
+	if bm != c.bm {
+		c.mu.Lock()
+		c.owned[pageTab] = bm
+		c.mu.Unlock()
+	}
+	return pageTab, false, nil
+}
+
+// releaseTab 分頁用完後，shared-tab 放回閒置分頁，其餘關閉；fresh-browser 一併關閉專屬的 Chrome
+func (c *Crawler) releaseTab(pageTab *tab.Tab, ov domains.Override) {
+	if c.options.Isolation == IsolationSharedTab && pageTab.Ctx != nil && pageTab.Ctx.Err() == nil {
+		c.mu.Lock()
+		if c.idle != nil {
+			key := tabKey(ov)
+			c.idle[key] = append(c.idle[key], pageTab)
+			c.mu.Unlock()
+			return
+		}
+		c.mu.Unlock()
+	}
+
+	c.mu.Lock()
+	bm, owned := c.owned[pageTab]
+	delete(c.owned, pageTab)
+	c.mu.Unlock()
+	if owned {
+		pageTab.Close(bm)
+		bm.Shutdown()
+		return
+	}
+	pageTab.Close(c.bm)
+}
+
+// idleTab 取出一個仍可使用的閒置分頁；沒有時回傳 nil
+func (c *Crawler) idleTab(key string) *tab.Tab {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	idle := c.idle[key]
+	for len(idle) > 0 {
+		pageTab := idle[len(idle)-1]
+		idle = idle[:len(idle)-1]
+		if pageTab.Ctx != nil && pageTab.Ctx.Err() == nil {
+			c.idle[key] = idle
+			return pageTab
+		}
+		// 瀏覽器已重置或分頁已崩潰
+		pageTab.Close(c.bm)
+	}
+	delete(c.idle, key)
+	return nil
+}
+
+// closeIdle 關閉所有閒置分頁與仍在使用中的專屬 Chrome，之後用完的分頁一律直接關閉
+func (c *Crawler) closeIdle() {
+	c.mu.Lock()
+	idle, owned := c.idle, c.owned
+	c.idle, c.owned = nil, map[*tab.Tab]*browser.BrowserManager{}
+	c.mu.Unlock()
+	for _, tabs := range idle {
+		for _, pageTab := range tabs {
+			pageTab.Close(c.bm)
+		}
+	}
+	for pageTab, bm := range owned {
+		pageTab.Close(bm)
+		bm.Shutdown()
+	}
+}
+
+// launchBrowser 以主要瀏覽器的設定在另一個連接埠啟動 Chrome
+func (c *Crawler) launchBrowser() (*browser.BrowserManager, error) {
+	cfg := c.browserCfg
+	port, err := browser.FreePort()
+	if err != nil {
+		return nil, err
+	}
+	cfg.RemotePort = port
+	bm, err := browser.NewManagerFromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("啟動獨立的 Chrome 失敗: %w", err)
+	}
+	return bm, nil
+}
@@ -0,0 +1,89 @@
+package crawler
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// RequestType 網址的種類，決定取得的方式
+type RequestType string
+
+const (
+	// RequestPage 以瀏覽器導航、渲染後執行擷取腳本，預設值
+	RequestPage RequestType = ""
+	// RequestJSON 以瀏覽器的網路堆疊取得 JSON 並解析到 Result.Data，不導航、不建立 DOM 也不渲染
+	RequestJSON RequestType = "json"
+)
+
+// FetchJSON 以分頁的網路堆疊取得 url 的 JSON 並解析到 Result.Data：代理、UA、cookies 與網域設定的標頭都與頁面相同，
+// 但不導航、不建立 DOM 也不渲染，適合同時爬取頁面與其背後 API 的工作。物件直接作為 Data，其他值放在 "result" 鍵下。
+// 速率限制、流量預算與重試與 Fetch 相同；擷取腳本、截圖等頁面相關的設定不適用
+func (c *Crawler) FetchJSON(url string) (Result, error) {
+	return c.fetchRetry(url, func() (Result, error) { return c.fetchJSON(url) })
+}
+
+// ----------------- 內部實作 -----------------
+
+func (c *Crawler) fetchJSON(url string) (result Result, err error) {
+	result = Result{URL: url, Timestamp: time.Now(), Timings: &Timings{}}
+	defer func(started time.Time) {
+		result.Timings.Total = time.Since(started)
+	}(result.Timestamp)
+
+	host, ov := c.domainOverride(url)
+	release, err := c.gate.acquire(c.ctx, host, ov)
+	result.Timings.Queue = time.Since(result.Timestamp)
+	if err != nil {
+		result.Error = fmt.Sprintf("等待網域 %s 的速率限制: %v", host, err)
+		return result, err
+	}
+	defer release()
+
+	if c.budget != nil && !c.budget.charge(host, 0) {
+		result.Truncated = true
+		return result, fmt.Errorf("%w: %s", ErrBandwidthExceeded, host)
+	}
+
+	opened := time.Now()
+	pageTab, err := c.openTab(url, host, ov)
+	result.Timings.Open = time.Since(opened)
+	if err != nil {
+		return result, err
+	}
+	defer c.closeTab(pageTab, ov)
+
+	started := time.Now()
+	res, err := pageTab.LoadResource(url, c.options.Timeout)
+	result.Timings.Navigate = time.Since(started)
+	if err != nil {
+		result.Error = err.Error()
+		return result, err
+	}
+	result.ResponseCode = res.Status
+	result.Timings.Resources = 1
+	if c.budget != nil {
+		c.budget.charge(host, int64(len(res.Body)))
+	}
+
+	extracted := time.Now()
+	var v interface{}
+	if err := json.Unmarshal(res.Body, &v); err != nil {
+		// 錯誤頁面常以 HTML 回應，與頁面相同只記錄狀態碼，由 Retry 與 Classify 依狀態碼處理
+		if res.Status >= 400 {
+			return result, nil
+		}
+		result.Error = fmt.Sprintf("解析 JSON 失敗（%s）: %v", res.ContentType(), err)
+		return result, fmt.Errorf("解析 %s 的 JSON 失敗: %w", url, err)
+	}
+	result.RawJSResponse = v
+	if m, ok := v.(map[string]interface{}); ok {
+		result.Data = m
+	} else {
+		result.Data = map[string]interface{}{"result": v}
+	}
+	result.Timings.Extract = time.Since(extracted)
+	result.ElapsedTime = time.Since(started)
+	c.scrub(&result)
+	return result, nil
+}
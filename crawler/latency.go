@@ -0,0 +1,218 @@
+package crawler
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// Timings 頁面各階段的耗時與載入的資源數，記錄於 Result.Timings 並彙整為 LatencyReport。
+// 以 HTTP 取得的頁面沒有 Open、Settle，Navigate 為 HTTP 請求的時間；網站專用處理器未呼叫 Page.Default 時只有 Queue、Open 與 Total
+type Timings struct {
+	// Queue 等待網域速率限制與並發上限
+	Queue time.Duration `json:"queue"`
+	// Open 取得分頁並套用標頭、cookies 等設定
+	Open time.Duration `json:"open"`
+	// Navigate 導航至頁面載入完成
+	Navigate time.Duration `json:"navigate"`
+	// Settle 等待條件、延遲與捲動載入
+	Settle time.Duration `json:"settle"`
+	// Extract 執行擷取腳本、取得 HTML 與截圖
+	Extract time.Duration `json:"extract"`
+	// Total 單次嘗試的總時間，不含重試之間的等待
+	Total time.Duration `json:"total"`
+	// Resources 頁面載入的網路資源數
+	Resources int `json:"resources"`
+}
+
+// LatencyPhases LatencyReport 中的階段名稱，依處理順序排列
+var LatencyPhases = []string{"queue", "open", "navigate", "settle", "extract", "total"}
+
+// Percentiles 一個階段的延遲分位數；以 √2 倍的直方圖桶界估計，最多高估約 40%，Max 為實際最大值
+type Percentiles struct {
+	P50 time.Duration `json:"p50"`
+	P90 time.Duration `json:"p90"`
+	P95 time.Duration `json:"p95"`
+	P99 time.Duration `json:"p99"`
+	Max time.Duration `json:"max"`
+}
+
+// HostLatency 一個 host 各階段的延遲分位數
+type HostLatency struct {
+	Host   string                 `json:"host"`
+	Pages  int                    `json:"pages"`
+	Phases map[string]Percentiles `json:"phases"`
+}
+
+// SlowPage 最慢頁面報告中的一筆
+type SlowPage struct {
+	URL     string  `json:"url"`
+	Host    string  `json:"host"`
+	Timings Timings `json:"timings"`
+	Error   string  `json:"error,omitempty"`
+}
+
+// LatencyReport 各 host 的延遲分位數（依總時間 p95 由慢到快）與最慢的頁面，
+// 用於判斷哪些網站值得阻擋資源或改用其他等待策略
+type LatencyReport struct {
+	Hosts   []HostLatency `json:"hosts"`
+	Slowest []SlowPage    `json:"slowest"`
+}
+
+// WriteText 以表格輸出報告；每格為 p50/p95
+func (r LatencyReport) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "主機\t頁數\t佇列\t開啟\t導航\t等待\t擷取\t總計")
+	for _, h := range r.Hosts {
+		fmt.Fprintf(tw, "%s\t%d", h.Host, h.Pages)
+		for _, phase := range LatencyPhases {
+			p := h.Phases[phase]
+			fmt.Fprintf(tw, "\t%s/%s", shortDuration(p.P50), shortDuration(p.P95))
+		}
+		fmt.Fprintln(tw)
+	}
+	if len(r.Slowest) > 0 {
+		fmt.Fprintln(tw, "\n最慢的頁面\t總計\t導航\t等待\t擷取\t資源數\t錯誤")
+		for _, p := range r.Slowest {
+			t := p.Timings
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%d\t%s\n", p.URL, shortDuration(t.Total), shortDuration(t.Navigate),
+				shortDuration(t.Settle), shortDuration(t.Extract), t.Resources, p.Error)
+		}
+	}
+	return tw.Flush()
+}
+
+// ----------------- 內部實作 -----------------
+
+// 直方圖的桶界為 1ms×√2^i，共 latencyBuckets 個，最後一桶約 12 分鐘
+const latencyBuckets = 40
+
+var latencyBounds = func() []time.Duration {
+	b := make([]time.Duration, latencyBuckets)
+	for i := range b {
+		b[i] = time.Duration(float64(time.Millisecond) * math.Pow(math.Sqrt2, float64(i)))
+	}
+	return b
+}()
+
+// histogram 固定桶界的延遲直方圖，記憶體用量與頁面數無關
+type histogram struct {
+	counts [latencyBuckets]int
+	n      int
+	max    time.Duration
+}
+
+func (h *histogram) add(d time.Duration) {
+	i := sort.Search(latencyBuckets, func(i int) bool { return latencyBounds[i] >= d })
+	if i == latencyBuckets {
+		i--
+	}
+	h.counts[i]++
+	h.n++
+	if d > h.max {
+		h.max = d
+	}
+}
+
+// quantile 回傳第 q 分位所在桶的上界，不超過實際最大值
+func (h *histogram) quantile(q float64) time.Duration {
+	if h.n == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(q * float64(h.n)))
+	seen := 0
+	for i, c := range h.counts {
+		seen += c
+		if seen >= rank {
+			if latencyBounds[i] > h.max {
+				return h.max
+			}
+			return latencyBounds[i]
+		}
+	}
+	return h.max
+}
+
+func (h *histogram) percentiles() Percentiles {
+	return Percentiles{P50: h.quantile(0.5), P90: h.quantile(0.9), P95: h.quantile(0.95), P99: h.quantile(0.99), Max: h.max}
+}
+
+// latencyStats 各 host 各階段的直方圖與最慢的 limit 個頁面
+type latencyStats struct {
+	mu      sync.Mutex
+	limit   int
+	hosts   map[string]*hostHistograms
+	slowest []SlowPage
+}
+
+type hostHistograms struct {
+	pages  int
+	phases [6]histogram
+}
+
+func newLatencyStats(limit int) *latencyStats {
+	return &latencyStats{limit: limit, hosts: make(map[string]*hostHistograms)}
+}
+
+func (s *latencyStats) add(r Result) {
+	if r.Timings == nil {
+		return
+	}
+	t := *r.Timings
+	host := hostOf(r.URL)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h := s.hosts[host]
+	if h == nil {
+		h = &hostHistograms{}
+		s.hosts[host] = h
+	}
+	h.pages++
+	for i, d := range []time.Duration{t.Queue, t.Open, t.Navigate, t.Settle, t.Extract, t.Total} {
+		h.phases[i].add(d)
+	}
+
+	if s.limit <= 0 || (len(s.slowest) == s.limit && t.Total <= s.slowest[len(s.slowest)-1].Timings.Total) {
+		return
+	}
+	i := sort.Search(len(s.slowest), func(i int) bool { return s.slowest[i].Timings.Total < t.Total })
+	s.slowest = append(s.slowest, SlowPage{})
+	copy(s.slowest[i+1:], s.slowest[i:])
+	s.slowest[i] = SlowPage{URL: r.URL, Host: host, Timings: t, Error: r.Error}
+	if len(s.slowest) > s.limit {
+		s.slowest = s.slowest[:s.limit]
+	}
+}
+
+func (s *latencyStats) report() LatencyReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := LatencyReport{Hosts: make([]HostLatency, 0, len(s.hosts)), Slowest: append([]SlowPage(nil), s.slowest...)}
+	for host, h := range s.hosts {
+		hl := HostLatency{Host: host, Pages: h.pages, Phases: make(map[string]Percentiles, len(LatencyPhases))}
+		for i, phase := range LatencyPhases {
+			hl.Phases[phase] = h.phases[i].percentiles()
+		}
+		r.Hosts = append(r.Hosts, hl)
+	}
+	sort.Slice(r.Hosts, func(i, j int) bool {
+		pi, pj := r.Hosts[i].Phases["total"].P95, r.Hosts[j].Phases["total"].P95
+		if pi != pj {
+			return pi > pj
+		}
+		return r.Hosts[i].Host < r.Hosts[j].Host
+	})
+	return r
+}
+
+// shortDuration 報告中的時間格式：一秒以下取到毫秒，以上取到 10 毫秒
+func shortDuration(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(10 * time.Millisecond).String()
+}
@@ -0,0 +1,171 @@
+package crawler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 預設嘗試的服務條款路徑
+var defaultTermsPaths = []string{"/terms", "/terms-of-service", "/terms-of-use", "/tos", "/legal"}
+
+// LegalDocument 單份已封存的法律相關文件
+type LegalDocument struct {
+	// Kind "robots"、"security" 或 "terms"
+	Kind       string    `json:"kind"`
+	URL        string    `json:"url"`
+	StatusCode int       `json:"status_code"`
+	FetchedAt  time.Time `json:"fetched_at"`
+	// Path 封存檔案位置；取得失敗時為空
+	Path   string `json:"path,omitempty"`
+	SHA256 string `json:"sha256,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// LegalRecord 單一網域的法律文件封存紀錄
+type LegalRecord struct {
+	Domain    string          `json:"domain"`
+	FetchedAt time.Time       `json:"fetched_at"`
+	Documents []LegalDocument `json:"documents"`
+}
+
+// legalArchiver 每個網域只封存一次
+type legalArchiver struct {
+	dir        string
+	termsPaths []string
+	client     *http.Client
+	userAgent  string
+
+	mu      sync.Mutex
+	once    map[string]*sync.Once
+	records []LegalRecord
+}
+
+func newLegalArchiver(opts Options) *legalArchiver {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if opts.ProxyURL != "" {
+		if u, err := url.Parse(opts.ProxyURL); err == nil {
+			transport.Proxy = http.ProxyURL(u)
+		}
+	}
+	paths := opts.TermsPaths
+	if len(paths) == 0 {
+		paths = defaultTermsPaths
+	}
+	dir := opts.LegalDir
+	if dir == "" {
+		dir = "legal"
+	}
+	return &legalArchiver{
+		dir:        dir,
+		termsPaths: paths,
+		client:     &http.Client{Transport: transport, Timeout: 30 * time.Second},
+		userAgent:  opts.UserAgent,
+		once:       map[string]*sync.Once{},
+	}
+}
+
+// capture 第一次遇到某網域時封存其 robots.txt、security.txt 與服務條款頁面
+func (a *legalArchiver) capture(pageURL string) {
+	u, err := url.Parse(pageURL)
+	if err != nil || u.Host == "" {
+		return
+	}
+	origin := u.Scheme + "://" + u.Host
+
+	a.mu.Lock()
+	once, ok := a.once[u.Host]
+	if !ok {
+		once = &sync.Once{}
+		a.once[u.Host] = once
+	}
+	a.mu.Unlock()
+
+	once.Do(func() {
+		rec := LegalRecord{Domain: u.Host, FetchedAt: time.Now()}
+		rec.Documents = append(rec.Documents,
+			a.archive(u.Host, "robots", origin+"/robots.txt"),
+			a.archive(u.Host, "security", origin+"/.well-known/security.txt"),
+		)
+		for _, p := range a.termsPaths {
+			doc := a.archive(u.Host, "terms", origin+p)
+			if doc.StatusCode == http.StatusOK {
+				rec.Documents = append(rec.Documents, doc)
+				break
+			}
+		}
+
+		a.mu.Lock()
+		a.records = append(a.records, rec)
+		a.mu.Unlock()
+	})
+}
+
+func (a *legalArchiver) archive(host, kind, docURL string) LegalDocument {
+	doc := LegalDocument{Kind: kind, URL: docURL, FetchedAt: time.Now()}
+
+	req, err := http.NewRequest(http.MethodGet, docURL, nil)
+	if err != nil {
+		doc.Error = err.Error()
+		return doc
+	}
+	if a.userAgent != "" {
+		req.Header.Set("User-Agent", a.userAgent)
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		doc.Error = err.Error()
+		return doc
+	}
+	defer resp.Body.Close()
+	doc.StatusCode = resp.StatusCode
+	if resp.StatusCode != http.StatusOK {
+		return doc
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 5<<20))
+	if err != nil {
+		doc.Error = err.Error()
+		return doc
+	}
+	sum := sha256.Sum256(body)
+	doc.SHA256 = hex.EncodeToString(sum[:])
+
+	ext := ".txt"
+	if kind == "terms" {
+		ext = ".html"
+	}
+	name := fmt.Sprintf("%s-%s%s", doc.FetchedAt.Format("20060102T150405"), kind, ext)
+	path := filepath.Join(a.dir, sanitizeHost(host), name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		doc.Error = err.Error()
+		return doc
+	}
+	if err := os.WriteFile(path, body, 0644); err != nil {
+		doc.Error = err.Error()
+		return doc
+	}
+	doc.Path = path
+	return doc
+}
+
+func (a *legalArchiver) snapshot() []LegalRecord {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	out := make([]LegalRecord, len(a.records))
+	copy(out, a.records)
+	return out
+}
+
+// sanitizeHost 將 host:port 轉為可作為目錄名稱的字串
+func sanitizeHost(host string) string {
+	return strings.NewReplacer(":", "_", "/", "_", "\\", "_").Replace(host)
+}
@@ -0,0 +1,319 @@
+package crawler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"time"
+
+	"github.com/firehourse/cdpkit/domains"
+	"github.com/firehourse/cdpkit/tab"
+)
+
+// Manifest 一次執行的紀錄：cdpkit 與 Chrome 版本、完整設定與其雜湊、隨機種子、擷取腳本的雜湊與起訖時間，
+// 與結果一併保存，日後以 LoadManifest 讀回並由 Reproduce 取得相同的設定重新執行
+type Manifest struct {
+	JobID string `json:"job_id"`
+	// CdpkitVersion 編譯進執行檔的 cdpkit 模組版本；開發中的版本為 "(devel)" 加上 VCS revision
+	CdpkitVersion string `json:"cdpkit_version"`
+	GoVersion     string `json:"go_version"`
+	// ChromeVersion 瀏覽器的產品版本，例如 "HeadlessChrome/126.0.6478.126"；尚未開啟任何分頁時為空
+	ChromeVersion string `json:"chrome_version,omitempty"`
+	Seed          int64  `json:"seed"`
+	// ConfigHash Options 的 SHA-256，不含 JobID 與 Seed；設定相同的執行有相同的雜湊
+	ConfigHash string `json:"config_hash"`
+	// Extractors 實際使用過的擷取腳本，依首次使用的順序
+	Extractors []Extractor `json:"extractors,omitempty"`
+	// Handlers 處理過頁面的網站專用處理器
+	Handlers []string `json:"handlers,omitempty"`
+	// Omitted 有設定但沒有記錄的選項：函式、已載入的阻擋清單、既有的瀏覽器與帳密，重現時由 Reproduce 的 base 提供
+	Omitted    []string  `json:"omitted,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	// Options 本次執行的設定，Omitted 列出的欄位已清除
+	Options Options `json:"options"`
+}
+
+// Extractor 一個擷取腳本的來源與雜湊
+type Extractor struct {
+	// Name "script"（Fetch、FetchAll 傳入的腳本）、"extract"（Options.Extract 產生的腳本）
+	// 或 "domain:<host>"（網域設定的腳本）
+	Name   string `json:"name"`
+	SHA256 string `json:"sha256"`
+}
+
+// Manifest 回傳目前為止的執行紀錄，FinishedAt 為呼叫的時間；應於 FetchAll 或 Crawl 結束後、Close 之前呼叫
+func (c *Crawler) Manifest() Manifest {
+	opts, omitted := manifestOptions(c.options)
+	m := Manifest{
+		JobID:         c.options.JobID,
+		CdpkitVersion: moduleVersion(),
+		GoVersion:     runtime.Version(),
+		Seed:          c.options.Seed,
+		ConfigHash:    configHash(opts),
+		Omitted:       omitted,
+		FinishedAt:    time.Now(),
+		Options:       opts,
+	}
+	c.mu.Lock()
+	m.ChromeVersion = c.chrome
+	m.Extractors = append([]Extractor(nil), c.extractors...)
+	for name := range c.handlers {
+		m.Handlers = append(m.Handlers, name)
+	}
+	m.StartedAt = c.startedAt
+	c.mu.Unlock()
+	sort.Strings(m.Handlers)
+	return m
+}
+
+// WriteManifest 將 Manifest 以 JSON 寫入 path
+func (c *Crawler) WriteManifest(path string) error {
+	data, err := json.MarshalIndent(c.Manifest(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化執行紀錄失敗: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("寫入執行紀錄 %s 失敗: %w", path, err)
+	}
+	return nil
+}
+
+// LoadManifest 讀取 WriteManifest 寫出的執行紀錄
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("無法讀取執行紀錄 %s: %w", path, err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("解析執行紀錄 %s 失敗: %w", path, err)
+	}
+	return &m, nil
+}
+
+// Reproduce 回傳重現此次執行的 Options：設定與種子取自 Manifest；JobID、Hooks、Transforms、Blocklist、Browser
+// 與 Omitted 列出的帳密取自 base。擷取腳本只記錄雜湊，須傳入相同的腳本，可於結束後以 Compare 確認
+func (m *Manifest) Reproduce(base Options) Options {
+	opts := m.Options
+	opts.Seed = m.Seed
+	opts.JobID = base.JobID
+	opts.Hooks = base.Hooks
+	opts.Transforms = base.Transforms
+	opts.Blocklist = base.Blocklist
+	opts.Browser = base.Browser
+	for _, name := range m.Omitted {
+		switch name {
+		case "ProxyURL":
+			opts.ProxyURL = base.ProxyURL
+		case "Config.Browser.Proxy", "Config.Browser.MergeFn", "Config.Tab.Credentials":
+			if base.Config == nil || opts.Config == nil {
+				continue
+			}
+			layers := *opts.Config
+			switch name {
+			case "Config.Browser.Proxy":
+				layers.Browser.Proxy = base.Config.Browser.Proxy
+			case "Config.Browser.MergeFn":
+				layers.Browser.MergeFn = base.Config.Browser.MergeFn
+			default:
+				layers.Tab.Credentials = base.Config.Tab.Credentials
+			}
+			opts.Config = &layers
+		}
+	}
+	return opts
+}
+
+// Compare 列出 other 與此紀錄在版本、設定、種子與擷取腳本上的差異；重現的執行應沒有差異
+func (m *Manifest) Compare(other Manifest) []string {
+	var diffs []string
+	diff := func(field, a, b string) {
+		if a != b {
+			diffs = append(diffs, fmt.Sprintf("%s: %q -> %q", field, a, b))
+		}
+	}
+	diff("cdpkit_version", m.CdpkitVersion, other.CdpkitVersion)
+	diff("go_version", m.GoVersion, other.GoVersion)
+	if m.ChromeVersion != "" && other.ChromeVersion != "" {
+		diff("chrome_version", m.ChromeVersion, other.ChromeVersion)
+	}
+	diff("config_hash", m.ConfigHash, other.ConfigHash)
+	if m.Seed != other.Seed {
+		diffs = append(diffs, fmt.Sprintf("seed: %d -> %d", m.Seed, other.Seed))
+	}
+
+	hashes := map[string]string{}
+	for _, e := range other.Extractors {
+		hashes[e.Name] = e.SHA256
+	}
+	for _, e := range m.Extractors {
+		if h, ok := hashes[e.Name]; ok {
+			diff("extractor "+e.Name, e.SHA256, h)
+		}
+	}
+	return diffs
+}
+
+// ----------------- 內部實作 -----------------
+
+const modulePath = "github.com/firehourse/cdpkit"
+
+// moduleVersion 由編譯資訊取得 cdpkit 的版本：作為相依套件時為 go.mod 中的版本，
+// 本身即為主模組時為 "(devel)" 加上 VCS revision
+func moduleVersion() string {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	if bi.Main.Path == modulePath {
+		v := bi.Main.Version
+		if v == "" {
+			v = "(devel)"
+		}
+		var rev, dirty string
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				rev = s.Value
+			case "vcs.modified":
+				if s.Value == "true" {
+					dirty = "+dirty"
+				}
+			}
+		}
+		if len(rev) > 12 {
+			rev = rev[:12]
+		}
+		if rev != "" && v == "(devel)" {
+			v += " " + rev + dirty
+		}
+		return v
+	}
+	for _, d := range bi.Deps {
+		if d.Path != modulePath {
+			continue
+		}
+		if d.Replace != nil {
+			return d.Version + " => " + d.Replace.Path + " " + d.Replace.Version
+		}
+		return d.Version
+	}
+	return "unknown"
+}
+
+// manifestOptions 回傳可寫入 Manifest 的設定副本，並列出清除的欄位；代理網址只移除密碼
+func manifestOptions(o Options) (Options, []string) {
+	var omitted []string
+	if o.Hooks != nil {
+		o.Hooks, omitted = nil, append(omitted, "Hooks")
+	}
+	if len(o.Transforms) > 0 {
+		o.Transforms, omitted = nil, append(omitted, "Transforms")
+	}
+	if o.Blocklist != nil {
+		o.Blocklist, omitted = nil, append(omitted, "Blocklist")
+	}
+	if o.Browser != nil {
+		o.Browser, omitted = nil, append(omitted, "Browser")
+	}
+	if redacted, ok := redactURL(o.ProxyURL); ok {
+		o.ProxyURL, omitted = redacted, append(omitted, "ProxyURL")
+	}
+	if o.Config != nil {
+		layers := *o.Config
+		if redacted, ok := redactURL(layers.Browser.Proxy); ok {
+			layers.Browser.Proxy, omitted = redacted, append(omitted, "Config.Browser.Proxy")
+		}
+		if layers.Browser.MergeFn != nil {
+			layers.Browser.MergeFn, omitted = nil, append(omitted, "Config.Browser.MergeFn")
+		}
+		if layers.Tab.Credentials != nil {
+			layers.Tab.Credentials, omitted = nil, append(omitted, "Config.Tab.Credentials")
+		}
+		o.Config = &layers
+	}
+	return o, omitted
+}
+
+// redactURL 將網址中的密碼換成 xxxxx，回傳是否有密碼
+func redactURL(raw string) (string, bool) {
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil {
+		return raw, false
+	}
+	if _, ok := u.User.Password(); !ok {
+		return raw, false
+	}
+	return u.Redacted(), true
+}
+
+// configHash 計算不含 JobID 與 Seed 的設定雜湊；JSON 的 map 鍵已排序，相同設定得到相同結果
+func configHash(o Options) string {
+	o.JobID, o.Seed = "", 0
+	data, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// noteBrowser 記錄瀏覽器版本，只在第一次成功取得時查詢
+func (c *Crawler) noteBrowser(pageTab *tab.Tab) {
+	c.mu.Lock()
+	known := c.chrome != ""
+	c.mu.Unlock()
+	if known {
+		return
+	}
+	version, err := pageTab.BrowserVersion()
+	if err != nil {
+		c.logf(4, "%v", err)
+		return
+	}
+	c.mu.Lock()
+	c.chrome = version
+	c.mu.Unlock()
+}
+
+// noteScript 記錄頁面使用的擷取腳本；相同內容只記錄第一次的來源
+func (c *Crawler) noteScript(js, host string, ov domains.Override) {
+	if js == "" {
+		return
+	}
+	name := "script"
+	switch {
+	case ov.Script != "":
+		name = "domain:" + host
+	case js == c.extractJS:
+		name = "extract"
+	}
+	sum := sha256.Sum256([]byte(js))
+	hash := hex.EncodeToString(sum[:])
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, e := range c.extractors {
+		if e.SHA256 == hash {
+			return
+		}
+	}
+	c.extractors = append(c.extractors, Extractor{Name: name, SHA256: hash})
+}
+
+// noteHandler 記錄處理過頁面的網站專用處理器
+func (c *Crawler) noteHandler(name string) {
+	c.mu.Lock()
+	if c.handlers == nil {
+		c.handlers = map[string]bool{}
+	}
+	c.handlers[name] = true
+	c.mu.Unlock()
+}
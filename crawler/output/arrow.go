@@ -0,0 +1,220 @@
+package output
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/firehourse/cdpkit/crawler"
+)
+
+// 每個 record batch 最多的筆數；字串 offsets 為 int32，分批可避免溢位
+const arrowBatchSize = 65536
+
+var arrowMagic = []byte("ARROW1")
+
+// Arrow flatbuffers 定義中的列舉值
+const (
+	arrowMetadataV5 = 4
+
+	arrowHeaderSchema      = 1
+	arrowHeaderRecordBatch = 3
+
+	arrowTypeInt           = 2
+	arrowTypeFloatingPoint = 3
+	arrowTypeUtf8          = 5
+	arrowTypeBool          = 6
+	arrowTypeTimestamp     = 10
+
+	arrowPrecisionDouble = 2
+	arrowUnitMillisecond = 1
+)
+
+// WriteArrow 以 Arrow IPC 檔案格式（Feather v2）輸出結果。
+// 檔案結構依 Arrow 規格撰寫並由測試檢查，尚未以其他 Arrow 實作的讀取端驗證
+func WriteArrow(w io.Writer, schema Schema, results []crawler.Result) error {
+	cw := &countingWriter{w: w}
+	cw.Write(arrowMagic)
+	cw.Write([]byte{0, 0})
+
+	writeArrowMessage(cw, arrowHeaderSchema, arrowSchema(schema), nil)
+
+	var blocks []byte
+	for start := 0; start < len(results); start += arrowBatchSize {
+		end := start + arrowBatchSize
+		if end > len(results) {
+			end = len(results)
+		}
+		rows := make([][]interface{}, 0, end-start)
+		for _, r := range results[start:end] {
+			rows = append(rows, schema.Row(r))
+		}
+
+		header, body := arrowRecordBatch(schema, rows)
+		offset := cw.n
+		metaLen := writeArrowMessage(cw, arrowHeaderRecordBatch, header, body)
+		blocks = binary.LittleEndian.AppendUint64(blocks, uint64(offset))
+		blocks = binary.LittleEndian.AppendUint32(blocks, uint32(metaLen))
+		blocks = append(blocks, 0, 0, 0, 0)
+		blocks = binary.LittleEndian.AppendUint64(blocks, uint64(len(body)))
+	}
+
+	// end-of-stream 標記
+	cw.Write([]byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0})
+
+	footer := finishFlatbuffer(fbTable{
+		fbScalar(2, arrowMetadataV5),
+		fbRef(arrowSchema(schema)),
+		fbRef(fbStructs{align: 8}),
+		fbRef(fbStructs{align: 8, count: len(blocks) / 24, data: blocks}),
+	})
+	cw.Write(footer)
+	var size [4]byte
+	binary.LittleEndian.PutUint32(size[:], uint32(len(footer)))
+	cw.Write(size[:])
+	cw.Write(arrowMagic)
+	if cw.err != nil {
+		return fmt.Errorf("寫入 Arrow 失敗: %w", cw.err)
+	}
+	return nil
+}
+
+// WriteArrowFile 將結果寫入 Arrow IPC 檔案
+func WriteArrowFile(path string, schema Schema, results []crawler.Result) error {
+	return writeFile(path, func(w io.Writer) error {
+		return WriteArrow(w, schema, results)
+	})
+}
+
+// ----------------- 內部實作 -----------------
+
+// writeArrowMessage 寫入封裝後的訊息（continuation、長度、中繼資料、body），回傳中繼資料區段長度
+func writeArrowMessage(cw *countingWriter, headerType byte, header fbTable, body []byte) int {
+	meta := finishFlatbuffer(fbTable{
+		fbScalar(2, arrowMetadataV5),
+		fbScalar(1, uint64(headerType)),
+		fbRef(header),
+		fbScalar(8, uint64(len(body))),
+	})
+	for (len(meta)+8)%8 != 0 {
+		meta = append(meta, 0)
+	}
+
+	var prefix [8]byte
+	binary.LittleEndian.PutUint32(prefix[0:], 0xffffffff)
+	binary.LittleEndian.PutUint32(prefix[4:], uint32(len(meta)))
+	cw.Write(prefix[:])
+	cw.Write(meta)
+	cw.Write(body)
+	return len(meta) + 8
+}
+
+func arrowSchema(schema Schema) fbTable {
+	fields := make(fbTables, 0, len(schema))
+	for _, c := range schema {
+		typeID, typ := arrowType(c.Type)
+		fields = append(fields, fbTable{
+			fbRef(fbString(c.Name)),
+			fbScalar(1, 1), // nullable
+			fbScalar(1, typeID),
+			fbRef(typ),
+			{},
+			fbRef(fbTables{}),
+		})
+	}
+	return fbTable{
+		fbScalar(2, 0), // little endian
+		fbRef(fields),
+	}
+}
+
+func arrowType(t ColumnType) (uint64, fbTable) {
+	switch t {
+	case TypeInt64:
+		return arrowTypeInt, fbTable{fbScalar(4, 64), fbScalar(1, 1)}
+	case TypeFloat64:
+		return arrowTypeFloatingPoint, fbTable{fbScalar(2, arrowPrecisionDouble)}
+	case TypeBool:
+		return arrowTypeBool, fbTable{}
+	case TypeTimestamp:
+		return arrowTypeTimestamp, fbTable{fbScalar(2, arrowUnitMillisecond), fbRef(fbString("UTC"))}
+	default:
+		return arrowTypeUtf8, fbTable{}
+	}
+}
+
+// arrowRecordBatch 產生 RecordBatch 中繼資料與 body；每個 buffer 皆補齊至 8 位元組
+func arrowRecordBatch(schema Schema, rows [][]interface{}) (fbTable, []byte) {
+	var body, nodes, buffers []byte
+	addBuffer := func(data []byte) {
+		buffers = binary.LittleEndian.AppendUint64(buffers, uint64(len(body)))
+		buffers = binary.LittleEndian.AppendUint64(buffers, uint64(len(data)))
+		body = append(body, data...)
+		for len(body)%8 != 0 {
+			body = append(body, 0)
+		}
+	}
+
+	n := len(rows)
+	for i, c := range schema {
+		validity := make([]byte, (n+7)/8)
+		nulls := 0
+		for r, row := range rows {
+			if row[i] == nil {
+				nulls++
+			} else {
+				validity[r/8] |= 1 << (r % 8)
+			}
+		}
+		nodes = binary.LittleEndian.AppendUint64(nodes, uint64(n))
+		nodes = binary.LittleEndian.AppendUint64(nodes, uint64(nulls))
+		addBuffer(validity)
+
+		switch c.Type {
+		case TypeString:
+			offsets := make([]byte, 0, 4*(n+1))
+			var data []byte
+			offsets = binary.LittleEndian.AppendUint32(offsets, 0)
+			for _, row := range rows {
+				if s, ok := row[i].(string); ok {
+					data = append(data, s...)
+				}
+				offsets = binary.LittleEndian.AppendUint32(offsets, uint32(len(data)))
+			}
+			addBuffer(offsets)
+			addBuffer(data)
+		case TypeBool:
+			values := make([]byte, (n+7)/8)
+			for r, row := range rows {
+				if b, _ := row[i].(bool); b {
+					values[r/8] |= 1 << (r % 8)
+				}
+			}
+			addBuffer(values)
+		default:
+			values := make([]byte, 0, 8*n)
+			for _, row := range rows {
+				var v uint64
+				switch x := row[i].(type) {
+				case int64:
+					v = uint64(x)
+				case float64:
+					v = math.Float64bits(x)
+				case time.Time:
+					v = uint64(x.UnixMilli())
+				}
+				values = binary.LittleEndian.AppendUint64(values, v)
+			}
+			addBuffer(values)
+		}
+	}
+
+	header := fbTable{
+		fbScalar(8, uint64(n)),
+		fbRef(fbStructs{align: 8, count: len(schema), data: nodes}),
+		fbRef(fbStructs{align: 8, count: len(buffers) / 16, data: buffers}),
+	}
+	return header, body
+}
@@ -0,0 +1,194 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/firehourse/cdpkit/crawler"
+)
+
+// Uploader 物件儲存的上傳後端，見 NewS3Uploader、NewGCSUploader
+type Uploader interface {
+	Upload(ctx context.Context, key, contentType string, body []byte) error
+}
+
+// BucketOptions BucketSink 的設定；零值欄位使用預設值
+type BucketOptions struct {
+	// Prefix 物件名稱前綴，例如 "crawls/2024-06-01/"
+	Prefix string
+	// BatchSize 每個 JSON Lines 物件的筆數，預設 1000
+	BatchSize int
+	// SeparateHTML 為 true 時 HTML 另存為 {Prefix}html/ 下的獨立物件，結果中以 html_object 記錄物件名稱
+	SeparateHTML bool
+	// Screenshots 為 true 時上傳 Result.Screenshot 至 {Prefix}screenshots/，結果中以 screenshot_object 記錄
+	Screenshots bool
+	// HAR 為 true 時上傳抽樣頁面的 Result.HAR 至 {Prefix}har/，結果中以 har_object 記錄
+	HAR bool
+	// MaxAttempts 上傳失敗（網路錯誤、429、5xx）時最多嘗試的次數，預設 3
+	MaxAttempts int
+	// Backoff 第一次重試前的等待時間，之後每次加倍，預設 1 秒
+	Backoff time.Duration
+	// Timeout 單次上傳的逾時，預設 60 秒
+	Timeout time.Duration
+}
+
+// BucketSink 將結果批次寫成 JSON Lines 物件上傳到物件儲存，
+// 物件名稱為 {Prefix}results-{開始時間}-{序號}.jsonl。Write 在批次滿時同步上傳
+type BucketSink struct {
+	up      Uploader
+	opts    BucketOptions
+	started string
+	seq     int
+	buf     bytes.Buffer
+	n       int
+}
+
+// NewBucketSink 以指定的 Uploader 建立 BucketSink
+func NewBucketSink(up Uploader, opts BucketOptions) *BucketSink {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 1000
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 3
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = time.Second
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 60 * time.Second
+	}
+	return &BucketSink{up: up, opts: opts, started: time.Now().UTC().Format("20060102T150405Z")}
+}
+
+func (s *BucketSink) Write(r crawler.Result) error {
+	rec := bucketRecord{Result: r, HTML: r.HTML}
+	name := objectName(r)
+	if s.opts.SeparateHTML && r.HTML != "" {
+		key := s.opts.Prefix + "html/" + name + ".html"
+		if err := s.upload(key, "text/html; charset=utf-8", []byte(r.HTML)); err != nil {
+			return err
+		}
+		rec.HTML, rec.HTMLObject = "", key
+	}
+	if s.opts.Screenshots && len(r.Screenshot) > 0 {
+		key := s.opts.Prefix + "screenshots/" + name + ".png"
+		if err := s.upload(key, "image/png", r.Screenshot); err != nil {
+			return err
+		}
+		rec.ScreenshotObject = key
+	}
+	if s.opts.HAR && len(r.HAR) > 0 {
+		key := s.opts.Prefix + "har/" + name + ".har"
+		if err := s.upload(key, "application/json", r.HAR); err != nil {
+			return err
+		}
+		rec.HARObject = key
+	}
+	if err := json.NewEncoder(&s.buf).Encode(rec); err != nil {
+		return fmt.Errorf("序列化結果失敗: %w", err)
+	}
+	s.n++
+	if s.n >= s.opts.BatchSize {
+		return s.flush()
+	}
+	return nil
+}
+
+// Close 上傳剩餘未滿一批的結果
+func (s *BucketSink) Close() error {
+	if s.n == 0 {
+		return nil
+	}
+	return s.flush()
+}
+
+// ----------------- 內部實作 -----------------
+
+// bucketRecord 上傳的每筆結果；HTML 另存時清空 html 欄位，改記錄物件名稱
+type bucketRecord struct {
+	crawler.Result
+	HTML             string `json:"html,omitempty"`
+	HTMLObject       string `json:"html_object,omitempty"`
+	ScreenshotObject string `json:"screenshot_object,omitempty"`
+	HARObject        string `json:"har_object,omitempty"`
+}
+
+// flush 上傳目前的批次；失敗時保留批次，下次 flush 一併上傳
+func (s *BucketSink) flush() error {
+	key := fmt.Sprintf("%sresults-%s-%06d.jsonl", s.opts.Prefix, s.started, s.seq+1)
+	if err := s.upload(key, "application/x-ndjson", s.buf.Bytes()); err != nil {
+		return fmt.Errorf("%w（%d 筆結果待上傳）", err, s.n)
+	}
+	s.seq++
+	s.buf.Reset()
+	s.n = 0
+	return nil
+}
+
+func (s *BucketSink) upload(key, contentType string, body []byte) error {
+	return withRetry(s.opts.MaxAttempts, s.opts.Backoff, func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), s.opts.Timeout)
+		defer cancel()
+		if err := s.up.Upload(ctx, key, contentType, body); err != nil {
+			return fmt.Errorf("上傳 %s 失敗: %w", key, err)
+		}
+		return nil
+	})
+}
+
+// objectName 以網址雜湊與爬取時間命名 HTML、截圖與 HAR 物件
+func objectName(r crawler.Result) string {
+	sum := sha256.Sum256([]byte(r.URL))
+	return fmt.Sprintf("%x-%d", sum[:8], r.Timestamp.UnixNano())
+}
+
+// statusError 非 2xx 回應
+type statusError struct {
+	code int
+	body string
+}
+
+func (e *statusError) Error() string {
+	if e.body == "" {
+		return fmt.Sprintf("狀態碼 %d", e.code)
+	}
+	return fmt.Sprintf("狀態碼 %d: %s", e.code, e.body)
+}
+
+// checkResponse 讀完並關閉回應，非 2xx 時回傳 statusError（含回應開頭供除錯）
+func checkResponse(resp *http.Response) error {
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+	return &statusError{code: resp.StatusCode, body: strings.TrimSpace(string(body))}
+}
+
+// withRetry 執行 fn，網路錯誤、408、429 與 5xx 時以指數退避重試
+func withRetry(attempts int, backoff time.Duration, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= attempts || !retryable(err) {
+			return err
+		}
+		time.Sleep(backoff << (attempt - 1))
+	}
+}
+
+func retryable(err error) bool {
+	var se *statusError
+	if errors.As(err, &se) {
+		return se.code == http.StatusRequestTimeout || se.code == http.StatusTooManyRequests || se.code >= 500
+	}
+	return !errors.Is(err, context.Canceled)
+}
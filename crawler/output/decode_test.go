@@ -0,0 +1,640 @@
+package output
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// 依 Parquet、Arrow 與 thrift、flatbuffers 規格獨立實作的解碼器，只用於測試：
+// 不共用寫入端的任何程式碼，用以確認輸出的檔案結構符合規格，而非只是與寫入端的假設一致
+
+// ----------------- thrift compact protocol -----------------
+
+// tStruct 以欄位 id 為鍵；值為 int64、float64、bool、string、[]interface{} 或 tStruct
+type tStruct map[int16]interface{}
+
+type thriftReader struct {
+	buf []byte
+	pos int
+	err error
+}
+
+func (r *thriftReader) byte() byte {
+	if r.pos >= len(r.buf) {
+		r.fail("讀取超出範圍")
+		return 0
+	}
+	b := r.buf[r.pos]
+	r.pos++
+	return b
+}
+
+func (r *thriftReader) fail(format string, args ...interface{}) {
+	if r.err == nil {
+		r.err = fmt.Errorf("thrift 位置 %d: %s", r.pos, fmt.Sprintf(format, args...))
+	}
+	r.pos = len(r.buf)
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.buf[min(r.pos, len(r.buf)):])
+	if n <= 0 {
+		r.fail("無效的 varint")
+		return 0
+	}
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) zigzag() int64 {
+	v := r.uvarint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *thriftReader) value(typ byte) interface{} {
+	switch typ {
+	case 1:
+		return true
+	case 2:
+		return false
+	case 3:
+		return int64(int8(r.byte()))
+	case 4, 5, 6:
+		return r.zigzag()
+	case 7:
+		if r.pos+8 > len(r.buf) {
+			r.fail("double 超出範圍")
+			return 0.0
+		}
+		v := math.Float64frombits(binary.LittleEndian.Uint64(r.buf[r.pos:]))
+		r.pos += 8
+		return v
+	case 8:
+		n := int(r.uvarint())
+		if r.pos+n > len(r.buf) {
+			r.fail("binary 超出範圍")
+			return ""
+		}
+		s := string(r.buf[r.pos : r.pos+n])
+		r.pos += n
+		return s
+	case 9, 10:
+		h := r.byte()
+		size, elem := int(h>>4), h&0x0f
+		if size == 15 {
+			size = int(r.uvarint())
+		}
+		list := make([]interface{}, 0, size)
+		for i := 0; i < size && r.err == nil; i++ {
+			if elem == 1 || elem == 2 {
+				// list 中的布林值各占一個位元組
+				list = append(list, r.byte() == 1)
+				continue
+			}
+			list = append(list, r.value(elem))
+		}
+		return list
+	case 12:
+		return r.readStruct()
+	}
+	r.fail("不支援的型別 %d", typ)
+	return nil
+}
+
+func (r *thriftReader) readStruct() tStruct {
+	s := tStruct{}
+	var last int16
+	for r.err == nil {
+		h := r.byte()
+		if h == 0 {
+			return s
+		}
+		typ := h & 0x0f
+		id := last + int16(h>>4)
+		if h>>4 == 0 {
+			id = int16(r.zigzag())
+		}
+		s[id] = r.value(typ)
+		last = id
+	}
+	return s
+}
+
+func (s tStruct) int(id int16) int64 {
+	v, _ := s[id].(int64)
+	return v
+}
+
+func (s tStruct) str(id int16) string {
+	v, _ := s[id].(string)
+	return v
+}
+
+func (s tStruct) sub(id int16) tStruct {
+	v, _ := s[id].(tStruct)
+	return v
+}
+
+func (s tStruct) list(id int16) []interface{} {
+	v, _ := s[id].([]interface{})
+	return v
+}
+
+// ----------------- Parquet -----------------
+
+// parquetFile 解碼後的 Parquet 檔案
+type parquetFile struct {
+	meta    tStruct
+	columns []parquetColumn
+	// rows 各 row group 依序串接的資料列，NULL 為 nil；INT64 為 int64、DOUBLE 為 float64
+	rows [][]interface{}
+}
+
+type parquetColumn struct {
+	name      string
+	physical  int64
+	converted int64
+	logical   tStruct
+}
+
+// decodeParquet 讀取 PLAIN 編碼、未壓縮、只有 OPTIONAL 平坦欄位的 Parquet 檔案
+func decodeParquet(data []byte) (*parquetFile, error) {
+	if len(data) < 12 || !bytes.Equal(data[:4], []byte("PAR1")) || !bytes.Equal(data[len(data)-4:], []byte("PAR1")) {
+		return nil, fmt.Errorf("缺少 PAR1")
+	}
+	metaLen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	if metaLen <= 0 || metaLen > len(data)-12 {
+		return nil, fmt.Errorf("檔尾長度 %d 無效", metaLen)
+	}
+	r := &thriftReader{buf: data[len(data)-8-metaLen : len(data)-8]}
+	f := &parquetFile{meta: r.readStruct()}
+	if r.err != nil {
+		return nil, r.err
+	}
+	if r.pos != metaLen {
+		return nil, fmt.Errorf("FileMetaData 長度 %d，檔尾記錄 %d", r.pos, metaLen)
+	}
+
+	schema := f.meta.list(2)
+	if len(schema) == 0 {
+		return nil, fmt.Errorf("沒有 schema")
+	}
+	root := schema[0].(tStruct)
+	if int(root.int(5)) != len(schema)-1 {
+		return nil, fmt.Errorf("根節點 num_children %d，實際 %d 個欄位", root.int(5), len(schema)-1)
+	}
+	for _, e := range schema[1:] {
+		el := e.(tStruct)
+		if el.int(3) != 1 {
+			return nil, fmt.Errorf("欄位 %s 的 repetition_type 為 %d，應為 OPTIONAL", el.str(4), el.int(3))
+		}
+		c := parquetColumn{name: el.str(4), physical: el.int(1), converted: -1, logical: el.sub(10)}
+		if _, ok := el[6]; ok {
+			c.converted = el.int(6)
+		}
+		f.columns = append(f.columns, c)
+	}
+
+	var total int64
+	for _, g := range f.meta.list(4) {
+		group := g.(tStruct)
+		chunks := group.list(1)
+		if len(chunks) != len(f.columns) {
+			return nil, fmt.Errorf("row group 有 %d 個 column chunk，應為 %d", len(chunks), len(f.columns))
+		}
+		n := int(group.int(3))
+		rows := make([][]interface{}, n)
+		for i := range rows {
+			rows[i] = make([]interface{}, len(f.columns))
+		}
+		var size int64
+		for i, ch := range chunks {
+			cm := ch.(tStruct).sub(3)
+			if cm.int(1) != f.columns[i].physical || cm.int(4) != 0 || cm.int(5) != int64(n) {
+				return nil, fmt.Errorf("欄位 %s 的 ColumnMetaData 不符: %v", f.columns[i].name, cm)
+			}
+			if path := cm.list(3); len(path) != 1 || path[0] != f.columns[i].name {
+				return nil, fmt.Errorf("欄位 %s 的 path_in_schema 為 %v", f.columns[i].name, path)
+			}
+			values, used, err := decodeParquetPage(data, int(cm.int(9)), n, f.columns[i].physical)
+			if err != nil {
+				return nil, fmt.Errorf("欄位 %s: %w", f.columns[i].name, err)
+			}
+			if int64(used) != cm.int(6) || int64(used) != cm.int(7) {
+				return nil, fmt.Errorf("欄位 %s 實際 %d 位元組，ColumnMetaData 記錄 %d/%d", f.columns[i].name, used, cm.int(6), cm.int(7))
+			}
+			size += int64(used)
+			for r, v := range values {
+				rows[r][i] = v
+			}
+		}
+		if size != group.int(2) {
+			return nil, fmt.Errorf("row group total_byte_size %d，實際 %d", group.int(2), size)
+		}
+		f.rows = append(f.rows, rows...)
+		total += int64(n)
+	}
+	if total != f.meta.int(3) {
+		return nil, fmt.Errorf("num_rows %d，各 row group 合計 %d", f.meta.int(3), total)
+	}
+	return f, nil
+}
+
+// decodeParquetPage 解碼 offset 處的 data page，回傳各列的值與 page（含標頭）的大小
+func decodeParquetPage(data []byte, offset, rows int, physical int64) ([]interface{}, int, error) {
+	if offset < 4 || offset >= len(data) {
+		return nil, 0, fmt.Errorf("data_page_offset %d 無效", offset)
+	}
+	r := &thriftReader{buf: data[offset:]}
+	header := r.readStruct()
+	if r.err != nil {
+		return nil, 0, r.err
+	}
+	dp := header.sub(5)
+	if header.int(1) != 0 || int(dp.int(1)) != rows || dp.int(2) != 0 || dp.int(3) != 3 {
+		return nil, 0, fmt.Errorf("page header 不符: %v", header)
+	}
+	size := int(header.int(3))
+	if header.int(2) != int64(size) || r.pos+size > len(r.buf) {
+		return nil, 0, fmt.Errorf("page 大小 %d/%d 無效", header.int(2), size)
+	}
+	page := r.buf[r.pos : r.pos+size]
+
+	levelsLen := int(binary.LittleEndian.Uint32(page))
+	if 4+levelsLen > len(page) {
+		return nil, 0, fmt.Errorf("definition levels 長度 %d 無效", levelsLen)
+	}
+	levels, err := decodeHybrid(page[4:4+levelsLen], rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	buf := page[4+levelsLen:]
+	out := make([]interface{}, rows)
+	var bit int
+	for i, lvl := range levels {
+		if lvl == 0 {
+			continue
+		}
+		switch physical {
+		case 0: // BOOLEAN
+			if bit/8 >= len(buf) {
+				return nil, 0, fmt.Errorf("BOOLEAN 值不足")
+			}
+			out[i] = buf[bit/8]&(1<<(bit%8)) != 0
+			bit++
+		case 2, 5: // INT64、DOUBLE
+			if len(buf) < 8 {
+				return nil, 0, fmt.Errorf("第 %d 列的值不足 8 位元組", i)
+			}
+			v := binary.LittleEndian.Uint64(buf)
+			if physical == 2 {
+				out[i] = int64(v)
+			} else {
+				out[i] = math.Float64frombits(v)
+			}
+			buf = buf[8:]
+		case 6: // BYTE_ARRAY
+			if len(buf) < 4 || 4+int(binary.LittleEndian.Uint32(buf)) > len(buf) {
+				return nil, 0, fmt.Errorf("第 %d 列的 BYTE_ARRAY 長度無效", i)
+			}
+			n := int(binary.LittleEndian.Uint32(buf))
+			out[i] = string(buf[4 : 4+n])
+			buf = buf[4+n:]
+		default:
+			return nil, 0, fmt.Errorf("不支援的物理型別 %d", physical)
+		}
+	}
+	if physical == 0 {
+		buf = buf[min((bit+7)/8, len(buf)):]
+	}
+	if len(buf) != 0 {
+		return nil, 0, fmt.Errorf("page 尾端多出 %d 位元組", len(buf))
+	}
+	return out, r.pos + size, nil
+}
+
+// decodeHybrid 解碼 bit width 為 1 的 RLE/bit-packing hybrid
+func decodeHybrid(data []byte, n int) ([]byte, error) {
+	out := make([]byte, 0, n)
+	for len(data) > 0 {
+		h, k := binary.Uvarint(data)
+		if k <= 0 {
+			return nil, fmt.Errorf("無效的 run 標頭")
+		}
+		data = data[k:]
+		if h&1 == 1 {
+			groups := int(h >> 1)
+			if len(data) < groups {
+				return nil, fmt.Errorf("bit-packed run 不足")
+			}
+			for i := 0; i < groups*8; i++ {
+				out = append(out, data[i/8]>>(i%8)&1)
+			}
+			data = data[groups:]
+			continue
+		}
+		if len(data) < 1 || data[0] > 1 {
+			return nil, fmt.Errorf("無效的 RLE 值")
+		}
+		for i := uint64(0); i < h>>1; i++ {
+			out = append(out, data[0])
+		}
+		data = data[1:]
+	}
+	if len(out) < n {
+		return nil, fmt.Errorf("definition levels 只有 %d 個，應為 %d", len(out), n)
+	}
+	return out[:n], nil
+}
+
+// ----------------- flatbuffers -----------------
+
+type fbReader struct {
+	buf []byte
+}
+
+// root 回傳根表格的位置
+func (r fbReader) root() int {
+	return int(binary.LittleEndian.Uint32(r.buf))
+}
+
+// field 回傳表格欄位的絕對位置；欄位缺省時為 0
+func (r fbReader) field(table, id int) int {
+	vt := table - int(int32(binary.LittleEndian.Uint32(r.buf[table:])))
+	vtLen := int(binary.LittleEndian.Uint16(r.buf[vt:]))
+	if 4+2*id >= vtLen {
+		return 0
+	}
+	off := int(binary.LittleEndian.Uint16(r.buf[vt+4+2*id:]))
+	if off == 0 {
+		return 0
+	}
+	return table + off
+}
+
+func (r fbReader) u8(table, id int) uint64 {
+	if p := r.field(table, id); p != 0 {
+		return uint64(r.buf[p])
+	}
+	return 0
+}
+
+func (r fbReader) u16(table, id int) uint64 {
+	if p := r.field(table, id); p != 0 {
+		return uint64(binary.LittleEndian.Uint16(r.buf[p:]))
+	}
+	return 0
+}
+
+func (r fbReader) u32(table, id int) uint64 {
+	if p := r.field(table, id); p != 0 {
+		return uint64(binary.LittleEndian.Uint32(r.buf[p:]))
+	}
+	return 0
+}
+
+func (r fbReader) u64(table, id int) uint64 {
+	if p := r.field(table, id); p != 0 {
+		return binary.LittleEndian.Uint64(r.buf[p:])
+	}
+	return 0
+}
+
+// ref 回傳欄位參照的物件位置；欄位缺省時為 0
+func (r fbReader) ref(table, id int) int {
+	p := r.field(table, id)
+	if p == 0 {
+		return 0
+	}
+	return p + int(binary.LittleEndian.Uint32(r.buf[p:]))
+}
+
+func (r fbReader) str(table, id int) string {
+	p := r.ref(table, id)
+	if p == 0 {
+		return ""
+	}
+	n := int(binary.LittleEndian.Uint32(r.buf[p:]))
+	return string(r.buf[p+4 : p+4+n])
+}
+
+// vector 回傳向量的長度與第一個元素的位置
+func (r fbReader) vector(table, id int) (int, int) {
+	p := r.ref(table, id)
+	if p == 0 {
+		return 0, 0
+	}
+	return int(binary.LittleEndian.Uint32(r.buf[p:])), p + 4
+}
+
+// tables 回傳表格向量中各表格的位置
+func (r fbReader) tables(table, id int) []int {
+	n, p := r.vector(table, id)
+	out := make([]int, n)
+	for i := range out {
+		at := p + 4*i
+		out[i] = at + int(binary.LittleEndian.Uint32(r.buf[at:]))
+	}
+	return out
+}
+
+// ----------------- Arrow IPC -----------------
+
+// arrowFile 解碼後的 Arrow IPC 檔案
+type arrowFile struct {
+	fields []arrowField
+	// rows 各 record batch 依序串接的資料列，NULL 為 nil；Timestamp 為毫秒 int64
+	rows [][]interface{}
+}
+
+type arrowField struct {
+	name     string
+	nullable bool
+	typeID   uint64
+	// bitWidth、signed 為 Int；precision 為 FloatingPoint；unit、timezone 為 Timestamp
+	bitWidth  uint64
+	signed    bool
+	precision uint64
+	unit      uint64
+	timezone  string
+}
+
+// decodeArrow 讀取 Arrow IPC 檔案格式，只支援平坦的 Int64、Double、Utf8、Bool、Timestamp 欄位
+func decodeArrow(data []byte) (*arrowFile, error) {
+	if len(data) < 18 || !bytes.Equal(data[:8], []byte("ARROW1\x00\x00")) || !bytes.Equal(data[len(data)-6:], []byte("ARROW1")) {
+		return nil, fmt.Errorf("缺少 ARROW1")
+	}
+	footerLen := int(binary.LittleEndian.Uint32(data[len(data)-10:]))
+	if footerLen <= 0 || footerLen > len(data)-18 {
+		return nil, fmt.Errorf("檔尾長度 %d 無效", footerLen)
+	}
+	footer := fbReader{buf: data[len(data)-10-footerLen : len(data)-10]}
+	root := footer.root()
+	if v := footer.u16(root, 0); v != 4 {
+		return nil, fmt.Errorf("footer 版本 %d，應為 V5", v)
+	}
+	f := &arrowFile{fields: decodeArrowSchema(footer, footer.ref(root, 1))}
+
+	// 檔案開頭的串流必須先是與檔尾相同的 schema
+	msg, _, err := arrowMessage(data, 8)
+	if err != nil {
+		return nil, err
+	}
+	if msg.u8(msg.root(), 1) != 1 {
+		return nil, fmt.Errorf("第一個訊息不是 Schema")
+	}
+	if got := decodeArrowSchema(msg, msg.ref(msg.root(), 2)); fmt.Sprint(got) != fmt.Sprint(f.fields) {
+		return nil, fmt.Errorf("串流的 schema %v 與檔尾 %v 不同", got, f.fields)
+	}
+
+	n, blocks := footer.vector(root, 3)
+	for i := 0; i < n; i++ {
+		b := footer.buf[blocks+24*i:]
+		offset := int(binary.LittleEndian.Uint64(b))
+		metaLen := int(binary.LittleEndian.Uint32(b[8:]))
+		bodyLen := int(binary.LittleEndian.Uint64(b[16:]))
+		if offset%8 != 0 || metaLen%8 != 0 {
+			return nil, fmt.Errorf("record batch %d 未對齊 8 位元組", i)
+		}
+		msg, used, err := arrowMessage(data, offset)
+		if err != nil {
+			return nil, err
+		}
+		if used != metaLen {
+			return nil, fmt.Errorf("record batch %d 中繼資料 %d 位元組，檔尾記錄 %d", i, used, metaLen)
+		}
+		mr := msg.root()
+		if msg.u8(mr, 1) != 3 || int(msg.u64(mr, 3)) != bodyLen {
+			return nil, fmt.Errorf("record batch %d 的訊息標頭不符", i)
+		}
+		if offset+metaLen+bodyLen > len(data) {
+			return nil, fmt.Errorf("record batch %d 超出檔案範圍", i)
+		}
+		rows, err := decodeArrowBatch(msg, msg.ref(mr, 2), f.fields, data[offset+metaLen:offset+metaLen+bodyLen])
+		if err != nil {
+			return nil, fmt.Errorf("record batch %d: %w", i, err)
+		}
+		f.rows = append(f.rows, rows...)
+	}
+	return f, nil
+}
+
+// arrowMessage 讀取 offset 處封裝的訊息，回傳訊息與含前綴的中繼資料長度
+func arrowMessage(data []byte, offset int) (fbReader, int, error) {
+	if offset+8 > len(data) || binary.LittleEndian.Uint32(data[offset:]) != 0xffffffff {
+		return fbReader{}, 0, fmt.Errorf("位置 %d 沒有 continuation 標記", offset)
+	}
+	n := int(binary.LittleEndian.Uint32(data[offset+4:]))
+	if offset+8+n > len(data) {
+		return fbReader{}, 0, fmt.Errorf("位置 %d 的訊息長度 %d 無效", offset, n)
+	}
+	msg := fbReader{buf: data[offset+8 : offset+8+n]}
+	if v := msg.u16(msg.root(), 0); v != 4 {
+		return fbReader{}, 0, fmt.Errorf("訊息版本 %d，應為 V5", v)
+	}
+	return msg, n + 8, nil
+}
+
+func decodeArrowSchema(r fbReader, schema int) []arrowField {
+	var out []arrowField
+	for _, field := range r.tables(schema, 1) {
+		f := arrowField{
+			name:     r.str(field, 0),
+			nullable: r.u8(field, 1) == 1,
+			typeID:   r.u8(field, 2),
+		}
+		typ := r.ref(field, 3)
+		switch f.typeID {
+		case 2:
+			f.bitWidth, f.signed = r.u32(typ, 0), r.u8(typ, 1) == 1
+		case 3:
+			f.precision = r.u16(typ, 0)
+		case 10:
+			f.unit, f.timezone = r.u16(typ, 0), r.str(typ, 1)
+		}
+		out = append(out, f)
+	}
+	return out
+}
+
+func decodeArrowBatch(r fbReader, batch int, fields []arrowField, body []byte) ([][]interface{}, error) {
+	n := int(r.u64(batch, 0))
+	nodeCount, nodes := r.vector(batch, 1)
+	bufCount, bufs := r.vector(batch, 2)
+	if nodeCount != len(fields) {
+		return nil, fmt.Errorf("%d 個 field node，應為 %d", nodeCount, len(fields))
+	}
+	buffer := func(i int) ([]byte, error) {
+		if i >= bufCount {
+			return nil, fmt.Errorf("buffer 不足")
+		}
+		b := r.buf[bufs+16*i:]
+		off, size := int(binary.LittleEndian.Uint64(b)), int(binary.LittleEndian.Uint64(b[8:]))
+		if off%8 != 0 || off+size > len(body) {
+			return nil, fmt.Errorf("buffer %d（%d+%d）無效", i, off, size)
+		}
+		return body[off : off+size], nil
+	}
+
+	rows := make([][]interface{}, n)
+	for i := range rows {
+		rows[i] = make([]interface{}, len(fields))
+	}
+	next := 0
+	for c, f := range fields {
+		node := r.buf[nodes+16*c:]
+		if int(binary.LittleEndian.Uint64(node)) != n {
+			return nil, fmt.Errorf("欄位 %s 的長度不是 %d", f.name, n)
+		}
+		validity, err := buffer(next)
+		if err != nil {
+			return nil, err
+		}
+		values, err := buffer(next + 1)
+		if err != nil {
+			return nil, err
+		}
+		next += 2
+		var strData []byte
+		if f.typeID == 5 {
+			if strData, err = buffer(next); err != nil {
+				return nil, err
+			}
+			next++
+		}
+
+		nulls := 0
+		for i := 0; i < n; i++ {
+			if len(validity) > 0 && validity[i/8]&(1<<(i%8)) == 0 {
+				nulls++
+				continue
+			}
+			switch f.typeID {
+			case 2, 10:
+				rows[i][c] = int64(binary.LittleEndian.Uint64(values[8*i:]))
+			case 3:
+				rows[i][c] = math.Float64frombits(binary.LittleEndian.Uint64(values[8*i:]))
+			case 6:
+				rows[i][c] = values[i/8]&(1<<(i%8)) != 0
+			case 5:
+				start, end := binary.LittleEndian.Uint32(values[4*i:]), binary.LittleEndian.Uint32(values[4*i+4:])
+				if start > end || int(end) > len(strData) {
+					return nil, fmt.Errorf("欄位 %s 第 %d 列的 offsets 無效", f.name, i)
+				}
+				rows[i][c] = string(strData[start:end])
+			default:
+				return nil, fmt.Errorf("不支援的型別 %d", f.typeID)
+			}
+		}
+		if got := int(binary.LittleEndian.Uint64(node[8:])); got != nulls {
+			return nil, fmt.Errorf("欄位 %s 的 null_count %d，實際 %d", f.name, got, nulls)
+		}
+	}
+	if next != bufCount {
+		return nil, fmt.Errorf("有 %d 個 buffer，只用了 %d 個", bufCount, next)
+	}
+	return rows, nil
+}
@@ -0,0 +1,143 @@
+package output
+
+import "encoding/binary"
+
+// 只實作 Arrow IPC 中繼資料需要的 flatbuffers 子集。
+// 物件由前往後寫入：父物件在前、子物件在後，因此所有 uoffset 皆為正值。
+
+// fbObject 可被 uoffset 參照的 flatbuffer 物件
+type fbObject interface {
+	writeTo(b *fbBuilder) int
+}
+
+// fbField 表格欄位；size 為 0 且 ref 為 nil 表示缺省
+type fbField struct {
+	size   int
+	scalar uint64
+	ref    fbObject
+}
+
+func fbScalar(size int, v uint64) fbField { return fbField{size: size, scalar: v} }
+func fbRef(obj fbObject) fbField          { return fbField{size: 4, ref: obj} }
+
+// fbTable 依欄位 id 排列的表格
+type fbTable []fbField
+
+// fbString 字串
+type fbString string
+
+// fbTables 表格向量
+type fbTables []fbTable
+
+// fbStructs 固定大小 struct 的向量；data 為已依 little-endian 排好的內容
+type fbStructs struct {
+	align int
+	count int
+	data  []byte
+}
+
+type fbBuilder struct {
+	buf []byte
+}
+
+// finishFlatbuffer 序列化 root 表格
+func finishFlatbuffer(root fbTable) []byte {
+	b := &fbBuilder{buf: make([]byte, 4)}
+	pos := root.writeTo(b)
+	binary.LittleEndian.PutUint32(b.buf[0:], uint32(pos))
+	return b.buf
+}
+
+func (b *fbBuilder) pad(align int) {
+	for len(b.buf)%align != 0 {
+		b.buf = append(b.buf, 0)
+	}
+}
+
+// patch 將位於 at 的 uoffset 指向 target
+func (b *fbBuilder) patch(at, target int) {
+	binary.LittleEndian.PutUint32(b.buf[at:], uint32(target-at))
+}
+
+func (t fbTable) writeTo(b *fbBuilder) int {
+	// 欄位在表格內的位置；表格起點對齊 8，因此相對對齊即絕對對齊
+	offsets := make([]int, len(t))
+	size := 4
+	for i, f := range t {
+		if f.size == 0 {
+			continue
+		}
+		for size%f.size != 0 {
+			size++
+		}
+		offsets[i] = size
+		size += f.size
+	}
+
+	b.pad(2)
+	vtable := len(b.buf)
+	b.buf = binary.LittleEndian.AppendUint16(b.buf, uint16(4+2*len(t)))
+	b.buf = binary.LittleEndian.AppendUint16(b.buf, uint16(size))
+	for _, off := range offsets {
+		b.buf = binary.LittleEndian.AppendUint16(b.buf, uint16(off))
+	}
+
+	b.pad(8)
+	table := len(b.buf)
+	b.buf = append(b.buf, make([]byte, size)...)
+	binary.LittleEndian.PutUint32(b.buf[table:], uint32(int32(table-vtable)))
+	for i, f := range t {
+		if f.size == 0 || f.ref != nil {
+			continue
+		}
+		at := b.buf[table+offsets[i]:]
+		switch f.size {
+		case 1:
+			at[0] = byte(f.scalar)
+		case 2:
+			binary.LittleEndian.PutUint16(at, uint16(f.scalar))
+		case 4:
+			binary.LittleEndian.PutUint32(at, uint32(f.scalar))
+		case 8:
+			binary.LittleEndian.PutUint64(at, f.scalar)
+		}
+	}
+	for i, f := range t {
+		if f.ref != nil {
+			b.patch(table+offsets[i], f.ref.writeTo(b))
+		}
+	}
+	return table
+}
+
+func (s fbString) writeTo(b *fbBuilder) int {
+	b.pad(4)
+	pos := len(b.buf)
+	b.buf = binary.LittleEndian.AppendUint32(b.buf, uint32(len(s)))
+	b.buf = append(b.buf, s...)
+	b.buf = append(b.buf, 0)
+	return pos
+}
+
+func (v fbTables) writeTo(b *fbBuilder) int {
+	b.pad(4)
+	pos := len(b.buf)
+	b.buf = binary.LittleEndian.AppendUint32(b.buf, uint32(len(v)))
+	b.buf = append(b.buf, make([]byte, 4*len(v))...)
+	for i, t := range v {
+		b.patch(pos+4+4*i, t.writeTo(b))
+	}
+	return pos
+}
+
+func (v fbStructs) writeTo(b *fbBuilder) int {
+	// 長度欄位之後的元素需對齊 struct 的對齊要求
+	b.pad(4)
+	for (len(b.buf)+4)%v.align != 0 {
+		b.buf = append(b.buf, 0)
+	}
+	pos := len(b.buf)
+	b.buf = binary.LittleEndian.AppendUint32(b.buf, uint32(v.count))
+	b.buf = append(b.buf, v.data...)
+	return pos
+}
@@ -0,0 +1,125 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// GCSConfig Google Cloud Storage 的連線設定；HMAC 金鑰、Token 與 metadata server 三選一，依序採用
+type GCSConfig struct {
+	Bucket string
+	// AccessKey、SecretKey HMAC 金鑰，設定時經 XML API 以 S3 相容的簽章上傳
+	AccessKey string
+	SecretKey string
+	// Token OAuth 2.0 存取權杖，例如 gcloud auth print-access-token 的輸出；
+	// 未設定金鑰與權杖時向 GCE/GKE 的 metadata server 取得服務帳戶的權杖
+	Token string
+	// Client 送出請求的 HTTP 用戶端，nil 時使用 http.DefaultClient
+	Client *http.Client
+}
+
+// NewGCSUploader 建立上傳到 GCS 的 Uploader
+func NewGCSUploader(cfg GCSConfig) (Uploader, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("未指定 GCS bucket")
+	}
+	if cfg.AccessKey != "" || cfg.SecretKey != "" {
+		return NewS3Uploader(S3Config{
+			Bucket:    cfg.Bucket,
+			Region:    "auto",
+			Endpoint:  "https://storage.googleapis.com",
+			AccessKey: cfg.AccessKey,
+			SecretKey: cfg.SecretKey,
+			Client:    cfg.Client,
+		})
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	return &gcsUploader{cfg: cfg}, nil
+}
+
+// NewGCSSink 建立上傳到 GCS 的 BucketSink
+func NewGCSSink(cfg GCSConfig, opts BucketOptions) (*BucketSink, error) {
+	up, err := NewGCSUploader(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return NewBucketSink(up, opts), nil
+}
+
+// ----------------- 內部實作 -----------------
+
+// metadataTokenURL GCE/GKE 上預設服務帳戶的權杖端點
+const metadataTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+
+// gcsUploader 以 JSON API 的 simple upload 上傳
+type gcsUploader struct {
+	cfg GCSConfig
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+func (u *gcsUploader) Upload(ctx context.Context, key, contentType string, body []byte) error {
+	token, err := u.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+	endpoint := fmt.Sprintf("https://storage.googleapis.com/upload/storage/v1/b/%s/o?uploadType=media&name=%s",
+		url.PathEscape(u.cfg.Bucket), url.QueryEscape(key))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := u.cfg.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	return checkResponse(resp)
+}
+
+// accessToken 回傳設定的權杖，或向 metadata server 取得並快取到過期前一分鐘
+func (u *gcsUploader) accessToken(ctx context.Context) (string, error) {
+	if u.cfg.Token != "" {
+		return u.cfg.Token, nil
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.token != "" && time.Now().Before(u.expires) {
+		return u.token, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metadataTokenURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := u.cfg.Client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("無法向 metadata server 取得 GCS 權杖（未設定 HMAC 金鑰或 Token）: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("metadata server 回應狀態碼 %d", resp.StatusCode)
+	}
+	var tok struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tok); err != nil {
+		return "", fmt.Errorf("解析 GCS 權杖失敗: %w", err)
+	}
+	u.token = tok.AccessToken
+	u.expires = time.Now().Add(time.Duration(tok.ExpiresIn)*time.Second - time.Minute)
+	return u.token, nil
+}
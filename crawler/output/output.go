@@ -0,0 +1,39 @@
+// Package output 將爬取結果輸出為 JSON Lines、CSV 或欄式格式（Parquet、Arrow IPC），
+// 方便以欄式分析工具處理大量結果而不必先轉換 JSON。
+// 大量網址時以 Create 取得 Sink，搭配 Crawler.FetchAllFunc 邊爬邊寫出
+package output
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/firehourse/cdpkit/crawler"
+)
+
+// WriteFile 依副檔名（.parquet、.arrow、.feather、.jsonl、.ndjson、.csv）選擇格式寫入結果；
+// schema 為 nil 時由結果推斷
+func WriteFile(path string, schema Schema, results []crawler.Result) error {
+	if schema == nil {
+		schema = InferSchema(results)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".parquet":
+		return WriteParquetFile(path, schema, results)
+	case ".arrow", ".feather", ".ipc":
+		return WriteArrowFile(path, schema, results)
+	case ".jsonl", ".ndjson", ".csv":
+		sink, err := Create(path, schema, Rotation{})
+		if err != nil {
+			return err
+		}
+		for _, r := range results {
+			if err := sink.Write(r); err != nil {
+				sink.Close()
+				return err
+			}
+		}
+		return sink.Close()
+	}
+	return fmt.Errorf("不支援的輸出格式: %s", path)
+}
@@ -0,0 +1,353 @@
+package output
+
+import (
+	"bytes"
+	"encoding/binary"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/firehourse/cdpkit/crawler"
+)
+
+var update = flag.Bool("update", false, "以目前的輸出更新 testdata 中的 golden 檔")
+
+// testResults 涵蓋各型別、NULL、空字串、非 ASCII 與巢狀值的固定結果
+func testResults() []crawler.Result {
+	ts := time.Date(2024, 5, 1, 8, 30, 0, 123e6, time.UTC)
+	return []crawler.Result{
+		{
+			URL: "https://shop.example.com/item/1", Title: "商品一", ResponseCode: 200,
+			FinalURL: "https://shop.example.com/item/1", ElapsedTime: 1500 * time.Millisecond, Timestamp: ts,
+			Data: map[string]interface{}{"price": 12.5, "stock": int64(3), "sale": true, "tags": []interface{}{"a", "b"}},
+		},
+		{
+			URL: "https://shop.example.com/item/2", Error: "逾時", ElapsedTime: 30 * time.Second, Timestamp: ts.Add(time.Second),
+		},
+		{
+			URL: "https://shop.example.com/item/3", Title: "", ResponseCode: 404, Timestamp: ts.Add(2 * time.Second),
+			Data: map[string]interface{}{"price": nil, "stock": int64(-1), "sale": false, "tags": []interface{}{}},
+		},
+		{
+			URL: "https://shop.example.com/item/4", Title: "Ünïcødé ✓", ResponseCode: 200,
+			Data: map[string]interface{}{"price": 0.1, "sale": true},
+		},
+	}
+}
+
+func testSchema() Schema {
+	return SchemaFromFields(map[string]ColumnType{
+		"price": TypeFloat64,
+		"stock": TypeInt64,
+		"sale":  TypeBool,
+		"tags":  TypeString,
+	})
+}
+
+// expectedRows 以解碼器的表示方式（時間為毫秒）列出 Schema.Row 的值
+func expectedRows(schema Schema, results []crawler.Result) [][]interface{} {
+	var rows [][]interface{}
+	for _, r := range results {
+		row := schema.Row(r)
+		for i, v := range row {
+			if ts, ok := v.(time.Time); ok {
+				row[i] = ts.UnixMilli()
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// golden 比對 testdata 中的 golden 檔；以 go test -update 重新產生
+func golden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("讀取 golden 檔失敗（以 -update 產生）: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s 與 golden 檔不同（%d / %d 位元組）；確認格式變更無誤後以 -update 更新", name, len(got), len(want))
+	}
+}
+
+func TestParquetRoundTrip(t *testing.T) {
+	schema, results := testSchema(), testResults()
+	var buf bytes.Buffer
+	if err := WriteParquet(&buf, schema, results); err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "results.parquet", buf.Bytes())
+
+	f, err := decodeParquet(buf.Bytes())
+	if err != nil {
+		t.Fatalf("解碼 Parquet 失敗: %v", err)
+	}
+	if len(f.columns) != len(schema) {
+		t.Fatalf("%d 個欄位，應為 %d", len(f.columns), len(schema))
+	}
+	for i, c := range schema {
+		got := f.columns[i]
+		if got.name != c.Name || got.physical != int64(parquetPhysicalType(c.Type)) {
+			t.Errorf("欄位 %d 為 %s（物理型別 %d），應為 %s", i, got.name, got.physical, c.Name)
+		}
+		switch c.Type {
+		case TypeString:
+			if got.converted != 0 || got.logical.sub(1) == nil {
+				t.Errorf("欄位 %s 應標示為 UTF8 / STRING", c.Name)
+			}
+		case TypeTimestamp:
+			ts := got.logical.sub(8)
+			if got.converted != 9 || ts == nil || ts[1] != true || ts.sub(2).sub(1) == nil {
+				t.Errorf("欄位 %s 應標示為 UTC 的毫秒 TIMESTAMP", c.Name)
+			}
+		}
+	}
+	if want := expectedRows(schema, results); !reflect.DeepEqual(f.rows, want) {
+		t.Errorf("解碼的資料列\n%v\n應為\n%v", f.rows, want)
+	}
+}
+
+// fields 回傳 thrift struct 中出現的欄位 id
+func fields(s tStruct) []int {
+	var ids []int
+	for id := range s {
+		ids = append(ids, int(id))
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// TestParquetFooterLayout 逐欄位檢查 FileMetaData 與 data page 的結構：
+// 只寫出規格中的必要欄位與 created_by，不寫 column_orders（沒有統計值時讀取端不需要排序規則），
+// path_in_schema 為只含欄位名稱的 list<binary>，definition levels 為 DataPage v1 的
+// 4 位元組長度前綴加上 RLE run，值的寬度由最大 definition level 1 推得為 1 位元組
+func TestParquetFooterLayout(t *testing.T) {
+	schema, results := testSchema(), testResults()
+	var buf bytes.Buffer
+	if err := WriteParquet(&buf, schema, results); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	metaLen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	r := &thriftReader{buf: data[len(data)-8-metaLen : len(data)-8]}
+	meta := r.readStruct()
+	if r.err != nil {
+		t.Fatal(r.err)
+	}
+
+	// FileMetaData: version、schema、num_rows、row_groups、created_by；沒有 key_value_metadata(5) 與 column_orders(7)
+	if got := fields(meta); !reflect.DeepEqual(got, []int{1, 2, 3, 4, 6}) {
+		t.Errorf("FileMetaData 欄位為 %v", got)
+	}
+	if meta.int(1) != 1 || meta.str(6) != "cdpkit" || meta.int(3) != int64(len(results)) {
+		t.Errorf("version=%d created_by=%q num_rows=%d", meta.int(1), meta.str(6), meta.int(3))
+	}
+
+	elements := meta.list(2)
+	root := elements[0].(tStruct)
+	if got := fields(root); !reflect.DeepEqual(got, []int{4, 5}) || root.str(4) != "schema" || root.int(5) != int64(len(schema)) {
+		t.Errorf("根節點為 %v", root)
+	}
+	for i, c := range schema {
+		el := elements[i+1].(tStruct)
+		want := []int{1, 3, 4}
+		if c.Type == TypeString || c.Type == TypeTimestamp {
+			want = []int{1, 3, 4, 6, 10}
+		}
+		if got := fields(el); !reflect.DeepEqual(got, want) {
+			t.Errorf("欄位 %s 的 SchemaElement 欄位為 %v，應為 %v", c.Name, got, want)
+		}
+	}
+
+	groups := meta.list(4)
+	if len(groups) != 1 {
+		t.Fatalf("%d 個 row group", len(groups))
+	}
+	group := groups[0].(tStruct)
+	if got := fields(group); !reflect.DeepEqual(got, []int{1, 2, 3}) {
+		t.Errorf("RowGroup 欄位為 %v", got)
+	}
+	checkedLevels := false
+	for i, ch := range group.list(1) {
+		c, chunk := schema[i], ch.(tStruct)
+		if got := fields(chunk); !reflect.DeepEqual(got, []int{2, 3}) {
+			t.Errorf("欄位 %s 的 ColumnChunk 欄位為 %v", c.Name, got)
+		}
+		cm := chunk.sub(3)
+		if got := fields(cm); !reflect.DeepEqual(got, []int{1, 2, 3, 4, 5, 6, 7, 9}) {
+			t.Errorf("欄位 %s 的 ColumnMetaData 欄位為 %v", c.Name, got)
+		}
+		if got := cm.list(2); !reflect.DeepEqual(got, []interface{}{int64(0), int64(3)}) {
+			t.Errorf("欄位 %s 的 encodings 為 %v，應為 [PLAIN RLE]", c.Name, got)
+		}
+		if got := cm.list(3); !reflect.DeepEqual(got, []interface{}{c.Name}) {
+			t.Errorf("欄位 %s 的 path_in_schema 為 %v", c.Name, got)
+		}
+		if chunk.int(2) != cm.int(9) {
+			t.Errorf("欄位 %s 的 file_offset %d 與 data_page_offset %d 不同", c.Name, chunk.int(2), cm.int(9))
+		}
+
+		// PageHeader: type、uncompressed/compressed size、data_page_header；沒有 crc(4)
+		pr := &thriftReader{buf: data[cm.int(9):]}
+		header := pr.readStruct()
+		if got := fields(header); !reflect.DeepEqual(got, []int{1, 2, 3, 5}) {
+			t.Errorf("欄位 %s 的 PageHeader 欄位為 %v", c.Name, got)
+		}
+		dp := header.sub(5)
+		if got := fields(dp); !reflect.DeepEqual(got, []int{1, 2, 3, 4}) || dp.int(1) != int64(len(results)) || dp.int(3) != 3 || dp.int(4) != 3 {
+			t.Errorf("欄位 %s 的 DataPageHeader 為 %v", c.Name, dp)
+		}
+
+		if c.Name != "price" {
+			continue
+		}
+		checkedLevels = true
+		// price 依序為 12.5、NULL、NULL、0.1：levels 1,0,0,1 編碼為三個 RLE run（標頭最低位元為 0）
+		page := data[int(cm.int(9))+pr.pos:]
+		want := []byte{6, 0, 0, 0, 1 << 1, 1, 2 << 1, 0, 1 << 1, 1}
+		if !bytes.Equal(page[:len(want)], want) {
+			t.Errorf("price 的 definition levels 為 % x，應為 % x", page[:len(want)], want)
+		}
+	}
+	if !checkedLevels {
+		t.Error("schema 中沒有 price 欄位")
+	}
+}
+
+func TestParquetWriterRowGroups(t *testing.T) {
+	results := testResults()
+	for len(results) < 11 {
+		results = append(results, testResults()...)
+	}
+	var buf bytes.Buffer
+	w := NewParquetWriter(&buf, nil)
+	w.RowGroupSize = 3
+	for _, r := range results {
+		if err := w.Write(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := decodeParquet(buf.Bytes())
+	if err != nil {
+		t.Fatalf("解碼 Parquet 失敗: %v", err)
+	}
+	if groups := len(f.meta.list(4)); groups != 4 {
+		t.Errorf("%d 個 row group，應為 4", groups)
+	}
+	// 未指定 schema 時由第一個 row group 推斷
+	if want := expectedRows(InferSchema(results[:3]), results); !reflect.DeepEqual(f.rows, want) {
+		t.Errorf("解碼的資料列\n%v\n應為\n%v", f.rows, want)
+	}
+}
+
+func TestArrowRoundTrip(t *testing.T) {
+	schema, results := testSchema(), testResults()
+	var buf bytes.Buffer
+	if err := WriteArrow(&buf, schema, results); err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "results.arrow", buf.Bytes())
+
+	f, err := decodeArrow(buf.Bytes())
+	if err != nil {
+		t.Fatalf("解碼 Arrow 失敗: %v", err)
+	}
+	if len(f.fields) != len(schema) {
+		t.Fatalf("%d 個欄位，應為 %d", len(f.fields), len(schema))
+	}
+	for i, c := range schema {
+		got := f.fields[i]
+		want := arrowField{name: c.Name, nullable: true}
+		switch c.Type {
+		case TypeInt64:
+			want.typeID, want.bitWidth, want.signed = 2, 64, true
+		case TypeFloat64:
+			want.typeID, want.precision = 3, 2
+		case TypeBool:
+			want.typeID = 6
+		case TypeTimestamp:
+			want.typeID, want.unit, want.timezone = 10, 1, "UTC"
+		default:
+			want.typeID = 5
+		}
+		if got != want {
+			t.Errorf("欄位 %d 為 %+v，應為 %+v", i, got, want)
+		}
+	}
+	if want := expectedRows(schema, results); !reflect.DeepEqual(f.rows, want) {
+		t.Errorf("解碼的資料列\n%v\n應為\n%v", f.rows, want)
+	}
+}
+
+func TestArrowEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteArrow(&buf, testSchema(), nil); err != nil {
+		t.Fatal(err)
+	}
+	f, err := decodeArrow(buf.Bytes())
+	if err != nil {
+		t.Fatalf("解碼 Arrow 失敗: %v", err)
+	}
+	if len(f.rows) != 0 || len(f.fields) != len(testSchema()) {
+		t.Errorf("空檔案解碼為 %d 列、%d 個欄位", len(f.rows), len(f.fields))
+	}
+}
+
+func TestSchemaFromSpec(t *testing.T) {
+	spec := crawler.ExtractSpec{
+		"name":    {Selector: "h1"},
+		"price":   {Selector: ".price", Type: "float"},
+		"stock":   {Selector: ".stock", Type: "int"},
+		"sale":    {Selector: ".sale", Type: "bool"},
+		"tags":    {Selector: ".tag", List: true, Type: "int"},
+		"seller":  {Selector: ".seller", Fields: crawler.ExtractSpec{"name": {Selector: ".n"}}},
+		"title":   {Selector: "title"},
+		"reviews": {Selector: ".review", List: true},
+	}
+	got := map[string]Column{}
+	for _, c := range SchemaFromSpec(spec)[len(baseColumns):] {
+		got[c.DataKey] = c
+	}
+	want := map[string]ColumnType{
+		"name": TypeString, "price": TypeFloat64, "stock": TypeInt64, "sale": TypeBool,
+		"tags": TypeString, "seller": TypeString, "title": TypeString, "reviews": TypeString,
+	}
+	for key, typ := range want {
+		if got[key].Type != typ {
+			t.Errorf("欄位 %s 的型別為 %d，應為 %d", key, got[key].Type, typ)
+		}
+	}
+	// 與固定欄位同名的鍵加上前綴
+	if got["title"].Name != "data_title" {
+		t.Errorf("title 欄位名稱為 %q，應為 data_title", got["title"].Name)
+	}
+
+	// 經 Coerce 轉型的值依規則的型別寫出
+	data := spec.Coerce(map[string]interface{}{"price": "$1,299.50", "stock": "12 件", "sale": "", "tags": []interface{}{"1", "2"}})
+	row := SchemaFromSpec(spec).Row(crawler.Result{URL: "https://example.com/", Data: data})
+	var values []string
+	for i, c := range SchemaFromSpec(spec) {
+		if c.DataKey != "" {
+			values = append(values, c.Name+"="+csvValue(row[i]))
+		}
+	}
+	if s := strings.Join(values, " "); s != "name= price=1299.5 reviews= sale=true seller= stock=12 tags=[1,2] data_title=" {
+		t.Errorf("資料列為 %s", s)
+	}
+}
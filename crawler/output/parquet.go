@@ -0,0 +1,307 @@
+package output
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"time"
+
+	"github.com/firehourse/cdpkit/crawler"
+)
+
+// 每個 row group 最多的筆數，避免單一 page 過大
+const parquetRowGroupSize = 65536
+
+var parquetMagic = []byte("PAR1")
+
+// Parquet 規格中的列舉值
+const (
+	parquetBoolean   = 0
+	parquetInt64     = 2
+	parquetDouble    = 5
+	parquetByteArray = 6
+
+	parquetOptional = 1
+
+	convertedUTF8            = 0
+	convertedTimestampMillis = 9
+
+	encodingPlain = 0
+	encodingRLE   = 3
+
+	pageTypeData = 0
+)
+
+// WriteParquet 以 Parquet 格式輸出結果（DataPage v1、PLAIN 編碼、不壓縮），所有欄位皆為 OPTIONAL。
+// 檔案結構依 Parquet 規格撰寫並由測試逐欄位檢查，尚未以其他 Parquet 實作的讀取端驗證
+func WriteParquet(w io.Writer, schema Schema, results []crawler.Result) error {
+	cw := &countingWriter{w: w}
+	cw.Write(parquetMagic)
+
+	var groups []parquetRowGroup
+	for start := 0; start < len(results); start += parquetRowGroupSize {
+		end := start + parquetRowGroupSize
+		if end > len(results) {
+			end = len(results)
+		}
+		groups = append(groups, writeParquetRowGroup(cw, schema, results[start:end]))
+	}
+	writeParquetFooter(cw, schema, groups, int64(len(results)))
+	if cw.err != nil {
+		return fmt.Errorf("寫入 Parquet 失敗: %w", cw.err)
+	}
+	return nil
+}
+
+// WriteParquetFile 將結果寫入 Parquet 檔案
+func WriteParquetFile(path string, schema Schema, results []crawler.Result) error {
+	return writeFile(path, func(w io.Writer) error {
+		return WriteParquet(w, schema, results)
+	})
+}
+
+// ----------------- 內部實作 -----------------
+
+type parquetChunk struct {
+	offset int64
+	size   int64
+}
+
+type parquetRowGroup struct {
+	chunks    []parquetChunk
+	numRows   int64
+	totalSize int64
+}
+
+// writeParquetRowGroup 寫入一個 row group，每個欄位一個 data page
+func writeParquetRowGroup(cw *countingWriter, schema Schema, results []crawler.Result) parquetRowGroup {
+	rows := make([][]interface{}, 0, len(results))
+	for _, r := range results {
+		rows = append(rows, schema.Row(r))
+	}
+
+	g := parquetRowGroup{numRows: int64(len(rows))}
+	for i, c := range schema {
+		page := encodeParquetPage(c, rows, i)
+		header := parquetPageHeader(len(page), len(rows))
+
+		offset := cw.n
+		cw.Write(header)
+		cw.Write(page)
+		g.chunks = append(g.chunks, parquetChunk{
+			offset: offset,
+			size:   int64(len(header) + len(page)),
+		})
+		g.totalSize += int64(len(header) + len(page))
+	}
+	return g
+}
+
+// writeParquetFooter 寫入檔案中繼資料、長度與結尾的 magic
+func writeParquetFooter(cw *countingWriter, schema Schema, groups []parquetRowGroup, numRows int64) {
+	meta := parquetFileMetaData(schema, groups, numRows)
+	cw.Write(meta)
+	var size [4]byte
+	binary.LittleEndian.PutUint32(size[:], uint32(len(meta)))
+	cw.Write(size[:])
+	cw.Write(parquetMagic)
+}
+
+// encodeParquetPage 產生 data page 內容：RLE 編碼的 definition levels 加上 PLAIN 編碼的非空值
+func encodeParquetPage(c Column, rows [][]interface{}, col int) []byte {
+	levels := make([]byte, len(rows))
+	var values bytes.Buffer
+	var bools []bool
+	for i, row := range rows {
+		v := row[col]
+		if v == nil {
+			continue
+		}
+		levels[i] = 1
+		switch c.Type {
+		case TypeString:
+			s := v.(string)
+			binary.Write(&values, binary.LittleEndian, uint32(len(s)))
+			values.WriteString(s)
+		case TypeInt64:
+			binary.Write(&values, binary.LittleEndian, v.(int64))
+		case TypeFloat64:
+			binary.Write(&values, binary.LittleEndian, math.Float64bits(v.(float64)))
+		case TypeTimestamp:
+			binary.Write(&values, binary.LittleEndian, v.(time.Time).UnixMilli())
+		case TypeBool:
+			bools = append(bools, v.(bool))
+		}
+	}
+	if c.Type == TypeBool {
+		values.Write(packBits(bools))
+	}
+
+	rle := encodeRLE(levels)
+	var page bytes.Buffer
+	binary.Write(&page, binary.LittleEndian, uint32(len(rle)))
+	page.Write(rle)
+	page.Write(values.Bytes())
+	return page.Bytes()
+}
+
+// encodeRLE 以 RLE/bit-packing hybrid 的 RLE run 編碼 bit width 為 1 的 levels
+func encodeRLE(levels []byte) []byte {
+	var out []byte
+	var tmp [binary.MaxVarintLen64]byte
+	for i := 0; i < len(levels); {
+		j := i
+		for j < len(levels) && levels[j] == levels[i] {
+			j++
+		}
+		n := binary.PutUvarint(tmp[:], uint64(j-i)<<1)
+		out = append(out, tmp[:n]...)
+		out = append(out, levels[i])
+		i = j
+	}
+	return out
+}
+
+// packBits 依 LSB 優先的順序打包布林值
+func packBits(bits []bool) []byte {
+	out := make([]byte, (len(bits)+7)/8)
+	for i, b := range bits {
+		if b {
+			out[i/8] |= 1 << (i % 8)
+		}
+	}
+	return out
+}
+
+func parquetPageHeader(pageSize, numValues int) []byte {
+	var t thriftWriter
+	t.beginStruct(0)
+	t.i32(1, pageTypeData)
+	t.i32(2, int32(pageSize))
+	t.i32(3, int32(pageSize))
+	t.beginStruct(5)
+	t.i32(1, int32(numValues))
+	t.i32(2, encodingPlain)
+	t.i32(3, encodingRLE)
+	t.i32(4, encodingRLE)
+	t.endStruct()
+	t.endStruct()
+	return t.buf.Bytes()
+}
+
+func parquetFileMetaData(schema Schema, groups []parquetRowGroup, numRows int64) []byte {
+	var t thriftWriter
+	t.beginStruct(0)
+	t.i32(1, 1)
+
+	t.beginList(2, thriftStruct, len(schema)+1)
+	t.beginStruct(0)
+	t.str(4, "schema")
+	t.i32(5, int32(len(schema)))
+	t.endStruct()
+	for _, c := range schema {
+		t.beginStruct(0)
+		t.i32(1, parquetPhysicalType(c.Type))
+		t.i32(3, parquetOptional)
+		t.str(4, c.Name)
+		switch c.Type {
+		case TypeString:
+			t.i32(6, convertedUTF8)
+			t.beginStruct(10)
+			t.beginStruct(1) // STRING
+			t.endStruct()
+			t.endStruct()
+		case TypeTimestamp:
+			t.i32(6, convertedTimestampMillis)
+			t.beginStruct(10)
+			t.beginStruct(8) // TIMESTAMP
+			t.boolean(1, true)
+			t.beginStruct(2)
+			t.beginStruct(1) // MILLIS
+			t.endStruct()
+			t.endStruct()
+			t.endStruct()
+			t.endStruct()
+		}
+		t.endStruct()
+	}
+
+	t.i64(3, numRows)
+
+	t.beginList(4, thriftStruct, len(groups))
+	for _, g := range groups {
+		t.beginStruct(0)
+		t.beginList(1, thriftStruct, len(g.chunks))
+		for i, ch := range g.chunks {
+			c := schema[i]
+			t.beginStruct(0)
+			t.i64(2, ch.offset)
+			t.beginStruct(3)
+			t.i32(1, parquetPhysicalType(c.Type))
+			t.beginList(2, thriftI32, 2)
+			t.listI32(encodingPlain)
+			t.listI32(encodingRLE)
+			t.beginList(3, thriftBinary, 1)
+			t.listStr(c.Name)
+			t.i32(4, 0) // UNCOMPRESSED
+			t.i64(5, g.numRows)
+			t.i64(6, ch.size)
+			t.i64(7, ch.size)
+			t.i64(9, ch.offset)
+			t.endStruct()
+			t.endStruct()
+		}
+		t.i64(2, g.totalSize)
+		t.i64(3, g.numRows)
+		t.endStruct()
+	}
+
+	t.str(6, "cdpkit")
+	t.endStruct()
+	return t.buf.Bytes()
+}
+
+func parquetPhysicalType(t ColumnType) int32 {
+	switch t {
+	case TypeInt64, TypeTimestamp:
+		return parquetInt64
+	case TypeFloat64:
+		return parquetDouble
+	case TypeBool:
+		return parquetBoolean
+	default:
+		return parquetByteArray
+	}
+}
+
+// countingWriter 記錄已寫入的位元組數，並保留第一個錯誤
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
+
+func writeFile(path string, write func(io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("無法建立輸出檔 %s: %w", path, err)
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
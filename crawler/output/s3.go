@@ -0,0 +1,159 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// S3Config S3 或相容服務（MinIO、R2、GCS 互通 API 等）的連線設定
+type S3Config struct {
+	Bucket string
+	// Region 預設讀取 AWS_REGION，未設定時為 us-east-1
+	Region string
+	// Endpoint 相容服務的位址，例如 http://localhost:9000；設定時使用 path-style 網址，
+	// 留空則使用 https://{Bucket}.s3.{Region}.amazonaws.com
+	Endpoint string
+	// AccessKey、SecretKey、SessionToken 預設讀取 AWS_ACCESS_KEY_ID、AWS_SECRET_ACCESS_KEY、AWS_SESSION_TOKEN
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+	// Client 送出請求的 HTTP 用戶端，nil 時使用 http.DefaultClient
+	Client *http.Client
+}
+
+// NewS3Uploader 建立以 Signature V4 簽章 PutObject 的 Uploader
+func NewS3Uploader(cfg S3Config) (Uploader, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("未指定 S3 bucket")
+	}
+	if cfg.Region == "" {
+		cfg.Region = os.Getenv("AWS_REGION")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.AccessKey == "" && cfg.SecretKey == "" {
+		cfg.AccessKey = os.Getenv("AWS_ACCESS_KEY_ID")
+		cfg.SecretKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		cfg.SessionToken = os.Getenv("AWS_SESSION_TOKEN")
+	}
+	if cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, fmt.Errorf("未設定 S3 存取金鑰")
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+
+	u := &s3Uploader{cfg: cfg}
+	if cfg.Endpoint == "" {
+		u.base = fmt.Sprintf("https://%s.s3.%s.amazonaws.com/", cfg.Bucket, cfg.Region)
+	} else {
+		u.base = strings.TrimRight(cfg.Endpoint, "/") + "/" + cfg.Bucket + "/"
+	}
+	return u, nil
+}
+
+// NewS3Sink 建立上傳到 S3 的 BucketSink
+func NewS3Sink(cfg S3Config, opts BucketOptions) (*BucketSink, error) {
+	up, err := NewS3Uploader(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return NewBucketSink(up, opts), nil
+}
+
+// ----------------- 內部實作 -----------------
+
+type s3Uploader struct {
+	cfg  S3Config
+	base string
+}
+
+func (u *s3Uploader) Upload(ctx context.Context, key, contentType string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.base+escapeKey(key), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	signV4(req, body, u.cfg, time.Now())
+	resp, err := u.cfg.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	return checkResponse(resp)
+}
+
+// signV4 以 AWS Signature Version 4 簽署請求，簽入 host、x-amz-content-sha256、x-amz-date 與 session token
+func signV4(req *http.Request, body []byte, cfg S3Config, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payload := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(payload[:])
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	headers := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	values := []string{req.URL.Host, payloadHash, amzDate}
+	if cfg.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", cfg.SessionToken)
+		headers = append(headers, "x-amz-security-token")
+		values = append(values, cfg.SessionToken)
+	}
+	var canonicalHeaders strings.Builder
+	for i, h := range headers {
+		canonicalHeaders.WriteString(h + ":" + values[i] + "\n")
+	}
+	signedHeaders := strings.Join(headers, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+
+	scope := date + "/" + cfg.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+cfg.SecretKey), date)
+	key = hmacSHA256(key, cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		cfg.AccessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// escapeKey 逐段編碼物件名稱，除 RFC 3986 的 unreserved 字元與 "/" 外一律編碼，與簽章的規則一致
+func escapeKey(key string) string {
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' || c == '/' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
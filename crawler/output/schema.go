@@ -0,0 +1,226 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/firehourse/cdpkit/crawler"
+)
+
+// ColumnType 欄位型別
+type ColumnType int
+
+const (
+	TypeString ColumnType = iota
+	TypeInt64
+	TypeFloat64
+	TypeBool
+	// TypeTimestamp 毫秒精度、UTC
+	TypeTimestamp
+)
+
+// Column 單一欄位定義
+type Column struct {
+	Name string
+	Type ColumnType
+	// DataKey 對應 Result.Data 的鍵；固定欄位（url、title 等）為空
+	DataKey string
+}
+
+// Schema 欄位清單，順序即輸出順序
+type Schema []Column
+
+// 每筆結果都會輸出的固定欄位
+var baseColumns = Schema{
+	{Name: "url", Type: TypeString},
+	{Name: "title", Type: TypeString},
+	{Name: "error", Type: TypeString},
+	{Name: "response_code", Type: TypeInt64},
+	{Name: "final_url", Type: TypeString},
+	{Name: "elapsed_ms", Type: TypeInt64},
+	{Name: "timestamp", Type: TypeTimestamp},
+}
+
+// SchemaFromFields 以明確的 Data 欄位定義建立 Schema；宣告式擷取規則可直接使用 SchemaFromSpec
+func SchemaFromFields(fields map[string]ColumnType) Schema {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	s := append(Schema{}, baseColumns...)
+	for _, k := range keys {
+		s = append(s, Column{Name: dataColumnName(k), Type: fields[k], DataKey: k})
+	}
+	return s
+}
+
+// SchemaFromSpec 依宣告式擷取規則的欄位型別建立 Schema，不必由結果推斷：
+// int、float、bool 對應 TypeInt64、TypeFloat64、TypeBool，list 與巢狀欄位以 JSON 字串輸出
+func SchemaFromSpec(spec crawler.ExtractSpec) Schema {
+	fields := make(map[string]ColumnType, len(spec))
+	for name, f := range spec {
+		fields[name] = specType(f)
+	}
+	return SchemaFromFields(fields)
+}
+
+// InferSchema 由結果推斷 Data 欄位型別；數字一律視為 float64（JS 數字沒有整數型別），
+// 巢狀物件、陣列或型別不一致的欄位以 JSON 字串輸出
+func InferSchema(results []crawler.Result) Schema {
+	types := map[string]ColumnType{}
+	seen := map[string]bool{}
+	for _, r := range results {
+		for k, v := range r.Data {
+			if v == nil {
+				if !seen[k] {
+					types[k] = TypeString
+				}
+				continue
+			}
+			t := valueType(v)
+			if seen[k] && types[k] != t {
+				t = TypeString
+			}
+			types[k] = t
+			seen[k] = true
+		}
+	}
+	return SchemaFromFields(types)
+}
+
+// Row 依 Schema 取出一筆結果的欄位值；缺少或型別不符的值為 nil
+func (s Schema) Row(r crawler.Result) []interface{} {
+	row := make([]interface{}, len(s))
+	for i, c := range s {
+		if c.DataKey == "" {
+			row[i] = baseValue(c.Name, r)
+			continue
+		}
+		row[i] = convert(r.Data[c.DataKey], c.Type)
+	}
+	return row
+}
+
+// ----------------- 內部輔助 -----------------
+
+func dataColumnName(key string) string {
+	for _, c := range baseColumns {
+		if c.Name == key {
+			return "data_" + key
+		}
+	}
+	return key
+}
+
+// specType 擷取欄位經 ExtractSpec.Coerce 轉型後的型別
+func specType(f crawler.Field) ColumnType {
+	if f.List || len(f.Fields) > 0 {
+		return TypeString
+	}
+	switch f.Type {
+	case "int":
+		return TypeInt64
+	case "float":
+		return TypeFloat64
+	case "bool":
+		return TypeBool
+	}
+	return TypeString
+}
+
+func valueType(v interface{}) ColumnType {
+	switch v.(type) {
+	case float64, float32:
+		return TypeFloat64
+	case int, int32, int64:
+		return TypeInt64
+	case bool:
+		return TypeBool
+	default:
+		return TypeString
+	}
+}
+
+func baseValue(name string, r crawler.Result) interface{} {
+	switch name {
+	case "url":
+		return r.URL
+	case "title":
+		return nilIfEmpty(r.Title)
+	case "error":
+		return nilIfEmpty(r.Error)
+	case "response_code":
+		if r.ResponseCode == 0 {
+			return nil
+		}
+		return int64(r.ResponseCode)
+	case "final_url":
+		return nilIfEmpty(r.FinalURL)
+	case "elapsed_ms":
+		return r.ElapsedTime.Milliseconds()
+	case "timestamp":
+		if r.Timestamp.IsZero() {
+			return nil
+		}
+		return r.Timestamp
+	}
+	return nil
+}
+
+func nilIfEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func convert(v interface{}, t ColumnType) interface{} {
+	if v == nil {
+		return nil
+	}
+	switch t {
+	case TypeFloat64:
+		switch x := v.(type) {
+		case float64:
+			return x
+		case float32:
+			return float64(x)
+		case int:
+			return float64(x)
+		case int64:
+			return float64(x)
+		}
+	case TypeInt64:
+		switch x := v.(type) {
+		case int:
+			return int64(x)
+		case int32:
+			return int64(x)
+		case int64:
+			return x
+		case float64:
+			return int64(x)
+		}
+	case TypeBool:
+		if b, ok := v.(bool); ok {
+			return b
+		}
+	case TypeTimestamp:
+		if ts, ok := v.(time.Time); ok {
+			return ts
+		}
+	case TypeString:
+		if s, ok := v.(string); ok {
+			return s
+		}
+		if b, err := json.Marshal(v); err == nil {
+			return string(b)
+		}
+		return fmt.Sprintf("%v", v)
+	}
+	return nil
+}
@@ -0,0 +1,327 @@
+package output
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/firehourse/cdpkit/crawler"
+)
+
+// Sink 逐筆寫出結果的輸出端，搭配 Crawler.FetchAllFunc 或 Stream 使用時不必把全部結果留在記憶體。
+// Sink 不是 goroutine 安全的，FetchAllFunc 會在同一個 goroutine 中依序呼叫
+type Sink interface {
+	Write(r crawler.Result) error
+	// Close 寫出緩衝中的資料與檔尾；不會關閉底層的 io.Writer
+	Close() error
+}
+
+// Rotation 輸出檔的輪替條件，任一條件達到時切換到下一個檔案；皆為 0 表示不輪替
+type Rotation struct {
+	// MaxBytes 單一檔案的大小上限；Parquet 只在寫出 row group 時計入大小
+	MaxBytes int64
+	// MaxResults 單一檔案的筆數上限
+	MaxResults int
+}
+
+// DataColumns 以固定欄位加上指定的 Data 鍵建立 Schema，欄位順序與 keys 相同，值一律以字串輸出
+func DataColumns(keys ...string) Schema {
+	s := append(Schema{}, baseColumns...)
+	for _, k := range keys {
+		s = append(s, Column{Name: dataColumnName(k), Type: TypeString, DataKey: k})
+	}
+	return s
+}
+
+// JSONLWriter 以 JSON Lines 格式輸出，每行一筆完整的 Result
+type JSONLWriter struct {
+	enc *json.Encoder
+}
+
+// NewJSONLWriter 建立寫入 w 的 JSONLWriter
+func NewJSONLWriter(w io.Writer) *JSONLWriter {
+	return &JSONLWriter{enc: json.NewEncoder(w)}
+}
+
+func (j *JSONLWriter) Write(r crawler.Result) error {
+	if err := j.enc.Encode(r); err != nil {
+		return fmt.Errorf("寫入 JSONL 失敗: %w", err)
+	}
+	return nil
+}
+
+func (j *JSONLWriter) Close() error { return nil }
+
+// CSVWriter 依 Schema 輸出 CSV，第一行為欄位名稱；巢狀的 Data 值以 JSON 字串輸出，時間為 RFC 3339
+type CSVWriter struct {
+	w      *csv.Writer
+	schema Schema
+	header bool
+}
+
+// NewCSVWriter 建立寫入 w 的 CSVWriter；schema 為 nil 時由第一筆結果推斷，
+// 之後出現的 Data 鍵不會輸出，需要固定欄位時請用 DataColumns 或 SchemaFromFields
+func NewCSVWriter(w io.Writer, schema Schema) *CSVWriter {
+	return &CSVWriter{w: csv.NewWriter(w), schema: schema}
+}
+
+func (c *CSVWriter) Write(r crawler.Result) error {
+	if c.schema == nil {
+		c.schema = InferSchema([]crawler.Result{r})
+	}
+	if !c.header {
+		names := make([]string, len(c.schema))
+		for i, col := range c.schema {
+			names[i] = col.Name
+		}
+		c.w.Write(names)
+		c.header = true
+	}
+	row := c.schema.Row(r)
+	record := make([]string, len(row))
+	for i, v := range row {
+		record[i] = csvValue(v)
+	}
+	c.w.Write(record)
+	// 每筆都寫到底層，讓輪替依實際大小判斷
+	c.w.Flush()
+	if err := c.w.Error(); err != nil {
+		return fmt.Errorf("寫入 CSV 失敗: %w", err)
+	}
+	return nil
+}
+
+func (c *CSVWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// ParquetWriter 逐批輸出 Parquet，每累積 RowGroupSize 筆寫出一個 row group，Close 時寫入檔尾
+type ParquetWriter struct {
+	// RowGroupSize 每個 row group 的筆數，預設 65536；結果含 HTML 時可調小以降低記憶體用量
+	RowGroupSize int
+
+	cw      *countingWriter
+	schema  Schema
+	pending []crawler.Result
+	groups  []parquetRowGroup
+	rows    int64
+	started bool
+}
+
+// NewParquetWriter 建立寫入 w 的 ParquetWriter；schema 為 nil 時由第一個 row group 的結果推斷
+func NewParquetWriter(w io.Writer, schema Schema) *ParquetWriter {
+	return &ParquetWriter{RowGroupSize: parquetRowGroupSize, cw: &countingWriter{w: w}, schema: schema}
+}
+
+func (p *ParquetWriter) Write(r crawler.Result) error {
+	p.pending = append(p.pending, r)
+	if len(p.pending) >= p.RowGroupSize {
+		return p.flush()
+	}
+	return nil
+}
+
+func (p *ParquetWriter) Close() error {
+	if len(p.pending) > 0 || !p.started {
+		if err := p.flush(); err != nil {
+			return err
+		}
+	}
+	writeParquetFooter(p.cw, p.schema, p.groups, p.rows)
+	if p.cw.err != nil {
+		return fmt.Errorf("寫入 Parquet 失敗: %w", p.cw.err)
+	}
+	return nil
+}
+
+// RotatingWriter 依 Rotation 切換輸出檔，檔名在副檔名前加上序號，例如 results-0001.jsonl
+type RotatingWriter struct {
+	path   string
+	rot    Rotation
+	schema Schema
+
+	cur   *fileSink
+	count int
+	files []string
+}
+
+// Files 目前已建立的輸出檔
+func (w *RotatingWriter) Files() []string {
+	return w.files
+}
+
+func (w *RotatingWriter) Write(r crawler.Result) error {
+	if w.cur == nil {
+		name := rotatedName(w.path, len(w.files)+1)
+		cur, err := openSink(name, w.schema)
+		if err != nil {
+			return err
+		}
+		w.cur, w.count = cur, 0
+		w.files = append(w.files, name)
+	}
+	if err := w.cur.Write(r); err != nil {
+		return err
+	}
+	w.count++
+	if (w.rot.MaxResults > 0 && w.count >= w.rot.MaxResults) || (w.rot.MaxBytes > 0 && w.cur.cw.n >= w.rot.MaxBytes) {
+		cur := w.cur
+		w.cur = nil
+		return cur.Close()
+	}
+	return nil
+}
+
+func (w *RotatingWriter) Close() error {
+	if w.cur == nil {
+		return nil
+	}
+	cur := w.cur
+	w.cur = nil
+	return cur.Close()
+}
+
+// NewSink 依格式名稱（jsonl、ndjson、csv、parquet）建立寫入 w 的 Sink
+func NewSink(format string, w io.Writer, schema Schema) (Sink, error) {
+	switch strings.ToLower(strings.TrimPrefix(format, ".")) {
+	case "jsonl", "ndjson":
+		return NewJSONLWriter(w), nil
+	case "csv":
+		return NewCSVWriter(w, schema), nil
+	case "parquet":
+		return NewParquetWriter(w, schema), nil
+	}
+	return nil, fmt.Errorf("不支援的串流輸出格式: %s", format)
+}
+
+// Create 依副檔名建立輸出檔的 Sink；rot 有設定時依大小或筆數輪替，回傳 *RotatingWriter。
+// path 也可以是遠端位置，此時 rot.MaxResults 作為每批的筆數、忽略 schema：
+// s3://bucket/prefix/ 與 gs://bucket/prefix/ 以 JSON Lines 上傳並另存 HTML、截圖與 HAR，
+// 金鑰與 AWS_ENDPOINT_URL 由環境變數讀取、GCS 使用 metadata server 的權杖；http(s):// 網址則 POST 到 webhook
+func Create(path string, schema Schema, rot Rotation) (Sink, error) {
+	if sink, ok, err := createRemote(path, rot); ok {
+		return sink, err
+	}
+	if _, err := NewSink(filepath.Ext(path), io.Discard, schema); err != nil {
+		return nil, err
+	}
+	if rot.MaxBytes > 0 || rot.MaxResults > 0 {
+		return &RotatingWriter{path: path, rot: rot, schema: schema}, nil
+	}
+	return openSink(path, schema)
+}
+
+// ----------------- 內部實作 -----------------
+
+// createRemote 處理遠端位置；ok 為 false 表示 path 是本機檔案
+func createRemote(path string, rot Rotation) (sink Sink, ok bool, err error) {
+	u, err := url.Parse(path)
+	if err != nil {
+		return nil, false, nil
+	}
+	opts := BucketOptions{
+		Prefix:       strings.TrimPrefix(u.Path, "/"),
+		BatchSize:    rot.MaxResults,
+		SeparateHTML: true,
+		Screenshots:  true,
+		HAR:          true,
+	}
+	switch u.Scheme {
+	case "s3":
+		sink, err = NewS3Sink(S3Config{Bucket: u.Host, Endpoint: os.Getenv("AWS_ENDPOINT_URL")}, opts)
+	case "gs":
+		sink, err = NewGCSSink(GCSConfig{Bucket: u.Host}, opts)
+	case "http", "https":
+		sink = NewWebhookSink(path, WebhookOptions{BatchSize: rot.MaxResults, Screenshots: true, HAR: true})
+	default:
+		return nil, false, nil
+	}
+	return sink, true, err
+}
+
+// fileSink 寫入檔案的 Sink，記錄已寫出的位元組數供輪替判斷
+type fileSink struct {
+	Sink
+	f  *os.File
+	bw *bufio.Writer
+	cw *countingWriter
+}
+
+func openSink(path string, schema Schema) (*fileSink, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("無法建立輸出檔 %s: %w", path, err)
+	}
+	bw := bufio.NewWriter(f)
+	cw := &countingWriter{w: bw}
+	sink, err := NewSink(filepath.Ext(path), cw, schema)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &fileSink{Sink: sink, f: f, bw: bw, cw: cw}, nil
+}
+
+func (s *fileSink) Close() error {
+	err := s.Sink.Close()
+	if ferr := s.bw.Flush(); err == nil && ferr != nil {
+		err = fmt.Errorf("寫入 %s 失敗: %w", s.f.Name(), ferr)
+	}
+	if cerr := s.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// flush 將累積的結果寫成一個 row group
+func (p *ParquetWriter) flush() error {
+	if p.schema == nil {
+		p.schema = InferSchema(p.pending)
+	}
+	if !p.started {
+		p.cw.Write(parquetMagic)
+		p.started = true
+	}
+	if len(p.pending) > 0 {
+		p.groups = append(p.groups, writeParquetRowGroup(p.cw, p.schema, p.pending))
+		p.rows += int64(len(p.pending))
+		p.pending = p.pending[:0]
+	}
+	if p.cw.err != nil {
+		return fmt.Errorf("寫入 Parquet 失敗: %w", p.cw.err)
+	}
+	return nil
+}
+
+// rotatedName 在副檔名前插入序號
+func rotatedName(path string, seq int) string {
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s-%04d%s", strings.TrimSuffix(path, ext), seq, ext)
+}
+
+func csvValue(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(x)
+	case time.Time:
+		return x.UTC().Format(time.RFC3339Nano)
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,170 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/firehourse/cdpkit/crawler"
+)
+
+// WebhookOptions WebhookSink 的設定；零值欄位使用預設值
+type WebhookOptions struct {
+	// Headers 額外的請求標頭，例如 Authorization
+	Headers map[string]string
+	// Secret 設定時以 HMAC-SHA256 簽署請求主體，放在 X-Cdpkit-Signature: sha256=<hex>
+	Secret string
+	// BatchSize 每次 POST 的筆數上限，預設 100
+	BatchSize int
+	// FlushInterval 未滿一批的結果最多等待多久送出，預設 10 秒；負值表示只在批次滿或 Close 時送出
+	FlushInterval time.Duration
+	// Screenshots 為 true 時以 base64 的 screenshot 欄位附上 Result.Screenshot
+	Screenshots bool
+	// HAR 為 true 時以 har 欄位附上抽樣頁面的 Result.HAR
+	HAR bool
+	// MaxAttempts 送出失敗（網路錯誤、408、429、5xx）時最多嘗試的次數，預設 3
+	MaxAttempts int
+	// Backoff 第一次重試前的等待時間，之後每次加倍，預設 1 秒
+	Backoff time.Duration
+	// Timeout 單次請求的逾時，預設 30 秒
+	Timeout time.Duration
+	// Client 送出請求的 HTTP 用戶端，nil 時使用 http.DefaultClient
+	Client *http.Client
+}
+
+// WebhookSink 將結果以 JSON 陣列批次 POST 到 webhook。
+// 定時送出在背景進行，失敗時保留該批並在下一次 Write 或 Close 回傳錯誤
+type WebhookSink struct {
+	url  string
+	opts WebhookOptions
+
+	mu      sync.Mutex
+	pending []webhookRecord
+	timer   *time.Timer
+	err     error
+}
+
+// NewWebhookSink 建立 POST 到 url 的 WebhookSink
+func NewWebhookSink(url string, opts WebhookOptions) *WebhookSink {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	if opts.FlushInterval == 0 {
+		opts.FlushInterval = 10 * time.Second
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 3
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = time.Second
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 30 * time.Second
+	}
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+	return &WebhookSink{url: url, opts: opts}
+}
+
+func (s *WebhookSink) Write(r crawler.Result) error {
+	rec := webhookRecord{Result: r}
+	if s.opts.Screenshots {
+		rec.Screenshot = r.Screenshot
+	}
+	if s.opts.HAR && len(r.HAR) > 0 {
+		rec.HAR = json.RawMessage(r.HAR)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending = append(s.pending, rec)
+	if len(s.pending) >= s.opts.BatchSize {
+		s.flushLocked()
+	} else if s.timer == nil && s.opts.FlushInterval > 0 {
+		s.timer = time.AfterFunc(s.opts.FlushInterval, func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.timer = nil
+			s.flushLocked()
+		})
+	}
+	err := s.err
+	s.err = nil
+	return err
+}
+
+// Close 送出剩餘的結果
+func (s *WebhookSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.pending) > 0 {
+		s.flushLocked()
+	}
+	err := s.err
+	s.err = nil
+	return err
+}
+
+// ----------------- 內部實作 -----------------
+
+// webhookRecord 送出的每筆結果
+type webhookRecord struct {
+	crawler.Result
+	Screenshot []byte          `json:"screenshot,omitempty"`
+	HAR        json.RawMessage `json:"har,omitempty"`
+}
+
+// flushLocked 送出目前的批次；呼叫端須持有 s.mu。失敗時保留批次並記錄錯誤
+func (s *WebhookSink) flushLocked() {
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	if len(s.pending) == 0 {
+		return
+	}
+	body, err := json.Marshal(s.pending)
+	if err != nil {
+		s.err = fmt.Errorf("序列化結果失敗: %w", err)
+		return
+	}
+	err = withRetry(s.opts.MaxAttempts, s.opts.Backoff, func() error {
+		return s.post(body)
+	})
+	if err != nil {
+		s.err = fmt.Errorf("送出 webhook 失敗（%d 筆結果待送出）: %w", len(s.pending), err)
+		return
+	}
+	s.pending = nil
+}
+
+func (s *WebhookSink) post(body []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.opts.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.opts.Headers {
+		req.Header.Set(k, v)
+	}
+	if s.opts.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(s.opts.Secret))
+		mac.Write(body)
+		req.Header.Set("X-Cdpkit-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	resp, err := s.opts.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	return checkResponse(resp)
+}
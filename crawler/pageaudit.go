@@ -0,0 +1,68 @@
+package crawler
+
+import (
+	"math"
+
+	"github.com/firehourse/cdpkit/pageaudit"
+	"github.com/firehourse/cdpkit/tab"
+)
+
+// ----------------- 內部實作 -----------------
+
+// startPageAudit 設定 PageAudit 時於導航前開始記錄；未設定時回傳 nil
+func (c *Crawler) startPageAudit(pageTab *tab.Tab) *pageaudit.Recorder {
+	if c.options.PageAudit == nil {
+		return nil
+	}
+	rec, err := pageaudit.Start(pageTab, *c.options.PageAudit)
+	if err != nil {
+		c.logf(2, "警告: 頁面稽核無法記錄覆蓋率: %v", err)
+	}
+	return rec
+}
+
+// finishPageAudit 產生 docURL 頁面的稽核報告並存入 result.Audit
+func (c *Crawler) finishPageAudit(rec *pageaudit.Recorder, result *Result, docURL string) {
+	if rec == nil {
+		return
+	}
+	report, err := rec.Finish(docURL)
+	if err != nil {
+		c.logf(2, "警告: %s 的頁面稽核不完整: %v", docURL, err)
+	}
+	result.Audit = report
+}
+
+// auditTotals 頁面稽核分數的合計，由 Crawler.mu 保護
+type auditTotals struct {
+	pages      int
+	score      int
+	categories map[string][2]int // 分數合計、頁面數
+}
+
+func (a *auditTotals) add(r *pageaudit.Report) {
+	if r == nil {
+		return
+	}
+	a.pages++
+	a.score += r.Score
+	if a.categories == nil {
+		a.categories = map[string][2]int{}
+	}
+	for cat, score := range r.Categories {
+		t := a.categories[cat]
+		a.categories[cat] = [2]int{t[0] + score, t[1] + 1}
+	}
+}
+
+// averages 回傳總分與各類別分數的平均；沒有報告時為 0 與 nil
+func (a *auditTotals) averages() (int, map[string]int) {
+	if a.pages == 0 {
+		return 0, nil
+	}
+	categories := make(map[string]int, len(a.categories))
+	for cat, t := range a.categories {
+		categories[cat] = int(math.Round(float64(t[0]) / float64(t[1])))
+	}
+	return int(math.Round(float64(a.score) / float64(a.pages))), categories
+}
@@ -0,0 +1,270 @@
+package crawler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/firehourse/cdpkit/domains"
+	"github.com/firehourse/cdpkit/tab"
+)
+
+// Pagination 自動翻頁：FetchAll 的每個網址視為列表的第一頁，依 NextSelector 或 URLTemplate
+// 走訪後續頁面，每頁產生一筆 Result。遇到下列情況即停止：找不到可點擊的下一頁、
+// 頁面載入失敗、擷取不到任何資料、內容與前一頁相同，或達到 MaxPages
+type Pagination struct {
+	// NextSelector 「下一頁」連結或按鈕；為連結時導航至其網址，否則點擊後等待新內容載入
+	NextSelector string
+	// URLTemplate 以頁碼產生後續頁面的網址，{page} 替換為頁碼、{url} 替換為第一頁網址，
+	// 例如 "{url}?page={page}"；與 NextSelector 擇一，同時設定時以 URLTemplate 為準
+	URLTemplate string
+	// Start 第一頁的頁碼，預設 1
+	Start int
+	// MaxPages 每個列表最多走訪的頁數，預設 10
+	MaxPages int
+	// Scroll 設定後每頁擷取前先以 Tab.ScrollToBottom 載入無限捲動的內容；
+	// 未設定 NextSelector 與 URLTemplate 時也適用於一般的 Fetch
+	Scroll *tab.ScrollOptions
+}
+
+// walks 是否需要翻頁
+func (p *Pagination) walks() bool {
+	return p != nil && (p.NextSelector != "" || p.URLTemplate != "")
+}
+
+func (p *Pagination) start() int {
+	if p.Start > 0 {
+		return p.Start
+	}
+	return 1
+}
+
+func (p *Pagination) maxPages() int {
+	if p.MaxPages > 0 {
+		return p.MaxPages
+	}
+	return 10
+}
+
+// pageURL 以範本產生第 page 頁的網址
+func (p *Pagination) pageURL(first string, page int) string {
+	return strings.NewReplacer("{url}", first, "{page}", strconv.Itoa(page)).Replace(p.URLTemplate)
+}
+
+// FetchPages 爬取列表的所有頁面；未設定 Options.Pagination 的翻頁方式時等同 Fetch。
+// 每筆結果的 Page 為頁碼；Drain 開始後回傳已完成的頁面與 ErrDraining
+func (c *Crawler) FetchPages(url string, jsScript string) ([]Result, error) {
+	p := c.options.Pagination
+	switch {
+	case !p.walks():
+		r, err := c.Fetch(url, jsScript)
+		return []Result{r}, err
+	case p.URLTemplate != "":
+		return c.fetchTemplatePages(url, jsScript)
+	default:
+		return c.fetchNextPages(url, jsScript)
+	}
+}
+
+// fetchTemplatePages 依 URLTemplate 逐頁以 Fetch 爬取，各頁使用獨立的分頁
+func (c *Crawler) fetchTemplatePages(first string, jsScript string) ([]Result, error) {
+	p := c.options.Pagination
+	var results []Result
+	prev := ""
+	for i := 0; i < p.maxPages(); i++ {
+		page := p.start() + i
+		pageURL := first
+		if i > 0 {
+			pageURL = p.pageURL(first, page)
+		}
+		r, err := c.Fetch(pageURL, jsScript)
+		if errors.Is(err, ErrDraining) {
+			return results, err
+		}
+		r.Page = page
+		key, empty := pageKey(r)
+		if i > 0 && key == prev {
+			c.logf(3, "第 %d 頁與前一頁相同，停止翻頁: %s", page, pageURL)
+			break
+		}
+		results = append(results, r)
+		if err != nil || r.Error != "" || r.ResponseCode >= 400 || empty {
+			break
+		}
+		prev = key
+	}
+	return results, nil
+}
+
+// fetchNextPages 在同一個分頁中依 NextSelector 翻頁，保留點擊翻頁所需的頁面狀態
+func (c *Crawler) fetchNextPages(first string, jsScript string) ([]Result, error) {
+	if !c.begin() {
+		return nil, ErrDraining
+	}
+	defer c.end()
+	if err := c.waitResume(); err != nil {
+		return nil, err
+	}
+
+	p := c.options.Pagination
+	page := p.start()
+	result := Result{URL: first, Page: page, Timestamp: time.Now(), Timings: &Timings{}}
+
+	host, ov := c.domainOverride(first)
+	if jsScript == "" {
+		jsScript = c.extractJS
+	}
+	if ov.Script != "" {
+		jsScript = ov.Script
+	}
+	release, err := c.gate.acquire(c.ctx, host, ov)
+	result.Timings.Queue = time.Since(result.Timestamp)
+	if err != nil {
+		result.Error = fmt.Sprintf("等待網域 %s 的速率限制: %v", host, err)
+		c.record(&result, err)
+		return []Result{result}, err
+	}
+	defer func() { release() }()
+
+	opened := time.Now()
+	pageTab, err := c.openTab(first, host, ov)
+	result.Timings.Open = time.Since(opened)
+	if err != nil {
+		result.Error = err.Error()
+		c.record(&result, err)
+		return []Result{result}, err
+	}
+	defer c.closeTab(pageTab, ov)
+
+	result, err = c.load(pageTab, result, jsScript, ov)
+	c.record(&result, err)
+	results := []Result{result}
+	prev, empty := pageKey(result)
+	seen := map[string]bool{first: true}
+
+	for err == nil && result.Error == "" && !empty && len(results) < p.maxPages() {
+		select {
+		case <-c.draining:
+			return results, ErrDraining
+		default:
+		}
+		ok, cerr := pageTab.Clickable(p.NextSelector)
+		if cerr != nil || !ok {
+			c.logf(4, "找不到可點擊的下一頁 %s，停止翻頁", p.NextSelector)
+			break
+		}
+
+		// 每一頁都遵守網域的速率限制
+		release()
+		if release, err = c.gate.acquire(c.ctx, host, ov); err != nil {
+			release = func() {}
+			break
+		}
+
+		page++
+		next := Result{URL: nextHref(pageTab, p.NextSelector), Page: page, Timestamp: time.Now()}
+		if next.URL != "" {
+			if seen[next.URL] {
+				c.logf(3, "下一頁 %s 已走訪過，停止翻頁", next.URL)
+				break
+			}
+			seen[next.URL] = true
+			result, err = c.load(pageTab, next, jsScript, ov)
+		} else {
+			result, err = c.clickNext(pageTab, next, jsScript, ov)
+		}
+
+		key, isEmpty := pageKey(result)
+		if err == nil && key == prev {
+			c.logf(3, "第 %d 頁與前一頁相同，停止翻頁", page)
+			break
+		}
+		c.record(&result, err)
+		results = append(results, result)
+		prev, empty = key, isEmpty
+	}
+	return results, nil
+}
+
+// clickNext 點擊下一頁按鈕，等待網路閒置後擷取；頁面可能以 XHR 更新而沒有導航
+func (c *Crawler) clickNext(pageTab *tab.Tab, result Result, jsScript string, ov domains.Override) (Result, error) {
+	startTime := time.Now()
+	result.Timings = &Timings{}
+	if err := pageTab.Click(c.options.Pagination.NextSelector); err != nil {
+		result.Error = fmt.Sprintf("點擊下一頁失敗: %v", err)
+		return result, err
+	}
+	if err := pageTab.WaitNetworkIdle(0, c.options.Timeout); err != nil {
+		c.logf(2, "警告: %v", err)
+	}
+	result.Timings.Navigate = time.Since(startTime)
+	settled := time.Now()
+	c.settle(pageTab, result.URL, ov)
+	result.Timings.Settle = time.Since(settled)
+	if href, err := pageTab.RunJS("location.href", c.options.Timeout); err == nil {
+		if s, ok := href.(string); ok {
+			result.URL = s
+		}
+	}
+	return c.extract(pageTab, result, jsScript, startTime), nil
+}
+
+// nextHref 回傳下一頁連結的絕對網址；不是連結或只是頁內錨點、javascript: 時回傳空字串
+func nextHref(pageTab *tab.Tab, selector string) string {
+	href, ok, err := pageTab.Attribute(selector, "href")
+	if err != nil || !ok || href == "" {
+		return ""
+	}
+	cur, err := pageTab.RunJS("location.href", 0)
+	if err != nil {
+		return ""
+	}
+	base, err := url.Parse(fmt.Sprint(cur))
+	if err != nil {
+		return ""
+	}
+	u, err := base.Parse(href)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	u.Fragment = ""
+	base.Fragment = ""
+	if u.String() == base.String() {
+		return ""
+	}
+	return u.String()
+}
+
+// pageKey 回傳用於比對重複頁面的內容鍵，以及頁面是否沒有擷取到任何資料。
+// 沒有執行擷取腳本（Data 為 nil）時以網址與 HTML 比對，不視為空頁
+func pageKey(r Result) (string, bool) {
+	if r.Data == nil {
+		return r.URL + "\n" + r.HTML, false
+	}
+	b, _ := json.Marshal(r.Data)
+	return string(b), isEmptyValue(r.Data)
+}
+
+// isEmptyValue 判斷擷取結果是否為空：nil、空字串、空陣列，或所有欄位皆為空的物件
+func isEmptyValue(v interface{}) bool {
+	switch x := v.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(x) == ""
+	case []interface{}:
+		return len(x) == 0
+	case map[string]interface{}:
+		for _, item := range x {
+			if !isEmptyValue(item) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
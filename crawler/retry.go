@@ -0,0 +1,177 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/firehourse/cdpkit/browser"
+	"github.com/firehourse/cdpkit/tab"
+)
+
+// ErrorClass 失敗原因的分類，用於 Retry.RetryOn
+type ErrorClass string
+
+const (
+	// ErrorTimeout 導航或腳本逾時
+	ErrorTimeout ErrorClass = "timeout"
+	// ErrorNetwork Chrome 的網路錯誤（net::ERR_ 開頭，DNS、代理、TLS 與逾時除外）
+	ErrorNetwork ErrorClass = "network"
+	// ErrorDNS 網域名稱無法解析
+	ErrorDNS ErrorClass = "dns"
+	// ErrorProxy 代理連線或驗證失敗
+	ErrorProxy ErrorClass = "proxy"
+	// ErrorTLS 憑證無效或 TLS 交握失敗；通常重試也無法成功，預設不重試
+	ErrorTLS ErrorClass = "tls"
+	// ErrorServer 主文件回應 5xx
+	ErrorServer ErrorClass = "5xx"
+	// ErrorRateLimited 主文件回應 429
+	ErrorRateLimited ErrorClass = "429"
+	// ErrorCrashed 分頁崩潰、渲染程序無回應或瀏覽器重置
+	ErrorCrashed ErrorClass = "crashed"
+	// ErrorOther 其他失敗，例如擷取腳本錯誤、4xx 或超過流量預算；通常重試也無法成功
+	ErrorOther ErrorClass = "other"
+)
+
+// Retry 暫時性失敗的自動重試
+type Retry struct {
+	// MaxAttempts 每個網址最多嘗試的次數（含第一次），預設 3
+	MaxAttempts int
+	// Backoff 第一次重試前的等待時間，之後每次加倍，預設 1 秒
+	Backoff time.Duration
+	// MaxBackoff 單次等待的上限，預設 30 秒
+	MaxBackoff time.Duration
+	// RetryOn 需要重試的失敗分類，預設 timeout、network、dns、proxy、5xx、429、crashed
+	RetryOn []ErrorClass
+}
+
+// defaultRetryOn Retry.RetryOn 的預設值
+var defaultRetryOn = []ErrorClass{ErrorTimeout, ErrorNetwork, ErrorDNS, ErrorProxy, ErrorServer, ErrorRateLimited, ErrorCrashed}
+
+// Classify 判斷一次爬取的失敗分類；成功時回傳空字串
+func Classify(r Result, err error) ErrorClass {
+	switch {
+	case r.ResponseCode == 429:
+		return ErrorRateLimited
+	case r.ResponseCode >= 500:
+		return ErrorServer
+	}
+	if err == nil && r.Error == "" {
+		return ""
+	}
+	// 預算用盡時阻擋的請求也是 net::ERR_ 錯誤，重試只會再次失敗
+	if errors.Is(err, ErrBandwidthExceeded) || r.Truncated {
+		return ErrorOther
+	}
+	if errors.Is(err, browser.ErrTabInvalidated) || errors.Is(err, tab.ErrRendererHung) {
+		return ErrorCrashed
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorTimeout
+	}
+	msg := r.Error
+	if err != nil {
+		msg = err.Error() + " " + msg
+	}
+	// 結果可能來自檢查點或 Hooks，只剩錯誤訊息時從中解析 net::ERR_ 代碼
+	var ne *tab.NetError
+	if !errors.As(err, &ne) {
+		ne = tab.ParseNetError(msg)
+	}
+	if ne != nil {
+		switch ne.Kind() {
+		case tab.ErrProxy:
+			return ErrorProxy
+		case tab.ErrDNS:
+			return ErrorDNS
+		case tab.ErrTLS:
+			return ErrorTLS
+		case tab.ErrNetTimeout:
+			return ErrorTimeout
+		}
+		return ErrorNetwork
+	}
+	switch {
+	case strings.Contains(msg, "context deadline exceeded") || strings.Contains(msg, "逾時"):
+		return ErrorTimeout
+	case strings.Contains(msg, browser.ErrTabInvalidated.Error()) || strings.Contains(msg, tab.ErrRendererHung.Error()) ||
+		strings.Contains(msg, "target closed") || strings.Contains(msg, "crashed"):
+		return ErrorCrashed
+	}
+	return ErrorOther
+}
+
+// ParseErrorClasses 解析以逗號分隔的失敗分類，例如 "timeout,5xx"
+func ParseErrorClasses(s string) ([]ErrorClass, error) {
+	var classes []ErrorClass
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		switch c := ErrorClass(name); c {
+		case ErrorTimeout, ErrorNetwork, ErrorDNS, ErrorProxy, ErrorTLS, ErrorServer, ErrorRateLimited, ErrorCrashed, ErrorOther:
+			classes = append(classes, c)
+		default:
+			return nil, fmt.Errorf("未知的失敗分類 %q", name)
+		}
+	}
+	return classes, nil
+}
+
+// ----------------- 內部實作 -----------------
+
+// retryDelay 判斷第 attempt 次爬取後是否重試並回傳等待時間；
+// 先依 Options.Retry 的失敗分類，再依 Hooks 的重試條件
+func (c *Crawler) retryDelay(r Result, err error, attempt int) (time.Duration, bool) {
+	if p := c.options.Retry; p != nil && attempt < p.MaxAttempts {
+		if class := Classify(r, err); class != "" && p.retries(class) {
+			return p.backoff(attempt), true
+		}
+	}
+	if c.options.Hooks.shouldRetry(r, attempt) {
+		return c.options.Hooks.delay, true
+	}
+	return 0, false
+}
+
+// withDefaults 補上未設定的欄位
+func (r *Retry) withDefaults() *Retry {
+	p := *r
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = 3
+	}
+	if p.Backoff <= 0 {
+		p.Backoff = time.Second
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = 30 * time.Second
+	}
+	if len(p.RetryOn) == 0 {
+		p.RetryOn = defaultRetryOn
+	}
+	return &p
+}
+
+func (p *Retry) retries(class ErrorClass) bool {
+	for _, c := range p.RetryOn {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff 第 attempt 次失敗後的等待時間：Backoff × 2^(attempt-1)，不超過 MaxBackoff
+func (p *Retry) backoff(attempt int) time.Duration {
+	d := p.Backoff
+	for i := 1; i < attempt && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	return d
+}
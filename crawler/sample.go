@@ -0,0 +1,76 @@
+package crawler
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/firehourse/cdpkit/tab"
+)
+
+// ----------------- 內部實作 -----------------
+
+// sample 判斷網址是否抽中品質稽核；SampleByURL 時以網址雜湊決定，同一網址每次都有相同結果，
+// 否則依 Seed 決定的亂數序列抽樣
+func (c *Crawler) sample(url string) bool {
+	rate := c.options.SampleRate
+	switch {
+	case rate <= 0:
+		return false
+	case rate >= 1:
+		return true
+	case c.options.SampleByURL:
+		sum := sha256.Sum256([]byte(url))
+		return float64(binary.BigEndian.Uint64(sum[:8]))/float64(^uint64(0)) < rate
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rng.Float64() < rate
+}
+
+// captureSample 為抽中的頁面補上完整 HTML、整頁截圖與 HAR，並在設定 SampleDir 時寫入檔案
+func (c *Crawler) captureSample(pageTab *tab.Tab, result *Result) {
+	if result.HTML == "" {
+		if html, err := pageTab.HTML(c.options.Timeout); err == nil {
+			result.HTML = html
+		}
+	}
+	if result.Screenshot == nil {
+		if png, err := pageTab.ScreenshotWith(c.screenshotOptions()); err != nil {
+			c.logf(2, "警告: %v", err)
+		} else {
+			result.Screenshot = png
+		}
+	}
+	har, err := pageTab.HAR()
+	if err != nil {
+		c.logf(2, "警告: %v", err)
+	} else {
+		result.HAR = har
+	}
+	if c.options.SampleDir != "" {
+		c.saveSample(result)
+	}
+}
+
+// saveSample 將抽樣資料寫入 SampleDir，檔名為網址雜湊加上爬取時間
+func (c *Crawler) saveSample(result *Result) {
+	if err := os.MkdirAll(c.options.SampleDir, 0755); err != nil {
+		c.logf(2, "警告: 無法建立抽樣目錄: %v", err)
+		return
+	}
+	sum := sha256.Sum256([]byte(result.URL))
+	base := filepath.Join(c.options.SampleDir, fmt.Sprintf("%x-%s", sum[:8], result.Timestamp.Format("20060102-150405")))
+	files := map[string][]byte{".html": []byte(result.HTML), ".png": result.Screenshot, ".har": result.HAR}
+	for ext, data := range files {
+		if len(data) == 0 {
+			continue
+		}
+		if err := os.WriteFile(base+ext, data, 0644); err != nil {
+			c.logf(2, "警告: 寫入抽樣資料失敗: %v", err)
+		}
+	}
+	c.logf(4, "抽樣 %s 已保存到 %s.*", result.URL, base)
+}
@@ -0,0 +1,240 @@
+package crawler
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/firehourse/cdpkit/tab"
+	"golang.org/x/net/publicsuffix"
+)
+
+// 合規掃描的規則名稱，記錄於 Finding.Rule 與 Summary.Findings
+const (
+	// RuleMixedContent HTTPS 頁面載入 HTTP 資源
+	RuleMixedContent = "mixed-content"
+	// RuleMissingHeader 主文件缺少安全性標頭
+	RuleMissingHeader = "missing-header"
+	// RuleThirdParty 頁面連線到允許清單外的第三方網域
+	RuleThirdParty = "third-party"
+	// RuleCookieCount 頁面設定的 cookies 超過上限
+	RuleCookieCount = "cookie-count"
+	// RuleCookieFlags HTTPS 頁面的 cookie 缺少 Secure、HttpOnly 或 SameSite
+	RuleCookieFlags = "cookie-flags"
+)
+
+// 發現的嚴重程度
+const (
+	SeverityHigh   = "high"
+	SeverityMedium = "medium"
+	SeverityLow    = "low"
+	SeverityInfo   = "info"
+)
+
+// Scan 合規掃描的設定：以頁面載入時擷取的回應與 cookies 檢查混合內容、安全性標頭、第三方網域與 cookie 屬性，
+// 結果記錄於 Result.Findings。零值檢查混合內容、預設的安全性標頭與 cookie 屬性，第三方網域只列為 info
+type Scan struct {
+	// RequiredHeaders 主文件必須帶有的回應標頭，預設為 DefaultRequiredHeaders；
+	// Strict-Transport-Security 只在 HTTPS 頁面檢查
+	RequiredHeaders []string
+	// AllowedThirdParties 允許連線的第三方網域，"*.example.com" 同時比對主網域與子網域；
+	// 設定後清單外的網域為 medium，未設定時所有第三方網域只列為 info
+	AllowedThirdParties []string
+	// MaxCookies 頁面可見的 cookies 上限，0 表示不檢查
+	MaxCookies int
+	// SkipCookieFlags 不檢查 cookie 的 Secure、HttpOnly 與 SameSite
+	SkipCookieFlags bool
+}
+
+// DefaultRequiredHeaders Scan 預設檢查的安全性標頭
+var DefaultRequiredHeaders = []string{
+	"Strict-Transport-Security",
+	"Content-Security-Policy",
+	"X-Content-Type-Options",
+	"X-Frame-Options",
+	"Referrer-Policy",
+}
+
+// Finding 合規掃描在頁面上發現的一個問題
+type Finding struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	// Subject 問題的對象，例如資源網址、標頭、網域或 cookie 名稱
+	Subject string `json:"subject,omitempty"`
+}
+
+// ----------------- 內部實作 -----------------
+
+// scanPage 以分頁記錄的回應與 cookies 檢查 docURL 頁面，結果存入 result.Findings
+func (c *Crawler) scanPage(pageTab *tab.Tab, result *Result, docURL string, doc *tab.Response) {
+	s := c.options.Scan
+	if s == nil {
+		return
+	}
+	page, err := url.Parse(docURL)
+	if err != nil {
+		return
+	}
+	secure := page.Scheme == "https"
+	pageSite := registrableDomain(page.Hostname())
+	responses := pageTab.Responses()
+
+	var findings []Finding
+	add := func(rule, severity, subject, format string, args ...interface{}) {
+		findings = append(findings, Finding{Rule: rule, Severity: severity, Subject: subject, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// 混合內容：主動內容（腳本、樣式、iframe、XHR）可竄改頁面，其餘為被動內容
+	if secure {
+		seen := map[string]bool{}
+		for _, r := range responses {
+			if !strings.HasPrefix(r.URL, "http://") || seen[r.URL] || isLoopbackURL(r.URL) {
+				continue
+			}
+			seen[r.URL] = true
+			severity := SeverityMedium
+			switch r.ResourceType {
+			case network.ResourceTypeScript, network.ResourceTypeStylesheet, network.ResourceTypeDocument,
+				network.ResourceTypeXHR, network.ResourceTypeFetch, network.ResourceTypeWebSocket:
+				severity = SeverityHigh
+			}
+			add(RuleMixedContent, severity, r.URL, "HTTPS 頁面載入 HTTP 資源（%s）", strings.ToLower(string(r.ResourceType)))
+		}
+	}
+
+	// 安全性標頭
+	if doc != nil {
+		required := s.RequiredHeaders
+		if required == nil {
+			required = DefaultRequiredHeaders
+		}
+		for _, h := range required {
+			if strings.EqualFold(h, "Strict-Transport-Security") && !secure {
+				continue
+			}
+			if headerValue(doc.Headers, h) != "" {
+				continue
+			}
+			// CSP 的 frame-ancestors 可取代 X-Frame-Options
+			if strings.EqualFold(h, "X-Frame-Options") &&
+				strings.Contains(strings.ToLower(headerValue(doc.Headers, "Content-Security-Policy")), "frame-ancestors") {
+				continue
+			}
+			add(RuleMissingHeader, SeverityMedium, h, "主文件缺少 %s 標頭", h)
+		}
+	}
+
+	// 第三方網域
+	thirdParty := map[string]int{}
+	for _, r := range responses {
+		u, err := url.Parse(r.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "wss" && u.Scheme != "ws") {
+			continue
+		}
+		if site := registrableDomain(u.Hostname()); site != pageSite {
+			thirdParty[site]++
+		}
+	}
+	sites := make([]string, 0, len(thirdParty))
+	for site := range thirdParty {
+		sites = append(sites, site)
+	}
+	sort.Strings(sites)
+	for _, site := range sites {
+		switch {
+		case s.AllowedThirdParties == nil:
+			add(RuleThirdParty, SeverityInfo, site, "連線到第三方網域 %s（%d 個請求）", site, thirdParty[site])
+		case !matchDomains(s.AllowedThirdParties, site):
+			add(RuleThirdParty, SeverityMedium, site, "連線到允許清單外的第三方網域 %s（%d 個請求）", site, thirdParty[site])
+		}
+	}
+
+	// cookies
+	if s.MaxCookies > 0 || (!s.SkipCookieFlags && secure) {
+		cookies, err := pageTab.Cookies()
+		if err != nil {
+			c.logf(2, "警告: 無法取得 %s 的 cookies: %v", docURL, err)
+		}
+		if s.MaxCookies > 0 && len(cookies) > s.MaxCookies {
+			add(RuleCookieCount, SeverityLow, "", "頁面有 %d 個 cookies，超過上限 %d", len(cookies), s.MaxCookies)
+		}
+		if !s.SkipCookieFlags && secure {
+			for _, ck := range cookies {
+				subject := ck.Name + "@" + strings.TrimPrefix(ck.Domain, ".")
+				if !ck.Secure {
+					add(RuleCookieFlags, SeverityMedium, subject, "cookie %s 未設定 Secure", ck.Name)
+				}
+				if !ck.HTTPOnly {
+					add(RuleCookieFlags, SeverityLow, subject, "cookie %s 未設定 HttpOnly", ck.Name)
+				}
+				if ck.SameSite == "" {
+					add(RuleCookieFlags, SeverityLow, subject, "cookie %s 未設定 SameSite", ck.Name)
+				}
+			}
+		}
+	}
+
+	result.Findings = findings
+	if len(findings) == 0 {
+		return
+	}
+	c.mu.Lock()
+	if c.findings == nil {
+		c.findings = map[string]int{}
+	}
+	for _, f := range findings {
+		c.findings[f.Rule]++
+	}
+	c.mu.Unlock()
+}
+
+// headerValue 不分大小寫取得標頭值；HTTP/2 的標頭名稱為小寫
+func headerValue(headers map[string]interface{}, name string) string {
+	for k, v := range headers {
+		if strings.EqualFold(k, name) {
+			return fmt.Sprintf("%v", v)
+		}
+	}
+	return ""
+}
+
+// registrableDomain 回傳 host 的可註冊網域（eTLD+1）；IP 或無法判斷時回傳 host 本身
+func registrableDomain(host string) string {
+	host = strings.ToLower(host)
+	if net.ParseIP(host) != nil {
+		return host
+	}
+	if site, err := publicsuffix.EffectiveTLDPlusOne(host); err == nil {
+		return site
+	}
+	return host
+}
+
+// matchDomains 判斷 domain 是否符合清單中的網域；"*.example.com" 同時比對主網域與子網域
+func matchDomains(patterns []string, domain string) bool {
+	for _, p := range patterns {
+		p = strings.ToLower(strings.TrimSpace(p))
+		base := strings.TrimPrefix(p, "*.")
+		if domain == base || (base != p && strings.HasSuffix(domain, "."+base)) {
+			return true
+		}
+	}
+	return false
+}
+
+func isLoopbackURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,349 @@
+package crawler
+
+import (
+	"container/heap"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Priority 排程優先度，數值越大越先處理
+type Priority int
+
+const (
+	PriorityLow    Priority = -10
+	PriorityNormal Priority = 0
+	PriorityHigh   Priority = 10
+)
+
+// ErrDeadline 網址輪到處理時已超過 Meta.Deadline，未爬取
+var ErrDeadline = errors.New("已超過排程截止時間，未爬取")
+
+// Meta Enqueue 的附加資訊
+type Meta struct {
+	// Deadline 輪到處理時已超過此時間則不再爬取，以 ErrDeadline 的結果回報；
+	// 相同優先度時截止時間較早的先處理。零值表示不限
+	Deadline time.Time
+	// Script 此網址的擷取腳本；留空使用 Run 的 jsScript
+	Script string
+	// Tags 呼叫端自訂的標記，原樣附在 Result.Tags，例如來源工作或租戶
+	Tags map[string]string
+	// Type 網址的種類；RequestJSON 以 FetchJSON 取得，不經導航與渲染
+	Type RequestType
+	// Finished 此網址的所有結果都交給 fn 之後呼叫，結果被 Hooks 或 Transforms 捨棄、
+	// 或翻頁產生多筆結果時也只呼叫一次；因 Drain 或關閉而未完整處理時 ok 為 false
+	Finished func(ok bool)
+}
+
+// Enqueue 將網址排入爬蟲的排程佇列，由 Run 依優先度處理；Run 執行中（包括在 fn 中）加入的網址也會被處理。
+// 已開始 Drain 時回傳 ErrDraining
+func (c *Crawler) Enqueue(url string, p Priority, meta Meta) error {
+	select {
+	case <-c.draining:
+		return ErrDraining
+	default:
+	}
+	c.queue.push(c.schedItem(url, p, meta))
+	return nil
+}
+
+// Queued 回傳 Enqueue 排入但尚未開始處理的網址數
+func (c *Crawler) Queued() int {
+	return c.queue.len()
+}
+
+// Run 以 Concurrency 個工作者處理 Enqueue 排入的網址：優先度高的先處理，相同優先度時依截止時間與加入順序；
+// 各 host 輪流派發，且不派發給已達同時處理上限（PerHostConcurrency 或網域設定）的 host，避免單一網站占滿所有工作者。
+// fn 同 FetchAllFunc。佇列清空且沒有處理中的網址時回傳；Drain 時回傳 ErrDraining，未處理的網址保留在佇列
+func (c *Crawler) Run(jsScript string, fn func(Result)) error {
+	if c.process(c.queue, jsScript, fn, nil) {
+		return ErrDraining
+	}
+	return nil
+}
+
+// ----------------- 內部實作 -----------------
+
+// schedItem 排程佇列中的一個網址
+type schedItem struct {
+	url      string
+	host     string
+	priority Priority
+	meta     Meta
+	seq      int64
+	// limit host 的同時處理上限，0 表示不限
+	limit int
+}
+
+// schedItem 以網域設定決定 host 與同時處理上限
+func (c *Crawler) schedItem(url string, p Priority, meta Meta) *schedItem {
+	host, ov := c.domainOverride(url)
+	limit := ov.Concurrency
+	if limit <= 0 {
+		limit = c.options.PerHostConcurrency
+	}
+	return &schedItem{url: url, host: host, priority: p, meta: meta, limit: limit}
+}
+
+// before 排序：優先度高、截止時間早（零值最晚）、加入早的先處理
+func (a *schedItem) before(b *schedItem) bool {
+	if a.priority != b.priority {
+		return a.priority > b.priority
+	}
+	if !a.meta.Deadline.Equal(b.meta.Deadline) {
+		if a.meta.Deadline.IsZero() || b.meta.Deadline.IsZero() {
+			return b.meta.Deadline.IsZero()
+		}
+		return a.meta.Deadline.Before(b.meta.Deadline)
+	}
+	return a.seq < b.seq
+}
+
+type schedHeap []*schedItem
+
+func (h schedHeap) Len() int            { return len(h) }
+func (h schedHeap) Less(i, j int) bool  { return h[i].before(h[j]) }
+func (h schedHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *schedHeap) Push(x interface{}) { *h = append(*h, x.(*schedItem)) }
+func (h *schedHeap) Pop() interface{} {
+	old := *h
+	it := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return it
+}
+
+// hostQueue 一個 host 的待處理網址與處理中的數量
+type hostQueue struct {
+	items    schedHeap
+	inflight int
+	// served 最近一次派發時的序號，相同優先度時最久沒輪到的 host 先派發
+	served int64
+}
+
+// scheduler 依 host 分組的優先佇列：每次派發從未達上限的 host 中挑選開頭最優先的網址，
+// 相同時輪流派發給各 host。佇列暫時為空但仍有網址處理中時，next 等待其完成或加入新的網址
+type scheduler struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	hosts   map[string]*hostQueue
+	seq     int64
+	served  int64
+	queued  int
+	active  int
+	stopped bool
+}
+
+func newScheduler() *scheduler {
+	s := &scheduler{hosts: map[string]*hostQueue{}}
+	s.cond = sync.NewCond(&s.mu)
+	return s
+}
+
+func (s *scheduler) push(it *schedItem) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	it.seq = s.seq
+	q := s.hosts[it.host]
+	if q == nil {
+		q = &hostQueue{}
+		s.hosts[it.host] = q
+	}
+	heap.Push(&q.items, it)
+	s.queued++
+	s.cond.Broadcast()
+}
+
+func (s *scheduler) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.queued
+}
+
+// next 取出下一個網址；已停止，或佇列清空且沒有處理中的網址時回傳 false
+func (s *scheduler) next() (*schedItem, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for {
+		if s.stopped {
+			return nil, false
+		}
+		var best *hostQueue
+		for _, q := range s.hosts {
+			if len(q.items) == 0 || (q.items[0].limit > 0 && q.inflight >= q.items[0].limit) {
+				continue
+			}
+			if best == nil || q.ahead(best) {
+				best = q
+			}
+		}
+		if best != nil {
+			it := heap.Pop(&best.items).(*schedItem)
+			s.served++
+			best.served = s.served
+			best.inflight++
+			s.queued--
+			s.active++
+			return it, true
+		}
+		if s.queued == 0 && s.active == 0 {
+			return nil, false
+		}
+		s.cond.Wait()
+	}
+}
+
+// ahead q 開頭的網址是否應先於 o 派發；優先度與截止時間相同時，最久沒輪到的 host 先派發
+func (q *hostQueue) ahead(o *hostQueue) bool {
+	a, b := q.items[0], o.items[0]
+	if a.priority != b.priority || !a.meta.Deadline.Equal(b.meta.Deadline) {
+		return a.before(b)
+	}
+	if q.served != o.served {
+		return q.served < o.served
+	}
+	return a.seq < b.seq
+}
+
+// done 結束一個處理中的網址
+func (s *scheduler) done(it *schedItem) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if q := s.hosts[it.host]; q != nil {
+		q.inflight--
+		if q.inflight == 0 && len(q.items) == 0 {
+			delete(s.hosts, it.host)
+		}
+	}
+	s.active--
+	s.cond.Broadcast()
+}
+
+// stop 停止派發，等待中的工作者隨即結束；排隊中的網址保留
+func (s *scheduler) stop() {
+	s.mu.Lock()
+	s.stopped = true
+	s.mu.Unlock()
+	s.cond.Broadcast()
+}
+
+// process 以 Concurrency 個工作者處理 q 中的網址，結果在呼叫端的 goroutine 中套用 Hooks 與 Transforms 後交給 fn；
+// finished 不為 nil 時，每個網址的結果都交給 fn 之後呼叫，完整處理（未因 Drain 或關閉中斷）時 ok 為 true。
+// 回傳是否因 Drain 而有網址未處理
+func (c *Crawler) process(q *scheduler, jsScript string, fn func(Result), finished func(url string, ok bool)) bool {
+	resultCh := make(chan fetched, c.options.Concurrency)
+
+	// drained 記錄是否因 Drain 而有網址未處理
+	var drained atomic.Bool
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-c.draining:
+			drained.Store(true)
+			q.stop()
+		case <-c.ctx.Done():
+			q.stop()
+		case <-stop:
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < c.options.Concurrency; i++ {
+		wg.Add(1)
+		go func(workerID int) {
+			defer wg.Done()
+			for {
+				it, ok := q.next()
+				if !ok {
+					return
+				}
+				resultCh <- c.processItem(workerID, it, jsScript, &drained)
+			}
+		}(i + 1)
+	}
+	go func() {
+		wg.Wait()
+		close(stop)
+		close(resultCh)
+	}()
+
+	pipeline := c.options.Transforms
+	if c.options.Hooks != nil {
+		pipeline = append(Pipeline{c.options.Hooks.Transformer()}, pipeline...)
+	}
+	for f := range resultCh {
+		for _, result := range f.pages {
+			if pipeline.apply(&result) {
+				fn(result)
+			}
+		}
+		if finished != nil {
+			finished(f.url, f.ok)
+		}
+		if f.item.meta.Finished != nil {
+			f.item.meta.Finished(f.ok)
+		}
+		// 結果交給 fn 之後才結束該網址，fn 依最後一筆結果 Enqueue 的網址也會在工作者結束前派發
+		q.done(f.item)
+	}
+
+	if c.incr != nil {
+		if err := c.incr.save(); err != nil {
+			c.logf(1, "%v", err)
+		}
+	}
+	return drained.Load()
+}
+
+// fetched 一個網址的所有結果；ok 為 false 時（Drain 或關閉中斷）不計入進度
+type fetched struct {
+	url   string
+	item  *schedItem
+	pages []Result
+	ok    bool
+}
+
+// processItem 爬取一個網址（含翻頁）；輪到時已超過截止時間則不爬取
+func (c *Crawler) processItem(workerID int, it *schedItem, jsScript string, drained *atomic.Bool) fetched {
+	f := fetched{url: it.url, item: it}
+	if !it.meta.Deadline.IsZero() && time.Now().After(it.meta.Deadline) {
+		c.logf(2, "工作者 %d: %s 已超過截止時間 %s，略過", workerID, it.url, it.meta.Deadline.Format(time.RFC3339))
+		f.pages = []Result{{URL: it.url, Error: ErrDeadline.Error(), Tags: it.meta.Tags, Timestamp: time.Now()}}
+		f.ok = true
+		return f
+	}
+	if it.meta.Script != "" {
+		jsScript = it.meta.Script
+	}
+
+	c.logf(3, "工作者 %d: 開始處理 %s", workerID, it.url)
+	var pages []Result
+	var err error
+	if it.meta.Type == RequestJSON {
+		var r Result
+		r, err = c.FetchJSON(it.url)
+		pages = []Result{r}
+	} else {
+		pages, err = c.FetchPages(it.url, jsScript)
+	}
+	f.ok = c.ctx.Err() == nil
+	if errors.Is(err, ErrDraining) {
+		drained.Store(true)
+		f.ok = false
+	} else if err != nil {
+		c.logf(2, "工作者 %d: 爬取 %s 失敗: %v", workerID, it.url, err)
+	} else {
+		c.logf(3, "工作者 %d: 成功爬取 %s", workerID, it.url)
+	}
+	for _, result := range pages {
+		if result.Error == ErrDraining.Error() {
+			f.ok = false
+			continue
+		}
+		result.Tags = it.meta.Tags
+		f.pages = append(f.pages, result)
+	}
+	return f
+}
@@ -0,0 +1,95 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// newQueueCrawler 建立只用於排程的 Crawler，不啟動瀏覽器；已逾期的網址不會爬取，直接回報 ErrDeadline
+func newQueueCrawler(concurrency int) *Crawler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Crawler{
+		options:  Options{Concurrency: concurrency},
+		ctx:      ctx,
+		cancel:   cancel,
+		queue:    newScheduler(),
+		draining: make(chan struct{}),
+	}
+}
+
+func TestRunProcessesURLsEnqueuedFromLastResult(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		c := newQueueCrawler(4)
+		expired := Meta{Deadline: time.Now().Add(-time.Minute)}
+		if err := c.Enqueue("https://example.com/0", PriorityNormal, expired); err != nil {
+			t.Fatal(err)
+		}
+
+		var got []string
+		err := c.Run("", func(r Result) {
+			got = append(got, r.URL)
+			// 拖慢 fn，讓工作者有時間在 Enqueue 之前檢查佇列
+			time.Sleep(5 * time.Millisecond)
+			if len(got) < 3 {
+				c.Enqueue(fmt.Sprintf("https://example.com/%d", len(got)), PriorityNormal, expired)
+			}
+		})
+		c.cancel()
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		if len(got) != 3 || c.Queued() != 0 {
+			t.Fatalf("第 %d 次：處理了 %v，佇列剩 %d 個網址", i, got, c.Queued())
+		}
+	}
+}
+
+func TestRunReportsDeadline(t *testing.T) {
+	c := newQueueCrawler(2)
+	defer c.cancel()
+	c.Enqueue("https://example.com/", PriorityNormal, Meta{
+		Deadline: time.Now().Add(-time.Second),
+		Tags:     map[string]string{"tenant": "a"},
+	})
+
+	var got []Result
+	if err := c.Run("", func(r Result) { got = append(got, r) }); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(got) != 1 || got[0].Error != ErrDeadline.Error() || got[0].Tags["tenant"] != "a" {
+		t.Fatalf("結果 %+v", got)
+	}
+}
+
+// 結果被 Transforms 捨棄時 Meta.Finished 仍在處理完畢後呼叫一次
+func TestRunCallsFinishedForDroppedResults(t *testing.T) {
+	c := newQueueCrawler(2)
+	defer c.cancel()
+	c.options.Transforms = Pipeline{func(r *Result) bool { return r.URL != "https://example.com/drop" }}
+
+	finished := map[string]int{}
+	for _, u := range []string{"https://example.com/keep", "https://example.com/drop"} {
+		u := u
+		c.Enqueue(u, PriorityNormal, Meta{
+			Deadline: time.Now().Add(-time.Second),
+			Finished: func(ok bool) {
+				if !ok {
+					t.Errorf("%s 的 ok 為 false", u)
+				}
+				finished[u]++
+			},
+		})
+	}
+	var got []string
+	if err := c.Run("", func(r Result) { got = append(got, r.URL) }); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(got) != 1 || got[0] != "https://example.com/keep" {
+		t.Errorf("交給 fn 的結果為 %v", got)
+	}
+	if finished["https://example.com/keep"] != 1 || finished["https://example.com/drop"] != 1 {
+		t.Errorf("Finished 呼叫次數為 %v，每個網址應各一次", finished)
+	}
+}
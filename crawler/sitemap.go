@@ -0,0 +1,166 @@
+package crawler
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SitemapOptions FetchSitemap 的選項
+type SitemapOptions struct {
+	// Client 下載 sitemap 的 HTTP 用戶端，例如設定代理；nil 時使用逾時 30 秒的預設用戶端
+	Client *http.Client
+	// UserAgent 請求的 User-Agent；留空使用 Go 的預設值
+	UserAgent string
+	// Limit 最多回傳的網址數，0 表示不限
+	Limit int
+	// MaxDepth sitemap index 巢狀展開的層數上限，預設 3
+	MaxDepth int
+}
+
+// FetchSitemap 下載 sitemap 並回傳其中的頁面網址，依出現順序並去除重複；sitemap index 會遞迴展開所列的 sitemap，
+// gzip 壓縮（.xml.gz）與每行一個網址的純文字 sitemap 皆可。展開時個別 sitemap 失敗只略過，全部失敗才回傳錯誤
+func FetchSitemap(ctx context.Context, sitemapURL string, opts SitemapOptions) ([]string, error) {
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 30 * time.Second}
+	}
+	if opts.MaxDepth <= 0 {
+		opts.MaxDepth = 3
+	}
+	s := &sitemapWalker{opts: opts, seen: map[string]bool{}, visited: map[string]bool{}}
+	if err := s.walk(ctx, sitemapURL, 0); err != nil && len(s.urls) == 0 {
+		return nil, err
+	}
+	return s.urls, nil
+}
+
+// ----------------- 內部實作 -----------------
+
+// sitemapDoc urlset 與 sitemapindex 共用的結構
+type sitemapDoc struct {
+	URLs []struct {
+		Loc string `xml:"loc"`
+	} `xml:"url"`
+	Sitemaps []struct {
+		Loc string `xml:"loc"`
+	} `xml:"sitemap"`
+}
+
+type sitemapWalker struct {
+	opts SitemapOptions
+	urls []string
+	// seen 已收集的頁面網址；visited 已下載的 sitemap，避免互相引用時無限展開
+	seen    map[string]bool
+	visited map[string]bool
+}
+
+func (s *sitemapWalker) full() bool {
+	return s.opts.Limit > 0 && len(s.urls) >= s.opts.Limit
+}
+
+func (s *sitemapWalker) walk(ctx context.Context, sitemapURL string, depth int) error {
+	if s.visited[sitemapURL] || s.full() {
+		return nil
+	}
+	s.visited[sitemapURL] = true
+	body, err := s.get(ctx, sitemapURL)
+	if err != nil {
+		return err
+	}
+
+	trimmed := strings.TrimSpace(string(body))
+	if !strings.HasPrefix(trimmed, "<") {
+		// 純文字 sitemap：每行一個網址
+		sc := bufio.NewScanner(strings.NewReader(trimmed))
+		for sc.Scan() && !s.full() {
+			s.add(sc.Text())
+		}
+		return nil
+	}
+
+	var doc sitemapDoc
+	if err := xml.Unmarshal(body, &doc); err != nil {
+		return fmt.Errorf("無法解析 sitemap %s: %w", sitemapURL, err)
+	}
+	for _, u := range doc.URLs {
+		if s.full() {
+			return nil
+		}
+		s.add(u.Loc)
+	}
+	if len(doc.Sitemaps) == 0 {
+		return nil
+	}
+	if depth+1 > s.opts.MaxDepth {
+		return fmt.Errorf("sitemap index %s 超過 %d 層，不再展開", sitemapURL, s.opts.MaxDepth)
+	}
+	var firstErr error
+	ok := false
+	for _, sm := range doc.Sitemaps {
+		if err := s.walk(ctx, strings.TrimSpace(sm.Loc), depth+1); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		ok = true
+	}
+	if !ok {
+		return firstErr
+	}
+	return nil
+}
+
+func (s *sitemapWalker) add(loc string) {
+	loc = strings.TrimSpace(loc)
+	if loc == "" || s.seen[loc] {
+		return
+	}
+	if !strings.HasPrefix(loc, "http://") && !strings.HasPrefix(loc, "https://") {
+		return
+	}
+	s.seen[loc] = true
+	s.urls = append(s.urls, loc)
+}
+
+// get 下載 sitemap，依內容而非副檔名判斷是否為 gzip（伺服器可能已自動解壓）
+func (s *sitemapWalker) get(ctx context.Context, sitemapURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, sitemapURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("無效的 sitemap 網址 %s: %w", sitemapURL, err)
+	}
+	if s.opts.UserAgent != "" {
+		req.Header.Set("User-Agent", s.opts.UserAgent)
+	}
+	resp, err := s.opts.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("下載 sitemap %s 失敗: %w", sitemapURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("下載 sitemap %s 失敗: HTTP %d", sitemapURL, resp.StatusCode)
+	}
+
+	r := bufio.NewReader(resp.Body)
+	var body io.Reader = r
+	if magic, _ := r.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("無法解壓 sitemap %s: %w", sitemapURL, err)
+		}
+		defer gz.Close()
+		body = gz
+	}
+	// sitemap 規範上限為 50 MiB（未壓縮）
+	data, err := io.ReadAll(io.LimitReader(body, 50<<20))
+	if err != nil {
+		return nil, fmt.Errorf("讀取 sitemap %s 失敗: %w", sitemapURL, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,143 @@
+package crawler
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/firehourse/cdpkit/tab"
+)
+
+// StructuredData 頁面中供搜尋引擎與社群平台使用的結構化資料
+type StructuredData struct {
+	// JSONLD 每個 <script type="application/ld+json"> 解析後的值，@graph 不展開；無法解析的區塊略過
+	JSONLD []interface{} `json:"json_ld,omitempty"`
+	// OpenGraph og:*、article:*、product:* 等 <meta property> 的值，鍵為完整名稱（例如 "og:title"），
+	// 依出現順序保留重複的屬性（例如多張 og:image）
+	OpenGraph map[string][]string `json:"open_graph,omitempty"`
+	// Twitter twitter:* 的 <meta name> 或 <meta property> 值
+	Twitter map[string][]string `json:"twitter,omitempty"`
+	// Microdata 頂層的 itemscope 項目
+	Microdata []*MicrodataItem `json:"microdata,omitempty"`
+}
+
+// MicrodataItem 一個 itemscope 項目
+type MicrodataItem struct {
+	Type []string `json:"type,omitempty"`
+	ID   string   `json:"id,omitempty"`
+	// Properties itemprop 名稱對應的值；值為字串，或巢狀項目（與 MicrodataItem 相同結構的 map）
+	Properties map[string][]interface{} `json:"properties,omitempty"`
+}
+
+// Meta 依完整名稱（例如 "og:title"、"twitter:card"）取得第一個 OpenGraph 或 Twitter card 值，沒有時回傳空字串
+func (d *StructuredData) Meta(name string) string {
+	if d == nil {
+		return ""
+	}
+	src := d.OpenGraph
+	if strings.HasPrefix(name, "twitter:") {
+		src = d.Twitter
+	}
+	if v := src[name]; len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// structuredDataJS 蒐集 JSON-LD、OpenGraph、Twitter card 與 microdata，以 JSON 字串回傳。
+// microdata 依 HTML 規範取值：meta 取 content，a、link 等取 href，img 等取 src，time 取 datetime，data、meter 取 value
+const structuredDataJS = `(() => {
+	const jsonld = [];
+	for (const s of document.querySelectorAll('script[type="application/ld+json"]')) {
+		try { jsonld.push(JSON.parse(s.textContent)); } catch (e) {}
+	}
+
+	const og = {}, twitter = {};
+	const add = (m, k, v) => { (m[k] = m[k] || []).push(v); };
+	for (const m of document.querySelectorAll('meta[property], meta[name]')) {
+		const key = (m.getAttribute('property') || m.getAttribute('name') || '').trim();
+		const value = m.getAttribute('content');
+		if (!key || value === null) continue;
+		if (key.startsWith('twitter:')) add(twitter, key, value);
+		else if (m.hasAttribute('property') && /^[a-z]+:/.test(key)) add(og, key, value);
+	}
+
+	const propValue = (el, seen) => {
+		if (el.hasAttribute('itemscope')) return item(el, seen);
+		switch (el.tagName) {
+		case 'META': return el.getAttribute('content') || '';
+		case 'A': case 'AREA': case 'LINK': return el.href;
+		case 'AUDIO': case 'EMBED': case 'IFRAME': case 'IMG': case 'SOURCE': case 'TRACK': case 'VIDEO': return el.src;
+		case 'OBJECT': return el.data;
+		case 'TIME': return el.getAttribute('datetime') || el.textContent.trim();
+		case 'DATA': case 'METER': return el.getAttribute('value') || '';
+		default: return el.textContent.trim();
+		}
+	};
+	const item = (scope, seen = new Set()) => {
+		const out = {properties: {}};
+		const type = (scope.getAttribute('itemtype') || '').trim();
+		if (type) out.type = type.split(/\s+/);
+		if (scope.hasAttribute('itemid')) out.id = scope.getAttribute('itemid');
+		if (seen.has(scope)) return out;
+		seen.add(scope);
+		const addProp = el => {
+			const value = propValue(el, seen);
+			for (const name of el.getAttribute('itemprop').trim().split(/\s+/)) {
+				(out.properties[name] = out.properties[name] || []).push(value);
+			}
+		};
+		const visit = el => {
+			for (const child of el.children) {
+				if (child.hasAttribute('itemprop')) addProp(child);
+				if (!child.hasAttribute('itemscope')) visit(child);
+			}
+		};
+		visit(scope);
+		for (const id of (scope.getAttribute('itemref') || '').split(/\s+/)) {
+			const el = id && document.getElementById(id);
+			if (!el || el === scope) continue;
+			if (el.hasAttribute('itemprop')) addProp(el);
+			if (!el.hasAttribute('itemscope')) visit(el);
+		}
+		return out;
+	};
+	const microdata = Array.from(document.querySelectorAll('[itemscope]:not([itemprop])')).map(el => item(el));
+
+	return JSON.stringify({json_ld: jsonld, open_graph: og, twitter, microdata});
+})()`
+
+// ----------------- 內部實作 -----------------
+
+// structuredData 擷取結構化資料存入 result；頁面沒有任何結構化資料時維持 nil
+func (c *Crawler) structuredData(pageTab *tab.Tab, result *Result) {
+	v, err := pageTab.RunJS(structuredDataJS, c.options.Timeout)
+	if err != nil {
+		c.logf(2, "警告: 擷取結構化資料失敗: %v", err)
+		return
+	}
+	s, _ := v.(string)
+	var d StructuredData
+	if err := json.Unmarshal([]byte(s), &d); err != nil {
+		c.logf(2, "警告: 無法解析結構化資料: %v", err)
+		return
+	}
+	if len(d.JSONLD) == 0 && len(d.OpenGraph) == 0 && len(d.Twitter) == 0 && len(d.Microdata) == 0 {
+		return
+	}
+	result.StructuredData = &d
+}
+
+// scrubStructuredData 以 JSON 形式遮蔽結構化資料中的個資
+func (c *Crawler) scrubStructuredData(r *Result) {
+	b, err := json.Marshal(r.StructuredData)
+	if err != nil {
+		return
+	}
+	var d StructuredData
+	if err := json.Unmarshal([]byte(c.options.Policy.Scrub(string(b))), &d); err != nil {
+		c.logf(2, "警告: 遮蔽結構化資料後無法解析，改為移除: %v", err)
+		r.StructuredData = nil
+		return
+	}
+	r.StructuredData = &d
+}
@@ -0,0 +1,64 @@
+package crawler
+
+import (
+	"encoding/pem"
+	"net/url"
+	"time"
+
+	"github.com/firehourse/cdpkit/tab"
+)
+
+// TLSInfo 主文件連線協商的 TLS 參數與伺服器憑證
+type TLSInfo struct {
+	// SecurityState Chrome 對頁面的安全判定，例如 "secure"；忽略憑證錯誤時為 "insecure"
+	SecurityState string `json:"security_state,omitempty"`
+	// Protocol 例如 "TLS 1.3"、"QUIC"；KeyExchange 為 TLS 1.3 時為空，改看 KeyExchangeGroup
+	Protocol         string `json:"protocol"`
+	KeyExchange      string `json:"key_exchange,omitempty"`
+	KeyExchangeGroup string `json:"key_exchange_group,omitempty"`
+	Cipher           string `json:"cipher"`
+	// Subject、Issuer、SANs 伺服器憑證的主體、簽發者與主體別名
+	Subject   string    `json:"subject"`
+	Issuer    string    `json:"issuer"`
+	SANs      []string  `json:"sans,omitempty"`
+	ValidFrom time.Time `json:"valid_from"`
+	ValidTo   time.Time `json:"valid_to"`
+	// Chain PEM 格式的憑證鏈，第一個為伺服器憑證；取不到時為空
+	Chain []string `json:"chain,omitempty"`
+}
+
+// ----------------- 內部實作 -----------------
+
+// recordTLS 將 docURL 主文件回應的 TLS 資訊與憑證鏈存入 result；HTTP 或由攔截器回應的文件沒有 TLS 資訊
+func (c *Crawler) recordTLS(pageTab *tab.Tab, result *Result, doc *tab.Response) {
+	if !c.options.CaptureTLS || doc == nil || doc.Security == nil {
+		return
+	}
+	s := doc.Security
+	info := &TLSInfo{
+		SecurityState:    string(doc.SecurityState),
+		Protocol:         s.Protocol,
+		KeyExchange:      s.KeyExchange,
+		KeyExchangeGroup: s.KeyExchangeGroup,
+		Cipher:           s.Cipher,
+		Subject:          s.SubjectName,
+		Issuer:           s.Issuer,
+		SANs:             s.SanList,
+	}
+	if s.ValidFrom != nil {
+		info.ValidFrom = s.ValidFrom.Time()
+	}
+	if s.ValidTo != nil {
+		info.ValidTo = s.ValidTo.Time()
+	}
+	if u, err := url.Parse(doc.URL); err == nil {
+		chain, err := pageTab.CertificateChain(u.Scheme + "://" + u.Host)
+		if err != nil {
+			c.logf(4, "無法取得 %s 的憑證鏈: %v", u.Host, err)
+		}
+		for _, cert := range chain {
+			info.Chain = append(info.Chain, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})))
+		}
+	}
+	result.TLS = info
+}
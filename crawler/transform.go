@@ -0,0 +1,294 @@
+package crawler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Transformer 處理一筆結果，可就地修改；回傳 false 表示捨棄該筆
+type Transformer func(r *Result) bool
+
+// Pipeline 依序套用的 Transformer，位於擷取之後、寫出之前
+type Pipeline []Transformer
+
+// Apply 對每筆結果依序套用所有 Transformer，回傳保留下來的結果
+func (p Pipeline) Apply(results []Result) []Result {
+	if len(p) == 0 {
+		return results
+	}
+	out := make([]Result, 0, len(results))
+	for _, r := range results {
+		if p.apply(&r) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+func (p Pipeline) apply(r *Result) bool {
+	for _, t := range p {
+		if !t(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// Filter 只保留符合條件的結果
+func Filter(keep func(r Result) bool) Transformer {
+	return func(r *Result) bool { return keep(*r) }
+}
+
+// Enrich 以自訂函式修改結果
+func Enrich(fn func(r *Result)) Transformer {
+	return func(r *Result) bool {
+		fn(r)
+		return true
+	}
+}
+
+// DropErrors 捨棄有錯誤的結果
+func DropErrors() Transformer {
+	return func(r *Result) bool { return r.Error == "" }
+}
+
+// RequireKeys 捨棄 Data 缺少任一指定鍵（或值為 nil）的結果
+func RequireKeys(keys ...string) Transformer {
+	return func(r *Result) bool {
+		for _, k := range keys {
+			if r.Data[k] == nil {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// FlattenData 將巢狀物件攤平為單層鍵，例如 {"price":{"amount":1}} 變成 {"price.amount":1}；
+// 陣列以索引展開。適合輸出至 CSV、試算表等表格格式
+func FlattenData(sep string) Transformer {
+	if sep == "" {
+		sep = "."
+	}
+	return func(r *Result) bool {
+		if r.Data == nil {
+			return true
+		}
+		flat := make(map[string]interface{}, len(r.Data))
+		for k, v := range r.Data {
+			flatten(flat, k, v, sep)
+		}
+		r.Data = flat
+		return true
+	}
+}
+
+func flatten(out map[string]interface{}, prefix string, v interface{}, sep string) {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		if len(x) == 0 {
+			out[prefix] = x
+		}
+		for k, child := range x {
+			flatten(out, prefix+sep+k, child, sep)
+		}
+	case []interface{}:
+		if len(x) == 0 {
+			out[prefix] = x
+		}
+		for i, child := range x {
+			flatten(out, prefix+sep+strconv.Itoa(i), child, sep)
+		}
+	default:
+		out[prefix] = v
+	}
+}
+
+// RenameKeys 依對應表重新命名 Data 的鍵（舊 -> 新）
+func RenameKeys(mapping map[string]string) Transformer {
+	return func(r *Result) bool {
+		for from, to := range mapping {
+			if v, ok := r.Data[from]; ok {
+				delete(r.Data, from)
+				r.Data[to] = v
+			}
+		}
+		return true
+	}
+}
+
+// DropKeys 移除 Data 中的指定鍵
+func DropKeys(keys ...string) Transformer {
+	return func(r *Result) bool {
+		for _, k := range keys {
+			delete(r.Data, k)
+		}
+		return true
+	}
+}
+
+// Derive 以函式計算衍生欄位並寫入 Data[key]；回傳 nil 時不寫入
+func Derive(key string, fn func(r Result) interface{}) Transformer {
+	return func(r *Result) bool {
+		v := fn(*r)
+		if v == nil {
+			return true
+		}
+		if r.Data == nil {
+			r.Data = map[string]interface{}{}
+		}
+		r.Data[key] = v
+		return true
+	}
+}
+
+// ----------------- 設定檔 -----------------
+
+// TransformSpec 以 JSON 描述一個內建 Transformer，供 CLI 設定檔使用
+//
+//	[
+//	  {"type": "drop_errors"},
+//	  {"type": "flatten", "separator": "_"},
+//	  {"type": "rename", "keys": {"productName": "name"}},
+//	  {"type": "derive", "key": "domain", "from": "url", "op": "host"},
+//	  {"type": "require", "fields": ["name", "price"]},
+//	  {"type": "drop", "fields": ["debug"]}
+//	]
+type TransformSpec struct {
+	// Type drop_errors、require、flatten、rename、drop、derive
+	Type string `json:"type"`
+	// Separator flatten 的鍵分隔字元，預設 "."
+	Separator string `json:"separator,omitempty"`
+	// Keys rename 的對應表（舊 -> 新）
+	Keys map[string]string `json:"keys,omitempty"`
+	// Fields require、drop 的鍵清單
+	Fields []string `json:"fields,omitempty"`
+	// Key derive 寫入的鍵
+	Key string `json:"key,omitempty"`
+	// From derive 的來源：url、title、error 或 Data 的鍵
+	From string `json:"from,omitempty"`
+	// Op derive 的運算：copy、host、path、lower、upper、trim、length、number
+	Op string `json:"op,omitempty"`
+}
+
+// BuildPipeline 由設定建立 Pipeline
+func BuildPipeline(specs []TransformSpec) (Pipeline, error) {
+	p := make(Pipeline, 0, len(specs))
+	for i, s := range specs {
+		var t Transformer
+		switch s.Type {
+		case "drop_errors":
+			t = DropErrors()
+		case "require":
+			t = RequireKeys(s.Fields...)
+		case "flatten":
+			t = FlattenData(s.Separator)
+		case "rename":
+			t = RenameKeys(s.Keys)
+		case "drop":
+			t = DropKeys(s.Fields...)
+		case "derive":
+			op, err := deriveOp(s.Op)
+			if err != nil {
+				return nil, fmt.Errorf("transform #%d: %w", i+1, err)
+			}
+			from := s.From
+			t = Derive(s.Key, func(r Result) interface{} {
+				v := sourceValue(r, from)
+				if v == nil {
+					return nil
+				}
+				return op(v)
+			})
+		default:
+			return nil, fmt.Errorf("transform #%d: 未知的類型 %q", i+1, s.Type)
+		}
+		p = append(p, t)
+	}
+	return p, nil
+}
+
+// LoadPipeline 從 JSON 檔讀取 TransformSpec 陣列並建立 Pipeline
+func LoadPipeline(path string) (Pipeline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("無法讀取轉換設定 %s: %w", path, err)
+	}
+	var specs []TransformSpec
+	if err := json.Unmarshal(data, &specs); err != nil {
+		return nil, fmt.Errorf("無法解析轉換設定: %w", err)
+	}
+	return BuildPipeline(specs)
+}
+
+func sourceValue(r Result, from string) interface{} {
+	switch from {
+	case "url":
+		return r.URL
+	case "title":
+		return r.Title
+	case "error":
+		return r.Error
+	}
+	return r.Data[from]
+}
+
+func deriveOp(op string) (func(v interface{}) interface{}, error) {
+	str := func(v interface{}) string {
+		if s, ok := v.(string); ok {
+			return s
+		}
+		return fmt.Sprintf("%v", v)
+	}
+	switch op {
+	case "", "copy":
+		return func(v interface{}) interface{} { return v }, nil
+	case "host", "path":
+		return func(v interface{}) interface{} {
+			u, err := url.Parse(str(v))
+			if err != nil {
+				return nil
+			}
+			if op == "host" {
+				return u.Hostname()
+			}
+			return u.Path
+		}, nil
+	case "lower":
+		return func(v interface{}) interface{} { return strings.ToLower(str(v)) }, nil
+	case "upper":
+		return func(v interface{}) interface{} { return strings.ToUpper(str(v)) }, nil
+	case "trim":
+		return func(v interface{}) interface{} { return strings.TrimSpace(str(v)) }, nil
+	case "length":
+		return func(v interface{}) interface{} {
+			if a, ok := v.([]interface{}); ok {
+				return float64(len(a))
+			}
+			return float64(len([]rune(str(v))))
+		}, nil
+	case "number":
+		// 去除貨幣符號與千分位，例如 "NT$1,299" -> 1299
+		return func(v interface{}) interface{} {
+			if f, ok := v.(float64); ok {
+				return f
+			}
+			s := strings.Map(func(c rune) rune {
+				if c >= '0' && c <= '9' || c == '.' || c == '-' {
+					return c
+				}
+				return -1
+			}, str(v))
+			f, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return nil
+			}
+			return f
+		}, nil
+	}
+	return nil, fmt.Errorf("未知的 derive 運算 %q", op)
+}
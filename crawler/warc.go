@@ -0,0 +1,48 @@
+package crawler
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/firehourse/cdpkit/tab"
+	"github.com/firehourse/cdpkit/warc"
+)
+
+// archiveWARC 將頁面主文件（以及選擇性的子資源）寫入 WARC
+func (c *Crawler) archiveWARC(pageTab *tab.Tab) {
+	for _, r := range pageTab.Responses() {
+		isDoc := r.ResourceType == network.ResourceTypeDocument
+		if !r.Finished || (!isDoc && !c.options.WARCSubresources) {
+			continue
+		}
+
+		body, err := pageTab.GetResponseBody(r.RequestID, c.options.Timeout)
+		if err != nil {
+			c.logf(2, "WARC: 無法取得 %s 的內容: %v", r.URL, err)
+			continue
+		}
+		if p := c.options.Policy; p != nil && p.ScrubPII && isTextual(r.MimeType) {
+			body = []byte(p.Scrub(string(body)))
+		}
+
+		headers := make(map[string]string, len(r.Headers))
+		for k, v := range r.Headers {
+			headers[k] = fmt.Sprintf("%v", v)
+		}
+		err = c.warc.WriteResponse(warc.Response{
+			URL:     r.URL,
+			Status:  int(r.Status),
+			Headers: headers,
+			Body:    body,
+		})
+		if err != nil {
+			c.logf(1, "WARC: 寫入 %s 失敗: %v", r.URL, err)
+		}
+	}
+}
+
+func isTextual(mime string) bool {
+	return strings.HasPrefix(mime, "text/") || strings.Contains(mime, "json") ||
+		strings.Contains(mime, "javascript") || strings.Contains(mime, "xml")
+}
@@ -0,0 +1,94 @@
+// Package devices 提供常見行動裝置與平板的模擬參數，用於爬取網站的行動版頁面。
+package devices
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Device 一個裝置的 viewport、像素比、觸控與 UA 設定
+type Device struct {
+	Name      string
+	UserAgent string
+	// Width、Height 為 CSS 像素的直向尺寸
+	Width  int
+	Height int
+	// Scale 裝置像素比（DPR）
+	Scale  float64
+	Mobile bool
+	Touch  bool
+	// Landscape 是否以橫向模式模擬（寬高互換）
+	Landscape bool
+}
+
+// Viewport 回傳考慮方向後的寬高
+func (d Device) Viewport() (int, int) {
+	if d.Landscape {
+		return d.Height, d.Width
+	}
+	return d.Width, d.Height
+}
+
+var catalog = map[string]Device{
+	"iPhone 14": {
+		UserAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1",
+		Width:     390, Height: 844, Scale: 3, Mobile: true, Touch: true,
+	},
+	"iPhone 14 Pro Max": {
+		UserAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1",
+		Width:     430, Height: 932, Scale: 3, Mobile: true, Touch: true,
+	},
+	"iPhone SE": {
+		UserAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1",
+		Width:     375, Height: 667, Scale: 2, Mobile: true, Touch: true,
+	},
+	"Pixel 7": {
+		UserAgent: "Mozilla/5.0 (Linux; Android 14; Pixel 7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/123.0.0.0 Mobile Safari/537.36",
+		Width:     412, Height: 915, Scale: 2.625, Mobile: true, Touch: true,
+	},
+	"Galaxy S23": {
+		UserAgent: "Mozilla/5.0 (Linux; Android 14; SM-S911B) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/123.0.0.0 Mobile Safari/537.36",
+		Width:     360, Height: 780, Scale: 3, Mobile: true, Touch: true,
+	},
+	"iPad": {
+		UserAgent: "Mozilla/5.0 (iPad; CPU OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1",
+		Width:     820, Height: 1180, Scale: 2, Mobile: true, Touch: true,
+	},
+	"iPad Pro 12.9": {
+		UserAgent: "Mozilla/5.0 (iPad; CPU OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1",
+		Width:     1024, Height: 1366, Scale: 2, Mobile: true, Touch: true,
+	},
+	"Galaxy Tab S8": {
+		UserAgent: "Mozilla/5.0 (Linux; Android 14; SM-X700) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/123.0.0.0 Safari/537.36",
+		Width:     800, Height: 1280, Scale: 2, Mobile: true, Touch: true,
+	},
+}
+
+// Lookup 依名稱取得裝置（不分大小寫）；名稱加上 " landscape" 後綴時以橫向模擬
+func Lookup(name string) (Device, error) {
+	base, landscape := strings.CutSuffix(strings.ToLower(strings.TrimSpace(name)), " landscape")
+	for n, d := range catalog {
+		if strings.ToLower(n) == base {
+			d.Name = n
+			d.Landscape = landscape
+			return d, nil
+		}
+	}
+	return Device{}, fmt.Errorf("devices: 未知的裝置 %q（可用：%s）", name, strings.Join(Names(), ", "))
+}
+
+// Register 新增或覆寫一個裝置；應於程式初始化時呼叫
+func Register(name string, d Device) {
+	catalog[name] = d
+}
+
+// Names 回傳所有裝置名稱
+func Names() []string {
+	names := make([]string, 0, len(catalog))
+	for n := range catalog {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,202 @@
+// Package domains 集中管理各網站的特殊設定：等待條件、額外標頭、必要 cookies、
+// 指紋 profile、速率限制與專用擷取腳本，由爬蟲依頁面 host 自動套用。
+//
+// 設定檔為 YAML（或 JSON），頂層鍵為 host，"*.example.com" 同時比對 example.com 與其子網域：
+//
+//	"*.example.com":
+//	  wait_selector: "#content"
+//	  wait_delay: 1s
+//	  headers:
+//	    Referer: https://www.google.com/
+//	  cookies:
+//	    consent: "yes"
+//	  stealth_profile: win-chrome-123
+//	  interval: 2s
+//	  concurrency: 1
+//	  script_file: extractors/example.js
+package domains
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/firehourse/cdpkit/config"
+)
+
+// Override 單一網域的設定覆寫；零值欄位沿用全域設定
+type Override struct {
+	// WaitSelector 導航後等待此元素出現才擷取
+	WaitSelector string `json:"wait_selector,omitempty"`
+	// WaitDelay 導航後（及 WaitSelector 出現後）額外等待的時間；未設定時，
+	// 有 WaitSelector 則不再等待，否則等待預設的 2 秒
+	WaitDelay Duration `json:"wait_delay,omitempty"`
+	// Headers 每個請求附加的標頭
+	Headers StringMap `json:"headers,omitempty"`
+	// Cookies 導航前設定的 cookies，例如同意條款或地區選擇
+	Cookies StringMap `json:"cookies,omitempty"`
+	// StealthProfile 指紋 profile 名稱，見 stealth.Names
+	StealthProfile string `json:"stealth_profile,omitempty"`
+	// UserAgent 覆寫 User-Agent
+	UserAgent string `json:"user_agent,omitempty"`
+	// Interval 同一網域兩次導航之間的最小間隔
+	Interval Duration `json:"interval,omitempty"`
+	// Concurrency 同一網域同時處理的頁面上限
+	Concurrency int `json:"concurrency,omitempty"`
+	// Script 取代全域擷取腳本的 JS
+	Script string `json:"script,omitempty"`
+	// ScriptFile 從檔案載入 Script，相對路徑以設定檔所在目錄為準
+	ScriptFile string `json:"script_file,omitempty"`
+}
+
+// Overrides 以 host 或 "*.domain" 為鍵的設定覆寫
+type Overrides map[string]Override
+
+// Load 讀取設定檔；副檔名為 .json 時以 JSON 解析，其餘以 YAML 解析。
+// ScriptFile 會在此時讀入 Script
+func Load(path string) (Overrides, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("無法讀取網域設定 %s: %w", path, err)
+	}
+
+	var o Overrides
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(data, &o)
+	} else {
+		o, err = Parse(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("無法解析網域設定 %s: %w", path, err)
+	}
+
+	dir := filepath.Dir(path)
+	for host, ov := range o {
+		if ov.ScriptFile == "" {
+			continue
+		}
+		p := ov.ScriptFile
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(dir, p)
+		}
+		script, err := os.ReadFile(p)
+		if err != nil {
+			return nil, fmt.Errorf("網域 %s: 無法讀取腳本: %w", host, err)
+		}
+		ov.Script = string(script)
+		o[host] = ov
+	}
+	return o, nil
+}
+
+// Parse 解析 YAML 格式的設定
+func Parse(data []byte) (Overrides, error) {
+	v, err := config.ParseYAML(data)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return Overrides{}, nil
+	}
+	if _, ok := v.(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("頂層必須是以 host 為鍵的 mapping")
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var o Overrides
+	if err := json.Unmarshal(raw, &o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// Lookup 回傳 host 適用的設定：完全相符優先，其次為最長的 "*.domain" 比對。
+// host 可含連接埠，比對時不分大小寫
+func (o Overrides) Lookup(host string) (Override, bool) {
+	if len(o) == 0 {
+		return Override{}, false
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+
+	best, bestLen, found := Override{}, -1, false
+	for key, ov := range o {
+		k := strings.ToLower(key)
+		if k == host {
+			return ov, true
+		}
+		domain, ok := strings.CutPrefix(k, "*.")
+		if !ok {
+			continue
+		}
+		if (host == domain || strings.HasSuffix(host, "."+domain)) && len(domain) > bestLen {
+			best, bestLen, found = ov, len(domain), true
+		}
+	}
+	return best, found
+}
+
+// Duration 可由 "1.5s"、"500ms" 等字串或秒數設定的時間長度
+type Duration time.Duration
+
+// UnmarshalJSON 接受 time.ParseDuration 格式的字串或以秒為單位的數字
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	switch x := v.(type) {
+	case float64:
+		*d = Duration(x * float64(time.Second))
+	case string:
+		p, err := time.ParseDuration(x)
+		if err != nil {
+			return fmt.Errorf("無效的時間長度 %q", x)
+		}
+		*d = Duration(p)
+	case nil:
+		*d = 0
+	default:
+		return fmt.Errorf("無效的時間長度 %s", data)
+	}
+	return nil
+}
+
+// MarshalJSON 輸出為字串形式
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// StringMap 值一律為字串的 mapping；YAML 中未加引號的數字或布林會轉為字串
+type StringMap map[string]string
+
+// UnmarshalJSON 將純量值轉為字串
+func (m *StringMap) UnmarshalJSON(data []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	out := make(StringMap, len(raw))
+	for k, v := range raw {
+		switch x := v.(type) {
+		case string:
+			out[k] = x
+		case float64, bool:
+			out[k] = fmt.Sprint(x)
+		case nil:
+			out[k] = ""
+		default:
+			return fmt.Errorf("%s 的值必須是字串", k)
+		}
+	}
+	*m = out
+	return nil
+}
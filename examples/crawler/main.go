@@ -15,6 +15,9 @@ import (
 
 	"github.com/firehourse/cdpkit/browser"
 	"github.com/firehourse/cdpkit/config"
+	cdpcrawler "github.com/firehourse/cdpkit/crawler"
+	"github.com/firehourse/cdpkit/query"
+	"github.com/firehourse/cdpkit/report"
 	"github.com/firehourse/cdpkit/tab"
 )
 
@@ -34,6 +37,16 @@ type CrawlerConfig struct {
 	OutputPath string
 	// 超時設置
 	Timeout time.Duration
+	// 結果轉換設定檔路徑（JSON 格式的 TransformSpec 陣列）
+	TransformsPath string
+	// 輸出前套用的 jq 風格查詢
+	Select string
+	// 是否輸出適合 diff 的 canonical JSON（排序、去除時間等易變欄位）
+	Canonical bool
+	// 報告輸出路徑（.html 或 .md），留空則不產生
+	ReportPath string
+	// 自訂報告模板路徑，留空使用內建模板
+	ReportTemplate string
 }
 
 // 爬取結果
@@ -56,6 +69,11 @@ func main() {
 	flag.StringVar(&cfg.CustomJS, "js", "", "自定義 JS 腳本文件路徑")
 	flag.StringVar(&cfg.OutputPath, "output", "results.json", "結果輸出路徑")
 	flag.DurationVar(&cfg.Timeout, "timeout", 60*time.Second, "操作超時時間")
+	flag.StringVar(&cfg.TransformsPath, "transforms", "", "結果轉換設定檔路徑 (JSON)")
+	flag.BoolVar(&cfg.Canonical, "canonical", false, "輸出適合 diff 的 canonical JSON")
+	flag.StringVar(&cfg.ReportPath, "report", "", "爬取後產生報告的路徑 (.html 或 .md)")
+	flag.StringVar(&cfg.ReportTemplate, "report-template", "", "自訂報告模板 (Go template)")
+	flag.StringVar(&cfg.Select, "select", "", "jq 風格查詢，例如 '.[] | select(.error == null) | {url, title}'")
 	flag.Parse()
 
 	// 獲取要爬取的 URL 列表
@@ -89,8 +107,30 @@ func main() {
 		`
 	}
 
+	// 讀取結果轉換設定
+	var pipeline cdpcrawler.Pipeline
+	if cfg.TransformsPath != "" {
+		p, err := cdpcrawler.LoadPipeline(cfg.TransformsPath)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		pipeline = p
+	}
+
+	// 先編譯查詢，避免爬完才發現語法錯誤
+	var selectQuery *query.Query
+	if cfg.Select != "" {
+		q, err := query.Compile(cfg.Select)
+		if err != nil {
+			log.Fatalf("無效的 -select 查詢: %v", err)
+		}
+		selectQuery = q
+	}
+
 	// 創建爬蟲實例並執行
 	crawler := NewCrawler(cfg, customScript)
+	crawler.pipeline = pipeline
+	crawler.query = selectQuery
 	crawler.Run()
 }
 
@@ -100,6 +140,8 @@ type Crawler struct {
 	customScript string
 	bm           *browser.BrowserManager
 	results      []ScrapeResult
+	pipeline     cdpcrawler.Pipeline
+	query        *query.Query
 	mu           sync.Mutex
 	wg           sync.WaitGroup
 }
@@ -183,8 +225,31 @@ func (c *Crawler) Run() {
 	// 等待所有工作完成
 	c.wg.Wait()
 
-	// 保存結果
+	// 套用結果轉換並保存
+	c.applyTransforms()
 	c.saveResults()
+	if c.config.ReportPath != "" {
+		c.writeReport()
+	}
+}
+
+// applyTransforms 以 cdpkit 的 Pipeline 處理結果
+func (c *Crawler) applyTransforms() {
+	if len(c.pipeline) == 0 {
+		return
+	}
+	kept := c.results[:0]
+	for _, sr := range c.results {
+		out := c.pipeline.Apply([]cdpcrawler.Result{toResult(sr)})
+		if len(out) == 0 {
+			continue
+		}
+		sr.Title, sr.Error = out[0].Title, out[0].Error
+		sr.ScriptData = out[0].Data
+		kept = append(kept, sr)
+	}
+	log.Printf("結果轉換後保留 %d / %d 筆", len(kept), len(c.results))
+	c.results = kept
 }
 
 // worker goroutine 處理每個 URL
@@ -197,7 +262,7 @@ func (c *Crawler) worker(ctx context.Context, workerID int, urlCh <-chan string)
 		log.Printf("工作者 %d: 創建分頁失敗: %v", workerID, err)
 		return
 	}
-	pageTab := tab.NewTab(tabCtx, tabCancel, config.Config{Timeout: c.config.Timeout})
+	pageTab := tab.Open(tabCtx, tabCancel, config.Layered{Request: config.Request{Timeout: c.config.Timeout}})
 	defer pageTab.Close(c.bm)
 
 	// 處理每個 URL
@@ -284,12 +349,78 @@ func (c *Crawler) scrapePage(pageTab *tab.Tab, url string, result *ScrapeResult)
 	return nil
 }
 
+// toResult 轉為 cdpkit 的 Result；腳本回傳值視為 Data
+func toResult(sr ScrapeResult) cdpcrawler.Result {
+	r := cdpcrawler.Result{URL: sr.URL, Title: sr.Title, Error: sr.Error, Timestamp: sr.Timestamp}
+	if d, err := time.ParseDuration(sr.ElapsedTime); err == nil {
+		r.ElapsedTime = d
+	}
+	if m, ok := sr.ScriptData.(map[string]interface{}); ok {
+		r.Data = m
+	} else if sr.ScriptData != nil {
+		r.Data = map[string]interface{}{"result": sr.ScriptData}
+	}
+	return r
+}
+
+// writeReport 以內建或自訂模板產生報告
+func (c *Crawler) writeReport() {
+	var renderer *report.Renderer
+	var err error
+	if c.config.ReportTemplate != "" {
+		renderer, err = report.ParseFile(c.config.ReportTemplate)
+	} else {
+		renderer, err = report.New(report.FormatFromPath(c.config.ReportPath))
+	}
+	if err != nil {
+		log.Fatalf("載入報告模板失敗: %v", err)
+	}
+
+	results := make([]cdpcrawler.Result, len(c.results))
+	for i, sr := range c.results {
+		results[i] = toResult(sr)
+	}
+	data := report.NewData(fmt.Sprintf("爬取報告 (%d 個網址)", len(results)), results)
+	if err := renderer.WriteFile(c.config.ReportPath, data); err != nil {
+		log.Fatalf("產生報告失敗: %v", err)
+	}
+	log.Printf("報告已保存到 %s", c.config.ReportPath)
+}
+
 // saveResults 保存結果到文件
 func (c *Crawler) saveResults() {
 	log.Printf("正在保存 %d 個結果到 %s", len(c.results), c.config.OutputPath)
 
-	// 將結果序列化為 JSON
-	jsonData, err := json.MarshalIndent(c.results, "", "  ")
+	// canonical 模式先正規化結果（排序、去除時間等易變欄位），查詢也作用在正規化後的資料上
+	var output interface{} = c.results
+	if c.config.Canonical {
+		results := make([]cdpcrawler.Result, len(c.results))
+		for i, sr := range c.results {
+			results[i] = toResult(sr)
+		}
+		canonical, err := cdpcrawler.ResultsToCanonicalJSON(results, cdpcrawler.CanonicalOptions{})
+		if err != nil {
+			log.Fatalf("序列化結果失敗: %v", err)
+		}
+		if err := json.Unmarshal(canonical, &output); err != nil {
+			log.Fatalf("序列化結果失敗: %v", err)
+		}
+	}
+
+	if c.query != nil {
+		out, err := c.query.RunJSON(output)
+		if err != nil {
+			log.Fatalf("執行 -select 查詢失敗: %v", err)
+		}
+		// 單一輸出直接寫出，多個輸出（例如 .[] | ...）則收集為陣列
+		if len(out) == 1 {
+			output = out[0]
+		} else {
+			output = out
+		}
+	}
+
+	jsonData, err := json.MarshalIndent(output, "", "  ")
 	if err != nil {
 		log.Fatalf("序列化結果失敗: %v", err)
 	}
@@ -0,0 +1,179 @@
+package flow
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Terminal 在終端機提示使用者選擇下一個動作；截圖與 HTML 寫入 Dir 供檢視
+type Terminal struct {
+	In  io.Reader
+	Out io.Writer
+	// Dir 快照輸出目錄，預設為 flow-debug
+	Dir string
+
+	once   sync.Once
+	reader *bufio.Reader
+}
+
+// NewTerminal 以標準輸入輸出建立 Terminal
+func NewTerminal(dir string) *Terminal {
+	return &Terminal{In: os.Stdin, Out: os.Stdout, Dir: dir}
+}
+
+// Pause 實作 Controller
+func (t *Terminal) Pause(s Snapshot) Command {
+	t.once.Do(func() {
+		t.reader = bufio.NewReader(t.In)
+		if t.Dir == "" {
+			t.Dir = "flow-debug"
+		}
+	})
+
+	status := "完成"
+	if s.Error != "" {
+		status = "失敗: " + s.Error
+	}
+	fmt.Fprintf(t.Out, "\n[%d/%d] %s %s (%s)\n  URL: %s\n", s.Index+1, s.Total, s.Step, status, s.Elapsed.Round(time.Millisecond), s.URL)
+	if err := os.MkdirAll(t.Dir, 0755); err == nil {
+		base := filepath.Join(t.Dir, fmt.Sprintf("step-%02d", s.Index+1))
+		if len(s.Screenshot) > 0 && os.WriteFile(base+".png", s.Screenshot, 0644) == nil {
+			fmt.Fprintf(t.Out, "  截圖: %s.png\n", base)
+		}
+		if s.HTML != "" && os.WriteFile(base+".html", []byte(s.HTML), 0644) == nil {
+			fmt.Fprintf(t.Out, "  DOM:  %s.html\n", base)
+		}
+	}
+
+	for {
+		fmt.Fprint(t.Out, "[Enter] 下一步  c) 繼續執行  r) 重試  q) 中止 > ")
+		line, err := t.reader.ReadString('\n')
+		if err != nil && line == "" {
+			// 輸入結束時視為中止，避免在無人值守時卡住
+			return Abort
+		}
+		switch strings.TrimSpace(strings.ToLower(line)) {
+		case "", "n", "next":
+			return Next
+		case "c", "continue":
+			return Continue
+		case "r", "retry":
+			return Retry
+		case "q", "quit", "abort":
+			return Abort
+		}
+	}
+}
+
+// HTTPControl 以 HTTP 端點控制除錯流程，適合在伺服器或容器中除錯：
+//
+//	GET  /                        目前狀態與已暫停步驟的清單 (JSON)
+//	GET  /steps/{i}/screenshot    第 i 筆快照的 PNG 截圖
+//	GET  /steps/{i}/dom           第 i 筆快照的 HTML
+//	POST /next、/continue、/retry、/abort
+type HTTPControl struct {
+	mux      *http.ServeMux
+	commands chan Command
+
+	mu     sync.Mutex
+	paused bool
+	snaps  []Snapshot
+}
+
+// NewHTTPControl 建立 HTTP 控制器，以 http.ListenAndServe 等方式掛載即可
+func NewHTTPControl() *HTTPControl {
+	h := &HTTPControl{mux: http.NewServeMux(), commands: make(chan Command)}
+	h.mux.HandleFunc("GET /{$}", h.status)
+	h.mux.HandleFunc("GET /steps/{i}/screenshot", h.screenshot)
+	h.mux.HandleFunc("GET /steps/{i}/dom", h.dom)
+	for name, cmd := range map[string]Command{"next": Next, "continue": Continue, "retry": Retry, "abort": Abort} {
+		h.mux.HandleFunc("POST /"+name, h.command(cmd))
+	}
+	return h
+}
+
+// Pause 實作 Controller：記錄快照並等待 POST 指令
+func (h *HTTPControl) Pause(s Snapshot) Command {
+	h.mu.Lock()
+	h.snaps = append(h.snaps, s)
+	h.paused = true
+	h.mu.Unlock()
+
+	cmd := <-h.commands
+
+	h.mu.Lock()
+	h.paused = false
+	h.mu.Unlock()
+	return cmd
+}
+
+func (h *HTTPControl) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *HTTPControl) status(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	body := struct {
+		Paused bool       `json:"paused"`
+		Steps  []Snapshot `json:"steps"`
+	}{h.paused, h.snaps}
+	data, err := json.MarshalIndent(body, "", "  ")
+	h.mu.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+func (h *HTTPControl) screenshot(w http.ResponseWriter, r *http.Request) {
+	s, ok := h.snapshot(r)
+	if !ok || len(s.Screenshot) == 0 {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Write(s.Screenshot)
+}
+
+func (h *HTTPControl) dom(w http.ResponseWriter, r *http.Request) {
+	s, ok := h.snapshot(r)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	// 以純文字輸出，避免在控制頁面的來源下執行目標網站的腳本
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	io.WriteString(w, s.HTML)
+}
+
+func (h *HTTPControl) command(cmd Command) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case h.commands <- cmd:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.Error(w, "流程目前未暫停", http.StatusConflict)
+		}
+	}
+}
+
+func (h *HTTPControl) snapshot(r *http.Request) (Snapshot, bool) {
+	i, err := strconv.Atoi(r.PathValue("i"))
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err != nil || i < 0 || i >= len(h.snaps) {
+		return Snapshot{}, false
+	}
+	return h.snaps[i], true
+}
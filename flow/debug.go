@@ -0,0 +1,152 @@
+package flow
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/firehourse/cdpkit/tab"
+)
+
+// ErrAborted 使用者在除錯時中止流程
+var ErrAborted = errors.New("流程已中止")
+
+// Command 暫停時使用者選擇的動作
+type Command int
+
+const (
+	// Next 執行下一步後再次暫停；剛才的步驟失敗時視為忽略錯誤繼續
+	Next Command = iota
+	// Continue 不再暫停直到流程結束，之後的步驟失敗時仍會暫停
+	Continue
+	// Retry 重新執行剛才的步驟，例如手動修正頁面狀態之後
+	Retry
+	// Abort 停止流程
+	Abort
+)
+
+// Snapshot 某個步驟執行後的頁面狀態
+type Snapshot struct {
+	// Index 步驟索引（從 0 開始）；Total 步驟總數
+	Index int    `json:"index"`
+	Total int    `json:"total"`
+	Step  string `json:"step"`
+	URL   string `json:"url"`
+	// Error 步驟的錯誤；成功時為空
+	Error   string        `json:"error,omitempty"`
+	Elapsed time.Duration `json:"elapsed"`
+	Time    time.Time     `json:"time"`
+	// Screenshot 可視範圍的 PNG 截圖；HTML 整頁 HTML。未暫停的步驟不擷取
+	Screenshot []byte `json:"-"`
+	HTML       string `json:"-"`
+}
+
+// Controller 在步驟執行後暫停並決定下一個動作，見 Terminal 與 HTTPControl
+type Controller interface {
+	Pause(s Snapshot) Command
+}
+
+// Debugger 逐步執行流程，每步之後擷取截圖與 DOM 並交由 Controller 決定是否繼續。
+// 所有步驟的快照都會保留，可回頭比對先前步驟的頁面
+type Debugger struct {
+	Tab     *tab.Tab
+	Control Controller
+	// FullPage 截取整頁而非可視範圍
+	FullPage bool
+
+	mu      sync.Mutex
+	history []Snapshot
+}
+
+// NewDebugger 建立除錯執行器
+func NewDebugger(t *tab.Tab, control Controller) *Debugger {
+	return &Debugger{Tab: t, Control: control}
+}
+
+// Run 逐步執行；使用者中止時回傳最後一個失敗步驟的 StepError，沒有失敗則回傳 ErrAborted
+func (d *Debugger) Run(steps []Step) error {
+	pausing := true
+	for i := 0; i < len(steps); {
+		s := steps[i]
+		start := time.Now()
+		err := s.Do(d.Tab)
+		elapsed := time.Since(start)
+
+		pause := pausing || err != nil
+		snap := d.record(i, len(steps), s.Name, err, elapsed, pause)
+		if err != nil {
+			log.Printf("[cdpkit] 步驟 %d (%s) 失敗: %v", i+1, s.Name, err)
+		}
+		if !pause {
+			i++
+			continue
+		}
+
+		switch d.Control.Pause(snap) {
+		case Retry:
+			continue
+		case Abort:
+			if err != nil {
+				return &StepError{Index: i, Step: s.Name, Err: err}
+			}
+			return ErrAborted
+		case Continue:
+			pausing = false
+		}
+		i++
+	}
+	return nil
+}
+
+// History 回傳目前為止所有步驟的快照（重試的步驟會出現多次）
+func (d *Debugger) History() []Snapshot {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]Snapshot(nil), d.history...)
+}
+
+// Snapshot 回傳第 i 筆快照
+func (d *Debugger) Snapshot(i int) (Snapshot, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if i < 0 || i >= len(d.history) {
+		return Snapshot{}, false
+	}
+	return d.history[i], true
+}
+
+// record 記錄快照；capture 為 true 時擷取截圖與 HTML，失敗時只記錄日誌
+func (d *Debugger) record(index, total int, name string, err error, elapsed time.Duration, capture bool) Snapshot {
+	snap := Snapshot{
+		Index:   index,
+		Total:   total,
+		Step:    name,
+		URL:     d.Tab.CurrentURL,
+		Elapsed: elapsed,
+		Time:    time.Now(),
+	}
+	if err != nil {
+		snap.Error = err.Error()
+	}
+	if capture {
+		if url, err := d.Tab.RunJS("location.href", 0); err == nil {
+			if s, ok := url.(string); ok {
+				snap.URL = s
+			}
+		}
+		if png, err := d.Tab.Screenshot(d.FullPage); err == nil {
+			snap.Screenshot = png
+		} else {
+			log.Printf("[cdpkit] 除錯截圖失敗: %v", err)
+		}
+		if html, err := d.Tab.HTML(0); err == nil {
+			snap.HTML = html
+		}
+	}
+
+	d.mu.Lock()
+	d.history = append(d.history, snap)
+	d.mu.Unlock()
+	return snap
+}
@@ -0,0 +1,130 @@
+// Package flow 將登入、搜尋、翻頁等多步驟操作描述為一串 Step，
+// 可直接執行，也可交給 Debugger 逐步執行並在每步之後檢視截圖與 DOM，
+// 找出流程在特定網站失敗的原因。
+//
+//	steps := []flow.Step{
+//		flow.Navigate("https://example.com/login"),
+//		flow.Type(`input[name="email"]`, "bot@example.com"),
+//		flow.Click(`button[type="submit"]`),
+//		flow.WaitVisible(`.dashboard`),
+//	}
+//	err := flow.Run(pageTab, steps)
+package flow
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/firehourse/cdpkit/tab"
+)
+
+// Step 流程中的單一步驟
+type Step struct {
+	// Name 顯示於日誌與除錯畫面的說明
+	Name string
+	// Do 在分頁上執行此步驟
+	Do func(t *tab.Tab) error
+}
+
+// Run 依序執行所有步驟，遇到錯誤即停止並回傳是哪一步失敗
+func Run(t *tab.Tab, steps []Step) error {
+	for i, s := range steps {
+		if err := s.Do(t); err != nil {
+			return &StepError{Index: i, Step: s.Name, Err: err}
+		}
+	}
+	return nil
+}
+
+// StepError 某個步驟執行失敗
+type StepError struct {
+	Index int
+	Step  string
+	Err   error
+}
+
+func (e *StepError) Error() string {
+	return fmt.Sprintf("步驟 %d (%s) 失敗: %v", e.Index+1, e.Step, e.Err)
+}
+
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
+
+// Navigate 導航至 url
+func Navigate(url string) Step {
+	return Step{
+		Name: "navigate " + url,
+		Do:   func(t *tab.Tab) error { return t.Navigate(url, 0) },
+	}
+}
+
+// Click 點擊元素
+func Click(selector string) Step {
+	return Step{
+		Name: "click " + selector,
+		Do:   func(t *tab.Tab) error { return t.Click(selector) },
+	}
+}
+
+// Type 在元素中輸入文字
+func Type(selector, text string) Step {
+	return Step{
+		Name: "type " + selector,
+		Do:   func(t *tab.Tab) error { return t.Type(selector, text, 0) },
+	}
+}
+
+// WaitVisible 等待元素出現
+func WaitVisible(selector string) Step {
+	return Step{
+		Name: "wait " + selector,
+		Do:   func(t *tab.Tab) error { return t.WaitVisible(selector, 0) },
+	}
+}
+
+// Wait 等待所有條件同時成立，例如 flow.Wait(tab.Hidden(`.spinner`), tab.Visible(`table`))
+func Wait(conds ...tab.Condition) Step {
+	names := make([]string, len(conds))
+	for i, c := range conds {
+		names[i] = c.String()
+	}
+	return Step{
+		Name: "wait " + strings.Join(names, ", "),
+		Do:   func(t *tab.Tab) error { return t.WaitAll(0, conds...) },
+	}
+}
+
+// Eval 執行 JS
+func Eval(script string) Step {
+	return Step{
+		Name: "eval",
+		Do: func(t *tab.Tab) error {
+			_, err := t.RunJS(script, 0)
+			return err
+		},
+	}
+}
+
+// Sleep 等待固定時間
+func Sleep(d time.Duration) Step {
+	return Step{
+		Name: "sleep " + d.String(),
+		Do: func(t *tab.Tab) error {
+			time.Sleep(d)
+			return nil
+		},
+	}
+}
+
+// Handoff 交由人工完成此步驟（例如罕見的驗證碼），完成後以人工操作後的狀態繼續流程，見 tab.Handoff
+func Handoff(opts tab.HandoffOptions) Step {
+	return Step{
+		Name: "handoff",
+		Do: func(t *tab.Tab) error {
+			_, err := t.Handoff(opts)
+			return err
+		},
+	}
+}
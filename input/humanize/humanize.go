@@ -0,0 +1,236 @@
+// Package humanize 產生近似真人的輸入時序與軌跡：Bezier 曲線滑鼠移動、
+// 不均勻的打字節奏、隨機的短暫停頓與捲動抖動。
+// 所有隨機性來自可指定種子的 RNG，相同種子會產生相同的動作序列，方便重現問題。
+package humanize
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Point viewport 座標
+type Point struct {
+	X, Y float64
+}
+
+// Step 一段滑鼠移動：移到 Point 後等待 Delay
+type Step struct {
+	Point
+	Delay time.Duration
+}
+
+// ScrollStep 一次滾輪事件
+type ScrollStep struct {
+	DX, DY float64
+	Delay  time.Duration
+}
+
+// Options 調整人性化程度；零值欄位使用預設值
+type Options struct {
+	// MoveSpeed 滑鼠平均移動速度（像素/秒），預設 900
+	MoveSpeed float64
+	// Overshoot 長距離移動時超過目標再修正的機率，預設 0.15
+	Overshoot float64
+	// ThinkPause 打字時出現較長停頓（思考）的機率，預設 0.04
+	ThinkPause float64
+	// WordPause 空白與標點後額外停頓的倍率，預設 1.8
+	WordPause float64
+}
+
+// Humanizer 產生人性化的輸入序列，可安全地被多個 goroutine 共用
+type Humanizer struct {
+	mu   sync.Mutex
+	rng  *rand.Rand
+	opts Options
+}
+
+// New 以種子建立 Humanizer；seed 為 0 時以目前時間為種子
+func New(seed int64) *Humanizer {
+	return NewWithOptions(seed, Options{})
+}
+
+// NewWithOptions 同 New 並指定參數
+func NewWithOptions(seed int64, opts Options) *Humanizer {
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	if opts.MoveSpeed <= 0 {
+		opts.MoveSpeed = 900
+	}
+	if opts.Overshoot == 0 {
+		opts.Overshoot = 0.15
+	}
+	if opts.ThinkPause == 0 {
+		opts.ThinkPause = 0.04
+	}
+	if opts.WordPause == 0 {
+		opts.WordPause = 1.8
+	}
+	return &Humanizer{rng: rand.New(rand.NewSource(seed)), opts: opts}
+}
+
+func (h *Humanizer) float() float64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.rng.Float64()
+}
+
+func (h *Humanizer) norm() float64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.rng.NormFloat64()
+}
+
+// between 回傳 [lo, hi) 之間的亂數
+func (h *Humanizer) between(lo, hi float64) float64 {
+	return lo + (hi-lo)*h.float()
+}
+
+// jitter 將 d 乘上 (1±ratio) 範圍內的隨機倍率
+func (h *Humanizer) jitter(d time.Duration, ratio float64) time.Duration {
+	return time.Duration(float64(d) * h.between(1-ratio, 1+ratio))
+}
+
+// TargetPoint 回傳元素方框內的點擊位置；以常態分佈集中在中心附近，並限制在方框內緣
+func (h *Humanizer) TargetPoint(x, y, width, height float64) Point {
+	clamp := func(v, lo, hi float64) float64 { return math.Max(lo, math.Min(hi, v)) }
+	cx := x + width/2 + h.norm()*width/6
+	cy := y + height/2 + h.norm()*height/6
+	return Point{
+		X: clamp(cx, x+width*0.1, x+width*0.9),
+		Y: clamp(cy, y+height*0.1, y+height*0.9),
+	}
+}
+
+// MousePath 產生從 from 到 to 的 Bezier 曲線軌跡。targetSize 為目標寬度（像素），
+// 依 Fitts 定律決定移動時間：距離越遠、目標越小，移動越久。長距離時偶爾會超過目標再修正
+func (h *Humanizer) MousePath(from, to Point, targetSize float64) []Step {
+	dist := math.Hypot(to.X-from.X, to.Y-from.Y)
+	if dist < 1 {
+		return []Step{{Point: to, Delay: h.jitter(15*time.Millisecond, 0.5)}}
+	}
+	if targetSize <= 0 {
+		targetSize = 20
+	}
+
+	if dist > 300 && h.float() < h.opts.Overshoot {
+		over := Point{
+			X: to.X + (to.X-from.X)/dist*h.between(8, 25) + h.norm()*4,
+			Y: to.Y + (to.Y-from.Y)/dist*h.between(8, 25) + h.norm()*4,
+		}
+		path := h.curve(from, over, targetSize)
+		path[len(path)-1].Delay += h.jitter(80*time.Millisecond, 0.5)
+		return append(path, h.curve(over, to, targetSize)...)
+	}
+	return h.curve(from, to, targetSize)
+}
+
+func (h *Humanizer) curve(from, to Point, targetSize float64) []Step {
+	dx, dy := to.X-from.X, to.Y-from.Y
+	dist := math.Hypot(dx, dy)
+
+	// Fitts 定律：MT = a + b*log2(D/W + 1)
+	fitts := 0.1 + 0.12*math.Log2(dist/targetSize+1)
+	total := math.Max(fitts, dist/h.opts.MoveSpeed) * h.between(0.85, 1.2)
+	steps := int(math.Max(8, math.Min(60, total*60)))
+
+	// 兩個控制點沿垂直方向偏移，形成自然的弧線
+	nx, ny := -dy/dist, dx/dist
+	spread := math.Min(dist*0.3, 120)
+	c1 := Point{from.X + dx*h.between(0.2, 0.4) + nx*h.norm()*spread, from.Y + dy*h.between(0.2, 0.4) + ny*h.norm()*spread}
+	c2 := Point{from.X + dx*h.between(0.6, 0.8) + nx*h.norm()*spread*0.5, from.Y + dy*h.between(0.6, 0.8) + ny*h.norm()*spread*0.5}
+
+	path := make([]Step, 0, steps)
+	prev := 0.0
+	for i := 1; i <= steps; i++ {
+		// 最小加加速度（minimum-jerk）曲線：起步與結尾慢、中段快
+		s := float64(i) / float64(steps)
+		p := s * s * s * (10 - 15*s + 6*s*s)
+		pt := bezier(from, c1, c2, to, p)
+		if i < steps {
+			// 手部微小顫動
+			pt.X += h.norm() * 0.6
+			pt.Y += h.norm() * 0.6
+		}
+		delay := time.Duration((s - prev) * total * float64(time.Second))
+		prev = s
+		path = append(path, Step{Point: pt, Delay: h.jitter(delay, 0.3)})
+	}
+	return path
+}
+
+func bezier(p0, p1, p2, p3 Point, t float64) Point {
+	u := 1 - t
+	a, b, c, d := u*u*u, 3*u*u*t, 3*u*t*t, t*t*t
+	return Point{
+		X: a*p0.X + b*p1.X + c*p2.X + d*p3.X,
+		Y: a*p0.Y + b*p1.Y + c*p2.Y + d*p3.Y,
+	}
+}
+
+// ClickHold 按下滑鼠到放開的時間，約 50~130ms
+func (h *Humanizer) ClickHold() time.Duration {
+	ms := 85 + h.norm()*20
+	return time.Duration(math.Max(45, math.Min(140, ms)) * float64(time.Millisecond))
+}
+
+// Pause 動作之間的短暫停頓，約 100~400ms
+func (h *Humanizer) Pause() time.Duration {
+	return time.Duration(h.between(100, 400) * float64(time.Millisecond))
+}
+
+// KeyDelays 回傳 text 每個字元輸入後的等待時間。base 為平均間隔；
+// 空白與標點後停頓較久，連續相同字元較快，並偶爾出現思考停頓
+func (h *Humanizer) KeyDelays(text string, base time.Duration) []time.Duration {
+	if base <= 0 {
+		base = 90 * time.Millisecond
+	}
+	runes := []rune(text)
+	out := make([]time.Duration, len(runes))
+	for i, r := range runes {
+		// 對數常態分佈：多數接近平均，偶爾明顯較慢
+		d := float64(base) * math.Exp(h.norm()*0.35-0.06)
+		switch {
+		case unicode.IsSpace(r) || unicode.IsPunct(r):
+			d *= h.opts.WordPause
+		case i > 0 && runes[i-1] == r:
+			d *= 0.7
+		}
+		if h.float() < h.opts.ThinkPause {
+			d += h.between(300, 900) * float64(time.Millisecond)
+		}
+		out[i] = time.Duration(d)
+	}
+	return out
+}
+
+// ScrollSteps 將 (dx, dy) 拆成數次滾輪事件：每次約 100 像素並帶有抖動，先快後慢
+func (h *Humanizer) ScrollSteps(dx, dy float64) []ScrollStep {
+	dist := math.Max(math.Abs(dx), math.Abs(dy))
+	if dist == 0 {
+		return nil
+	}
+	n := int(math.Max(1, math.Round(dist/h.between(80, 120))))
+	weights := make([]float64, n)
+	sum := 0.0
+	for i := range weights {
+		// 慣性：前段量大、後段遞減
+		weights[i] = (1 - 0.5*float64(i)/float64(n)) * h.between(0.8, 1.2)
+		sum += weights[i]
+	}
+	out := make([]ScrollStep, n)
+	for i, w := range weights {
+		out[i] = ScrollStep{
+			DX:    dx * w / sum,
+			DY:    dy * w / sum,
+			Delay: time.Duration(h.between(16, 60)*float64(time.Millisecond)) + time.Duration(float64(i)*2*float64(time.Millisecond)),
+		}
+	}
+	if h.float() < 0.3 {
+		out[n-1].Delay += h.Pause()
+	}
+	return out
+}
@@ -0,0 +1,158 @@
+// Package lifecycle 處理 Kubernetes 等環境的優雅關閉：收到 SIGTERM 或 preStop 請求後
+// 停止接受新工作，在寬限期內等待進行中的頁面完成，再依序寫出結果、檢查點並關閉 Chrome。
+//
+//	lc := lifecycle.New(25 * time.Second)
+//	lc.OnShutdown("crawler", c.Drain)
+//	lc.OnShutdown("output", func(ctx context.Context) error { return w.Close() })
+//	lc.Listen()
+//
+// 寬限期應比 Pod 的 terminationGracePeriodSeconds 短，保留寫出與關閉的時間。
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// DefaultGrace 預設寬限期，配合 Kubernetes 預設 30 秒的 terminationGracePeriodSeconds
+const DefaultGrace = 25 * time.Second
+
+// Lifecycle 依註冊順序執行的關閉流程
+type Lifecycle struct {
+	grace time.Duration
+
+	mu    sync.Mutex
+	steps []step
+
+	once   sync.Once
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+	err    error
+}
+
+type step struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// New 建立關閉流程；grace <= 0 時使用 DefaultGrace
+func New(grace time.Duration) *Lifecycle {
+	if grace <= 0 {
+		grace = DefaultGrace
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Lifecycle{
+		grace:  grace,
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+}
+
+// OnShutdown 註冊關閉步驟，依註冊順序執行；所有步驟共用同一個寬限期期限，
+// 逾時後 ctx 結束，步驟應盡快中斷並寫出已完成的部分。
+// 通常先註冊 crawler.Crawler.Drain，再註冊結果輸出等寫出步驟
+func (l *Lifecycle) OnShutdown(name string, fn func(ctx context.Context) error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.steps = append(l.steps, step{name: name, fn: fn})
+}
+
+// Context 開始關閉時取消，工作迴圈可據此停止接受新工作
+func (l *Lifecycle) Context() context.Context {
+	return l.ctx
+}
+
+// Draining 是否已開始關閉；就緒探測可據此回應失敗，讓流量不再導向此 Pod
+func (l *Lifecycle) Draining() bool {
+	return l.ctx.Err() != nil
+}
+
+// Done 關閉流程全部完成後關閉
+func (l *Lifecycle) Done() <-chan struct{} {
+	return l.done
+}
+
+// Listen 在背景等待訊號（預設 SIGTERM 與 SIGINT）後開始關閉；
+// 關閉期間再次收到訊號時立即結束行程
+func (l *Lifecycle) Listen(signals ...os.Signal) {
+	if len(signals) == 0 {
+		signals = []os.Signal{syscall.SIGTERM, os.Interrupt}
+	}
+	ch := make(chan os.Signal, 2)
+	signal.Notify(ch, signals...)
+
+	go func() {
+		select {
+		case sig := <-ch:
+			log.Printf("[cdpkit] 收到 %v，開始優雅關閉 (寬限期 %s)", sig, l.grace)
+			go l.Shutdown()
+		case <-l.done:
+			signal.Stop(ch)
+			return
+		}
+		select {
+		case sig := <-ch:
+			log.Printf("[cdpkit] 再次收到 %v，立即結束", sig)
+			os.Exit(1)
+		case <-l.done:
+			signal.Stop(ch)
+		}
+	}()
+}
+
+// Shutdown 開始關閉並等待所有步驟完成，回傳各步驟的錯誤；重複呼叫只執行一次
+func (l *Lifecycle) Shutdown() error {
+	l.once.Do(func() {
+		l.cancel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), l.grace)
+		defer cancel()
+
+		l.mu.Lock()
+		steps := append([]step(nil), l.steps...)
+		l.mu.Unlock()
+
+		var errs []error
+		for _, s := range steps {
+			start := time.Now()
+			if err := s.fn(ctx); err != nil {
+				log.Printf("[cdpkit] 關閉步驟 %s 失敗: %v", s.name, err)
+				errs = append(errs, fmt.Errorf("%s: %w", s.name, err))
+				continue
+			}
+			log.Printf("[cdpkit] 關閉步驟 %s 完成 (%s)", s.name, time.Since(start).Round(time.Millisecond))
+		}
+		l.err = errors.Join(errs...)
+		close(l.done)
+	})
+	<-l.done
+	return l.err
+}
+
+// Wait 等待關閉流程完成並回傳結果；不會主動開始關閉
+func (l *Lifecycle) Wait() error {
+	<-l.done
+	return l.err
+}
+
+// PreStopHandler 回傳供 Kubernetes preStop httpGet 呼叫的 http.Handler：
+// 開始關閉並在完成後才回應，使 SIGTERM 在工作寫出後才送達
+func (l *Lifecycle) PreStopHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := l.Shutdown(); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
@@ -0,0 +1,355 @@
+// Package liveview 以 HTTP 即時串流無頭分頁的畫面（MJPEG），
+// 不必改用有頭 Chrome 就能在瀏覽器中觀看爬蟲或流程的操作過程，方便支援與除錯。
+package liveview
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/firehourse/cdpkit/tab"
+)
+
+// Server 串流已登錄分頁的畫面，以 http.ListenAndServe 等方式掛載或使用 Serve：
+//
+//	GET /                   分頁清單
+//	GET /tabs               分頁清單 (JSON)
+//	GET /tabs/{id}/         觀看頁面
+//	GET /tabs/{id}/stream   MJPEG 串流，可直接作為 <img> 的來源
+//	GET /tabs/{id}/frame    目前畫面的單張 JPEG
+type Server struct {
+	// Options 畫面串流設定，於分頁開始串流時套用
+	Options tab.ScreencastOptions
+
+	mux *http.ServeMux
+
+	mu   sync.Mutex
+	seq  int
+	tabs map[string]*entry
+
+	// token、addr、server 由 Serve 設定
+	token  string
+	addr   string
+	server *http.Server
+}
+
+// TabInfo 已登錄的分頁
+type TabInfo struct {
+	ID      string    `json:"id"`
+	Label   string    `json:"label"`
+	Added   time.Time `json:"added"`
+	Viewers int       `json:"viewers"`
+}
+
+type entry struct {
+	info   TabInfo
+	tab    *tab.Tab
+	closed chan struct{}
+}
+
+// New 建立串流伺服器；串流預設縮小至 1280x720 以節省頻寬
+func New() *Server {
+	s := &Server{
+		Options: tab.ScreencastOptions{MaxWidth: 1280, MaxHeight: 720},
+		mux:     http.NewServeMux(),
+		tabs:    make(map[string]*entry),
+	}
+	s.mux.HandleFunc("GET /{$}", s.index)
+	s.mux.HandleFunc("GET /tabs", s.list)
+	s.mux.HandleFunc("GET /tabs/{id}/{$}", s.viewer)
+	s.mux.HandleFunc("GET /tabs/{id}/stream", s.stream)
+	s.mux.HandleFunc("GET /tabs/{id}/frame", s.frame)
+	return s
+}
+
+// Serve 建立伺服器並在 addr（預設 127.0.0.1:0）監聽。所有路徑都須以隨機 token 開頭，
+// 否則回傳 404；開啟 s.URL() 即可列出分頁。畫面可能含有帳號等敏感資訊，請勿在未經保護的網路介面上監聽
+func Serve(addr string) (*Server, error) {
+	if addr == "" {
+		addr = "127.0.0.1:0"
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("無法啟動畫面串流: %w", err)
+	}
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		ln.Close()
+		return nil, err
+	}
+
+	s := New()
+	s.token = hex.EncodeToString(buf)
+	s.addr = ln.Addr().String()
+	s.server = &http.Server{Handler: http.HandlerFunc(s.serveToken), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := s.server.Serve(ln); err != nil && err != http.ErrServerClosed {
+			log.Printf("[cdpkit] 畫面串流結束: %v", err)
+		}
+	}()
+	log.Printf("[cdpkit] 畫面串流: %s", s.URL())
+	return s, nil
+}
+
+// URL 回傳分頁清單的網址（含 token）；未以 Serve 啟動時為空字串
+func (s *Server) URL() string {
+	if s.server == nil {
+		return ""
+	}
+	return "http://" + s.addr + "/" + s.token + "/"
+}
+
+// Close 停止 Serve 啟動的伺服器並結束所有串流
+func (s *Server) Close() error {
+	s.mu.Lock()
+	for id, e := range s.tabs {
+		close(e.closed)
+		delete(s.tabs, id)
+	}
+	s.mu.Unlock()
+	if s.server == nil {
+		return nil
+	}
+	return s.server.Close()
+}
+
+// Add 登錄分頁供觀看，label 顯示於分頁清單（例如目前的網址），回傳分頁 ID。
+// 只有在有人觀看時才會向 Chrome 要求畫面
+func (s *Server) Add(label string, t *tab.Tab) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	id := strconv.Itoa(s.seq)
+	s.tabs[id] = &entry{
+		info:   TabInfo{ID: id, Label: label, Added: time.Now()},
+		tab:    t,
+		closed: make(chan struct{}),
+	}
+	return id
+}
+
+// SetLabel 更新分頁的顯示名稱，例如翻頁後的網址
+func (s *Server) SetLabel(t *tab.Tab, label string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range s.tabs {
+		if e.tab == t {
+			e.info.Label = label
+		}
+	}
+}
+
+// Remove 移除分頁並結束觀看中的串流；應在關閉分頁前呼叫
+func (s *Server) Remove(t *tab.Tab) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, e := range s.tabs {
+		if e.tab == t {
+			close(e.closed)
+			delete(s.tabs, id)
+		}
+	}
+}
+
+// Tabs 回傳已登錄的分頁，依登錄順序排列
+func (s *Server) Tabs() []TabInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	infos := make([]TabInfo, 0, len(s.tabs))
+	for _, e := range s.tabs {
+		infos = append(infos, e.info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Added.Before(infos[j].Added) })
+	return infos
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// ----------------- 內部實作 -----------------
+
+// serveToken 驗證路徑開頭的 token 後去掉 token 交給 mux
+func (s *Server) serveToken(w http.ResponseWriter, r *http.Request) {
+	prefix := "/" + s.token
+	path := r.URL.Path
+	if len(path) < len(prefix) || subtle.ConstantTimeCompare([]byte(path[:len(prefix)]), []byte(prefix)) != 1 {
+		http.NotFound(w, r)
+		return
+	}
+	rest := path[len(prefix):]
+	if rest == "" {
+		http.Redirect(w, r, prefix+"/", http.StatusFound)
+		return
+	}
+	if rest[0] != '/' {
+		http.NotFound(w, r)
+		return
+	}
+	r2 := r.Clone(r.Context())
+	r2.URL.Path = rest
+	r2.URL.RawPath = ""
+	s.mux.ServeHTTP(w, r2)
+}
+
+func (s *Server) lookup(r *http.Request) (*entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.tabs[r.PathValue("id")]
+	return e, ok
+}
+
+// viewers 調整觀看人數
+func (s *Server) viewers(e *entry, delta int) {
+	s.mu.Lock()
+	e.info.Viewers += delta
+	s.mu.Unlock()
+}
+
+var indexPage = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>cdpkit 即時畫面</title>
+<meta http-equiv="refresh" content="5"></head>
+<body><h1>分頁</h1>
+{{if not .}}<p>目前沒有可觀看的分頁</p>{{end}}
+<ul>{{range .}}<li><a href="tabs/{{.ID}}/">{{.Label}}</a> <small>({{.Viewers}} 人觀看)</small></li>{{end}}</ul>
+</body></html>`))
+
+var viewerPage = template.Must(template.New("viewer").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>{{.Label}}</title>
+<style>body{margin:0;background:#222;color:#ddd;font:14px sans-serif}header{padding:6px 10px}
+img{display:block;max-width:100%;margin:0 auto;background:#000}a{color:#9cf}</style></head>
+<body><header><a href="../../">分頁清單</a> · {{.Label}}</header>
+<img src="stream" alt="畫面載入中，頁面靜止時不會有新影格">
+</body></html>`))
+
+func (s *Server) index(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	indexPage.Execute(w, s.Tabs())
+}
+
+func (s *Server) list(w http.ResponseWriter, r *http.Request) {
+	data, err := json.MarshalIndent(s.Tabs(), "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+func (s *Server) viewer(w http.ResponseWriter, r *http.Request) {
+	e, ok := s.lookup(r)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	s.mu.Lock()
+	info := e.info
+	s.mu.Unlock()
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	viewerPage.Execute(w, info)
+}
+
+// stream 以 multipart/x-mixed-replace 輸出 MJPEG；觀看端跟不上時捨棄舊影格，只送最新的一格
+func (s *Server) stream(w http.ResponseWriter, r *http.Request) {
+	e, ok := s.lookup(r)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "不支援串流", http.StatusInternalServerError)
+		return
+	}
+
+	frames := make(chan []byte, 1)
+	stop, err := e.tab.Screencast(s.Options, func(f []byte) { offer(frames, f) })
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer stop()
+	s.viewers(e, 1)
+	defer s.viewers(e, -1)
+
+	const boundary = "cdpkitframe"
+	w.Header().Set("Content-Type", "multipart/x-mixed-replace; boundary="+boundary)
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-e.closed:
+			return
+		case f := <-frames:
+			header := fmt.Sprintf("--%s\r\nContent-Type: image/jpeg\r\nContent-Length: %d\r\n\r\n", boundary, len(f))
+			if _, err := w.Write([]byte(header)); err != nil {
+				return
+			}
+			// 影格由所有觀看者共用，不可 append 修改
+			if _, err := w.Write(f); err != nil {
+				return
+			}
+			if _, err := w.Write([]byte("\r\n")); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// frame 回傳目前畫面；等待第一個影格最多 5 秒
+func (s *Server) frame(w http.ResponseWriter, r *http.Request) {
+	e, ok := s.lookup(r)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	frames := make(chan []byte, 1)
+	stop, err := e.tab.Screencast(s.Options, func(f []byte) { offer(frames, f) })
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer stop()
+
+	select {
+	case f := <-frames:
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Header().Set("Cache-Control", "no-store")
+		w.Write(f)
+	case <-time.After(5 * time.Second):
+		http.Error(w, "逾時未收到畫面", http.StatusGatewayTimeout)
+	case <-e.closed:
+		http.NotFound(w, r)
+	case <-r.Context().Done():
+	}
+}
+
+// offer 放入最新影格，通道已滿時先丟掉舊的
+func offer(ch chan []byte, f []byte) {
+	for {
+		select {
+		case ch <- f:
+			return
+		default:
+		}
+		select {
+		case <-ch:
+		default:
+		}
+	}
+}
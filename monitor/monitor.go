@@ -0,0 +1,476 @@
+// Package monitor 合成監控：依排程以瀏覽器載入設定的網址，檢查狀態碼、元素、文字與載入時間等斷言，
+// 以 Prometheus 格式輸出通過與失敗的指標，並在檢查由通過轉為失敗（或恢復）時送出 webhook 告警。
+//
+// 設定檔為 YAML（或 JSON）：
+//
+//	webhook: https://hooks.example.com/cdpkit
+//	checks:
+//	  - name: 首頁
+//	    url: https://example.com/
+//	    interval: 1m
+//	    assert:
+//	      status: 200
+//	      selectors: ["#main", "footer"]
+//	      text: "(?i)welcome"
+//	      max_load_time: 3s
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/firehourse/cdpkit/browser"
+	"github.com/firehourse/cdpkit/config"
+	"github.com/firehourse/cdpkit/domains"
+	"github.com/firehourse/cdpkit/tab"
+)
+
+// Check 一個監控項目
+type Check struct {
+	// Name 指標與告警中的名稱，留空時使用 URL
+	Name string `json:"name,omitempty"`
+	URL  string `json:"url"`
+	// Interval 檢查間隔，預設 5 分鐘
+	Interval domains.Duration `json:"interval,omitempty"`
+	// Timeout 載入與斷言的總期限，預設 30 秒
+	Timeout domains.Duration `json:"timeout,omitempty"`
+	Assert  Assertions       `json:"assert,omitempty"`
+}
+
+// Assertions 檢查的斷言；零值欄位不檢查，全部為零值時只要求狀態碼小於 400
+type Assertions struct {
+	// Status 主文件預期的 HTTP 狀態碼
+	Status int `json:"status,omitempty"`
+	// Selectors 須存在於頁面的元素，在 Timeout 內等待出現
+	Selectors []string `json:"selectors,omitempty"`
+	// Text 頁面可見文字須符合的正規表示式；TextAbsent 則不可符合，例如錯誤訊息
+	Text       string `json:"text,omitempty"`
+	TextAbsent string `json:"text_absent,omitempty"`
+	// MaxLoadTime 導航到 load 事件的時間上限
+	MaxLoadTime domains.Duration `json:"max_load_time,omitempty"`
+}
+
+// Config 監控設定檔
+type Config struct {
+	Checks []Check `json:"checks"`
+	// Webhook 狀態改變時 POST 告警的網址；WebhookSecret 設定時以 HMAC-SHA256 簽署，
+	// 放在 X-Cdpkit-Signature: sha256=<hex>，與 output.WebhookSink 相同
+	Webhook       string `json:"webhook,omitempty"`
+	WebhookSecret string `json:"webhook_secret,omitempty"`
+}
+
+// LoadConfig 讀取設定檔；副檔名為 .json 時以 JSON 解析，其餘以 YAML 解析
+func LoadConfig(path string) (Config, error) {
+	var c Config
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return c, fmt.Errorf("無法讀取監控設定 %s: %w", path, err)
+	}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(data, &c)
+	} else {
+		err = config.UnmarshalYAML(data, &c)
+	}
+	if err != nil {
+		return c, fmt.Errorf("無法解析監控設定 %s: %w", path, err)
+	}
+	for i, ch := range c.Checks {
+		if ch.URL == "" {
+			return c, fmt.Errorf("監控設定 %s: 第 %d 個檢查沒有 url", path, i+1)
+		}
+		if _, err := compileAssertions(ch.Assert); err != nil {
+			return c, fmt.Errorf("監控設定 %s: %s: %w", path, ch.label(), err)
+		}
+	}
+	return c, nil
+}
+
+// Outcome 一次檢查的結果
+type Outcome struct {
+	Check string `json:"check"`
+	URL   string `json:"url"`
+	// OK 所有斷言都通過
+	OK bool `json:"ok"`
+	// Failures 未通過的斷言說明；導航失敗時為錯誤訊息
+	Failures []string      `json:"failures,omitempty"`
+	Status   int           `json:"status,omitempty"`
+	LoadTime time.Duration `json:"load_time"`
+	Time     time.Time     `json:"time"`
+}
+
+// Options Monitor 的選項
+type Options struct {
+	// Layers 每次檢查開啟分頁時套用的設定，例如裝置、代理所在地的時區
+	Layers config.Layered
+	// Concurrency 同時進行的檢查數，預設 2
+	Concurrency int
+	// Webhook、WebhookSecret 取代 Config 中的同名欄位
+	Webhook       string
+	WebhookSecret string
+	// OnOutcome 每次檢查完成後呼叫
+	OnOutcome func(Outcome)
+	// Client 送出 webhook 的 HTTP 用戶端，nil 時使用逾時 10 秒的用戶端
+	Client *http.Client
+}
+
+// Monitor 依排程執行檢查並保存各項目的最新狀態
+type Monitor struct {
+	bm     *browser.BrowserManager
+	checks []Check
+	opts   Options
+	sem    chan struct{}
+
+	mu    sync.Mutex
+	stats map[string]*checkStats
+}
+
+// New 建立 Monitor；checks 通常來自 LoadConfig，webhook 未在 opts 指定時沿用 cfg 的設定
+func New(bm *browser.BrowserManager, cfg Config, opts Options) *Monitor {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 2
+	}
+	if opts.Webhook == "" {
+		opts.Webhook, opts.WebhookSecret = cfg.Webhook, cfg.WebhookSecret
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Monitor{
+		bm:     bm,
+		checks: cfg.Checks,
+		opts:   opts,
+		sem:    make(chan struct{}, opts.Concurrency),
+		stats:  map[string]*checkStats{},
+	}
+}
+
+// Run 立即執行所有檢查，之後依各自的間隔重複，直到 ctx 結束
+func (m *Monitor) Run(ctx context.Context) error {
+	if len(m.checks) == 0 {
+		return fmt.Errorf("沒有設定任何檢查")
+	}
+	var wg sync.WaitGroup
+	for _, ch := range m.checks {
+		wg.Add(1)
+		go func(ch Check) {
+			defer wg.Done()
+			interval := time.Duration(ch.Interval)
+			if interval <= 0 {
+				interval = 5 * time.Minute
+			}
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				m.runScheduled(ctx, ch)
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}(ch)
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+// RunOnce 執行一次檢查並記錄結果、送出需要的告警
+func (m *Monitor) RunOnce(ctx context.Context, ch Check) Outcome {
+	o := m.evaluate(ctx, ch)
+	if ctx.Err() != nil {
+		return o
+	}
+	changed := m.record(o)
+	if m.opts.OnOutcome != nil {
+		m.opts.OnOutcome(o)
+	}
+	if changed && m.opts.Webhook != "" {
+		if err := m.alert(o); err != nil {
+			log.Printf("[cdpkit] 監控告警送出失敗: %v", err)
+		}
+	}
+	return o
+}
+
+// MetricsHandler 以 Prometheus 文字格式輸出各檢查的指標：
+// cdpkit_monitor_up、cdpkit_monitor_checks_total、cdpkit_monitor_load_seconds 與 cdpkit_monitor_last_check_timestamp_seconds
+func (m *Monitor) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		m.writeMetrics(w)
+	})
+}
+
+// ----------------- 內部實作 -----------------
+
+// checkStats 單一檢查的累計統計
+type checkStats struct {
+	url    string
+	passed int
+	failed int
+	last   Outcome
+	// alertedState 最近一次告警（或第一次檢查）時是否通過；nil 表示尚未檢查過
+	alertedState *bool
+}
+
+func (ch Check) label() string {
+	if ch.Name != "" {
+		return ch.Name
+	}
+	return ch.URL
+}
+
+// compiledAssertions 已編譯的文字斷言
+type compiledAssertions struct {
+	text, absent *regexp.Regexp
+}
+
+func compileAssertions(a Assertions) (compiledAssertions, error) {
+	var c compiledAssertions
+	var err error
+	if a.Text != "" {
+		if c.text, err = regexp.Compile(a.Text); err != nil {
+			return c, fmt.Errorf("無效的 text: %w", err)
+		}
+	}
+	if a.TextAbsent != "" {
+		if c.absent, err = regexp.Compile(a.TextAbsent); err != nil {
+			return c, fmt.Errorf("無效的 text_absent: %w", err)
+		}
+	}
+	return c, nil
+}
+
+func (m *Monitor) runScheduled(ctx context.Context, ch Check) {
+	select {
+	case m.sem <- struct{}{}:
+	case <-ctx.Done():
+		return
+	}
+	defer func() { <-m.sem }()
+	m.RunOnce(ctx, ch)
+}
+
+// evaluate 以新分頁載入網址並檢查所有斷言
+func (m *Monitor) evaluate(ctx context.Context, ch Check) Outcome {
+	o := Outcome{Check: ch.label(), URL: ch.URL, Time: time.Now()}
+	fail := func(format string, args ...interface{}) {
+		o.Failures = append(o.Failures, fmt.Sprintf(format, args...))
+	}
+	compiled, err := compileAssertions(ch.Assert)
+	if err != nil {
+		fail("%v", err)
+		return o
+	}
+	timeout := time.Duration(ch.Timeout)
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	deadline := time.Now().Add(timeout)
+
+	tabCtx, cancel, err := m.bm.NewPageContext()
+	if err != nil {
+		fail("創建分頁失敗: %v", err)
+		return o
+	}
+	// ctx 結束時中止進行中的檢查
+	stop := context.AfterFunc(ctx, cancel)
+	defer stop()
+	t := tab.Open(tabCtx, cancel, m.opts.Layers)
+	defer t.Close(m.bm)
+
+	start := time.Now()
+	err = t.Navigate(ch.URL, timeout)
+	o.LoadTime = time.Since(start)
+	if err != nil {
+		fail("導航失敗: %v", err)
+		return o
+	}
+	o.Status = documentStatus(t)
+
+	a := ch.Assert
+	switch {
+	case a.Status != 0 && o.Status != a.Status:
+		fail("狀態碼為 %d，預期 %d", o.Status, a.Status)
+	case a.Status == 0 && o.Status >= 400:
+		fail("狀態碼為 %d", o.Status)
+	}
+	if limit := time.Duration(a.MaxLoadTime); limit > 0 && o.LoadTime > limit {
+		fail("載入時間 %v 超過 %v", o.LoadTime.Round(time.Millisecond), limit)
+	}
+	if len(a.Selectors) > 0 {
+		conds := make([]tab.Condition, len(a.Selectors))
+		for i, sel := range a.Selectors {
+			conds[i] = tab.Present(sel)
+		}
+		if err := t.WaitAll(remaining(deadline), conds...); err != nil {
+			fail("元素不存在: %v", err)
+		}
+	}
+	if compiled.text != nil || compiled.absent != nil {
+		v, err := t.RunJS("document.body ? document.body.innerText : ''", remaining(deadline))
+		text, _ := v.(string)
+		switch {
+		case err != nil:
+			fail("無法取得頁面文字: %v", err)
+		default:
+			if compiled.text != nil && !compiled.text.MatchString(text) {
+				fail("頁面文字不符合 %q", a.Text)
+			}
+			if compiled.absent != nil && compiled.absent.MatchString(text) {
+				fail("頁面文字出現 %q", a.TextAbsent)
+			}
+		}
+	}
+	o.OK = len(o.Failures) == 0
+	return o
+}
+
+// remaining 距離期限的時間，至少 1 秒，避免期限已過時改用分頁的預設逾時
+func remaining(deadline time.Time) time.Duration {
+	if d := time.Until(deadline); d > time.Second {
+		return d
+	}
+	return time.Second
+}
+
+// documentStatus 主框架目前文件的狀態碼；找不到時為 0
+func documentStatus(t *tab.Tab) int {
+	docURL := t.DocumentURL()
+	status := 0
+	for _, r := range t.Responses() {
+		if r.ResourceType != network.ResourceTypeDocument {
+			continue
+		}
+		if r.URL == docURL {
+			return int(r.Status)
+		}
+		if status == 0 {
+			status = int(r.Status)
+		}
+	}
+	return status
+}
+
+// record 更新統計，回傳是否需要告警：第一次失敗、由通過轉為失敗或由失敗恢復
+func (m *Monitor) record(o Outcome) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := m.stats[o.Check]
+	if s == nil {
+		s = &checkStats{url: o.URL}
+		m.stats[o.Check] = s
+	}
+	if o.OK {
+		s.passed++
+	} else {
+		s.failed++
+	}
+	s.last = o
+	// 第一次通過不告警
+	if s.alertedState == nil {
+		ok := o.OK
+		s.alertedState = &ok
+		return !o.OK
+	}
+	if *s.alertedState == o.OK {
+		return false
+	}
+	*s.alertedState = o.OK
+	return true
+}
+
+// alertPayload webhook 告警的主體
+type alertPayload struct {
+	// State "failing" 或 "recovered"
+	State string `json:"state"`
+	Outcome
+}
+
+func (m *Monitor) alert(o Outcome) error {
+	state := "failing"
+	if o.OK {
+		state = "recovered"
+	}
+	body, err := json.Marshal(alertPayload{State: state, Outcome: o})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, m.opts.Webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if m.opts.WebhookSecret != "" {
+		mac := hmac.New(sha256.New, []byte(m.opts.WebhookSecret))
+		mac.Write(body)
+		req.Header.Set("X-Cdpkit-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	resp, err := m.opts.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook 回應 %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (m *Monitor) writeMetrics(w http.ResponseWriter) {
+	m.mu.Lock()
+	names := make([]string, 0, len(m.stats))
+	for name := range m.stats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	b.WriteString("# HELP cdpkit_monitor_up 最近一次檢查是否通過\n# TYPE cdpkit_monitor_up gauge\n")
+	for _, name := range names {
+		s := m.stats[name]
+		up := 0
+		if s.last.OK {
+			up = 1
+		}
+		fmt.Fprintf(&b, "cdpkit_monitor_up{%s} %d\n", labels(name, s.url), up)
+	}
+	b.WriteString("# HELP cdpkit_monitor_checks_total 檢查次數\n# TYPE cdpkit_monitor_checks_total counter\n")
+	for _, name := range names {
+		s := m.stats[name]
+		fmt.Fprintf(&b, "cdpkit_monitor_checks_total{%s,result=\"pass\"} %d\n", labels(name, s.url), s.passed)
+		fmt.Fprintf(&b, "cdpkit_monitor_checks_total{%s,result=\"fail\"} %d\n", labels(name, s.url), s.failed)
+	}
+	b.WriteString("# HELP cdpkit_monitor_load_seconds 最近一次檢查的載入時間\n# TYPE cdpkit_monitor_load_seconds gauge\n")
+	for _, name := range names {
+		s := m.stats[name]
+		fmt.Fprintf(&b, "cdpkit_monitor_load_seconds{%s} %g\n", labels(name, s.url), s.last.LoadTime.Seconds())
+	}
+	b.WriteString("# HELP cdpkit_monitor_last_check_timestamp_seconds 最近一次檢查的時間\n# TYPE cdpkit_monitor_last_check_timestamp_seconds gauge\n")
+	for _, name := range names {
+		s := m.stats[name]
+		fmt.Fprintf(&b, "cdpkit_monitor_last_check_timestamp_seconds{%s} %d\n", labels(name, s.url), s.last.Time.Unix())
+	}
+	m.mu.Unlock()
+	w.Write([]byte(b.String()))
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func labels(name, url string) string {
+	return fmt.Sprintf(`check="%s",url="%s"`, labelEscaper.Replace(name), labelEscaper.Replace(url))
+}
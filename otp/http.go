@@ -0,0 +1,78 @@
+package otp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// HTTPFetcher 輪詢一個回傳最新簡訊/郵件內容的 HTTP 端點
+// 適用於多數 SMS 接收服務（Twilio webhook 暫存、簡訊轉發 API 等）
+type HTTPFetcher struct {
+	// URL 端點位址；Recipient、From 會以 query 參數 recipient、from 附加
+	URL string
+	// Header 額外請求標頭，例如 Authorization
+	Header http.Header
+	// Client 可自訂 HTTP client；nil 時使用 http.DefaultClient
+	Client *http.Client
+}
+
+// FetchCode 實作 Fetcher
+func (f *HTTPFetcher) FetchCode(ctx context.Context, req Request) (string, error) {
+	return Poll(ctx, req.PollInterval, func(ctx context.Context) (string, error) {
+		return f.fetchOnce(ctx, req)
+	})
+}
+
+func (f *HTTPFetcher) fetchOnce(ctx context.Context, req Request) (string, error) {
+	u, err := url.Parse(f.URL)
+	if err != nil {
+		return "", fmt.Errorf("無效的端點 URL: %w", err)
+	}
+	q := u.Query()
+	if req.Recipient != "" {
+		q.Set("recipient", req.Recipient)
+	}
+	if req.From != "" {
+		q.Set("from", req.From)
+	}
+	u.RawQuery = q.Encode()
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	for k, vs := range f.Header {
+		for _, v := range vs {
+			httpReq.Header.Add(k, v)
+		}
+	}
+
+	client := f.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("請求驗證碼端點失敗: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusNoContent {
+		return "", ErrNoCode
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("驗證碼端點回應 %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+	if code, ok := ExtractCode(string(body), req.Pattern); ok {
+		return code, nil
+	}
+	return "", ErrNoCode
+}
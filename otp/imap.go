@@ -0,0 +1,246 @@
+package otp
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IMAPFetcher 透過 IMAP 輪詢收件匣取得驗證碼
+// 只使用最基本的 IMAP4rev1 指令，相容 Gmail、Outlook 等主流服務
+type IMAPFetcher struct {
+	// Addr 伺服器位址，例如 imap.gmail.com:993
+	Addr     string
+	Username string
+	Password string
+	// Mailbox 要搜尋的信箱，空字串表示 INBOX
+	Mailbox string
+	// Insecure 為 true 時使用明文連線（僅供本地測試伺服器）
+	Insecure bool
+	// MarkSeen 取得驗證碼後將該郵件標為已讀，避免下次重複使用
+	MarkSeen bool
+	// DialTimeout 連線逾時；<=0 則退回 10 秒
+	DialTimeout time.Duration
+}
+
+// FetchCode 實作 Fetcher：每次輪詢都重新連線，搜尋最新符合條件的未讀郵件
+func (f *IMAPFetcher) FetchCode(ctx context.Context, req Request) (string, error) {
+	return Poll(ctx, req.PollInterval, func(ctx context.Context) (string, error) {
+		return f.fetchOnce(ctx, req)
+	})
+}
+
+func (f *IMAPFetcher) fetchOnce(ctx context.Context, req Request) (string, error) {
+	c, err := f.dial(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer c.close()
+
+	if _, err := c.cmd("LOGIN %s %s", quote(f.Username), quote(f.Password)); err != nil {
+		return "", fmt.Errorf("IMAP 登入失敗: %w", err)
+	}
+
+	mailbox := f.Mailbox
+	if mailbox == "" {
+		mailbox = "INBOX"
+	}
+	if _, err := c.cmd("SELECT %s", quote(mailbox)); err != nil {
+		return "", fmt.Errorf("無法選擇信箱 %s: %w", mailbox, err)
+	}
+
+	uids, err := c.search(req)
+	if err != nil {
+		return "", err
+	}
+
+	// 由新到舊檢查，取第一封含有驗證碼的郵件
+	for i := len(uids) - 1; i >= 0; i-- {
+		date, body, err := c.fetchBody(uids[i])
+		if err != nil {
+			return "", err
+		}
+		if !req.Since.IsZero() && !date.IsZero() && date.Before(req.Since) {
+			continue
+		}
+		code, ok := ExtractCode(body, req.Pattern)
+		if !ok {
+			continue
+		}
+		if f.MarkSeen {
+			if _, err := c.cmd(`UID STORE %d +FLAGS (\Seen)`, uids[i]); err != nil {
+				log.Printf("[cdpkit] 警告：無法將郵件 %d 標為已讀: %v", uids[i], err)
+			}
+		}
+		log.Printf("[cdpkit] 從 IMAP 取得驗證碼 (UID %d)", uids[i])
+		return code, nil
+	}
+	return "", ErrNoCode
+}
+
+func (f *IMAPFetcher) dial(ctx context.Context) (*imapConn, error) {
+	timeout := f.DialTimeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	dialer := &net.Dialer{Timeout: timeout}
+
+	var conn net.Conn
+	var err error
+	if f.Insecure {
+		conn, err = dialer.DialContext(ctx, "tcp", f.Addr)
+	} else {
+		host, _, _ := net.SplitHostPort(f.Addr)
+		td := &tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: host}}
+		conn, err = td.DialContext(ctx, "tcp", f.Addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("無法連線 IMAP 伺服器 %s: %w", f.Addr, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	c := &imapConn{conn: conn, r: bufio.NewReader(conn)}
+	// 讀取伺服器問候
+	if _, err := c.r.ReadString('\n'); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("讀取 IMAP 問候失敗: %w", err)
+	}
+	return c, nil
+}
+
+// ----------------- IMAP 最小實作 -----------------
+
+type imapConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	seq  int
+}
+
+func (c *imapConn) close() {
+	c.cmd("LOGOUT")
+	c.conn.Close()
+}
+
+// cmd 送出指令並讀取至對應的 tagged 回應，回傳所有 untagged 行（literal 已內嵌）
+func (c *imapConn) cmd(format string, args ...interface{}) ([]string, error) {
+	c.seq++
+	tag := fmt.Sprintf("a%03d", c.seq)
+	if _, err := fmt.Fprintf(c.conn, "%s %s\r\n", tag, fmt.Sprintf(format, args...)); err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(line, tag+" ") {
+			status := strings.TrimPrefix(line, tag+" ")
+			if !strings.HasPrefix(status, "OK") {
+				return lines, fmt.Errorf("IMAP 回應錯誤: %s", status)
+			}
+			return lines, nil
+		}
+		lines = append(lines, line)
+	}
+}
+
+var literalRe = regexp.MustCompile(`\{(\d+)\}$`)
+
+// readLine 讀取一個邏輯行，若行尾為 {n} literal 則一併讀入後續位元組
+func (c *imapConn) readLine() (string, error) {
+	var sb strings.Builder
+	for {
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		m := literalRe.FindStringSubmatch(line)
+		if m == nil {
+			sb.WriteString(line)
+			return sb.String(), nil
+		}
+		n, _ := strconv.Atoi(m[1])
+		sb.WriteString(line[:len(line)-len(m[0])])
+		buf := make([]byte, n)
+		if _, err := io.ReadFull(c.r, buf); err != nil {
+			return "", err
+		}
+		sb.Write(buf)
+	}
+}
+
+func (c *imapConn) search(req Request) ([]int, error) {
+	criteria := []string{"UNSEEN"}
+	if !req.Since.IsZero() {
+		criteria = append(criteria, "SINCE", req.Since.Format("02-Jan-2006"))
+	}
+	if req.From != "" {
+		criteria = append(criteria, "FROM", quote(req.From))
+	}
+	if req.Recipient != "" {
+		criteria = append(criteria, "TO", quote(req.Recipient))
+	}
+	if req.Subject != "" {
+		criteria = append(criteria, "SUBJECT", quote(req.Subject))
+	}
+
+	lines, err := c.cmd("UID SEARCH %s", strings.Join(criteria, " "))
+	if err != nil {
+		return nil, fmt.Errorf("IMAP 搜尋失敗: %w", err)
+	}
+
+	var uids []int
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "* SEARCH") {
+			continue
+		}
+		for _, f := range strings.Fields(strings.TrimPrefix(line, "* SEARCH")) {
+			if n, err := strconv.Atoi(f); err == nil {
+				uids = append(uids, n)
+			}
+		}
+	}
+	return uids, nil
+}
+
+var internalDateRe = regexp.MustCompile(`INTERNALDATE "([^"]+)"`)
+
+func (c *imapConn) fetchBody(uid int) (time.Time, string, error) {
+	lines, err := c.cmd("UID FETCH %d (INTERNALDATE BODY.PEEK[TEXT])", uid)
+	if err != nil {
+		return time.Time{}, "", fmt.Errorf("讀取郵件 %d 失敗: %w", uid, err)
+	}
+
+	var date time.Time
+	var body strings.Builder
+	for _, line := range lines {
+		if m := internalDateRe.FindStringSubmatch(line); m != nil {
+			date, _ = time.Parse("02-Jan-2006 15:04:05 -0700", strings.TrimSpace(m[1]))
+		}
+		// 只保留 BODY[TEXT] 之後的內容，避免 UID 或日期被誤認為驗證碼
+		if i := strings.Index(line, "BODY[TEXT] "); i >= 0 {
+			body.WriteString(line[i+len("BODY[TEXT] "):])
+			body.WriteString("\n")
+		}
+	}
+	return date, body.String(), nil
+}
+
+func quote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
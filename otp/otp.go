@@ -0,0 +1,86 @@
+package otp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// ErrNoCode 表示在來源中找不到符合條件的驗證碼
+var ErrNoCode = errors.New("otp: 找不到驗證碼")
+
+// DefaultPattern 預設的驗證碼格式：4~8 位數字
+var DefaultPattern = regexp.MustCompile(`\b(\d{4,8})\b`)
+
+// Request 描述要取得的驗證碼條件
+type Request struct {
+	// Recipient 收件者（Email 地址或手機號碼），由來源自行解讀
+	Recipient string
+	// From 寄件者過濾，例如 no-reply@example.com
+	From string
+	// Subject 主旨需包含的字串
+	Subject string
+	// Since 只接受此時間之後收到的訊息；零值表示不限制
+	Since time.Time
+	// Pattern 擷取驗證碼的正規表示式；若有子群組則取第一個子群組，nil 時使用 DefaultPattern
+	Pattern *regexp.Regexp
+	// PollInterval 輪詢間隔；<=0 則退回 5 秒
+	PollInterval time.Duration
+}
+
+// Fetcher 從外部來源（Email、SMS 閘道等）取得一次性驗證碼
+// 實作應在 ctx 結束前持續嘗試，找不到時回傳 ErrNoCode
+type Fetcher interface {
+	FetchCode(ctx context.Context, req Request) (string, error)
+}
+
+// FetcherFunc 讓一般函式滿足 Fetcher 介面
+type FetcherFunc func(ctx context.Context, req Request) (string, error)
+
+// FetchCode 實作 Fetcher
+func (f FetcherFunc) FetchCode(ctx context.Context, req Request) (string, error) {
+	return f(ctx, req)
+}
+
+// ExtractCode 依 pattern 從文字中擷取驗證碼
+func ExtractCode(text string, pattern *regexp.Regexp) (string, bool) {
+	if pattern == nil {
+		pattern = DefaultPattern
+	}
+	m := pattern.FindStringSubmatch(text)
+	if m == nil {
+		return "", false
+	}
+	if len(m) > 1 && m[1] != "" {
+		return m[1], true
+	}
+	return m[0], true
+}
+
+// Poll 以固定間隔呼叫 try，直到取得驗證碼或 ctx 結束
+// try 回傳 ErrNoCode 表示尚未收到，其他錯誤會直接中止
+func Poll(ctx context.Context, interval time.Duration, try func(ctx context.Context) (string, error)) (string, error) {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		code, err := try(ctx)
+		if err == nil {
+			return code, nil
+		}
+		if !errors.Is(err, ErrNoCode) {
+			return "", err
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("等待驗證碼逾時: %w", ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,442 @@
+// Package pageaudit 將載入效能、JS 與 CSS 覆蓋率、console 錯誤、混合內容與無障礙樹的檢查合併為每個網址一份計分報告。
+// 計分方式參考 Lighthouse：每項檢查得到 0 到 1 的分數，依權重平均為類別分數（0 到 100），類別再平均為總分。
+//
+//	rec, err := pageaudit.Start(t, pageaudit.Options{})
+//	t.Navigate(url, 30*time.Second)
+//	report, err := rec.Finish(url)
+//	fmt.Println(report.Score, report.Categories)
+package pageaudit
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/firehourse/cdpkit/tab"
+)
+
+// 報告的類別
+const (
+	CategoryPerformance   = "performance"
+	CategoryAccessibility = "accessibility"
+	CategoryBestPractices = "best-practices"
+)
+
+// Categories 報告的類別，依顯示順序
+var Categories = []string{CategoryPerformance, CategoryAccessibility, CategoryBestPractices}
+
+// maxItems 每項檢查列出的對象上限
+const maxItems = 20
+
+// Options 稽核的設定，零值以桌面版門檻計分並記錄覆蓋率
+type Options struct {
+	// Mobile 以 Lighthouse 行動版的門檻為效能計分；行動版假設較慢的網路與 CPU，門檻較寬鬆
+	Mobile bool
+	// SkipCoverage 不記錄 JS 與 CSS 覆蓋率，報告不含 unused-javascript 與 unused-css-rules；
+	// 覆蓋率須在載入期間由 V8 追蹤，會拖慢腳本多的頁面
+	SkipCoverage bool
+}
+
+// Report 一個網址的稽核報告
+type Report struct {
+	URL string `json:"url"`
+	// Score 各類別分數的平均，0 到 100
+	Score int `json:"score"`
+	// Categories 各類別的分數，0 到 100；沒有適用檢查的類別不列出
+	Categories map[string]int `json:"categories"`
+	Audits     []Audit        `json:"audits"`
+	Vitals     *tab.WebVitals `json:"vitals,omitempty"`
+	// Coverage 各腳本與樣式表的覆蓋率，依未使用的位元組由多到少排列
+	Coverage []tab.CoverageEntry `json:"coverage,omitempty"`
+	// ConsoleErrors 載入期間 error 層級的 console 訊息、未捕捉的例外與瀏覽器記錄的錯誤
+	ConsoleErrors []tab.ConsoleMessage `json:"console_errors,omitempty"`
+}
+
+// Audit 一項檢查的結果
+type Audit struct {
+	ID       string `json:"id"`
+	Category string `json:"category"`
+	Title    string `json:"title"`
+	// Score 0 到 1，1 表示通過
+	Score float64 `json:"score"`
+	// Weight 計算類別分數時的權重
+	Weight float64 `json:"weight"`
+	// NotApplicable 頁面沒有適用的對象（例如沒有圖片），不計入類別分數
+	NotApplicable bool `json:"not_applicable,omitempty"`
+	// DisplayValue 量測值，例如 "1.8 s"、"3 個元素"
+	DisplayValue string `json:"display_value,omitempty"`
+	// Items 未通過的對象，例如資源網址或缺少名稱的元素，最多 20 個
+	Items []string `json:"items,omitempty"`
+}
+
+// Failed 回傳未通過（分數低於 0.9）的檢查
+func (r *Report) Failed() []Audit {
+	var out []Audit
+	for _, a := range r.Audits {
+		if !a.NotApplicable && a.Score < 0.9 {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// Recorder 一次頁面載入的稽核，由 Start 開始記錄、Finish 產生報告
+type Recorder struct {
+	t        *tab.Tab
+	opts     Options
+	coverage bool
+}
+
+// Start 開始記錄 console 訊息與覆蓋率，應於導航前呼叫；無法記錄覆蓋率時回傳錯誤，Recorder 仍可使用，報告不含覆蓋率
+func Start(t *tab.Tab, opts Options) (*Recorder, error) {
+	r := &Recorder{t: t, opts: opts}
+	t.CaptureConsole()
+	if opts.SkipCoverage {
+		return r, nil
+	}
+	if err := t.StartCoverage(); err != nil {
+		return r, err
+	}
+	r.coverage = true
+	return r, nil
+}
+
+// Finish 於頁面載入完成後取得效能指標、覆蓋率與無障礙樹並計分；pageURL 為主文件的網址，用於判斷 HTTPS 與混合內容。
+// 部分資料無法取得時仍回傳以其餘資料計分的報告，並以錯誤說明缺少的部分
+func (r *Recorder) Finish(pageURL string) (*Report, error) {
+	var errs []error
+	in := input{url: pageURL, mobile: r.opts.Mobile, console: r.t.ConsoleMessages(), responses: r.t.Responses()}
+	vitals, err := r.t.WebVitals()
+	if err != nil {
+		errs = append(errs, err)
+	}
+	in.vitals = vitals
+	if r.coverage {
+		r.coverage = false
+		if in.coverage, err = r.t.TakeCoverage(); err != nil {
+			errs = append(errs, err)
+		}
+		in.hasCoverage = err == nil
+	}
+	if in.ax, err = r.t.AccessibilityTree(tab.AXTreeOptions{}); err != nil {
+		errs = append(errs, err)
+	}
+	return build(in), errors.Join(errs...)
+}
+
+// ----------------- 內部實作 -----------------
+
+// input 計分所需的資料；vitals、ax 為 nil 或 hasCoverage 為 false 時略過對應的檢查
+type input struct {
+	url         string
+	mobile      bool
+	vitals      *tab.WebVitals
+	coverage    []tab.CoverageEntry
+	hasCoverage bool
+	console     []tab.ConsoleMessage
+	responses   []tab.Response
+	ax          *tab.AXNode
+}
+
+// build 執行各項檢查並計算類別與總分
+func build(in input) *Report {
+	r := &Report{URL: in.url, Vitals: in.vitals, Categories: map[string]int{}}
+	if in.hasCoverage {
+		r.Coverage = in.coverage
+	}
+	for _, m := range in.console {
+		if m.Level == "error" {
+			r.ConsoleErrors = append(r.ConsoleErrors, m)
+		}
+	}
+
+	r.Audits = append(r.Audits, performanceAudits(in)...)
+	r.Audits = append(r.Audits, accessibilityAudits(in.ax)...)
+	r.Audits = append(r.Audits, bestPracticeAudits(in, r.ConsoleErrors)...)
+
+	var total float64
+	var n int
+	for _, cat := range Categories {
+		var sum, weights float64
+		for _, a := range r.Audits {
+			if a.Category == cat && !a.NotApplicable {
+				sum += a.Score * a.Weight
+				weights += a.Weight
+			}
+		}
+		if weights == 0 {
+			continue
+		}
+		score := sum / weights * 100
+		r.Categories[cat] = int(math.Round(score))
+		total += score
+		n++
+	}
+	if n > 0 {
+		r.Score = int(math.Round(total / float64(n)))
+	}
+	return r
+}
+
+// thresholds 效能指標計分曲線的第 10 百分位與中位數：量測值等於 p10 得 0.9 分、等於中位數得 0.5 分
+type thresholds struct{ p10, median float64 }
+
+// Lighthouse 10 的門檻；時間以毫秒計
+var (
+	desktopThresholds = map[string]thresholds{
+		"first-contentful-paint":   {934, 1600},
+		"largest-contentful-paint": {1200, 2400},
+		"total-blocking-time":      {150, 350},
+		"cumulative-layout-shift":  {0.1, 0.25},
+	}
+	mobileThresholds = map[string]thresholds{
+		"first-contentful-paint":   {1800, 3000},
+		"largest-contentful-paint": {2500, 4000},
+		"total-blocking-time":      {200, 600},
+		"cumulative-layout-shift":  {0.1, 0.25},
+	}
+)
+
+// 未使用的 JS 與 CSS 的計分門檻（KiB）
+var unusedThresholds = thresholds{p10: 20, median: 100}
+
+func performanceAudits(in input) []Audit {
+	var audits []Audit
+	if v := in.vitals; v != nil {
+		th := desktopThresholds
+		if in.mobile {
+			th = mobileThresholds
+		}
+		metric := func(id, title string, weight, value float64, display string, measured bool) {
+			a := Audit{ID: id, Category: CategoryPerformance, Title: title, Weight: weight}
+			if measured {
+				a.Score, a.DisplayValue = logNormalScore(value, th[id]), display
+			} else {
+				a.NotApplicable = true
+			}
+			audits = append(audits, a)
+		}
+		// 瀏覽器未記錄 FCP、LCP（例如沒有內容的頁面）時不計分
+		metric("first-contentful-paint", "First Contentful Paint", 10, ms(v.FCP), seconds(v.FCP), v.FCP > 0)
+		metric("largest-contentful-paint", "Largest Contentful Paint", 25, ms(v.LCP), seconds(v.LCP), v.LCP > 0)
+		metric("total-blocking-time", "Total Blocking Time", 30, ms(v.TBT), fmt.Sprintf("%d ms", v.TBT.Milliseconds()), true)
+		metric("cumulative-layout-shift", "Cumulative Layout Shift", 25, v.CLS, fmt.Sprintf("%.3f", v.CLS), true)
+	}
+	if in.hasCoverage {
+		audits = append(audits,
+			unusedAudit("unused-javascript", "減少未使用的 JavaScript", "script", in.coverage),
+			unusedAudit("unused-css-rules", "減少未使用的 CSS", "stylesheet", in.coverage))
+	}
+	return audits
+}
+
+// unusedAudit 依未使用的位元組計分，列出未使用超過 2 KiB 的資源
+func unusedAudit(id, title, typ string, coverage []tab.CoverageEntry) Audit {
+	a := Audit{ID: id, Category: CategoryPerformance, Title: title, Weight: 5}
+	var unused, total int64
+	for _, e := range coverage {
+		if e.Type != typ {
+			continue
+		}
+		unused += e.UnusedBytes()
+		total += e.TotalBytes
+		if e.UnusedBytes() >= 2048 && len(a.Items) < maxItems {
+			a.Items = append(a.Items, fmt.Sprintf("%s（未使用 %s / %s）", e.URL, kib(e.UnusedBytes()), kib(e.TotalBytes)))
+		}
+	}
+	if total == 0 {
+		a.NotApplicable = true
+		return a
+	}
+	a.Score = logNormalScore(float64(unused)/1024, unusedThresholds)
+	a.DisplayValue = "未使用 " + kib(unused)
+	return a
+}
+
+// accessibilityAudits 以無障礙樹檢查文件標題、元素的可存取名稱與標題層級；權重取自 Lighthouse
+func accessibilityAudits(root *tab.AXNode) []Audit {
+	if root == nil {
+		return nil
+	}
+	type check struct {
+		id, title string
+		weight    float64
+		roles     []string
+	}
+	checks := []check{
+		{"image-alt", "圖片有替代文字", 10, []string{"image", "img"}},
+		{"button-name", "按鈕有可存取名稱", 10, []string{"button"}},
+		{"link-name", "連結有可存取名稱", 7, []string{"link"}},
+		{"label", "表單欄位有標籤", 7, []string{"textbox", "searchbox", "combobox", "listbox", "checkbox", "radio", "slider", "spinbutton", "switch"}},
+	}
+	applicable := make([]int, len(checks))
+	missing := make([][]string, len(checks))
+
+	var headings []int
+	var walk func(n *tab.AXNode)
+	walk = func(n *tab.AXNode) {
+		for i, c := range checks {
+			if !hasRole(c.roles, n.Role) {
+				continue
+			}
+			applicable[i]++
+			if strings.TrimSpace(n.Name) == "" {
+				missing[i] = append(missing[i], describeNode(n))
+			}
+		}
+		if n.Role == "heading" {
+			if level, ok := n.Properties["level"].(float64); ok {
+				headings = append(headings, int(level))
+			}
+		}
+		for _, child := range n.Children {
+			walk(child)
+		}
+	}
+	walk(root)
+
+	title := Audit{ID: "document-title", Category: CategoryAccessibility, Title: "文件有標題", Weight: 7, Score: 1}
+	if root.Role == "RootWebArea" && strings.TrimSpace(root.Name) == "" {
+		title.Score = 0
+	}
+	audits := []Audit{title}
+	for i, c := range checks {
+		a := Audit{ID: c.id, Category: CategoryAccessibility, Title: c.title, Weight: c.weight}
+		switch {
+		case applicable[i] == 0:
+			a.NotApplicable = true
+		case len(missing[i]) == 0:
+			a.Score = 1
+		default:
+			a.DisplayValue = fmt.Sprintf("%d 個元素", len(missing[i]))
+			a.Items = limit(missing[i])
+		}
+		audits = append(audits, a)
+	}
+
+	order := Audit{ID: "heading-order", Category: CategoryAccessibility, Title: "標題層級依序遞增", Weight: 3, Score: 1}
+	order.NotApplicable = len(headings) == 0
+	for i := 1; i < len(headings); i++ {
+		if headings[i] > headings[i-1]+1 {
+			order.Score = 0
+			order.Items = append(order.Items, fmt.Sprintf("h%d 之後出現 h%d", headings[i-1], headings[i]))
+		}
+	}
+	order.Items = limit(order.Items)
+	return append(audits, order)
+}
+
+// bestPracticeAudits 檢查 HTTPS、混合內容與 console 錯誤
+func bestPracticeAudits(in input, consoleErrors []tab.ConsoleMessage) []Audit {
+	https := Audit{ID: "is-on-https", Category: CategoryBestPractices, Title: "使用 HTTPS 且沒有混合內容", Weight: 1, Score: 1}
+	if u, err := url.Parse(in.url); err == nil {
+		switch {
+		case u.Scheme == "http" && !isLoopback(u.Hostname()):
+			https.Score = 0
+			https.Items = append(https.Items, in.url)
+		case u.Scheme == "https":
+			// 被瀏覽器封鎖的混合內容沒有回應，只出現在 console
+			seen := map[string]bool{}
+			for _, resp := range in.responses {
+				if strings.HasPrefix(resp.URL, "http://") && !seen[resp.URL] {
+					if ru, err := url.Parse(resp.URL); err == nil && !isLoopback(ru.Hostname()) {
+						seen[resp.URL] = true
+						https.Items = append(https.Items, resp.URL)
+					}
+				}
+			}
+			for _, m := range in.console {
+				if strings.HasPrefix(m.Text, "Mixed Content:") && !seen[m.Text] {
+					seen[m.Text] = true
+					https.Items = append(https.Items, m.Text)
+				}
+			}
+			if len(https.Items) > 0 {
+				https.Score = 0
+				https.DisplayValue = fmt.Sprintf("%d 個不安全的請求", len(https.Items))
+			}
+		}
+	}
+	https.Items = limit(https.Items)
+
+	console := Audit{ID: "errors-in-console", Category: CategoryBestPractices, Title: "console 沒有錯誤", Weight: 1, Score: 1}
+	if len(consoleErrors) > 0 {
+		console.Score = 0
+		console.DisplayValue = fmt.Sprintf("%d 個錯誤", len(consoleErrors))
+		for _, m := range consoleErrors {
+			item := m.Text
+			if m.URL != "" {
+				item += "（" + m.URL + "）"
+			}
+			console.Items = append(console.Items, item)
+		}
+		console.Items = limit(console.Items)
+	}
+	return []Audit{https, console}
+}
+
+// logNormalScore 以對數常態分布的互補累積分布計分：value 等於 p10 時為 0.9、等於中位數時為 0.5，與 Lighthouse 相同
+func logNormalScore(value float64, th thresholds) float64 {
+	if value <= 0 {
+		return 1
+	}
+	// 0.9 分位的標準常態值
+	const z90 = 1.2815515655446004
+	sigma := (math.Log(th.median) - math.Log(th.p10)) / z90
+	z := (math.Log(value) - math.Log(th.median)) / sigma
+	score := 0.5 * math.Erfc(z/math.Sqrt2)
+	// 四捨五入到小數兩位，避免極小的差異造成分數跳動
+	return math.Round(score*100) / 100
+}
+
+func hasRole(roles []string, role string) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// describeNode 以角色與可辨識的屬性描述缺少名稱的節點
+func describeNode(n *tab.AXNode) string {
+	desc := n.Role
+	if u, ok := n.Properties["url"].(string); ok && u != "" {
+		desc += " " + u
+	}
+	if n.BackendNodeID != 0 {
+		desc += fmt.Sprintf("（backend node %d）", n.BackendNodeID)
+	}
+	return desc
+}
+
+func limit(items []string) []string {
+	if len(items) > maxItems {
+		return items[:maxItems]
+	}
+	return items
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.1f s", d.Seconds())
+}
+
+func kib(n int64) string {
+	return fmt.Sprintf("%d KiB", (n+1023)/1024)
+}
@@ -0,0 +1,137 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ErrDomainBlocked 導航目標不在允許清單內，可用 errors.Is 判斷
+var ErrDomainBlocked = errors.New("網域不在允許清單內")
+
+// BlockedError 說明被阻擋的 URL
+type BlockedError struct {
+	URL  string
+	Host string
+}
+
+func (e *BlockedError) Error() string {
+	return fmt.Sprintf("已阻擋 %s：網域 %q 不在允許清單內", e.URL, e.Host)
+}
+
+// Is 讓 errors.Is(err, ErrDomainBlocked) 成立
+func (e *BlockedError) Is(target error) bool {
+	return target == ErrDomainBlocked
+}
+
+// Policy 合規防護設定，於網路層與結果儲存層強制執行，使用者腳本無法繞過
+type Policy struct {
+	// AllowedDomains 允許的網域；"example.com" 同時涵蓋其子網域，空清單表示不限制
+	AllowedDomains []string
+	// ScrubPII 儲存結果前遮蔽 Email、電話號碼等個資
+	ScrubPII bool
+	// ExtraPatterns 額外需要遮蔽的正規表示式（例如身分證字號）
+	ExtraPatterns []string
+
+	once     sync.Once
+	compiled []piiRule
+}
+
+type piiRule struct {
+	re          *regexp.Regexp
+	replacement string
+}
+
+var (
+	emailRe = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	// phoneRe 只比對有國碼（+886 2 2345 6789）、區碼括號（(02) 2345-6789）或以分隔符號分組
+	// （02-2345-6789、415.555.0100、0912-345-678）的號碼；沒有分隔的長數字（訂單編號、SKU、時間戳）
+	// 與 ISBN、日期、IP 位址不會被遮蔽
+	phoneRe = regexp.MustCompile(strings.Join([]string{
+		`\+\d{1,3}[\s.\-]?(?:\(\d{1,4}\)[\s.\-]?)?\d{1,4}(?:[\s.\-]?\d{2,4}){2,4}\b`,
+		`\(\d{2,4}\)[\s.\-]?\d{3,4}[\s.\-]\d{4}\b`,
+		`\b\d{2,4}[\s.\-]\d{3,4}[\s.\-]\d{4}\b`,
+		`\b09\d{2}[\s\-]\d{3}[\s\-]?\d{3}\b`,
+	}, "|"))
+)
+
+// AllowURL 檢查 URL 是否允許造訪；about:、data:、blob: 不會產生對外連線，一律放行
+func (p *Policy) AllowURL(rawURL string) error {
+	if p == nil || len(p.AllowedDomains) == 0 {
+		return nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return &BlockedError{URL: rawURL}
+	}
+	switch u.Scheme {
+	case "about", "data", "blob":
+		return nil
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if host != "" {
+		for _, d := range p.AllowedDomains {
+			d = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(d), "*."))
+			if host == d || strings.HasSuffix(host, "."+d) {
+				return nil
+			}
+		}
+	}
+	return &BlockedError{URL: rawURL, Host: host}
+}
+
+// Scrub 遮蔽文字中的個資；未啟用 ScrubPII 時原樣回傳
+func (p *Policy) Scrub(s string) string {
+	if p == nil || !p.ScrubPII || s == "" {
+		return s
+	}
+	for _, r := range p.rules() {
+		s = r.re.ReplaceAllString(s, r.replacement)
+	}
+	return s
+}
+
+// ScrubValue 遞迴遮蔽 JSON 類型的值（map、slice、string）
+func (p *Policy) ScrubValue(v interface{}) interface{} {
+	if p == nil || !p.ScrubPII {
+		return v
+	}
+	switch x := v.(type) {
+	case string:
+		return p.Scrub(x)
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(x))
+		for k, val := range x {
+			out[k] = p.ScrubValue(val)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(x))
+		for i, val := range x {
+			out[i] = p.ScrubValue(val)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// rules 延遲編譯遮蔽規則；無效的 ExtraPatterns 會被略過
+func (p *Policy) rules() []piiRule {
+	p.once.Do(func() {
+		p.compiled = []piiRule{
+			{emailRe, "[REDACTED_EMAIL]"},
+			{phoneRe, "[REDACTED_PHONE]"},
+		}
+		for _, pat := range p.ExtraPatterns {
+			if re, err := regexp.Compile(pat); err == nil {
+				p.compiled = append(p.compiled, piiRule{re, "[REDACTED]"})
+			}
+		}
+	})
+	return p.compiled
+}
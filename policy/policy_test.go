@@ -0,0 +1,106 @@
+package policy
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestScrubPhones(t *testing.T) {
+	p := &Policy{ScrubPII: true}
+	for _, tc := range []struct {
+		in, want string
+	}{
+		// 電話號碼
+		{"電話 +886 2 2345 6789", "電話 [REDACTED_PHONE]"},
+		{"手機 +886912345678。", "手機 [REDACTED_PHONE]。"},
+		{"call +1 (415) 555-0100 now", "call [REDACTED_PHONE] now"},
+		{"+44 20 7946 0958", "[REDACTED_PHONE]"},
+		{"(02) 2345-6789", "[REDACTED_PHONE]"},
+		{"(415)555-0100", "[REDACTED_PHONE]"},
+		{"02-2345-6789", "[REDACTED_PHONE]"},
+		{"415.555.0100", "[REDACTED_PHONE]"},
+		{"0912-345-678", "[REDACTED_PHONE]"},
+		{"0912 345678", "[REDACTED_PHONE]"},
+		{"A:02-2345-6789,B:03-456-7890", "A:[REDACTED_PHONE],B:[REDACTED_PHONE]"},
+
+		// 不是電話號碼的數字
+		{"order 1700000000", "order 1700000000"},
+		{"sku 12345678", "sku 12345678"},
+		{"0912345678", "0912345678"},
+		{"ISBN 978-3-16-148410-0", "ISBN 978-3-16-148410-0"},
+		{"ISBN 978-0-306-40615-7", "ISBN 978-0-306-40615-7"},
+		{"2024-05-01 12:30:45", "2024-05-01 12:30:45"},
+		{"192.168.100.200", "192.168.100.200"},
+		{"NT$1,299,000", "NT$1,299,000"},
+		{"10 000 000", "10 000 000"},
+		{"v1.2.3", "v1.2.3"},
+		{"+15 points", "+15 points"},
+		{"id=a12-3456-78901", "id=a12-3456-78901"},
+	} {
+		if got := p.Scrub(tc.in); got != tc.want {
+			t.Errorf("Scrub(%q) = %q，應為 %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestScrubEmailsAndExtraPatterns(t *testing.T) {
+	p := &Policy{ScrubPII: true, ExtraPatterns: []string{`[A-Z][12]\d{8}`, `(`}}
+	got := p.Scrub("聯絡 jane.doe+shop@example.com.tw，身分證 A123456789")
+	if want := "聯絡 [REDACTED_EMAIL]，身分證 [REDACTED]"; got != want {
+		t.Errorf("Scrub = %q，應為 %q", got, want)
+	}
+	if got := (&Policy{}).Scrub("a@example.com"); got != "a@example.com" {
+		t.Errorf("未啟用 ScrubPII 時不應遮蔽：%q", got)
+	}
+	var nilPolicy *Policy
+	if got := nilPolicy.Scrub("a@example.com"); got != "a@example.com" {
+		t.Errorf("nil Policy 不應遮蔽：%q", got)
+	}
+}
+
+func TestScrubValue(t *testing.T) {
+	p := &Policy{ScrubPII: true}
+	in := map[string]interface{}{
+		"contact": "02-2345-6789",
+		"order":   "1700000000",
+		"price":   1299.5,
+		"emails":  []interface{}{"a@example.com", "無"},
+	}
+	want := map[string]interface{}{
+		"contact": "[REDACTED_PHONE]",
+		"order":   "1700000000",
+		"price":   1299.5,
+		"emails":  []interface{}{"[REDACTED_EMAIL]", "無"},
+	}
+	if got := p.ScrubValue(in); !reflect.DeepEqual(got, want) {
+		t.Errorf("ScrubValue = %v，應為 %v", got, want)
+	}
+	if in["contact"] != "02-2345-6789" {
+		t.Error("ScrubValue 不應修改輸入")
+	}
+}
+
+func TestAllowURL(t *testing.T) {
+	p := &Policy{AllowedDomains: []string{"example.com", "*.shop.test"}}
+	for url, ok := range map[string]bool{
+		"https://example.com/":          true,
+		"https://www.example.com/a":     true,
+		"https://a.shop.test/":          true,
+		"https://shop.test/":            true,
+		"https://notexample.com/":       false,
+		"https://example.com.evil.net/": false,
+		"about:blank":                   true,
+		"data:text/html,x":              true,
+		"file:///etc/passwd":            false,
+		"%zz":                           false,
+	} {
+		err := p.AllowURL(url)
+		if ok != (err == nil) {
+			t.Errorf("AllowURL(%q) = %v", url, err)
+		}
+		if err != nil && !errors.Is(err, ErrDomainBlocked) {
+			t.Errorf("AllowURL(%q) 的錯誤 %v 應為 ErrDomainBlocked", url, err)
+		}
+	}
+}
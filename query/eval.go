@@ -0,0 +1,562 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// node 查詢語法樹節點；eval 回傳所有輸出（jq 的產生器語意）
+type node interface {
+	eval(in interface{}) ([]interface{}, error)
+}
+
+type identityNode struct{}
+
+func (identityNode) eval(in interface{}) ([]interface{}, error) {
+	return []interface{}{in}, nil
+}
+
+type fieldNode string
+
+func (f fieldNode) eval(in interface{}) ([]interface{}, error) {
+	switch x := in.(type) {
+	case nil:
+		return []interface{}{nil}, nil
+	case map[string]interface{}:
+		return []interface{}{x[string(f)]}, nil
+	}
+	return nil, fmt.Errorf("query: 無法以 %q 索引 %s", string(f), typeName(in))
+}
+
+type iterNode struct{}
+
+func (iterNode) eval(in interface{}) ([]interface{}, error) {
+	switch x := in.(type) {
+	case []interface{}:
+		return x, nil
+	case map[string]interface{}:
+		out := make([]interface{}, 0, len(x))
+		for _, k := range sortedKeys(x) {
+			out = append(out, x[k])
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("query: 無法迭代 %s", typeName(in))
+}
+
+// indexNode 對應 target[idx]；idx 以整個運算式的輸入求值，而非 target 的輸出
+type indexNode struct{ target, idx node }
+
+func (n indexNode) eval(in interface{}) ([]interface{}, error) {
+	targets, err := n.target.eval(in)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := n.idx.eval(in)
+	if err != nil {
+		return nil, err
+	}
+	var out []interface{}
+	for _, t := range targets {
+		v, err := index(t, keys)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v...)
+	}
+	return out, nil
+}
+
+func index(in interface{}, keys []interface{}) ([]interface{}, error) {
+	var out []interface{}
+	for _, k := range keys {
+		switch key := k.(type) {
+		case string:
+			v, err := fieldNode(key).eval(in)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, v...)
+		case float64:
+			arr, ok := in.([]interface{})
+			if in == nil {
+				out = append(out, nil)
+				continue
+			}
+			if !ok {
+				return nil, fmt.Errorf("query: 無法以數字索引 %s", typeName(in))
+			}
+			i := int(key)
+			if i < 0 {
+				i += len(arr)
+			}
+			if i < 0 || i >= len(arr) {
+				out = append(out, nil)
+			} else {
+				out = append(out, arr[i])
+			}
+		default:
+			return nil, fmt.Errorf("query: 無效的索引 %s", typeName(k))
+		}
+	}
+	return out, nil
+}
+
+type pipeNode struct{ left, right node }
+
+func (n pipeNode) eval(in interface{}) ([]interface{}, error) {
+	lefts, err := n.left.eval(in)
+	if err != nil {
+		return nil, err
+	}
+	var out []interface{}
+	for _, l := range lefts {
+		r, err := n.right.eval(l)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, r...)
+	}
+	return out, nil
+}
+
+type commaNode []node
+
+func (n commaNode) eval(in interface{}) ([]interface{}, error) {
+	var out []interface{}
+	for _, item := range n {
+		v, err := item.eval(in)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v...)
+	}
+	return out, nil
+}
+
+// tryNode 對應 expr?，忽略錯誤
+type tryNode struct{ n node }
+
+func (t tryNode) eval(in interface{}) ([]interface{}, error) {
+	out, err := t.n.eval(in)
+	if err != nil {
+		return nil, nil
+	}
+	return out, nil
+}
+
+type literalNode struct{ v interface{} }
+
+func (l literalNode) eval(interface{}) ([]interface{}, error) {
+	return []interface{}{l.v}, nil
+}
+
+type negNode struct{ n node }
+
+func (n negNode) eval(in interface{}) ([]interface{}, error) {
+	vs, err := n.n.eval(in)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]interface{}, len(vs))
+	for i, v := range vs {
+		f, ok := v.(float64)
+		if !ok {
+			return nil, fmt.Errorf("query: 無法對 %s 取負值", typeName(v))
+		}
+		out[i] = -f
+	}
+	return out, nil
+}
+
+type arrayNode []node
+
+func (a arrayNode) eval(in interface{}) ([]interface{}, error) {
+	arr := []interface{}{}
+	for _, n := range a {
+		v, err := n.eval(in)
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, v...)
+	}
+	return []interface{}{arr}, nil
+}
+
+type objectEntry struct{ key, val node }
+
+type objectNode []objectEntry
+
+// eval 每個鍵值可能產生多個輸出，依 jq 語意取笛卡兒積
+func (o objectNode) eval(in interface{}) ([]interface{}, error) {
+	objs := []map[string]interface{}{{}}
+	for _, e := range o {
+		keys, err := e.key.eval(in)
+		if err != nil {
+			return nil, err
+		}
+		vals, err := e.val.eval(in)
+		if err != nil {
+			return nil, err
+		}
+		var next []map[string]interface{}
+		for _, obj := range objs {
+			for _, k := range keys {
+				ks, ok := k.(string)
+				if !ok {
+					return nil, fmt.Errorf("query: 物件鍵必須是字串，實際為 %s", typeName(k))
+				}
+				for _, v := range vals {
+					m := make(map[string]interface{}, len(obj)+1)
+					for kk, vv := range obj {
+						m[kk] = vv
+					}
+					m[ks] = v
+					next = append(next, m)
+				}
+			}
+		}
+		objs = next
+	}
+	out := make([]interface{}, len(objs))
+	for i, m := range objs {
+		out[i] = m
+	}
+	return out, nil
+}
+
+type logicNode struct {
+	op          string
+	left, right node
+}
+
+func (n logicNode) eval(in interface{}) ([]interface{}, error) {
+	lefts, err := n.left.eval(in)
+	if err != nil {
+		return nil, err
+	}
+	var out []interface{}
+	for _, l := range lefts {
+		if n.op == "and" && !truthy(l) {
+			out = append(out, false)
+			continue
+		}
+		if n.op == "or" && truthy(l) {
+			out = append(out, true)
+			continue
+		}
+		rights, err := n.right.eval(in)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rights {
+			out = append(out, truthy(r))
+		}
+	}
+	return out, nil
+}
+
+type compareNode struct {
+	op          string
+	left, right node
+}
+
+func (n compareNode) eval(in interface{}) ([]interface{}, error) {
+	lefts, err := n.left.eval(in)
+	if err != nil {
+		return nil, err
+	}
+	rights, err := n.right.eval(in)
+	if err != nil {
+		return nil, err
+	}
+	var out []interface{}
+	for _, r := range rights {
+		for _, l := range lefts {
+			c := compare(l, r)
+			var v bool
+			switch n.op {
+			case "==":
+				v = c == 0
+			case "!=":
+				v = c != 0
+			case "<":
+				v = c < 0
+			case "<=":
+				v = c <= 0
+			case ">":
+				v = c > 0
+			case ">=":
+				v = c >= 0
+			}
+			out = append(out, v)
+		}
+	}
+	return out, nil
+}
+
+// ----------------- 函式 -----------------
+
+type funcNode struct {
+	name string
+	args []node
+}
+
+var funcArity = map[string]int{
+	"select": 1, "map": 1, "has": 1, "contains": 1, "startswith": 1, "endswith": 1, "test": 1,
+	"length": 0, "keys": 0, "not": 0, "tostring": 0, "tonumber": 0, "ascii_downcase": 0,
+	"ascii_upcase": 0, "empty": 0, "type": 0,
+}
+
+func newFuncNode(name string, args []node) (node, error) {
+	n, ok := funcArity[name]
+	if !ok {
+		return nil, fmt.Errorf("query: 不支援的函式 %s", name)
+	}
+	if len(args) != n {
+		return nil, fmt.Errorf("query: %s 需要 %d 個參數", name, n)
+	}
+	return funcNode{name, args}, nil
+}
+
+func (f funcNode) eval(in interface{}) ([]interface{}, error) {
+	switch f.name {
+	case "select":
+		conds, err := f.args[0].eval(in)
+		if err != nil {
+			return nil, err
+		}
+		var out []interface{}
+		for _, c := range conds {
+			if truthy(c) {
+				out = append(out, in)
+			}
+		}
+		return out, nil
+	case "map":
+		return arrayNode{pipeNode{iterNode{}, f.args[0]}}.eval(in)
+	case "empty":
+		return nil, nil
+	}
+
+	if len(f.args) == 1 {
+		args, err := f.args[0].eval(in)
+		if err != nil {
+			return nil, err
+		}
+		var out []interface{}
+		for _, a := range args {
+			v, err := f.call1(in, a)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, v)
+		}
+		return out, nil
+	}
+	v, err := f.call0(in)
+	if err != nil {
+		return nil, err
+	}
+	return []interface{}{v}, nil
+}
+
+func (f funcNode) call0(in interface{}) (interface{}, error) {
+	switch f.name {
+	case "length":
+		switch x := in.(type) {
+		case nil:
+			return float64(0), nil
+		case string:
+			return float64(len([]rune(x))), nil
+		case []interface{}:
+			return float64(len(x)), nil
+		case map[string]interface{}:
+			return float64(len(x)), nil
+		case float64:
+			if x < 0 {
+				return -x, nil
+			}
+			return x, nil
+		}
+	case "keys":
+		if m, ok := in.(map[string]interface{}); ok {
+			keys := sortedKeys(m)
+			out := make([]interface{}, len(keys))
+			for i, k := range keys {
+				out[i] = k
+			}
+			return out, nil
+		}
+		if a, ok := in.([]interface{}); ok {
+			out := make([]interface{}, len(a))
+			for i := range a {
+				out[i] = float64(i)
+			}
+			return out, nil
+		}
+	case "not":
+		return !truthy(in), nil
+	case "type":
+		return typeName(in), nil
+	case "tostring":
+		if s, ok := in.(string); ok {
+			return s, nil
+		}
+		b, err := json.Marshal(in)
+		return string(b), err
+	case "tonumber":
+		switch x := in.(type) {
+		case float64:
+			return x, nil
+		case string:
+			v, err := strconv.ParseFloat(strings.TrimSpace(x), 64)
+			if err != nil {
+				return nil, fmt.Errorf("query: 無法將 %q 轉為數字", x)
+			}
+			return v, nil
+		}
+	case "ascii_downcase", "ascii_upcase":
+		if s, ok := in.(string); ok {
+			if f.name == "ascii_downcase" {
+				return strings.ToLower(s), nil
+			}
+			return strings.ToUpper(s), nil
+		}
+	}
+	return nil, fmt.Errorf("query: %s 無法用於 %s", f.name, typeName(in))
+}
+
+func (f funcNode) call1(in, arg interface{}) (interface{}, error) {
+	switch f.name {
+	case "has":
+		switch x := in.(type) {
+		case map[string]interface{}:
+			if k, ok := arg.(string); ok {
+				_, found := x[k]
+				return found, nil
+			}
+		case []interface{}:
+			if i, ok := arg.(float64); ok {
+				return i >= 0 && int(i) < len(x), nil
+			}
+		}
+	case "contains", "startswith", "endswith", "test":
+		s, ok1 := in.(string)
+		sub, ok2 := arg.(string)
+		if ok1 && ok2 {
+			switch f.name {
+			case "contains":
+				return strings.Contains(s, sub), nil
+			case "startswith":
+				return strings.HasPrefix(s, sub), nil
+			case "endswith":
+				return strings.HasSuffix(s, sub), nil
+			default:
+				re, err := regexp.Compile(sub)
+				if err != nil {
+					return nil, fmt.Errorf("query: 無效的正規表示式 %q: %w", sub, err)
+				}
+				return re.MatchString(s), nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("query: %s 無法用於 %s", f.name, typeName(in))
+}
+
+// ----------------- 輔助 -----------------
+
+func truthy(v interface{}) bool {
+	if v == nil {
+		return false
+	}
+	if b, ok := v.(bool); ok {
+		return b
+	}
+	return true
+}
+
+// typeOrder jq 的型別排序：null < false < true < 數字 < 字串 < 陣列 < 物件
+func typeOrder(v interface{}) int {
+	switch x := v.(type) {
+	case nil:
+		return 0
+	case bool:
+		if x {
+			return 2
+		}
+		return 1
+	case float64:
+		return 3
+	case string:
+		return 4
+	case []interface{}:
+		return 5
+	default:
+		return 6
+	}
+}
+
+func compare(a, b interface{}) int {
+	ta, tb := typeOrder(a), typeOrder(b)
+	if ta != tb {
+		return ta - tb
+	}
+	switch x := a.(type) {
+	case float64:
+		y := b.(float64)
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	case string:
+		return strings.Compare(x, b.(string))
+	case []interface{}:
+		y := b.([]interface{})
+		for i := 0; i < len(x) && i < len(y); i++ {
+			if c := compare(x[i], y[i]); c != 0 {
+				return c
+			}
+		}
+		return len(x) - len(y)
+	case map[string]interface{}:
+		ja, _ := json.Marshal(x)
+		jb, _ := json.Marshal(b)
+		return strings.Compare(string(ja), string(jb))
+	}
+	return 0
+}
+
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
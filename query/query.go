@@ -0,0 +1,477 @@
+// Package query 實作 jq 語法的常用子集，用於在輸出前投影或過濾結果 JSON。
+//
+// 支援：. .foo .foo.bar .["key"] .[0] .[] | , ( ) [ ... ] { key: expr, key }
+// 比較 == != < <= > >=、and、or、字面值，以及函式 select、map、has、length、keys、not、
+// tostring、tonumber、ascii_downcase、ascii_upcase、contains、startswith、endswith、test。
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Query 已編譯的查詢
+type Query struct {
+	src  string
+	root node
+}
+
+// Compile 解析查詢運算式
+func Compile(expr string) (*Query, error) {
+	toks, err := lex(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks}
+	root, err := p.parsePipe()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokEOF {
+		return nil, fmt.Errorf("query: 無法解析 %q 附近", p.peek().text)
+	}
+	return &Query{src: expr, root: root}, nil
+}
+
+// Run 對輸入執行查詢並回傳所有輸出；輸入應為 encoding/json 解碼後的值
+func (q *Query) Run(input interface{}) ([]interface{}, error) {
+	return q.root.eval(input)
+}
+
+// RunJSON 將任意值轉為 JSON 型別後執行查詢
+func (q *Query) RunJSON(v interface{}) ([]interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var input interface{}
+	if err := json.Unmarshal(b, &input); err != nil {
+		return nil, err
+	}
+	return q.Run(input)
+}
+
+// Match 以 jq 的真值規則判斷第一個輸出：false 與 null 為假；沒有輸出也視為假
+func (q *Query) Match(input interface{}) (bool, error) {
+	out, err := q.Run(input)
+	if err != nil || len(out) == 0 {
+		return false, err
+	}
+	return truthy(out[0]), nil
+}
+
+// String 回傳原始運算式
+func (q *Query) String() string {
+	return q.src
+}
+
+// ----------------- 詞法分析 -----------------
+
+type tokKind int
+
+const (
+	tokEOF tokKind = iota
+	tokDot
+	tokIdent
+	tokString
+	tokNumber
+	tokPunct
+)
+
+type token struct {
+	kind tokKind
+	text string
+}
+
+func lex(src string) ([]token, error) {
+	var toks []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '.':
+			toks = append(toks, token{tokDot, "."})
+			i++
+		case c == '"':
+			j := i + 1
+			for j < len(src) && src[j] != '"' {
+				if src[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(src) {
+				return nil, fmt.Errorf("query: 字串未結束")
+			}
+			s, err := strconv.Unquote(src[i : j+1])
+			if err != nil {
+				return nil, fmt.Errorf("query: 無效的字串 %s", src[i:j+1])
+			}
+			toks = append(toks, token{tokString, s})
+			i = j + 1
+		case c >= '0' && c <= '9':
+			j := i
+			for j < len(src) && (src[j] >= '0' && src[j] <= '9' || src[j] == '.') {
+				j++
+			}
+			toks = append(toks, token{tokNumber, src[i:j]})
+			i = j
+		case isIdentRune(decodeRune(src[i:]), false):
+			j := i
+			for j < len(src) && isIdentRune(decodeRune(src[j:]), true) {
+				_, size := utf8.DecodeRuneInString(src[j:])
+				j += size
+			}
+			toks = append(toks, token{tokIdent, src[i:j]})
+			i = j
+		default:
+			if i+1 < len(src) {
+				if two := src[i : i+2]; two == "==" || two == "!=" || two == "<=" || two == ">=" {
+					toks = append(toks, token{tokPunct, two})
+					i += 2
+					continue
+				}
+			}
+			if !strings.ContainsRune("|,()[]{}:;<>?-", rune(c)) {
+				return nil, fmt.Errorf("query: 無法辨識的字元 %q", decodeRune(src[i:]))
+			}
+			toks = append(toks, token{tokPunct, string(c)})
+			i++
+		}
+	}
+	return append(toks, token{kind: tokEOF}), nil
+}
+
+// decodeRune 取出開頭的字元；無效的 UTF-8 回傳 utf8.RuneError
+func decodeRune(s string) rune {
+	r, _ := utf8.DecodeRuneInString(s)
+	return r
+}
+
+// isIdentRune 識別字可包含字母（含中文等非 ASCII 字母）、_、$，非開頭位置另可包含數字
+func isIdentRune(r rune, inner bool) bool {
+	if r == utf8.RuneError {
+		return false
+	}
+	return r == '_' || r == '$' || unicode.IsLetter(r) || inner && r >= '0' && r <= '9'
+}
+
+// ----------------- 語法分析 -----------------
+
+type parser struct {
+	toks []token
+	pos  int
+}
+
+func (p *parser) peek() token { return p.toks[p.pos] }
+
+func (p *parser) next() token {
+	t := p.toks[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) isPunct(s string) bool {
+	t := p.peek()
+	return t.kind == tokPunct && t.text == s
+}
+
+func (p *parser) expect(s string) error {
+	if !p.isPunct(s) {
+		return fmt.Errorf("query: 預期 %q，實際為 %q", s, p.peek().text)
+	}
+	p.next()
+	return nil
+}
+
+func (p *parser) parsePipe() (node, error) {
+	left, err := p.parseComma()
+	if err != nil {
+		return nil, err
+	}
+	for p.isPunct("|") {
+		p.next()
+		right, err := p.parseComma()
+		if err != nil {
+			return nil, err
+		}
+		left = pipeNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseComma() (node, error) {
+	first, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	items := []node{first}
+	for p.isPunct(",") {
+		p.next()
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, n)
+	}
+	if len(items) == 1 {
+		return first, nil
+	}
+	return commaNode(items), nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokIdent && p.peek().text == "or" {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = logicNode{op: "or", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseCompare()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokIdent && p.peek().text == "and" {
+		p.next()
+		right, err := p.parseCompare()
+		if err != nil {
+			return nil, err
+		}
+		left = logicNode{op: "and", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseCompare() (node, error) {
+	left, err := p.parsePostfix()
+	if err != nil {
+		return nil, err
+	}
+	t := p.peek()
+	if t.kind == tokPunct {
+		switch t.text {
+		case "==", "!=", "<", "<=", ">", ">=":
+			p.next()
+			right, err := p.parsePostfix()
+			if err != nil {
+				return nil, err
+			}
+			return compareNode{op: t.text, left: left, right: right}, nil
+		}
+	}
+	return left, nil
+}
+
+func (p *parser) parsePostfix() (node, error) {
+	n, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch {
+		case p.peek().kind == tokDot && p.toks[p.pos+1].kind == tokIdent:
+			p.next()
+			n = pipeNode{n, fieldNode(p.next().text)}
+		case p.peek().kind == tokDot && p.toks[p.pos+1].kind == tokString:
+			p.next()
+			n = pipeNode{n, fieldNode(p.next().text)}
+		case p.peek().kind == tokDot && p.toks[p.pos+1].kind == tokPunct && p.toks[p.pos+1].text == "[":
+			p.next()
+		case p.isPunct("["):
+			idx, err := p.parseBracket(n)
+			if err != nil {
+				return nil, err
+			}
+			n = idx
+		case p.isPunct("?"):
+			p.next()
+			n = tryNode{n}
+		default:
+			return n, nil
+		}
+	}
+}
+
+// parseBracket 解析 target 之後的 [] 或 [expr]；expr 與 jq 相同，以 target 的輸入求值
+func (p *parser) parseBracket(target node) (node, error) {
+	p.next()
+	if p.isPunct("]") {
+		p.next()
+		return pipeNode{target, iterNode{}}, nil
+	}
+	idx, err := p.parsePipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect("]"); err != nil {
+		return nil, err
+	}
+	return indexNode{target, idx}, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.peek()
+	switch t.kind {
+	case tokDot:
+		p.next()
+		switch nt := p.peek(); {
+		case nt.kind == tokIdent || nt.kind == tokString:
+			p.next()
+			return fieldNode(nt.text), nil
+		case nt.kind == tokPunct && nt.text == "[":
+			return p.parseBracket(identityNode{})
+		}
+		return identityNode{}, nil
+	case tokString:
+		p.next()
+		return literalNode{t.text}, nil
+	case tokNumber:
+		p.next()
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("query: 無效的數字 %s", t.text)
+		}
+		return literalNode{f}, nil
+	case tokIdent:
+		p.next()
+		switch t.text {
+		case "true":
+			return literalNode{true}, nil
+		case "false":
+			return literalNode{false}, nil
+		case "null":
+			return literalNode{nil}, nil
+		}
+		var args []node
+		if p.isPunct("(") {
+			p.next()
+			for {
+				a, err := p.parsePipe()
+				if err != nil {
+					return nil, err
+				}
+				args = append(args, a)
+				if p.isPunct(";") {
+					p.next()
+					continue
+				}
+				break
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+		}
+		return newFuncNode(t.text, args)
+	case tokPunct:
+		switch t.text {
+		case "-":
+			p.next()
+			n, err := p.parsePostfix()
+			if err != nil {
+				return nil, err
+			}
+			return negNode{n}, nil
+		case "(":
+			p.next()
+			n, err := p.parsePipe()
+			if err != nil {
+				return nil, err
+			}
+			return n, p.expect(")")
+		case "[":
+			p.next()
+			if p.isPunct("]") {
+				p.next()
+				return arrayNode{}, nil
+			}
+			n, err := p.parsePipe()
+			if err != nil {
+				return nil, err
+			}
+			return arrayNode{n}, p.expect("]")
+		case "{":
+			return p.parseObject()
+		}
+	}
+	if t.kind == tokEOF {
+		return nil, fmt.Errorf("query: 運算式不完整")
+	}
+	return nil, fmt.Errorf("query: 非預期的 %q", t.text)
+}
+
+func (p *parser) parseObject() (node, error) {
+	p.next()
+	var obj objectNode
+	for !p.isPunct("}") {
+		t := p.next()
+		var key node
+		var name string
+		computed := false
+		switch {
+		case t.kind == tokIdent || t.kind == tokString:
+			name = t.text
+			key = literalNode{t.text}
+		case t.kind == tokPunct && t.text == "(":
+			k, err := p.parsePipe()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			key = k
+			computed = true
+		default:
+			return nil, fmt.Errorf("query: 物件鍵無效 %q", t.text)
+		}
+
+		var val node = fieldNode(name)
+		if p.isPunct(":") {
+			p.next()
+			v, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			// 允許 {a: .x | f} 形式
+			for p.isPunct("|") {
+				p.next()
+				r, err := p.parseOr()
+				if err != nil {
+					return nil, err
+				}
+				v = pipeNode{v, r}
+			}
+			val = v
+		} else if computed {
+			return nil, fmt.Errorf("query: 計算鍵需要值")
+		}
+		obj = append(obj, objectEntry{key, val})
+
+		if p.isPunct(",") {
+			p.next()
+			continue
+		}
+		if !p.isPunct("}") {
+			return nil, fmt.Errorf("query: 物件中預期 , 或 }，實際為 %q", p.peek().text)
+		}
+	}
+	p.next()
+	return obj, nil
+}
@@ -0,0 +1,318 @@
+package query
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testInput = `{
+	"title": "Trail Shoe",
+	"price": 1299.5,
+	"stock": 0,
+	"sale": false,
+	"brand": null,
+	"名稱": "越野鞋",
+	"a b": 1,
+	"tags": ["running", "trail"],
+	"variants": [
+		{"sku": "TS-42", "size": 42, "stock": 3},
+		{"sku": "TS-43", "size": 43, "stock": 0}
+	],
+	"meta": {"k": "tags", "z": 1, "a": 2}
+}`
+
+func decode(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestRun(t *testing.T) {
+	in := decode(t, testInput)
+	for _, tc := range []struct {
+		expr string
+		want string // 所有輸出組成的 JSON 陣列
+	}{
+		// 路徑
+		{".stock", `[0]`},
+		{".title", `["Trail Shoe"]`},
+		{`."a b"`, `[1]`},
+		{`.["a b"]`, `[1]`},
+		{".名稱", `["越野鞋"]`},
+		{".missing", `[null]`},
+		{".brand.name", `[null]`},
+		{".variants[0].sku", `["TS-42"]`},
+		{".variants.[1].sku", `["TS-43"]`},
+		{".variants[-1].size", `[43]`},
+		{".variants[5]", `[null]`},
+		{".variants[-5]", `[null]`},
+		{".tags[]", `["running","trail"]`},
+		{".meta[]", `[2,"tags",1]`},
+		{".variants[.meta.z].sku", `["TS-43"]`},
+		{".meta[.meta.k]", `[null]`},
+		{".tags[.variants[1].stock]", `["running"]`},
+		{".[.meta.k][0]", `["running"]`},
+		{".brand[0]", `[null]`},
+		{".brand[]?", `[]`},
+
+		// 管線、逗號、括號與建構
+		{".variants[] | .sku", `["TS-42","TS-43"]`},
+		{".title, .price", `["Trail Shoe",1299.5]`},
+		{"(.title, .price) | type", `["string","number"]`},
+		{"[.tags[], .price]", `[["running","trail",1299.5]]`},
+		{"[]", `[[]]`},
+		{"[.tags[] | select(. == \"x\")]", `[[]]`},
+		{"{title, p: .price}", `[{"p":1299.5,"title":"Trail Shoe"}]`},
+		{`{"a b", (.meta.k): 1, s: .variants[0].sku | ascii_downcase}`, `[{"a b":1,"s":"ts-42","tags":1}]`},
+		{"{t: .tags[]}", `[{"t":"running"},{"t":"trail"}]`},
+		{"{a: (1, 2), b: (3, 4)}", `[{"a":1,"b":3},{"a":1,"b":4},{"a":2,"b":3},{"a":2,"b":4}]`},
+		{"{}", `[{}]`},
+
+		// 比較與邏輯
+		{".price > 1000", `[true]`},
+		{".price <= 1000", `[false]`},
+		{".title != \"x\"", `[true]`},
+		{"null < false", `[true]`},
+		{"true < 0", `[true]`},
+		{"9 < \"1\"", `[true]`},
+		{"\"b\" > \"a\"", `[true]`},
+		{"[1, 2] < [1, 3]", `[true]`},
+		{"[1] < [1, 0]", `[true]`},
+		{"{} > []", `[true]`},
+		{".stock == 0 and .sale", `[false]`},
+		{".sale or .brand", `[false]`},
+		{".stock or .title.x", `[true]`},
+		{".stock == 0 or .missing", `[true]`},
+		{"(true, false) and true", `[true,false]`},
+		{"-.price", `[-1299.5]`},
+		{"-1 < 0", `[true]`},
+
+		// 函式
+		{".variants[] | select(.stock > 0) | .sku", `["TS-42"]`},
+		{".variants | map(.size)", `[[42,43]]`},
+		{".meta | map(.)", `[[2,"tags",1]]`},
+		{".title | map(.)?", `[]`},
+		{".tags | length", `[2]`},
+		{".名稱 | length", `[3]`},
+		{".meta | length", `[3]`},
+		{".brand | length", `[0]`},
+		{"-3 | length", `[3]`},
+		{".meta | keys", `[["a","k","z"]]`},
+		{".tags | keys", `[[0,1]]`},
+		{"has(\"price\"), has(\"nope\")", `[true,false]`},
+		{".tags | has(1), has(2), has(-1)", `[true,false,false]`},
+		{".sale | not", `[true]`},
+		{".price | tostring", `["1299.5"]`},
+		{".tags | tostring", `["[\"running\",\"trail\"]"]`},
+		{"\" 42 \" | tonumber", `[42]`},
+		{".title | ascii_upcase", `["TRAIL SHOE"]`},
+		{".title | contains(\"Shoe\"), startswith(\"Trail\"), endswith(\"x\")", `[true,true,false]`},
+		{".variants[].sku | test(\"-4[23]$\")", `[true,true]`},
+		{".tags[] | select(test(\"^t\"))", `["trail"]`},
+		{"empty", `[]`},
+		{".brand, .sale, .stock, .tags, .meta | type", `["null","boolean","number","array","object"]`},
+	} {
+		q, err := Compile(tc.expr)
+		if err != nil {
+			t.Errorf("Compile(%q): %v", tc.expr, err)
+			continue
+		}
+		out, err := q.Run(in)
+		if err != nil {
+			t.Errorf("Run(%q): %v", tc.expr, err)
+			continue
+		}
+		if out == nil {
+			out = []interface{}{}
+		}
+		if got, _ := json.Marshal(out); string(got) != tc.want {
+			t.Errorf("Run(%q) = %s，應為 %s", tc.expr, got, tc.want)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, tc := range []struct {
+		expr, want string
+	}{
+		{"", "query: 運算式不完整"},
+		{".a |", "query: 運算式不完整"},
+		{".a,", "query: 運算式不完整"},
+		{".a ==", "query: 運算式不完整"},
+		{".a and", "query: 運算式不完整"},
+		{"-", "query: 運算式不完整"},
+		{".a.", `query: 無法解析 "." 附近`},
+		{"..", `query: 無法解析 "." 附近`},
+		{".a )", `query: 無法解析 ")" 附近`},
+		{"1 == 2 == 3", `query: 無法解析 "==" 附近`},
+		{".a - 1", `query: 無法解析 "-" 附近`},
+		{`"abc`, "query: 字串未結束"},
+		{`"abc\"`, "query: 字串未結束"},
+		{`"\q"`, `query: 無效的字串 "\q"`},
+		{"1.2.3", "query: 無效的數字 1.2.3"},
+		{".a + 1", `query: 無法辨識的字元 '+'`},
+		{".a & 1", `query: 無法辨識的字元 '&'`},
+		{".a，.b", `query: 無法辨識的字元 '，'`},
+		{".a\xff", "query: 無法辨識的字元 '\ufffd'"},
+		{"(.a", `query: 預期 ")"，實際為 ""`},
+		{".a[0", `query: 預期 "]"，實際為 ""`},
+		{"[.a", `query: 預期 "]"，實際為 ""`},
+		{"]", `query: 非預期的 "]"`},
+		{"; .a", `query: 非預期的 ";"`},
+		{"length()", `query: 非預期的 ")"`},
+		{"frobnicate", "query: 不支援的函式 frobnicate"},
+		{"select", "query: select 需要 1 個參數"},
+		{"has(1; 2)", "query: has 需要 1 個參數"},
+		{"length(.)", "query: length 需要 0 個參數"},
+		{"{", `query: 物件鍵無效 ""`},
+		{"{1: 2}", `query: 物件鍵無效 "1"`},
+		{"{a", `query: 物件中預期 , 或 }，實際為 ""`},
+		{"{a: 1 b: 2}", `query: 物件中預期 , 或 }，實際為 "b"`},
+		{"{(.k)}", "query: 計算鍵需要值"},
+		{"{a: }", `query: 非預期的 "}"`},
+	} {
+		q, err := Compile(tc.expr)
+		if err == nil {
+			t.Errorf("Compile(%q) 應失敗，得到 %v", tc.expr, q)
+			continue
+		}
+		if err.Error() != tc.want {
+			t.Errorf("Compile(%q) 的錯誤為 %v，應為 %s", tc.expr, err, tc.want)
+		}
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	in := decode(t, testInput)
+	for _, tc := range []struct {
+		expr, want string
+	}{
+		{".title.x", `query: 無法以 "x" 索引 string`},
+		{".tags.x", `query: 無法以 "x" 索引 array`},
+		{".title[0]", "query: 無法以數字索引 string"},
+		{".tags[true]", "query: 無效的索引 boolean"},
+		{".price[]", "query: 無法迭代 number"},
+		{"-.title", "query: 無法對 string 取負值"},
+		{"{(.price): 1}", "query: 物件鍵必須是字串，實際為 number"},
+		{".sale | length", "query: length 無法用於 boolean"},
+		{".title | keys", "query: keys 無法用於 string"},
+		{".title | tonumber", `query: 無法將 "Trail Shoe" 轉為數字`},
+		{".tags | ascii_downcase", "query: ascii_downcase 無法用於 array"},
+		{".tags | has(\"x\")", "query: has 無法用於 array"},
+		{".price | contains(\"1\")", "query: contains 無法用於 number"},
+		{".title | test(\"(\")", "query: 無效的正規表示式"},
+		{".variants | map(.sku.x)", `query: 無法以 "x" 索引 string`},
+		{"[.tags[] | .x]", `query: 無法以 "x" 索引 string`},
+		{".sale or .title.x", `query: 無法以 "x" 索引 string`},
+		{".price == .title.x", `query: 無法以 "x" 索引 string`},
+	} {
+		q, err := Compile(tc.expr)
+		if err != nil {
+			t.Errorf("Compile(%q): %v", tc.expr, err)
+			continue
+		}
+		if _, err := q.Run(in); err == nil || !strings.HasPrefix(err.Error(), tc.want) {
+			t.Errorf("Run(%q) 的錯誤為 %v，應為 %s", tc.expr, err, tc.want)
+		}
+		// ? 會吞掉錯誤
+		q, err = Compile("(" + tc.expr + ")?")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out, err := q.Run(in); err != nil || len(out) != 0 {
+			t.Errorf("Run(%q?) = %v, %v，應無輸出且無錯誤", tc.expr, out, err)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	in := decode(t, testInput)
+	for expr, want := range map[string]bool{
+		".price > 1000":        true,
+		".sale":                false,
+		".brand":               false,
+		".stock":               true,
+		".title":               true,
+		"empty":                false,
+		".tags[] == \"trail\"": false,
+		"select(.stock == 0)":  true,
+	} {
+		q, err := Compile(expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := q.Match(in)
+		if err != nil || got != want {
+			t.Errorf("Match(%q) = %v, %v，應為 %v", expr, got, err, want)
+		}
+	}
+
+	q, _ := Compile(".title.x")
+	if ok, err := q.Match(in); ok || err == nil {
+		t.Errorf("執行錯誤時 Match 應回傳 false 與錯誤，得到 %v, %v", ok, err)
+	}
+}
+
+func TestRunJSON(t *testing.T) {
+	q, err := Compile("{u: .url, n: (.data.tags | length)}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := struct {
+		URL  string                 `json:"url"`
+		Data map[string]interface{} `json:"data"`
+	}{"https://example.com/", map[string]interface{}{"tags": []string{"a", "b"}}}
+	out, err := q.RunJSON(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{map[string]interface{}{"u": "https://example.com/", "n": 2.0}}
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("RunJSON = %v，應為 %v", out, want)
+	}
+	if q.String() != "{u: .url, n: (.data.tags | length)}" {
+		t.Errorf("String() = %q", q.String())
+	}
+	if _, err := q.RunJSON(func() {}); err == nil {
+		t.Error("無法序列化的輸入應回傳錯誤")
+	}
+}
+
+// 截斷、逐字元替換的運算式只能回傳錯誤，不可 panic
+func TestCompileGarbage(t *testing.T) {
+	in := decode(t, testInput)
+	exprs := []string{
+		`.variants[] | select(.stock > 0 and (.sku | test("^TS"))) | {sku, s: .size, (.sku): [.stock, -1]}`,
+		`[.tags[]?, .meta["k"], ."名稱"] | map(ascii_upcase) | .[0:1]`,
+		`{a: has("x"; 1), b: .x.[0]?} | keys, length, not, tostring, tonumber`,
+	}
+	junk := []string{"", ".", "[", "]", "(", ")", "{", "}", "|", ",", ":", ";", "?", "-", `"`, `\`, "==", "and", "1", "\xff", "名"}
+	run := func(expr string) {
+		defer func() {
+			if r := recover(); r != nil {
+				t.Errorf("%q panic: %v", expr, r)
+			}
+		}()
+		if q, err := Compile(expr); err == nil {
+			q.Run(in)
+			q.Run(nil)
+			q.Run([]interface{}{1.0, "x", nil})
+		}
+	}
+	for _, expr := range exprs {
+		for i := 0; i <= len(expr); i++ {
+			run(expr[:i])
+			run(expr[i:])
+			for _, j := range junk {
+				if i < len(expr) {
+					run(expr[:i] + j + expr[i+1:])
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,397 @@
+// Package rendercache 渲染結果（HTML、截圖等）的 TTL 快取，供服務模式「渲染一次、回應多次」：
+// 快取鍵由網址與影響輸出的選項組成，有效期間內的重複請求直接回傳快取內容，
+// 同一個鍵同時只會渲染一次。提供請求端的快取控制與清除快取的 HTTP 端點。
+package rendercache
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry 一筆渲染結果
+type Entry struct {
+	// URL 渲染的網址，用於依網址清除
+	URL         string
+	ContentType string
+	Body        []byte
+	RenderedAt  time.Time
+	// Expires 過期時間，Put 時為零值則以 Cache.TTL 計算
+	Expires time.Time
+	// ETag 內容的強驗證值，Put 時為空則以 Body 的雜湊產生
+	ETag string
+	// Modified 內容最後變更的時間，作為 Last-Modified；重新渲染的內容與前次相同時保留前次的時間
+	Modified time.Time
+}
+
+// Control 請求端的快取控制，由 ParseControl 解析
+type Control struct {
+	// NoCache 略過快取重新渲染，結果仍會寫入快取
+	NoCache bool
+	// NoStore 不寫入快取
+	NoStore bool
+	// MaxAge 只接受渲染時間在此之內的快取，並以此作為寫入的有效期間；0 表示使用預設
+	MaxAge time.Duration
+}
+
+// Stats 快取統計
+type Stats struct {
+	Entries int   `json:"entries"`
+	Bytes   int64 `json:"bytes"`
+	Hits    int64 `json:"hits"`
+	Misses  int64 `json:"misses"`
+}
+
+// Cache 以 LRU 淘汰的 TTL 快取，可同時由多個 goroutine 使用
+type Cache struct {
+	// TTL 預設有效期間
+	TTL time.Duration
+	// MaxEntries 最多保存的筆數，0 表示不限
+	MaxEntries int
+	// MaxBytes 所有內容合計的位元組上限，0 表示不限
+	MaxBytes int64
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	bytes   int64
+	hits    int64
+	misses  int64
+	// pending 渲染中的鍵，相同鍵的請求等待同一次渲染
+	pending map[string]*call
+}
+
+type item struct {
+	key   string
+	entry Entry
+}
+
+type call struct {
+	done  chan struct{}
+	entry Entry
+	err   error
+}
+
+// New 建立快取；ttl <= 0 時為 5 分鐘
+func New(ttl time.Duration, maxEntries int) *Cache {
+	if ttl <= 0 {
+		ttl = 5 * time.Minute
+	}
+	return &Cache{
+		TTL:        ttl,
+		MaxEntries: maxEntries,
+		entries:    map[string]*list.Element{},
+		lru:        list.New(),
+		pending:    map[string]*call{},
+	}
+}
+
+// Key 以網址與選項（JSON 序列化後）產生快取鍵；選項應只包含影響輸出的欄位
+func Key(url string, opts interface{}) string {
+	h := sha256.New()
+	h.Write([]byte(url))
+	h.Write([]byte{0})
+	if opts != nil {
+		json.NewEncoder(h).Encode(opts)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Get 回傳未過期的快取
+func (c *Cache) Get(key string) (Entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.lookup(key, 0)
+	if ok {
+		c.hits++
+	} else {
+		c.misses++
+	}
+	return e, ok
+}
+
+// Put 寫入快取；e.Expires 為零值時以 TTL 計算
+func (c *Cache) Put(key string, e Entry) {
+	c.put(key, e)
+}
+
+// Do 依 ctl 回傳快取或呼叫 render 渲染並寫入快取；hit 表示結果來自快取。
+// 相同鍵同時有多個請求時只渲染一次，其餘等待並共用結果。render 的錯誤不會被快取
+func (c *Cache) Do(key string, ctl Control, render func() (Entry, error)) (e Entry, hit bool, err error) {
+	c.mu.Lock()
+	if !ctl.NoCache {
+		if e, ok := c.lookup(key, ctl.MaxAge); ok {
+			c.hits++
+			c.mu.Unlock()
+			return e, true, nil
+		}
+	}
+	c.misses++
+	if p, ok := c.pending[key]; ok && !ctl.NoCache {
+		c.mu.Unlock()
+		<-p.done
+		return p.entry, false, p.err
+	}
+	p := &call{done: make(chan struct{})}
+	c.pending[key] = p
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		if c.pending[key] == p {
+			delete(c.pending, key)
+		}
+		c.mu.Unlock()
+		close(p.done)
+	}()
+	p.entry, p.err = render()
+	if p.err != nil {
+		return p.entry, false, p.err
+	}
+	if ctl.MaxAge > 0 && p.entry.Expires.IsZero() {
+		p.entry.Expires = time.Now().Add(ctl.MaxAge)
+	}
+	if ctl.NoStore {
+		p.entry = c.complete(p.entry, nil)
+	} else {
+		p.entry = c.put(key, p.entry)
+	}
+	return p.entry, false, nil
+}
+
+// Purge 清除網址以 prefix 開頭的快取，回傳清除的筆數；prefix 為空字串時清除全部
+func (c *Cache) Purge(prefix string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, el := range c.entries {
+		if strings.HasPrefix(el.Value.(*item).entry.URL, prefix) {
+			c.remove(el)
+			n++
+		}
+	}
+	return n
+}
+
+// PurgeKey 清除單一快取鍵
+func (c *Cache) PurgeKey(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if ok {
+		c.remove(el)
+	}
+	return ok
+}
+
+// Stats 回傳目前的統計
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return Stats{Entries: c.lru.Len(), Bytes: c.bytes, Hits: c.hits, Misses: c.misses}
+}
+
+// Handler 快取管理端點，掛載於服務的管理路徑下，例如 mux.Handle("/cache", cache.Handler())：
+//
+//	GET    /cache               統計 (JSON)
+//	DELETE /cache?url=<prefix>  清除網址以 prefix 開頭的快取
+//	DELETE /cache?key=<key>     清除單一快取鍵
+//	DELETE /cache               清除全部
+func (c *Cache) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case http.MethodGet:
+			json.NewEncoder(w).Encode(c.Stats())
+		case http.MethodDelete, http.MethodPost:
+			q := r.URL.Query()
+			n := 0
+			if key := q.Get("key"); key != "" {
+				if c.PurgeKey(key) {
+					n = 1
+				}
+			} else {
+				n = c.Purge(q.Get("url"))
+			}
+			json.NewEncoder(w).Encode(map[string]int{"purged": n})
+		default:
+			w.Header().Set("Allow", "GET, DELETE, POST")
+			http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// ParseControl 由請求的 Cache-Control 標頭（no-cache、no-store、max-age=秒）
+// 與查詢參數 cache=refresh|bypass、max_age=秒 解析快取控制
+func ParseControl(r *http.Request) Control {
+	var ctl Control
+	for _, d := range strings.Split(r.Header.Get("Cache-Control"), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(strings.ToLower(d)), "=")
+		switch name {
+		case "no-cache":
+			ctl.NoCache = true
+		case "no-store":
+			ctl.NoStore = true
+		case "max-age":
+			if sec, err := strconv.Atoi(value); err == nil && sec >= 0 {
+				ctl.MaxAge = time.Duration(sec) * time.Second
+				// max-age=0 等同 no-cache
+				ctl.NoCache = ctl.NoCache || sec == 0
+			}
+		}
+	}
+	q := r.URL.Query()
+	switch q.Get("cache") {
+	case "refresh":
+		ctl.NoCache = true
+	case "bypass":
+		ctl.NoCache, ctl.NoStore = true, true
+	}
+	if sec, err := strconv.Atoi(q.Get("max_age")); err == nil && sec > 0 {
+		ctl.MaxAge = time.Duration(sec) * time.Second
+	}
+	return ctl
+}
+
+// SetHeaders 在回應加上 X-Cache（HIT 或 MISS）、Age、ETag 與 Last-Modified 標頭
+func SetHeaders(w http.ResponseWriter, e Entry, hit bool) {
+	if hit {
+		w.Header().Set("X-Cache", "HIT")
+	} else {
+		w.Header().Set("X-Cache", "MISS")
+	}
+	age := time.Since(e.RenderedAt)
+	if age < 0 {
+		age = 0
+	}
+	w.Header().Set("Age", strconv.Itoa(int(age/time.Second)))
+	if e.ETag != "" {
+		w.Header().Set("ETag", e.ETag)
+	}
+	if !e.Modified.IsZero() {
+		w.Header().Set("Last-Modified", e.Modified.UTC().Format(http.TimeFormat))
+	}
+}
+
+// Serve 以 e 回應請求：設定 SetHeaders 的標頭與 Content-Type，並依 If-None-Match、
+// If-Modified-Since 回應 304，讓下游（例如提供給搜尋引擎爬蟲的預渲染）能以條件式請求節省頻寬
+func Serve(w http.ResponseWriter, r *http.Request, e Entry, hit bool) {
+	SetHeaders(w, e, hit)
+	if NotModified(r, e) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if e.ContentType != "" {
+		w.Header().Set("Content-Type", e.ContentType)
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(e.Body)))
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		w.Write(e.Body)
+	}
+}
+
+// NotModified 回報請求的驗證值是否與 e 相符；有 If-None-Match 時忽略 If-Modified-Since
+func NotModified(r *http.Request, e Entry) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		etag := e.ETag
+		if etag == "" {
+			etag = ETag(e.Body)
+		}
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || tag == etag {
+				return true
+			}
+		}
+		return false
+	}
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" && !e.Modified.IsZero() {
+		t, err := http.ParseTime(ims)
+		return err == nil && !e.Modified.Truncate(time.Second).After(t)
+	}
+	return false
+}
+
+// ETag 以內容的 SHA-256 產生強驗證值
+func ETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// ----------------- 內部實作 -----------------
+
+// lookup 回傳未過期且渲染時間在 maxAge 之內的快取；過期的快取留到覆寫或淘汰時才移除，
+// 以便重新渲染的內容相同時沿用 Modified。呼叫時須持有 mu
+func (c *Cache) lookup(key string, maxAge time.Duration) (Entry, bool) {
+	el, ok := c.entries[key]
+	if !ok {
+		return Entry{}, false
+	}
+	e := el.Value.(*item).entry
+	now := time.Now()
+	if !now.Before(e.Expires) || (maxAge > 0 && now.Sub(e.RenderedAt) > maxAge) {
+		return Entry{}, false
+	}
+	c.lru.MoveToFront(el)
+	return e, true
+}
+
+// put 補上預設欄位後寫入快取並回傳寫入的內容；超過上限時淘汰最久未使用的快取，並順便移除過期的快取
+func (c *Cache) put(key string, e Entry) Entry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var prev *Entry
+	if el, ok := c.entries[key]; ok {
+		old := el.Value.(*item).entry
+		prev = &old
+		c.remove(el)
+	}
+	e = c.complete(e, prev)
+	c.entries[key] = c.lru.PushFront(&item{key: key, entry: e})
+	c.bytes += int64(len(e.Body))
+	now := time.Now()
+	for c.lru.Len() > 1 {
+		back := c.lru.Back()
+		full := (c.MaxEntries > 0 && c.lru.Len() > c.MaxEntries) || (c.MaxBytes > 0 && c.bytes > c.MaxBytes)
+		if !full && now.Before(back.Value.(*item).entry.Expires) {
+			break
+		}
+		c.remove(back)
+	}
+	return e
+}
+
+// complete 補上 RenderedAt、Expires、ETag 與 Modified；內容與 prev 相同時沿用其 Modified
+func (c *Cache) complete(e Entry, prev *Entry) Entry {
+	if e.RenderedAt.IsZero() {
+		e.RenderedAt = time.Now()
+	}
+	if e.Expires.IsZero() {
+		e.Expires = e.RenderedAt.Add(c.TTL)
+	}
+	if e.ETag == "" {
+		e.ETag = ETag(e.Body)
+	}
+	if e.Modified.IsZero() {
+		e.Modified = e.RenderedAt
+		if prev != nil && prev.ETag == e.ETag && !prev.Modified.IsZero() {
+			e.Modified = prev.Modified
+		}
+	}
+	return e
+}
+
+// remove 移除一筆快取；呼叫時須持有 mu
+func (c *Cache) remove(el *list.Element) {
+	it := el.Value.(*item)
+	c.lru.Remove(el)
+	delete(c.entries, it.key)
+	c.bytes -= int64(len(it.entry.Body))
+}
@@ -0,0 +1,196 @@
+// Package report 以 Go 模板將爬取結果渲染成人類可讀的 HTML 或 Markdown 報告。
+package report
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/firehourse/cdpkit/crawler"
+)
+
+// Format 報告格式
+type Format string
+
+const (
+	HTML     Format = "html"
+	Markdown Format = "markdown"
+)
+
+// Data 傳給模板的資料
+type Data struct {
+	Title       string
+	GeneratedAt time.Time
+	Results     []crawler.Result
+	// Columns 所有結果 Data 鍵的聯集（已排序），方便模板輸出表格
+	Columns []string
+	Total   int
+	Failed  int
+}
+
+// NewData 由結果建立模板資料
+func NewData(title string, results []crawler.Result) Data {
+	d := Data{Title: title, GeneratedAt: time.Now(), Results: results, Total: len(results)}
+	seen := map[string]bool{}
+	for _, r := range results {
+		if r.Error != "" {
+			d.Failed++
+		}
+		for k := range r.Data {
+			if !seen[k] {
+				seen[k] = true
+				d.Columns = append(d.Columns, k)
+			}
+		}
+	}
+	sort.Strings(d.Columns)
+	return d
+}
+
+// Renderer 已解析的報告模板
+type Renderer struct {
+	format Format
+	html   *htmltemplate.Template
+	text   *texttemplate.Template
+}
+
+// New 使用內建模板
+func New(format Format) (*Renderer, error) {
+	switch format {
+	case HTML:
+		return Parse(format, defaultHTML)
+	case Markdown:
+		return Parse(format, defaultMarkdown)
+	}
+	return nil, fmt.Errorf("report: 不支援的格式 %q", format)
+}
+
+// Parse 解析自訂模板；HTML 格式使用 html/template 自動跳脫
+func Parse(format Format, src string) (*Renderer, error) {
+	r := &Renderer{format: format}
+	var err error
+	switch format {
+	case HTML:
+		r.html, err = htmltemplate.New("report").Funcs(htmltemplate.FuncMap(funcs)).Funcs(htmltemplate.FuncMap{
+			"embedImage": embedImageHTML,
+		}).Parse(src)
+	case Markdown:
+		r.text, err = texttemplate.New("report").Funcs(texttemplate.FuncMap(funcs)).Funcs(texttemplate.FuncMap{
+			"embedImage": embedImage,
+		}).Parse(src)
+	default:
+		return nil, fmt.Errorf("report: 不支援的格式 %q", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("report: 解析模板失敗: %w", err)
+	}
+	return r, nil
+}
+
+// ParseFile 讀取模板檔；格式依副檔名判斷（.md 為 Markdown，其餘為 HTML）
+func ParseFile(path string) (*Renderer, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("report: 無法讀取模板 %s: %w", path, err)
+	}
+	return Parse(FormatFromPath(path), string(src))
+}
+
+// FormatFromPath 依副檔名判斷格式
+func FormatFromPath(path string) Format {
+	name := strings.TrimSuffix(strings.ToLower(path), ".tmpl")
+	if strings.HasSuffix(name, ".md") || strings.HasSuffix(name, ".markdown") {
+		return Markdown
+	}
+	return HTML
+}
+
+// Render 將資料寫入 w
+func (r *Renderer) Render(w io.Writer, data Data) error {
+	var err error
+	if r.html != nil {
+		err = r.html.Execute(w, data)
+	} else {
+		err = r.text.Execute(w, data)
+	}
+	if err != nil {
+		return fmt.Errorf("report: 渲染失敗: %w", err)
+	}
+	return nil
+}
+
+// WriteFile 渲染報告並寫入檔案
+func (r *Renderer) WriteFile(path string, data Data) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("report: 無法建立 %s: %w", path, err)
+	}
+	if err := r.Render(f, data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ----------------- 模板函式 -----------------
+
+var funcs = map[string]interface{}{
+	"json": func(v interface{}) string {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(b)
+	},
+	"value": func(r crawler.Result, key string) interface{} {
+		v := r.Data[key]
+		switch v.(type) {
+		case map[string]interface{}, []interface{}:
+			b, _ := json.Marshal(v)
+			return string(b)
+		}
+		return v
+	},
+	"formatTime": func(layout string, t time.Time) string {
+		return t.Format(layout)
+	},
+	"ms": func(d time.Duration) int64 {
+		return d.Milliseconds()
+	},
+	"join": strings.Join,
+	"mdEscape": func(v interface{}) string {
+		s := fmt.Sprintf("%v", v)
+		if v == nil {
+			s = ""
+		}
+		s = strings.ReplaceAll(s, "|", `\|`)
+		return strings.ReplaceAll(s, "\n", " ")
+	},
+}
+
+// embedImage 將圖片檔轉為 data URI，讓報告成為單一自足檔案；讀取失敗時回傳原路徑
+func embedImage(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return path
+	}
+	typ := mime.TypeByExtension(filepath.Ext(path))
+	if typ == "" {
+		typ = "image/png"
+	}
+	return "data:" + typ + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
+
+// embedImageHTML 與 embedImage 相同，但標記為安全的 URL 讓 html/template 不會改寫
+func embedImageHTML(path string) htmltemplate.URL {
+	return htmltemplate.URL(embedImage(path))
+}
@@ -0,0 +1,59 @@
+package report
+
+// 內建 HTML 模板；Data 中名為 screenshot 的欄位會當作圖片路徑嵌入
+const defaultHTML = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; width: 100%; font-size: 14px; }
+th, td { border: 1px solid #ddd; padding: 6px 8px; text-align: left; vertical-align: top; }
+th { background: #f4f4f4; }
+tr.error td { background: #fff0f0; }
+img.shot { max-width: 320px; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>產生時間 {{formatTime "2006-01-02 15:04:05" .GeneratedAt}}，共 {{.Total}} 筆，失敗 {{.Failed}} 筆</p>
+<table>
+<tr><th>URL</th><th>標題</th>{{range .Columns}}<th>{{.}}</th>{{end}}<th>耗時 (ms)</th></tr>
+{{- $cols := .Columns}}
+{{- range .Results}}
+{{- $r := .}}
+<tr{{if .Error}} class="error"{{end}}>
+<td><a href="{{.URL}}">{{.URL}}</a>{{if .Error}}<br><small>{{.Error}}</small>{{end}}</td>
+<td>{{.Title}}</td>
+{{- range $cols}}
+<td>{{if eq . "screenshot"}}{{with index $r.Data .}}<img class="shot" src="{{embedImage (print .)}}">{{end}}{{else}}{{value $r .}}{{end}}</td>
+{{- end}}
+<td>{{ms .ElapsedTime}}</td>
+</tr>
+{{- end}}
+</table>
+</body>
+</html>
+`
+
+// 內建 Markdown 模板
+const defaultMarkdown = `# {{.Title}}
+
+產生時間 {{formatTime "2006-01-02 15:04:05" .GeneratedAt}}，共 {{.Total}} 筆，失敗 {{.Failed}} 筆
+
+| URL | 標題 |{{range .Columns}} {{.}} |{{end}}
+|---|---|{{range .Columns}}---|{{end}}
+{{- $cols := .Columns}}
+{{- range .Results}}
+{{- $r := .}}
+| {{.URL}} | {{mdEscape .Title}} |{{range $cols}} {{if eq . "screenshot"}}{{with index $r.Data .}}![]({{print .}}){{end}}{{else}}{{mdEscape (value $r .)}}{{end}} |{{end}}
+{{- end}}
+{{- if .Failed}}
+
+## 失敗
+
+{{range .Results}}{{if .Error}}- {{.URL}}: {{.Error}}
+{{end}}{{end}}
+{{- end}}
+`
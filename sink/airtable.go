@@ -0,0 +1,75 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/firehourse/cdpkit/crawler"
+)
+
+// airtableMaxRecords Airtable 單次建立紀錄的上限
+const airtableMaxRecords = 10
+
+// Airtable 將結果建立為 Airtable 資料表中的紀錄
+type Airtable struct {
+	// BaseID 例如 appXXXXXXXXXXXXXX
+	BaseID string
+	// Table 資料表名稱或 ID
+	Table string
+	// Fields 欄位對應；nil 時使用 DefaultFields
+	Fields []Field
+	// Token 個人存取權杖
+	Token string
+	// Typecast 是否讓 Airtable 自動轉換型別並建立新的選項
+	Typecast bool
+	// Client 可自訂 HTTP client；nil 時使用 http.DefaultClient
+	Client *http.Client
+	// Endpoint API 位址，預設為 https://api.airtable.com
+	Endpoint string
+
+	// Airtable 每個 base 每秒最多 5 次請求
+	limit throttle
+}
+
+// Write 實作 Sink
+func (a *Airtable) Write(ctx context.Context, results []crawler.Result) error {
+	if a.BaseID == "" || a.Table == "" {
+		return fmt.Errorf("Airtable: 未設定 BaseID 或 Table")
+	}
+	fields := a.Fields
+	if fields == nil {
+		fields = DefaultFields(results)
+	}
+	endpoint := a.Endpoint
+	if endpoint == "" {
+		endpoint = "https://api.airtable.com"
+	}
+	u := fmt.Sprintf("%s/v0/%s/%s", endpoint, url.PathEscape(a.BaseID), url.PathEscape(a.Table))
+
+	for start := 0; start < len(results); start += airtableMaxRecords {
+		end := min(start+airtableMaxRecords, len(results))
+		records := make([]map[string]interface{}, 0, end-start)
+		for _, r := range results[start:end] {
+			rec := make(map[string]interface{}, len(fields))
+			for _, f := range fields {
+				if v := f.Value(r); v != nil {
+					rec[f.Name] = scalar(v)
+				}
+			}
+			records = append(records, map[string]interface{}{"fields": rec})
+		}
+
+		if err := a.limit.wait(ctx, 200*time.Millisecond); err != nil {
+			return err
+		}
+		// 超過速率限制時 Airtable 要求等待 30 秒
+		body := map[string]interface{}{"records": records, "typecast": a.Typecast}
+		if err := postJSON(ctx, a.Client, u, a.Token, body, 30*time.Second); err != nil {
+			return fmt.Errorf("Airtable: %w", err)
+		}
+	}
+	return nil
+}
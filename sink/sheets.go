@@ -0,0 +1,75 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/firehourse/cdpkit/crawler"
+)
+
+// sheetsMaxRows 單次 append 請求的列數上限，避免觸發請求大小限制
+const sheetsMaxRows = 500
+
+// GoogleSheets 以 values:append API 將結果附加到 Google 試算表
+type GoogleSheets struct {
+	// SpreadsheetID 試算表 ID（網址 /d/ 之後的部分）
+	SpreadsheetID string
+	// Range 附加的工作表範圍，例如 "Sheet1!A1"；預設為 "Sheet1"
+	Range string
+	// Fields 欄位對應，依序成為各欄；nil 時使用 DefaultFields
+	Fields []Field
+	// Token OAuth2 存取權杖；若 Client 已自行處理授權（例如 oauth2.Client）可留空
+	Token string
+	// Client 可自訂 HTTP client；nil 時使用 http.DefaultClient
+	Client *http.Client
+	// Endpoint API 位址，預設為 https://sheets.googleapis.com
+	Endpoint string
+
+	// Sheets API 每位使用者每分鐘 60 次寫入，間隔至少 1 秒
+	limit throttle
+}
+
+// Write 實作 Sink
+func (s *GoogleSheets) Write(ctx context.Context, results []crawler.Result) error {
+	if s.SpreadsheetID == "" {
+		return fmt.Errorf("GoogleSheets: 未設定 SpreadsheetID")
+	}
+	fields := s.Fields
+	if fields == nil {
+		fields = DefaultFields(results)
+	}
+	rng := s.Range
+	if rng == "" {
+		rng = "Sheet1"
+	}
+	endpoint := s.Endpoint
+	if endpoint == "" {
+		endpoint = "https://sheets.googleapis.com"
+	}
+	u := fmt.Sprintf("%s/v4/spreadsheets/%s/values/%s:append?valueInputOption=RAW&insertDataOption=INSERT_ROWS",
+		endpoint, url.PathEscape(s.SpreadsheetID), url.PathEscape(rng))
+
+	for start := 0; start < len(results); start += sheetsMaxRows {
+		end := min(start+sheetsMaxRows, len(results))
+		values := make([][]interface{}, 0, end-start)
+		for _, r := range results[start:end] {
+			row := make([]interface{}, len(fields))
+			for i, f := range fields {
+				row[i] = scalar(f.Value(r))
+			}
+			values = append(values, row)
+		}
+
+		if err := s.limit.wait(ctx, time.Second); err != nil {
+			return err
+		}
+		body := map[string]interface{}{"values": values}
+		if err := postJSON(ctx, s.Client, u, s.Token, body, 5*time.Second); err != nil {
+			return fmt.Errorf("GoogleSheets: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+// Package sink 將爬取結果寫到外部服務（試算表、資料庫等），供非工程人員直接使用。
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/firehourse/cdpkit/crawler"
+)
+
+// Sink 接收一批結果；實作自行處理分批上限與速率限制
+type Sink interface {
+	Write(ctx context.Context, results []crawler.Result) error
+}
+
+// Field 一個輸出欄位的對應
+type Field struct {
+	// Name 目標欄位名稱（試算表欄、Airtable 欄位）
+	Name string
+	// Source 來源：url、title、error、response_code、timestamp，或 data.<鍵> 取 Result.Data
+	Source string
+}
+
+// Value 依 Source 取出結果中的值；不存在時為 nil
+func (f Field) Value(r crawler.Result) interface{} {
+	if key, ok := strings.CutPrefix(f.Source, "data."); ok {
+		return r.Data[key]
+	}
+	switch f.Source {
+	case "url":
+		return r.URL
+	case "title":
+		return r.Title
+	case "error":
+		return r.Error
+	case "response_code":
+		return r.ResponseCode
+	case "timestamp":
+		return r.Timestamp.Format(time.RFC3339)
+	}
+	return nil
+}
+
+// DefaultFields 未指定對應時輸出的欄位：固定欄位加上第一筆結果的所有 Data 鍵
+func DefaultFields(results []crawler.Result) []Field {
+	fields := []Field{{Name: "url", Source: "url"}, {Name: "title", Source: "title"}}
+	if len(results) > 0 {
+		for _, k := range sortedKeys(results[0].Data) {
+			fields = append(fields, Field{Name: k, Source: "data." + k})
+		}
+	}
+	return fields
+}
+
+// ----------------- 內部輔助 -----------------
+
+// maxRateLimitRetries 遇到 429 時最多重試的次數
+const maxRateLimitRetries = 5
+
+// postJSON 送出 JSON 請求；遇到 429 或 503 時依 Retry-After（或 fallback）等待後重試
+func postJSON(ctx context.Context, client *http.Client, url, token string, body interface{}, fallback time.Duration) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("請求 %s 失敗: %w", url, err)
+		}
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		resp.Body.Close()
+
+		limited := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable
+		if limited && attempt < maxRateLimitRetries {
+			wait := fallback << attempt
+			if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+				wait = time.Duration(s) * time.Second
+			}
+			select {
+			case <-time.After(wait):
+				continue
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		if resp.StatusCode/100 != 2 {
+			return fmt.Errorf("%s 回應 %d: %s", url, resp.StatusCode, strings.TrimSpace(string(msg)))
+		}
+		return nil
+	}
+}
+
+// throttle 確保兩次請求間隔至少 interval；可被多個 goroutine 共用
+type throttle struct {
+	mu   sync.Mutex
+	last time.Time
+}
+
+func (t *throttle) wait(ctx context.Context, interval time.Duration) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if d := time.Until(t.last.Add(interval)); d > 0 {
+		select {
+		case <-time.After(d):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	t.last = time.Now()
+	return nil
+}
+
+// scalar 將巢狀值轉為 JSON 字串，讓試算表儲存格只收到純量
+func scalar(v interface{}) interface{} {
+	switch v.(type) {
+	case nil, string, bool, float64, float32, int, int64:
+		return v
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
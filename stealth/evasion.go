@@ -0,0 +1,316 @@
+// Package stealth 提供可組合的反檢測腳本與具名指紋 profile。
+// 腳本透過 Page.addScriptToEvaluateOnNewDocument 在每個文件載入前執行。
+package stealth
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// Evasion 一項可獨立啟用的規避；Script 依 profile 產生 JS 片段，回傳空字串表示不適用
+type Evasion struct {
+	Name   string
+	Script func(p Profile) string
+}
+
+// 所有 evasion 共用的輔助函式：讓覆寫後的函式在 toString 時仍像原生函式
+const prelude = `
+const __cdpkitNative = new WeakMap();
+const __cdpkitToString = Function.prototype.toString;
+Function.prototype.toString = new Proxy(__cdpkitToString, {
+	apply(target, self, args) {
+		return __cdpkitNative.has(self) ? __cdpkitNative.get(self) : Reflect.apply(target, self, args);
+	}
+});
+__cdpkitNative.set(Function.prototype.toString, 'function toString() { [native code] }');
+const __cdpkitPatch = (obj, name, fn) => {
+	__cdpkitNative.set(fn, 'function ' + name + '() { [native code] }');
+	obj[name] = fn;
+};
+const __cdpkitGetter = (obj, name, value) => {
+	Object.defineProperty(obj, name, {get: () => value, configurable: true});
+};
+`
+
+// defaultPlugins 桌面版 Chrome 的 PDF 外掛清單
+var defaultPlugins = []string{"PDF Viewer", "Chrome PDF Viewer", "Chromium PDF Viewer", "Microsoft Edge PDF Viewer", "WebKit built-in PDF"}
+
+var (
+	// Webdriver 隱藏 navigator.webdriver
+	Webdriver = Evasion{"webdriver", func(p Profile) string {
+		return `Object.defineProperty(Navigator.prototype, 'webdriver', {get: () => undefined, configurable: true});`
+	}}
+
+	// Plugins 依 profile 的外掛清單設定 navigator.plugins、navigator.mimeTypes 與 pdfViewerEnabled
+	Plugins = Evasion{"plugins", func(p Profile) string {
+		names := p.Plugins
+		if names == nil {
+			names = defaultPlugins
+		}
+		return fmt.Sprintf(`
+const names = %s;
+const types = names.length ? ['application/pdf', 'text/pdf'] : [];
+const plugins = names.map(name => {
+	const plugin = {name, filename: 'internal-pdf-viewer', description: 'Portable Document Format', length: types.length};
+	Object.setPrototypeOf(plugin, Plugin.prototype);
+	return plugin;
+});
+const mimeTypes = types.map(type => {
+	const m = {type, suffixes: 'pdf', description: 'Portable Document Format', enabledPlugin: plugins[0]};
+	Object.setPrototypeOf(m, MimeType.prototype);
+	return m;
+});
+plugins.forEach(plugin => mimeTypes.forEach((m, i) => { plugin[i] = m; plugin[m.type] = m; }));
+plugins.forEach(plugin => { plugin.item = i => mimeTypes[i] || null; plugin.namedItem = n => mimeTypes.find(x => x.type === n) || null; });
+plugins.item = i => plugins[i] || null;
+plugins.namedItem = n => plugins.find(x => x.name === n) || null;
+plugins.refresh = () => {};
+mimeTypes.item = i => mimeTypes[i] || null;
+mimeTypes.namedItem = n => mimeTypes.find(x => x.type === n) || null;
+Object.setPrototypeOf(plugins, PluginArray.prototype);
+Object.setPrototypeOf(mimeTypes, MimeTypeArray.prototype);
+__cdpkitGetter(Navigator.prototype, 'plugins', plugins);
+__cdpkitGetter(Navigator.prototype, 'mimeTypes', mimeTypes);
+__cdpkitGetter(Navigator.prototype, 'pdfViewerEnabled', names.length > 0);`, jsValue(names))
+	}}
+
+	// Languages 設定 navigator.languages
+	Languages = Evasion{"languages", func(p Profile) string {
+		if len(p.Languages) == 0 {
+			return ""
+		}
+		return fmt.Sprintf(`const languages = Object.freeze(%s);
+__cdpkitGetter(Navigator.prototype, 'languages', languages);
+__cdpkitGetter(Navigator.prototype, 'language', languages[0]);`, jsValue(p.Languages))
+	}}
+
+	// Permissions 讓通知等權限查詢回傳 prompt，與一般瀏覽器一致
+	Permissions = Evasion{"permissions", func(p Profile) string {
+		return `
+if (navigator.permissions) {
+	const query = navigator.permissions.query.bind(navigator.permissions);
+	__cdpkitPatch(Permissions.prototype, 'query', function query(parameters) {
+		return ['notifications', 'clipboard-read', 'clipboard-write'].includes(parameters && parameters.name)
+			? Promise.resolve({state: 'prompt', onchange: null})
+			: query(parameters);
+	});
+}`
+	}}
+
+	// CDC 移除 chromedriver 留下的全域變數
+	CDC = Evasion{"cdc", func(p Profile) string {
+		return `for (const k of Object.keys(window)) { if (/^cdc_|^\$cdc_/.test(k)) delete window[k]; }`
+	}}
+
+	// Navigator 覆寫 platform、vendor；非 Google vendor 時移除 userAgentData
+	Navigator = Evasion{"navigator", func(p Profile) string {
+		var b strings.Builder
+		if p.Platform != "" {
+			fmt.Fprintf(&b, "__cdpkitGetter(Navigator.prototype, 'platform', %s);\n", jsValue(p.Platform))
+		}
+		if p.Vendor != "" {
+			fmt.Fprintf(&b, "__cdpkitGetter(Navigator.prototype, 'vendor', %s);\n", jsValue(p.Vendor))
+			if p.Vendor != VendorGoogle {
+				b.WriteString("__cdpkitGetter(Navigator.prototype, 'userAgentData', undefined);\n")
+			}
+		}
+		return b.String()
+	}}
+
+	// WebGLVendor 覆寫 WEBGL_debug_renderer_info 的 vendor 與 renderer
+	WebGLVendor = Evasion{"webgl", func(p Profile) string {
+		if p.WebGLVendor == "" && p.WebGLRenderer == "" {
+			return ""
+		}
+		return fmt.Sprintf(`
+const vendor = %s, renderer = %s;
+for (const proto of [WebGLRenderingContext.prototype, self.WebGL2RenderingContext && WebGL2RenderingContext.prototype]) {
+	if (!proto) continue;
+	const getParameter = proto.getParameter;
+	__cdpkitPatch(proto, 'getParameter', function getParameter_(pname) {
+		if (pname === 37445 && vendor) return vendor;
+		if (pname === 37446 && renderer) return renderer;
+		return getParameter.call(this, pname);
+	});
+}`, jsValue(p.WebGLVendor), jsValue(p.WebGLRenderer))
+	}}
+
+	// CanvasNoise 在讀取 canvas 像素時加入固定種子的微量雜訊，讓 canvas 指紋與實體機器不同但在同一 session 內穩定
+	CanvasNoise = Evasion{"canvas", func(p Profile) string {
+		return fmt.Sprintf(`
+const seed = %d;
+const noise = (data) => {
+	let s = seed;
+	for (let i = 0; i < data.length; i += 4) {
+		s = (s * 1103515245 + 12345) & 0x7fffffff;
+		if (s %% 10 === 0) data[i] ^= 1;
+	}
+};
+const getImageData = CanvasRenderingContext2D.prototype.getImageData;
+__cdpkitPatch(CanvasRenderingContext2D.prototype, 'getImageData', function getImageData_(...args) {
+	const img = getImageData.apply(this, args);
+	noise(img.data);
+	return img;
+});
+for (const name of ['toDataURL', 'toBlob']) {
+	const orig = HTMLCanvasElement.prototype[name];
+	// 在複本上加雜訊，原 canvas 保持不變，重複讀取的結果一致
+	const fn = function (...args) {
+		if (!this.width || !this.height) return orig.apply(this, args);
+		const copy = document.createElement('canvas');
+		copy.width = this.width;
+		copy.height = this.height;
+		const ctx = copy.getContext('2d');
+		ctx.drawImage(this, 0, 0);
+		const img = getImageData.call(ctx, 0, 0, copy.width, copy.height);
+		noise(img.data);
+		ctx.putImageData(img, 0, 0);
+		return orig.apply(copy, args);
+	};
+	__cdpkitPatch(HTMLCanvasElement.prototype, name, fn);
+}`, p.seed())
+	}}
+
+	// AudioContext 對音訊取樣加入微量雜訊，避免 AudioContext 指紋
+	AudioContext = Evasion{"audio", func(p Profile) string {
+		return fmt.Sprintf(`
+if (self.AudioBuffer) {
+	const seed = %d;
+	const getChannelData = AudioBuffer.prototype.getChannelData;
+	const touched = new WeakSet();
+	__cdpkitPatch(AudioBuffer.prototype, 'getChannelData', function getChannelData_(...args) {
+		const data = getChannelData.apply(this, args);
+		if (!touched.has(data)) {
+			touched.add(data);
+			let s = seed;
+			for (let i = 0; i < data.length; i += 100) {
+				s = (s * 1103515245 + 12345) & 0x7fffffff;
+				data[i] += (s / 0x7fffffff - 0.5) * 1e-7;
+			}
+		}
+		return data;
+	});
+}`, p.seed())
+	}}
+
+	// Battery 讓 getBattery 回傳插電、滿電的桌機狀態
+	Battery = Evasion{"battery", func(p Profile) string {
+		if p.Vendor == VendorApple {
+			return `delete Navigator.prototype.getBattery;`
+		}
+		return `
+if (navigator.getBattery) {
+	const battery = {charging: true, chargingTime: 0, dischargingTime: Infinity, level: 1,
+		addEventListener() {}, removeEventListener() {}, onchargingchange: null,
+		onchargingtimechange: null, ondischargingtimechange: null, onlevelchange: null};
+	__cdpkitPatch(Navigator.prototype, 'getBattery', function getBattery() { return Promise.resolve(battery); });
+}`
+	}}
+
+	// HardwareConcurrency 覆寫 CPU 核心數
+	HardwareConcurrency = Evasion{"hardwareConcurrency", func(p Profile) string {
+		if p.HardwareConcurrency <= 0 {
+			return ""
+		}
+		return fmt.Sprintf(`__cdpkitGetter(Navigator.prototype, 'hardwareConcurrency', %d);`, p.HardwareConcurrency)
+	}}
+
+	// DeviceMemory 覆寫記憶體大小；Safari 沒有此屬性
+	DeviceMemory = Evasion{"deviceMemory", func(p Profile) string {
+		if p.Vendor == VendorApple {
+			return `delete Navigator.prototype.deviceMemory;`
+		}
+		if p.DeviceMemory <= 0 {
+			return ""
+		}
+		return fmt.Sprintf(`__cdpkitGetter(Navigator.prototype, 'deviceMemory', %d);`, p.DeviceMemory)
+	}}
+
+	// ChromeRuntime 補上一般 Chrome 才有的 window.chrome；Safari profile 則移除
+	ChromeRuntime = Evasion{"chrome.runtime", func(p Profile) string {
+		if p.Vendor == VendorApple {
+			return `delete window.chrome;`
+		}
+		return `
+if (!window.chrome) {
+	Object.defineProperty(window, 'chrome', {value: {}, writable: true, configurable: true});
+}
+if (!window.chrome.runtime) {
+	window.chrome.runtime = {
+		OnInstalledReason: {CHROME_UPDATE: 'chrome_update', INSTALL: 'install', SHARED_MODULE_UPDATE: 'shared_module_update', UPDATE: 'update'},
+		PlatformOs: {ANDROID: 'android', CROS: 'cros', LINUX: 'linux', MAC: 'mac', OPENBSD: 'openbsd', WIN: 'win'},
+		connect() {}, sendMessage() {},
+	};
+}
+if (!window.chrome.loadTimes) {
+	window.chrome.loadTimes = function () { return {}; };
+	window.chrome.csi = function () { return {}; };
+}`
+	}}
+)
+
+// All 回傳所有內建 evasion，依建議的套用順序排列
+func All() []Evasion {
+	return []Evasion{
+		Webdriver, Plugins, Languages, Permissions, CDC, Navigator, WebGLVendor,
+		CanvasNoise, AudioContext, Battery, HardwareConcurrency, DeviceMemory, ChromeRuntime,
+	}
+}
+
+// ByName 依名稱挑選 evasion
+func ByName(names ...string) ([]Evasion, error) {
+	all := All()
+	out := make([]Evasion, 0, len(names))
+	for _, n := range names {
+		found := false
+		for _, e := range all {
+			if e.Name == n {
+				out = append(out, e)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("stealth: 未知的 evasion %q", n)
+		}
+	}
+	return out, nil
+}
+
+// Script 組合 profile 啟用的 evasion；每項各自包在 try 區塊中，單項失敗不影響其他項目
+func Script(p Profile) (string, error) {
+	evasions := All()
+	if p.Evasions != nil {
+		var err error
+		if evasions, err = ByName(p.Evasions...); err != nil {
+			return "", err
+		}
+	}
+	if p.NoiseSeed == 0 {
+		p.NoiseSeed = rand.Int63n(1<<31-1) + 1
+	}
+
+	var b strings.Builder
+	b.WriteString("(() => {\n")
+	b.WriteString(prelude)
+	for _, e := range evasions {
+		js := e.Script(p)
+		if js == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "// %s\ntry {\n%s\n} catch (e) {}\n", e.Name, strings.TrimSpace(js))
+	}
+	b.WriteString("})();\n")
+	return b.String(), nil
+}
+
+func (p Profile) seed() int64 {
+	return p.NoiseSeed & 0x7fffffff
+}
+
+func jsValue(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
@@ -0,0 +1,117 @@
+package stealth
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Profile 一組一致的瀏覽器指紋；同一個 profile 的 UA、平台、WebGL 等數值彼此相符
+type Profile struct {
+	Name      string
+	UserAgent string
+	// Platform navigator.platform
+	Platform string
+	// Vendor navigator.vendor；"Apple Computer, Inc." 時會移除 window.chrome 與 userAgentData
+	Vendor string
+	// Languages navigator.languages，第一個同時作為 navigator.language；nil 表示不覆寫
+	Languages []string
+	// Plugins navigator.plugins 的外掛名稱，每個外掛都附帶 PDF 的 mimeTypes；
+	// nil 使用桌面版 Chrome 的 PDF 外掛清單，空 slice 表示沒有外掛（行動裝置）
+	Plugins []string
+	// WebGLVendor、WebGLRenderer 為 WEBGL_debug_renderer_info 回傳的未遮蔽值
+	WebGLVendor   string
+	WebGLRenderer string
+	// HardwareConcurrency navigator.hardwareConcurrency；0 表示不覆寫
+	HardwareConcurrency int
+	// DeviceMemory navigator.deviceMemory（GB）；0 表示不覆寫
+	DeviceMemory int
+	// NoiseSeed canvas 與 AudioContext 雜訊的種子；0 表示每次產生腳本時隨機
+	NoiseSeed int64
+	// Evasions 啟用的規避項目名稱；nil 表示全部
+	Evasions []string
+}
+
+// Chromium 與 WebKit 的 navigator.vendor
+const (
+	VendorGoogle = "Google Inc."
+	VendorApple  = "Apple Computer, Inc."
+)
+
+var profiles = map[string]Profile{
+	"win-chrome-123": {
+		UserAgent:           "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/123.0.0.0 Safari/537.36",
+		Platform:            "Win32",
+		Vendor:              VendorGoogle,
+		Languages:           []string{"en-US", "en"},
+		WebGLVendor:         "Google Inc. (NVIDIA)",
+		WebGLRenderer:       "ANGLE (NVIDIA, NVIDIA GeForce GTX 1660 SUPER Direct3D11 vs_5_0 ps_5_0, D3D11)",
+		HardwareConcurrency: 8,
+		DeviceMemory:        8,
+	},
+	"mac-chrome-123": {
+		UserAgent:           "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/123.0.0.0 Safari/537.36",
+		Platform:            "MacIntel",
+		Vendor:              VendorGoogle,
+		Languages:           []string{"en-US", "en"},
+		WebGLVendor:         "Google Inc. (Apple)",
+		WebGLRenderer:       "ANGLE (Apple, ANGLE Metal Renderer: Apple M1, Unspecified Version)",
+		HardwareConcurrency: 8,
+		DeviceMemory:        8,
+	},
+	"linux-chrome-123": {
+		UserAgent:           "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/123.0.0.0 Safari/537.36",
+		Platform:            "Linux x86_64",
+		Vendor:              VendorGoogle,
+		Languages:           []string{"en-US", "en"},
+		WebGLVendor:         "Google Inc. (Intel)",
+		WebGLRenderer:       "ANGLE (Intel, Mesa Intel(R) UHD Graphics 620 (KBL GT2), OpenGL 4.6)",
+		HardwareConcurrency: 4,
+		DeviceMemory:        8,
+	},
+	"mac-safari": {
+		UserAgent:           "Mozilla/5.0 (Macintosh; Intel Mac OS X 14_4) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Safari/605.1.15",
+		Platform:            "MacIntel",
+		Vendor:              VendorApple,
+		Languages:           []string{"en-US", "en"},
+		WebGLVendor:         "Apple Inc.",
+		WebGLRenderer:       "Apple GPU",
+		HardwareConcurrency: 8,
+	},
+	// legacy 對應 NewTab 過去內建的反檢測腳本：只隱藏自動化特徵，不覆寫硬體指紋；
+	// 語言由分頁的 Locale 決定，未設定時維持瀏覽器原生值
+	"legacy": {
+		Evasions: []string{"webdriver", "plugins", "languages", "permissions", "cdc"},
+	},
+}
+
+// Lookup 依名稱取得內建 profile
+func Lookup(name string) (Profile, error) {
+	p, ok := profiles[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("stealth: 未知的 profile %q（可用：%v）", name, Names())
+	}
+	p.Name = name
+	p.Languages = append([]string(nil), p.Languages...)
+	if p.Plugins != nil {
+		p.Plugins = append([]string{}, p.Plugins...)
+	}
+	if p.Evasions != nil {
+		p.Evasions = append([]string(nil), p.Evasions...)
+	}
+	return p, nil
+}
+
+// Register 新增或覆寫一個具名 profile，供 config 以名稱選用；應於程式初始化時呼叫
+func Register(name string, p Profile) {
+	profiles[name] = p
+}
+
+// Names 回傳所有內建 profile 名稱
+func Names() []string {
+	names := make([]string, 0, len(profiles))
+	for n := range profiles {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
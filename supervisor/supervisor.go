@@ -0,0 +1,295 @@
+// Package supervisor 追蹤應用程式中建立的所有爬蟲、分頁、瀏覽器與其他用戶端，
+// 在結束時依相依順序（爬蟲 → 分頁 → 瀏覽器 → 用戶端）全部關閉，避免遺留 Chrome 行程。
+//
+//	c, err := supervisor.NewCrawler(opts)
+//	bm, err := supervisor.NewBrowser(cfg)
+//	supervisor.TrackClient("output", sink)
+//	defer supervisor.Shutdown(context.Background())
+//
+// 也可直接註冊為 lifecycle 的關閉步驟：lc.OnShutdown("cdpkit", supervisor.Shutdown)。
+// 所有方法皆可在多個 goroutine 中同時呼叫。
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+
+	"github.com/firehourse/cdpkit/browser"
+	"github.com/firehourse/cdpkit/config"
+	"github.com/firehourse/cdpkit/crawler"
+	"github.com/firehourse/cdpkit/tab"
+)
+
+// ErrShutdown Supervisor 已關閉；之後建立或追蹤的資源會立即關閉並回傳此錯誤
+var ErrShutdown = errors.New("supervisor 已關閉")
+
+// Default 套件層級函式使用的 Supervisor
+var Default = New()
+
+// Supervisor 追蹤資源並統一關閉；零值不可用，請以 New 建立
+type Supervisor struct {
+	mu       sync.Mutex
+	crawlers map[*crawler.Crawler]struct{}
+	tabs     map[*tab.Tab]*browser.BrowserManager
+	browsers map[*browser.BrowserManager]struct{}
+	clients  map[io.Closer]string
+	// order 各類資源的追蹤順序，同一層依建立的反序關閉
+	order []interface{}
+	// started Shutdown 已開始，之後不再接受新資源
+	started bool
+
+	once sync.Once
+	done chan struct{}
+	err  error
+}
+
+// New 建立 Supervisor
+func New() *Supervisor {
+	return &Supervisor{
+		crawlers: make(map[*crawler.Crawler]struct{}),
+		tabs:     make(map[*tab.Tab]*browser.BrowserManager),
+		browsers: make(map[*browser.BrowserManager]struct{}),
+		clients:  make(map[io.Closer]string),
+		done:     make(chan struct{}),
+	}
+}
+
+// NewBrowser 以 browser.NewManagerFromConfig 建立並追蹤瀏覽器管理器
+func (s *Supervisor) NewBrowser(cfg config.Config) (*browser.BrowserManager, error) {
+	if s.closed() {
+		return nil, ErrShutdown
+	}
+	bm, err := browser.NewManagerFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return bm, s.TrackBrowser(bm)
+}
+
+// NewCrawler 以 crawler.New 建立並追蹤爬蟲
+func (s *Supervisor) NewCrawler(opts crawler.Options) (*crawler.Crawler, error) {
+	if s.closed() {
+		return nil, ErrShutdown
+	}
+	c, err := crawler.New(opts)
+	if err != nil {
+		return nil, err
+	}
+	return c, s.TrackCrawler(c)
+}
+
+// NewTab 在 bm 上開啟並追蹤分頁；bm 未被追蹤時一併追蹤
+func (s *Supervisor) NewTab(bm *browser.BrowserManager, cfg config.Config) (*tab.Tab, error) {
+	if s.closed() {
+		return nil, ErrShutdown
+	}
+	ctx, cancel, err := bm.NewPageContext()
+	if err != nil {
+		return nil, fmt.Errorf("創建分頁失敗: %w", err)
+	}
+	t := tab.Open(ctx, cancel, config.Split(cfg))
+	if err := s.TrackBrowser(bm); err != nil {
+		t.Close(bm)
+		return nil, err
+	}
+	return t, s.TrackTab(t, bm)
+}
+
+// TrackCrawler 追蹤自行建立的爬蟲；關閉時先以 Drain 等待進行中的頁面
+func (s *Supervisor) TrackCrawler(c *crawler.Crawler) error {
+	return s.track(c, func() bool {
+		if _, ok := s.crawlers[c]; ok {
+			return false
+		}
+		s.crawlers[c] = struct{}{}
+		return true
+	}, c.Close)
+}
+
+// TrackTab 追蹤自行建立的分頁，bm 為分頁所屬的瀏覽器管理器，可為 nil
+func (s *Supervisor) TrackTab(t *tab.Tab, bm *browser.BrowserManager) error {
+	return s.track(t, func() bool {
+		if _, ok := s.tabs[t]; ok {
+			return false
+		}
+		s.tabs[t] = bm
+		return true
+	}, func() { t.Close(bm) })
+}
+
+// TrackBrowser 追蹤自行建立的瀏覽器管理器
+func (s *Supervisor) TrackBrowser(bm *browser.BrowserManager) error {
+	return s.track(bm, func() bool {
+		if _, ok := s.browsers[bm]; ok {
+			return false
+		}
+		s.browsers[bm] = struct{}{}
+		return true
+	}, bm.Shutdown)
+}
+
+// TrackClient 追蹤其他需要在最後關閉的資源，例如結果輸出、frontier、HTTP 伺服器；name 用於日誌與錯誤訊息
+func (s *Supervisor) TrackClient(name string, c io.Closer) error {
+	return s.track(c, func() bool {
+		if _, ok := s.clients[c]; ok {
+			return false
+		}
+		s.clients[c] = name
+		return true
+	}, func() { c.Close() })
+}
+
+// Untrack 停止追蹤已自行關閉的資源，v 為 *crawler.Crawler、*tab.Tab、*browser.BrowserManager 或 TrackClient 傳入的值
+func (s *Supervisor) Untrack(v interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch x := v.(type) {
+	case *crawler.Crawler:
+		delete(s.crawlers, x)
+	case *tab.Tab:
+		delete(s.tabs, x)
+	case *browser.BrowserManager:
+		delete(s.browsers, x)
+	case io.Closer:
+		delete(s.clients, x)
+	}
+	for i, o := range s.order {
+		if o == v {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// Shutdown 依序關閉所有追蹤的資源：爬蟲（Drain，ctx 結束時中斷進行中的頁面）→ 分頁 → 瀏覽器 → 用戶端，
+// 同一層依建立的反序關閉。回傳各資源的錯誤；重複或同時呼叫只執行一次，其餘呼叫等待完成
+func (s *Supervisor) Shutdown(ctx context.Context) error {
+	s.once.Do(func() {
+		defer close(s.done)
+		// 取走所有資源，關閉期間的 Untrack 不影響關閉流程
+		s.mu.Lock()
+		s.started = true
+		order := s.order
+		crawlers, tabs, browsers, clients := s.crawlers, s.tabs, s.browsers, s.clients
+		s.order = nil
+		s.crawlers = make(map[*crawler.Crawler]struct{})
+		s.tabs = make(map[*tab.Tab]*browser.BrowserManager)
+		s.browsers = make(map[*browser.BrowserManager]struct{})
+		s.clients = make(map[io.Closer]string)
+		s.mu.Unlock()
+
+		var errs []error
+		reverse := func(fn func(v interface{})) {
+			for i := len(order) - 1; i >= 0; i-- {
+				fn(order[i])
+			}
+		}
+
+		// 爬蟲可同時 Drain，共用同一個期限
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		reverse(func(v interface{}) {
+			c, ok := v.(*crawler.Crawler)
+			if _, tracked := crawlers[c]; !ok || !tracked {
+				return
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := c.Drain(ctx); err != nil {
+					mu.Lock()
+					errs = append(errs, fmt.Errorf("爬蟲: %w", err))
+					mu.Unlock()
+				}
+			}()
+		})
+		wg.Wait()
+
+		reverse(func(v interface{}) {
+			if t, ok := v.(*tab.Tab); ok {
+				if bm, tracked := tabs[t]; tracked {
+					t.Close(bm)
+				}
+			}
+		})
+		reverse(func(v interface{}) {
+			if bm, ok := v.(*browser.BrowserManager); ok {
+				if _, tracked := browsers[bm]; tracked {
+					bm.Shutdown()
+				}
+			}
+		})
+		reverse(func(v interface{}) {
+			c, ok := v.(io.Closer)
+			if !ok {
+				return
+			}
+			name, tracked := clients[c]
+			if !tracked {
+				return
+			}
+			if err := c.Close(); err != nil {
+				log.Printf("[cdpkit] 關閉 %s 失敗: %v", name, err)
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			}
+		})
+		log.Printf("[cdpkit] 已關閉 %d 個爬蟲、%d 個分頁、%d 個瀏覽器、%d 個用戶端",
+			len(crawlers), len(tabs), len(browsers), len(clients))
+		s.err = errors.Join(errs...)
+	})
+	<-s.done
+	return s.err
+}
+
+// NewBrowser 以 Default 建立並追蹤瀏覽器管理器
+func NewBrowser(cfg config.Config) (*browser.BrowserManager, error) {
+	return Default.NewBrowser(cfg)
+}
+
+// NewCrawler 以 Default 建立並追蹤爬蟲
+func NewCrawler(opts crawler.Options) (*crawler.Crawler, error) {
+	return Default.NewCrawler(opts)
+}
+
+// NewTab 以 Default 開啟並追蹤分頁
+func NewTab(bm *browser.BrowserManager, cfg config.Config) (*tab.Tab, error) {
+	return Default.NewTab(bm, cfg)
+}
+
+// TrackClient 以 Default 追蹤其他資源
+func TrackClient(name string, c io.Closer) error {
+	return Default.TrackClient(name, c)
+}
+
+// Shutdown 關閉 Default 追蹤的所有資源
+func Shutdown(ctx context.Context) error {
+	return Default.Shutdown(ctx)
+}
+
+// ----------------- 內部實作 -----------------
+
+// closed 是否已開始關閉
+func (s *Supervisor) closed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.started
+}
+
+// track 在持有 s.mu 時以 add 登記資源；已開始關閉時立即以 closeNow 關閉並回傳 ErrShutdown
+func (s *Supervisor) track(v interface{}, add func() bool, closeNow func()) error {
+	s.mu.Lock()
+	if s.started {
+		s.mu.Unlock()
+		closeNow()
+		return ErrShutdown
+	}
+	if add() {
+		s.order = append(s.order, v)
+	}
+	s.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,89 @@
+package tab
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/chromedp/cdproto/animation"
+	"github.com/chromedp/chromedp"
+)
+
+// StopAnimations 讓頁面畫面靜止，供視覺比對的截圖與 DOM 快照使用：有限的 CSS 動畫與 Web Animations 直接跳到結束，
+// 無限循環的取消回到初始狀態，停用 CSS transition 與文字游標，暫停 video、audio 並回到開頭；
+// 之後新建立的動畫也以 Animation 網域停在起點。以 JS 計時器移動的輪播不受影響。
+// 效果持續到導航或呼叫 ResumeAnimations
+func (t *Tab) StopAnimations() error {
+	var remaining int
+	err := t.run(chromedp.ActionFunc(func(ctx context.Context) error {
+		if err := animation.Enable().Do(ctx); err != nil {
+			return err
+		}
+		if err := animation.SetPlaybackRate(0).Do(ctx); err != nil {
+			return err
+		}
+		return chromedp.Evaluate(stopAnimationsJS, &remaining).Do(ctx)
+	}))
+	if err != nil {
+		return fmt.Errorf("停止動畫失敗: %w", err)
+	}
+	if remaining > 0 {
+		log.Printf("[cdpkit] 仍有 %d 個動畫無法停止，已暫停播放", remaining)
+	}
+	return nil
+}
+
+// ResumeAnimations 恢復 StopAnimations 停止的動畫速率並移除注入的樣式；已結束或取消的動畫與暫停的影片不會恢復
+func (t *Tab) ResumeAnimations() error {
+	err := t.run(chromedp.ActionFunc(func(ctx context.Context) error {
+		if err := animation.SetPlaybackRate(1).Do(ctx); err != nil {
+			return err
+		}
+		if err := animation.Disable().Do(ctx); err != nil {
+			return err
+		}
+		return chromedp.Evaluate(`document.getElementById('__cdpkit_stop_animations')?.remove()`, nil).Do(ctx)
+	}))
+	if err != nil {
+		return fmt.Errorf("恢復動畫失敗: %w", err)
+	}
+	return nil
+}
+
+// ----------------- 內部實作 -----------------
+
+// stopAnimationsJS 注入停用 transition 的樣式、結束或取消現有動畫並暫停媒體，回傳仍在執行的動畫數
+const stopAnimationsJS = `(() => {
+	if (!document.getElementById('__cdpkit_stop_animations')) {
+		const style = document.createElement('style');
+		style.id = '__cdpkit_stop_animations';
+		style.textContent = '*, *::before, *::after { transition: none !important; caret-color: transparent !important; scroll-behavior: auto !important; }';
+		(document.head || document.documentElement).appendChild(style);
+	}
+	for (const a of document.getAnimations()) {
+		try {
+			const timing = a.effect && a.effect.getComputedTiming();
+			if (timing && timing.endTime !== Infinity) a.finish();
+			else a.cancel();
+		} catch (e) {
+			a.pause();
+		}
+	}
+	for (const m of document.querySelectorAll('video, audio')) {
+		try {
+			m.pause();
+			m.currentTime = 0;
+		} catch (e) {}
+	}
+	return document.getAnimations().filter(a => a.playState === 'running').length;
+})()`
+
+// stopAnimationsBefore 擷取前依需要停止動畫；失敗只記錄警告，仍照常擷取
+func (t *Tab) stopAnimationsBefore(stop bool) {
+	if !stop {
+		return
+	}
+	if err := t.StopAnimations(); err != nil {
+		log.Printf("[cdpkit] 警告: %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+package tab
+
+import (
+	"context"
+	"log"
+	"net/url"
+
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/chromedp"
+	"github.com/firehourse/cdpkit/config"
+)
+
+// SetCredentials 設定目標網站要求 HTTP 驗證（Basic、Digest、NTLM、Negotiate）時回應的帳密，key 為來源，
+// 例如 https://intranet.example.com；取代先前的設定，傳入空的 map 則停止接手驗證。
+// 帳密錯誤時不會重送，分頁照常收到 401 回應；未設定的來源交由 Chrome 預設處理
+func (t *Tab) SetCredentials(creds map[string]config.Credential) error {
+	normalized := make(map[string]config.Credential, len(creds))
+	for origin, c := range creds {
+		o, err := normalizeOrigin(origin)
+		if err != nil {
+			return err
+		}
+		normalized[o] = c
+	}
+	t.mu.Lock()
+	t.credentials = normalized
+	enabled := t.fetchListening
+	t.mu.Unlock()
+
+	// 未使用 Fetch 網域且不需要驗證時不必啟用
+	if len(normalized) == 0 && !enabled {
+		return nil
+	}
+	return t.enableFetch()
+}
+
+// ----------------- 內部實作 -----------------
+
+// handleAuth 以符合來源的帳密回應驗證要求；同一請求第二次要求驗證時取消，避免以錯誤的帳密反覆重試
+func (t *Tab) handleAuth(ctx context.Context, ev *fetch.EventAuthRequired) {
+	resp := &fetch.AuthChallengeResponse{Response: fetch.AuthChallengeResponseResponseDefault}
+	origin := ""
+	if ev.AuthChallenge != nil {
+		if u, err := url.Parse(ev.AuthChallenge.Origin); err == nil {
+			origin = originOf(u)
+		}
+	}
+
+	t.mu.Lock()
+	cred, ok := t.credentials[origin]
+	if ok {
+		if t.authTried == nil {
+			t.authTried = make(map[fetch.RequestID]bool)
+		}
+		if t.authTried[ev.RequestID] {
+			resp.Response = fetch.AuthChallengeResponseResponseCancelAuth
+		} else {
+			t.authTried[ev.RequestID] = true
+			resp = &fetch.AuthChallengeResponse{
+				Response: fetch.AuthChallengeResponseResponseProvideCredentials,
+				Username: cred.Username,
+				Password: cred.Password,
+			}
+		}
+	}
+	t.mu.Unlock()
+	if resp.Response == fetch.AuthChallengeResponseResponseCancelAuth {
+		log.Printf("[cdpkit] %s 的 HTTP 驗證失敗，請檢查帳密", origin)
+	}
+
+	if err := chromedp.Run(ctx, fetch.ContinueWithAuth(ev.RequestID, resp)); err != nil && ctx.Err() == nil {
+		log.Printf("[cdpkit] 回應 HTTP 驗證失敗: %v", err)
+	}
+}
+
+// applyCredentials 套用設定中的 HTTP 驗證帳密；NoAutomationTweaks 時同樣套用
+func (t *Tab) applyCredentials(cfg config.Config) {
+	if len(cfg.Credentials) == 0 {
+		return
+	}
+	if err := t.SetCredentials(cfg.Credentials); err != nil {
+		log.Printf("[cdpkit] 警告：設定 HTTP 驗證失敗: %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package tab
+
+import (
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// MeterBytes 以 meter 計量分頁之後從網路收到的位元組（含標頭，以傳輸時壓縮後的大小計算）。
+// meter 回傳 false 表示超過預算：分頁隨即停止載入，之後的請求以 BlockedByClient 失敗，直到再次呼叫 MeterBytes。
+// 每個請求開始前會以 0 呼叫 meter 查詢預算，其他分頁用盡共用的預算時也不再送出請求。傳入 nil 停止計量
+func (t *Tab) MeterBytes(meter func(n int64) bool) error {
+	t.mu.Lock()
+	first := !t.meterListening && meter != nil
+	if first {
+		t.meterListening = true
+	}
+	t.meter = meter
+	t.metered = nil
+	t.overBudget = false
+	t.mu.Unlock()
+
+	if !first {
+		return nil
+	}
+	chromedp.ListenTarget(t.Ctx, func(ev interface{}) {
+		switch e := ev.(type) {
+		case *network.EventDataReceived:
+			t.chargeBytes(e.RequestID, int64(e.EncodedDataLength), false)
+		case *network.EventLoadingFinished:
+			t.chargeBytes(e.RequestID, int64(e.EncodedDataLength), true)
+		case *network.EventLoadingFailed:
+			t.mu.Lock()
+			delete(t.metered, e.RequestID)
+			t.mu.Unlock()
+		}
+	})
+	return t.AddInterceptor(func(r *PausedRequest) {
+		t.mu.Lock()
+		meter, over := t.meter, t.overBudget
+		t.mu.Unlock()
+		if meter == nil {
+			return
+		}
+		if over || !meter(0) {
+			t.exceedBudget()
+			r.Block(network.ErrorReasonBlockedByClient)
+		}
+	})
+}
+
+// BytesExceeded 回傳 MeterBytes 的預算是否已用盡
+func (t *Tab) BytesExceeded() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.overBudget
+}
+
+// ----------------- 內部實作 -----------------
+
+// chargeBytes 將請求收到的位元組交給 meter；loadingFinished 的大小為請求的總量，只計入尚未計量的部分
+func (t *Tab) chargeBytes(id network.RequestID, n int64, final bool) {
+	t.mu.Lock()
+	meter := t.meter
+	if meter == nil {
+		t.mu.Unlock()
+		return
+	}
+	if final {
+		n -= t.metered[id]
+		delete(t.metered, id)
+	} else {
+		if t.metered == nil {
+			t.metered = map[network.RequestID]int64{}
+		}
+		t.metered[id] += n
+	}
+	t.mu.Unlock()
+
+	if n > 0 && !meter(n) {
+		t.exceedBudget()
+	}
+}
+
+// exceedBudget 標記預算用盡，第一次時停止頁面載入中斷進行中的請求
+func (t *Tab) exceedBudget() {
+	t.mu.Lock()
+	first := !t.overBudget
+	t.overBudget = true
+	t.mu.Unlock()
+	if first {
+		// 監聽器中不能阻塞，另開 goroutine 停止載入；分頁可能已關閉，錯誤無需處理
+		go chromedp.Run(t.Ctx, page.StopLoading())
+	}
+}
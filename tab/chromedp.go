@@ -0,0 +1,24 @@
+package tab
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/chromedp/chromedp"
+)
+
+// ChromedpContext 回傳分頁的 chromedp context，可直接傳給 chromedp.Run、chromedp.ListenTarget 等，
+// 與 Tab 的方法操作同一個頁面。此 context 不含逾時，也不受看門狗監看；分頁關閉後為 nil
+func (t *Tab) ChromedpContext() context.Context {
+	return t.Ctx
+}
+
+// Run 在此分頁依序執行任意 chromedp 動作（例如 chromedp.SetValue、chromedp.Nodes 或 cdproto 的命令），
+// 補足 Tab 未提供的操作，可與 Navigate、Click 等方法交錯使用。
+// 整體以 DefaultTimeout 為逾時，同其他方法受看門狗監看，瀏覽器重置時回傳 TabInvalidatedError
+func (t *Tab) Run(actions ...chromedp.Action) error {
+	if t.Ctx == nil {
+		return fmt.Errorf("分頁已關閉")
+	}
+	return t.run(chromedp.Tasks(actions))
+}
@@ -0,0 +1,126 @@
+package tab
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	cdplog "github.com/chromedp/cdproto/log"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+)
+
+// maxConsoleMessages 每個分頁保留的 console 訊息上限，超過時捨棄最舊的
+const maxConsoleMessages = 500
+
+// ConsoleMessage 頁面輸出的 console 訊息、未捕捉的例外，或瀏覽器記錄的問題（例如資源載入失敗、混合內容警告）
+type ConsoleMessage struct {
+	// Source "console"、"exception"，或瀏覽器記錄的來源，例如 "network"、"security"、"javascript"
+	Source string `json:"source"`
+	// Level "error"、"warning"、"info" 等；例外一律為 "error"
+	Level string `json:"level"`
+	Text  string `json:"text"`
+	URL   string `json:"url,omitempty"`
+	// Line 訊息所在的行號（從 1 起算），不明時為 0
+	Line int64     `json:"line,omitempty"`
+	Time time.Time `json:"time"`
+}
+
+// CaptureConsole 清除已記錄的訊息並開始記錄 console 訊息、未捕捉的例外與瀏覽器記錄的問題，
+// 應於導航前呼叫；以 ConsoleMessages 取得
+func (t *Tab) CaptureConsole() {
+	t.mu.Lock()
+	first := !t.consoleListening
+	t.consoleListening = true
+	t.console = nil
+	t.mu.Unlock()
+	if !first || t.Ctx == nil {
+		return
+	}
+	chromedp.ListenTarget(t.Ctx, func(ev interface{}) {
+		switch e := ev.(type) {
+		case *runtime.EventConsoleAPICalled:
+			m := ConsoleMessage{Source: "console", Level: consoleLevel(e.Type), Text: consoleText(e.Args), Time: time.Now()}
+			if e.StackTrace != nil && len(e.StackTrace.CallFrames) > 0 {
+				f := e.StackTrace.CallFrames[0]
+				m.URL, m.Line = f.URL, f.LineNumber+1
+			}
+			t.addConsole(m)
+		case *runtime.EventExceptionThrown:
+			d := e.ExceptionDetails
+			if d == nil {
+				return
+			}
+			text := d.Text
+			if d.Exception != nil && d.Exception.Description != "" {
+				text = d.Exception.Description
+			}
+			t.addConsole(ConsoleMessage{Source: "exception", Level: "error", Text: text, URL: d.URL, Line: d.LineNumber + 1, Time: time.Now()})
+		case *cdplog.EventEntryAdded:
+			if e.Entry == nil {
+				return
+			}
+			m := ConsoleMessage{Source: string(e.Entry.Source), Level: string(e.Entry.Level), Text: e.Entry.Text, URL: e.Entry.URL, Time: time.Now()}
+			if e.Entry.LineNumber > 0 {
+				m.Line = e.Entry.LineNumber + 1
+			}
+			t.addConsole(m)
+		}
+	})
+}
+
+// ConsoleMessages 回傳 CaptureConsole 之後記錄的訊息
+func (t *Tab) ConsoleMessages() []ConsoleMessage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]ConsoleMessage(nil), t.console...)
+}
+
+// ----------------- 內部實作 -----------------
+
+func (t *Tab) addConsole(m ConsoleMessage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.console = append(t.console, m)
+	if n := len(t.console); n > maxConsoleMessages {
+		t.console = t.console[n-maxConsoleMessages:]
+	}
+}
+
+// consoleLevel 將 console API 的呼叫種類對應到記錄層級
+func consoleLevel(typ runtime.APIType) string {
+	switch typ {
+	case runtime.APITypeError, runtime.APITypeAssert:
+		return "error"
+	case runtime.APITypeWarning:
+		return "warning"
+	case runtime.APITypeDebug:
+		return "verbose"
+	default:
+		return "info"
+	}
+}
+
+// consoleText 以空白串接 console 呼叫的參數；字串取原值，其他物件取瀏覽器的描述
+func consoleText(args []*runtime.RemoteObject) string {
+	parts := make([]string, 0, len(args))
+	for _, a := range args {
+		if a == nil {
+			continue
+		}
+		var str string
+		switch {
+		case a.Type == runtime.TypeString && json.Unmarshal(a.Value, &str) == nil:
+			parts = append(parts, str)
+		case a.Description != "":
+			parts = append(parts, a.Description)
+		case len(a.Value) > 0:
+			parts = append(parts, string(a.Value))
+		case a.UnserializableValue != "":
+			parts = append(parts, string(a.UnserializableValue))
+		default:
+			parts = append(parts, string(a.Type))
+		}
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,167 @@
+package tab
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/chromedp/cdproto/css"
+	"github.com/chromedp/cdproto/profiler"
+	"github.com/chromedp/chromedp"
+)
+
+// CoverageEntry 一個腳本或樣式表實際執行、套用的位元組；同一網址的多個腳本或 inline 區塊合併計算
+type CoverageEntry struct {
+	URL string `json:"url"`
+	// Type "script" 或 "stylesheet"
+	Type       string `json:"type"`
+	TotalBytes int64  `json:"total_bytes"`
+	UsedBytes  int64  `json:"used_bytes"`
+}
+
+// UnusedBytes 未執行或未套用的位元組
+func (e CoverageEntry) UnusedBytes() int64 {
+	return e.TotalBytes - e.UsedBytes
+}
+
+// StartCoverage 開始記錄 JS 的區塊覆蓋率與 CSS 規則的使用情形，應於導航前呼叫；以 TakeCoverage 取得結果並停止記錄
+func (t *Tab) StartCoverage() error {
+	t.mu.Lock()
+	first := !t.coverageListening
+	t.coverageListening = true
+	t.styleSheets = map[css.StyleSheetID]*css.StyleSheetHeader{}
+	t.mu.Unlock()
+	if first {
+		chromedp.ListenTarget(t.Ctx, func(ev interface{}) {
+			if e, ok := ev.(*css.EventStyleSheetAdded); ok && e.Header != nil {
+				t.mu.Lock()
+				if t.styleSheets != nil {
+					t.styleSheets[e.Header.StyleSheetID] = e.Header
+				}
+				t.mu.Unlock()
+			}
+		})
+	}
+
+	err := t.run(chromedp.ActionFunc(func(ctx context.Context) error {
+		if err := profiler.Enable().Do(ctx); err != nil {
+			return err
+		}
+		if _, err := profiler.StartPreciseCoverage().WithCallCount(false).WithDetailed(true).Do(ctx); err != nil {
+			return err
+		}
+		return css.StartRuleUsageTracking().Do(ctx)
+	}))
+	if err != nil {
+		return fmt.Errorf("開始記錄覆蓋率失敗: %w", t.wrapErr(err))
+	}
+	return nil
+}
+
+// TakeCoverage 停止記錄並回傳 StartCoverage 之後載入的腳本與樣式表的覆蓋率，依未使用的位元組由多到少排列；
+// 沒有網址的腳本（eval、擴充功能注入）不列出
+func (t *Tab) TakeCoverage() ([]CoverageEntry, error) {
+	var (
+		scripts []*profiler.ScriptCoverage
+		rules   []*css.RuleUsage
+	)
+	err := t.run(chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		if scripts, _, err = profiler.TakePreciseCoverage().Do(ctx); err != nil {
+			return err
+		}
+		if err := profiler.StopPreciseCoverage().Do(ctx); err != nil {
+			return err
+		}
+		rules, err = css.StopRuleUsageTracking().Do(ctx)
+		return err
+	}))
+	t.mu.Lock()
+	sheets := t.styleSheets
+	t.styleSheets = nil
+	t.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("取得覆蓋率失敗: %w", t.wrapErr(err))
+	}
+
+	byKey := map[string]*CoverageEntry{}
+	add := func(typ, url string, total, used int64) {
+		key := typ + " " + url
+		e := byKey[key]
+		if e == nil {
+			e = &CoverageEntry{URL: url, Type: typ}
+			byKey[key] = e
+		}
+		e.TotalBytes += total
+		e.UsedBytes += used
+	}
+
+	for _, s := range scripts {
+		if s.URL == "" {
+			continue
+		}
+		total, used := scriptCoverage(s)
+		add("script", s.URL, total, used)
+	}
+
+	usedCSS := map[css.StyleSheetID]int64{}
+	for _, r := range rules {
+		if r.Used {
+			usedCSS[r.StyleSheetID] += int64(r.EndOffset - r.StartOffset)
+		}
+	}
+	for id, h := range sheets {
+		if h.SourceURL == "" || h.Origin != css.StyleSheetOriginRegular {
+			continue
+		}
+		total := int64(h.Length)
+		add("stylesheet", h.SourceURL, total, min(usedCSS[id], total))
+	}
+
+	out := make([]CoverageEntry, 0, len(byKey))
+	for _, e := range byKey {
+		out = append(out, *e)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if a, b := out[i].UnusedBytes(), out[j].UnusedBytes(); a != b {
+			return a > b
+		}
+		return out[i].URL < out[j].URL
+	})
+	return out, nil
+}
+
+// ----------------- 內部實作 -----------------
+
+// scriptCoverage 計算腳本的總長度與執行過的位元組。V8 的區段為巢狀結構，內層的次數覆寫外層，
+// 依起點遞增、終點遞減排序後依序套用即可；總長度取最外層（腳本本身）的終點
+func scriptCoverage(s *profiler.ScriptCoverage) (total, used int64) {
+	var ranges []*profiler.CoverageRange
+	for _, f := range s.Functions {
+		ranges = append(ranges, f.Ranges...)
+	}
+	if len(ranges) == 0 {
+		return 0, 0
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		if ranges[i].StartOffset != ranges[j].StartOffset {
+			return ranges[i].StartOffset < ranges[j].StartOffset
+		}
+		return ranges[i].EndOffset > ranges[j].EndOffset
+	})
+	for _, r := range ranges {
+		total = max(total, r.EndOffset)
+	}
+	executed := make([]bool, total)
+	for _, r := range ranges {
+		for i := max(r.StartOffset, 0); i < r.EndOffset && i < total; i++ {
+			executed[i] = r.Count > 0
+		}
+	}
+	for _, ok := range executed {
+		if ok {
+			used++
+		}
+	}
+	return total, used
+}
@@ -0,0 +1,234 @@
+package tab
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// ShadowPierce 選擇器中的 shadow root 穿透組合子：
+// "my-app >>> .price" 先找到 my-app，再於其 shadow root 內尋找 .price
+const ShadowPierce = ">>>"
+
+// XPathPrefix 明確指定 XPath 的前綴，例如 "xpath=//h1"；以 "/"、"./" 或 "(" 開頭的選擇器也會視為 XPath
+const XPathPrefix = "xpath="
+
+// 等待元素時預設的輪詢間隔，見 WaitOptions.PollInterval
+const queryPollInterval = 100 * time.Millisecond
+
+// deepQueryJS 依 ">>>" 逐段查詢，每段在前一段第一個元素的 shadow root 內尋找；
+// deep 為 true 時每段也會遞迴搜尋所有開放的 shadow root。many 為 true 時回傳最後一段的
+// 所有元素（陣列），否則回傳第一個元素或 null。closed shadow root 無法穿透
+const deepQueryJS = `((sel, deep, many) => {
+	const find = (root, s, many, out) => {
+		for (const el of root.querySelectorAll(s)) {
+			out.push(el);
+			if (!many) return out;
+		}
+		if (deep) {
+			for (const host of root.querySelectorAll('*')) {
+				if (host.shadowRoot) find(host.shadowRoot, s, many, out);
+				if (!many && out.length) return out;
+			}
+		}
+		return out;
+	};
+	const parts = sel.split('>>>').map(s => s.trim());
+	let root = document;
+	for (const part of parts.slice(0, -1)) {
+		root = root && (find(root, part, false, [])[0] || {}).shadowRoot;
+	}
+	const found = root ? find(root, parts[parts.length - 1], many, []) : [];
+	return many ? found : found[0] || null;
+})(%s, %t, %t)`
+
+// xpathQueryJS 以 XPath 查詢，many 為 true 時回傳陣列
+const xpathQueryJS = `((xp, many) => {
+	if (!many) return document.evaluate(xp, document, null, XPathResult.FIRST_ORDERED_NODE_TYPE, null).singleNodeValue;
+	const r = document.evaluate(xp, document, null, XPathResult.ORDERED_NODE_SNAPSHOT_TYPE, null);
+	return Array.from({length: r.snapshotLength}, (_, i) => r.snapshotItem(i));
+})(%s, %t)`
+
+// IsXPath 回傳選擇器是否為 XPath：以 XPathPrefix、"/"、"./" 或 "(" 開頭（CSS 選擇器不會以這些字元開頭）
+func IsXPath(selector string) bool {
+	s := strings.TrimSpace(selector)
+	for _, p := range []string{XPathPrefix, "/", "./", "("} {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Text 回傳第一個符合元素的 innerText；選擇器支援 XPath 與 ">>>" 穿透 shadow root
+func (t *Tab) Text(selector string) (string, error) {
+	var text *string
+	err := t.run(chromedp.Evaluate(fmt.Sprintf(
+		`(el => el && (el.innerText ?? el.textContent))(%s)`, t.queryJS(selector)), &text))
+	if err != nil {
+		return "", fmt.Errorf("取得 %s 文字失敗: %w", selector, err)
+	}
+	if text == nil {
+		return "", fmt.Errorf("找不到元素 %s", selector)
+	}
+	return *text, nil
+}
+
+// InnerHTML 回傳第一個符合元素的 innerHTML
+func (t *Tab) InnerHTML(selector string) (string, error) {
+	var html *string
+	err := t.run(chromedp.Evaluate(fmt.Sprintf(`(el => el && el.innerHTML)(%s)`, t.queryJS(selector)), &html))
+	if err != nil {
+		return "", fmt.Errorf("取得 %s HTML 失敗: %w", selector, err)
+	}
+	if html == nil {
+		return "", fmt.Errorf("找不到元素 %s", selector)
+	}
+	return *html, nil
+}
+
+// Attribute 回傳第一個符合元素的屬性值；ok 為 false 表示元素沒有該屬性
+func (t *Tab) Attribute(selector, name string) (value string, ok bool, err error) {
+	var res *struct {
+		Value *string `json:"value"`
+	}
+	err = t.run(chromedp.Evaluate(fmt.Sprintf(
+		`(el => el && {value: el.getAttribute(%s)})(%s)`, jsString(name), t.queryJS(selector)), &res))
+	if err != nil {
+		return "", false, fmt.Errorf("取得 %s 的 %s 屬性失敗: %w", selector, name, err)
+	}
+	if res == nil {
+		return "", false, fmt.Errorf("找不到元素 %s", selector)
+	}
+	if res.Value == nil {
+		return "", false, nil
+	}
+	return *res.Value, true, nil
+}
+
+// Exists 回傳頁面上是否有符合的元素，不等待元素出現
+func (t *Tab) Exists(selector string) (bool, error) {
+	var exists bool
+	err := t.run(chromedp.Evaluate(fmt.Sprintf(`!!%s`, t.queryJS(selector)), &exists))
+	if err != nil {
+		return false, fmt.Errorf("查詢 %s 失敗: %w", selector, err)
+	}
+	return exists, nil
+}
+
+// Clickable 回傳元素是否存在、可見且未停用（disabled 或 aria-disabled="true"），不等待元素出現；
+// 用於判斷「下一頁」等按鈕是否還能點擊
+func (t *Tab) Clickable(selector string) (bool, error) {
+	var ok bool
+	err := t.run(chromedp.Evaluate(fmt.Sprintf(`(el => !!el && el.isConnected &&
+		el.getClientRects().length > 0 &&
+		getComputedStyle(el).visibility !== 'hidden' &&
+		!el.closest('[disabled], [aria-disabled="true"]'))(%s)`, t.elementJS(selector)), &ok))
+	if err != nil {
+		return false, fmt.Errorf("查詢 %s 失敗: %w", selector, err)
+	}
+	return ok, nil
+}
+
+// SetContent 以 html 取代目前頁面的文件內容，不經過導航；相對網址以目前頁面的網址解析，
+// 可在 html 中加上 <base href> 指定
+func (t *Tab) SetContent(html string) error {
+	err := t.run(chromedp.ActionFunc(func(ctx context.Context) error {
+		tree, err := page.GetFrameTree().Do(ctx)
+		if err != nil {
+			return err
+		}
+		return page.SetDocumentContent(tree.Frame.ID, html).Do(ctx)
+	}))
+	if err != nil {
+		return fmt.Errorf("設定頁面內容失敗: %w", err)
+	}
+	return nil
+}
+
+// Links 回傳符合元素的連結絕對網址（依出現順序，已去除重複與非 http(s) 連結）；
+// 元素本身不是連結時取其內所有的 a[href]，例如 Links("nav") 回傳導覽列中的連結
+func (t *Tab) Links(selector string) ([]string, error) {
+	var links []string
+	err := t.run(chromedp.Evaluate(fmt.Sprintf(`(els => {
+		const out = new Set();
+		for (const el of els) {
+			const anchors = el.nodeType === Node.ELEMENT_NODE && el.matches('a[href], area[href]') ? [el]
+				: el.querySelectorAll ? el.querySelectorAll('a[href], area[href]') : [];
+			for (const a of anchors) {
+				// SVG 的 a.href 不是字串
+				if (typeof a.href === 'string' && /^https?:/i.test(a.href)) out.add(a.href);
+			}
+		}
+		return [...out];
+	})(%s)`, t.queryAllJS(selector)), &links))
+	if err != nil {
+		return nil, fmt.Errorf("取得 %s 的連結失敗: %w", selector, err)
+	}
+	return links, nil
+}
+
+// Count 回傳符合的元素數量
+func (t *Tab) Count(selector string) (int, error) {
+	var n int
+	err := t.run(chromedp.Evaluate(fmt.Sprintf(`%s.length`, t.queryAllJS(selector)), &n))
+	if err != nil {
+		return 0, fmt.Errorf("查詢 %s 失敗: %w", selector, err)
+	}
+	return n, nil
+}
+
+// ----------------- 內部實作 -----------------
+
+// deep 選擇器是否需要穿透 shadow root
+func (t *Tab) deep(selector string) bool {
+	return !IsXPath(selector) && (t.DeepQuery || strings.Contains(selector, ShadowPierce))
+}
+
+// byJS 選擇器是否需以 JS 查詢；chromedp 的 ByQuery 無法處理 XPath 與 shadow root
+func (t *Tab) byJS(selector string) bool {
+	return IsXPath(selector) || t.deep(selector)
+}
+
+// queryJS 回傳取得第一個符合元素的 JS 運算式，找不到時為 null
+func (t *Tab) queryJS(selector string) string {
+	if IsXPath(selector) {
+		return fmt.Sprintf(xpathQueryJS, jsString(xpathExpr(selector)), false)
+	}
+	if !t.deep(selector) {
+		return fmt.Sprintf("document.querySelector(%s)", jsString(selector))
+	}
+	return fmt.Sprintf(deepQueryJS, jsString(selector), t.DeepQuery, false)
+}
+
+// queryAllJS 回傳取得所有符合元素（陣列）的 JS 運算式
+func (t *Tab) queryAllJS(selector string) string {
+	if IsXPath(selector) {
+		return fmt.Sprintf(xpathQueryJS, jsString(xpathExpr(selector)), true)
+	}
+	if !t.deep(selector) {
+		return fmt.Sprintf("Array.from(document.querySelectorAll(%s))", jsString(selector))
+	}
+	return fmt.Sprintf(deepQueryJS, jsString(selector), t.DeepQuery, true)
+}
+
+// elementJS 同 queryJS，但 XPath 選到文字或屬性節點時改為所屬的元素，供需要版面資訊的操作使用
+func (t *Tab) elementJS(selector string) string {
+	return fmt.Sprintf(`(n => n && n.nodeType !== Node.ELEMENT_NODE ? (n.parentElement || n.ownerElement) : n)(%s)`,
+		t.queryJS(selector))
+}
+
+// waitQuery 等待直到以 JS 查詢的元素出現且可見
+func (t *Tab) waitQuery(ctx context.Context, selector string) error {
+	_, err := t.waitFor(ctx, []Condition{Visible(selector)}, true)
+	return err
+}
+
+// xpathExpr 去除 XPathPrefix
+func xpathExpr(selector string) string {
+	return strings.TrimPrefix(strings.TrimSpace(selector), XPathPrefix)
+}
@@ -0,0 +1,61 @@
+package tab
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/chromedp"
+	"github.com/firehourse/cdpkit/audit"
+)
+
+// download 進行中的下載，完成或取消時寫入稽核紀錄
+type download struct {
+	url      string
+	filename string
+}
+
+// auditDownloads 開啟 Browser 網域的下載事件，將此分頁開始、完成與取消的下載寫入稽核紀錄；
+// 下載行為維持瀏覽器預設。設定 Audit 後於第一次 Navigate 時啟用，每個分頁只註冊一次
+func (t *Tab) auditDownloads() {
+	t.mu.Lock()
+	if t.downloadListening {
+		t.mu.Unlock()
+		return
+	}
+	t.downloadListening = true
+	t.mu.Unlock()
+
+	chromedp.ListenTarget(t.Ctx, func(ev interface{}) {
+		switch e := ev.(type) {
+		case *browser.EventDownloadWillBegin:
+			t.mu.Lock()
+			if t.downloads == nil {
+				t.downloads = map[string]download{}
+			}
+			t.downloads[e.GUID] = download{url: e.URL, filename: e.SuggestedFilename}
+			t.mu.Unlock()
+			t.audit(audit.ActionDownload, e.URL, "start: "+e.SuggestedFilename)
+		case *browser.EventDownloadProgress:
+			if e.State == browser.DownloadProgressStateInProgress {
+				return
+			}
+			t.mu.Lock()
+			d, ok := t.downloads[e.GUID]
+			delete(t.downloads, e.GUID)
+			t.mu.Unlock()
+			if !ok {
+				return
+			}
+			detail := fmt.Sprintf("%s: %s", e.State, d.filename)
+			if e.State == browser.DownloadProgressStateCompleted {
+				detail += fmt.Sprintf(" (%d bytes)", int64(e.ReceivedBytes))
+			}
+			t.audit(audit.ActionDownload, d.url, detail)
+		}
+	})
+	err := chromedp.Run(t.Ctx, browser.SetDownloadBehavior(browser.SetDownloadBehaviorBehaviorDefault).WithEventsEnabled(true))
+	if err != nil {
+		log.Printf("[cdpkit] 警告：無法啟用下載事件，下載不會寫入稽核紀錄：%v", err)
+	}
+}
@@ -0,0 +1,242 @@
+package tab
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	cdpbrowser "github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+	"github.com/firehourse/cdpkit/config"
+	"github.com/firehourse/cdpkit/devices"
+	"github.com/firehourse/cdpkit/stealth"
+)
+
+// EmulateTimezone 覆寫頁面時區（IANA 名稱，例如 "Europe/Berlin"），影響 Date 與 Intl
+func (t *Tab) EmulateTimezone(tz string) error {
+	err := chromedp.Run(t.Ctx, emulation.SetTimezoneOverride(tz))
+	if err != nil {
+		return fmt.Errorf("設定時區 %s 失敗: %w", tz, t.wrapErr(err))
+	}
+	return nil
+}
+
+// EmulateLocale 覆寫語系（例如 "de-DE"），並同步 Accept-Language 標頭
+func (t *Tab) EmulateLocale(locale string) error {
+	t.mu.Lock()
+	ua, hints := t.userAgent, t.clientHints
+	t.acceptLanguage = acceptLanguage(locale)
+	lang := t.acceptLanguage
+	t.mu.Unlock()
+
+	actions := chromedp.Tasks{emulation.SetLocaleOverride().WithLocale(locale)}
+	if ua != "" {
+		// 已套用 UA 覆寫的分頁同步 navigator.languages，之後載入的頁面與 Accept-Language 一致
+		actions = append(actions,
+			chromedp.ActionFunc(func(ctx context.Context) error {
+				return userAgentOverride(ua, lang, hints).Do(ctx)
+			}),
+			chromedp.ActionFunc(func(ctx context.Context) error {
+				script, err := stealth.Script(stealth.Profile{
+					Languages: localeLanguages(locale),
+					Evasions:  []string{stealth.Languages.Name},
+				})
+				if err == nil {
+					_, err = page.AddScriptToEvaluateOnNewDocument(script).Do(ctx)
+				}
+				return err
+			}),
+		)
+	}
+	if err := chromedp.Run(t.Ctx, actions); err != nil {
+		return fmt.Errorf("設定語系 %s 失敗: %w", locale, t.wrapErr(err))
+	}
+	return nil
+}
+
+// SetGeolocation 覆寫地理位置並授予 geolocation 權限；accuracy 單位為公尺，<=0 時使用 100
+func (t *Tab) SetGeolocation(lat, lng, accuracy float64) error {
+	if accuracy <= 0 {
+		accuracy = 100
+	}
+	err := chromedp.Run(t.Ctx,
+		cdpbrowser.GrantPermissions([]cdpbrowser.PermissionType{cdpbrowser.PermissionTypeGeolocation}),
+		emulation.SetGeolocationOverride().
+			WithLatitude(lat).
+			WithLongitude(lng).
+			WithAccuracy(accuracy),
+	)
+	if err != nil {
+		return fmt.Errorf("設定地理位置失敗: %w", t.wrapErr(err))
+	}
+	return nil
+}
+
+// DisableScripts 停用或恢復頁面本身的 JavaScript；RunJS 等透過 DevTools 執行的腳本不受影響
+func (t *Tab) DisableScripts(disabled bool) error {
+	err := chromedp.Run(t.Ctx, emulation.SetScriptExecutionDisabled(disabled))
+	if err != nil {
+		return fmt.Errorf("設定 JavaScript 執行狀態失敗: %w", t.wrapErr(err))
+	}
+	return nil
+}
+
+// EmulateDevice 依 devices 目錄中的名稱（例如 "iPhone 14"、"Pixel 7 landscape"）
+// 一併設定 viewport、像素比、觸控、行動版 UA 與 Client Hints
+func (t *Tab) EmulateDevice(name string) error {
+	d, err := devices.Lookup(name)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	lang, hints := t.acceptLanguage, t.clientHints
+	t.mu.Unlock()
+
+	err = chromedp.Run(t.Ctx,
+		deviceMetrics(d),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			return userAgentOverride(d.UserAgent, lang, hints).Do(ctx)
+		}),
+	)
+	if err != nil {
+		return fmt.Errorf("模擬裝置 %s 失敗: %w", d.Name, t.wrapErr(err))
+	}
+	t.mu.Lock()
+	t.userAgent = d.UserAgent
+	t.mu.Unlock()
+	return nil
+}
+
+// NetworkConditions 模擬的網路狀況；頻寬 <= 0 表示不限制
+type NetworkConditions struct {
+	// Latency 從送出請求到收到回應標頭的最短時間
+	Latency time.Duration
+	// DownloadKbps、UploadKbps 下載與上傳頻寬（kbit/s）
+	DownloadKbps float64
+	UploadKbps   float64
+	// Offline 模擬斷線，所有請求以 net::ERR_INTERNET_DISCONNECTED 失敗
+	Offline bool
+}
+
+// NetworkPresets 與 Chrome DevTools 相同的網路節流預設
+var NetworkPresets = map[string]NetworkConditions{
+	"Slow 3G": {Latency: 2000 * time.Millisecond, DownloadKbps: 400, UploadKbps: 400},
+	"Fast 3G": {Latency: 562500 * time.Microsecond, DownloadKbps: 1440, UploadKbps: 675},
+	"Offline": {Offline: true},
+}
+
+// NetworkPreset 依名稱（不分大小寫）取得 NetworkPresets 中的網路狀況
+func NetworkPreset(name string) (NetworkConditions, error) {
+	for n, c := range NetworkPresets {
+		if strings.EqualFold(n, strings.TrimSpace(name)) {
+			return c, nil
+		}
+	}
+	names := make([]string, 0, len(NetworkPresets))
+	for n := range NetworkPresets {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return NetworkConditions{}, fmt.Errorf("未知的網路預設 %q（可用：%s）", name, strings.Join(names, ", "))
+}
+
+// EmulateNetworkConditions 模擬延遲、頻寬或斷線，用於測試頁面在行動網路下的表現；頻寬 <= 0 表示不限制，
+// 全部為零值時取消模擬
+func (t *Tab) EmulateNetworkConditions(latency time.Duration, downKbps, upKbps float64, offline bool) error {
+	throughput := func(kbps float64) float64 {
+		if kbps <= 0 {
+			return -1
+		}
+		return kbps * 1000 / 8
+	}
+	err := chromedp.Run(t.Ctx, network.EmulateNetworkConditions(offline,
+		float64(latency)/float64(time.Millisecond), throughput(downKbps), throughput(upKbps)))
+	if err != nil {
+		return fmt.Errorf("設定網路狀況失敗: %w", t.wrapErr(err))
+	}
+	return nil
+}
+
+// EmulateNetwork 套用 NetworkConditions；零值等同取消模擬
+func (t *Tab) EmulateNetwork(c NetworkConditions) error {
+	return t.EmulateNetworkConditions(c.Latency, c.DownloadKbps, c.UploadKbps, c.Offline)
+}
+
+// deviceMetrics 設定裝置尺寸、像素比、方向與觸控
+func deviceMetrics(d devices.Device) chromedp.Tasks {
+	w, h := d.Viewport()
+	orientation := &emulation.ScreenOrientation{Type: emulation.OrientationTypePortraitPrimary}
+	if d.Landscape {
+		orientation = &emulation.ScreenOrientation{Type: emulation.OrientationTypeLandscapePrimary, Angle: 90}
+	}
+	touch := emulation.SetTouchEmulationEnabled(d.Touch)
+	if d.Touch {
+		touch = touch.WithMaxTouchPoints(5)
+	}
+	return chromedp.Tasks{
+		emulation.SetDeviceMetricsOverride(int64(w), int64(h), d.Scale, d.Mobile).
+			WithScreenOrientation(orientation),
+		touch,
+	}
+}
+
+// applyEmulation 套用 config 中的時區、語系與地理位置
+func (t *Tab) applyEmulation(cfg config.Config) error {
+	if cfg.Timezone != "" {
+		if err := t.EmulateTimezone(cfg.Timezone); err != nil {
+			return err
+		}
+	}
+	if cfg.Locale != "" {
+		if err := t.EmulateLocale(cfg.Locale); err != nil {
+			return err
+		}
+	}
+	if g := cfg.Geolocation; g != nil {
+		if err := t.SetGeolocation(g.Latitude, g.Longitude, g.Accuracy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// acceptLanguage 由語系產生 Accept-Language，例如 de-DE -> "de-DE,de;q=0.9"
+func acceptLanguage(locale string) string {
+	return acceptLanguageFor(localeLanguages(locale))
+}
+
+// localeLanguages 由語系推得 navigator.languages，例如 de-DE -> ["de-DE", "de"]
+func localeLanguages(locale string) []string {
+	if locale == "" {
+		return nil
+	}
+	lang, _, found := strings.Cut(locale, "-")
+	if !found {
+		return []string{locale}
+	}
+	return []string{locale, lang}
+}
+
+// acceptLanguageFor 依 navigator.languages 的順序產生 Accept-Language，q 值逐項遞減 0.1，
+// 讓標頭與頁面看到的語言一致
+func acceptLanguageFor(langs []string) string {
+	var b strings.Builder
+	for i, l := range langs {
+		if i > 0 {
+			q := 10 - i
+			if q < 1 {
+				q = 1
+			}
+			fmt.Fprintf(&b, ",%s;q=0.%d", l, q)
+			continue
+		}
+		b.WriteString(l)
+	}
+	return b.String()
+}
@@ -0,0 +1,221 @@
+package tab
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+	"github.com/firehourse/cdpkit/audit"
+)
+
+// 送出後等待導航開始的時間；超過仍未開始則視為以 XHR 送出的表單，不再等待
+const submitNavigationGrace = 3 * time.Second
+
+// fieldInfo 表單欄位的種類與狀態
+type fieldInfo struct {
+	Tag     string `json:"tag"`
+	Type    string `json:"type"`
+	Checked bool   `json:"checked"`
+}
+
+// FillForm 依 selector 填寫表單欄位，欄位依 selector 排序後逐一處理：
+// 文字欄位先清空再逐字輸入；<select> 選取 value 或顯示文字相符的選項；
+// checkbox 依 "true"/"false"（也接受 "on"/"off"、"1"/"0"）勾選或取消，radio 只能選取
+func (t *Tab) FillForm(fields map[string]string) error {
+	selectors := make([]string, 0, len(fields))
+	for sel := range fields {
+		selectors = append(selectors, sel)
+	}
+	sort.Strings(selectors)
+
+	for _, sel := range selectors {
+		if err := t.fillField(sel, fields[sel]); err != nil {
+			return fmt.Errorf("填寫 %s 失敗: %w", sel, err)
+		}
+	}
+	return nil
+}
+
+// Submit 送出表單並等待因此產生的導航載入完成。selector 指向 <form> 時呼叫 requestSubmit，
+// 否則點擊該元素（通常是送出按鈕）；若送出後沒有開始導航（XHR 表單）則直接返回
+func (t *Tab) Submit(selector string) error {
+	ctx, cancel := context.WithTimeout(t.Ctx, t.DefaultTimeout())
+	defer cancel()
+
+	mainFrame := cdp.FrameID(chromedp.FromContext(t.Ctx).Target.TargetID)
+	started := make(chan struct{}, 1)
+	navigated := make(chan string, 1)
+	loaded := make(chan struct{}, 1)
+	chromedp.ListenTarget(ctx, func(ev interface{}) {
+		switch e := ev.(type) {
+		case *page.EventFrameStartedLoading:
+			if e.FrameID == mainFrame {
+				select {
+				case started <- struct{}{}:
+				default:
+				}
+			}
+		case *page.EventFrameNavigated:
+			if e.Frame.ID == mainFrame {
+				select {
+				case navigated <- e.Frame.URL:
+				default:
+				}
+			}
+		case *page.EventLoadEventFired:
+			select {
+			case loaded <- struct{}{}:
+			default:
+			}
+		}
+	})
+
+	info, err := t.inspectField(selector)
+	if err == nil {
+		if info.Tag == "form" {
+			err = t.run(chromedp.Evaluate(fmt.Sprintf(
+				`(el => el.requestSubmit ? el.requestSubmit() : el.submit())(%s)`,
+				t.queryJS(selector)), nil))
+		} else {
+			err = t.Click(selector)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("送出 %s 失敗: %w", selector, err)
+	}
+
+	select {
+	case <-started:
+	case <-time.After(submitNavigationGrace):
+		log.Printf("[cdpkit] 送出 %s 後未發生導航", selector)
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("送出 %s 後等待導航失敗: %w", selector, t.wrapErr(ctx.Err()))
+	}
+
+	t.IsNavigating = true
+	defer func() { t.IsNavigating = false }()
+
+	var url string
+	for url == "" || loaded != nil {
+		select {
+		case url = <-navigated:
+		case <-loaded:
+			loaded = nil
+		case <-ctx.Done():
+			return fmt.Errorf("送出 %s 後等待頁面載入失敗: %w", selector, t.wrapErr(ctx.Err()))
+		}
+	}
+
+	t.CurrentURL = url
+	t.audit(audit.ActionNavigate, url, "")
+	log.Printf("[cdpkit] 送出表單後導航至: %s", url)
+	return nil
+}
+
+// ----------------- 內部實作 -----------------
+
+func (t *Tab) fillField(sel, value string) error {
+	info, err := t.inspectField(sel)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case info.Tag == "select":
+		var ok bool
+		err := t.run(chromedp.Evaluate(fmt.Sprintf(`((el, want) => {
+			const opts = Array.from(el.options);
+			const opt = opts.find(o => o.value === want) || opts.find(o => o.text.trim() === want);
+			if (!opt) return false;
+			opt.selected = true;
+			el.dispatchEvent(new Event('input', {bubbles: true}));
+			el.dispatchEvent(new Event('change', {bubbles: true}));
+			return true;
+		})(%s, %s)`, t.queryJS(sel), jsString(value)), &ok))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("找不到選項 %q", value)
+		}
+		if t.Audit != nil {
+			t.Audit.RecordSecret(audit.ActionType, sel, value)
+		}
+		return nil
+
+	case info.Type == "checkbox" || info.Type == "radio":
+		want, err := parseCheck(value)
+		if err != nil {
+			return err
+		}
+		if want == info.Checked {
+			return nil
+		}
+		if !want && info.Type == "radio" {
+			return fmt.Errorf("radio 無法取消選取，請改為選取同組的其他選項")
+		}
+		return t.Click(sel)
+
+	case info.Type == "file":
+		return fmt.Errorf("不支援檔案欄位")
+
+	default:
+		// contenteditable 元素沒有 value，直接輸入
+		err := t.run(chromedp.Evaluate(fmt.Sprintf(`(el => {
+			if (!('value' in el) || el.value === '') return;
+			el.focus();
+			el.value = '';
+			el.dispatchEvent(new Event('input', {bubbles: true}));
+		})(%s)`, t.queryJS(sel)), nil))
+		if err != nil {
+			return err
+		}
+		return t.Type(sel, value, 0)
+	}
+}
+
+// inspectField 回傳元素的標籤、type 與勾選狀態
+func (t *Tab) inspectField(sel string) (fieldInfo, error) {
+	var info *fieldInfo
+	err := t.run(chromedp.Evaluate(fmt.Sprintf(`(el => el && {
+		tag: el.tagName.toLowerCase(),
+		type: (el.getAttribute('type') || '').toLowerCase(),
+		checked: !!el.checked,
+	})(%s)`, t.queryJS(sel)), &info))
+	if err != nil {
+		return fieldInfo{}, err
+	}
+	if info == nil {
+		return fieldInfo{}, fmt.Errorf("找不到元素 %s", sel)
+	}
+	return *info, nil
+}
+
+func parseCheck(value string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "on", "yes", "checked":
+		return true, nil
+	case "off", "no", "":
+		return false, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("無法解析勾選值 %q", value)
+	}
+	return b, nil
+}
+
+// jsString 將字串轉為 JS 字串常值
+func jsString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
@@ -0,0 +1,113 @@
+package tab
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/cdproto/target"
+	"github.com/chromedp/chromedp"
+)
+
+// Frame 找出 selector 指向的跨網域 iframe（OOPIF，例如 Stripe、PayPal、SSO 元件），
+// 自動附加到它的 target，回傳可直接使用 RunJS、HTML、WaitVisible 等方法的 Tab。
+// 回傳的 Tab 生命週期跟隨父分頁，不需要也不應該另外 Close
+func (t *Tab) Frame(selector string, timeout time.Duration) (*Tab, error) {
+	if timeout <= 0 {
+		timeout = t.DefaultTimeout()
+	}
+	ctx, cancel := context.WithTimeout(t.Ctx, timeout)
+	defer cancel()
+
+	log.Printf("[cdpkit] 尋找 iframe: %s", selector)
+	var nodes []*cdp.Node
+	if err := chromedp.Run(ctx, chromedp.Nodes(selector, &nodes, chromedp.ByQuery)); err != nil {
+		return nil, fmt.Errorf("找不到 iframe %s: %w", selector, t.wrapErr(err))
+	}
+	frameID := nodes[0].FrameID
+	if frameID == "" {
+		return nil, fmt.Errorf("元素 %s 不是 iframe", selector)
+	}
+
+	// OOPIF 的 target ID 與 frame ID 相同；iframe 剛插入時 target 可能稍晚才出現
+	return t.attachFrame(ctx, func(info *target.Info) bool {
+		return info.TargetID == target.ID(frameID)
+	}, selector)
+}
+
+// FrameByURL 以 URL 子字串尋找屬於本分頁的跨網域 iframe，適合沒有穩定 selector 的元件
+func (t *Tab) FrameByURL(substr string, timeout time.Duration) (*Tab, error) {
+	if timeout <= 0 {
+		timeout = t.DefaultTimeout()
+	}
+	ctx, cancel := context.WithTimeout(t.Ctx, timeout)
+	defer cancel()
+
+	log.Printf("[cdpkit] 尋找 URL 含 %q 的 iframe", substr)
+	return t.attachFrame(ctx, func(info *target.Info) bool {
+		return strings.Contains(info.URL, substr) && t.ownsFrame(ctx, cdp.FrameID(info.TargetID))
+	}, substr)
+}
+
+// attachFrame 輪詢瀏覽器的 iframe target，找到符合 match 者後附加
+func (t *Tab) attachFrame(ctx context.Context, match func(*target.Info) bool, desc string) (*Tab, error) {
+	for {
+		targets, err := chromedp.Targets(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("無法列出 targets: %w", t.wrapErr(err))
+		}
+		for _, info := range targets {
+			if info.Type == "iframe" && match(info) {
+				return t.frameTab(info.TargetID)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("找不到跨網域 iframe %s（同源 iframe 請直接透過 contentDocument 存取）: %w", desc, t.wrapErr(ctx.Err()))
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+}
+
+// ownsFrame 檢查 frame 是否屬於本分頁：只有父頁面能查到 iframe 的擁有者元素
+func (t *Tab) ownsFrame(ctx context.Context, frameID cdp.FrameID) bool {
+	err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		_, _, err := dom.GetFrameOwner(frameID).Do(ctx)
+		return err
+	}))
+	return err == nil
+}
+
+// frameTab 附加到 iframe target 並快取，同一個 iframe 只會建立一個 session
+func (t *Tab) frameTab(id target.ID) (*Tab, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if ft, ok := t.frames[id]; ok {
+		return ft, nil
+	}
+
+	// 由父分頁衍生，父分頁關閉時一併結束；刻意不保留 cancel，
+	// 因為 chromedp 取消附加的 context 時會嘗試 CloseTarget
+	frameCtx, _ := chromedp.NewContext(t.Ctx, chromedp.WithTargetID(id))
+	if err := chromedp.Run(frameCtx); err != nil {
+		return nil, fmt.Errorf("附加 iframe target 失敗: %w", t.wrapErr(err))
+	}
+
+	ft := &Tab{
+		Ctx:     frameCtx,
+		Timeout: t.Timeout,
+		Audit:   t.Audit,
+	}
+	if t.frames == nil {
+		t.frames = map[target.ID]*Tab{}
+	}
+	t.frames[id] = ft
+	log.Printf("[cdpkit] 已附加 iframe target: %s", id)
+	return ft, nil
+}
@@ -0,0 +1,151 @@
+package tab
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+	"github.com/firehourse/cdpkit/audit"
+)
+
+// GraphQLRequest SendGraphQL 的請求
+type GraphQLRequest struct {
+	// Endpoint GraphQL 端點，可為相對於目前頁面的網址，例如 "/graphql"
+	Endpoint      string
+	Query         string
+	Variables     map[string]interface{}
+	OperationName string
+	// Headers 額外的請求標頭，例如網站前端由 localStorage 取出後附加的 CSRF token 或 Authorization
+	Headers map[string]string
+	// Timeout 逾時，預設使用分頁的預設逾時
+	Timeout time.Duration
+}
+
+// GraphQLResponse GraphQL 的回應
+type GraphQLResponse struct {
+	// Status HTTP 狀態碼
+	Status int `json:"status"`
+	// Data 回應的 data，以 Decode 解析到結構
+	Data       json.RawMessage        `json:"data,omitempty"`
+	Errors     []GraphQLError         `json:"errors,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// GraphQLError GraphQL 回應中的一個錯誤
+type GraphQLError struct {
+	Message    string                 `json:"message"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// Decode 將 Data 解析到 v
+func (r *GraphQLResponse) Decode(v interface{}) error {
+	if len(r.Data) == 0 || string(r.Data) == "null" {
+		return fmt.Errorf("GraphQL 回應沒有 data")
+	}
+	return json.Unmarshal(r.Data, v)
+}
+
+// GraphQL 在目前頁面中以 fetch 送出 GraphQL 查詢並回傳解析後的回應。請求由頁面發出，
+// 附帶網站的 cookies 與瀏覽器的標頭，適合資料層為需要登入的 GraphQL API 的網站；應先導航到該網站
+func (t *Tab) GraphQL(endpoint, query string, variables map[string]interface{}) (*GraphQLResponse, error) {
+	return t.SendGraphQL(GraphQLRequest{Endpoint: endpoint, Query: query, Variables: variables})
+}
+
+// SendGraphQL 同 GraphQL，可指定 operationName 與額外的標頭。
+// HTTP 狀態碼不是 2xx、回應不是 JSON，或只有 errors 沒有 data 時回傳錯誤；部分成功（同時有 data 與 errors）時不回傳錯誤
+func (t *Tab) SendGraphQL(req GraphQLRequest) (*GraphQLResponse, error) {
+	timeout := req.Timeout
+	if timeout <= 0 {
+		timeout = t.DefaultTimeout()
+	}
+	payload := map[string]interface{}{"query": req.Query}
+	if req.Variables != nil {
+		payload["variables"] = req.Variables
+	}
+	if req.OperationName != "" {
+		payload["operationName"] = req.OperationName
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("序列化 GraphQL 查詢失敗: %w", err)
+	}
+	headers := map[string]string{
+		"Content-Type": "application/json",
+		"Accept":       "application/graphql-response+json, application/json",
+	}
+	for k, v := range req.Headers {
+		headers[k] = v
+	}
+	args, err := json.Marshal([]interface{}{req.Endpoint, headers, string(body), timeout.Milliseconds()})
+	if err != nil {
+		return nil, fmt.Errorf("序列化 GraphQL 查詢失敗: %w", err)
+	}
+
+	log.Printf("[cdpkit] 送出 GraphQL 查詢: %s", req.Endpoint)
+	var res struct {
+		Status int    `json:"status"`
+		Type   string `json:"type"`
+		Body   string `json:"body"`
+		Error  string `json:"error"`
+	}
+	err = t.runFor(timeout+5*time.Second, chromedp.Evaluate(fmt.Sprintf(graphQLJS, args), &res, func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+		return p.WithAwaitPromise(true)
+	}))
+	t.audit(audit.ActionScript, req.Endpoint, audit.HashValue(string(body)))
+	if err != nil {
+		return nil, fmt.Errorf("送出 GraphQL 查詢失敗: %w", t.wrapErr(err))
+	}
+	if res.Error != "" {
+		return nil, fmt.Errorf("送出 GraphQL 查詢失敗: %s", res.Error)
+	}
+
+	out := &GraphQLResponse{}
+	if err := json.Unmarshal([]byte(res.Body), out); err != nil {
+		if res.Status < 200 || res.Status >= 300 {
+			return nil, fmt.Errorf("GraphQL 端點回應狀態碼 %d", res.Status)
+		}
+		return nil, fmt.Errorf("GraphQL 回應不是 JSON（%s）: %w", res.Type, err)
+	}
+	out.Status = res.Status
+	noData := len(out.Data) == 0 || string(out.Data) == "null"
+	switch {
+	case res.Status < 200 || res.Status >= 300:
+		return out, fmt.Errorf("GraphQL 端點回應狀態碼 %d%s", res.Status, graphQLMessages(out.Errors))
+	case noData && len(out.Errors) > 0:
+		return out, fmt.Errorf("GraphQL 查詢失敗%s", graphQLMessages(out.Errors))
+	}
+	return out, nil
+}
+
+// ----------------- 內部實作 -----------------
+
+// graphQLJS 以 fetch 送出查詢；參數為 [endpoint, headers, body, timeout]，逾時以 AbortController 中止
+const graphQLJS = `(async ([endpoint, headers, body, timeout]) => {
+	const ctrl = new AbortController();
+	const timer = setTimeout(() => ctrl.abort(), timeout);
+	try {
+		const resp = await fetch(endpoint, {method: 'POST', credentials: 'include', headers, body, signal: ctrl.signal});
+		return {status: resp.status, type: resp.headers.get('content-type') || '', body: await resp.text()};
+	} catch (e) {
+		return {error: String(e)};
+	} finally {
+		clearTimeout(timer);
+	}
+})(%s)`
+
+// graphQLMessages 將錯誤訊息串接為 "：a; b"，沒有錯誤時為空字串
+func graphQLMessages(errs []GraphQLError) string {
+	if len(errs) == 0 {
+		return ""
+	}
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = e.Message
+	}
+	return "：" + strings.Join(msgs, "; ")
+}
@@ -0,0 +1,184 @@
+package tab
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+	"github.com/firehourse/cdpkit/audit"
+	"github.com/firehourse/cdpkit/browser"
+	"github.com/firehourse/cdpkit/config"
+)
+
+// HandoffOptions 人工接手的設定
+type HandoffOptions struct {
+	// Browser 人工操作用 Chrome 的設定，例如 Proxy、ChromePath、Locale；一律以有頭模式啟動。
+	// UserAgent 為空時沿用此分頁的 UA，避免網站因瀏覽器不同而使登入失效
+	Browser config.Config
+	// Message 顯示於頁面底部提示列的說明，預設「請完成此頁面的操作後按下完成」
+	Message string
+	// UntilSelector 人工操作的頁面出現此元素時自動結束，例如登入後才有的選單
+	UntilSelector string
+	// UntilURL 人工操作的頁面網址包含此字串時自動結束
+	UntilURL string
+	// Timeout 等待人工完成的上限，預設 10 分鐘
+	Timeout time.Duration
+}
+
+// Handoff 暫停自動化並交給人工處理：在本機啟動有頭 Chrome，還原此分頁的網址、cookies 與 Web Storage，
+// 頁面底部顯示提示列與「完成」按鈕。人工按下完成，或符合 UntilSelector、UntilURL 後，
+// 將人工操作後的狀態匯回此分頁並導航至最後的網址，之後即可繼續自動化。
+// 適用於無法自動處理的步驟，例如罕見的驗證碼或二次驗證；回傳匯回的狀態
+func (t *Tab) Handoff(opts HandoffOptions) (*StorageState, error) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Minute
+	}
+	if opts.Message == "" {
+		opts.Message = "請完成此頁面的操作後按下完成"
+	}
+	state, err := t.ExportState()
+	if err != nil {
+		return nil, fmt.Errorf("無法匯出工作階段: %w", err)
+	}
+
+	cfg, err := t.handoffConfig(opts.Browser)
+	if err != nil {
+		return nil, err
+	}
+	bm, err := browser.NewManagerFromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("無法啟動人工操作用的 Chrome: %w", err)
+	}
+	defer bm.Shutdown()
+	ctx, cancel, err := bm.NewPageContext()
+	if err != nil {
+		return nil, err
+	}
+	human := NewTab(ctx, cancel, cfg)
+	defer human.Close(bm)
+
+	err = human.run(chromedp.ActionFunc(func(ctx context.Context) error {
+		_, err := page.AddScriptToEvaluateOnNewDocument(handoffBannerJS(opts.Message)).Do(ctx)
+		return err
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("無法加入提示列: %w", err)
+	}
+	if err := human.ImportState(state); err != nil {
+		return nil, fmt.Errorf("無法還原工作階段: %w", err)
+	}
+
+	log.Printf("[cdpkit] 已交由人工處理: %s", state.URL)
+	t.audit(audit.ActionHandoff, state.URL, "start")
+	if err := human.waitHandoff(opts); err != nil {
+		t.audit(audit.ActionHandoff, state.URL, err.Error())
+		return nil, err
+	}
+
+	final, err := human.ExportState()
+	if err != nil {
+		return nil, fmt.Errorf("無法匯出人工操作後的工作階段: %w", err)
+	}
+	log.Printf("[cdpkit] 人工處理完成，繼續自動化: %s", final.URL)
+	t.audit(audit.ActionHandoff, final.URL, "done")
+	if err := t.ImportState(final); err != nil {
+		return nil, fmt.Errorf("無法匯回工作階段: %w", err)
+	}
+	return final, nil
+}
+
+// ----------------- 內部實作 -----------------
+
+// handoffConfig 以 base 為準產生有頭 Chrome 的設定，使用另一個空閒的調試埠避免連到自動化用的 Chrome
+func (t *Tab) handoffConfig(base config.Config) (config.Config, error) {
+	cfg := base
+	cfg.WebSocketURL = ""
+	flags := config.SafeDefaults()
+	for k, v := range base.Flags {
+		flags[k] = v
+	}
+	flags["headless"] = false
+	cfg.Flags = flags
+
+	port, err := browser.FreePort()
+	if err != nil {
+		return cfg, err
+	}
+	cfg.RemotePort = port
+
+	if cfg.UserAgent == "" {
+		t.mu.Lock()
+		cfg.UserAgent = t.userAgent
+		t.mu.Unlock()
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = t.DefaultTimeout()
+	}
+	return cfg, nil
+}
+
+// waitHandoff 輪詢人工操作的頁面直到按下完成或符合結束條件；連續讀取失敗視為視窗已關閉
+func (t *Tab) waitHandoff(opts HandoffOptions) error {
+	deadline := time.Now().Add(opts.Timeout)
+	failures := 0
+	for time.Now().Before(deadline) {
+		if err := wait(t.Ctx, 500*time.Millisecond); err != nil {
+			return err
+		}
+		var status struct {
+			Done bool   `json:"done"`
+			URL  string `json:"url"`
+		}
+		err := t.runFor(5*time.Second, chromedp.Evaluate(`({done: !!window.__cdpkitHandoffDone, url: location.href})`, &status))
+		if err != nil {
+			if failures++; failures >= 3 {
+				return fmt.Errorf("無法讀取人工操作的頁面，視窗可能已關閉: %w", err)
+			}
+			continue
+		}
+		failures = 0
+		if status.Done || (opts.UntilURL != "" && strings.Contains(status.URL, opts.UntilURL)) {
+			return nil
+		}
+		if opts.UntilSelector != "" {
+			if n, err := t.Count(opts.UntilSelector); err == nil && n > 0 {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("等待人工處理逾時 (%v)", opts.Timeout)
+}
+
+// handoffBannerJS 在每個頁面底部加上提示列；按下完成時設定 window.__cdpkitHandoffDone
+func handoffBannerJS(message string) string {
+	return fmt.Sprintf(`(() => {
+	if (window.top !== window) return;
+	const add = () => {
+		if (document.getElementById('__cdpkit_handoff')) return;
+		const bar = document.createElement('div');
+		bar.id = '__cdpkit_handoff';
+		bar.style.cssText = 'position:fixed;left:0;right:0;bottom:0;z-index:2147483647;display:flex;gap:12px;' +
+			'align-items:center;justify-content:space-between;padding:8px 12px;background:#1e293b;color:#fff;font:14px sans-serif';
+		const text = document.createElement('span');
+		text.textContent = %s;
+		const btn = document.createElement('button');
+		btn.textContent = '完成，交回自動化';
+		btn.onclick = () => {
+			window.__cdpkitHandoffDone = true;
+			btn.disabled = true;
+			btn.textContent = '已交回';
+		};
+		bar.append(text, btn);
+		document.documentElement.appendChild(bar);
+	};
+	if (document.readyState === 'loading') {
+		document.addEventListener('DOMContentLoaded', add);
+	} else {
+		add();
+	}
+})()`, jsString(message))
+}
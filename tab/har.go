@@ -0,0 +1,207 @@
+package tab
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// HAR 以 HAR 1.2 格式匯出目前記錄的回應（最多 500 筆），可在 Chrome DevTools 或 HAR 檢視器開啟。
+// 不含回應主體與細部的連線時間，time 為送出請求到接收完畢的總時間
+func (t *Tab) HAR() ([]byte, error) {
+	responses := t.Responses()
+	sort.SliceStable(responses, func(i, j int) bool {
+		return responses[i].Started.Before(responses[j].Started)
+	})
+
+	page := harPage{ID: "page_1", Title: t.CurrentURL, PageTimings: harPageTimings{OnLoad: -1, OnContentLoad: -1}}
+	if len(responses) > 0 {
+		page.StartedDateTime = harTime(responses[0].Started)
+	} else {
+		page.StartedDateTime = harTime(time.Now())
+	}
+
+	entries := make([]harEntry, 0, len(responses))
+	for _, r := range responses {
+		method := r.Method
+		if method == "" {
+			method = "GET"
+		}
+		ms := float64(r.Duration) / float64(time.Millisecond)
+		bodySize := r.EncodedSize
+		if !r.Finished {
+			bodySize = -1
+		}
+		entries = append(entries, harEntry{
+			PageRef:         page.ID,
+			StartedDateTime: harTime(r.Started),
+			Time:            ms,
+			Request: harRequest{
+				Method:      method,
+				URL:         r.URL,
+				HTTPVersion: harVersion(r.Protocol),
+				Cookies:     []harPair{},
+				Headers:     harHeaders(r.RequestHeaders),
+				QueryString: harQuery(r.URL),
+				HeadersSize: -1,
+				BodySize:    -1,
+			},
+			Response: harResponse{
+				Status:      r.Status,
+				HTTPVersion: harVersion(r.Protocol),
+				Cookies:     []harPair{},
+				Headers:     harHeaders(r.Headers),
+				Content:     harContent{Size: bodySize, MimeType: r.MimeType},
+				RedirectURL: headerValue(r.Headers, "Location"),
+				HeadersSize: -1,
+				BodySize:    bodySize,
+			},
+			Cache:   struct{}{},
+			Timings: harTimings{Blocked: -1, DNS: -1, Connect: -1, Send: 0, Wait: ms, Receive: 0, SSL: -1},
+		})
+	}
+
+	doc := map[string]interface{}{
+		"log": map[string]interface{}{
+			"version": "1.2",
+			"creator": map[string]string{"name": "cdpkit", "version": "1"},
+			"pages":   []harPage{page},
+			"entries": entries,
+		},
+	}
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("產生 HAR 失敗: %w", err)
+	}
+	return b, nil
+}
+
+// ----------------- 內部實作 -----------------
+
+type harPage struct {
+	StartedDateTime string         `json:"startedDateTime"`
+	ID              string         `json:"id"`
+	Title           string         `json:"title"`
+	PageTimings     harPageTimings `json:"pageTimings"`
+}
+
+type harPageTimings struct {
+	OnContentLoad float64 `json:"onContentLoad"`
+	OnLoad        float64 `json:"onLoad"`
+}
+
+type harEntry struct {
+	PageRef         string      `json:"pageref"`
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+}
+
+type harRequest struct {
+	Method      string    `json:"method"`
+	URL         string    `json:"url"`
+	HTTPVersion string    `json:"httpVersion"`
+	Cookies     []harPair `json:"cookies"`
+	Headers     []harPair `json:"headers"`
+	QueryString []harPair `json:"queryString"`
+	HeadersSize int       `json:"headersSize"`
+	BodySize    int       `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int64      `json:"status"`
+	StatusText  string     `json:"statusText"`
+	HTTPVersion string     `json:"httpVersion"`
+	Cookies     []harPair  `json:"cookies"`
+	Headers     []harPair  `json:"headers"`
+	Content     harContent `json:"content"`
+	RedirectURL string     `json:"redirectURL"`
+	HeadersSize int        `json:"headersSize"`
+	BodySize    int64      `json:"bodySize"`
+}
+
+type harContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+}
+
+type harTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+}
+
+type harPair struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+func harTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000Z")
+}
+
+// harVersion 將 CDP 的協定名稱轉為 HAR 慣用的寫法
+func harVersion(protocol string) string {
+	switch strings.ToLower(protocol) {
+	case "h2":
+		return "HTTP/2.0"
+	case "h3", "h3-29":
+		return "HTTP/3"
+	case "":
+		return "HTTP/1.1"
+	}
+	return strings.ToUpper(protocol)
+}
+
+// harHeaders 依名稱排序輸出；CDP 以換行合併同名標頭，HAR 中拆回多筆
+func harHeaders(headers map[string]interface{}) []harPair {
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	pairs := []harPair{}
+	for _, k := range names {
+		for _, v := range strings.Split(fmt.Sprint(headers[k]), "\n") {
+			pairs = append(pairs, harPair{Name: k, Value: v})
+		}
+	}
+	return pairs
+}
+
+func harQuery(rawURL string) []harPair {
+	pairs := []harPair{}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return pairs
+	}
+	for _, kv := range strings.Split(u.RawQuery, "&") {
+		if kv == "" {
+			continue
+		}
+		k, v, _ := strings.Cut(kv, "=")
+		k, _ = url.QueryUnescape(k)
+		v, _ = url.QueryUnescape(v)
+		pairs = append(pairs, harPair{Name: k, Value: v})
+	}
+	return pairs
+}
+
+func headerValue(headers map[string]interface{}, name string) string {
+	for k, v := range headers {
+		if strings.EqualFold(k, name) {
+			return fmt.Sprint(v)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,371 @@
+package tab
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/chromedp/cdproto/input"
+	"github.com/chromedp/chromedp"
+	"github.com/chromedp/chromedp/kb"
+	"github.com/firehourse/cdpkit/audit"
+	"github.com/firehourse/cdpkit/input/humanize"
+)
+
+// InputOptions 模擬輸入的時間參數；零值欄位使用預設值
+type InputOptions struct {
+	// MoveSteps 滑鼠移動到目標的分段數，預設 12
+	MoveSteps int
+	// StepDelay 每段移動之間的間隔，預設 10ms
+	StepDelay time.Duration
+	// ClickHold 按下到放開滑鼠的時間，預設 70ms
+	ClickHold time.Duration
+	// KeyDelay Type 未指定 delay 時每個字元的間隔，預設 90ms
+	KeyDelay time.Duration
+	// Jitter 各間隔隨機增減的比例（0~1），預設 0.4；設為負值則不加入隨機
+	Jitter float64
+	// Rand 亂數來源，nil 時使用全域來源；指定固定種子可重現動作
+	Rand *rand.Rand
+	// Humanizer 設定後改用 Bezier 曲線移動、不均勻的打字節奏與捲動抖動，
+	// 並忽略上述時間參數
+	Humanizer *humanize.Humanizer
+}
+
+// keyNames Press 支援的按鍵名稱
+var keyNames = map[string]string{
+	"Enter":      kb.Enter,
+	"Tab":        kb.Tab,
+	"Escape":     kb.Escape,
+	"Backspace":  kb.Backspace,
+	"Delete":     kb.Delete,
+	"ArrowUp":    kb.ArrowUp,
+	"ArrowDown":  kb.ArrowDown,
+	"ArrowLeft":  kb.ArrowLeft,
+	"ArrowRight": kb.ArrowRight,
+	"Home":       kb.Home,
+	"End":        kb.End,
+	"PageUp":     kb.PageUp,
+	"PageDown":   kb.PageDown,
+	"Space":      " ",
+}
+
+// Click 將元素捲動到可視範圍後，移動滑鼠至元素內並以左鍵點擊
+func (t *Tab) Click(selector string) error {
+	x, y, size, err := t.elementPoint(selector)
+	if err == nil {
+		err = t.run(chromedp.ActionFunc(func(ctx context.Context) error {
+			if err := t.moveMouse(ctx, x, y, size); err != nil {
+				return err
+			}
+			if h := t.Input.Humanizer; h != nil {
+				if err := wait(ctx, h.Pause()/3); err != nil {
+					return err
+				}
+			}
+			return t.clickAt(ctx, x, y)
+		}))
+	}
+	if err != nil {
+		log.Printf("[cdpkit] 點擊 %s 失敗: %v", selector, err)
+		t.audit(audit.ActionClick, selector, err.Error())
+		return fmt.Errorf("點擊 %s 失敗: %w", selector, err)
+	}
+	t.audit(audit.ActionClick, selector, "")
+	return nil
+}
+
+// Hover 將滑鼠移到元素上
+func (t *Tab) Hover(selector string) error {
+	x, y, size, err := t.elementPoint(selector)
+	if err == nil {
+		err = t.run(chromedp.ActionFunc(func(ctx context.Context) error {
+			return t.moveMouse(ctx, x, y, size)
+		}))
+	}
+	if err != nil {
+		return fmt.Errorf("移動到 %s 失敗: %w", selector, err)
+	}
+	return nil
+}
+
+// Type 點擊元素取得焦點後逐字輸入；delay 為每個字元的平均間隔，<=0 時使用 Input.KeyDelay。
+// 輸入內容在稽核紀錄中只保存雜湊
+func (t *Tab) Type(selector, text string, delay time.Duration) error {
+	if err := t.Click(selector); err != nil {
+		return err
+	}
+	if delay <= 0 {
+		delay = t.inputOptions().KeyDelay
+	}
+	var delays []time.Duration
+	if h := t.Input.Humanizer; h != nil {
+		delays = h.KeyDelays(text, delay)
+	}
+	// 長字串所需時間可能超過預設逾時，依字數延長
+	timeout := t.DefaultTimeout() + 2*delay*time.Duration(len(text))
+	for _, d := range delays {
+		timeout += d
+	}
+	err := t.runFor(timeout, chromedp.ActionFunc(func(ctx context.Context) error {
+		for i, r := range []rune(text) {
+			if err := t.dispatchKeys(ctx, kb.Encode(r)); err != nil {
+				return err
+			}
+			var err error
+			if delays != nil {
+				err = wait(ctx, delays[i])
+			} else {
+				err = t.sleep(ctx, delay)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+	if err != nil {
+		return fmt.Errorf("輸入 %s 失敗: %w", selector, err)
+	}
+	if t.Audit != nil {
+		t.Audit.RecordSecret(audit.ActionType, selector, text)
+	}
+	return nil
+}
+
+// Press 按下並放開一個按鍵，例如 "Enter"、"Tab"、"ArrowDown"，或單一字元
+func (t *Tab) Press(key string) error {
+	keys, ok := keyNames[key]
+	if !ok {
+		if len([]rune(key)) != 1 {
+			return fmt.Errorf("不支援的按鍵 %q", key)
+		}
+		keys = key
+	}
+	err := t.run(chromedp.ActionFunc(func(ctx context.Context) error {
+		for _, r := range keys {
+			if err := t.dispatchKeys(ctx, kb.Encode(r)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+	if err != nil {
+		return fmt.Errorf("按下 %s 失敗: %w", key, err)
+	}
+	return nil
+}
+
+// Scroll 以滑鼠滾輪捲動 (x, y) 像素，分成數段送出以模擬實際滾動
+func (t *Tab) Scroll(x, y float64) error {
+	err := t.run(chromedp.ActionFunc(func(ctx context.Context) error {
+		mx, my := t.mousePosition()
+		if h := t.Input.Humanizer; h != nil {
+			for _, s := range h.ScrollSteps(x, y) {
+				ev := input.DispatchMouseEvent(input.MouseWheel, mx, my).WithDeltaX(s.DX).WithDeltaY(s.DY)
+				if err := ev.Do(ctx); err != nil {
+					return err
+				}
+				if err := wait(ctx, s.Delay); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		opts := t.inputOptions()
+		steps := int(math.Max(1, math.Ceil(math.Max(math.Abs(x), math.Abs(y))/120)))
+		for i := 0; i < steps; i++ {
+			ev := input.DispatchMouseEvent(input.MouseWheel, mx, my).
+				WithDeltaX(x / float64(steps)).
+				WithDeltaY(y / float64(steps))
+			if err := ev.Do(ctx); err != nil {
+				return err
+			}
+			if err := t.sleep(ctx, opts.StepDelay*3); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+	if err != nil {
+		return fmt.Errorf("捲動失敗: %w", err)
+	}
+	return nil
+}
+
+// ----------------- 內部實作 -----------------
+
+func (t *Tab) inputOptions() InputOptions {
+	o := t.Input
+	if o.MoveSteps <= 0 {
+		o.MoveSteps = 12
+	}
+	if o.StepDelay <= 0 {
+		o.StepDelay = 10 * time.Millisecond
+	}
+	if o.ClickHold <= 0 {
+		o.ClickHold = 70 * time.Millisecond
+	}
+	if o.KeyDelay <= 0 {
+		o.KeyDelay = 90 * time.Millisecond
+	}
+	if o.Jitter == 0 {
+		o.Jitter = 0.4
+	}
+	return o
+}
+
+// run 以預設逾時執行動作
+func (t *Tab) run(action chromedp.Action) error {
+	return t.runFor(t.DefaultTimeout(), action)
+}
+
+func (t *Tab) runFor(timeout time.Duration, action chromedp.Action) error {
+	ctx, cancel := context.WithTimeout(t.Ctx, timeout)
+	defer cancel()
+	return t.exec(ctx, action)
+}
+
+func (t *Tab) randFloat() float64 {
+	if r := t.Input.Rand; r != nil {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		return r.Float64()
+	}
+	return rand.Float64()
+}
+
+// sleep 等待 d 並依 Jitter 隨機增減
+func (t *Tab) sleep(ctx context.Context, d time.Duration) error {
+	if j := t.inputOptions().Jitter; j > 0 {
+		d = time.Duration(float64(d) * (1 + j*(2*t.randFloat()-1)))
+	}
+	return wait(ctx, d)
+}
+
+// wait 等待 d 或 ctx 結束
+func wait(ctx context.Context, d time.Duration) error {
+	select {
+	case <-time.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// elementPoint 捲動元素到可視範圍，回傳元素內靠近中心的隨機一點（viewport 座標）
+// 以及元素較短邊的長度，供計算移動時間
+func (t *Tab) elementPoint(selector string) (float64, float64, float64, error) {
+	var scroll chromedp.Action = chromedp.ScrollIntoView(selector, chromedp.ByQuery)
+	if t.byJS(selector) {
+		scroll = chromedp.ActionFunc(func(ctx context.Context) error {
+			if err := t.waitQuery(ctx, selector); err != nil {
+				return err
+			}
+			return chromedp.Evaluate(fmt.Sprintf(
+				`%s.scrollIntoView({block: 'center', inline: 'center'})`, t.elementJS(selector)), nil).Do(ctx)
+		})
+	}
+	var box []float64
+	err := t.run(chromedp.Tasks{
+		scroll,
+		chromedp.Evaluate(fmt.Sprintf(`(() => {
+			const r = %s.getBoundingClientRect();
+			return [r.left, r.top, r.width, r.height];
+		})()`, t.elementJS(selector)), &box),
+	})
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if len(box) != 4 || box[2] == 0 || box[3] == 0 {
+		return 0, 0, 0, fmt.Errorf("元素 %s 沒有可點擊的區域", selector)
+	}
+	size := math.Min(box[2], box[3])
+	if h := t.Input.Humanizer; h != nil {
+		p := h.TargetPoint(box[0], box[1], box[2], box[3])
+		return p.X, p.Y, size, nil
+	}
+	// 落在元素中央 60% 的範圍內
+	x := box[0] + box[2]*(0.2+0.6*t.randFloat())
+	y := box[1] + box[3]*(0.2+0.6*t.randFloat())
+	return x, y, size, nil
+}
+
+func (t *Tab) mousePosition() (float64, float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.mouseX, t.mouseY
+}
+
+// moveMouse 從目前位置分段移動到 (x, y)；size 為目標元素大小
+func (t *Tab) moveMouse(ctx context.Context, x, y, size float64) error {
+	opts := t.inputOptions()
+	fromX, fromY := t.mousePosition()
+	if h := opts.Humanizer; h != nil {
+		path := h.MousePath(humanize.Point{X: fromX, Y: fromY}, humanize.Point{X: x, Y: y}, size)
+		for _, s := range path {
+			if err := input.DispatchMouseEvent(input.MouseMoved, s.X, s.Y).Do(ctx); err != nil {
+				return err
+			}
+			if err := wait(ctx, s.Delay); err != nil {
+				return err
+			}
+		}
+		t.mu.Lock()
+		t.mouseX, t.mouseY = x, y
+		t.mu.Unlock()
+		return nil
+	}
+	for i := 1; i <= opts.MoveSteps; i++ {
+		p := float64(i) / float64(opts.MoveSteps)
+		// ease-in-out：起步與結尾較慢
+		p = p * p * (3 - 2*p)
+		cx, cy := fromX+(x-fromX)*p, fromY+(y-fromY)*p
+		if err := input.DispatchMouseEvent(input.MouseMoved, cx, cy).Do(ctx); err != nil {
+			return err
+		}
+		if err := t.sleep(ctx, opts.StepDelay); err != nil {
+			return err
+		}
+	}
+	t.mu.Lock()
+	t.mouseX, t.mouseY = x, y
+	t.mu.Unlock()
+	return nil
+}
+
+func (t *Tab) clickAt(ctx context.Context, x, y float64) error {
+	press := input.DispatchMouseEvent(input.MousePressed, x, y).
+		WithButton(input.Left).
+		WithButtons(1).
+		WithClickCount(1)
+	if err := press.Do(ctx); err != nil {
+		return err
+	}
+	var err error
+	if h := t.Input.Humanizer; h != nil {
+		err = wait(ctx, h.ClickHold())
+	} else {
+		err = t.sleep(ctx, t.inputOptions().ClickHold)
+	}
+	if err != nil {
+		return err
+	}
+	return input.DispatchMouseEvent(input.MouseReleased, x, y).
+		WithButton(input.Left).
+		WithClickCount(1).
+		Do(ctx)
+}
+
+// dispatchKeys 送出一個字元對應的 keyDown/char/keyUp 事件
+func (t *Tab) dispatchKeys(ctx context.Context, events []*input.DispatchKeyEventParams) error {
+	for _, ev := range events {
+		if err := ev.Do(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package tab
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"github.com/firehourse/cdpkit/blocklist"
+)
+
+// PausedRequest 一個被攔截暫停的請求；interceptor 可標記阻擋、直接回應或修改標頭，
+// 所有 interceptor 執行完後才統一決定放行、回應或失敗
+type PausedRequest struct {
+	Event *fetch.EventRequestPaused
+
+	blocked bool
+	reason  network.ErrorReason
+	headers map[string]string
+	// fulfill 不為 nil 時以此回應，不送出請求
+	fulfill *fetch.FulfillRequestParams
+}
+
+// Block 以指定原因讓請求失敗
+func (r *PausedRequest) Block(reason network.ErrorReason) {
+	r.blocked = true
+	r.reason = reason
+}
+
+// Blocked 回傳是否已被先前的 interceptor 阻擋
+func (r *PausedRequest) Blocked() bool {
+	return r.blocked
+}
+
+// Fulfill 不送出請求，直接以指定的狀態碼、標頭與主體回應；之後的 interceptor 不再執行
+func (r *PausedRequest) Fulfill(status int, headers map[string]string, body []byte) {
+	entries := make([]*fetch.HeaderEntry, 0, len(headers))
+	for k, v := range headers {
+		entries = append(entries, &fetch.HeaderEntry{Name: k, Value: v})
+	}
+	r.fulfill = fetch.FulfillRequest(r.Event.RequestID, int64(status)).
+		WithResponseHeaders(entries).
+		WithBody(base64.StdEncoding.EncodeToString(body))
+}
+
+// SetHeader 放行時覆寫或新增請求標頭
+func (r *PausedRequest) SetHeader(name, value string) {
+	if r.headers == nil {
+		r.headers = map[string]string{}
+		for k, v := range r.Event.Request.Headers {
+			r.headers[k] = fmt.Sprintf("%v", v)
+		}
+	}
+	r.headers[name] = value
+}
+
+// Interceptor 處理被暫停的請求，依註冊順序執行
+type Interceptor func(r *PausedRequest)
+
+// AddInterceptor 註冊請求攔截器；第一次註冊時才啟用 Fetch 網域，未使用時不影響效能
+func (t *Tab) AddInterceptor(fn Interceptor) error {
+	t.mu.Lock()
+	first := len(t.interceptors) == 0
+	t.interceptors = append(t.interceptors, fn)
+	t.mu.Unlock()
+
+	if !first {
+		return nil
+	}
+	return t.enableFetch()
+}
+
+// BlockResources 阻擋指定類型（"image"、"font"、"media"、"stylesheet" 等，不分大小寫）
+// 或 URL 符合 patterns 的請求；patterns 的比對規則與 OnResponse 相同
+func (t *Tab) BlockResources(types []string, patterns []string) error {
+	if len(types) == 0 && len(patterns) == 0 {
+		return nil
+	}
+	blocked := make(map[string]bool, len(types))
+	for _, typ := range types {
+		blocked[strings.ToLower(typ)] = true
+	}
+	return t.AddInterceptor(func(r *PausedRequest) {
+		if blocked[strings.ToLower(string(r.Event.ResourceType))] {
+			r.Block(network.ErrorReasonBlockedByClient)
+			return
+		}
+		for _, p := range patterns {
+			if matchURL(p, r.Event.Request.URL) {
+				r.Block(network.ErrorReasonBlockedByClient)
+				return
+			}
+		}
+	})
+}
+
+// BlockTrackers 依阻擋清單丟棄追蹤器與廣告請求；主框架的導航不經比對，並作為判斷第三方請求的頁面網址。
+// onBlock 不為 nil 時於每次阻擋後以請求網址與命中的規則呼叫
+func (t *Tab) BlockTrackers(list *blocklist.List, onBlock func(url, rule string)) error {
+	if list == nil {
+		return nil
+	}
+	mainFrame := cdp.FrameID(chromedp.FromContext(t.Ctx).Target.TargetID)
+	var page atomic.Value
+	page.Store("")
+	return t.AddInterceptor(func(r *PausedRequest) {
+		ev := r.Event
+		if ev.ResourceType == network.ResourceTypeDocument && ev.FrameID == mainFrame {
+			page.Store(ev.Request.URL)
+			return
+		}
+		rule, ok := list.Match(ev.Request.URL, page.Load().(string), string(ev.ResourceType))
+		if !ok {
+			return
+		}
+		r.Block(network.ErrorReasonBlockedByClient)
+		if onBlock != nil {
+			onBlock(ev.Request.URL, rule)
+		}
+	})
+}
+
+// enableFetch 啟用 Fetch 網域並監聽暫停的請求與驗證要求；已設定 Credentials 時一併接手 HTTP 驗證。
+// 重複呼叫會以目前的設定重新啟用
+func (t *Tab) enableFetch() error {
+	ctx := t.Ctx
+	t.mu.Lock()
+	listen := !t.fetchListening
+	t.fetchListening = true
+	handleAuth := len(t.credentials) > 0
+	t.mu.Unlock()
+
+	if listen {
+		chromedp.ListenTarget(ctx, func(ev interface{}) {
+			// 監聽器中不能阻塞，另開 goroutine 回應
+			switch e := ev.(type) {
+			case *fetch.EventRequestPaused:
+				go t.handlePaused(ctx, e)
+			case *fetch.EventAuthRequired:
+				go t.handleAuth(ctx, e)
+			}
+		})
+	}
+
+	err := chromedp.Run(ctx, fetch.Enable().WithHandleAuthRequests(handleAuth).WithPatterns([]*fetch.RequestPattern{
+		{URLPattern: "*", RequestStage: fetch.RequestStageRequest},
+	}))
+	if err != nil {
+		return fmt.Errorf("啟用請求攔截失敗: %w", t.wrapErr(err))
+	}
+	return nil
+}
+
+func (t *Tab) handlePaused(ctx context.Context, ev *fetch.EventRequestPaused) {
+	t.mu.Lock()
+	interceptors := make([]Interceptor, len(t.interceptors))
+	copy(interceptors, t.interceptors)
+	t.mu.Unlock()
+
+	r := &PausedRequest{Event: ev}
+	for _, fn := range interceptors {
+		fn(r)
+		if r.blocked || r.fulfill != nil {
+			break
+		}
+	}
+
+	var action chromedp.Action
+	switch {
+	case r.blocked:
+		action = fetch.FailRequest(ev.RequestID, r.reason)
+	case r.fulfill != nil:
+		action = r.fulfill
+	case r.headers != nil:
+		headers := make([]*fetch.HeaderEntry, 0, len(r.headers))
+		for k, v := range r.headers {
+			headers = append(headers, &fetch.HeaderEntry{Name: k, Value: v})
+		}
+		action = fetch.ContinueRequest(ev.RequestID).WithHeaders(headers)
+	default:
+		action = fetch.ContinueRequest(ev.RequestID)
+	}
+
+	// 分頁可能已關閉，此時錯誤無需處理
+	if err := chromedp.Run(ctx, action); err != nil && ctx.Err() == nil {
+		log.Printf("[cdpkit] 回應攔截請求失敗: %v", err)
+	}
+}
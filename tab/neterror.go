@@ -0,0 +1,110 @@
+package tab
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// 導航網路錯誤的分類，可用 errors.Is 判斷 NetError 屬於哪一類；所有 NetError 都符合 ErrNetwork
+var (
+	// ErrNetwork Chromium 回報的網路錯誤
+	ErrNetwork = errors.New("網路錯誤")
+	// ErrDNS 網域名稱無法解析
+	ErrDNS = errors.New("DNS 解析失敗")
+	// ErrConnection 連線被拒、重置、中斷或無法連到位址
+	ErrConnection = errors.New("連線失敗")
+	// ErrNetTimeout 連線或回應逾時
+	ErrNetTimeout = errors.New("網路逾時")
+	// ErrProxy 代理連線、驗證或設定失敗
+	ErrProxy = errors.New("代理連線失敗")
+	// ErrTLS 憑證無效或 TLS 交握失敗
+	ErrTLS = errors.New("TLS 錯誤")
+	// ErrBlocked 請求被攔截器、合規防護或伺服器的政策阻擋
+	ErrBlocked = errors.New("請求被阻擋")
+	// ErrAborted 導航被中止，例如回應為下載或 204
+	ErrAborted = errors.New("導航已中止")
+)
+
+// NetError 導航時 Chromium 回報的網路錯誤（net::ERR_*）
+type NetError struct {
+	// URL 導航的網址
+	URL string
+	// Code 不含 "net::" 的錯誤代碼，例如 "ERR_NAME_NOT_RESOLVED"
+	Code string
+	// Err 原始錯誤；由 ParseNetError 從訊息解析時為 nil
+	Err error
+}
+
+func (e *NetError) Error() string {
+	if e.URL == "" {
+		return fmt.Sprintf("%s：net::%s", e.Kind(), e.Code)
+	}
+	return fmt.Sprintf("%s：net::%s (%s)", e.Kind(), e.Code, e.URL)
+}
+
+// Is 讓 errors.Is(err, ErrNetwork) 與對應分類的 errors.Is 成立
+func (e *NetError) Is(target error) bool {
+	return target == ErrNetwork || target == e.Kind()
+}
+
+func (e *NetError) Unwrap() error {
+	return e.Err
+}
+
+// Kind 回傳錯誤的分類：ErrDNS、ErrConnection、ErrNetTimeout、ErrProxy、ErrTLS、ErrBlocked、ErrAborted，
+// 無法歸類時為 ErrNetwork
+func (e *NetError) Kind() error {
+	code := e.Code
+	switch {
+	// 代理的憑證與逾時錯誤也歸為代理，須先判斷
+	case strings.HasPrefix(code, "ERR_PROXY_") || strings.HasPrefix(code, "ERR_SOCKS_") ||
+		code == "ERR_TUNNEL_CONNECTION_FAILED" || code == "ERR_MANDATORY_PROXY_CONFIGURATION_FAILED":
+		return ErrProxy
+	case code == "ERR_NAME_NOT_RESOLVED" || code == "ERR_NAME_RESOLUTION_FAILED" ||
+		strings.HasPrefix(code, "ERR_DNS_") || code == "ERR_ICANN_NAME_COLLISION":
+		return ErrDNS
+	case code == "ERR_TIMED_OUT" || code == "ERR_CONNECTION_TIMED_OUT":
+		return ErrNetTimeout
+	case strings.HasPrefix(code, "ERR_CERT") || strings.HasPrefix(code, "ERR_SSL_") ||
+		strings.HasPrefix(code, "ERR_BAD_SSL_") || strings.HasPrefix(code, "ERR_TLS") ||
+		strings.HasPrefix(code, "ERR_CT_"):
+		return ErrTLS
+	case strings.HasPrefix(code, "ERR_CONNECTION_") || code == "ERR_ADDRESS_UNREACHABLE" ||
+		code == "ERR_ADDRESS_INVALID" || code == "ERR_INTERNET_DISCONNECTED" || code == "ERR_NETWORK_CHANGED" ||
+		code == "ERR_EMPTY_RESPONSE" || code == "ERR_SOCKET_NOT_CONNECTED" || code == "ERR_NETWORK_ACCESS_DENIED":
+		return ErrConnection
+	case strings.HasPrefix(code, "ERR_BLOCKED_BY_"):
+		return ErrBlocked
+	case code == "ERR_ABORTED":
+		return ErrAborted
+	}
+	return ErrNetwork
+}
+
+// ParseNetError 從錯誤訊息（例如 Result.Error）找出 net::ERR_* 代碼；沒有時回傳 nil
+func ParseNetError(msg string) *NetError {
+	m := netErrorCode.FindStringSubmatch(msg)
+	if m == nil {
+		return nil
+	}
+	return &NetError{Code: m[1]}
+}
+
+// ----------------- 內部實作 -----------------
+
+var netErrorCode = regexp.MustCompile(`net::(ERR_[A-Z0-9_]+)`)
+
+// netError 將帶有 net::ERR_* 的導航錯誤轉為 NetError，其餘原樣回傳
+func netError(err error, url string) error {
+	if err == nil || errors.As(err, new(*NetError)) {
+		return err
+	}
+	ne := ParseNetError(err.Error())
+	if ne == nil {
+		return err
+	}
+	ne.URL, ne.Err = url, err
+	return ne
+}
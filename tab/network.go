@@ -0,0 +1,417 @@
+package tab
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/security"
+	"github.com/chromedp/chromedp"
+)
+
+// maxCapturedResponses 每個分頁保留的回應紀錄上限，超過時捨棄最舊的
+const maxCapturedResponses = 500
+
+// Response 分頁載入過程中收到的一個網路回應
+type Response struct {
+	RequestID    network.RequestID
+	URL          string
+	Status       int64
+	MimeType     string
+	ResourceType network.ResourceType
+	Headers      map[string]interface{}
+	// Finished 回應主體是否已接收完畢，完成後才能取得 body
+	Finished bool
+	// Body 回應主體；僅在傳給 OnResponse 的 handler 時填入
+	Body []byte
+	// Method、RequestHeaders、Started 對應請求的方法、標頭與送出時間
+	Method         string
+	RequestHeaders map[string]interface{}
+	Started        time.Time
+	// Protocol 例如 "http/1.1"、"h2"
+	Protocol string
+	// Duration 從送出請求到接收完畢的時間；EncodedSize 實際傳輸的位元組數。皆在 Finished 後才有值
+	Duration    time.Duration
+	EncodedSize int64
+	// SecurityState 例如 "secure"、"insecure"；Security HTTPS 回應協商的 TLS 版本、加密套件與伺服器憑證，HTTP 時為 nil
+	SecurityState security.State
+	Security      *network.SecurityDetails
+
+	// sentAt 送出請求的 CDP 時間戳（秒），用於計算 Duration
+	sentAt float64
+}
+
+// Redirect 主框架導航經過的一次重新導向
+type Redirect struct {
+	// URL 重新導向前的網址；Location 導向的網址
+	URL      string
+	Location string
+	// Status HTTP 重新導向的 3xx 狀態碼；meta refresh 或 JS 改變網址時為 0
+	Status int64
+}
+
+// pendingRequest 進行中的請求，回應到達時補上 Response 的請求資訊
+type pendingRequest struct {
+	method  string
+	headers map[string]interface{}
+	started time.Time
+	sentAt  float64
+}
+
+// responseHandler OnResponse 註冊的監聽
+type responseHandler struct {
+	pattern string
+	fn      func(Response)
+}
+
+// captureResponses 監聽 Network 事件，記錄回應供 FetchResource 查詢，並追蹤進行中的請求供 WaitNetworkIdle 判斷
+func (t *Tab) captureResponses() {
+	if t.Ctx == nil {
+		return
+	}
+	chromedp.ListenTarget(t.Ctx, func(ev interface{}) {
+		t.touch()
+		switch e := ev.(type) {
+		case *network.EventRequestWillBeSent:
+			if e.Type == network.ResourceTypeDocument && e.Request != nil && t.isMainFrame(e.FrameID) {
+				t.recordDocument(e)
+			}
+			// 長連線不會結束，不列入閒置判斷
+			if e.Type == network.ResourceTypeEventSource || e.Type == network.ResourceTypeWebSocket {
+				return
+			}
+			t.mu.Lock()
+			if t.pending == nil {
+				t.pending = make(map[network.RequestID]*pendingRequest)
+			}
+			req := &pendingRequest{sentAt: timestamp(e.Timestamp)}
+			if e.Request != nil {
+				req.method, req.headers = e.Request.Method, e.Request.Headers
+			}
+			if e.WallTime != nil {
+				req.started = e.WallTime.Time()
+			}
+			t.pending[e.RequestID] = req
+			t.lastNetwork = time.Now()
+			t.mu.Unlock()
+		case *network.EventLoadingFailed:
+			t.mu.Lock()
+			delete(t.pending, e.RequestID)
+			t.lastNetwork = time.Now()
+			t.mu.Unlock()
+		case *network.EventResponseReceived:
+			t.mu.Lock()
+			r := &Response{
+				RequestID:     e.RequestID,
+				URL:           e.Response.URL,
+				Status:        e.Response.Status,
+				MimeType:      e.Response.MimeType,
+				ResourceType:  e.Type,
+				Headers:       e.Response.Headers,
+				Protocol:      e.Response.Protocol,
+				SecurityState: e.Response.SecurityState,
+				Security:      e.Response.SecurityDetails,
+				Started:       time.Now(),
+				sentAt:        timestamp(e.Timestamp),
+			}
+			if req := t.pending[e.RequestID]; req != nil {
+				r.Method, r.RequestHeaders, r.sentAt = req.method, req.headers, req.sentAt
+				if !req.started.IsZero() {
+					r.Started = req.started
+				}
+			}
+			t.responses = append(t.responses, r)
+			if n := len(t.responses); n > maxCapturedResponses {
+				t.responses = t.responses[n-maxCapturedResponses:]
+			}
+			t.mu.Unlock()
+		case *network.EventLoadingFinished:
+			t.mu.Lock()
+			delete(t.pending, e.RequestID)
+			t.lastNetwork = time.Now()
+			var done Response
+			var handlers []func(Response)
+			if r := t.findResponse(func(r *Response) bool { return r.RequestID == e.RequestID }); r != nil {
+				r.Finished = true
+				r.EncodedSize = int64(e.EncodedDataLength)
+				if end := timestamp(e.Timestamp); end > r.sentAt {
+					r.Duration = time.Duration((end - r.sentAt) * float64(time.Second))
+				}
+				done = *r
+				handlers = t.matchHandlers(r)
+			}
+			t.mu.Unlock()
+			if len(handlers) > 0 {
+				// 監聽器中不能阻塞，另開 goroutine 取得 body
+				go t.deliverResponse(done, handlers)
+			}
+		}
+	})
+}
+
+// OnResponse 註冊 XHR/fetch 回應的監聽；回應接收完畢後以含 body 的 Response 呼叫 handler。
+// pattern 不含 * 時以子字串比對 URL，含 * 時為萬用字元比對整個 URL（例如 "*/api/products?*"）。
+// 許多網站由內部 API 渲染，直接擷取 API 回應比解析 DOM 可靠
+func (t *Tab) OnResponse(pattern string, handler func(Response)) {
+	t.mu.Lock()
+	t.responseHandlers = append(t.responseHandlers, responseHandler{pattern: pattern, fn: handler})
+	t.mu.Unlock()
+}
+
+// ResetResponses 清除記錄的回應、重新導向與 OnResponse 註冊的監聽，分頁重複用於其他網址時呼叫
+func (t *Tab) ResetResponses() {
+	t.mu.Lock()
+	t.responses = nil
+	t.responseHandlers = nil
+	t.redirects, t.documentURL = nil, ""
+	t.mu.Unlock()
+}
+
+// Redirects 回傳最近一次 Navigate 後主框架經過的重新導向，依發生順序排列；
+// 包含 HTTP 3xx 以及 meta refresh、JS 造成的後續導航
+func (t *Tab) Redirects() []Redirect {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Redirect(nil), t.redirects...)
+}
+
+// DocumentURL 回傳主框架目前文件的網址（經過所有重新導向後）；尚未導航時為空字串。
+// history.pushState 不產生請求，不反映在此
+func (t *Tab) DocumentURL() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.documentURL
+}
+
+// matchHandlers 回傳符合該回應的 handler；只處理 XHR、fetch 與 JSON 回應。呼叫端須持有 t.mu
+func (t *Tab) matchHandlers(r *Response) []func(Response) {
+	if len(t.responseHandlers) == 0 {
+		return nil
+	}
+	if r.ResourceType != network.ResourceTypeXHR && r.ResourceType != network.ResourceTypeFetch &&
+		!strings.Contains(r.MimeType, "json") {
+		return nil
+	}
+	var fns []func(Response)
+	for _, h := range t.responseHandlers {
+		if matchURL(h.pattern, r.URL) {
+			fns = append(fns, h.fn)
+		}
+	}
+	return fns
+}
+
+func (t *Tab) deliverResponse(r Response, handlers []func(Response)) {
+	body, err := t.GetResponseBody(r.RequestID, 0)
+	if err != nil {
+		// 分頁關閉或回應已被清除時取不到 body，無需警告
+		if t.Ctx.Err() == nil {
+			log.Printf("[cdpkit] 無法取得 %s 的回應主體: %v", r.URL, err)
+		}
+		return
+	}
+	r.Body = body
+	for _, fn := range handlers {
+		fn(r)
+	}
+}
+
+// isMainFrame 判斷 frameID 是否為分頁的主框架（主框架 ID 與 target ID 相同）
+func (t *Tab) isMainFrame(frameID cdp.FrameID) bool {
+	c := chromedp.FromContext(t.Ctx)
+	return c != nil && c.Target != nil && frameID == cdp.FrameID(c.Target.TargetID)
+}
+
+// recordDocument 記錄主框架的文件請求：帶有 RedirectResponse 的為 HTTP 重新導向，
+// 已有文件後的新請求視為 meta refresh 或 JS 導航
+func (t *Tab) recordDocument(e *network.EventRequestWillBeSent) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	switch {
+	case e.RedirectResponse != nil:
+		t.redirects = append(t.redirects, Redirect{
+			URL:      e.RedirectResponse.URL,
+			Location: e.Request.URL,
+			Status:   e.RedirectResponse.Status,
+		})
+	case t.documentURL != "" && t.documentURL != e.Request.URL:
+		t.redirects = append(t.redirects, Redirect{URL: t.documentURL, Location: e.Request.URL})
+	}
+	t.documentURL = e.Request.URL
+}
+
+// timestamp 將 CDP 的單調時間轉為秒數；nil 時為 0
+func timestamp(ts *cdp.MonotonicTime) float64 {
+	if ts == nil {
+		return 0
+	}
+	return float64(ts.Time().UnixNano()) / float64(time.Second)
+}
+
+// matchURL 無萬用字元時以子字串比對，否則 * 可匹配任意字元
+func matchURL(pattern, url string) bool {
+	if !strings.Contains(pattern, "*") {
+		return strings.Contains(url, pattern)
+	}
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(url, parts[0]) {
+		return false
+	}
+	url = url[len(parts[0]):]
+	last := parts[len(parts)-1]
+	for _, p := range parts[1 : len(parts)-1] {
+		i := strings.Index(url, p)
+		if i < 0 {
+			return false
+		}
+		url = url[i+len(p):]
+	}
+	return strings.HasSuffix(url, last)
+}
+
+// WaitNetworkIdle 等待沒有進行中的請求且持續 idle 時間（預設 500ms），
+// 用於無限捲動、點擊後以 XHR 載入內容等沒有導航事件的情況；timeout 內未達閒置時回傳錯誤
+func (t *Tab) WaitNetworkIdle(idle, timeout time.Duration) error {
+	if idle <= 0 {
+		idle = 500 * time.Millisecond
+	}
+	if timeout <= 0 {
+		timeout = t.DefaultTimeout()
+	}
+	ctx, cancel := context.WithTimeout(t.Ctx, timeout)
+	defer cancel()
+
+	for {
+		t.mu.Lock()
+		n, quiet := len(t.pending), time.Since(t.lastNetwork)
+		t.mu.Unlock()
+		if n == 0 && quiet >= idle {
+			return nil
+		}
+		if err := wait(ctx, 50*time.Millisecond); err != nil {
+			if t.Ctx.Err() != nil {
+				return t.wrapErr(err)
+			}
+			return fmt.Errorf("等待網路閒置逾時（仍有 %d 個請求）", n)
+		}
+	}
+}
+
+// Responses 回傳目前記錄的回應（最新在後）
+func (t *Tab) Responses() []Response {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make([]Response, len(t.responses))
+	for i, r := range t.responses {
+		out[i] = *r
+	}
+	return out
+}
+
+// GetResponseBody 取得指定請求的回應主體
+func (t *Tab) GetResponseBody(requestID network.RequestID, timeout time.Duration) ([]byte, error) {
+	if timeout <= 0 {
+		timeout = t.DefaultTimeout()
+	}
+	ctx, cancel := context.WithTimeout(t.Ctx, timeout)
+	defer cancel()
+
+	var body []byte
+	err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		body, err = network.GetResponseBody(requestID).Do(ctx)
+		return err
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("取得回應主體失敗 (%s): %w", requestID, t.wrapErr(err))
+	}
+	return body, nil
+}
+
+// FetchResource 取得頁面載入時抓取的子資源（XHR、JSON API 等）的主體。
+// 優先比對完整 URL，找不到時改以子字串比對最新的一筆；尚未接收完畢時會等待至逾時
+func (t *Tab) FetchResource(url string, timeout time.Duration) ([]byte, error) {
+	if timeout <= 0 {
+		timeout = t.DefaultTimeout()
+	}
+	deadline := time.Now().Add(timeout)
+
+	for {
+		t.mu.Lock()
+		r := t.findResponse(func(r *Response) bool { return r.URL == url })
+		if r == nil {
+			r = t.findResponse(func(r *Response) bool { return strings.Contains(r.URL, url) })
+		}
+		var id network.RequestID
+		finished := false
+		if r != nil {
+			id, finished = r.RequestID, r.Finished
+		}
+		t.mu.Unlock()
+
+		if finished {
+			log.Printf("[cdpkit] 取得子資源: %s", url)
+			return t.GetResponseBody(id, time.Until(deadline))
+		}
+		if time.Now().After(deadline) {
+			if r == nil {
+				return nil, fmt.Errorf("找不到符合 %s 的回應", url)
+			}
+			return nil, fmt.Errorf("回應 %s 在逾時前未接收完畢", url)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// findResponse 由新到舊尋找第一筆符合的回應；呼叫端須持有 t.mu
+func (t *Tab) findResponse(match func(*Response) bool) *Response {
+	for i := len(t.responses) - 1; i >= 0; i-- {
+		if match(t.responses[i]) {
+			return t.responses[i]
+		}
+	}
+	return nil
+}
+
+// Cookies 回傳瀏覽器中適用於 urls 的 cookies；未指定時回傳目前頁面的 cookies
+func (t *Tab) Cookies(urls ...string) ([]*network.Cookie, error) {
+	ctx, cancel := context.WithTimeout(t.Ctx, t.DefaultTimeout())
+	defer cancel()
+
+	var cookies []*network.Cookie
+	err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		cookies, err = network.GetCookies().WithURLs(urls).Do(ctx)
+		return err
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("取得 cookies 失敗: %w", t.wrapErr(err))
+	}
+	return cookies, nil
+}
+
+// SetCookies 為 pageURL 設定 cookies；須在導航前呼叫才會隨第一個請求送出
+func (t *Tab) SetCookies(pageURL string, cookies map[string]string) error {
+	if len(cookies) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(t.Ctx, t.DefaultTimeout())
+	defer cancel()
+
+	err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		for name, value := range cookies {
+			if err := network.SetCookie(name, value).WithURL(pageURL).Do(ctx); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+	if err != nil {
+		return fmt.Errorf("設定 cookies 失敗: %w", t.wrapErr(err))
+	}
+	return nil
+}
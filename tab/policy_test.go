@@ -0,0 +1,36 @@
+package tab
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/chromedp/chromedp"
+	"github.com/firehourse/cdpkit/config"
+	"github.com/firehourse/cdpkit/policy"
+)
+
+// 無法連線的瀏覽器上無法啟用請求攔截，設定了允許清單的分頁必須拒絕導航與其他操作
+func TestPolicyFailsClosed(t *testing.T) {
+	allocCtx, allocCancel := chromedp.NewRemoteAllocator(context.Background(), "ws://127.0.0.1:1/devtools/browser/test")
+	defer allocCancel()
+	ctx, cancel := chromedp.NewContext(allocCtx)
+
+	tb := Open(ctx, cancel, config.Split(config.Config{
+		Timeout:            2 * time.Second,
+		NoAutomationTweaks: true,
+		Policy:             &policy.Policy{AllowedDomains: []string{"example.com"}},
+	}))
+	defer tb.Close(nil)
+
+	if err := tb.Navigate("https://example.com/", 0); !errors.Is(err, ErrPolicyNotEnforced) {
+		t.Fatalf("Navigate 回傳 %v，應為 ErrPolicyNotEnforced", err)
+	}
+	if err := tb.Run(chromedp.Evaluate(`1`, nil)); !errors.Is(err, ErrPolicyNotEnforced) {
+		t.Fatalf("Run 回傳 %v，應為 ErrPolicyNotEnforced", err)
+	}
+	if _, err := tb.RunJS(`location.href = "https://evil.example.net/"`, 0); !errors.Is(err, ErrPolicyNotEnforced) {
+		t.Fatalf("RunJS 回傳 %v，應為 ErrPolicyNotEnforced", err)
+	}
+}
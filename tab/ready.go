@@ -0,0 +1,114 @@
+package tab
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+)
+
+// ReadyOptions 截圖或輸出 PDF 前等待網頁字型與圖片就緒，避免字型尚未套用（FOUT）或延遲載入的圖片仍是空白；
+// 零值同時等待字型與圖片
+type ReadyOptions struct {
+	// SkipFonts 不等待 document.fonts.ready
+	SkipFonts bool
+	// SkipImages 不等待圖片載入與解碼
+	SkipImages bool
+	// Timeout 最多等待的時間，逾時後照常擷取，預設 5 秒
+	Timeout time.Duration
+}
+
+// WaitReady 等待網頁字型載入完成，以及範圍內的圖片載入並解碼完成；fullPage 為 true 時檢查整頁的圖片，
+// 否則只檢查目前可視範圍。範圍內 loading="lazy" 的圖片會改為立即載入。逾時只記錄警告，不回傳錯誤
+func (t *Tab) WaitReady(opts ReadyOptions, fullPage bool) error {
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	js := fmt.Sprintf(readyJS, fullPage, !opts.SkipFonts, !opts.SkipImages, timeout.Milliseconds())
+
+	var ready bool
+	err := t.runFor(timeout+5*time.Second, chromedp.Evaluate(js, &ready, func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+		return p.WithAwaitPromise(true)
+	}))
+	if err != nil {
+		return fmt.Errorf("等待字型與圖片失敗: %w", err)
+	}
+	if !ready {
+		log.Printf("[cdpkit] 等待字型與圖片超過 %v，照常擷取", timeout)
+	}
+	return nil
+}
+
+// WaitDOMStable 等待 DOM 持續 quiet 時間（預設 500ms）沒有任何變化，用於判斷以 JS 渲染的內容是否已完成；
+// timeout 內 DOM 仍持續變化（例如輪播或計時器）時回傳錯誤
+func (t *Tab) WaitDOMStable(quiet, timeout time.Duration) error {
+	if quiet <= 0 {
+		quiet = 500 * time.Millisecond
+	}
+	if timeout <= 0 {
+		timeout = t.DefaultTimeout()
+	}
+	var stable bool
+	js := fmt.Sprintf(domStableJS, quiet.Milliseconds(), timeout.Milliseconds())
+	err := t.runFor(timeout+5*time.Second, chromedp.Evaluate(js, &stable, func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+		return p.WithAwaitPromise(true)
+	}))
+	if err != nil {
+		return fmt.Errorf("等待 DOM 穩定失敗: %w", err)
+	}
+	if !stable {
+		return fmt.Errorf("等待 DOM 穩定逾時（%v 內持續變化）", timeout)
+	}
+	return nil
+}
+
+// ----------------- 內部實作 -----------------
+
+// domStableJS 以 MutationObserver 監看整份文件，持續 quiet 毫秒沒有變化時回傳 true，超過 limit 毫秒回傳 false
+const domStableJS = `new Promise(resolve => {
+	let timer, limit;
+	const done = ok => { observer.disconnect(); clearTimeout(timer); clearTimeout(limit); resolve(ok); };
+	const observer = new MutationObserver(() => {
+		clearTimeout(timer);
+		timer = setTimeout(() => done(true), %[1]d);
+	});
+	observer.observe(document, {subtree: true, childList: true, attributes: true, characterData: true});
+	timer = setTimeout(() => done(true), %[1]d);
+	limit = setTimeout(() => done(false), %[2]d);
+})`
+
+// readyJS 等待字型與圖片，最後再等兩個影格讓解碼後的圖片完成繪製；逾時時回傳 false
+const readyJS = `(async (full, fonts, images, limit) => {
+	const tasks = [];
+	if (fonts && document.fonts) tasks.push(document.fonts.ready);
+	if (images) {
+		const inView = r => r.bottom > 0 && r.right > 0 && r.top < innerHeight && r.left < innerWidth;
+		for (const img of Array.from(document.images)) {
+			// display: none 的圖片不會出現在畫面上
+			if (img.getClientRects().length === 0) continue;
+			if (!full && !inView(img.getBoundingClientRect())) continue;
+			if (img.loading === 'lazy') img.loading = 'eager';
+			const loaded = img.complete ? Promise.resolve() : new Promise(resolve => {
+				img.addEventListener('load', resolve, {once: true});
+				img.addEventListener('error', resolve, {once: true});
+			});
+			tasks.push(loaded.then(() => img.naturalWidth ? img.decode() : null).catch(() => null));
+		}
+	}
+	const frames = () => new Promise(resolve => requestAnimationFrame(() => requestAnimationFrame(resolve)));
+	const timeout = new Promise(resolve => setTimeout(() => resolve(false), limit));
+	return Promise.race([Promise.all(tasks).then(frames).then(() => true), timeout]);
+})(%t, %t, %t, %d)`
+
+// waitReadyBefore 擷取前依 opts 等待；失敗只記錄警告，仍照常擷取
+func (t *Tab) waitReadyBefore(opts *ReadyOptions, fullPage bool) {
+	if opts == nil {
+		return
+	}
+	if err := t.WaitReady(*opts, fullPage); err != nil {
+		log.Printf("[cdpkit] 警告: %v", err)
+	}
+}
@@ -0,0 +1,194 @@
+package tab
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RecordingOptions StartScreencast 的設定；Path 與 OnFrame 至少須設定一個
+type RecordingOptions struct {
+	ScreencastOptions
+	// Path 輸出檔案，副檔名決定格式：.mjpeg、.mjpg 為串接的 JPEG（以 ffplay -f mjpeg -framerate 10 播放），
+	// .webm 交由 PATH 中的 ffmpeg 編碼為 VP8；留空時只呼叫 OnFrame
+	Path string
+	// FPS 檔案的影格率，預設 10。Chrome 只在畫面變化時送出影格，其間重複上一格，讓播放的時間與實際相同
+	FPS int
+	// OnFrame 每個影格的回呼，at 為收到影格的時間；不應阻塞
+	OnFrame func(frame []byte, at time.Time)
+}
+
+// ScreencastRecording StartScreencast 開始的錄影，以 Stop 結束並寫完檔案
+type ScreencastRecording struct {
+	stop    func()
+	out     io.WriteCloser
+	cmd     *exec.Cmd
+	stderr  *bytes.Buffer
+	onFrame func([]byte, time.Time)
+
+	mu     sync.Mutex
+	last   []byte
+	frames int
+	err    error
+
+	quit     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+	stopErr  error
+}
+
+// StartScreencast 以 Page.startScreencast 錄下分頁畫面，寫入 MJPEG 或 WebM 檔案並／或交給 OnFrame，
+// 供重播不穩定的爬取流程、找出失敗的步驟。與 Screencast 的其他訂閱（例如即時畫面）共用 Chrome 的串流
+func (t *Tab) StartScreencast(opts RecordingOptions) (*ScreencastRecording, error) {
+	if opts.Path == "" && opts.OnFrame == nil {
+		return nil, fmt.Errorf("錄影須指定 Path 或 OnFrame")
+	}
+	fps := opts.FPS
+	if fps <= 0 {
+		fps = 10
+	}
+	r := &ScreencastRecording{onFrame: opts.OnFrame, quit: make(chan struct{}), done: make(chan struct{})}
+	if opts.Path != "" {
+		if err := r.open(opts.Path, fps); err != nil {
+			return nil, err
+		}
+	}
+
+	stop, err := t.Screencast(opts.ScreencastOptions, r.add)
+	if err != nil {
+		r.closeOutput()
+		return nil, err
+	}
+	r.stop = stop
+	if r.out == nil {
+		close(r.done)
+		return r, nil
+	}
+	go r.writeLoop(t, time.Second/time.Duration(fps))
+	return r, nil
+}
+
+// Frames 回傳收到的影格數
+func (r *ScreencastRecording) Frames() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.frames
+}
+
+// Stop 停止錄影並寫完檔案；回傳寫入或 ffmpeg 編碼的錯誤。可重複呼叫
+func (r *ScreencastRecording) Stop() error {
+	r.stopOnce.Do(func() {
+		r.stop()
+		close(r.quit)
+		<-r.done
+		r.mu.Lock()
+		err := r.err
+		r.mu.Unlock()
+		if cerr := r.closeOutput(); err == nil {
+			err = cerr
+		}
+		r.stopErr = err
+	})
+	return r.stopErr
+}
+
+// ----------------- 內部實作 -----------------
+
+// open 依副檔名建立輸出：MJPEG 直接寫檔，WebM 寫入 ffmpeg 的標準輸入
+func (r *ScreencastRecording) open(path string, fps int) error {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".mjpeg", ".mjpg":
+		f, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("建立錄影檔失敗: %w", err)
+		}
+		r.out = f
+	case ".webm":
+		ffmpeg, err := exec.LookPath("ffmpeg")
+		if err != nil {
+			return fmt.Errorf("輸出 WebM 需要 ffmpeg: %w", err)
+		}
+		rate := strconv.Itoa(fps)
+		cmd := exec.Command(ffmpeg, "-y", "-loglevel", "error",
+			"-f", "mjpeg", "-framerate", rate, "-i", "-",
+			// VP8 的 yuv420p 需要偶數的寬高
+			"-vf", "scale=trunc(iw/2)*2:trunc(ih/2)*2", "-pix_fmt", "yuv420p",
+			"-c:v", "libvpx", "-deadline", "realtime", "-cpu-used", "8", "-b:v", "1M", "-r", rate,
+			path)
+		r.stderr = &bytes.Buffer{}
+		cmd.Stderr = r.stderr
+		in, err := cmd.StdinPipe()
+		if err != nil {
+			return fmt.Errorf("啟動 ffmpeg 失敗: %w", err)
+		}
+		if err := cmd.Start(); err != nil {
+			return fmt.Errorf("啟動 ffmpeg 失敗: %w", err)
+		}
+		r.out, r.cmd = in, cmd
+	default:
+		return fmt.Errorf("不支援的錄影格式 %q，請使用 .mjpeg 或 .webm", ext)
+	}
+	return nil
+}
+
+// add 記錄最新的影格並交給 OnFrame
+func (r *ScreencastRecording) add(frame []byte) {
+	at := time.Now()
+	r.mu.Lock()
+	r.last = frame
+	r.frames++
+	r.mu.Unlock()
+	if r.onFrame != nil {
+		r.onFrame(frame, at)
+	}
+}
+
+// writeLoop 依影格率寫入最新的影格，直到 Stop 或分頁關閉；收到第一格前不寫入
+func (r *ScreencastRecording) writeLoop(t *Tab, interval time.Duration) {
+	defer close(r.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.quit:
+			return
+		case <-t.Ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		r.mu.Lock()
+		frame, failed := r.last, r.err != nil
+		r.mu.Unlock()
+		if frame == nil || failed {
+			continue
+		}
+		if _, err := r.out.Write(frame); err != nil {
+			r.mu.Lock()
+			r.err = fmt.Errorf("寫入錄影檔失敗: %w", err)
+			r.mu.Unlock()
+		}
+	}
+}
+
+// closeOutput 關閉檔案或 ffmpeg 的輸入並等待編碼完成
+func (r *ScreencastRecording) closeOutput() error {
+	if r.out == nil {
+		return nil
+	}
+	err := r.out.Close()
+	if r.cmd != nil {
+		if werr := r.cmd.Wait(); werr != nil {
+			msg := strings.TrimSpace(r.stderr.String())
+			err = errors.Join(err, fmt.Errorf("ffmpeg 編碼失敗: %w: %s", werr, msg))
+		}
+	}
+	return err
+}
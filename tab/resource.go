@@ -0,0 +1,109 @@
+package tab
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	cdpio "github.com/chromedp/cdproto/io"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"github.com/firehourse/cdpkit/audit"
+)
+
+// Resource LoadResource 取得的回應
+type Resource struct {
+	URL     string
+	Status  int
+	Headers map[string]string
+	Body    []byte
+}
+
+// ContentType 回傳 Content-Type 標頭
+func (r *Resource) ContentType() string {
+	for k, v := range r.Headers {
+		if strings.EqualFold(k, "Content-Type") {
+			return v
+		}
+	}
+	return ""
+}
+
+// LoadResource 以瀏覽器的網路堆疊（代理、TLS、HTTP/2 與 cookies 皆與分頁相同）取得 url 的內容，
+// 不導航、不建立 DOM 也不渲染，適合直接取得 JSON API 等不需要渲染的網址。
+// cookies 依瀏覽器對目前分頁文件發出的請求的規則附帶；網路錯誤回傳 *NetError
+func (t *Tab) LoadResource(url string, timeout time.Duration) (*Resource, error) {
+	if timeout <= 0 {
+		timeout = t.DefaultTimeout()
+	}
+	if err := t.Policy.AllowURL(url); err != nil {
+		t.audit(audit.ActionNavigate, url, err.Error())
+		return nil, err
+	}
+
+	log.Printf("[cdpkit] 取得資源: %s", url)
+	res := &Resource{URL: url}
+	err := t.runFor(timeout, chromedp.ActionFunc(func(ctx context.Context) error {
+		frame := cdp.FrameID(chromedp.FromContext(ctx).Target.TargetID)
+		r, err := network.LoadNetworkResource(url, &network.LoadNetworkResourceOptions{IncludeCredentials: true}).
+			WithFrameID(frame).Do(ctx)
+		if err != nil {
+			return err
+		}
+		if !r.Success {
+			if r.NetErrorName != "" {
+				return &NetError{URL: url, Code: strings.TrimPrefix(r.NetErrorName, "net::")}
+			}
+			if r.HTTPStatusCode == 0 {
+				return errors.New("沒有回應")
+			}
+		}
+		res.Status = int(r.HTTPStatusCode)
+		res.Headers = make(map[string]string, len(r.Headers))
+		for k, v := range r.Headers {
+			res.Headers[k] = fmt.Sprintf("%v", v)
+		}
+		if r.Stream == "" {
+			return nil
+		}
+		res.Body, err = readStream(ctx, r.Stream)
+		return err
+	}))
+	if err != nil {
+		err = netError(t.wrapErr(err), url)
+		t.audit(audit.ActionNavigate, url, err.Error())
+		return nil, fmt.Errorf("取得 %s 失敗: %w", url, err)
+	}
+	t.audit(audit.ActionNavigate, url, "")
+	return res, nil
+}
+
+// ----------------- 內部實作 -----------------
+
+// readStream 讀完 IO 串流後關閉；cdproto 的 IO.read 不回傳是否為 base64，須自行解析回傳值
+func readStream(ctx context.Context, handle cdpio.StreamHandle) ([]byte, error) {
+	defer cdpio.Close(handle).Do(ctx)
+	var body []byte
+	for {
+		var res cdpio.ReadReturns
+		if err := cdp.Execute(ctx, cdpio.CommandRead, cdpio.Read(handle).WithSize(1<<20), &res); err != nil {
+			return nil, err
+		}
+		chunk := []byte(res.Data)
+		if res.Base64encoded {
+			var err error
+			if chunk, err = base64.StdEncoding.DecodeString(res.Data); err != nil {
+				return nil, err
+			}
+		}
+		body = append(body, chunk...)
+		if res.EOF {
+			return body, nil
+		}
+	}
+}
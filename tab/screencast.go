@@ -0,0 +1,124 @@
+package tab
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// ScreencastOptions 畫面串流設定；零值欄位使用預設值
+type ScreencastOptions struct {
+	// Quality JPEG 品質 1-100，預設 60
+	Quality int
+	// MaxWidth、MaxHeight 影格尺寸上限，預設使用 viewport 尺寸
+	MaxWidth  int
+	MaxHeight int
+	// EveryNthFrame 每 N 個畫面送出一格，預設 1
+	EveryNthFrame int
+}
+
+// Screencast 開始以 JPEG 串流分頁畫面，每個影格呼叫一次 fn，回傳的 stop 取消此訂閱。
+// 同一分頁的多個訂閱共用 Chrome 的 screencast，設定以第一個訂閱為準，最後一個停止時才結束。
+// Chrome 只在畫面變化時送出影格，新訂閱會先收到最近一格；fn 不應阻塞
+func (t *Tab) Screencast(opts ScreencastOptions, fn func(frame []byte)) (stop func(), err error) {
+	if t.Ctx == nil {
+		return nil, fmt.Errorf("分頁已關閉")
+	}
+	ctx := t.Ctx
+
+	t.mu.Lock()
+	if t.casts == nil {
+		t.casts = make(map[int]func([]byte))
+	}
+	t.castSeq++
+	id := t.castSeq
+	first := len(t.casts) == 0
+	t.casts[id] = fn
+	last := t.lastFrame
+	if !t.castListening {
+		t.castListening = true
+		chromedp.ListenTarget(ctx, func(ev interface{}) { t.onScreencastFrame(ctx, ev) })
+	}
+	t.mu.Unlock()
+
+	var once sync.Once
+	stop = func() {
+		once.Do(func() {
+			t.mu.Lock()
+			delete(t.casts, id)
+			empty := len(t.casts) == 0
+			if empty {
+				t.lastFrame = nil
+			}
+			t.mu.Unlock()
+			if empty && ctx.Err() == nil {
+				stopCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+				defer cancel()
+				chromedp.Run(stopCtx, page.StopScreencast())
+			}
+		})
+	}
+
+	if first {
+		if err := t.run(startScreencast(opts)); err != nil {
+			stop()
+			return nil, fmt.Errorf("無法開始畫面串流: %w", err)
+		}
+	} else if last != nil {
+		fn(last)
+	}
+	return stop, nil
+}
+
+// onScreencastFrame 將影格分送給所有訂閱並回覆 ack，Chrome 收到 ack 後才送出下一格
+func (t *Tab) onScreencastFrame(ctx context.Context, ev interface{}) {
+	e, ok := ev.(*page.EventScreencastFrame)
+	if !ok {
+		return
+	}
+	frame, err := base64.StdEncoding.DecodeString(e.Data)
+	t.mu.Lock()
+	if err == nil {
+		t.lastFrame = frame
+	}
+	fns := make([]func([]byte), 0, len(t.casts))
+	for _, fn := range t.casts {
+		fns = append(fns, fn)
+	}
+	t.mu.Unlock()
+
+	// 監聽器中不能阻塞，另開 goroutine 分送並回覆
+	go func() {
+		if err == nil {
+			for _, fn := range fns {
+				fn(frame)
+			}
+		}
+		if ctx.Err() == nil {
+			chromedp.Run(ctx, page.ScreencastFrameAck(e.SessionID))
+		}
+	}()
+}
+
+func startScreencast(opts ScreencastOptions) chromedp.Action {
+	quality := opts.Quality
+	if quality <= 0 || quality > 100 {
+		quality = 60
+	}
+	p := page.StartScreencast().WithFormat(page.ScreencastFormatJpeg).WithQuality(int64(quality))
+	if opts.MaxWidth > 0 {
+		p = p.WithMaxWidth(int64(opts.MaxWidth))
+	}
+	if opts.MaxHeight > 0 {
+		p = p.WithMaxHeight(int64(opts.MaxHeight))
+	}
+	if opts.EveryNthFrame > 1 {
+		p = p.WithEveryNthFrame(int64(opts.EveryNthFrame))
+	}
+	return p
+}
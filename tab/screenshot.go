@@ -0,0 +1,99 @@
+package tab
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// PDFOptions PDF 的輸出選項
+type PDFOptions struct {
+	Landscape       bool
+	PrintBackground bool
+	// PaperWidth、PaperHeight 紙張尺寸（英吋），為 0 時使用 Chrome 預設的 Letter（8.5 x 11）
+	PaperWidth  float64
+	PaperHeight float64
+	// Ready 不為 nil 時先等待網頁字型與整頁的圖片就緒
+	Ready *ReadyOptions
+}
+
+// ScreenshotOptions 截圖選項
+type ScreenshotOptions struct {
+	// FullPage 擷取整頁，否則只擷取目前可視範圍
+	FullPage bool
+	// Ready 不為 nil 時先等待網頁字型與擷取範圍內的圖片就緒
+	Ready *ReadyOptions
+	// StopAnimations 擷取前先以 StopAnimations 停止動畫與影片，截圖後不恢復
+	StopAnimations bool
+}
+
+// Screenshot 擷取 PNG 截圖；fullPage 為 true 時擷取整頁，否則只擷取目前可視範圍
+func (t *Tab) Screenshot(fullPage bool) ([]byte, error) {
+	return t.ScreenshotWith(ScreenshotOptions{FullPage: fullPage})
+}
+
+// ScreenshotWith 依選項擷取 PNG 截圖
+func (t *Tab) ScreenshotWith(opts ScreenshotOptions) ([]byte, error) {
+	t.stopAnimationsBefore(opts.StopAnimations)
+	t.waitReadyBefore(opts.Ready, opts.FullPage)
+	var buf []byte
+	action := chromedp.CaptureScreenshot(&buf)
+	if opts.FullPage {
+		// quality 100 時輸出 PNG
+		action = chromedp.FullScreenshot(&buf, 100)
+	}
+	if err := t.run(action); err != nil {
+		return nil, fmt.Errorf("截圖失敗: %w", err)
+	}
+	return buf, nil
+}
+
+// PDF 以列印的方式將目前頁面輸出為 PDF；只支援 headless 模式
+func (t *Tab) PDF(opts PDFOptions) ([]byte, error) {
+	t.waitReadyBefore(opts.Ready, true)
+	var buf []byte
+	err := t.run(chromedp.ActionFunc(func(ctx context.Context) error {
+		params := page.PrintToPDF().
+			WithLandscape(opts.Landscape).
+			WithPrintBackground(opts.PrintBackground)
+		if opts.PaperWidth > 0 {
+			params = params.WithPaperWidth(opts.PaperWidth)
+		}
+		if opts.PaperHeight > 0 {
+			params = params.WithPaperHeight(opts.PaperHeight)
+		}
+		var err error
+		buf, _, err = params.Do(ctx)
+		return err
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("輸出 PDF 失敗: %w", err)
+	}
+	return buf, nil
+}
+
+// MHTML 將目前頁面連同圖片、樣式等資源保存為單一 MHTML 檔
+func (t *Tab) MHTML() ([]byte, error) {
+	data, err := t.CaptureSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(data), nil
+}
+
+// CaptureSnapshot 以 Page.captureSnapshot 取得目前頁面的 MHTML 快照，內含圖片、樣式等子資源，
+// 可直接存成 .mhtml 由瀏覽器離線開啟
+func (t *Tab) CaptureSnapshot() (string, error) {
+	var data string
+	err := t.run(chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		data, err = page.CaptureSnapshot().WithFormat(page.CaptureSnapshotFormatMhtml).Do(ctx)
+		return err
+	}))
+	if err != nil {
+		return "", fmt.Errorf("保存 MHTML 失敗: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,145 @@
+package tab
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+// 停止捲動的原因
+const (
+	ScrollStable        = "stable"
+	ScrollMaxHeight     = "max-height"
+	ScrollMaxIterations = "max-iterations"
+)
+
+// ScrollOptions ScrollToBottom 的停止條件與等待時間；零值欄位使用預設值
+type ScrollOptions struct {
+	// MaxIterations 最多捲動次數，預設 50
+	MaxIterations int
+	// MaxHeight 頁面高度（px）達到此值即停止，0 為不限
+	MaxHeight int
+	// StableRounds 連續幾次捲動後內容都沒有增加即視為到底，預設 3
+	StableRounds int
+	// ItemSelector 以符合的元素數量判斷內容是否增加；留空則以頁面高度判斷
+	ItemSelector string
+	// IdleTime 每次捲動後網路需閒置多久才視為載入完成，預設 500ms
+	IdleTime time.Duration
+	// IdleTimeout 每次等待網路閒置的上限，預設 10s；逾時仍繼續捲動
+	IdleTimeout time.Duration
+	// Wheel 以滑鼠滾輪捲動（較像真人操作但較慢）；預設直接捲到頁面底部
+	Wheel bool
+}
+
+// ScrollResult ScrollToBottom 的結果
+type ScrollResult struct {
+	Iterations int
+	// Height 最後的頁面高度；Items 最後符合 ItemSelector 的元素數
+	Height int
+	Items  int
+	// Reason 停止的原因：ScrollStable、ScrollMaxHeight 或 ScrollMaxIterations
+	Reason string
+}
+
+// ScrollToBottom 反覆捲動到頁面底部並等待網路閒置，直到內容不再增加、
+// 頁面高度達到上限或捲動次數用盡，用於載入無限捲動的列表
+func (t *Tab) ScrollToBottom(opts ScrollOptions) (ScrollResult, error) {
+	if opts.MaxIterations <= 0 {
+		opts.MaxIterations = 50
+	}
+	if opts.StableRounds <= 0 {
+		opts.StableRounds = 3
+	}
+	if opts.IdleTimeout <= 0 {
+		opts.IdleTimeout = 10 * time.Second
+	}
+
+	var res ScrollResult
+	height, items, err := t.measurePage(opts.ItemSelector)
+	if err != nil {
+		return res, err
+	}
+	res.Height, res.Items = height, items
+
+	stable := 0
+	for res.Iterations < opts.MaxIterations {
+		if opts.MaxHeight > 0 && res.Height >= opts.MaxHeight {
+			res.Reason = ScrollMaxHeight
+			break
+		}
+		if err := t.scrollDown(opts.Wheel); err != nil {
+			return res, err
+		}
+		res.Iterations++
+		if err := t.WaitNetworkIdle(opts.IdleTime, opts.IdleTimeout); err != nil {
+			if t.Ctx.Err() != nil {
+				return res, err
+			}
+			log.Printf("[cdpkit] 捲動後%v，繼續捲動", err)
+		}
+
+		height, items, err := t.measurePage(opts.ItemSelector)
+		if err != nil {
+			return res, err
+		}
+		if height == res.Height && items == res.Items {
+			stable++
+		} else {
+			stable = 0
+		}
+		res.Height, res.Items = height, items
+		if stable >= opts.StableRounds {
+			res.Reason = ScrollStable
+			break
+		}
+	}
+	if res.Reason == "" {
+		res.Reason = ScrollMaxIterations
+		if opts.MaxHeight > 0 && res.Height >= opts.MaxHeight {
+			res.Reason = ScrollMaxHeight
+		}
+	}
+	log.Printf("[cdpkit] 捲動 %d 次後停止 (%s)，頁面高度 %dpx", res.Iterations, res.Reason, res.Height)
+	return res, nil
+}
+
+// scrollDown 捲動到頁面底部
+func (t *Tab) scrollDown(wheel bool) error {
+	if !wheel {
+		if err := t.run(chromedp.Evaluate(
+			`window.scrollTo(0, document.scrollingElement.scrollHeight)`, nil)); err != nil {
+			return fmt.Errorf("捲動失敗: %w", err)
+		}
+		return nil
+	}
+	var remaining float64
+	if err := t.run(chromedp.Evaluate(
+		`document.scrollingElement.scrollHeight - window.scrollY - window.innerHeight`, &remaining)); err != nil {
+		return fmt.Errorf("捲動失敗: %w", err)
+	}
+	if remaining <= 0 {
+		// 已在底部時仍小幅捲動，觸發依滾輪事件載入的頁面
+		remaining = 120
+	}
+	return t.Scroll(0, remaining)
+}
+
+// measurePage 回傳頁面高度與符合 itemSelector 的元素數
+func (t *Tab) measurePage(itemSelector string) (int, int, error) {
+	items := "0"
+	if itemSelector != "" {
+		items = t.queryAllJS(itemSelector) + ".length"
+	}
+	var m []int
+	err := t.run(chromedp.Evaluate(fmt.Sprintf(
+		`[document.scrollingElement.scrollHeight, %s]`, items), &m))
+	if err != nil {
+		return 0, 0, fmt.Errorf("取得頁面高度失敗: %w", err)
+	}
+	if len(m) != 2 {
+		return 0, 0, fmt.Errorf("取得頁面高度失敗: 非預期的結果 %v", m)
+	}
+	return m[0], m[1], nil
+}
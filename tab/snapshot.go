@@ -0,0 +1,355 @@
+package tab
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/chromedp/cdproto/accessibility"
+	"github.com/chromedp/cdproto/domsnapshot"
+	"github.com/chromedp/chromedp"
+)
+
+// DefaultSnapshotStyles SnapshotOptions.Styles 為空時取得的計算樣式，足以判斷可見性與文字層級
+var DefaultSnapshotStyles = []string{"display", "visibility", "opacity", "position", "font-size", "font-weight", "color", "background-color"}
+
+// SnapshotOptions DOMSnapshot 的選項
+type SnapshotOptions struct {
+	// Styles 每個節點要取得的計算樣式名稱；為空時使用 DefaultSnapshotStyles
+	Styles []string
+	// PaintOrder 是否記錄繪製順序，可判斷元素是否被其他元素覆蓋
+	PaintOrder bool
+	// StopAnimations 快照前先以 StopAnimations 停止動畫，避免位置與樣式停在動畫中途
+	StopAnimations bool
+}
+
+// DOMSnapshot 頁面的 DOM 快照：節點樹連同版面位置與計算樣式，iframe 的文件掛在所屬節點的 ContentDocument
+type DOMSnapshot struct {
+	URL           string   `json:"url"`
+	Title         string   `json:"title,omitempty"`
+	ContentWidth  float64  `json:"content_width"`
+	ContentHeight float64  `json:"content_height"`
+	Root          *DOMNode `json:"root"`
+}
+
+// DOMNode DOM 快照中的一個節點
+type DOMNode struct {
+	// NodeType DOM 的 nodeType，1 為元素、3 為文字、9 為文件
+	NodeType int `json:"node_type"`
+	// Name nodeName，例如 "DIV"、"#text"
+	Name string `json:"name"`
+	// Value 文字與註解節點的內容
+	Value         string            `json:"value,omitempty"`
+	Attributes    map[string]string `json:"attributes,omitempty"`
+	BackendNodeID int64             `json:"backend_node_id"`
+	// InputValue input 與 textarea 目前的值；Checked 核取方塊、選項是否選取
+	InputValue string `json:"input_value,omitempty"`
+	Checked    bool   `json:"checked,omitempty"`
+	// Clickable 是否為連結或註冊了 click 事件
+	Clickable bool `json:"clickable,omitempty"`
+	// Bounds 版面的絕對位置（CSS 像素）；沒有版面物件（例如 display:none 或 <head>）時為 nil
+	Bounds *Rect `json:"bounds,omitempty"`
+	// Styles 依 SnapshotOptions.Styles 取得的計算樣式；沒有版面物件時為 nil
+	Styles map[string]string `json:"styles,omitempty"`
+	// PaintOrder 繪製順序，數值大者在上層；需啟用 SnapshotOptions.PaintOrder
+	PaintOrder int64 `json:"paint_order,omitempty"`
+	// ContentDocument iframe 內的文件
+	ContentDocument *DOMNode   `json:"content_document,omitempty"`
+	Children        []*DOMNode `json:"children,omitempty"`
+}
+
+// Rect 矩形範圍
+type Rect struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// AXTreeOptions AccessibilityTree 的選項
+type AXTreeOptions struct {
+	// Depth 最大深度，0 表示整棵樹
+	Depth int
+	// IncludeIgnored 是否保留無障礙樹忽略的節點（例如純排版用的 div）；
+	// false 時略過這些節點，其子節點直接接到上層
+	IncludeIgnored bool
+}
+
+// AXNode 無障礙樹的一個節點
+type AXNode struct {
+	Role        string `json:"role,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	Value       string `json:"value,omitempty"`
+	Ignored     bool   `json:"ignored,omitempty"`
+	// Properties 其餘屬性，例如 level、checked、focusable、url
+	Properties map[string]interface{} `json:"properties,omitempty"`
+	// BackendNodeID 對應的 DOM 節點，與 DOMNode.BackendNodeID 相同；沒有對應節點時為 0
+	BackendNodeID int64     `json:"backend_node_id,omitempty"`
+	Children      []*AXNode `json:"children,omitempty"`
+}
+
+// DOMSnapshot 以 DOMSnapshot.captureSnapshot 擷取整個頁面（含 iframe）的節點樹、版面位置與計算樣式，
+// 供內容分類、可讀性擷取等需要比原始 HTML 更多資訊的處理使用；shadow DOM 會攤平到所屬元素之下
+func (t *Tab) DOMSnapshot(opts SnapshotOptions) (*DOMSnapshot, error) {
+	styles := opts.Styles
+	if len(styles) == 0 {
+		styles = DefaultSnapshotStyles
+	}
+	t.stopAnimationsBefore(opts.StopAnimations)
+	var (
+		docs  []*domsnapshot.DocumentSnapshot
+		table []string
+	)
+	err := t.run(chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		docs, table, err = domsnapshot.CaptureSnapshot(styles).WithIncludePaintOrder(opts.PaintOrder).Do(ctx)
+		return err
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("擷取 DOM 快照失敗: %w", err)
+	}
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("擷取 DOM 快照失敗: 沒有文件")
+	}
+
+	str := func(i domsnapshot.StringIndex) string {
+		if i < 0 || int(i) >= len(table) {
+			return ""
+		}
+		return table[i]
+	}
+	roots := make([]*DOMNode, len(docs))
+	nodes := make([][]*DOMNode, len(docs))
+	for i, d := range docs {
+		nodes[i] = buildDOMTree(d, styles, str)
+		if len(nodes[i]) > 0 {
+			roots[i] = nodes[i][0]
+		}
+	}
+	// iframe 節點記錄其文件在 docs 中的索引
+	for i, d := range docs {
+		if d.Nodes == nil || d.Nodes.ContentDocumentIndex == nil {
+			continue
+		}
+		r := d.Nodes.ContentDocumentIndex
+		for j, n := range r.Index {
+			if j < len(r.Value) && int(n) < len(nodes[i]) && int(r.Value[j]) < len(roots) && int(r.Value[j]) != i {
+				nodes[i][n].ContentDocument = roots[r.Value[j]]
+			}
+		}
+	}
+
+	d := docs[0]
+	return &DOMSnapshot{
+		URL:           str(d.DocumentURL),
+		Title:         str(d.Title),
+		ContentWidth:  d.ContentWidth,
+		ContentHeight: d.ContentHeight,
+		Root:          roots[0],
+	}, nil
+}
+
+// AccessibilityTree 以 Accessibility.getFullAXTree 取得主框架的無障礙樹，
+// 包含瀏覽器計算出的角色與可存取名稱，比 HTML 更貼近使用者實際看到的語意結構
+func (t *Tab) AccessibilityTree(opts AXTreeOptions) (*AXNode, error) {
+	var nodes []*accessibility.Node
+	err := t.run(chromedp.ActionFunc(func(ctx context.Context) error {
+		p := accessibility.GetFullAXTree()
+		if opts.Depth > 0 {
+			p = p.WithDepth(int64(opts.Depth))
+		}
+		var err error
+		nodes, err = p.Do(ctx)
+		return err
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("取得無障礙樹失敗: %w", err)
+	}
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("取得無障礙樹失敗: 沒有節點")
+	}
+
+	byID := make(map[accessibility.NodeID]*accessibility.Node, len(nodes))
+	for _, n := range nodes {
+		byID[n.NodeID] = n
+	}
+	var build func(n *accessibility.Node, depth int) []*AXNode
+	build = func(n *accessibility.Node, depth int) []*AXNode {
+		var children []*AXNode
+		if depth < 1000 {
+			for _, id := range n.ChildIDs {
+				if c, ok := byID[id]; ok {
+					children = append(children, build(c, depth+1)...)
+				}
+			}
+		}
+		if n.Ignored && !opts.IncludeIgnored {
+			return children
+		}
+		ax := toAXNode(n)
+		ax.Children = children
+		return []*AXNode{ax}
+	}
+
+	// 根節點為沒有 parentId 的第一個節點
+	root := nodes[0]
+	for _, n := range nodes {
+		if n.ParentID == "" {
+			root = n
+			break
+		}
+	}
+	tree := build(root, 0)
+	switch len(tree) {
+	case 0:
+		return nil, fmt.Errorf("取得無障礙樹失敗: 所有節點都被忽略")
+	case 1:
+		return tree[0], nil
+	default:
+		// 根節點被忽略時以第一層子節點組成虛擬根節點
+		return &AXNode{Role: "RootWebArea", Children: tree}, nil
+	}
+}
+
+// ----------------- 內部實作 -----------------
+
+// buildDOMTree 將 DOMSnapshot 的扁平表格還原為樹狀結構，回傳依快照索引排列的節點，第一個為文件節點
+func buildDOMTree(d *domsnapshot.DocumentSnapshot, styles []string, str func(domsnapshot.StringIndex) string) []*DOMNode {
+	t := d.Nodes
+	if t == nil || len(t.NodeType) == 0 {
+		return nil
+	}
+	nodes := make([]*DOMNode, len(t.NodeType))
+	for i := range nodes {
+		n := &DOMNode{NodeType: int(t.NodeType[i])}
+		if i < len(t.NodeName) {
+			n.Name = str(t.NodeName[i])
+		}
+		if i < len(t.NodeValue) {
+			n.Value = str(t.NodeValue[i])
+		}
+		if i < len(t.BackendNodeID) {
+			n.BackendNodeID = int64(t.BackendNodeID[i])
+		}
+		if i < len(t.Attributes) && len(t.Attributes[i]) > 0 {
+			attrs := t.Attributes[i]
+			n.Attributes = make(map[string]string, len(attrs)/2)
+			for j := 0; j+1 < len(attrs); j += 2 {
+				n.Attributes[str(domsnapshot.StringIndex(attrs[j]))] = str(domsnapshot.StringIndex(attrs[j+1]))
+			}
+		}
+		nodes[i] = n
+	}
+
+	rareString(t.InputValue, len(nodes), str, func(i int, v string) { nodes[i].InputValue = v })
+	rareString(t.TextValue, len(nodes), str, func(i int, v string) { nodes[i].InputValue = v })
+	rareBool(t.InputChecked, len(nodes), func(i int) { nodes[i].Checked = true })
+	rareBool(t.OptionSelected, len(nodes), func(i int) { nodes[i].Checked = true })
+	rareBool(t.IsClickable, len(nodes), func(i int) { nodes[i].Clickable = true })
+
+	if l := d.Layout; l != nil {
+		for j, i := range l.NodeIndex {
+			if i < 0 || int(i) >= len(nodes) {
+				continue
+			}
+			n := nodes[i]
+			if j < len(l.Bounds) && len(l.Bounds[j]) == 4 {
+				b := l.Bounds[j]
+				n.Bounds = &Rect{X: b[0], Y: b[1], Width: b[2], Height: b[3]}
+			}
+			if j < len(l.Styles) && len(l.Styles[j]) > 0 {
+				n.Styles = make(map[string]string, len(styles))
+				for k, s := range l.Styles[j] {
+					if k < len(styles) {
+						n.Styles[styles[k]] = str(domsnapshot.StringIndex(s))
+					}
+				}
+			}
+			if j < len(l.PaintOrders) {
+				n.PaintOrder = l.PaintOrders[j]
+			}
+		}
+	}
+
+	for i, n := range nodes {
+		if i < len(t.ParentIndex) {
+			if p := t.ParentIndex[i]; p >= 0 && int(p) < len(nodes) && int(p) != i {
+				nodes[p].Children = append(nodes[p].Children, n)
+			}
+		}
+	}
+	return nodes
+}
+
+// rareString 套用只出現在少數節點的字串資料，索引超出 n 的項目略過
+func rareString(r *domsnapshot.RareStringData, n int, str func(domsnapshot.StringIndex) string, set func(i int, v string)) {
+	if r == nil {
+		return
+	}
+	for j, i := range r.Index {
+		if j < len(r.Value) && i >= 0 && int(i) < n {
+			set(int(i), str(r.Value[j]))
+		}
+	}
+}
+
+func rareBool(r *domsnapshot.RareBooleanData, n int, set func(i int)) {
+	if r == nil {
+		return
+	}
+	for _, i := range r.Index {
+		if i >= 0 && int(i) < n {
+			set(int(i))
+		}
+	}
+}
+
+// toAXNode 轉換單一節點，不含子節點
+func toAXNode(n *accessibility.Node) *AXNode {
+	ax := &AXNode{
+		Role:          axString(n.Role),
+		Name:          axString(n.Name),
+		Description:   axString(n.Description),
+		Value:         axString(n.Value),
+		Ignored:       n.Ignored,
+		BackendNodeID: int64(n.BackendDOMNodeID),
+	}
+	for _, p := range n.Properties {
+		if p == nil || p.Value == nil {
+			continue
+		}
+		if ax.Properties == nil {
+			ax.Properties = map[string]interface{}{}
+		}
+		ax.Properties[p.Name.String()] = axValue(p.Value)
+	}
+	return ax
+}
+
+// axString 將 AX 值轉為字串；非字串值以 JSON 表示
+func axString(v *accessibility.Value) string {
+	if v == nil {
+		return ""
+	}
+	switch x := axValue(v).(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	default:
+		b, _ := json.Marshal(x)
+		return string(b)
+	}
+}
+
+func axValue(v *accessibility.Value) interface{} {
+	if len(v.Value) == 0 {
+		return nil
+	}
+	var x interface{}
+	if err := json.Unmarshal(v.Value, &x); err != nil {
+		return string(v.Value)
+	}
+	return x
+}
@@ -0,0 +1,168 @@
+package tab
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/storage"
+	"github.com/chromedp/chromedp"
+)
+
+// StorageState 分頁的工作階段狀態：目前網址、瀏覽器的所有 cookies 與目前來源的 Web Storage。
+// 可存成 JSON 檔，在其他分頁或其他瀏覽器以 ImportState 還原登入狀態
+type StorageState struct {
+	URL     string            `json:"url"`
+	Cookies []*network.Cookie `json:"cookies"`
+	// Origins 各來源的 localStorage 與 sessionStorage；ExportState 只含目前頁面的來源
+	Origins []OriginStorage `json:"origins,omitempty"`
+}
+
+// OriginStorage 單一來源的 Web Storage
+type OriginStorage struct {
+	Origin         string            `json:"origin"`
+	LocalStorage   map[string]string `json:"localStorage,omitempty"`
+	SessionStorage map[string]string `json:"sessionStorage,omitempty"`
+}
+
+// LoadState 讀取 SaveFile 存的狀態檔
+func LoadState(path string) (*StorageState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("無法讀取狀態檔 %s: %w", path, err)
+	}
+	var s StorageState
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("無法解析狀態檔 %s: %w", path, err)
+	}
+	return &s, nil
+}
+
+// SaveFile 將狀態寫入 path；內容含登入憑證，檔案權限為 0600
+func (s *StorageState) SaveFile(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+const exportStorageJS = `(() => {
+	const dump = (store) => {
+		const out = {};
+		try {
+			for (let i = 0; i < store.length; i++) {
+				const k = store.key(i);
+				out[k] = store.getItem(k);
+			}
+		} catch (e) {}
+		return out;
+	};
+	let local = {}, session = {};
+	try { local = dump(window.localStorage); } catch (e) {}
+	try { session = dump(window.sessionStorage); } catch (e) {}
+	return JSON.stringify({url: location.href, origin: location.origin, localStorage: local, sessionStorage: session});
+})()`
+
+// ExportState 匯出目前的網址、cookies 與目前來源的 Web Storage
+func (t *Tab) ExportState() (*StorageState, error) {
+	var raw string
+	if err := t.run(chromedp.Evaluate(exportStorageJS, &raw)); err != nil {
+		return nil, fmt.Errorf("讀取 Web Storage 失敗: %w", err)
+	}
+	var page struct {
+		URL string `json:"url"`
+		OriginStorage
+	}
+	if err := json.Unmarshal([]byte(raw), &page); err != nil {
+		return nil, fmt.Errorf("解析 Web Storage 失敗: %w", err)
+	}
+
+	var cookies []*network.Cookie
+	err := t.run(chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		cookies, err = storage.GetCookies().Do(ctx)
+		return err
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("取得 cookies 失敗: %w", err)
+	}
+
+	s := &StorageState{URL: page.URL, Cookies: cookies}
+	// 空白頁等不透明來源沒有可還原的 storage
+	if page.Origin != "" && page.Origin != "null" {
+		s.Origins = []OriginStorage{page.OriginStorage}
+	}
+	return s, nil
+}
+
+// ImportState 還原狀態：設定 cookies 後導航至 s.URL，若該來源有 Web Storage 則寫入後重新載入。
+// 導航同樣受 Policy 限制
+func (t *Tab) ImportState(s *StorageState) error {
+	params := make([]*network.CookieParam, 0, len(s.Cookies))
+	for _, c := range s.Cookies {
+		p := &network.CookieParam{
+			Name:         c.Name,
+			Value:        c.Value,
+			Domain:       c.Domain,
+			Path:         c.Path,
+			Secure:       c.Secure,
+			HTTPOnly:     c.HTTPOnly,
+			SameSite:     c.SameSite,
+			Priority:     c.Priority,
+			SourceScheme: c.SourceScheme,
+			SourcePort:   c.SourcePort,
+			PartitionKey: c.PartitionKey,
+		}
+		if !c.Session && c.Expires > 0 {
+			sec := int64(c.Expires)
+			exp := cdp.TimeSinceEpoch(time.Unix(sec, int64((c.Expires-float64(sec))*1e9)))
+			p.Expires = &exp
+		}
+		params = append(params, p)
+	}
+	if len(params) > 0 {
+		err := t.run(chromedp.ActionFunc(func(ctx context.Context) error {
+			return storage.SetCookies(params).Do(ctx)
+		}))
+		if err != nil {
+			return fmt.Errorf("設定 cookies 失敗: %w", err)
+		}
+	}
+	if s.URL == "" || s.URL == "about:blank" {
+		return nil
+	}
+	if err := t.Navigate(s.URL, 0); err != nil {
+		return err
+	}
+	if len(s.Origins) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(s.Origins)
+	if err != nil {
+		return err
+	}
+	script := fmt.Sprintf(`((origins) => {
+	const o = origins.find((o) => o.origin === location.origin);
+	if (!o) return false;
+	for (const [k, v] of Object.entries(o.localStorage || {})) localStorage.setItem(k, v);
+	for (const [k, v] of Object.entries(o.sessionStorage || {})) sessionStorage.setItem(k, v);
+	return true;
+})(%s)`, data)
+	var restored bool
+	if err := t.run(chromedp.Evaluate(script, &restored)); err != nil {
+		return fmt.Errorf("寫入 Web Storage 失敗: %w", err)
+	}
+	if restored {
+		// 讓頁面以還原後的 storage 重新初始化
+		if err := t.run(chromedp.Reload()); err != nil {
+			return fmt.Errorf("重新載入失敗: %w", err)
+		}
+	}
+	return nil
+}
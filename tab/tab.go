@@ -3,15 +3,26 @@ package tab
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"math/rand"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/css"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
 	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/target"
 	"github.com/chromedp/chromedp"
+	"github.com/firehourse/cdpkit/audit"
 	"github.com/firehourse/cdpkit/browser"
 	"github.com/firehourse/cdpkit/config"
+	"github.com/firehourse/cdpkit/devices"
+	"github.com/firehourse/cdpkit/policy"
+	"github.com/firehourse/cdpkit/stealth"
 )
 
 // Go 1.20+ 不需要手動設置種子，但為了兼容性保留初始化
@@ -27,6 +38,10 @@ func init() {
 	}
 }
 
+// ErrPolicyNotEnforced 設定了網域允許清單，但無法在網路層啟用攔截；
+// 此時分頁拒絕導航與所有操作，不會在沒有防護的情況下繼續，可用 errors.Is 判斷
+var ErrPolicyNotEnforced = errors.New("無法啟用網域允許清單")
+
 // Tab 包裹單一 chromedp Context 與輔助方法
 type Tab struct {
 	Ctx     context.Context
@@ -35,30 +50,162 @@ type Tab struct {
 	// 追踪分頁狀態
 	IsNavigating bool
 	CurrentURL   string
+	// Audit 若不為 nil，導航、腳本執行等操作都會寫入稽核紀錄；設定後第一次 Navigate 起，下載的開始與結束也會寫入
+	Audit *audit.Log
+	// Policy 合規防護；由 NewTab 依 config 設定，於網路層阻擋允許清單外的導航
+	Policy *policy.Policy
+	// policyErr 允許清單無法啟用時的錯誤，之後的所有操作都回傳此錯誤；只在 Open 中設定
+	policyErr error
+	// Input Click、Type 等輸入模擬的時間參數
+	Input InputOptions
+	// DeepQuery 為 true 時選擇器會搜尋所有開放的 shadow root；
+	// 未啟用時也可用 ">>>" 明確指定穿透位置，例如 "my-app >>> .price"
+	DeepQuery bool
+	// Waiting WaitVisible、WaitAny 等等待元素時的輪詢間隔與是否以 MutationObserver 監看
+	Waiting WaitOptions
+	// HangTimeout 操作期間超過此時間沒有任何 CDP 事件且分頁無法回應探測時，強制關閉分頁並回傳
+	// ErrRendererHung；0 表示停用看門狗
+	HangTimeout time.Duration
+
+	// lastEvent 最近一次收到 CDP 事件的時間（UnixNano），供看門狗判斷
+	lastEvent atomic.Int64
+
+	mu sync.Mutex
+	// frames 已附加的跨網域 iframe，key 為 iframe 的 target ID
+	frames map[target.ID]*Tab
+	// interceptors 已註冊的請求攔截器；fetchListening 是否已監聽 Fetch 事件
+	interceptors   []Interceptor
+	fetchListening bool
+	// credentials SetCredentials 設定的 HTTP 驗證帳密，key 為來源；authTried 已回應過帳密的請求，
+	// 再次要求驗證表示帳密錯誤，改為取消
+	credentials map[string]config.Credential
+	authTried   map[fetch.RequestID]bool
+	// meter MeterBytes 設定的流量計量；metered 各請求已計入的位元組；overBudget 預算已用盡
+	meter          func(n int64) bool
+	metered        map[network.RequestID]int64
+	overBudget     bool
+	meterListening bool
+	// responses 載入過程中記錄的網路回應
+	responses []*Response
+	// responseHandlers OnResponse 註冊的回應監聽
+	responseHandlers []responseHandler
+	// pending 進行中的請求；lastNetwork 最近一次請求開始或結束的時間
+	pending     map[network.RequestID]*pendingRequest
+	lastNetwork time.Time
+	// redirects 最近一次 Navigate 後主框架的重新導向；documentURL 主框架目前文件的網址
+	redirects   []Redirect
+	documentURL string
+	// userAgent、acceptLanguage、clientHints 目前套用的 UA 覆寫，EmulateLocale 等需要一併更新
+	userAgent      string
+	acceptLanguage string
+	clientHints    *config.ClientHints
+	// insecure IgnoreCertErrors 是否啟用，用戶端憑證的請求一併略過伺服器憑證驗證
+	insecure bool
+	// mouseX、mouseY 模擬滑鼠目前位置，移動時由此出發
+	mouseX, mouseY float64
+	// casts Screencast 的訂閱；lastFrame 最近一個影格，供新訂閱立即顯示
+	casts         map[int]func([]byte)
+	castSeq       int
+	castListening bool
+	lastFrame     []byte
+	// console CaptureConsole 記錄的訊息；consoleListening 是否已監聽 console 事件
+	console          []ConsoleMessage
+	consoleListening bool
+	// styleSheets StartCoverage 後載入的樣式表；coverageListening 是否已監聽樣式表事件
+	styleSheets       map[css.StyleSheetID]*css.StyleSheetHeader
+	coverageListening bool
+	// downloads 進行中的下載，key 為 GUID；downloadListening 是否已監聽下載事件
+	downloads         map[string]download
+	downloadListening bool
 }
 
-// New 由 BrowserManager 建立完 Context 後包裝成 Tab
-// 推薦使用 NewTab 代替，它會自動套用配置
+// New 由 BrowserManager 建立完 Context 後包裝成 Tab，不套用任何配置
+//
+// Deprecated: 請改用 cdpkit.Browser.NewTab，或以 Open 套用分層設定。
 func New(ctx context.Context, cancel context.CancelFunc, timeout time.Duration) *Tab {
-	return &Tab{
+	t := &Tab{
 		Ctx:     ctx,
 		Cancel:  cancel,
 		Timeout: timeout,
 	}
+	t.captureResponses()
+	return t
 }
 
 // NewTab 創建一個新分頁，並自動套用配置（UA、viewport、反檢測等）
+//
+// Deprecated: 請改用 cdpkit.Browser.NewTab 取得可自行關閉的分頁，或以 Open 套用分層設定。
 func NewTab(ctx context.Context, cancel context.CancelFunc, cfg config.Config) *Tab {
+	return Open(ctx, cancel, config.Split(cfg))
+}
+
+// Open 以分層設定包裝 BrowserManager 建立的分頁 context，套用分頁層（UA、viewport、反檢測、
+// 時區語系、合規防護）與請求層（預設逾時）；瀏覽器層在此不使用。
+// 分頁層設定 NoAutomationTweaks 時只套用合規防護與逾時，不做任何模擬或注入。
+// ctx 也可以是呼叫端自行以 chromedp.NewContext 建立的分頁；cancel 傳 nil 時 Close 不會關閉該分頁
+func Open(ctx context.Context, cancel context.CancelFunc, layers config.Layered) *Tab {
+	cfg := layers.Flatten()
 	t := &Tab{
-		Ctx:     ctx,
-		Cancel:  cancel,
-		Timeout: cfg.Timeout,
+		Ctx:         ctx,
+		Cancel:      cancel,
+		Timeout:     cfg.Timeout,
+		HangTimeout: cfg.HangTimeout,
+	}
+	t.captureResponses()
+
+	// 已自行設定指紋的瀏覽器：只包裝 context，不做任何覆寫或注入
+	if cfg.NoAutomationTweaks {
+		t.enforceConfigPolicy(cfg)
+		t.applyTLS(cfg)
+		t.applyCredentials(cfg)
+		log.Printf("[cdpkit] 分頁創建成功，未套用 UA、視窗與反檢測設置 (NoAutomationTweaks)")
+		return t
+	}
+
+	// 1. 準備指紋 profile、UA 和視窗尺寸
+	profileName := cfg.StealthProfile
+	if profileName == "" {
+		profileName = "legacy"
+	}
+	profile, err := stealth.Lookup(profileName)
+	if err != nil {
+		log.Printf("[cdpkit] 警告：%v，改用 legacy", err)
+		profile, _ = stealth.Lookup("legacy")
+	}
+
+	// 指定裝置時以裝置的 UA 與 viewport 為準
+	var dev *devices.Device
+	if cfg.Device != "" {
+		if d, err := devices.Lookup(cfg.Device); err != nil {
+			log.Printf("[cdpkit] 警告：%v", err)
+		} else {
+			dev = &d
+		}
 	}
 
-	// 1. 準備 UA 和視窗尺寸
 	ua := cfg.UserAgent
+	if ua == "" && dev != nil {
+		ua = dev.UserAgent
+	}
+	if ua == "" {
+		ua = profile.UserAgent
+	}
 	if ua == "" {
-		ua = randomUA()
+		ua = randomUA(cfg.Seed)
+	}
+
+	// 注入的語言、平台、vendor 與外掛跟著實際的 UA 與語系，Accept-Language 也由同一組語言產生
+	langs := localeLanguages(cfg.Locale)
+	if len(langs) == 0 {
+		langs = profile.Languages
+	}
+	profile = fitProfile(profile, ua, langs)
+	if profile.NoiseSeed == 0 {
+		profile.NoiseSeed = cfg.Seed
+	}
+	script, err := stealth.Script(profile)
+	if err != nil {
+		log.Printf("[cdpkit] 警告：%v", err)
 	}
 
 	w, h := cfg.WindowSize[0], cfg.WindowSize[1]
@@ -66,46 +213,38 @@ func NewTab(ctx context.Context, cancel context.CancelFunc, cfg config.Config) *
 		w = 1280
 		h = 720
 	}
+	viewport := chromedp.Action(chromedp.EmulateViewport(int64(w), int64(h)))
+	if dev != nil {
+		viewport = deviceMetrics(*dev)
+	}
+	t.userAgent, t.acceptLanguage, t.clientHints = ua, acceptLanguageFor(langs), cfg.ClientHints
+	lang, hints := t.acceptLanguage, t.clientHints
 
 	// 2. 一次註冊所有腳本，在每個新頁面載入時自動執行
-	err := chromedp.Run(ctx,
-		chromedp.EmulateViewport(int64(w), int64(h)),
+	err = chromedp.Run(ctx,
+		viewport,
 
-		// 設置 UA
+		// 設置 UA 與一致的 Client Hints
 		chromedp.ActionFunc(func(ctx context.Context) error {
-			return emulation.SetUserAgentOverride(ua).Do(ctx)
+			return userAgentOverride(ua, lang, hints).Do(ctx)
 		}),
 
-		// 註冊全局腳本：反檢測和其他注入
+		// 註冊全局腳本：依 profile 組合的反檢測腳本
 		chromedp.ActionFunc(func(ctx context.Context) error {
-			// 主要反檢測腳本
-			script := `
-				// 隱藏 webdriver
-				Object.defineProperty(navigator, 'webdriver', {get: () => undefined});
-				
-				// 模擬正常用戶特徵
-				Object.defineProperty(navigator, 'plugins', {get: () => [1, 2, 3, 4, 5]});
-				Object.defineProperty(navigator, 'languages', {get: () => ['zh-TW', 'zh', 'en-US', 'en']});
-				
-				// 防止自動化檢測
-				const originalQuery = window.navigator.permissions.query;
-				window.navigator.permissions.query = (parameters) => (
-					parameters.name === 'notifications' || 
-					parameters.name === 'clipboard-read' || 
-					parameters.name === 'clipboard-write' ? 
-					Promise.resolve({state: 'prompt', onchange: null}) : 
-					originalQuery(parameters)
-				);
-				
-				// 常見的反機器人檢測對象
-				delete window.cdc_adoQpoasnfa76pfcZLmcfl_Array;
-				delete window.cdc_adoQpoasnfa76pfcZLmcfl_Promise;
-				delete window.cdc_adoQpoasnfa76pfcZLmcfl_Symbol;
-			`
 			// 忽略 ScriptIdentifier 返回值，只關注錯誤
 			_, err := page.AddScriptToEvaluateOnNewDocument(script).Do(ctx)
 			return err
 		}),
+
+		// WebRTC：過濾或移除會洩漏真實 IP 的 ICE candidate
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			js := webrtcScript(cfg.WebRTC)
+			if js == "" {
+				return nil
+			}
+			_, err := page.AddScriptToEvaluateOnNewDocument(js).Do(ctx)
+			return err
+		}),
 	)
 
 	if err != nil {
@@ -114,9 +253,51 @@ func NewTab(ctx context.Context, cancel context.CancelFunc, cfg config.Config) *
 		log.Printf("[cdpkit] 分頁創建成功，已套用 UA 和反檢測設置")
 	}
 
+	// 3. 時區、語系與地理位置，讓指紋與代理所在地一致
+	if err := t.applyEmulation(cfg); err != nil {
+		log.Printf("[cdpkit] 警告：%v", err)
+	}
+
+	// 4. 合規防護
+	t.enforceConfigPolicy(cfg)
+
+	// 5. 憑證錯誤、用戶端憑證與 HTTP 驗證
+	t.applyTLS(cfg)
+	t.applyCredentials(cfg)
+
 	return t
 }
 
+// enforceConfigPolicy 合規防護：在網路層攔截所有文件請求（含轉址、JS 觸發的導航與 iframe）
+func (t *Tab) enforceConfigPolicy(cfg config.Config) {
+	if cfg.Policy == nil {
+		return
+	}
+	t.Policy = cfg.Policy
+	if err := t.enforcePolicy(); err != nil {
+		// 無法在網路層攔截時不能保證允許清單外的網址被阻擋，分頁改為拒絕所有操作
+		t.policyErr = fmt.Errorf("%w: %v", ErrPolicyNotEnforced, err)
+		log.Printf("[cdpkit] 錯誤：%v，分頁將拒絕所有操作", t.policyErr)
+	}
+}
+
+// enforcePolicy 阻擋允許清單外的文件請求；使用者腳本無法繞過網路層的攔截
+func (t *Tab) enforcePolicy() error {
+	if len(t.Policy.AllowedDomains) == 0 {
+		return nil
+	}
+	return t.AddInterceptor(func(r *PausedRequest) {
+		if r.Event.ResourceType != network.ResourceTypeDocument {
+			return
+		}
+		if err := t.Policy.AllowURL(r.Event.Request.URL); err != nil {
+			log.Printf("[cdpkit] %v", err)
+			t.audit(audit.ActionBlocked, r.Event.Request.URL, err.Error())
+			r.Block(network.ErrorReasonBlockedByClient)
+		}
+	})
+}
+
 // DefaultTimeout 取預設逾時 (fallback 30 s)
 func (t *Tab) DefaultTimeout() time.Duration {
 	if t.Timeout <= 0 {
@@ -125,25 +306,43 @@ func (t *Tab) DefaultTimeout() time.Duration {
 	return t.Timeout
 }
 
-// Navigate 前往 URL
+// Navigate 前往 URL；Chromium 回報網路錯誤時回傳 *NetError，可用 errors.Is(err, ErrDNS) 等判斷分類
 func (t *Tab) Navigate(url string, timeout time.Duration) error {
 	if timeout <= 0 {
 		timeout = t.DefaultTimeout()
 	}
 
+	if t.policyErr != nil {
+		t.audit(audit.ActionNavigate, url, t.policyErr.Error())
+		return t.policyErr
+	}
+	if err := t.Policy.AllowURL(url); err != nil {
+		t.audit(audit.ActionNavigate, url, err.Error())
+		return err
+	}
+	if t.Audit != nil {
+		t.auditDownloads()
+	}
+
 	// 設置狀態
 	t.IsNavigating = true
 	defer func() { t.IsNavigating = false }()
 
 	log.Printf("[cdpkit] 正在導航到: %s", url)
+	t.mu.Lock()
+	t.redirects, t.documentURL = nil, ""
+	t.authTried = nil
+	t.mu.Unlock()
 	ctx, cancel := context.WithTimeout(t.Ctx, timeout)
 	defer cancel()
 
-	err := chromedp.Run(ctx, chromedp.Navigate(url))
+	err := netError(t.exec(ctx, chromedp.Navigate(url)), url)
 	if err != nil {
 		log.Printf("[cdpkit] 導航失敗: %v", err)
+		t.audit(audit.ActionNavigate, url, err.Error())
 		return err
 	}
+	t.audit(audit.ActionNavigate, url, "")
 
 	// 更新當前 URL
 	t.CurrentURL = url
@@ -161,10 +360,11 @@ func (t *Tab) RunJS(script string, timeout time.Duration) (interface{}, error) {
 
 	log.Printf("[cdpkit] 執行 JS 腳本 (長度: %d 字符)", len(script))
 	var res interface{}
-	err := chromedp.Run(ctx, chromedp.Evaluate(script, &res))
+	err := t.exec(ctx, chromedp.Evaluate(script, &res))
 	if err != nil {
 		log.Printf("[cdpkit] JS 執行失敗: %v", err)
 	}
+	t.audit(audit.ActionScript, t.CurrentURL, audit.HashValue(script))
 	return res, err
 }
 
@@ -178,7 +378,7 @@ func (t *Tab) HTML(timeout time.Duration) (string, error) {
 
 	log.Printf("[cdpkit] 獲取頁面 HTML")
 	var html string
-	err := chromedp.Run(ctx, chromedp.OuterHTML("html", &html))
+	err := t.exec(ctx, chromedp.OuterHTML("html", &html))
 	if err != nil {
 		log.Printf("[cdpkit] 獲取 HTML 失敗: %v", err)
 	} else {
@@ -187,25 +387,33 @@ func (t *Tab) HTML(timeout time.Duration) (string, error) {
 	return html, err
 }
 
-// WaitVisible 等待元素出現
+// WaitVisible 等待元素出現；選擇器支援 XPath 與 ">>>" 穿透 shadow root，輪詢方式見 Tab.Waiting
 func (t *Tab) WaitVisible(sel string, timeout time.Duration) error {
-	if timeout <= 0 {
-		timeout = t.DefaultTimeout()
-	}
-	ctx, cancel := context.WithTimeout(t.Ctx, timeout)
-	defer cancel()
+	return t.WaitAll(timeout, Visible(sel))
+}
 
-	log.Printf("[cdpkit] 等待元素出現: %s", sel)
-	err := chromedp.Run(ctx, chromedp.WaitVisible(sel, chromedp.ByQuery))
-	if err != nil {
-		log.Printf("[cdpkit] 等待元素超時: %v", err)
-	} else {
-		log.Printf("[cdpkit] 元素已出現: %s", sel)
+// wrapErr 若分頁因瀏覽器重置或關閉而失效，改回傳 browser.TabInvalidatedError，
+// 讓呼叫端能與一般的逾時或 context canceled 區分
+func (t *Tab) wrapErr(err error) error {
+	if err == nil {
+		return nil
+	}
+	if cause := browser.InvalidationCause(t.Ctx); cause != nil {
+		return cause
 	}
 	return err
 }
 
+// audit 寫入稽核紀錄；未設定 Audit 時不做任何事
+func (t *Tab) audit(action, target, detail string) {
+	if t.Audit != nil {
+		t.Audit.Record(action, target, detail)
+	}
+}
+
 // Close 關閉分頁
+// 分頁計數會隨 context 取消自動釋放，mgr 參數僅為相容性保留，可傳 nil；
+// cdpkit.Browser.NewTab 建立的分頁可直接呼叫不需參數的 Close
 func (t *Tab) Close(mgr *browser.BrowserManager) {
 	log.Printf("[cdpkit] 關閉分頁")
 	if t.Cancel != nil {
@@ -213,9 +421,9 @@ func (t *Tab) Close(mgr *browser.BrowserManager) {
 		t.Cancel = nil
 	}
 	t.Ctx = nil
-	if mgr != nil {
-		mgr.DecrementTabCount()
-	}
+	t.mu.Lock()
+	t.frames = nil
+	t.mu.Unlock()
 }
 
 // Spoof 移除 navigator.webdriver
@@ -235,31 +443,54 @@ func (t *Tab) Spoof() error {
 
 // -------------------- 附加工具 --------------------
 
-func randomUA() string {
+// randomUA 隨機選擇 UA；seed 不為 0 時固定選擇
+func randomUA(seed int64) string {
 	ua := []string{
 		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/123.0.0.0 Safari/537.36",
 		"Mozilla/5.0 (Macintosh; Intel Mac OS X 14_4) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Safari/605.1.15",
 		"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/123.0.0.0 Safari/537.36",
 	}
+	if seed != 0 {
+		return ua[uint64(seed)%uint64(len(ua))]
+	}
 	return ua[rand.Intn(len(ua))]
 }
 
 // ApplyConfig 套用 UA、視窗尺寸、隱蔽 JS
-// 注意：如果使用 NewTab 創建分頁，這個方法是多餘的
+// 注意：如果使用 NewTab 創建分頁，這個方法是多餘的；cfg.NoAutomationTweaks 為 true 時不做任何事
 func (t *Tab) ApplyConfig(cfg config.Config) error {
+	if cfg.NoAutomationTweaks {
+		return nil
+	}
+
 	// ---- UA ----
 	ua := cfg.UserAgent
 	if ua == "" {
-		ua = randomUA()
+		ua = randomUA(cfg.Seed)
 	}
 
 	// ---- 視窗尺寸 ----
 	w, h := cfg.WindowSize[0], cfg.WindowSize[1]
 	if w == 0 || h == 0 {
-		w = 1280 + rand.Intn(201) - 100 // 1180‑1380
-		h = 720 + rand.Intn(201) - 100  // 620‑820
+		intn := rand.Intn
+		if cfg.Seed != 0 {
+			intn = rand.New(rand.NewSource(cfg.Seed)).Intn
+		}
+		w = 1280 + intn(201) - 100 // 1180‑1380
+		h = 720 + intn(201) - 100  // 620‑820
 	}
 
+	t.mu.Lock()
+	t.userAgent = ua
+	if cfg.Locale != "" {
+		t.acceptLanguage = acceptLanguage(cfg.Locale)
+	}
+	if cfg.ClientHints != nil {
+		t.clientHints = cfg.ClientHints
+	}
+	lang, hints := t.acceptLanguage, t.clientHints
+	t.mu.Unlock()
+
 	log.Printf("[cdpkit] 套用配置 (UA 長度: %d, 窗口: %dx%d)", len(ua), w, h)
 	ctx, cancel := context.WithTimeout(t.Ctx, t.DefaultTimeout())
 	defer cancel()
@@ -267,10 +498,13 @@ func (t *Tab) ApplyConfig(cfg config.Config) error {
 	err := chromedp.Run(ctx,
 		chromedp.EmulateViewport(int64(w), int64(h)),
 		chromedp.ActionFunc(func(ctx context.Context) error {
-			return emulation.SetUserAgentOverride(ua).Do(ctx)
+			return userAgentOverride(ua, lang, hints).Do(ctx)
 		}),
 		chromedp.Evaluate(`Object.defineProperty(navigator, 'webdriver', {get: () => undefined})`, nil),
 	)
+	if err == nil {
+		err = t.applyEmulation(cfg)
+	}
 
 	if err != nil {
 		log.Printf("[cdpkit] 套用配置失敗: %v", err)
@@ -0,0 +1,233 @@
+package tab
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/security"
+	"github.com/chromedp/chromedp"
+	"github.com/firehourse/cdpkit/config"
+)
+
+// IgnoreCertErrors 設定此分頁是否忽略憑證錯誤（自簽、過期、主機名稱不符）
+func (t *Tab) IgnoreCertErrors(ignore bool) error {
+	err := t.run(security.SetIgnoreCertificateErrors(ignore))
+	if err != nil {
+		return fmt.Errorf("設定忽略憑證錯誤失敗: %w", err)
+	}
+	t.mu.Lock()
+	t.insecure = ignore
+	t.mu.Unlock()
+	return nil
+}
+
+// UseClientCertificates 對指定來源出示用戶端憑證。Chrome 無法經由 CDP 選擇用戶端憑證，
+// 因此符合來源的請求改由 Go 以該憑證送出（附上分頁的 cookies），再將回應交給分頁；
+// 重新導向交由分頁處理。請求不經瀏覽器的代理，回應主體整個經由 CDP 傳送，不適合大型下載
+func (t *Tab) UseClientCertificates(certs []config.ClientCertificate) error {
+	// 每個來源兩個用戶端，依分頁目前是否忽略憑證錯誤選用
+	clients := make(map[string][2]*http.Client, len(certs))
+	for _, c := range certs {
+		origin, err := normalizeOrigin(c.Origin)
+		if err != nil {
+			return err
+		}
+		pair, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return fmt.Errorf("無法載入 %s 的用戶端憑證: %w", c.Origin, err)
+		}
+		clients[origin] = [2]*http.Client{clientCertClient(pair, false), clientCertClient(pair, true)}
+	}
+	if len(clients) == 0 {
+		return nil
+	}
+	return t.AddInterceptor(func(r *PausedRequest) {
+		u, err := url.Parse(r.Event.Request.URL)
+		if err != nil {
+			return
+		}
+		pair, ok := clients[originOf(u)]
+		if !ok || r.Blocked() || r.fulfill != nil {
+			return
+		}
+		t.mu.Lock()
+		client := pair[0]
+		if t.insecure {
+			client = pair[1]
+		}
+		t.mu.Unlock()
+		if err := t.fetchWithClient(client, r); err != nil {
+			log.Printf("[cdpkit] 以用戶端憑證請求 %s 失敗: %v", r.Event.Request.URL, err)
+			r.Block(network.ErrorReasonConnectionFailed)
+		}
+	})
+}
+
+// CertificateChain 取得 origin（例如 https://example.com）目前連線的伺服器憑證鏈，第一個為伺服器憑證；
+// 須在分頁載入過該來源後呼叫
+func (t *Tab) CertificateChain(origin string) ([]*x509.Certificate, error) {
+	var names []string
+	err := t.run(chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		names, err = network.GetCertificate(origin).Do(ctx)
+		return err
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("取得 %s 的憑證失敗: %w", origin, err)
+	}
+	chain := make([]*x509.Certificate, 0, len(names))
+	for _, name := range names {
+		der, err := base64.StdEncoding.DecodeString(name)
+		if err != nil {
+			return nil, fmt.Errorf("無法解碼 %s 的憑證: %w", origin, err)
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, fmt.Errorf("無法解析 %s 的憑證: %w", origin, err)
+		}
+		chain = append(chain, cert)
+	}
+	return chain, nil
+}
+
+// ----------------- 內部實作 -----------------
+
+// applyTLS 套用設定中的憑證選項；NoAutomationTweaks 時同樣套用
+func (t *Tab) applyTLS(cfg config.Config) {
+	if cfg.IgnoreCertErrors {
+		if err := t.IgnoreCertErrors(true); err != nil {
+			log.Printf("[cdpkit] 警告：%v", err)
+		}
+	}
+	if len(cfg.ClientCertificates) > 0 {
+		if err := t.UseClientCertificates(cfg.ClientCertificates); err != nil {
+			log.Printf("[cdpkit] 警告：%v", err)
+		}
+	}
+}
+
+// clientCertClient 建立出示 pair 的 HTTP 用戶端；重新導向原樣回傳給分頁
+func clientCertClient(pair tls.Certificate, insecure bool) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{
+		Certificates:       []tls.Certificate{pair},
+		InsecureSkipVerify: insecure,
+	}
+	return &http.Client{
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// fetchWithClient 以 client 送出被攔截的請求，並以回應完成請求
+func (t *Tab) fetchWithClient(client *http.Client, r *PausedRequest) error {
+	ev := r.Event
+	body, err := t.postData(ev)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(t.Ctx, ev.Request.Method, ev.Request.URL, strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, v := range ev.Request.Headers {
+		// 交給 Go 處理壓縮，回應才能以解壓後的內容交給分頁
+		if strings.EqualFold(k, "Accept-Encoding") {
+			continue
+		}
+		req.Header.Set(k, fmt.Sprintf("%v", v))
+	}
+	if cookies, err := t.Cookies(ev.Request.URL); err == nil {
+		for _, c := range cookies {
+			req.AddCookie(&http.Cookie{Name: c.Name, Value: c.Value})
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	var headers []*fetch.HeaderEntry
+	for k, vs := range resp.Header {
+		if strings.EqualFold(k, "Content-Length") {
+			continue
+		}
+		for _, v := range vs {
+			headers = append(headers, &fetch.HeaderEntry{Name: k, Value: v})
+		}
+	}
+	r.fulfill = fetch.FulfillRequest(ev.RequestID, int64(resp.StatusCode)).
+		WithResponseHeaders(headers).
+		WithBody(base64.StdEncoding.EncodeToString(data))
+	return nil
+}
+
+// postData 取得請求主體；過長未附在事件中時向 Network 網域查詢
+func (t *Tab) postData(ev *fetch.EventRequestPaused) (string, error) {
+	if !ev.Request.HasPostData {
+		return "", nil
+	}
+	if len(ev.Request.PostDataEntries) > 0 {
+		var b strings.Builder
+		for _, e := range ev.Request.PostDataEntries {
+			data, err := base64.StdEncoding.DecodeString(e.Bytes)
+			if err != nil {
+				return "", fmt.Errorf("無法解碼請求主體: %w", err)
+			}
+			b.Write(data)
+		}
+		return b.String(), nil
+	}
+	var body string
+	err := t.run(chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		body, err = network.GetRequestPostData(network.RequestID(ev.NetworkID)).Do(ctx)
+		return err
+	}))
+	if err != nil {
+		return "", fmt.Errorf("無法取得請求主體: %w", err)
+	}
+	return body, nil
+}
+
+// normalizeOrigin 將設定的來源整理為 scheme://host[:port]，省略預設連接埠
+func normalizeOrigin(origin string) (string, error) {
+	u, err := url.Parse(origin)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("無效的來源 %q", origin)
+	}
+	return originOf(u), nil
+}
+
+// originOf 回傳網址的來源，規則同 normalizeOrigin
+func originOf(u *url.URL) string {
+	scheme, host, port := strings.ToLower(u.Scheme), strings.ToLower(u.Hostname()), u.Port()
+	if (scheme == "https" && port == "443") || (scheme == "http" && port == "80") {
+		port = ""
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port != "" {
+		host += ":" + port
+	}
+	return scheme + "://" + host
+}
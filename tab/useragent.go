@@ -0,0 +1,273 @@
+package tab
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	cdpbrowser "github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"github.com/firehourse/cdpkit/config"
+	"github.com/firehourse/cdpkit/stealth"
+)
+
+var (
+	chromeVersionRe  = regexp.MustCompile(`(?:Chrome|CriOS)/(\d+)\.([\d.]+)`)
+	windowsVersionRe = regexp.MustCompile(`Windows NT ([\d.]+)`)
+	macVersionRe     = regexp.MustCompile(`Mac OS X ([\d_.]+)`)
+	androidVersionRe = regexp.MustCompile(`Android ([\d.]+)(?:; ([^;)]+))?`)
+)
+
+// SetExtraHeaders 設定此分頁之後所有請求都會附帶的標頭；傳入 nil 清除
+func (t *Tab) SetExtraHeaders(headers map[string]string) error {
+	h := make(network.Headers, len(headers))
+	for k, v := range headers {
+		h[k] = v
+	}
+	err := chromedp.Run(t.Ctx, network.SetExtraHTTPHeaders(h))
+	if err != nil {
+		return fmt.Errorf("設定額外標頭失敗: %w", t.wrapErr(err))
+	}
+	return nil
+}
+
+// SetUserAgent 覆寫 UA，並同步 Sec-CH-UA 等 Client Hints 與 navigator.userAgentData，
+// 避免 UA 字串與 Client Hints 不一致而暴露自動化
+func (t *Tab) SetUserAgent(ua string) error {
+	t.mu.Lock()
+	lang, hints := t.acceptLanguage, t.clientHints
+	t.mu.Unlock()
+
+	err := chromedp.Run(t.Ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		return userAgentOverride(ua, lang, hints).Do(ctx)
+	}))
+	if err != nil {
+		return fmt.Errorf("設定 UA 失敗: %w", t.wrapErr(err))
+	}
+	t.mu.Lock()
+	t.userAgent = ua
+	t.mu.Unlock()
+	return nil
+}
+
+// SetClientHints 以 hints 覆寫由 UA 推導的 Client Hints 並立即套用到目前的 UA；傳入 nil 恢復自動推導。
+// 尚未覆寫 UA 的分頁（例如 NoAutomationTweaks）會以瀏覽器原本的 UA 建立覆寫
+func (t *Tab) SetClientHints(hints *config.ClientHints) error {
+	t.mu.Lock()
+	ua, lang := t.userAgent, t.acceptLanguage
+	t.mu.Unlock()
+
+	err := chromedp.Run(t.Ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		if ua == "" {
+			_, _, _, browserUA, _, err := cdpbrowser.GetVersion().Do(ctx)
+			if err != nil {
+				return err
+			}
+			ua = strings.ReplaceAll(browserUA, "HeadlessChrome", "Chrome")
+		}
+		return userAgentOverride(ua, lang, hints).Do(ctx)
+	}))
+	if err != nil {
+		return fmt.Errorf("設定 Client Hints 失敗: %w", t.wrapErr(err))
+	}
+	t.mu.Lock()
+	t.userAgent, t.clientHints = ua, hints
+	t.mu.Unlock()
+	return nil
+}
+
+// BrowserVersion 回傳瀏覽器的產品版本，例如 "HeadlessChrome/126.0.6478.126"
+func (t *Tab) BrowserVersion() (string, error) {
+	var product string
+	err := t.runFor(t.DefaultTimeout(), chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		_, product, _, _, _, err = cdpbrowser.GetVersion().Do(ctx)
+		return err
+	}))
+	if err != nil {
+		return "", fmt.Errorf("取得瀏覽器版本失敗: %w", err)
+	}
+	return product, nil
+}
+
+// userAgentOverride 由 UA 字串推導對應的 Client Hints，hints 不為 nil 時覆寫推導的欄位；
+// 非 Chromium 的 UA 且未指定 hints 時不附帶 metadata，瀏覽器便不送出 Client Hints。
+// acceptLanguage 為空時保留瀏覽器預設的 Accept-Language
+func userAgentOverride(ua, acceptLanguage string, hints *config.ClientHints) *emulation.SetUserAgentOverrideParams {
+	p := emulation.SetUserAgentOverride(ua)
+	if acceptLanguage != "" {
+		p = p.WithAcceptLanguage(acceptLanguage)
+	}
+	if md := clientHintsMetadata(ua, hints); md != nil {
+		p = p.WithUserAgentMetadata(md)
+		p = p.WithPlatform(navigatorPlatform(md.Platform))
+	}
+	return p
+}
+
+// clientHintsMetadata 以 hints 中的非零欄位覆寫由 UA 推導的 metadata
+func clientHintsMetadata(ua string, hints *config.ClientHints) *emulation.UserAgentMetadata {
+	md := userAgentMetadata(ua)
+	if hints == nil {
+		return md
+	}
+	if md == nil {
+		md = &emulation.UserAgentMetadata{Mobile: strings.Contains(ua, "Mobile")}
+	}
+	if len(hints.Brands) > 0 {
+		md.Brands, md.FullVersionList = nil, nil
+		for _, b := range hints.Brands {
+			major, _, _ := strings.Cut(b.Version, ".")
+			md.Brands = append(md.Brands, &emulation.UserAgentBrandVersion{Brand: b.Brand, Version: major})
+			md.FullVersionList = append(md.FullVersionList, &emulation.UserAgentBrandVersion{Brand: b.Brand, Version: b.Version})
+		}
+	}
+	if hints.Platform != "" {
+		md.Platform = hints.Platform
+	}
+	if hints.PlatformVersion != "" {
+		md.PlatformVersion = hints.PlatformVersion
+	}
+	if hints.Architecture != "" {
+		md.Architecture = hints.Architecture
+	}
+	if hints.Bitness != "" {
+		md.Bitness = hints.Bitness
+	}
+	if hints.Model != "" {
+		md.Model = hints.Model
+	}
+	if hints.Mobile != nil {
+		md.Mobile = *hints.Mobile
+	}
+	md.Wow64 = md.Wow64 || hints.Wow64
+	return md
+}
+
+// userAgentMetadata 由 Chromium 的 UA 推導 Client Hints；iOS 上的 Chrome（CriOS）是 WebKit，不支援 Client Hints
+func userAgentMetadata(ua string) *emulation.UserAgentMetadata {
+	m := chromeVersionRe.FindStringSubmatch(ua)
+	if m == nil || strings.Contains(ua, "iPhone") || strings.Contains(ua, "iPad") {
+		return nil
+	}
+	major, full := m[1], m[1]+"."+m[2]
+
+	md := &emulation.UserAgentMetadata{
+		Brands: []*emulation.UserAgentBrandVersion{
+			{Brand: "Chromium", Version: major},
+			{Brand: "Google Chrome", Version: major},
+			{Brand: "Not-A.Brand", Version: "99"},
+		},
+		FullVersionList: []*emulation.UserAgentBrandVersion{
+			{Brand: "Chromium", Version: full},
+			{Brand: "Google Chrome", Version: full},
+			{Brand: "Not-A.Brand", Version: "99.0.0.0"},
+		},
+		Architecture: "x86",
+		Bitness:      "64",
+		Mobile:       strings.Contains(ua, "Mobile"),
+	}
+
+	switch {
+	case strings.Contains(ua, "Android"):
+		md.Platform = "Android"
+		md.Architecture, md.Bitness = "arm", ""
+		if a := androidVersionRe.FindStringSubmatch(ua); a != nil {
+			md.PlatformVersion = a[1]
+			if a[2] != "K" {
+				md.Model = strings.TrimSpace(a[2])
+			}
+		}
+	case strings.Contains(ua, "Windows"):
+		md.Platform = "Windows"
+		// Windows 10 與 11 的 UA 都是 NT 10.0，Client Hints 以 platformVersion 區分
+		md.PlatformVersion = "10.0.0"
+		if w := windowsVersionRe.FindStringSubmatch(ua); w != nil && w[1] != "10.0" {
+			md.PlatformVersion = w[1]
+		}
+	case strings.Contains(ua, "Macintosh"):
+		md.Platform = "macOS"
+		md.Architecture = "arm"
+		if v := macVersionRe.FindStringSubmatch(ua); v != nil {
+			md.PlatformVersion = strings.ReplaceAll(v[1], "_", ".")
+		}
+	case strings.Contains(ua, "CrOS"):
+		md.Platform = "Chrome OS"
+	default:
+		md.Platform = "Linux"
+	}
+	return md
+}
+
+// navigatorPlatform 回傳與 Client Hints 平台一致的 navigator.platform
+func navigatorPlatform(platform string) string {
+	switch platform {
+	case "Windows":
+		return "Win32"
+	case "macOS":
+		return "MacIntel"
+	case "Android":
+		return "Linux armv8l"
+	default:
+		return "Linux x86_64"
+	}
+}
+
+// fitProfile 讓注入的指紋與分頁實際送出的 UA、語言一致：langs 不為空時取代 profile 的語言；
+// UA 不是 profile 自己的（自訂、裝置或隨機 UA）時，platform、vendor 與外掛清單改由 UA 推得，
+// WebGL 與硬體數值屬於機器本身，維持 profile 的設定
+func fitProfile(p stealth.Profile, ua string, langs []string) stealth.Profile {
+	if len(langs) > 0 {
+		p.Languages = langs
+	}
+	if ua == "" || ua == p.UserAgent {
+		return p
+	}
+	p.UserAgent = ua
+	p.Platform = uaPlatform(ua)
+	if v := uaVendor(ua); v != "" {
+		p.Vendor = v
+	}
+	// 行動版瀏覽器（含不帶 Mobile 的 Android 平板與 iPad）沒有外掛
+	p.Plugins = nil
+	if strings.Contains(ua, "Mobile") || strings.Contains(ua, "Android") || strings.Contains(ua, "iPad") {
+		p.Plugins = []string{}
+	}
+	return p
+}
+
+// uaPlatform 由 UA 推得 navigator.platform；Chromium 的 UA 與 navigatorPlatform 的結果相同
+func uaPlatform(ua string) string {
+	switch {
+	case strings.Contains(ua, "iPhone"):
+		return "iPhone"
+	case strings.Contains(ua, "iPad"):
+		return "iPad"
+	case strings.Contains(ua, "Android"):
+		return navigatorPlatform("Android")
+	case strings.Contains(ua, "Windows"):
+		return navigatorPlatform("Windows")
+	case strings.Contains(ua, "Macintosh"):
+		return navigatorPlatform("macOS")
+	default:
+		return navigatorPlatform("Linux")
+	}
+}
+
+// uaVendor 由 UA 推得 navigator.vendor；iOS 上所有瀏覽器都是 WebKit。
+// Firefox 的 vendor 為空字串，無法以 profile 表示，回傳空字串維持原值
+func uaVendor(ua string) string {
+	switch {
+	case strings.Contains(ua, "iPhone"), strings.Contains(ua, "iPad"):
+		return stealth.VendorApple
+	case chromeVersionRe.MatchString(ua):
+		return stealth.VendorGoogle
+	case strings.Contains(ua, "Safari/"):
+		return stealth.VendorApple
+	default:
+		return ""
+	}
+}
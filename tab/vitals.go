@@ -0,0 +1,91 @@
+package tab
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+)
+
+// WebVitals 頁面載入的效能指標，時間自導航開始起算；瀏覽器未記錄的項目為 0
+type WebVitals struct {
+	// TTFB 收到主文件第一個位元組
+	TTFB time.Duration `json:"ttfb"`
+	// FCP 第一次繪製文字或圖片（First Contentful Paint）
+	FCP time.Duration `json:"fcp"`
+	// LCP 最大的文字或圖片區塊繪製完成（Largest Contentful Paint）
+	LCP              time.Duration `json:"lcp"`
+	DOMContentLoaded time.Duration `json:"dom_content_loaded"`
+	Load             time.Duration `json:"load"`
+	// CLS 版面位移（Cumulative Layout Shift），取間隔不超過 1 秒、總長不超過 5 秒的連續位移中總和最大的一段
+	CLS float64 `json:"cls"`
+	// TBT FCP 之後各長任務超過 50ms 部分的總和（Total Blocking Time），反映主執行緒被佔用而無法回應輸入的時間
+	TBT time.Duration `json:"tbt"`
+	// LongTasks 超過 50ms 的任務數
+	LongTasks int `json:"long_tasks"`
+}
+
+// WebVitals 以 Performance Timeline 取得目前頁面的載入效能指標，應於頁面載入完成後呼叫。
+// 指標取自瀏覽器預設緩衝的紀錄，不需在導航前注入腳本；長任務的緩衝有上限，極長的頁面可能少計
+func (t *Tab) WebVitals() (*WebVitals, error) {
+	var v struct {
+		TTFB, FCP, LCP, DCL, Load, CLS, TBT float64
+		LongTasks                           int
+	}
+	err := t.run(chromedp.Evaluate(webVitalsJS, &v, func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+		return p.WithAwaitPromise(true)
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("取得效能指標失敗: %w", err)
+	}
+	ms := func(f float64) time.Duration { return time.Duration(f * float64(time.Millisecond)) }
+	return &WebVitals{
+		TTFB:             ms(v.TTFB),
+		FCP:              ms(v.FCP),
+		LCP:              ms(v.LCP),
+		DOMContentLoaded: ms(v.DCL),
+		Load:             ms(v.Load),
+		CLS:              v.CLS,
+		TBT:              ms(v.TBT),
+		LongTasks:        v.LongTasks,
+	}, nil
+}
+
+// ----------------- 內部實作 -----------------
+
+// webVitalsJS 讀取導航與繪製時間，並以 buffered 的 PerformanceObserver 取得 LCP、版面位移與長任務；
+// 緩衝的紀錄於下一個任務送達，稍候再回傳
+const webVitalsJS = `new Promise(resolve => {
+	const nav = performance.getEntriesByType('navigation')[0] || {};
+	const paint = performance.getEntriesByName('first-contentful-paint')[0];
+	const out = {TTFB: nav.responseStart || 0, FCP: paint ? paint.startTime : 0, LCP: 0,
+		DCL: nav.domContentLoadedEventEnd || 0, Load: nav.loadEventEnd || 0, CLS: 0, TBT: 0, LongTasks: 0};
+	const observers = [];
+	const observe = (type, fn) => {
+		try {
+			const o = new PerformanceObserver(list => list.getEntries().forEach(fn));
+			o.observe({type, buffered: true});
+			observers.push(o);
+		} catch (e) {}
+	};
+	observe('largest-contentful-paint', e => { out.LCP = Math.max(out.LCP, e.startTime); });
+	let win = 0, first = 0, last = 0;
+	observe('layout-shift', e => {
+		if (e.hadRecentInput) return;
+		if (win && e.startTime - last < 1000 && e.startTime - first < 5000) {
+			win += e.value;
+		} else {
+			win = e.value;
+			first = e.startTime;
+		}
+		last = e.startTime;
+		out.CLS = Math.max(out.CLS, win);
+	});
+	observe('longtask', e => {
+		out.LongTasks++;
+		const end = e.startTime + e.duration;
+		if (end > out.FCP) out.TBT += Math.max(0, e.duration - Math.max(0, out.FCP - e.startTime) - 50);
+	});
+	setTimeout(() => { observers.forEach(o => o.disconnect()); resolve(out); }, 100);
+})`
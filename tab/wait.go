@@ -0,0 +1,246 @@
+package tab
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+)
+
+// WaitOptions 等待元素的方式；零值欄位使用預設值
+type WaitOptions struct {
+	// PollInterval 檢查條件的間隔，預設 100ms
+	PollInterval time.Duration
+	// Mutation 為 true 時在頁面內以 MutationObserver 監看 DOM，變化後立即檢查而不必等到下一次輪詢；
+	// 仍會以 PollInterval 定期檢查，涵蓋 shadow root 內與只影響版面的變化
+	Mutation bool
+}
+
+// WaitState 等待的元素狀態
+type WaitState string
+
+const (
+	// StateVisible 元素存在且可見（有版面且 visibility 不是 hidden）
+	StateVisible WaitState = "visible"
+	// StatePresent 元素存在於 DOM，不論是否可見
+	StatePresent WaitState = "present"
+	// StateHidden 元素不存在或不可見，例如載入中的遮罩消失
+	StateHidden WaitState = "hidden"
+)
+
+// Condition 等待條件；選擇器支援 XPath 與 ">>>" 穿透 shadow root
+type Condition struct {
+	Selector string
+	State    WaitState
+}
+
+// Visible 元素出現且可見的條件
+func Visible(selector string) Condition { return Condition{Selector: selector, State: StateVisible} }
+
+// Present 元素存在於 DOM 的條件
+func Present(selector string) Condition { return Condition{Selector: selector, State: StatePresent} }
+
+// Hidden 元素消失或不可見的條件
+func Hidden(selector string) Condition { return Condition{Selector: selector, State: StateHidden} }
+
+func (c Condition) String() string {
+	if c.State == "" {
+		return string(StateVisible) + " " + c.Selector
+	}
+	return string(c.State) + " " + c.Selector
+}
+
+// WaitAny 等待任一條件成立，回傳成立條件的索引（多個同時成立時取最前面的）；
+// 所有條件共用同一個期限，timeout <= 0 時使用預設逾時
+func (t *Tab) WaitAny(timeout time.Duration, conds ...Condition) (int, error) {
+	met, err := t.waitConditions(timeout, conds, false)
+	if err != nil {
+		return -1, err
+	}
+	for i, ok := range met {
+		if ok {
+			return i, nil
+		}
+	}
+	return -1, nil
+}
+
+// WaitAll 等待所有條件同時成立；所有條件共用同一個期限，timeout <= 0 時使用預設逾時
+func (t *Tab) WaitAll(timeout time.Duration, conds ...Condition) error {
+	_, err := t.waitConditions(timeout, conds, true)
+	return err
+}
+
+// ----------------- 內部實作 -----------------
+
+// waitConditions 以單一期限等待條件成立，回傳各條件最後一次檢查的結果；
+// 逾時的錯誤列出尚未成立的條件，仍可用 errors.Is 判斷 context.DeadlineExceeded
+func (t *Tab) waitConditions(timeout time.Duration, conds []Condition, all bool) ([]bool, error) {
+	if len(conds) == 0 {
+		return nil, fmt.Errorf("沒有指定等待條件")
+	}
+	if timeout <= 0 {
+		timeout = t.DefaultTimeout()
+	}
+	ctx, cancel := context.WithTimeout(t.Ctx, timeout)
+	defer cancel()
+
+	desc := describeConditions(conds, all)
+	log.Printf("[cdpkit] 等待 %s", desc)
+	var met []bool
+	err := t.exec(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		met, err = t.waitFor(ctx, conds, all)
+		return err
+	}))
+	if err != nil {
+		if ctx.Err() != nil && t.Ctx.Err() == nil {
+			var pending []Condition
+			for i, c := range conds {
+				if i >= len(met) || !met[i] {
+					pending = append(pending, c)
+				}
+			}
+			err = fmt.Errorf("等待逾時，尚未成立: %s: %w", describeConditions(pending, true), ctx.Err())
+		}
+		log.Printf("[cdpkit] %v", err)
+		return met, err
+	}
+	log.Printf("[cdpkit] 條件已成立: %s", desc)
+	return met, nil
+}
+
+// waitFor 在 ctx 內反覆檢查條件直到成立；導航造成的執行環境重建不視為錯誤
+func (t *Tab) waitFor(ctx context.Context, conds []Condition, all bool) ([]bool, error) {
+	opts := t.waitOptions()
+	var met []bool
+	for {
+		script := t.conditionsJS(conds)
+		var res []bool
+		var err error
+		if opts.Mutation {
+			res, err = t.observe(ctx, script, all, opts.PollInterval)
+		} else {
+			err = chromedp.Evaluate(script, &res).Do(ctx)
+		}
+		switch {
+		case err == nil:
+			met = res
+			if satisfied(res, all) {
+				return res, nil
+			}
+		case ctx.Err() != nil:
+			return met, ctx.Err()
+		case !contextLost(err):
+			return met, err
+		}
+		if !opts.Mutation || err != nil {
+			if err := wait(ctx, opts.PollInterval); err != nil {
+				return met, err
+			}
+		}
+	}
+}
+
+// observe 在頁面內等待條件成立：DOM 變化或每隔 poll 檢查一次，最多等 30 秒後交回，
+// 避免導航或 ctx 結束後頁面內留下觀察器
+func (t *Tab) observe(ctx context.Context, script string, all bool, poll time.Duration) ([]bool, error) {
+	limit := 30 * time.Second
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < limit {
+		limit = time.Until(deadline)
+	}
+	if limit <= 0 {
+		return nil, ctx.Err()
+	}
+	js := fmt.Sprintf(`new Promise(resolve => {
+		const check = () => %s;
+		const ok = r => %t ? r.every(Boolean) : r.some(Boolean);
+		let observer, timer, deadline;
+		const finish = r => {
+			if (observer) observer.disconnect();
+			clearInterval(timer);
+			clearTimeout(deadline);
+			resolve(r);
+		};
+		const test = () => {
+			const r = check();
+			if (ok(r)) { finish(r); return true; }
+			return false;
+		};
+		if (test()) return;
+		observer = new MutationObserver(test);
+		observer.observe(document, {subtree: true, childList: true, attributes: true, characterData: true});
+		timer = setInterval(test, %d);
+		deadline = setTimeout(() => finish(check()), %d);
+	})`, script, all, poll.Milliseconds(), limit.Milliseconds())
+
+	var res []bool
+	err := chromedp.Evaluate(js, &res, func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+		return p.WithAwaitPromise(true)
+	}).Do(ctx)
+	return res, err
+}
+
+// conditionsJS 回傳一次檢查所有條件、結果為布林陣列的 JS 運算式
+func (t *Tab) conditionsJS(conds []Condition) string {
+	checks := make([]string, len(conds))
+	for i, c := range conds {
+		switch c.State {
+		case StatePresent:
+			checks[i] = fmt.Sprintf(`!!%s`, t.queryJS(c.Selector))
+		case StateHidden:
+			checks[i] = fmt.Sprintf(`!(%s)(%s)`, visibleJS, t.elementJS(c.Selector))
+		default:
+			checks[i] = fmt.Sprintf(`(%s)(%s)`, visibleJS, t.elementJS(c.Selector))
+		}
+	}
+	return "[" + strings.Join(checks, ", ") + "]"
+}
+
+// visibleJS 判斷元素是否可見的 JS 函式
+const visibleJS = `el => !!el && el.isConnected &&
+		el.getClientRects().length > 0 &&
+		getComputedStyle(el).visibility !== 'hidden'`
+
+func (t *Tab) waitOptions() WaitOptions {
+	o := t.Waiting
+	if o.PollInterval <= 0 {
+		o.PollInterval = queryPollInterval
+	}
+	return o
+}
+
+func satisfied(met []bool, all bool) bool {
+	if len(met) == 0 {
+		return false
+	}
+	for _, ok := range met {
+		if ok != all {
+			return !all
+		}
+	}
+	return all
+}
+
+// contextLost 導航期間舊的執行環境已銷毀、新的尚未建立
+func contextLost(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "Execution context was destroyed") ||
+		strings.Contains(msg, "Cannot find context") ||
+		strings.Contains(msg, "Inspected target navigated or closed")
+}
+
+func describeConditions(conds []Condition, all bool) string {
+	parts := make([]string, len(conds))
+	for i, c := range conds {
+		parts[i] = c.String()
+	}
+	if all {
+		return strings.Join(parts, " 且 ")
+	}
+	return strings.Join(parts, " 或 ")
+}
@@ -0,0 +1,128 @@
+package tab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/cdproto/target"
+	"github.com/chromedp/chromedp"
+)
+
+// ErrRendererHung 渲染程序無回應，分頁已被強制關閉，可用 errors.Is 判斷
+var ErrRendererHung = errors.New("渲染程序無回應")
+
+// RendererHungError 看門狗判定分頁無回應時回傳的錯誤
+type RendererHungError struct {
+	// URL 分頁最後導航的網址
+	URL string
+	// Silent 判定時已持續沒有收到 CDP 事件的時間
+	Silent time.Duration
+}
+
+func (e *RendererHungError) Error() string {
+	return fmt.Sprintf("%s：%s 已 %v 沒有任何事件且無法回應探測，分頁已強制關閉", ErrRendererHung, e.URL, e.Silent.Round(time.Second))
+}
+
+// Is 讓 errors.Is(err, ErrRendererHung) 成立
+func (e *RendererHungError) Is(target error) bool {
+	return target == ErrRendererHung
+}
+
+// ----------------- 內部實作 -----------------
+
+// exec 執行 action 並套用看門狗與分頁失效的錯誤轉換；允許清單無法啟用的分頁一律拒絕
+func (t *Tab) exec(ctx context.Context, action chromedp.Action) error {
+	if t.policyErr != nil {
+		return t.policyErr
+	}
+	return t.watch(ctx, func(ctx context.Context) error {
+		return t.wrapErr(chromedp.Run(ctx, action))
+	})
+}
+
+// watch 在 fn 執行期間監看分頁：超過 HangTimeout 沒有收到任何 CDP 事件時以簡單的 JS 探測，
+// 探測也無回應則視為渲染程序卡住，強制關閉分頁並回傳 RendererHungError，而非等到操作逾時。
+// 長時間執行且不讓出主執行緒的同步腳本同樣會被判定為無回應
+func (t *Tab) watch(ctx context.Context, fn func(ctx context.Context) error) error {
+	hang := t.HangTimeout
+	if hang <= 0 {
+		return fn(ctx)
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	done := make(chan struct{})
+	defer close(done)
+	t.touch()
+	go func() {
+		ticker := time.NewTicker(hang / 4)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			silent := time.Since(time.Unix(0, t.lastEvent.Load()))
+			if silent < hang || t.probe(hang) {
+				continue
+			}
+			hung := &RendererHungError{URL: t.CurrentURL, Silent: silent}
+			log.Printf("[cdpkit] %v", hung)
+			cancel(hung)
+			t.closeTarget()
+			return
+		}
+	}()
+
+	err := fn(ctx)
+	var hung *RendererHungError
+	if errors.As(context.Cause(ctx), &hung) {
+		return hung
+	}
+	return err
+}
+
+// touch 記錄收到 CDP 事件的時間
+func (t *Tab) touch() {
+	t.lastEvent.Store(time.Now().UnixNano())
+}
+
+// probe 以最簡單的運算確認渲染程序仍能回應；成功時視同收到事件
+func (t *Tab) probe(hang time.Duration) bool {
+	timeout := hang / 2
+	if timeout > 5*time.Second {
+		timeout = 5 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(t.Ctx, timeout)
+	defer cancel()
+	err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		_, _, err := runtime.Evaluate("1").Do(ctx)
+		return err
+	}))
+	if err != nil {
+		return false
+	}
+	t.touch()
+	return true
+}
+
+// closeTarget 透過瀏覽器連線關閉分頁，渲染程序卡住時頁面本身的連線無法使用
+func (t *Tab) closeTarget() {
+	c := chromedp.FromContext(t.Ctx)
+	if c == nil || c.Target == nil || c.Browser == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := target.CloseTarget(c.Target.TargetID).Do(cdp.WithExecutor(ctx, c.Browser)); err != nil {
+		log.Printf("[cdpkit] 無法關閉無回應的分頁: %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package tab
+
+import (
+	"fmt"
+
+	"github.com/firehourse/cdpkit/config"
+)
+
+// webrtcDisableJS 移除 WebRTC 相關的建構函式
+const webrtcDisableJS = `(() => {
+	for (const k of ['RTCPeerConnection', 'webkitRTCPeerConnection', 'RTCDataChannel', 'RTCSessionDescription', 'RTCIceCandidate']) {
+		try { delete window[k]; } catch (e) {}
+	}
+})();`
+
+// webrtcFilterJS 過濾 ICE candidate：icecandidate 事件與 localDescription 的 SDP 中只保留允許的類型
+const webrtcFilterJS = `(() => {
+	const Native = window.RTCPeerConnection;
+	if (!Native) return;
+	const types = %s;
+	const allowed = line => { const m = / typ ([a-z]+)/.exec(line || ''); return !m || types.includes(m[1]); };
+	const strip = sdp => sdp && sdp.split('\r\n').filter(l => !l.startsWith('a=candidate:') || allowed(l)).join('\r\n');
+	const proto = Native.prototype;
+
+	for (const name of ['localDescription', 'currentLocalDescription', 'pendingLocalDescription']) {
+		const d = Object.getOwnPropertyDescriptor(proto, name);
+		if (!d || !d.get) continue;
+		Object.defineProperty(proto, name, {configurable: true, enumerable: d.enumerable, get() {
+			const desc = d.get.call(this);
+			return desc ? new RTCSessionDescription({type: desc.type, sdp: strip(desc.sdp)}) : desc;
+		}});
+	}
+
+	const wrapped = new WeakMap();
+	const wrap = fn => {
+		if (typeof fn !== 'function') return fn;
+		if (!wrapped.has(fn)) {
+			wrapped.set(fn, function (e) {
+				if (e && e.candidate && !allowed(e.candidate.candidate)) return;
+				return fn.apply(this, arguments);
+			});
+		}
+		return wrapped.get(fn);
+	};
+	const on = Object.getOwnPropertyDescriptor(proto, 'onicecandidate');
+	if (on && on.set) {
+		Object.defineProperty(proto, 'onicecandidate', {configurable: true, enumerable: on.enumerable, get: on.get,
+			set(fn) { on.set.call(this, wrap(fn)); }});
+	}
+	const add = EventTarget.prototype.addEventListener, remove = EventTarget.prototype.removeEventListener;
+	EventTarget.prototype.addEventListener = function addEventListener(type, fn, opts) {
+		return add.call(this, type, this instanceof Native && type === 'icecandidate' ? wrap(fn) : fn, opts);
+	};
+	EventTarget.prototype.removeEventListener = function removeEventListener(type, fn, opts) {
+		return remove.call(this, type, this instanceof Native && type === 'icecandidate' ? wrap(fn) : fn, opts);
+	};
+})();`
+
+// webrtcScript 依 WebRTC 政策產生分頁的初始化腳本；連接既有 Chrome 時無法加上啟動旗標，只能靠此腳本。
+// 未知的政策回傳空字串
+func webrtcScript(p config.WebRTCPolicy) string {
+	switch p {
+	case config.WebRTCDisabled:
+		return webrtcDisableJS
+	case config.WebRTCProxyOnly:
+		return fmt.Sprintf(webrtcFilterJS, `['relay']`)
+	case config.WebRTCPublicOnly:
+		// host candidate 帶有區網 IP（mDNS 的 .local 名稱也一併略過）
+		return fmt.Sprintf(webrtcFilterJS, `['srflx', 'prflx', 'relay']`)
+	default:
+		return ""
+	}
+}
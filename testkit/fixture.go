@@ -0,0 +1,213 @@
+// Package testkit 擷取規則的黃金頁面回歸測試：Record 將頁面與子資源錄製成 fixture，
+// Run 在 go test 中離線重播 fixture、執行擷取並與預期輸出比對，不需要連網
+package testkit
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/firehourse/cdpkit/tab"
+)
+
+// Fixture 錄製的頁面：主文件與載入時抓取的子資源
+type Fixture struct {
+	// URL 重播時導航的網址（重導向後的最終網址）
+	URL        string     `json:"url"`
+	RecordedAt time.Time  `json:"recorded_at"`
+	Resources  []Resource `json:"resources"`
+}
+
+// Resource 一個錄製的回應；文字主體直接保存以便在版本控制中檢視差異，二進位主體以 base64 保存
+type Resource struct {
+	URL         string `json:"url"`
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        string `json:"body"`
+	Base64      bool   `json:"base64,omitempty"`
+}
+
+// RecordOptions Record 的設定；零值欄位使用預設值
+type RecordOptions struct {
+	// Types 要錄製的資源類型，預設 Document、Script、Stylesheet、XHR、Fetch；
+	// 圖片與字型通常不影響擷取，未錄製的請求在重播時一律失敗
+	Types []network.ResourceType
+	// Idle 導航後等待網路閒置的時間，預設 1 秒
+	Idle time.Duration
+	// Timeout 導航與取得回應主體的逾時，預設使用分頁的逾時
+	Timeout time.Duration
+}
+
+// Record 導航至 url，等待網路閒置後錄製主文件與子資源
+func Record(pageTab *tab.Tab, url string, opts RecordOptions) (*Fixture, error) {
+	if len(opts.Types) == 0 {
+		opts.Types = defaultTypes
+	}
+	if opts.Idle <= 0 {
+		opts.Idle = time.Second
+	}
+	pageTab.ResetResponses()
+	if err := pageTab.Navigate(url, opts.Timeout); err != nil {
+		return nil, err
+	}
+	if err := pageTab.WaitNetworkIdle(opts.Idle, opts.Timeout); err != nil {
+		return nil, err
+	}
+
+	f := &Fixture{URL: url, RecordedAt: time.Now().UTC()}
+	if href, err := pageTab.RunJS("location.href", opts.Timeout); err == nil {
+		if s, ok := href.(string); ok && s != "" {
+			f.URL = s
+		}
+	}
+	seen := make(map[string]bool)
+	for _, r := range pageTab.Responses() {
+		if !r.Finished || !wanted(opts.Types, r.ResourceType) || seen[r.URL] || strings.HasPrefix(r.URL, "data:") {
+			continue
+		}
+		body, err := pageTab.GetResponseBody(r.RequestID, opts.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("錄製 %s 失敗: %w", r.URL, err)
+		}
+		seen[r.URL] = true
+		res := Resource{URL: r.URL, Status: int(r.Status), ContentType: contentType(r)}
+		if utf8.Valid(body) {
+			res.Body = string(body)
+		} else {
+			res.Body, res.Base64 = base64.StdEncoding.EncodeToString(body), true
+		}
+		f.Resources = append(f.Resources, res)
+	}
+	if f.resource(f.URL) == nil {
+		return nil, fmt.Errorf("錄製 %s 失敗: 找不到主文件的回應", f.URL)
+	}
+	return f, nil
+}
+
+// Load 讀取 fixture 檔
+func Load(path string) (*Fixture, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("無法讀取 fixture %s: %w", path, err)
+	}
+	var f Fixture
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("解析 fixture %s 失敗: %w", path, err)
+	}
+	return &f, nil
+}
+
+// Save 將 fixture 寫入檔案
+func (f *Fixture) Save(path string) error {
+	return writeJSON(path, f)
+}
+
+// Bytes 回傳解碼後的主體
+func (r Resource) Bytes() ([]byte, error) {
+	if r.Base64 {
+		return base64.StdEncoding.DecodeString(r.Body)
+	}
+	return []byte(r.Body), nil
+}
+
+// Replay 以 fixture 回應分頁的所有請求並導航至錄製的網址；fixture 中沒有的請求一律以離線失敗，
+// 因此頁面不會連網。分頁應專供重播使用，攔截在分頁關閉前持續有效
+func Replay(pageTab *tab.Tab, f *Fixture, timeout time.Duration) error {
+	byURL := make(map[string][]byte, len(f.Resources))
+	for _, r := range f.Resources {
+		body, err := r.Bytes()
+		if err != nil {
+			return fmt.Errorf("fixture 中 %s 的主體無法解碼: %w", r.URL, err)
+		}
+		byURL[stripFragment(r.URL)] = body
+	}
+	err := pageTab.AddInterceptor(func(req *tab.PausedRequest) {
+		url := stripFragment(req.Event.Request.URL)
+		res := f.resource(url)
+		if res == nil {
+			req.Block(network.ErrorReasonInternetDisconnected)
+			return
+		}
+		headers := map[string]string{"Access-Control-Allow-Origin": "*"}
+		if res.ContentType != "" {
+			headers["Content-Type"] = res.ContentType
+		}
+		req.Fulfill(res.Status, headers, byURL[url])
+	})
+	if err != nil {
+		return err
+	}
+	if err := pageTab.Navigate(f.URL, timeout); err != nil {
+		return err
+	}
+	// 重播不經網路，短暫閒置即可視為載入完成
+	return pageTab.WaitNetworkIdle(200*time.Millisecond, timeout)
+}
+
+// ----------------- 內部實作 -----------------
+
+var defaultTypes = []network.ResourceType{
+	network.ResourceTypeDocument,
+	network.ResourceTypeScript,
+	network.ResourceTypeStylesheet,
+	network.ResourceTypeXHR,
+	network.ResourceTypeFetch,
+}
+
+func wanted(types []network.ResourceType, t network.ResourceType) bool {
+	for _, want := range types {
+		if want == t {
+			return true
+		}
+	}
+	return false
+}
+
+// resource 依網址找出錄製的回應，忽略 fragment
+func (f *Fixture) resource(url string) *Resource {
+	url = stripFragment(url)
+	for i := range f.Resources {
+		if stripFragment(f.Resources[i].URL) == url {
+			return &f.Resources[i]
+		}
+	}
+	return nil
+}
+
+func stripFragment(url string) string {
+	if i := strings.IndexByte(url, '#'); i >= 0 {
+		return url[:i]
+	}
+	return url
+}
+
+// contentType 優先使用原始標頭（保留 charset），沒有時以 MIME 類型代替
+func contentType(r tab.Response) string {
+	for k, v := range r.Headers {
+		if strings.EqualFold(k, "Content-Type") {
+			return fmt.Sprint(v)
+		}
+	}
+	return r.MimeType
+}
+
+func writeJSON(path string, v interface{}) error {
+	// 不跳脫 <、>、&，讓 HTML 在版本控制中保持可讀
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("無法寫入 %s: %w", path, err)
+	}
+	return nil
+}
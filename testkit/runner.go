@@ -0,0 +1,271 @@
+package testkit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/firehourse/cdpkit/browser"
+	"github.com/firehourse/cdpkit/config"
+	"github.com/firehourse/cdpkit/crawler"
+	"github.com/firehourse/cdpkit/tab"
+)
+
+// fixture 與預期輸出的副檔名：<名稱>.fixture.json 搭配 <名稱>.golden.json
+const (
+	FixtureExt = ".fixture.json"
+	GoldenExt  = ".golden.json"
+)
+
+// UpdateEnv 設為非空值時 Run 以實際輸出覆寫 golden 檔，例如 CDPKIT_UPDATE_GOLDEN=1 go test ./...
+const UpdateEnv = "CDPKIT_UPDATE_GOLDEN"
+
+// Extractor 在重播完成的分頁上執行擷取，回傳要與 golden 比對的資料
+type Extractor func(pageTab *tab.Tab, timeout time.Duration) (map[string]interface{}, error)
+
+// Script 以 JS 腳本擷取，語意與 crawler 相同：Promise 會等待解析，非物件的結果放在 "result" 鍵
+func Script(js string) Extractor {
+	return func(pageTab *tab.Tab, timeout time.Duration) (map[string]interface{}, error) {
+		v, err := pageTab.RunJS(fmt.Sprintf(scriptWrapper, js), timeout)
+		if err != nil {
+			return nil, err
+		}
+		if m, ok := v.(map[string]interface{}); ok {
+			return m, nil
+		}
+		return map[string]interface{}{"result": v}, nil
+	}
+}
+
+// Spec 以宣告式擷取規則擷取，輸出經 Coerce 轉型，與 crawler.Options.Extract 的結果相同
+func Spec(spec crawler.ExtractSpec) Extractor {
+	return func(pageTab *tab.Tab, timeout time.Duration) (map[string]interface{}, error) {
+		script, err := spec.Script()
+		if err != nil {
+			return nil, err
+		}
+		data, err := Script(script)(pageTab, timeout)
+		if err != nil {
+			return nil, err
+		}
+		return spec.Coerce(data), nil
+	}
+}
+
+// Options Run 的設定
+type Options struct {
+	// Config 瀏覽器設定；Flags 為 nil 時使用 SafeDefaults 的無頭模式
+	Config config.Config
+	// Update 為 true 時以實際輸出覆寫 golden 檔，也可設定環境變數 UpdateEnv
+	Update bool
+	// RequireChrome 為 true 時無法啟動 Chrome 視為測試失敗，預設略過測試
+	RequireChrome bool
+	// Timeout 每個 fixture 的重播與擷取逾時，預設 30 秒
+	Timeout time.Duration
+}
+
+// Run 對 dir 中的每個 fixture 開一個子測試：離線重播、執行 extract，並與同名的 golden 檔比對。
+// golden 檔不存在或設定更新模式時寫入實際輸出
+func Run(t *testing.T, dir string, extract Extractor, opts Options) {
+	t.Helper()
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+FixtureExt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatalf("%s 中沒有 %s 檔", dir, FixtureExt)
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 30 * time.Second
+	}
+	cfg := opts.Config
+	if cfg.Flags == nil {
+		cfg.Flags = config.SafeDefaults()
+		cfg.Flags["headless"] = true
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = opts.Timeout
+	}
+	update := opts.Update || os.Getenv(UpdateEnv) != ""
+
+	bm, err := browser.NewManagerFromConfig(cfg)
+	if err != nil {
+		if opts.RequireChrome {
+			t.Fatalf("無法啟動 Chrome: %v", err)
+		}
+		t.Skipf("無法啟動 Chrome，略過黃金頁面測試: %v", err)
+	}
+	defer bm.Shutdown()
+
+	for _, path := range paths {
+		name := strings.TrimSuffix(filepath.Base(path), FixtureExt)
+		golden := strings.TrimSuffix(path, FixtureExt) + GoldenExt
+		t.Run(name, func(t *testing.T) {
+			f, err := Load(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx, cancel, err := bm.NewPageContext()
+			if err != nil {
+				t.Fatalf("創建分頁失敗: %v", err)
+			}
+			pageTab := tab.Open(ctx, cancel, config.Split(cfg))
+			defer pageTab.Close(bm)
+
+			got, err := Extract(pageTab, f, extract, opts.Timeout)
+			if err != nil {
+				t.Fatal(err)
+			}
+			diffs, wrote, err := compareGolden(golden, got, update)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if wrote {
+				t.Logf("已寫入 %s", golden)
+			}
+			if len(diffs) > 0 {
+				t.Errorf("擷取結果與 %s 不符（以 %s=1 重新產生）:\n%s", golden, UpdateEnv, strings.Join(diffs, "\n"))
+			}
+		})
+	}
+}
+
+// Extract 在分頁上重播 fixture 並執行擷取，回傳正規化為 JSON 型別的資料
+func Extract(pageTab *tab.Tab, f *Fixture, extract Extractor, timeout time.Duration) (map[string]interface{}, error) {
+	if err := Replay(pageTab, f, timeout); err != nil {
+		return nil, fmt.Errorf("重播 %s 失敗: %w", f.URL, err)
+	}
+	data, err := extract(pageTab, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("擷取 %s 失敗: %w", f.URL, err)
+	}
+	return normalize(data)
+}
+
+// LoadGolden 讀取預期輸出
+func LoadGolden(path string) (map[string]interface{}, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("無法讀取 golden %s: %w", path, err)
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal(b, &data); err != nil {
+		return nil, fmt.Errorf("解析 golden %s 失敗: %w", path, err)
+	}
+	return data, nil
+}
+
+// WriteGolden 寫入預期輸出；鍵依字母排序，方便在版本控制中檢視差異
+func WriteGolden(path string, data map[string]interface{}) error {
+	return writeJSON(path, data)
+}
+
+// Diff 比對兩份 JSON 資料，回傳每個差異的說明，例如 `$.items[2].price: 預期 12.5，實際 13`；相同時回傳 nil
+func Diff(want, got interface{}) []string {
+	var diffs []string
+	diff("$", want, got, &diffs)
+	return diffs
+}
+
+// ----------------- 內部實作 -----------------
+
+// scriptWrapper 與 crawler 相同的非同步處理：Promise 失敗時回傳 {error}
+const scriptWrapper = `
+	(function() {
+		const result = %s;
+		if (result && typeof result.then === 'function') {
+			return new Promise((resolve) => {
+				result.then(resolve).catch(err => resolve({error: err.toString()}));
+			});
+		}
+		return result;
+	})()
+`
+
+// compareGolden 將 got 與 golden 檔比對並回傳差異；golden 檔不存在或 update 時改為寫入 got
+func compareGolden(golden string, got map[string]interface{}, update bool) (diffs []string, wrote bool, err error) {
+	if _, statErr := os.Stat(golden); update || os.IsNotExist(statErr) {
+		return nil, true, WriteGolden(golden, got)
+	}
+	want, err := LoadGolden(golden)
+	if err != nil {
+		return nil, false, err
+	}
+	return Diff(want, got), false, nil
+}
+
+// normalize 經過一次 JSON 序列化，讓數字、陣列等型別與讀回的 golden 一致
+func normalize(data map[string]interface{}) (map[string]interface{}, error) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("序列化擷取結果失敗: %w", err)
+	}
+	var out map[string]interface{}
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func diff(path string, want, got interface{}, diffs *[]string) {
+	switch w := want.(type) {
+	case map[string]interface{}:
+		g, ok := got.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(w)+len(g))
+		for k := range w {
+			keys = append(keys, k)
+		}
+		for k := range g {
+			if _, ok := w[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			wv, inWant := w[k]
+			gv, inGot := g[k]
+			switch {
+			case !inGot:
+				*diffs = append(*diffs, fmt.Sprintf("%s.%s: 缺少（預期 %s）", path, k, show(wv)))
+			case !inWant:
+				*diffs = append(*diffs, fmt.Sprintf("%s.%s: 多出 %s", path, k, show(gv)))
+			default:
+				diff(path+"."+k, wv, gv, diffs)
+			}
+		}
+		return
+	case []interface{}:
+		g, ok := got.([]interface{})
+		if !ok {
+			break
+		}
+		if len(w) != len(g) {
+			*diffs = append(*diffs, fmt.Sprintf("%s: 預期 %d 項，實際 %d 項", path, len(w), len(g)))
+		}
+		for i := 0; i < len(w) && i < len(g); i++ {
+			diff(fmt.Sprintf("%s[%d]", path, i), w[i], g[i], diffs)
+		}
+		return
+	}
+	if !reflect.DeepEqual(want, got) {
+		*diffs = append(*diffs, fmt.Sprintf("%s: 預期 %s，實際 %s", path, show(want), show(got)))
+	}
+}
+
+// show 以 JSON 顯示值，過長時截斷
+func show(v interface{}) string {
+	b, _ := json.Marshal(v)
+	if s := []rune(string(b)); len(s) > 200 {
+		return string(s[:200]) + "…"
+	}
+	return string(b)
+}
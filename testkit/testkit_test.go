@@ -0,0 +1,183 @@
+package testkit
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/firehourse/cdpkit/browser"
+	"github.com/firehourse/cdpkit/config"
+	"github.com/firehourse/cdpkit/crawler"
+	"github.com/firehourse/cdpkit/tab"
+)
+
+const (
+	testFixture = "testdata/product" + FixtureExt
+	testGolden  = "testdata/product" + GoldenExt
+)
+
+func loadSpec(t *testing.T) crawler.ExtractSpec {
+	t.Helper()
+	spec, err := crawler.LoadExtractSpec("testdata/product.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return spec
+}
+
+// newTestTab 以與 Run 相同的設定啟動瀏覽器並開一個分頁，找不到 Chrome 時略過測試
+func newTestTab(t *testing.T) (*browser.BrowserManager, func() *tab.Tab) {
+	t.Helper()
+	cfg := config.Config{Flags: config.SafeDefaults(), Timeout: 30 * time.Second}
+	cfg.Flags["headless"] = true
+	bm, err := browser.NewManagerFromConfig(cfg)
+	if err != nil {
+		t.Skipf("無法啟動 Chrome: %v", err)
+	}
+	t.Cleanup(bm.Shutdown)
+	return bm, func() *tab.Tab {
+		ctx, cancel, err := bm.NewPageContext()
+		if err != nil {
+			t.Fatalf("創建分頁失敗: %v", err)
+		}
+		return tab.Open(ctx, cancel, config.Split(cfg))
+	}
+}
+
+// 提交的 fixture 離線重播後與 golden 相符
+func TestGoldenPages(t *testing.T) {
+	Run(t, "testdata", Spec(loadSpec(t)), Options{})
+}
+
+// 以本機伺服器錄製頁面，關閉伺服器後重播並與提交的 golden 比對；
+// 故意不符的 golden 必須回報差異
+func TestRecordReplayDiff(t *testing.T) {
+	src, err := Load(testFixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, res := range src.Resources {
+			if strings.HasSuffix(res.URL, r.URL.Path) {
+				w.Header().Set("Content-Type", res.ContentType)
+				w.WriteHeader(res.Status)
+				w.Write([]byte(res.Body))
+				return
+			}
+		}
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+
+	bm, newTab := newTestTab(t)
+	liveTab := newTab()
+	f, err := Record(liveTab, srv.URL+"/item/42", RecordOptions{Idle: 300 * time.Millisecond, Timeout: 10 * time.Second})
+	liveTab.Close(bm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Resources) != len(src.Resources) {
+		t.Errorf("錄製 %d 個資源，應為 %d", len(f.Resources), len(src.Resources))
+	}
+	path := filepath.Join(t.TempDir(), "product"+FixtureExt)
+	if err := f.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	// 重播不得連網
+	srv.Close()
+	if f, err = Load(path); err != nil {
+		t.Fatal(err)
+	}
+
+	replayTab := newTab()
+	defer replayTab.Close(bm)
+	got, err := Extract(replayTab, f, Spec(loadSpec(t)), 10*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	diffs, wrote, err := compareGolden(testGolden, got, false)
+	if err != nil || wrote {
+		t.Fatalf("比對 golden 失敗: %v（wrote=%v）", err, wrote)
+	}
+	if len(diffs) > 0 {
+		t.Errorf("重播結果與 golden 不符:\n%s", strings.Join(diffs, "\n"))
+	}
+
+	mismatch := filepath.Join(t.TempDir(), "product"+GoldenExt)
+	if err := os.WriteFile(mismatch, []byte(`{"price": 999, "sku": "TS-42", "stock": 12, "tags": ["running"], "title": "Trail Shoe"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if diffs, _, _ := compareGolden(mismatch, got, false); len(diffs) == 0 {
+		t.Error("不符的 golden 未回報差異")
+	}
+}
+
+// 讀回再寫出的 fixture 與提交的檔案逐位元組相同，錄製結果在版本控制中保持穩定
+func TestFixtureRoundTrip(t *testing.T) {
+	f, err := Load(testFixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "product"+FixtureExt)
+	if err := f.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	want, _ := os.ReadFile(testFixture)
+	got, _ := os.ReadFile(path)
+	if !bytes.Equal(got, want) {
+		t.Errorf("重新寫出的 fixture 與 %s 不同:\n%s", testFixture, got)
+	}
+	if r := f.resource(f.URL + "#reviews"); r == nil || r.Status != 200 {
+		t.Errorf("以含 fragment 的網址找不到主文件")
+	}
+}
+
+// 與 golden 不符的擷取結果逐一列出差異的路徑；不需要瀏覽器
+func TestCompareGoldenReportsMismatch(t *testing.T) {
+	got, err := LoadGolden(testGolden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	diffs, wrote, err := compareGolden(testGolden, got, false)
+	if err != nil || wrote || len(diffs) != 0 {
+		t.Fatalf("相同的結果回報 %v（wrote=%v, err=%v）", diffs, wrote, err)
+	}
+
+	got["price"] = 1199.5
+	got["tags"] = []interface{}{"running", "trail", "outdoor"}
+	delete(got, "sku")
+	got["brand"] = "Acme"
+	diffs, _, err = compareGolden(testGolden, got, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`$.brand: 多出 "Acme"`,
+		`$.price: 預期 1299.5，實際 1199.5`,
+		`$.sku: 缺少（預期 "TS-42"）`,
+		`$.tags: 預期 2 項，實際 3 項`,
+		`$.tags[1]: 預期 "outdoor"，實際 "trail"`,
+	}
+	if !reflect.DeepEqual(diffs, want) {
+		t.Errorf("差異為\n%s\n應為\n%s", strings.Join(diffs, "\n"), strings.Join(want, "\n"))
+	}
+
+	// golden 不存在時寫入實際輸出
+	path := filepath.Join(t.TempDir(), "new"+GoldenExt)
+	if _, wrote, err := compareGolden(path, got, false); err != nil || !wrote {
+		t.Fatalf("未寫入新的 golden: %v", err)
+	}
+	written, err := LoadGolden(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diffs := Diff(written, got); len(diffs) > 0 {
+		t.Errorf("寫入的 golden 與輸出不同: %v", diffs)
+	}
+}
@@ -0,0 +1,174 @@
+package warc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Response 要寫入 WARC 的 HTTP 回應
+type Response struct {
+	URL     string
+	Status  int
+	Headers map[string]string
+	Body    []byte
+	Date    time.Time
+}
+
+// Writer 依 WARC/1.1 規格輸出紀錄，可供 pywb、replayweb.page 等工具重播
+// 可安全地被多個 goroutine 共用
+type Writer struct {
+	mu       sync.Mutex
+	w        io.Writer
+	closer   io.Closer
+	compress bool
+}
+
+// NewWriter 包裝任意 io.Writer；compress 為 true 時每筆紀錄各自 gzip（.warc.gz 慣例）
+func NewWriter(w io.Writer, compress bool) *Writer {
+	return &Writer{w: w, compress: compress}
+}
+
+// Create 建立 WARC 檔案；副檔名為 .gz 時自動壓縮
+func Create(path string) (*Writer, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("無法建立 WARC 檔 %s: %w", path, err)
+	}
+	w := NewWriter(f, strings.HasSuffix(path, ".gz"))
+	w.closer = f
+	return w, nil
+}
+
+// Close 關閉底層檔案（若由 Create 建立）
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closer != nil {
+		return w.closer.Close()
+	}
+	return nil
+}
+
+// WriteInfo 寫入 warcinfo 紀錄，描述產生此檔的軟體與設定
+func (w *Writer) WriteInfo(fields map[string]string) error {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var block bytes.Buffer
+	for _, k := range keys {
+		fmt.Fprintf(&block, "%s: %s\r\n", k, fields[k])
+	}
+	return w.writeRecord([][2]string{
+		{"WARC-Type", "warcinfo"},
+		{"WARC-Record-ID", newRecordID()},
+		{"WARC-Date", formatDate(time.Now())},
+		{"Content-Type", "application/warc-fields"},
+	}, block.Bytes())
+}
+
+// WriteResponse 寫入 response 紀錄，區塊為完整的 HTTP 回應（狀態列、標頭與主體）
+func (w *Writer) WriteResponse(r Response) error {
+	if r.Date.IsZero() {
+		r.Date = time.Now()
+	}
+	if r.Status == 0 {
+		r.Status = http.StatusOK
+	}
+
+	var block bytes.Buffer
+	fmt.Fprintf(&block, "HTTP/1.1 %d %s\r\n", r.Status, http.StatusText(r.Status))
+	for _, h := range normalizeHeaders(r.Headers, len(r.Body)) {
+		fmt.Fprintf(&block, "%s: %s\r\n", h[0], h[1])
+	}
+	block.WriteString("\r\n")
+	block.Write(r.Body)
+
+	return w.writeRecord([][2]string{
+		{"WARC-Type", "response"},
+		{"WARC-Record-ID", newRecordID()},
+		{"WARC-Date", formatDate(r.Date)},
+		{"WARC-Target-URI", r.URL},
+		{"WARC-Payload-Digest", digest(r.Body)},
+		{"Content-Type", "application/http;msgtype=response"},
+	}, block.Bytes())
+}
+
+// ----------------- 內部輔助 -----------------
+
+func (w *Writer) writeRecord(headers [][2]string, block []byte) error {
+	var rec bytes.Buffer
+	rec.WriteString("WARC/1.1\r\n")
+	for _, h := range headers {
+		fmt.Fprintf(&rec, "%s: %s\r\n", h[0], h[1])
+	}
+	fmt.Fprintf(&rec, "WARC-Block-Digest: %s\r\n", digest(block))
+	fmt.Fprintf(&rec, "Content-Length: %d\r\n\r\n", len(block))
+	rec.Write(block)
+	rec.WriteString("\r\n\r\n")
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.compress {
+		_, err := w.w.Write(rec.Bytes())
+		return err
+	}
+	gz := gzip.NewWriter(w.w)
+	if _, err := gz.Write(rec.Bytes()); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// normalizeHeaders 瀏覽器提供的主體已解壓縮，移除會誤導重播工具的編碼標頭並修正長度
+func normalizeHeaders(headers map[string]string, bodyLen int) [][2]string {
+	keys := make([]string, 0, len(headers))
+	for k := range headers {
+		switch strings.ToLower(k) {
+		case "content-encoding", "transfer-encoding", "content-length":
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	out := make([][2]string, 0, len(keys)+1)
+	for _, k := range keys {
+		// 多值標頭在 CDP 中以換行分隔
+		for _, v := range strings.Split(headers[k], "\n") {
+			out = append(out, [2]string{k, v})
+		}
+	}
+	return append(out, [2]string{"Content-Length", fmt.Sprintf("%d", bodyLen)})
+}
+
+func digest(data []byte) string {
+	sum := sha1.Sum(data)
+	return "sha1:" + base32.StdEncoding.EncodeToString(sum[:])
+}
+
+func formatDate(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05Z")
+}
+
+func newRecordID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("<urn:uuid:%x-%x-%x-%x-%x>", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
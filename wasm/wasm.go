@@ -0,0 +1,226 @@
+// Package wasm 在沙箱中執行使用者以 WebAssembly 編譯的轉換器與擷取器。
+// 模組只能存取自己的線性記憶體：不掛載檔案系統、不提供網路與環境變數，
+// 並限制記憶體與執行時間，適合在服務模式下執行不受信任的擷取邏輯。
+//
+// 模組需匯出以下函式（以 TinyGo、Rust 等編譯皆可）：
+//
+//	alloc(size u32) u32             配置 size 位元組並回傳指標，主機將輸入寫入此處
+//	transform(ptr, len u32) u64     輸入為 crawler.Result 的 JSON，回傳處理後的 JSON；長度 0 表示捨棄
+//	extract(ptr, len u32) u64       輸入為 {"url","title","html"}，回傳擷取出的 JSON 物件
+//
+// 回傳值高 32 位元為輸出指標、低 32 位元為長度。transform 與 extract 只需匯出用到的一個。
+// 每次呼叫都使用新的模組實例，呼叫之間不共享狀態。
+package wasm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/firehourse/cdpkit/crawler"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+// Options 沙箱限制；零值欄位使用預設值
+type Options struct {
+	// MemoryLimitMB 線性記憶體上限，預設 64MB
+	MemoryLimitMB int
+	// Timeout 單次呼叫的執行時間上限，預設 5 秒；逾時會中斷無窮迴圈
+	Timeout time.Duration
+	// Stderr 模組的標準錯誤輸出，nil 時捨棄
+	Stderr io.Writer
+}
+
+// Extension 已編譯的 WASM 擴充
+type Extension struct {
+	name     string
+	opts     Options
+	runtime  wazero.Runtime
+	compiled wazero.CompiledModule
+}
+
+// Load 讀取並編譯 .wasm 檔案
+func Load(ctx context.Context, path string, opts Options) (*Extension, error) {
+	code, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("無法讀取 WASM 模組 %s: %w", path, err)
+	}
+	ext, err := New(ctx, code, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	ext.name = path
+	return ext, nil
+}
+
+// New 編譯 WASM 位元組碼
+func New(ctx context.Context, code []byte, opts Options) (*Extension, error) {
+	if opts.MemoryLimitMB <= 0 {
+		opts.MemoryLimitMB = 64
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Second
+	}
+	if opts.Stderr == nil {
+		opts.Stderr = io.Discard
+	}
+
+	// 每頁 64KiB
+	cfg := wazero.NewRuntimeConfig().
+		WithCloseOnContextDone(true).
+		WithMemoryLimitPages(uint32(opts.MemoryLimitMB * 16))
+	rt := wazero.NewRuntimeWithConfig(ctx, cfg)
+
+	// TinyGo 等工具鏈會匯入 WASI；未設定任何掛載點與環境變數，因此無法存取主機
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, rt); err != nil {
+		rt.Close(ctx)
+		return nil, fmt.Errorf("初始化 WASI 失敗: %w", err)
+	}
+	compiled, err := rt.CompileModule(ctx, code)
+	if err != nil {
+		rt.Close(ctx)
+		return nil, fmt.Errorf("編譯 WASM 模組失敗: %w", err)
+	}
+	if _, ok := compiled.ExportedFunctions()["alloc"]; !ok {
+		rt.Close(ctx)
+		return nil, fmt.Errorf("WASM 模組未匯出 alloc")
+	}
+	return &Extension{name: "wasm", opts: opts, runtime: rt, compiled: compiled}, nil
+}
+
+// Close 釋放執行環境
+func (e *Extension) Close(ctx context.Context) error {
+	return e.runtime.Close(ctx)
+}
+
+// Has 回傳模組是否匯出指定函式
+func (e *Extension) Has(export string) bool {
+	_, ok := e.compiled.ExportedFunctions()[export]
+	return ok
+}
+
+// Call 以新的模組實例呼叫 export，傳入 input 並回傳輸出
+func (e *Extension) Call(ctx context.Context, export string, input []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, e.opts.Timeout)
+	defer cancel()
+
+	mod, err := e.runtime.InstantiateModule(ctx, e.compiled, wazero.NewModuleConfig().
+		WithName("").
+		WithStderr(e.opts.Stderr).
+		WithStartFunctions("_initialize"))
+	if err != nil {
+		return nil, fmt.Errorf("建立 WASM 實例失敗: %w", err)
+	}
+	defer mod.Close(ctx)
+
+	fn := mod.ExportedFunction(export)
+	if fn == nil {
+		return nil, fmt.Errorf("WASM 模組未匯出 %s", export)
+	}
+
+	res, err := mod.ExportedFunction("alloc").Call(ctx, uint64(len(input)))
+	if err != nil {
+		return nil, fmt.Errorf("WASM alloc 失敗: %w", err)
+	}
+	ptr := uint32(res[0])
+	if !mod.Memory().Write(ptr, input) {
+		return nil, fmt.Errorf("WASM alloc 回傳的位址超出記憶體範圍")
+	}
+
+	res, err = fn.Call(ctx, uint64(ptr), uint64(len(input)))
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("WASM %s 執行逾時 (%s)", export, e.opts.Timeout)
+		}
+		return nil, fmt.Errorf("WASM %s 執行失敗: %w", export, err)
+	}
+	outPtr, outLen := uint32(res[0]>>32), uint32(res[0])
+	if outLen == 0 {
+		return nil, nil
+	}
+	out, ok := mod.Memory().Read(outPtr, outLen)
+	if !ok {
+		return nil, fmt.Errorf("WASM %s 回傳的位址超出記憶體範圍", export)
+	}
+	// Read 回傳的是記憶體的視圖，模組關閉前需複製
+	return append([]byte(nil), out...), nil
+}
+
+// Transform 以模組的 transform 處理結果；回傳 false 表示捨棄
+func (e *Extension) Transform(ctx context.Context, r *crawler.Result) (bool, error) {
+	input, err := json.Marshal(r)
+	if err != nil {
+		return true, err
+	}
+	out, err := e.Call(ctx, "transform", input)
+	if err != nil {
+		return true, err
+	}
+	if out == nil {
+		return false, nil
+	}
+
+	// 以原結果的副本為基礎；map 清空以免合併進與原結果共用的 map，
+	// 模組刪除的鍵才會生效
+	updated := *r
+	updated.Data, updated.Tags = nil, nil
+	if err := json.Unmarshal(out, &updated); err != nil {
+		return true, fmt.Errorf("WASM transform 回傳的 JSON 無效: %w", err)
+	}
+	// 不序列化的欄位模組看不到，一律保留原值
+	updated.Screenshot, updated.HAR, updated.RawJSResponse = r.Screenshot, r.HAR, r.RawJSResponse
+	*r = updated
+	return true, nil
+}
+
+// Transformer 包裝為 crawler.Transformer，可加入 Options.Transforms；
+// 執行失敗時保留結果並將錯誤寫入 Result.Error
+func (e *Extension) Transformer() crawler.Transformer {
+	return func(r *crawler.Result) bool {
+		keep, err := e.Transform(context.Background(), r)
+		if err != nil {
+			r.Error = fmt.Sprintf("%s: %v", e.name, err)
+		}
+		return keep
+	}
+}
+
+// Extract 以模組的 extract 從頁面 HTML 擷取資料
+func (e *Extension) Extract(ctx context.Context, url, title, html string) (map[string]interface{}, error) {
+	input, err := json.Marshal(map[string]string{"url": url, "title": title, "html": html})
+	if err != nil {
+		return nil, err
+	}
+	out, err := e.Call(ctx, "extract", input)
+	if err != nil || out == nil {
+		return nil, err
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal(out, &data); err != nil {
+		return nil, fmt.Errorf("WASM extract 必須回傳 JSON 物件: %w", err)
+	}
+	return data, nil
+}
+
+// Extractor 包裝為 crawler.Transformer：以結果的 HTML 執行 extract 並合併到 Data。
+// 需搭配 Options.SaveHTML 使用
+func (e *Extension) Extractor() crawler.Transformer {
+	return func(r *crawler.Result) bool {
+		data, err := e.Extract(context.Background(), r.URL, r.Title, r.HTML)
+		if err != nil {
+			r.Error = fmt.Sprintf("%s: %v", e.name, err)
+			return true
+		}
+		if len(data) > 0 && r.Data == nil {
+			r.Data = map[string]interface{}{}
+		}
+		for k, v := range data {
+			r.Data[k] = v
+		}
+		return true
+	}
+}
@@ -0,0 +1,93 @@
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/firehourse/cdpkit/crawler"
+)
+
+// transformModule 組出最小的 WASM 模組：alloc 固定回傳 0，transform 忽略輸入、
+// 回傳資料段中的 out
+func transformModule(out string) []byte {
+	const outPtr = 4096
+	section := func(id byte, body ...[]byte) []byte {
+		b := bytes.Join(body, nil)
+		return append(append([]byte{id}, uleb(uint64(len(b)))...), b...)
+	}
+	name := func(s string) []byte { return append(uleb(uint64(len(s))), s...) }
+	ret := sleb(outPtr<<32 | int64(len(out)))
+
+	return bytes.Join([][]byte{
+		{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00},
+		// (i32) -> i32、(i32, i32) -> i64
+		section(1, []byte{2, 0x60, 1, 0x7f, 1, 0x7f, 0x60, 2, 0x7f, 0x7f, 1, 0x7e}),
+		section(3, []byte{2, 0, 1}),
+		section(5, []byte{1, 0x00, 1}),
+		section(7, []byte{3}, name("memory"), []byte{0x02, 0}, name("alloc"), []byte{0x00, 0}, name("transform"), []byte{0x00, 1}),
+		section(10, []byte{2, 4, 0, 0x41, 0, 0x0b},
+			uleb(uint64(len(ret)+3)), []byte{0, 0x42}, ret, []byte{0x0b}),
+		section(11, []byte{1, 0x00, 0x41}, sleb(outPtr), []byte{0x0b}, name(out)),
+	}, nil)
+}
+
+func uleb(v uint64) []byte {
+	var b []byte
+	for {
+		c := byte(v & 0x7f)
+		v >>= 7
+		if v == 0 {
+			return append(b, c)
+		}
+		b = append(b, c|0x80)
+	}
+}
+
+func sleb(v int64) []byte {
+	var b []byte
+	for {
+		c := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && c&0x40 == 0) || (v == -1 && c&0x40 != 0) {
+			return append(b, c)
+		}
+		b = append(b, c|0x80)
+	}
+}
+
+// 模組看不到不序列化的欄位，transform 後仍須保留；模組回傳的欄位與 Data 取代原值
+func TestTransformKeepsNonSerializedFields(t *testing.T) {
+	ctx := context.Background()
+	ext, err := New(ctx, transformModule(`{"url":"https://example.com/","title":"轉換後","data":{"price":1}}`), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ext.Close(ctx)
+
+	r := &crawler.Result{
+		URL:           "https://example.com/",
+		Title:         "原標題",
+		Data:          map[string]interface{}{"price": "1", "secret": "x"},
+		Screenshot:    []byte("png"),
+		HAR:           []byte(`{"log":{}}`),
+		RawJSResponse: "raw",
+	}
+	data := r.Data
+	keep, err := ext.Transform(ctx, r)
+	if err != nil || !keep {
+		t.Fatalf("Transform 回傳 %v, %v", keep, err)
+	}
+	if r.Title != "轉換後" || r.Data["price"] != float64(1) {
+		t.Errorf("模組的輸出未套用：%q %v", r.Title, r.Data)
+	}
+	if _, ok := r.Data["secret"]; ok {
+		t.Error("模組未回傳的 Data 鍵不應保留")
+	}
+	if len(data) != 2 || data["price"] != "1" {
+		t.Errorf("原本的 Data map 被修改：%v", data)
+	}
+	if string(r.Screenshot) != "png" || string(r.HAR) != `{"log":{}}` || r.RawJSResponse != "raw" {
+		t.Errorf("不序列化的欄位遺失：%q %q %v", r.Screenshot, r.HAR, r.RawJSResponse)
+	}
+}